@@ -0,0 +1,289 @@
+// Package cli is the command framework of passman's command line: each
+// command declares its flags once, and parsing, per-command help, the
+// command overview and the man page are all derived from them.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// RunFunc runs a command with the arguments left after its flags and
+// returns the process exit code
+type RunFunc func(args []string) int
+
+// Command is one subcommand of the application
+type Command struct {
+	Name        string
+	Aliases     []string // Other names, e.g. the legacy --version
+	Args        string   // Positional arguments shown after the flags, e.g. "JOBFILE"
+	Summary     string   // One sentence for the command overview
+	Description string   // Paragraphs for the command's help and man page; defaults to Summary
+
+	// Setup defines the command's flags on flags and returns the function
+	// running it. It must not have side effects: it is also called to
+	// document the flags.
+	Setup func(flags *flag.FlagSet) RunFunc
+}
+
+// Option is a global option, handled before commands are dispatched
+type Option struct {
+	Name  string // With its argument, e.g. "--profile NAME"
+	Usage string
+}
+
+// Section is a free-form section of the help and the man page
+type Section struct {
+	Title string
+	Body  string // Preformatted lines
+	Man   string // Replaces Body in the man page, e.g. without this machine's paths
+}
+
+// App is a command line application made of commands
+type App struct {
+	Name     string
+	Version  string
+	Summary  string
+	Options  []Option
+	Commands []*Command
+	Sections []Section
+
+	Stdout io.Writer // Default os.Stdout
+	Stderr io.Writer // Default os.Stderr
+}
+
+// Exit codes of the framework itself; commands choose their own
+const (
+	ExitOK    = 0
+	ExitUsage = 2 // Unknown command or invalid flags
+)
+
+// Lookup finds a command by name or alias, including the built-in help
+// and man commands
+func (a *App) Lookup(name string) *Command {
+	for _, cmd := range a.commands() {
+		if cmd.Name == name {
+			return cmd
+		}
+		for _, alias := range cmd.Aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// Run parses the flags of the named command and runs it. -h and -help
+// print the command's help.
+func (a *App) Run(name string, args []string) int {
+	cmd := a.Lookup(name)
+	if cmd == nil {
+		fmt.Fprintf(a.stderr(), "Error: unknown command %q; see '%s help'\n", name, a.Name)
+		return ExitUsage
+	}
+
+	flags, run := a.flagSet(cmd)
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			a.CommandHelp(a.stdout(), cmd)
+			return ExitOK
+		}
+		fmt.Fprintf(a.stderr(), "Error: %v\n", err)
+		fmt.Fprintf(a.stderr(), "Usage: %s\n", a.Synopsis(cmd))
+		return ExitUsage
+	}
+	return run(flags.Args())
+}
+
+// Synopsis returns the usage line of a command, e.g.
+// "passman run [-dry-run] [-report FILE] JOBFILE"
+func (a *App) Synopsis(cmd *Command) string {
+	parts := []string{a.Name, cmd.Name}
+	flags, _ := a.flagSet(cmd)
+	flags.VisitAll(func(f *flag.Flag) {
+		name, _ := flag.UnquoteUsage(f)
+		if name == "" {
+			parts = append(parts, "[-"+f.Name+"]")
+		} else {
+			parts = append(parts, "[-"+f.Name+" "+strings.ToUpper(name)+"]")
+		}
+	})
+	if cmd.Args != "" {
+		parts = append(parts, cmd.Args)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Usage writes the overview of the application: its options, commands
+// and sections
+func (a *App) Usage(w io.Writer) {
+	fmt.Fprintf(w, "%s %s\n%s\n\n", a.Name, a.Version, a.Summary)
+	fmt.Fprintf(w, "USAGE:\n  %-32s Start the TUI\n  %-32s Run a command; '%s help COMMAND' describes it\n",
+		a.Name+" [options]", a.Name+" [options] COMMAND [ARGS]", a.Name)
+
+	if len(a.Options) > 0 {
+		fmt.Fprintln(w, "\nOPTIONS:")
+		for _, opt := range a.Options {
+			writeItem(w, opt.Name, opt.Usage)
+		}
+	}
+
+	fmt.Fprintln(w, "\nCOMMANDS:")
+	for _, cmd := range a.sortedCommands() {
+		writeItem(w, wrap(strings.TrimPrefix(a.Synopsis(cmd), a.Name+" "), 70, "      "), cmd.Summary)
+	}
+
+	for _, section := range a.Sections {
+		fmt.Fprintf(w, "\n%s:\n", strings.ToUpper(section.Title))
+		for _, line := range strings.Split(strings.TrimRight(section.Body, "\n"), "\n") {
+			if line == "" {
+				fmt.Fprintln(w)
+			} else {
+				fmt.Fprintln(w, "  "+line)
+			}
+		}
+	}
+}
+
+// CommandHelp writes the help of one command: its usage line,
+// description and flags
+func (a *App) CommandHelp(w io.Writer, cmd *Command) {
+	fmt.Fprintf(w, "Usage: %s\n", a.Synopsis(cmd))
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "Aliases: %s\n", strings.Join(cmd.Aliases, ", "))
+	}
+	fmt.Fprintf(w, "\n%s\n", wrap(cmd.description(), 72, ""))
+
+	flags, _ := a.flagSet(cmd)
+	hasFlags := false
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w, "\nFlags:")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+}
+
+// flagSet creates the flags of a command
+func (a *App) flagSet(cmd *Command) (*flag.FlagSet, RunFunc) {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	run := cmd.Setup(flags)
+	return flags, run
+}
+
+// commands returns the application's commands and the built-in ones
+func (a *App) commands() []*Command {
+	return append(append([]*Command{}, a.Commands...), a.helpCommand(), a.manCommand())
+}
+
+// sortedCommands returns all commands sorted by name
+func (a *App) sortedCommands() []*Command {
+	commands := a.commands()
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// helpCommand prints the overview, or the help of a command
+func (a *App) helpCommand() *Command {
+	return &Command{
+		Name:    "help",
+		Aliases: []string{"--help", "-help", "-h"},
+		Args:    "[COMMAND]",
+		Summary: "Show this help, or the help of a command",
+		Setup: func(flags *flag.FlagSet) RunFunc {
+			return func(args []string) int {
+				if len(args) == 0 {
+					a.Usage(a.stdout())
+					return ExitOK
+				}
+				cmd := a.Lookup(args[0])
+				if cmd == nil {
+					fmt.Fprintf(a.stderr(), "Error: unknown command %q\n", args[0])
+					return ExitUsage
+				}
+				a.CommandHelp(a.stdout(), cmd)
+				return ExitOK
+			}
+		},
+	}
+}
+
+// manCommand prints the man page
+func (a *App) manCommand() *Command {
+	return &Command{
+		Name:        "man",
+		Summary:     "Print the man page",
+		Description: fmt.Sprintf("Print the man page in roff format, e.g. '%s man | man -l -' or '%s man > %s.1'.", a.Name, a.Name, a.Name),
+		Setup: func(flags *flag.FlagSet) RunFunc {
+			return func(args []string) int {
+				a.WriteMan(a.stdout())
+				return ExitOK
+			}
+		},
+	}
+}
+
+// description returns the long description, or the summary
+func (c *Command) description() string {
+	if c.Description != "" {
+		return c.Description
+	}
+	return c.Summary
+}
+
+func (a *App) stdout() io.Writer {
+	if a.Stdout != nil {
+		return a.Stdout
+	}
+	return os.Stdout
+}
+
+func (a *App) stderr() io.Writer {
+	if a.Stderr != nil {
+		return a.Stderr
+	}
+	return os.Stderr
+}
+
+// writeItem writes a name and its wrapped description, aligned like the
+// rest of the overview
+func writeItem(w io.Writer, name, usage string) {
+	const indent = 19
+	if len(name) <= indent-4 && !strings.Contains(name, "\n") {
+		fmt.Fprintf(w, "  %-*s %s\n", indent-3, name, wrap(usage, 50, strings.Repeat(" ", indent)))
+		return
+	}
+	fmt.Fprintf(w, "  %s\n%s%s\n", name, strings.Repeat(" ", indent), wrap(usage, 50, strings.Repeat(" ", indent)))
+}
+
+// wrap breaks text into lines of at most width characters, indenting the
+// continuation lines; blank lines separate paragraphs
+func wrap(text string, width int, indent string) string {
+	var out []string
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		var lines []string
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = word
+			} else if line == "" {
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		out = append(out, strings.Join(lines, "\n"+indent))
+	}
+	return strings.Join(out, "\n\n"+indent)
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMan writes the man page of the application in roff format. It has
+// no date, so the same version always produces the same page.
+func (a *App) WriteMan(w io.Writer) {
+	name := strings.ToUpper(a.Name)
+	fmt.Fprintf(w, ".TH %s 1 \"\" \"%s %s\" \"User Commands\"\n", name, a.Name, a.Version)
+
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintf(w, "%s \\- %s\n", a.Name, roffEscape(a.Summary))
+
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B %s\n[\\fIOPTIONS\\fR]\n.br\n", a.Name)
+	fmt.Fprintf(w, ".B %s\n[\\fIOPTIONS\\fR] \\fICOMMAND\\fR [\\fIFLAGS\\fR] [\\fIARGS\\fR]\n", a.Name)
+
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintf(w, "Without a command, \\fB%s\\fR starts its terminal UI. ", a.Name)
+	fmt.Fprintf(w, "\\fB%s help\\fR \\fICOMMAND\\fR describes a command.\n", a.Name)
+
+	if len(a.Options) > 0 {
+		fmt.Fprintln(w, ".SH OPTIONS")
+		for _, opt := range a.Options {
+			fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roffEscape(opt.Name), roffEscape(opt.Usage))
+		}
+	}
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, cmd := range a.sortedCommands() {
+		fmt.Fprintf(w, ".SS \"%s\"\n", roffEscape(a.Synopsis(cmd)))
+		for i, paragraph := range strings.Split(cmd.description(), "\n\n") {
+			if i > 0 {
+				fmt.Fprintln(w, ".PP")
+			}
+			fmt.Fprintln(w, roffEscape(strings.Join(strings.Fields(paragraph), " ")))
+		}
+		if len(cmd.Aliases) > 0 {
+			fmt.Fprintf(w, ".PP\nAliases: %s\n", roffEscape(strings.Join(cmd.Aliases, ", ")))
+		}
+
+		flags, _ := a.flagSet(cmd)
+		flags.VisitAll(func(f *flag.Flag) {
+			argName, usage := flag.UnquoteUsage(f)
+			fmt.Fprintf(w, ".TP\n.B \\-%s", roffEscape(f.Name))
+			if argName != "" {
+				fmt.Fprintf(w, " \\fI%s\\fR", roffEscape(argName))
+			}
+			fmt.Fprintln(w)
+			if def := f.DefValue; def != "" && def != "false" && def != "0" {
+				usage += fmt.Sprintf(" (default %s)", def)
+			}
+			fmt.Fprintln(w, roffEscape(usage))
+		})
+	}
+
+	for _, section := range a.Sections {
+		fmt.Fprintf(w, ".SH \"%s\"\n.nf\n", roffEscape(strings.ToUpper(section.Title)))
+		body := section.Body
+		if section.Man != "" {
+			body = section.Man
+		}
+		for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+			fmt.Fprintln(w, roffEscape(line))
+		}
+		fmt.Fprintln(w, ".fi")
+	}
+}
+
+// roffEscape escapes text for roff: backslashes and hyphens, and a
+// leading dot or quote that would start a request
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -1,13 +1,26 @@
 package config
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// Where the history passphrase comes from
+const (
+	PassphraseStored   = "stored"   // history_encryption_key in the config file
+	PassphrasePrompt   = "prompt"   // Asked for when needed, optionally cached
+	PassphraseKeychain = "keychain" // Kept in the OS keychain, asked for once
+	PassphraseHardware = "hardware" // Wrapped with a hardware token, which must be touched
 )
 
 type Config struct {
 	// Password Generation Defaults
+	DefaultGenerator         string `json:"default_generator"` // Menu entry selected at start: a registered generator, e.g. random
 	DefaultLength            int  `json:"default_length"`
 	DefaultIncludeLowercase  bool `json:"default_include_lowercase"`
 	DefaultIncludeUppercase  bool `json:"default_include_uppercase"`
@@ -20,33 +33,86 @@ type Config struct {
 	DefaultPassphraseWords      int    `json:"default_passphrase_words"`
 	DefaultPassphraseSeparator  string `json:"default_passphrase_separator"`
 	DefaultPassphraseCapitalize bool   `json:"default_passphrase_capitalize"`
+	DefaultPassphraseDigit      bool   `json:"default_passphrase_digit"` // Add a random digit
+	DefaultPassphraseLeet       string `json:"default_passphrase_leet"` // off, all or random
+	LeetSubstitutions           string `json:"leet_substitutions"`      // e.g. "a=@,e=3"
 	
 	// PIN Defaults
 	DefaultPinLength            int    `json:"default_pin_length"`
+	DefaultPinGroupSize         int    `json:"default_pin_group_size"` // Digits per group, 0 for none
+	DefaultPinSeparator         string `json:"default_pin_separator"`  // Between groups, e.g. "-"
 	
 	// Clipboard Settings
-	AutoCopyToClipboard    bool `json:"auto_copy_to_clipboard"`
-	ClearClipboardAfter    int  `json:"clear_clipboard_after_seconds"` // 0 = never
-	ShowClipboardSuccess   bool `json:"show_clipboard_success"`
+	AutoCopyToClipboard    bool   `json:"auto_copy_to_clipboard"`
+	ClearClipboardAfter    int    `json:"clear_clipboard_after_seconds"` // 0 = never
+	ShowClipboardSuccess   bool   `json:"show_clipboard_success"`
+	CopyMethod             string `json:"copy_method"`                   // clipboard or type
+	AutoTypeDelay          int    `json:"auto_type_delay_seconds"`       // Time to focus the target window
+	SensitiveCopy          bool   `json:"sensitive_copy"`                // Hide from clipboard history, clear after one paste
+	ClipboardCopyCommand   string `json:"clipboard_copy_command,omitempty"`  // Reads the text to copy on stdin, e.g. "xclip -selection clipboard"; empty = automatic
+	ClipboardPasteCommand  string `json:"clipboard_paste_command,omitempty"` // Prints the clipboard on stdout, e.g. "xclip -selection clipboard -o"; empty = automatic
 	
 	// Export Settings
 	DefaultExportFormat    string `json:"default_export_format"`
 	DefaultExportPath      string `json:"default_export_path"`
 	IncludeTimestampInName bool   `json:"include_timestamp_in_name"`
+	ExportFileMode         string `json:"export_file_mode"` // Octal permissions of exported files, e.g. 0600
+	ExportEncryption       string `json:"export_encryption"`          // off, age or gpg
+	ExportRecipient        string `json:"export_recipient,omitempty"` // age recipients or gpg key IDs, comma-separated
 	
 	// History Settings
 	HistoryEnabled         bool   `json:"history_enabled"`
 	HistoryMaxEntries      int    `json:"history_max_entries"`
+	HistoryDedupe          bool   `json:"history_dedupe"`                    // A repeated password replaces its older entry
+	HistoryRetentionDays   int    `json:"history_retention_days"`            // Purge entries older than this; 0 = keep
+	HistoryMaxSizeKB       int    `json:"history_max_size_kb"`               // Cap on history.enc; 0 = no cap
 	HistoryEncryptionKey   string `json:"history_encryption_key,omitempty"` // Empty = prompt for passphrase
+	HistoryPassphrase      string `json:"history_passphrase"`                // stored (in history_encryption_key), prompt, keychain or hardware
+	PassphraseCacheMinutes int    `json:"passphrase_cache_minutes"`          // Remember a prompted passphrase between runs; 0 = never
+	HistoryKDF             string `json:"history_kdf"`                       // pbkdf2 or argon2id; change with passman migrate
+	ScratchpadClearAfter   int    `json:"scratchpad_clear_after_minutes"`    // 0 = never
+	AutoLockMinutes        int    `json:"auto_lock_minutes"`                 // Lock the TUI after this many idle minutes; 0 = never
+	ShareExpiryDays        int    `json:"share_expiry_days"`                 // Rotate shared secrets after this; 0 = never
+	RecentPasswords        int    `json:"recent_passwords"`                  // Kept in memory per session for undo
+	CandidateCount         int    `json:"candidate_count"`                   // Candidates offered at once (5-10)
+	SyncRemote             string `json:"sync_remote,omitempty"`             // WebDAV, S3 or git URL to sync the history with; empty = off
+	SyncIntervalMinutes    int    `json:"sync_interval_minutes"`             // Sync in the background this often; 0 = only with passman sync
+	HistoryColumns         string `json:"history_columns"`                   // Columns of the history table, e.g. "time,password,strength"
+	HistorySort            string `json:"history_sort"`                      // time, length, type or strength
+	HistorySortReverse     bool   `json:"history_sort_reverse"`              // Oldest, shortest or weakest first
+	BackupBeforeClear      bool   `json:"backup_before_clear"`               // Back up the history before clearing it
 	
 	// UI Settings
 	Theme                  string `json:"theme"`
 	ShowStrengthMeter      bool   `json:"show_strength_meter"`
+	MaskPasswords          bool   `json:"mask_passwords"` // Show secrets as dots until revealed
+	PasswordGroupSize      int    `json:"password_group_size"` // Visual grouping of random passwords, 0 = off
+	AnnotateConfusables    bool   `json:"annotate_confusables"` // Spell out 0/O, 1/l/I under passwords
 	ShowGenerationTime     bool   `json:"show_generation_time"`
+	CrackAttacker          string `json:"crack_attacker"`       // Attacker model for crack times, e.g. offline
+	CrackDoublingYears     int    `json:"crack_doubling_years"` // Attacker hardware doubles this often; 0 = no crack year
 	ConfirmBeforeExit      bool   `json:"confirm_before_exit"`
+	ShowFooter             bool   `json:"show_footer"` // Session statistics strip at the bottom
+	
+	// Notifications when background tasks finish: event -> off, bell, desktop or both
+	Notifications          map[string]string `json:"notifications"`
+	
+	// Keybindings: action -> keys, replacing the default keys of that action
+	Keys                   map[string][]string `json:"keys,omitempty"`
+	
+	// Saved generator configurations by name
+	Presets                map[string]Preset `json:"presets,omitempty"`
+	
+	// Last-used settings of each generator screen, restored when it opens
+	GeneratorSettings      map[string]Preset `json:"generator_settings,omitempty"`
+	
+	// Secrets per second of each generator at its defaults, measured by passman bench
+	GenerationRates        map[string]int `json:"generation_rates,omitempty"`
 	
 	// Advanced Settings
-	WordlistUpdateInterval int    `json:"wordlist_update_interval_days"`
+	WordlistUpdateInterval int    `json:"wordlist_update_interval_days"` // Download the cached wordlist again this often; 0 = only with passman wordlist update
+	AgentAllowedClients    string `json:"agent_allowed_clients,omitempty"` // Programs allowed on the agent socket, comma-separated paths or names; empty = any of yours
+	AgentQuotaPerMinute    int    `json:"agent_quota_per_minute"`          // Secrets each client of the agent or API may get a minute
 	EnableTelemetry        bool   `json:"enable_telemetry"`
 	Debug                  bool   `json:"debug"`
 }
@@ -57,6 +123,7 @@ func Default() Config {
 	
 	return Config{
 		// Password Generation Defaults
+		DefaultGenerator:         "random",
 		DefaultLength:            12,
 		DefaultIncludeLowercase:  true,
 		DefaultIncludeUppercase:  true,
@@ -69,62 +136,102 @@ func Default() Config {
 		DefaultPassphraseWords:      4,
 		DefaultPassphraseSeparator:  "-",
 		DefaultPassphraseCapitalize: false,
+		DefaultPassphraseDigit:      false,
+		DefaultPassphraseLeet:       "off",
+		LeetSubstitutions:           "a=@,e=3,i=1,o=0,s=$",
 		
 		// PIN Defaults
 		DefaultPinLength:            4,
+		DefaultPinGroupSize:         0,
+		DefaultPinSeparator:         "-",
 		
 		// Clipboard Settings
 		AutoCopyToClipboard:    true,
 		ClearClipboardAfter:    0, // Never clear automatically
 		ShowClipboardSuccess:   true,
+		CopyMethod:             "clipboard",
+		AutoTypeDelay:          5,
+		SensitiveCopy:          false,
+		ClipboardCopyCommand:   "",
+		ClipboardPasteCommand:  "",
 		
 		// Export Settings
 		DefaultExportFormat:    "txt",
 		DefaultExportPath:      defaultExportPath,
 		IncludeTimestampInName: true,
+		ExportFileMode:         "0600", // Exports hold plaintext passwords
+		ExportEncryption:       "off",
+		ExportRecipient:        "",
 		
 		// History Settings
 		HistoryEnabled:         true, // Enable by default with encryption
 		HistoryMaxEntries:      100,
+		HistoryDedupe:          false,
+		HistoryRetentionDays:   0,
+		HistoryMaxSizeKB:       0,
 		HistoryEncryptionKey:   "default-key", // Default encryption key
+		HistoryPassphrase:      PassphraseStored,
+		PassphraseCacheMinutes: 15,
+		HistoryKDF:             "pbkdf2",
+		ScratchpadClearAfter:   15,
+		AutoLockMinutes:        5,
+		ShareExpiryDays:        7,
+		RecentPasswords:        20,
+		CandidateCount:         5,
+		SyncRemote:             "",
+		SyncIntervalMinutes:    15,
+		HistoryColumns:         "time,password,length,type,strength",
+		HistorySort:            "time",
+		HistorySortReverse:     false,
+		BackupBeforeClear:      true,
 		
 		// UI Settings
-		Theme:                  "default",
+		Theme:                  "auto", // Detect NO_COLOR and light terminals
 		ShowStrengthMeter:      true,
+		MaskPasswords:          false,
+		PasswordGroupSize:      4,
+		AnnotateConfusables:    true,
 		ShowGenerationTime:     false,
+		CrackAttacker:          "offline",
+		CrackDoublingYears:     0, // Don't model hardware improvement
 		ConfirmBeforeExit:      false,
+		ShowFooter:             true,
+		
+		// Notifications
+		Notifications: map[string]string{
+			"auto_type":   "off",
+			"export":      "off",
+			"agent_quota": "desktop", // Someone asked the agent for too many secrets
+		},
 		
 		// Advanced Settings
-		WordlistUpdateInterval: 30, // 30 days
+		WordlistUpdateInterval: 0, // Never downloaded unless asked for
+		AgentAllowedClients:    "",
+		AgentQuotaPerMinute:    60,
 		EnableTelemetry:        false,
 		Debug:                  false,
 	}
 }
 
+// Load reads the config file, which may be JSON, YAML or TOML. A file with
+// unknown keys or invalid values returns a *SchemaError listing each one
+// with its line, along with the config using defaults in their place.
 func Load() (Config, error) {
 	configPath, err := getConfigPath()
 	if err != nil {
 		return Default(), err
 	}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	info, err := os.Stat(configPath)
+	if os.IsNotExist(err) {
 		return Default(), nil
 	}
-
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return Default(), err
-	}
-
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return Default(), err
+	if err == nil {
+		restrictConfigFile(configPath, info)
 	}
 
-	// Ensure missing fields have default values
-	config = mergeWithDefaults(config)
-
-	return config, nil
+	// Missing fields get their default values
+	return readConfigFile(configPath)
 }
 
 // mergeWithDefaults ensures missing fields have default values
@@ -132,10 +239,80 @@ func mergeWithDefaults(config Config) Config {
 	defaults := Default()
 	
 	// Only set defaults for empty/zero values that should have defaults
-	if config.HistoryEncryptionKey == "" {
+	if config.HistoryPassphrase == "" {
+		config.HistoryPassphrase = defaults.HistoryPassphrase
+	}
+	
+	// A prompted or keychain passphrase is entered once, never defaulted
+	if config.HistoryEncryptionKey == "" && config.StoresPassphrase() {
 		config.HistoryEncryptionKey = defaults.HistoryEncryptionKey
 	}
 	
+	if config.DefaultPassphraseLeet == "" {
+		config.DefaultPassphraseLeet = defaults.DefaultPassphraseLeet
+	}
+	
+	if config.LeetSubstitutions == "" {
+		config.LeetSubstitutions = defaults.LeetSubstitutions
+	}
+	
+	if config.DefaultGenerator == "" {
+		config.DefaultGenerator = defaults.DefaultGenerator
+	}
+	
+	if config.HistoryKDF == "" {
+		config.HistoryKDF = defaults.HistoryKDF
+	}
+	
+	if config.CrackAttacker == "" {
+		config.CrackAttacker = defaults.CrackAttacker
+	}
+	
+	if config.CopyMethod == "" {
+		config.CopyMethod = defaults.CopyMethod
+	}
+	
+	if config.HistoryColumns == "" {
+		config.HistoryColumns = defaults.HistoryColumns
+	}
+	
+	if config.HistorySort == "" {
+		config.HistorySort = defaults.HistorySort
+	}
+	
+	if config.CandidateCount == 0 {
+		config.CandidateCount = defaults.CandidateCount
+	}
+	
+	if config.RecentPasswords == 0 {
+		config.RecentPasswords = defaults.RecentPasswords
+	}
+	
+	if config.AgentQuotaPerMinute == 0 {
+		config.AgentQuotaPerMinute = defaults.AgentQuotaPerMinute
+	}
+	
+	if config.AutoTypeDelay == 0 {
+		config.AutoTypeDelay = defaults.AutoTypeDelay
+	}
+	
+	if config.Notifications == nil {
+		config.Notifications = defaults.Notifications
+	}
+	for event, method := range defaults.Notifications {
+		if _, ok := config.Notifications[event]; !ok {
+			config.Notifications[event] = method
+		}
+	}
+	
+	if config.ExportFileMode == "" {
+		config.ExportFileMode = defaults.ExportFileMode
+	}
+	
+	if config.ExportEncryption == "" {
+		config.ExportEncryption = defaults.ExportEncryption
+	}
+	
 	// Add other fields that might need default merging in the future
 	if config.DefaultExportPath == "" {
 		config.DefaultExportPath = defaults.DefaultExportPath
@@ -154,33 +331,63 @@ func (c Config) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
-	if err != nil {
-		return err
+	// A prompted or keychain passphrase never goes in the file
+	if !c.StoresPassphrase() {
+		c.HistoryEncryptionKey = ""
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	// Keep the format the user chose. A file that is there already only
+	// has the keys that changed rewritten, keeping its comments and layout.
+	var data []byte
+	if existing, err := os.ReadFile(configPath); err == nil {
+		data, _ = patchConfig(configPath, existing, c)
+	}
+	if data == nil {
+		if data, err = encodeConfig(FormatOf(configPath), c); err != nil {
+			return err
+		}
+	}
+
+	// The config may hold the history passphrase
+	return writeConfigFile(configPath, data)
+}
+
+// IsFirstRun reports whether there is no config file yet, i.e. passman
+// has not been set up
+func IsFirstRun() bool {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return false
+	}
+	return !fileExists(configPath)
 }
 
 func getConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	configDir, err := GetConfigDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(homeDir, ".config", "passman", "config.json"), nil
+	return findConfigFile(configDir), nil
+}
+
+// GetConfigPath returns the path of the config file: config.json,
+// config.yaml, config.yml or config.toml, whichever exists first
+func GetConfigPath() (string, error) {
+	return getConfigPath()
 }
 
 // GetConfigDir returns the configuration directory path
 func GetConfigDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(homeDir, ".config", "passman"), nil
+	paths, err := ResolvePaths()
+	return paths.Config, err
 }
 
 // Validate validates the configuration settings
 func (c *Config) Validate() error {
+	if _, ok := generator.Lookup(c.DefaultGenerator); !ok {
+		c.DefaultGenerator = "random"
+	}
+	
 	if c.DefaultLength < 1 || c.DefaultLength > 512 {
 		c.DefaultLength = 12
 	}
@@ -193,32 +400,258 @@ func (c *Config) Validate() error {
 		c.DefaultPinLength = 4
 	}
 	
+	if c.DefaultPinGroupSize < 0 || c.DefaultPinGroupSize > 10 {
+		c.DefaultPinGroupSize = 0
+	}
+	
+	if c.DefaultPinSeparator == "" || strings.ContainsAny(c.DefaultPinSeparator, "0123456789") {
+		c.DefaultPinSeparator = "-"
+	}
+	
 	if c.DefaultPassphraseSeparator == "" {
 		c.DefaultPassphraseSeparator = "-"
 	}
 	
+	validLeetModes := map[string]bool{"off": true, "all": true, "random": true}
+	if !validLeetModes[c.DefaultPassphraseLeet] {
+		c.DefaultPassphraseLeet = "off"
+	}
+	
 	if c.ClearClipboardAfter < 0 {
 		c.ClearClipboardAfter = 0
 	}
 	
+	if c.CopyMethod != "clipboard" && c.CopyMethod != "type" {
+		c.CopyMethod = "clipboard"
+	}
+	
+	if c.AutoTypeDelay < 1 || c.AutoTypeDelay > 60 {
+		c.AutoTypeDelay = 5
+	}
+	
+	if c.PasswordGroupSize < 0 || c.PasswordGroupSize > 16 {
+		c.PasswordGroupSize = 0
+	}
+	
+	switch c.HistoryPassphrase {
+	case PassphraseStored, PassphrasePrompt, PassphraseKeychain, PassphraseHardware:
+	default:
+		c.HistoryPassphrase = PassphraseStored
+	}
+	
+	if c.PassphraseCacheMinutes < 0 || c.PassphraseCacheMinutes > 1440 {
+		c.PassphraseCacheMinutes = 0
+	}
+	
+	if c.HistoryKDF != "pbkdf2" && c.HistoryKDF != "argon2id" {
+		c.HistoryKDF = "pbkdf2"
+	}
+	
+	validAttackers := map[string]bool{"online": true, "offline-slow": true, "offline": true, "offline-fast": true, "nation-state": true}
+	if !validAttackers[c.CrackAttacker] {
+		c.CrackAttacker = "offline"
+	}
+	
+	if c.CrackDoublingYears < 0 || c.CrackDoublingYears > 20 {
+		c.CrackDoublingYears = 0
+	}
+	
+	if c.ScratchpadClearAfter < 0 {
+		c.ScratchpadClearAfter = 0
+	}
+	
+	if c.AutoLockMinutes < 0 || c.AutoLockMinutes > 1440 {
+		c.AutoLockMinutes = 0
+	}
+	
+	if c.ShareExpiryDays < 0 {
+		c.ShareExpiryDays = 0
+	}
+	
+	if c.RecentPasswords < 1 || c.RecentPasswords > 100 {
+		c.RecentPasswords = 20
+	}
+	
+	if c.CandidateCount < 5 {
+		c.CandidateCount = 5
+	} else if c.CandidateCount > 10 {
+		c.CandidateCount = 10
+	}
+	
 	if c.HistoryMaxEntries < 1 {
 		c.HistoryMaxEntries = 100
 	} else if c.HistoryMaxEntries > 10000 {
 		c.HistoryMaxEntries = 10000
 	}
 	
+	if c.HistoryRetentionDays < 0 || c.HistoryRetentionDays > 3650 {
+		c.HistoryRetentionDays = 0
+	}
+	
+	if c.HistoryMaxSizeKB < 0 || c.HistoryMaxSizeKB > 102400 {
+		c.HistoryMaxSizeKB = 0
+	}
+	
+	if c.SyncRemote != "" && !ValidSyncRemote(c.SyncRemote) {
+		c.SyncRemote = ""
+	}
+	
+	if _, err := SplitCommand(c.ClipboardCopyCommand); err != nil {
+		c.ClipboardCopyCommand = ""
+	}
+	
+	if _, err := SplitCommand(c.ClipboardPasteCommand); err != nil {
+		c.ClipboardPasteCommand = ""
+	}
+	
+	if c.SyncIntervalMinutes < 0 || c.SyncIntervalMinutes > 1440 {
+		c.SyncIntervalMinutes = 0
+	}
+	
+	if _, err := ParseHistoryColumns(c.HistoryColumns); err != nil {
+		c.HistoryColumns = "time,password,length,type,strength"
+	}
+	
+	validSorts := map[string]bool{"time": true, "length": true, "type": true, "strength": true}
+	if !validSorts[c.HistorySort] {
+		c.HistorySort = "time"
+	}
+	
 	validFormats := map[string]bool{"txt": true, "json": true, "csv": true}
 	if !validFormats[c.DefaultExportFormat] {
 		c.DefaultExportFormat = "txt"
 	}
 	
-	if c.WordlistUpdateInterval < 1 {
-		c.WordlistUpdateInterval = 30
+	// The owner must be able to read and rewrite their own exports
+	if mode, err := strconv.ParseUint(c.ExportFileMode, 8, 32); err != nil || mode > 0777 || mode&0600 != 0600 {
+		c.ExportFileMode = "0600"
+	}
+	
+	c.validatePresets()
+	
+	for kind, rate := range c.GenerationRates {
+		if _, ok := generator.Lookup(kind); !ok || rate <= 0 {
+			delete(c.GenerationRates, kind)
+		}
+	}
+	
+	validEncryptions := map[string]bool{"off": true, "age": true, "gpg": true}
+	if !validEncryptions[c.ExportEncryption] {
+		c.ExportEncryption = "off"
+	}
+	
+	if c.WordlistUpdateInterval < 0 {
+		c.WordlistUpdateInterval = 0
+	}
+	
+	if c.AgentQuotaPerMinute < 1 {
+		c.AgentQuotaPerMinute = 60
+	}
+	if c.AgentQuotaPerMinute > 10000 {
+		c.AgentQuotaPerMinute = 10000
+	}
+	
+	validNotifications := map[string]bool{"off": true, "bell": true, "desktop": true, "both": true}
+	for event, method := range c.Notifications {
+		if !validNotifications[method] {
+			c.Notifications[event] = "off"
+		}
 	}
 	
 	return nil
 }
 
+// ValidSyncRemote reports whether remote is a URL a history sync backend
+// handles: WebDAV over http(s)://, an s3:// object or a git+ repository
+func ValidSyncRemote(remote string) bool {
+	for _, prefix := range []string{"https://", "http://", "s3://", "git+"} {
+		if strings.HasPrefix(remote, prefix) && len(remote) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitCommand splits a command line such as the clipboard commands into
+// the program and its arguments. Words are separated by spaces; single or
+// double quotes keep spaces in a word and a backslash escapes the next
+// character. No shell runs it, so pipes and variables are not expanded.
+// An empty line gives no words.
+func SplitCommand(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// HistoryColumnNames lists the columns the history table can show
+var HistoryColumnNames = []string{"time", "password", "length", "type", "strength", "description", "site", "username", "tags"}
+
+// ParseHistoryColumns parses a comma-separated list of history table
+// columns, such as "time,password,strength"
+func ParseHistoryColumns(value string) ([]string, error) {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, valid := range HistoryColumnNames {
+			known = known || name == valid
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q (want %s)", name, strings.Join(HistoryColumnNames, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q is listed twice", name)
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns listed")
+	}
+	return columns, nil
+}
+
 // Reset resets the configuration to default values
 func (c *Config) Reset() {
 	*c = Default()
@@ -229,6 +662,26 @@ func (c *Config) IsHistoryEnabled() bool {
 	return c.HistoryEnabled
 }
 
+// StoresPassphrase reports whether the history passphrase is kept in the
+// config file, rather than asked for, kept in the OS keychain or wrapped
+// with a hardware token
+func (c *Config) StoresPassphrase() bool {
+	switch c.HistoryPassphrase {
+	case PassphrasePrompt, PassphraseKeychain, PassphraseHardware:
+		return false
+	}
+	return true
+}
+
+// ExportMode returns the permissions of exported files
+func (c *Config) ExportMode() os.FileMode {
+	mode, err := strconv.ParseUint(c.ExportFileMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0600
+	}
+	return os.FileMode(mode)
+}
+
 // GetExportPath returns the full export path for a given filename
 func (c *Config) GetExportPath(filename string) string {
 	if filepath.IsAbs(filename) {
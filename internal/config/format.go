@@ -0,0 +1,313 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats, chosen by the file's extension
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// configFileNames are the config files looked for, in order of preference.
+// config.json is created when none exists.
+var configFileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// field is a top-level key of a config file with the line it is on
+type field struct {
+	key   string
+	line  int
+	value interface{}
+
+	// Bytes of the key and its value in the file, for patching it; a
+	// TOML table runs from its header to its last key. Both are 0 when
+	// the entry can't be patched on its own.
+	start, end int
+}
+
+// FormatOf returns the config format of path from its extension
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// findConfigFile returns the first config file in dir, or config.json
+// there when there is none yet
+func findConfigFile(dir string) string {
+	for _, name := range configFileNames {
+		if path := filepath.Join(dir, name); fileExists(path) {
+			return path
+		}
+	}
+	return filepath.Join(dir, configFileNames[0])
+}
+
+// hasConfigFile reports whether dir holds a config file in any format
+func hasConfigFile(dir string) bool {
+	for _, name := range configFileNames {
+		if fileExists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFields reads the top-level keys of a config file in format
+func parseFields(format string, data []byte) ([]field, error) {
+	switch format {
+	case FormatYAML:
+		return parseYAML(data)
+	case FormatTOML:
+		return parseTOML(data)
+	default:
+		return parseJSON(data)
+	}
+}
+
+// parseJSON reads the keys of a JSON object, noting the line of each
+func parseJSON(data []byte) ([]field, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return nil, jsonError(data, dec, err)
+	} else if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, &syntaxError{1, "expected a JSON object"}
+	}
+
+	var fields []field
+	for dec.More() {
+		// Only blanks and a comma come before the key
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, jsonError(data, dec, err)
+		}
+		key, _ := tok.(string)
+		line := lineAt(data, dec.InputOffset())
+		start += bytes.IndexByte(data[start:], '"')
+
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, jsonError(data, dec, err)
+		}
+		fields = append(fields, field{key: key, line: line, value: value, start: start, end: int(dec.InputOffset())})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, jsonError(data, dec, err)
+	}
+	return fields, nil
+}
+
+// jsonError gives a JSON decoding error the line it happened on
+func jsonError(data []byte, dec *json.Decoder, err error) error {
+	offset := dec.InputOffset()
+	if syntax, ok := err.(*json.SyntaxError); ok {
+		offset = syntax.Offset
+	}
+	return &syntaxError{lineAt(data, offset), err.Error()}
+}
+
+// lineAt returns the 1-based line of offset in data
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// parseYAML reads the keys of a YAML mapping, noting the line of each
+func parseYAML(data []byte) ([]field, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlError(err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &syntaxError{root.Line, "expected a mapping of keys to values"}
+	}
+
+	fields := make([]field, 0, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, node := root.Content[i], root.Content[i+1]
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, &syntaxError{node.Line, err.Error()}
+		}
+		fields = append(fields, field{key: key.Value, line: key.Line, value: value})
+	}
+	if root.Style&yaml.FlowStyle == 0 {
+		yamlSpans(data, root, fields)
+	}
+	return fields, nil
+}
+
+// yamlSpans finds the bytes of each key of a block mapping and its value:
+// its lines up to the next key, less the blank lines and comments at the
+// start of a line before it, which belong to the next key
+func yamlSpans(data []byte, root *yaml.Node, fields []field) {
+	starts := lineStarts(data)
+	for i := range fields {
+		key := root.Content[2*i]
+		next := len(starts) // One past the last line
+		if 2*i+2 < len(root.Content) {
+			next = root.Content[2*i+2].Line - 1
+		}
+		last := next - 1
+		for last > key.Line-1 {
+			line := bytes.TrimRight(data[starts[last]:lineEnd(data, starts, last)], " \t\r")
+			if len(line) > 0 && line[0] != '#' {
+				break
+			}
+			last--
+		}
+		fields[i].start = starts[key.Line-1] + key.Column - 1
+		fields[i].end = lineEnd(data, starts, last)
+	}
+}
+
+// lineStarts returns the offset of each line of data
+func lineStarts(data []byte) []int {
+	starts := []int{0}
+	for i, c := range data {
+		if c == '\n' && i+1 < len(data) {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// lineEnd returns the offset of the end of line, before its newline
+func lineEnd(data []byte, starts []int, line int) int {
+	if line+1 < len(starts) {
+		return starts[line+1] - 1
+	}
+	return len(bytes.TrimSuffix(data, []byte("\n")))
+}
+
+// yamlError moves the line number out of a yaml.v3 error message
+func yamlError(err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	var line int
+	if n, _ := fmt.Sscanf(msg, "line %d:", &line); n == 1 {
+		msg = strings.TrimSpace(msg[strings.Index(msg, ":")+1:])
+	}
+	return &syntaxError{line, msg}
+}
+
+// configFields returns the keys of c in struct order, leaving out empty
+// omitempty fields
+func configFields(c Config) []field {
+	v := reflect.ValueOf(c)
+	t := v.Type()
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "omitempty") && v.Field(i).IsZero() {
+			continue
+		}
+		fields = append(fields, field{key: name, value: v.Field(i).Interface()})
+	}
+	return fields
+}
+
+// encodeConfig writes c in format
+func encodeConfig(format string, c Config) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		root := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range configFields(c) {
+			value := &yaml.Node{}
+			if err := value.Encode(f.value); err != nil {
+				return nil, err
+			}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.key}, value)
+		}
+		return yaml.Marshal(root)
+	case FormatTOML:
+		return encodeTOML(configFields(c)), nil
+	default:
+		return json.MarshalIndent(c, "", "  ")
+	}
+}
+
+// readConfigFile reads and decodes the config file at path
+func readConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Default(), err
+	}
+	return decodeConfig(path, data)
+}
+
+// writeConfigFile writes data to a temporary file next to path, readable
+// only by the owner, and renames it into place, so the config watcher and
+// other passman instances never read a partly written config. A symlinked
+// config, e.g. from a dotfiles repository, is written through the link.
+func writeConfigFile(path string, data []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// restrictConfigFile makes a config file written before configs were kept
+// owner-only, e.g. 0644, readable only by the owner: it may hold the
+// history passphrase. Windows has no such modes.
+func restrictConfigFile(path string, info os.FileInfo) {
+	if runtime.GOOS == "windows" || info.Mode().Perm()&0077 == 0 {
+		return
+	}
+	_ = os.Chmod(path, 0600)
+}
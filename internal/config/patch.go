@@ -0,0 +1,312 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// edit replaces the bytes from start to end of a file with text
+type edit struct {
+	start, end int
+	text       string
+}
+
+// patchConfig rewrites only the keys of the config file data whose values
+// differ from c, leaving its comments, key order and layout as they are,
+// so a setting toggled in the TUI doesn't reformat a hand-edited file. ok
+// is false when data can't be patched and has to be rewritten whole.
+func patchConfig(path string, data []byte, c Config) (patched []byte, ok bool) {
+	format := FormatOf(path)
+	fields, err := parseFields(format, data)
+	if err != nil || len(fields) == 0 {
+		return nil, false
+	}
+	onDisk, _ := decodeConfig(path, data)
+
+	// Keys that changed; a nil value is a key to remove
+	saved := make(map[string]interface{})
+	for _, f := range configFields(onDisk) {
+		saved[f.key] = f.value
+	}
+	var changes []field
+	for _, f := range configFields(c) {
+		if value, ok := saved[f.key]; !ok || !reflect.DeepEqual(value, f.value) {
+			changes = append(changes, f)
+		}
+		delete(saved, f.key)
+	}
+	for key := range saved {
+		changes = append(changes, field{key: key})
+	}
+	if len(changes) == 0 {
+		return data, true
+	}
+
+	inFile := make(map[string]field, len(fields))
+	for _, f := range fields {
+		if f.end == 0 {
+			return nil, false
+		}
+		inFile[f.key] = f
+	}
+	var edits []edit
+	switch format {
+	case FormatYAML:
+		edits, err = yamlEdits(data, inFile, changes)
+	case FormatTOML:
+		edits, err = tomlEdits(data, fields, inFile, changes)
+	default:
+		edits, err = jsonEdits(data, fields, changes)
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	// Apply the edits from the end, so the offsets of the rest still hold;
+	// text inserted where an entry is replaced goes before it, and entries
+	// inserted at the same place stay in order
+	slices.Reverse(edits)
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start > edits[j].start
+		}
+		return edits[i].end > edits[j].end
+	})
+	patched = append([]byte(nil), data...)
+	for i, e := range edits {
+		if i > 0 && e.end > edits[i-1].start {
+			return nil, false
+		}
+		patched = append(patched[:e.start], append([]byte(e.text), patched[e.end:]...)...)
+	}
+
+	// Anything the edits got wrong falls back to rewriting the file
+	if _, err := parseFields(format, patched); err != nil {
+		return nil, false
+	}
+	decoded, _ := decodeConfig(path, patched)
+	if !reflect.DeepEqual(configFields(decoded), configFields(c)) {
+		return nil, false
+	}
+	return patched, true
+}
+
+// fullLines widens the bytes from start to end to whole lines, with the
+// newline after them, for removing an entry
+func fullLines(data []byte, start, end int) (int, int) {
+	start = bytes.LastIndexByte(data[:start], '\n') + 1
+	if i := bytes.IndexByte(data[end:], '\n'); i >= 0 {
+		return start, end + i + 1
+	}
+	return start, len(data)
+}
+
+// appendAtEnd returns an edit adding text as new lines at the end of data
+func appendAtEnd(data []byte, text string) edit {
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		text = "\n" + text
+	}
+	return edit{len(data), len(data), text + "\n"}
+}
+
+// yamlEdits patches a YAML mapping. A replaced entry keeps a comment after
+// a value on the key's line.
+func yamlEdits(data []byte, inFile map[string]field, changes []field) ([]edit, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+	indent := yamlIndent(root)
+	comments := make(map[string]string)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		comments[root.Content[i].Value] = root.Content[i+1].LineComment
+	}
+
+	var edits []edit
+	for _, change := range changes {
+		f, found := inFile[change.key]
+		var text string
+		if change.value != nil {
+			entry := &yaml.Node{Kind: yaml.MappingNode}
+			value := &yaml.Node{}
+			if err := value.Encode(change.value); err != nil {
+				return nil, err
+			}
+			entry.Content = []*yaml.Node{{Kind: yaml.ScalarNode, Value: change.key}, value}
+
+			var b bytes.Buffer
+			enc := yaml.NewEncoder(&b)
+			enc.SetIndent(indent)
+			if err := enc.Encode(entry); err != nil {
+				return nil, err
+			}
+			text = strings.TrimSuffix(b.String(), "\n")
+			if comment := comments[change.key]; found && comment != "" && !strings.Contains(text, "\n") {
+				text += " " + comment
+			}
+		}
+
+		switch {
+		case !found && change.value != nil:
+			edits = append(edits, appendAtEnd(data, text))
+		case found && change.value == nil:
+			start, end := fullLines(data, f.start, f.end)
+			edits = append(edits, edit{start, end, ""})
+		case found:
+			edits = append(edits, edit{f.start, f.end, text})
+		}
+	}
+	return edits, nil
+}
+
+// yamlIndent returns the indentation of the first nested block mapping or
+// sequence of root, or yaml.v3's 4 when there is none
+func yamlIndent(root *yaml.Node) int {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 && value.Content[0].Column > key.Column {
+			return value.Content[0].Column - key.Column
+		}
+	}
+	return 4
+}
+
+// tomlEdits patches a TOML file. A map that is a table stays one, and so
+// does an inline table; new plain keys go after the last one, before any
+// table as TOML requires, and new tables at the end.
+func tomlEdits(data []byte, fields []field, inFile map[string]field, changes []field) ([]edit, error) {
+	plainEnd := -1
+	for _, f := range fields {
+		if data[f.start] == '[' {
+			if plainEnd < 0 {
+				plainEnd = f.start
+			}
+			break
+		}
+		_, plainEnd = fullLines(data, f.start, f.end)
+	}
+
+	var edits []edit
+	for _, change := range changes {
+		f, found := inFile[change.key]
+		if !found && change.value == nil {
+			continue
+		}
+		if found && change.value == nil {
+			start, end := fullLines(data, f.start, f.end)
+			edits = append(edits, edit{start, end, ""})
+			continue
+		}
+
+		isMap := reflect.ValueOf(change.value).Kind() == reflect.Map
+		table := isMap && (!found || data[f.start] == '[')
+		var text string
+		if table {
+			text = strings.TrimSpace(string(encodeTOML([]field{change})))
+		} else if isMap {
+			text = tomlKey(change.key) + " = " + tomlInlineMap(change.value)
+		} else {
+			text = tomlKey(change.key) + " = " + tomlValue(change.value)
+		}
+
+		switch {
+		case found:
+			edits = append(edits, edit{f.start, f.end, text})
+		case table:
+			edits = append(edits, appendAtEnd(data, "\n"+text))
+		case plainEnd >= 0 && (plainEnd == 0 || data[plainEnd-1] == '\n'):
+			edits = append(edits, edit{plainEnd, plainEnd, text + "\n"})
+		default:
+			edits = append(edits, appendAtEnd(data, text))
+		}
+	}
+	return edits, nil
+}
+
+// tomlInlineMap encodes a map as an inline table, sorted by key
+func tomlInlineMap(value interface{}) string {
+	m := reflect.ValueOf(value)
+	keys := make([]string, 0, m.Len())
+	for _, k := range m.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = tomlKey(k) + " = " + tomlValue(m.MapIndex(reflect.ValueOf(k)).Interface())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// jsonEdits patches a JSON object. A file with a key on each line gets
+// new entries and nested values indented to match; a file on one line
+// stays on one line.
+func jsonEdits(data []byte, fields []field, changes []field) ([]edit, error) {
+	indent, multiline := "", bytes.Contains(data[:fields[0].start], []byte("\n"))
+	if multiline {
+		lineStart := bytes.LastIndexByte(data[:fields[0].start], '\n') + 1
+		indent = string(data[lineStart:fields[0].start])
+	}
+	entry := func(key string, value interface{}) (string, error) {
+		k, err := json.Marshal(key)
+		if err != nil {
+			return "", err
+		}
+		var v []byte
+		if multiline {
+			v, err = json.MarshalIndent(value, indent, "  ")
+		} else {
+			v, err = json.Marshal(value)
+		}
+		return fmt.Sprintf("%s: %s", k, v), err
+	}
+
+	index := make(map[string]int, len(fields))
+	for i, f := range fields {
+		index[f.key] = i
+	}
+	removed := 0
+	var edits []edit
+	for _, change := range changes {
+		i, found := index[change.key]
+		switch {
+		case found && change.value == nil:
+			// Take the comma before the entry with it, or after the first
+			removed++
+			if removed == len(fields) {
+				return nil, fmt.Errorf("cannot remove every key")
+			}
+			if i > 0 {
+				edits = append(edits, edit{fields[i-1].end, fields[i].end, ""})
+			} else {
+				edits = append(edits, edit{fields[0].start, fields[1].start, ""})
+			}
+		case found:
+			text, err := entry(change.key, change.value)
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, edit{fields[i].start, fields[i].end, text})
+		case change.value != nil:
+			text, err := entry(change.key, change.value)
+			if err != nil {
+				return nil, err
+			}
+			separator := " "
+			if multiline {
+				separator = "\n" + indent
+			}
+			last := fields[len(fields)-1].end
+			edits = append(edits, edit{last, last, "," + separator + text})
+		}
+	}
+	return edits, nil
+}
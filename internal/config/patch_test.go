@@ -0,0 +1,112 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestPatchConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		data   string
+		change func(c *Config)
+		want   string
+	}{
+		{
+			name:   "YAML value",
+			file:   "config.yaml",
+			data:   "# passman\ntheme: dark # mine\n\n# history\nhistory_sort: time\nnotifications:\n  export: \"off\"\nshow_footer: true\n",
+			change: func(c *Config) { c.Theme = "light"; c.ShowFooter = false },
+			want:   "# passman\ntheme: light # mine\n\n# history\nhistory_sort: time\nnotifications:\n  export: \"off\"\nshow_footer: false\n",
+		},
+		{
+			name:   "YAML new map",
+			file:   "config.yaml",
+			data:   "notifications:\n  export: \"off\"\n\n# the end\n",
+			change: func(c *Config) { c.Presets = map[string]Preset{"pin": {Type: "pin", Length: 6}} },
+			want:   "notifications:\n  export: \"off\"\n\n# the end\npresets:\n  pin:\n    type: pin\n    length: 6\n",
+		},
+		{
+			name:   "YAML removed key",
+			file:   "config.yaml",
+			data:   "theme: dark\nagent_allowed_clients: rofi\n# kept\nshow_footer: true\n",
+			change: func(c *Config) { c.AgentAllowedClients = "" },
+			want:   "theme: dark\n# kept\nshow_footer: true\n",
+		},
+		{
+			name:   "TOML",
+			file:   "config.toml",
+			data:   "# passman\ntheme = \"dark\" # mine\nshow_footer = true\n\n[notifications]\nexport = \"off\"\n",
+			change: func(c *Config) { c.ShowFooter = false; c.HistorySort = "length" },
+			want:   "# passman\ntheme = \"dark\" # mine\nshow_footer = false\nhistory_sort = \"length\"\n\n[notifications]\nexport = \"off\"\n",
+		},
+		{
+			name:   "TOML new table",
+			file:   "config.toml",
+			data:   "theme = \"dark\"\n",
+			change: func(c *Config) { c.Presets = map[string]Preset{"pin": {Type: "pin", Length: 6}} },
+			want:   "theme = \"dark\"\n\n[presets]\npin = {type = \"pin\", length = 6}\n",
+		},
+		{
+			name:   "JSON on one line",
+			file:   "config.json",
+			data:   `{"theme": "dark", "show_footer": true}`,
+			change: func(c *Config) { c.ShowFooter = false; c.HistorySort = "length"; c.CopyMethod = "type" },
+			want:   `{"theme": "dark", "show_footer": false, "copy_method": "type", "history_sort": "length"}`,
+		},
+		{
+			name:   "JSON indented",
+			file:   "config.json",
+			data:   "{\n  \"theme\": \"dark\",\n  \"show_footer\": true\n}\n",
+			change: func(c *Config) { c.HistorySort = "length"; c.Theme = "light" },
+			want:   "{\n  \"theme\": \"light\",\n  \"show_footer\": true,\n  \"history_sort\": \"length\"\n}\n",
+		},
+		{
+			name:   "JSON removed key",
+			file:   "config.json",
+			data:   `{"agent_allowed_clients": "rofi", "theme": "dark"}`,
+			change: func(c *Config) { c.AgentAllowedClients = "" },
+			want:   `{"theme": "dark"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := decodeConfig(tt.file, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("decodeConfig failed: %v", err)
+			}
+			tt.change(&c)
+
+			got, ok := patchConfig(tt.file, []byte(tt.data), c)
+			if !ok {
+				t.Fatal("Expected the file to be patched")
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected\n%s\ngot\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPatchConfigFallsBack(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"Syntax error", "config.json", `{"theme": `},
+		{"Empty", "config.yaml", ""},
+		{"YAML flow mapping", "config.yaml", "{theme: dark}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			c.ShowFooter = !c.ShowFooter
+			if _, ok := patchConfig(tt.file, []byte(tt.data), c); ok {
+				t.Error("Expected the file to be rewritten whole")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appDirName is the directory name used under each base directory
+const appDirName = "passman"
+
+// ConfigDirEnv overrides every passman directory with a single one, as
+// the original ~/.config/passman layout: config, history and the wordlist
+// cache side by side, logs in a logs subdirectory
+const ConfigDirEnv = "PASSMAN_CONFIG_DIR"
+
+// Paths are the directories passman keeps its files in
+type Paths struct {
+	Config string // config.json (or .yaml, .toml) and state.json
+	Data   string // Encrypted history, scratchpad and quarantine
+	Cache  string // Downloaded wordlists, safe to delete
+	Logs   string // Application log
+	Temp   string // Scratch files removed after use: $TMPDIR, %TEMP% or /tmp
+}
+
+// ResolvePaths returns the directories for this platform:
+//
+//   - PASSMAN_CONFIG_DIR, if set, for everything
+//   - Linux and BSD: the XDG base directories, $XDG_CONFIG_HOME,
+//     $XDG_DATA_HOME, $XDG_CACHE_HOME and $XDG_STATE_HOME, defaulting to
+//     ~/.config, ~/.local/share, ~/.cache and ~/.local/state
+//   - macOS: ~/Library/Application Support, ~/Library/Caches and
+//     ~/Library/Logs
+//   - Windows: %AppData% for config, %LocalAppData% for the rest
+//
+// Scratch files go to the platform's temporary directory, never a
+// hardcoded /tmp.
+//
+// Files from before the split, in ~/.config/passman, keep being used until
+// the new location has its own copy, so upgrading never hides a history.
+//
+// With a profile selected, config and data are that profile's
+// subdirectories, see SetProfile.
+func ResolvePaths() (Paths, error) {
+	paths, err := basePaths()
+	if err != nil {
+		return Paths{}, err
+	}
+	return profilePaths(paths), nil
+}
+
+// basePaths returns the directories of the default profile
+func basePaths() (Paths, error) {
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return Paths{}, fmt.Errorf("%s: %w", ConfigDirEnv, err)
+		}
+		return Paths{Config: dir, Data: dir, Cache: dir, Logs: filepath.Join(dir, "logs"), Temp: os.TempDir()}, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Paths{}, err
+	}
+
+	paths, err := platformPaths(runtime.GOOS, home)
+	if err != nil {
+		return Paths{}, err
+	}
+
+	legacy := filepath.Join(home, ".config", appDirName)
+	paths.Config = preferLegacy(paths.Config, legacy, hasConfigFile)
+	paths.Data = preferLegacy(paths.Data, legacy, func(dir string) bool {
+		return fileExists(filepath.Join(dir, "history.enc"))
+	})
+	return paths, nil
+}
+
+// platformPaths returns the standard directories of goos
+func platformPaths(goos, home string) (Paths, error) {
+	configBase, err := os.UserConfigDir()
+	if err != nil {
+		return Paths{}, err
+	}
+	cacheBase, err := os.UserCacheDir()
+	if err != nil {
+		return Paths{}, err
+	}
+
+	var dataBase, logs string
+	switch goos {
+	case "windows":
+		dataBase = os.Getenv("LocalAppData")
+		if dataBase == "" {
+			dataBase = filepath.Join(home, "AppData", "Local")
+		}
+		logs = filepath.Join(dataBase, appDirName, "logs")
+	case "darwin", "ios":
+		dataBase = configBase // ~/Library/Application Support
+		logs = filepath.Join(home, "Library", "Logs", appDirName)
+	default:
+		dataBase = xdgDir("XDG_DATA_HOME", home, ".local", "share")
+		logs = filepath.Join(xdgDir("XDG_STATE_HOME", home, ".local", "state"), appDirName, "logs")
+	}
+
+	return Paths{
+		Config: filepath.Join(configBase, appDirName),
+		Data:   filepath.Join(dataBase, appDirName),
+		Cache:  filepath.Join(cacheBase, appDirName),
+		Logs:   logs,
+		Temp:   os.TempDir(),
+	}, nil
+}
+
+// xdgDir returns the XDG base directory in env, or home joined with the
+// default elements when it is unset or not absolute, as the spec requires
+func xdgDir(env, home string, elem ...string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+// preferLegacy returns the legacy directory when only it is in use
+func preferLegacy(dir, legacy string, inUse func(dir string) bool) string {
+	if dir == legacy || inUse(dir) {
+		return dir
+	}
+	if inUse(legacy) {
+		return legacy
+	}
+	return dir
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// GetDataDir returns the directory of the encrypted history files
+func GetDataDir() (string, error) {
+	paths, err := ResolvePaths()
+	return paths.Data, err
+}
+
+// GetCacheDir returns the directory of downloaded wordlists
+func GetCacheDir() (string, error) {
+	paths, err := ResolvePaths()
+	return paths.Cache, err
+}
+
+// GetTempDir returns the directory for scratch files removed after use
+func GetTempDir() (string, error) {
+	paths, err := ResolvePaths()
+	return paths.Temp, err
+}
+
+// GetLogDir returns the directory of the application log
+func GetLogDir() (string, error) {
+	paths, err := ResolvePaths()
+	return paths.Logs, err
+}
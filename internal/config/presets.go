@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// maxPresetName limits preset names so they fit on a menu line
+const maxPresetName = 40
+
+// Preset is a named generator configuration saved from a generator screen.
+// It is offered in the menu and used by passman generate -preset; zero
+// values select the generator's defaults.
+type Preset struct {
+	Type       string   `json:"type" yaml:"type"` // A registered generator, e.g. random
+	Length     int      `json:"length,omitempty" yaml:"length,omitempty"`
+	GroupSize  int      `json:"group_size,omitempty" yaml:"group_size,omitempty"` // PIN digits per group
+	Charsets   []string `json:"charsets,omitempty" yaml:"charsets,omitempty"`     // lower, upper, digits, symbols or a locale
+	Words      int      `json:"words,omitempty" yaml:"words,omitempty"`
+	Separator  string   `json:"separator,omitempty" yaml:"separator,omitempty"` // none joins the words directly
+	Wordlist   string   `json:"wordlist,omitempty" yaml:"wordlist,omitempty"`
+	Capitalize bool     `json:"capitalize,omitempty" yaml:"capitalize,omitempty"`
+	Digit      bool     `json:"digit,omitempty" yaml:"digit,omitempty"`
+}
+
+// Summary describes the preset in a few words, e.g. "random, 20 chars"
+func (p Preset) Summary() string {
+	parts := []string{p.Type}
+	if p.Length > 0 {
+		parts = append(parts, fmt.Sprintf("%d chars", p.Length))
+	}
+	if p.Words > 0 {
+		parts = append(parts, fmt.Sprintf("%d words", p.Words))
+	}
+	if len(p.Charsets) > 0 {
+		parts = append(parts, strings.Join(p.Charsets, "+"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ValidatePresetName reports why name cannot name a preset, or nil
+func ValidatePresetName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("preset name cannot be empty")
+	case name != strings.TrimSpace(name):
+		return fmt.Errorf("preset name cannot start or end with spaces")
+	case len(name) > maxPresetName:
+		return fmt.Errorf("preset name is longer than %d characters", maxPresetName)
+	case strings.ContainsAny(name, "\r\n\t"):
+		return fmt.Errorf("preset name cannot contain line breaks or tabs")
+	}
+	return nil
+}
+
+// PresetNames returns the names of the saved presets, sorted
+func (c *Config) PresetNames() []string {
+	names := make([]string, 0, len(c.Presets))
+	for name := range c.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FindPreset returns the preset called name, ignoring case
+func (c *Config) FindPreset(name string) (Preset, bool) {
+	if preset, ok := c.Presets[name]; ok {
+		return preset, true
+	}
+	for saved, preset := range c.Presets {
+		if strings.EqualFold(saved, name) {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+// validatePresets drops presets that no generator could produce, and
+// last-used settings of generators that are not registered
+func (c *Config) validatePresets() {
+	for name, preset := range c.Presets {
+		if _, ok := generator.Lookup(preset.Type); !ok || ValidatePresetName(name) != nil ||
+			preset.Length < 0 || preset.Words < 0 || preset.GroupSize < 0 {
+			delete(c.Presets, name)
+		}
+	}
+	for kind, settings := range c.GeneratorSettings {
+		if _, ok := generator.Lookup(kind); !ok || (settings.Type != "" && settings.Type != kind) ||
+			settings.Length < 0 || settings.Words < 0 || settings.GroupSize < 0 {
+			delete(c.GeneratorSettings, kind)
+		}
+	}
+}
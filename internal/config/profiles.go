@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// ProfileEnv selects a profile when --profile is not given
+const ProfileEnv = "PASSMAN_PROFILE"
+
+// DefaultProfile is the name of the base configuration, the one used
+// when no profile is selected
+const DefaultProfile = "default"
+
+// profilesDir holds one directory per profile, under both the config and
+// the data directory
+const profilesDir = "profiles"
+
+// profileNamePattern keeps profile names usable as directory names everywhere
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+var (
+	profileMu     sync.RWMutex
+	activeProfile string // Empty for the default profile
+)
+
+// ActiveProfile returns the name of the selected profile
+func ActiveProfile() string {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	if activeProfile == "" {
+		return DefaultProfile
+	}
+	return activeProfile
+}
+
+// SetProfile selects the profile whose config and history are used from
+// now on. Each profile has its own config file, so its own defaults and
+// export path, and its own history; the wordlist cache and logs are
+// shared. The profile must exist, see CreateProfile.
+func SetProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		profileMu.Lock()
+		activeProfile = ""
+		profileMu.Unlock()
+		return nil
+	}
+
+	exists, err := profileExists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("profile %q does not exist; create it with 'passman profile create %s'", name, name)
+	}
+
+	profileMu.Lock()
+	activeProfile = name
+	profileMu.Unlock()
+	return nil
+}
+
+// ProfileNames returns the default profile followed by every created
+// profile in alphabetical order
+func ProfileNames() ([]string, error) {
+	base, err := basePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{DefaultProfile}
+	entries, err := os.ReadDir(filepath.Join(base.Config, profilesDir))
+	if os.IsNotExist(err) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileNamePattern.MatchString(entry.Name()) {
+			created = append(created, entry.Name())
+		}
+	}
+	sort.Strings(created)
+	return append(names, created...), nil
+}
+
+// CreateProfile creates a profile from the default profile's settings.
+// Its export path is a subdirectory named after the profile, so exports
+// from different profiles never mix; its history starts empty.
+func CreateProfile(name string) error {
+	if !profileNamePattern.MatchString(name) || name == DefaultProfile {
+		return fmt.Errorf("invalid profile name %q: use up to 32 lowercase letters, digits, - and _", name)
+	}
+
+	exists, err := profileExists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+
+	base, err := basePaths()
+	if err != nil {
+		return err
+	}
+
+	// Start from the default profile's file, in its format
+	baseFile := findConfigFile(base.Config)
+	cfg := Default()
+	if fileExists(baseFile) {
+		if cfg, err = readConfigFile(baseFile); err != nil {
+			return fmt.Errorf("default profile: %w", err)
+		}
+	}
+	cfg.DefaultExportPath = filepath.Join(cfg.DefaultExportPath, name)
+
+	data, err := encodeConfig(FormatOf(baseFile), cfg)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(base.Config, profilesDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, filepath.Base(baseFile)), data, 0600)
+}
+
+// profileExists reports whether the profile has a config directory
+func profileExists(name string) (bool, error) {
+	if !profileNamePattern.MatchString(name) {
+		return false, fmt.Errorf("invalid profile name %q", name)
+	}
+	base, err := basePaths()
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(filepath.Join(base.Config, profilesDir, name))
+	return err == nil && info.IsDir(), nil
+}
+
+// profilePaths moves the config and data directories into the active
+// profile's subdirectories
+func profilePaths(paths Paths) Paths {
+	profileMu.RLock()
+	name := activeProfile
+	profileMu.RUnlock()
+
+	if name != "" {
+		paths.Config = filepath.Join(paths.Config, profilesDir, name)
+		paths.Data = filepath.Join(paths.Data, profilesDir, name)
+	}
+	return paths
+}
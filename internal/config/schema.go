@@ -0,0 +1,328 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// Problem is one thing wrong with a config file
+type Problem struct {
+	Line    int    // 1-based line, 0 when unknown
+	Key     string // Top-level key, empty for syntax errors
+	Message string
+}
+
+// SchemaError lists everything wrong with a config file, so that all of it
+// can be fixed in one go rather than having invalid values quietly
+// replaced with defaults
+type SchemaError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *SchemaError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		if p.Line > 0 {
+			lines[i] = fmt.Sprintf("%s:%d: %s", e.Path, p.Line, p.Message)
+		} else {
+			lines[i] = fmt.Sprintf("%s: %s", e.Path, p.Message)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// allowedValues describes the valid values of each key Validate corrects
+var allowedValues = map[string]string{
+	"default_generator":              generator.NamesText(),
+	"default_length":                 "1-512",
+	"default_passphrase_words":       "1-20",
+	"default_pin_length":             "1-50",
+	"default_pin_group_size":         "0-10",
+	"default_pin_separator":          "text without digits",
+	"default_passphrase_separator":   "a non-empty string",
+	"default_passphrase_leet":        "off, all or random",
+	"clear_clipboard_after_seconds":  "0 or more",
+	"copy_method":                    "clipboard or type",
+	"auto_type_delay_seconds":        "1-60",
+	"password_group_size":            "0-16",
+	"history_kdf":                    "pbkdf2 or argon2id",
+	"history_passphrase":             "stored, prompt, keychain or hardware",
+	"passphrase_cache_minutes":       "0-1440",
+	"crack_attacker":                 "online, offline-slow, offline, offline-fast or nation-state",
+	"crack_doubling_years":           "0-20",
+	"scratchpad_clear_after_minutes": "0 or more",
+	"clipboard_copy_command":         "a command with balanced quotes",
+	"clipboard_paste_command":        "a command with balanced quotes",
+	"auto_lock_minutes":              "0-1440",
+	"share_expiry_days":              "0 or more",
+	"recent_passwords":               "1-100",
+	"candidate_count":                "5-10",
+	"history_max_entries":            "1-10000",
+	"history_retention_days":         "0-3650",
+	"history_max_size_kb":            "0-102400",
+	"sync_remote":                    "an http(s)://, s3:// or git+ URL",
+	"sync_interval_minutes":          "0-1440",
+	"history_columns":                "a comma-separated list of time, password, length, type, strength, description, site, username and tags",
+	"history_sort":                   "time, length, type or strength",
+	"default_export_format":          "txt, json or csv",
+	"export_file_mode":               "an octal mode with owner read and write, e.g. 0600 or 0640",
+	"export_encryption":              "off, age or gpg",
+	"wordlist_update_interval_days":  "0 or more",
+	"agent_quota_per_minute":         "1-10000",
+	"notifications":                  "off, bell, desktop or both for each event",
+	"presets":                        "a registered generator type for each preset",
+	"generator_settings":             "settings of registered generator types",
+	"generation_rates":               "positive secrets per second of registered generator types",
+}
+
+// decodeConfig parses a config file in the format of its extension and
+// checks it against the Config schema. Keys that are unknown, of the wrong
+// type or out of range are reported in a *SchemaError with their line; the
+// returned config has defaults in their place.
+func decodeConfig(path string, data []byte) (Config, error) {
+	schemaErr := &SchemaError{Path: path}
+
+	fields, err := parseFields(FormatOf(path), data)
+	if err != nil {
+		problem := Problem{Message: err.Error()}
+		if syntax, ok := err.(*syntaxError); ok {
+			problem = Problem{Line: syntax.line, Message: syntax.msg}
+		}
+		schemaErr.Problems = append(schemaErr.Problems, problem)
+		return Default(), schemaErr
+	}
+
+	index := schemaIndex()
+	var config Config
+	target := reflect.ValueOf(&config).Elem()
+	set := make(map[string]Problem)
+
+	for _, f := range fields {
+		i, ok := index[f.key]
+		if !ok {
+			schemaErr.Problems = append(schemaErr.Problems, Problem{
+				Line: f.line, Key: f.key, Message: unknownKeyMessage(f.key, index),
+			})
+			continue
+		}
+
+		fieldType := target.Field(i).Type()
+		value := reflect.New(fieldType)
+		raw, err := json.Marshal(f.value)
+		if err == nil {
+			err = json.Unmarshal(raw, value.Interface())
+		}
+		if err != nil {
+			schemaErr.Problems = append(schemaErr.Problems, Problem{
+				Line: f.line, Key: f.key, Message: fmt.Sprintf("%s must be %s", f.key, describeType(fieldType)),
+			})
+			continue
+		}
+		target.Field(i).Set(value.Elem())
+		set[f.key] = Problem{Line: f.line, Key: f.key}
+	}
+
+	config = mergeWithDefaults(config)
+	schemaErr.Problems = append(schemaErr.Problems, rangeProblems(config, set)...)
+
+	if len(schemaErr.Problems) > 0 {
+		sort.SliceStable(schemaErr.Problems, func(i, j int) bool {
+			return schemaErr.Problems[i].Line < schemaErr.Problems[j].Line
+		})
+		config.Validate()
+		return config, schemaErr
+	}
+	return config, nil
+}
+
+// rangeProblems reports each key set in the file that Validate would
+// have to correct
+func rangeProblems(config Config, set map[string]Problem) []Problem {
+	validated := config
+	validated.Notifications = make(map[string]string, len(config.Notifications))
+	for event, method := range config.Notifications {
+		validated.Notifications[event] = method
+	}
+	validated.Presets = copyPresets(config.Presets)
+	validated.GeneratorSettings = copyPresets(config.GeneratorSettings)
+	validated.GenerationRates = maps.Clone(config.GenerationRates)
+	validated.Validate()
+
+	// Match fields by key: Validate may clear an omitempty field, which
+	// then drops out of the list
+	after := make(map[string]any)
+	for _, f := range configFields(validated) {
+		after[f.key] = f.value
+	}
+	var problems []Problem
+	for _, f := range configFields(config) {
+		problem, ok := set[f.key]
+		if !ok || reflect.DeepEqual(f.value, after[f.key]) {
+			continue
+		}
+		value := f.value
+		if events, ok := value.(map[string]string); ok {
+			fixed, _ := after[f.key].(map[string]string)
+			value = changedEntries(events, fixed)
+		}
+		if presets, ok := value.(map[string]Preset); ok {
+			fixed, _ := after[f.key].(map[string]Preset)
+			value = droppedKeys(presets, fixed)
+		}
+		if rates, ok := value.(map[string]int); ok {
+			fixed, _ := after[f.key].(map[string]int)
+			value = droppedKeys(rates, fixed)
+		}
+		problem.Message = fmt.Sprintf("invalid value %s for %s", formatValue(value), f.key)
+		if allowed, ok := allowedValues[f.key]; ok {
+			problem.Message += " (want " + allowed + ")"
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// changedEntries returns the entries of before that differ in after
+func changedEntries(before, after map[string]string) map[string]string {
+	changed := make(map[string]string)
+	for key, value := range before {
+		if after[key] != value {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+// copyPresets copies a table of presets, keeping nil as nil
+func copyPresets(presets map[string]Preset) map[string]Preset {
+	if presets == nil {
+		return nil
+	}
+	copied := make(map[string]Preset, len(presets))
+	for name, preset := range presets {
+		copied[name] = preset
+	}
+	return copied
+}
+
+// keyNames prints the keys of table entries in problems, quoted and
+// comma-separated
+type keyNames []string
+
+func (n keyNames) String() string {
+	quoted := make([]string, len(n))
+	for i, name := range n {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// droppedKeys returns the keys of before missing in after
+func droppedKeys[V any](before, after map[string]V) keyNames {
+	var dropped keyNames
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			dropped = append(dropped, name)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// schemaIndex maps each config key to its struct field
+func schemaIndex() map[string]int {
+	t := reflect.TypeOf(Config{})
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	return index
+}
+
+// unknownKeyMessage reports an unknown key, suggesting the closest known
+// key when it is likely a typo
+func unknownKeyMessage(key string, index map[string]int) string {
+	msg := fmt.Sprintf("unknown key %q", key)
+	best, bestDistance := "", 3 // At most 2 edits away
+	for name := range index {
+		if d := editDistance(key, name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best != "" {
+		msg += fmt.Sprintf("; did you mean %q?", best)
+	}
+	return msg
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// describeType names the kind of value a field of type t takes
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int:
+		return "a whole number"
+	case reflect.String:
+		return "a string"
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Slice {
+			return "a table of key lists"
+		}
+		if t.Elem().Kind() == reflect.Struct {
+			return "a table of presets"
+		}
+		if t.Elem().Kind() == reflect.Int {
+			return "a table of whole numbers"
+		}
+		return "a table of strings"
+	default:
+		return t.String()
+	}
+}
+
+// formatValue shows a config value as it would be written in the file
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	if m, ok := value.(map[string]string); ok {
+		events := make([]string, 0, len(m))
+		for event, method := range m {
+			events = append(events, fmt.Sprintf("%s=%q", event, method))
+		}
+		sort.Strings(events)
+		return strings.Join(events, ", ")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// State holds data remembered between runs that is not user configuration
+type State struct {
+	LastSeenVersion string               `json:"last_seen_version"`
+	ChainHeads      map[string]ChainHead `json:"chain_heads,omitempty"` // By history file
+}
+
+// ChainHead is the newest entry of a history when passman last saved it.
+// No entry of the history's hash chain links to the newest one, so it is
+// kept outside the history file to show that entry edited or removed.
+type ChainHead struct {
+	ID      string `json:"id"`
+	Hash    string `json:"hash"`
+	Entries int    `json:"entries"`
+}
+
+// LoadState loads the application state, returning an empty state if none exists
+func LoadState() (State, error) {
+	statePath, err := getStatePath()
+	if err != nil {
+		return State{}, err
+	}
+
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+// Save writes the application state to disk
+func (s State) Save() error {
+	statePath, err := getStatePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeConfigFile(statePath, data)
+}
+
+// IsNewVersion reports whether the given version differs from the last one
+// seen. A fresh install, which has seen no version yet, has nothing new.
+func (s State) IsNewVersion(version string) bool {
+	return s.LastSeenVersion != "" && s.LastSeenVersion != version
+}
+
+// getStatePath returns the state file, which all profiles share
+func getStatePath() (string, error) {
+	paths, err := basePaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(paths.Config, "state.json"), nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestIsNewVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		lastSeen string
+		want     bool
+	}{
+		{"Fresh install", "", false},
+		{"Same version", "1.2.0", false},
+		{"Upgrade", "1.1.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (State{LastSeenVersion: tt.lastSeen}).IsNewVersion("1.2.0"); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStateSave(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnv, dir)
+
+	if err := (State{LastSeenVersion: "1.2.0"}).Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	state, err := LoadState()
+	if err != nil || state.LastSeenVersion != "1.2.0" {
+		t.Fatalf("Expected the saved version back, got %+v: %v", state, err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected state.json to be 0600, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,460 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// syntaxError is a syntax error on a line of a config file
+type syntaxError struct {
+	line int
+	msg  string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+// tomlParser reads the subset of TOML a config file needs: key = value
+// pairs, [table] headers, comments, strings, integers, floats, booleans,
+// arrays and inline tables. Dotted keys, dates and arrays of tables are
+// rejected with an error rather than misread.
+type tomlParser struct {
+	data string
+	pos  int
+	line int
+}
+
+// parseTOML returns the top-level keys of data in file order
+func parseTOML(data []byte) ([]field, error) {
+	p := &tomlParser{data: string(data), line: 1}
+	var fields []field
+	var table map[string]interface{}
+	seen := make(map[string]bool)
+
+	for {
+		p.skipBlank(true)
+		if p.done() {
+			return fields, nil
+		}
+
+		line, start := p.line, p.pos
+		if p.peek() == '[' {
+			p.pos++
+			if p.peek() == '[' {
+				return nil, p.errorf("arrays of tables are not supported")
+			}
+			p.skipBlank(false)
+			name, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			p.skipBlank(false)
+			if p.peek() != ']' {
+				return nil, p.errorf("expected ] after table name %q", name)
+			}
+			p.pos++
+			if seen[name] {
+				return nil, p.errorf("duplicate key %q", name)
+			}
+			seen[name] = true
+			table = make(map[string]interface{})
+			fields = append(fields, field{key: name, line: line, value: table, start: start, end: p.pos})
+			if err := p.endOfLine(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, value, err := p.parseKeyValue()
+		if err != nil {
+			return nil, err
+		}
+		if table != nil {
+			if _, ok := table[key]; ok {
+				return nil, &syntaxError{line, fmt.Sprintf("duplicate key %q", key)}
+			}
+			table[key] = value
+			fields[len(fields)-1].end = p.pos
+		} else {
+			if seen[key] {
+				return nil, &syntaxError{line, fmt.Sprintf("duplicate key %q", key)}
+			}
+			seen[key] = true
+			fields = append(fields, field{key: key, line: line, value: value, start: start, end: p.pos})
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *tomlParser) done() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return &syntaxError{p.line, fmt.Sprintf(format, args...)}
+}
+
+// skipBlank skips spaces and comments, and newlines too when newlines is set
+func (p *tomlParser) skipBlank(newlines bool) {
+	for !p.done() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+			p.line++
+		case c == '#':
+			for !p.done() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine requires the rest of the line to be blank or a comment
+func (p *tomlParser) endOfLine() error {
+	p.skipBlank(false)
+	if p.done() || p.peek() == '\n' {
+		return nil
+	}
+	return p.errorf("unexpected %q after value", p.peek())
+}
+
+// parseKey reads a bare or quoted key
+func (p *tomlParser) parseKey() (string, error) {
+	switch p.peek() {
+	case '"':
+		return p.parseBasicString()
+	case '\'':
+		return p.parseLiteralString()
+	}
+
+	start := p.pos
+	for !p.done() && isBareKeyChar(p.peek()) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected a key")
+	}
+	if p.peek() == '.' {
+		return "", p.errorf("dotted keys are not supported")
+	}
+	return p.data[start:p.pos], nil
+}
+
+func (p *tomlParser) parseKeyValue() (string, interface{}, error) {
+	key, err := p.parseKey()
+	if err != nil {
+		return "", nil, err
+	}
+	p.skipBlank(false)
+	if p.peek() != '=' {
+		return "", nil, p.errorf("expected = after key %q", key)
+	}
+	p.pos++
+	p.skipBlank(false)
+	value, err := p.parseValue()
+	return key, value, err
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		return p.parseBasicString()
+	case c == '\'':
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case strings.HasPrefix(p.data[p.pos:], "true"):
+		p.pos += len("true")
+		return true, nil
+	case strings.HasPrefix(p.data[p.pos:], "false"):
+		p.pos += len("false")
+		return false, nil
+	case c == '+' || c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c == 0 || c == '\n':
+		return nil, p.errorf("missing value")
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *tomlParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	for !p.done() && strings.IndexByte("+-_.eE0123456789", p.peek()) >= 0 {
+		p.pos++
+	}
+	text := strings.ReplaceAll(p.data[start:p.pos], "_", "")
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return nil, &syntaxError{p.line, fmt.Sprintf("invalid number %q", p.data[start:p.pos])}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	if strings.HasPrefix(p.data[p.pos:], `"""`) {
+		return "", p.errorf("multi-line strings are not supported")
+	}
+	p.pos++ // opening quote
+
+	var b strings.Builder
+	for {
+		if p.done() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	if p.done() {
+		return p.errorf("unterminated string")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.data) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.data[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape")
+		}
+		p.pos += size
+		b.WriteRune(rune(code))
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	if strings.HasPrefix(p.data[p.pos:], "'''") {
+		return "", p.errorf("multi-line strings are not supported")
+	}
+	p.pos++ // opening quote
+	start := p.pos
+	for !p.done() && p.peek() != '\'' {
+		if p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		p.pos++
+	}
+	if p.done() {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++ // closing quote
+	return p.data[start : p.pos-1], nil
+}
+
+// parseArray reads an array, which may span several lines
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++ // [
+	values := []interface{}{}
+	for {
+		p.skipBlank(true)
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipBlank(true)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
+
+// parseInlineTable reads { key = value, ... } on one line
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++ // {
+	table := make(map[string]interface{})
+	for {
+		p.skipBlank(false)
+		if p.peek() == '}' && len(table) == 0 {
+			p.pos++
+			return table, nil
+		}
+		key, value, err := p.parseKeyValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := table[key]; ok {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		table[key] = value
+
+		p.skipBlank(false)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.errorf("expected , or } in inline table")
+		}
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// encodeTOML writes fields as TOML: plain values first, then a table for
+// each map, as TOML requires
+func encodeTOML(fields []field) []byte {
+	var b strings.Builder
+	var tables []field
+	for _, f := range fields {
+		if reflect.ValueOf(f.value).Kind() == reflect.Map {
+			tables = append(tables, f)
+			continue
+		}
+		fmt.Fprintf(&b, "%s = %s\n", tomlKey(f.key), tomlValue(f.value))
+	}
+
+	for _, t := range tables {
+		fmt.Fprintf(&b, "\n[%s]\n", tomlKey(t.key))
+		table := reflect.ValueOf(t.value)
+		keys := make([]string, 0, table.Len())
+		for _, k := range table.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value := table.MapIndex(reflect.ValueOf(k)).Interface()
+			fmt.Fprintf(&b, "%s = %s\n", tomlKey(k), tomlValue(value))
+		}
+	}
+	return []byte(b.String())
+}
+
+// tomlKey returns key bare when it can be, quoted otherwise
+func tomlKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		if !isBareKeyChar(key[i]) {
+			return tomlString(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return tomlString(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = tomlString(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case Preset:
+		return tomlInlineTable(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// tomlInlineTable encodes a struct as an inline table keyed by its json
+// tags, leaving out empty omitempty fields
+func tomlInlineTable(value interface{}) string {
+	v := reflect.ValueOf(value)
+	var pairs []string
+	for i := 0; i < v.NumField(); i++ {
+		name, opts, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || (opts == "omitempty" && v.Field(i).IsZero()) {
+			continue
+		}
+		pairs = append(pairs, tomlKey(name)+" = "+tomlValue(v.Field(i).Interface()))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// tomlString quotes s as a TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval is how often Watch checks the config file
+const DefaultWatchInterval = time.Second
+
+// Watch checks the config file every interval until ctx is done and calls
+// onChange with the reloaded config whenever its contents change, for
+// example after editing it by hand. A file that cannot be read or parsed
+// is reported with the error, and reported again only once it changes.
+//
+// The file is polled rather than watched with filesystem events so that
+// editors which replace the file on save are handled the same everywhere.
+// Switching profiles moves the watch to the new profile's file without
+// reporting a change, since the switch already applied it.
+func Watch(ctx context.Context, interval time.Duration, onChange func(Config, error)) error {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	// A missing file reads as empty, so creating it counts as a change
+	last, _ := os.ReadFile(configPath)
+	profile := ActiveProfile()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if path, err := getConfigPath(); err == nil {
+			configPath = path
+		}
+		if current := ActiveProfile(); current != profile {
+			profile = current
+			last, _ = os.ReadFile(configPath)
+			continue
+		}
+
+		data, err := os.ReadFile(configPath)
+		if err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+
+		cfg, err := Load()
+		if err == nil {
+			err = cfg.Validate()
+		}
+		onChange(cfg, err)
+	}
+}
@@ -1,26 +1,36 @@
 package generator
 
 import (
-	"fmt"
-	"math"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // SecurityAnalyzer analyzes password security and provides detailed metrics
 type SecurityAnalyzer struct {
-	commonPasswords []string
-	commonWords     []string
+	commonWords []string
+	attacker    AttackerModel // Whose crack time is the analysis' CrackTime
 }
 
 // NewSecurityAnalyzer creates a new security analyzer
 func NewSecurityAnalyzer() *SecurityAnalyzer {
 	return &SecurityAnalyzer{
-		commonPasswords: getCommonPasswords(),
-		commonWords:     getCommonWords(),
+		commonWords: getCommonWords(),
+		attacker:    attackerModels[DefaultAttacker],
 	}
 }
 
+// SetAttacker chooses the attacker model, by name, that CrackTime is
+// estimated for; CrackTimes always covers every model
+func (s *SecurityAnalyzer) SetAttacker(name string) error {
+	attacker, err := Attacker(name)
+	if err != nil {
+		return err
+	}
+	s.attacker = attacker
+	return nil
+}
+
 // Analyze performs comprehensive security analysis of a password
 func (s *SecurityAnalyzer) Analyze(password string) SecurityAnalysis {
 	analysis := SecurityAnalysis{
@@ -35,9 +45,11 @@ func (s *SecurityAnalyzer) Analyze(password string) SecurityAnalysis {
 		Feedback:     []string{},
 	}
 	
-	analysis.Level = s.calculateSecurityLevel(analysis.Entropy, len(password), password)
+	analysis.Level = s.calculateSecurityLevel(analysis.Entropy, utf8.RuneCountInString(password), password)
 	analysis.CrackTime = s.estimateCrackTime(analysis.Entropy)
+	analysis.CrackTimes = CrackTimes(analysis.Entropy)
 	analysis.IsCompromised = s.isCommonPassword(password)
+	analysis.Segments = s.segment(password)
 	analysis.Feedback = s.generateFeedback(password, analysis)
 	
 	return analysis
@@ -50,7 +62,7 @@ func (s *SecurityAnalyzer) calculateEntropy(password string) float64 {
 	}
 	
 	charsetSize := s.calculateCharsetSize(password)
-	basicEntropy := float64(len(password)) * logBase2(float64(charsetSize))
+	basicEntropy := float64(utf8.RuneCountInString(password)) * logBase2(float64(charsetSize))
 	
 	// Apply entropy reduction factors
 	repetitionPenalty := s.calculateRepetitionPenalty(password)
@@ -87,6 +99,9 @@ func (s *SecurityAnalyzer) calculateCharsetSize(password string) int {
 	if hasSymbol {
 		size += 32 // Common symbols
 	}
+	if !isASCII(password) {
+		size += 32 // Accented and other non-ASCII letters
+	}
 	
 	// If only unique characters are used, charset size is the unique count
 	uniqueChars := make(map[rune]bool)
@@ -119,7 +134,7 @@ func (s *SecurityAnalyzer) calculateRepetitionPenalty(password string) float64 {
 		}
 	}
 	
-	penalty := 1.0 - (float64(totalRepeats) / float64(len(password)) * 0.5)
+	penalty := 1.0 - (float64(totalRepeats) / float64(utf8.RuneCountInString(password)) * 0.5)
 	if penalty < 0.3 {
 		penalty = 0.3 // Minimum penalty
 	}
@@ -230,22 +245,7 @@ func (s *SecurityAnalyzer) hasRepeatedPatterns(password string) bool {
 // calculateSecurityLevel determines overall security level
 func (s *SecurityAnalyzer) calculateSecurityLevel(entropy float64, length int, password string) SecurityLevel {
 	// Base level on entropy
-	level := VeryWeak
-	
-	switch {
-	case entropy >= 80:
-		level = VeryStrong
-	case entropy >= 60:
-		level = Strong
-	case entropy >= 45:
-		level = Good
-	case entropy >= 30:
-		level = Fair
-	case entropy >= 20:
-		level = Weak
-	default:
-		level = VeryWeak
-	}
+	level := EntropySecurityLevel(entropy)
 	
 	// Adjust for length
 	if length < 8 {
@@ -262,35 +262,10 @@ func (s *SecurityAnalyzer) calculateSecurityLevel(entropy float64, length int, p
 	return level
 }
 
-// estimateCrackTime provides human-readable crack time estimates
+// estimateCrackTime provides human-readable crack time estimates for the
+// chosen attacker
 func (s *SecurityAnalyzer) estimateCrackTime(entropy float64) string {
-	if entropy <= 0 {
-		return "Instantly"
-	}
-	
-	// Assume 1 billion guesses per second
-	guessesPerSecond := 1e9
-	combinations := math.Pow(2, entropy)
-	seconds := combinations / (2 * guessesPerSecond) // Average case
-	
-	switch {
-	case seconds < 1:
-		return "Instantly"
-	case seconds < 60:
-		return "Less than a minute"
-	case seconds < 3600:
-		return fmt.Sprintf("%.0f minutes", seconds/60)
-	case seconds < 86400:
-		return fmt.Sprintf("%.0f hours", seconds/3600)
-	case seconds < 2592000: // 30 days
-		return fmt.Sprintf("%.0f days", seconds/86400)
-	case seconds < 31536000: // 1 year
-		return fmt.Sprintf("%.0f months", seconds/2592000)
-	case seconds < 31536000000: // 1000 years
-		return fmt.Sprintf("%.0f years", seconds/31536000)
-	default:
-		return "Centuries"
-	}
+	return s.attacker.CrackTime(entropy)
 }
 
 // Character type checking functions
@@ -354,15 +329,10 @@ func (s *SecurityAnalyzer) findCommonWords(password string) []string {
 	return found
 }
 
-// isCommonPassword checks if password is in common password lists
+// isCommonPassword checks if password is on the embedded list of
+// breached passwords
 func (s *SecurityAnalyzer) isCommonPassword(password string) bool {
-	lower := strings.ToLower(password)
-	for _, common := range s.commonPasswords {
-		if lower == common {
-			return true
-		}
-	}
-	return false
+	return IsCommonPassword(password)
 }
 
 // generateFeedback provides actionable improvement suggestions
@@ -415,20 +385,6 @@ func (s *SecurityAnalyzer) generateFeedback(password string, analysis SecurityAn
 
 
 // Helper functions for common passwords and words
-func getCommonPasswords() []string {
-	return []string{
-		"password", "123456", "123456789", "guest", "qwerty", "12345678", "111111", "12345",
-		"col123456", "123123", "1234567", "1234", "1234567890", "000000", "555555", "666666",
-		"123321", "654321", "7777777", "123", "D1lakiss", "777777", "110110jp", "1111", "987654321",
-		"121212", "Gizli", "abc123", "112233", "azerty", "159753", "1q2w3e4r", "54321", "pass@123",
-		"222222", "qwertyui", "1234554321", "123qwe", "qwerty123", "password1", "administrator",
-		"1111111", "123456a", "qwerty1", "password123", "Passwd", "welcome", "admin", "master",
-		"hello", "dragon", "monkey", "letmein", "login", "princess", "qwertyuiop", "solo",
-		"passw0rd", "starwars", "shadow", "sunshine", "12345678910", "football", "iloveyou",
-		"superman", "trustno1", "jesus", "mustang", "ninja", "michael", "charlie",
-	}
-}
-
 func getCommonWords() []string {
 	return []string{
 		"password", "admin", "user", "login", "welcome", "hello", "world", "test", "home",
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BenchResult is how fast a generator produced secrets of one size
+type BenchResult struct {
+	Generator string
+	Size      int    // Characters or words, 0 for the generator's defaults
+	Unit      string // "chars" or "words", empty for generators without a size
+	Count     int    // Secrets generated
+	Elapsed   time.Duration
+}
+
+// PerSecond returns the measured secrets per second
+func (r BenchResult) PerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Count) / r.Elapsed.Seconds()
+}
+
+// BenchSizes returns the sizes passman bench measures a generator at,
+// starting with 0 for its defaults, and the unit they count
+func BenchSizes(r Registration) ([]int, string) {
+	switch {
+	case r.Name == "pin":
+		return []int{0, 4, 8, 16}, "chars"
+	case r.Uses(OptionWords):
+		return []int{0, 4, 8, 12}, "words"
+	case r.Uses(OptionLength):
+		return []int{0, 16, 32, 64, 128}, "chars"
+	}
+	return []int{0}, ""
+}
+
+// Benchmark generates secrets with the generator called name for about
+// duration and reports how many it made. size sets its length or word
+// count; 0 keeps its defaults.
+func Benchmark(ctx context.Context, name string, size int, duration time.Duration) (BenchResult, error) {
+	reg, ok := Lookup(name)
+	if !ok {
+		return BenchResult{}, fmt.Errorf("unknown type %q (use %s)", name, NamesText())
+	}
+	opts := Options{}
+	result := BenchResult{Generator: reg.Name, Size: size}
+	if size > 0 {
+		sizes, unit := BenchSizes(reg)
+		if len(sizes) == 1 {
+			return BenchResult{}, fmt.Errorf("%s has no size to benchmark", reg.Name)
+		}
+		result.Unit = unit
+		if unit == "words" {
+			opts.Words = size
+		} else {
+			opts.Length = size
+		}
+	}
+	gen, err := reg.New(opts)
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	// Check the clock every few secrets so reading it costs little
+	start := time.Now()
+	for {
+		for i := 0; i < 16; i++ {
+			if _, err := gen.Generate(ctx); err != nil {
+				return BenchResult{}, err
+			}
+			result.Count++
+		}
+		if result.Elapsed = time.Since(start); result.Elapsed >= duration {
+			return result, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return BenchResult{}, err
+		}
+	}
+}
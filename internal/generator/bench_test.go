@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBenchmark(t *testing.T) {
+	result, err := Benchmark(context.Background(), "random", 32, 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Benchmark() error = %v", err)
+	}
+	if result.Count == 0 || result.Elapsed < 5*time.Millisecond || result.PerSecond() <= 0 {
+		t.Errorf("Benchmark() = %+v, want secrets generated for at least the duration", result)
+	}
+	if result.Size != 32 || result.Unit != "chars" {
+		t.Errorf("Benchmark() size = %d %s, want 32 chars", result.Size, result.Unit)
+	}
+}
+
+func TestBenchmarkSizes(t *testing.T) {
+	for _, reg := range Registered() {
+		sizes, unit := BenchSizes(reg)
+		if len(sizes) == 0 || sizes[0] != 0 {
+			t.Errorf("%s: sizes %v should start with the defaults", reg.Name, sizes)
+		}
+		if (len(sizes) > 1) != (unit != "") {
+			t.Errorf("%s: sizes %v counted in %q", reg.Name, sizes, unit)
+		}
+	}
+
+	if _, err := Benchmark(context.Background(), "totp", 16, time.Millisecond); err == nil {
+		t.Error("Benchmark() accepted a size for a generator without one")
+	}
+	if _, err := Benchmark(context.Background(), "dice", 0, time.Millisecond); err == nil {
+		t.Error("Benchmark() accepted an unregistered generator")
+	}
+}
@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// commonPasswordData lists about 100,000 passwords common in breaches,
+// lowercase, sorted and one per line, gzip-compressed. Any list in that
+// format can replace it.
+//
+//go:embed data/common_passwords.txt.gz
+var commonPasswordData []byte
+
+// commonPasswordList decompresses the embedded list on first use, so
+// only analysing passwords pays for it
+var commonPasswordList = sync.OnceValue(func() []string {
+	zr, err := gzip.NewReader(bytes.NewReader(commonPasswordData))
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+
+	passwords := make([]string, 0, 100000)
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			passwords = append(passwords, line)
+		}
+	}
+	// A list edited by hand may be out of order; the search needs it sorted
+	if !sort.StringsAreSorted(passwords) {
+		sort.Strings(passwords)
+	}
+	return passwords
+})
+
+// IsCommonPassword reports whether password, ignoring case, is on the
+// embedded list of passwords common in breaches. It never goes online.
+func IsCommonPassword(password string) bool {
+	lower := strings.ToLower(password)
+	list := commonPasswordList()
+	i := sort.SearchStrings(list, lower)
+	return i < len(list) && list[i] == lower
+}
+
+// CommonPasswordCount returns the number of passwords on the embedded list
+func CommonPasswordCount() int {
+	return len(commonPasswordList())
+}
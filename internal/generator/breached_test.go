@@ -0,0 +1,28 @@
+package generator
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCommonPasswordList(t *testing.T) {
+	if n := CommonPasswordCount(); n < 50000 {
+		t.Errorf("Expected a list of tens of thousands of passwords, got %d", n)
+	}
+	if !sort.StringsAreSorted(commonPasswordList()) {
+		t.Error("Expected the embedded list to be sorted")
+	}
+}
+
+func TestIsCommonPassword(t *testing.T) {
+	for _, password := range []string{"password", "PASSWORD", "Monkey123", "dragon2000", "asdfghjkl", "15081987", "p@ssw0rd"} {
+		if !IsCommonPassword(password) {
+			t.Errorf("Expected %q to be common", password)
+		}
+	}
+	for _, password := range []string{"", "correct-horse-battery-staple", "xK9#mQ2$vL7p", "zebra-quartz-41"} {
+		if IsCommonPassword(password) {
+			t.Errorf("Expected %q not to be common", password)
+		}
+	}
+}
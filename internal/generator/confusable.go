@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// confusableNames spells out characters that are easily misread, in the
+// order they are reported
+var confusableNames = []struct {
+	char rune
+	name string
+}{
+	{'0', "zero"},
+	{'O', "capital O"},
+	{'o', "lowercase o"},
+	{'1', "one"},
+	{'l', "lowercase L"},
+	{'I', "capital i"},
+	{'|', "pipe"},
+}
+
+// Confusable is a misreadable character and where it occurs in a password
+type Confusable struct {
+	Char      rune
+	Name      string
+	Positions []int // 1-based character positions
+}
+
+// String formats the annotation, e.g. "0 (zero) at 3, 7"
+func (c Confusable) String() string {
+	positions := make([]string, len(c.Positions))
+	for i, pos := range c.Positions {
+		positions[i] = fmt.Sprint(pos)
+	}
+	return fmt.Sprintf("%c (%s) at %s", c.Char, c.Name, strings.Join(positions, ", "))
+}
+
+// FindConfusables lists the misreadable characters in password, such as
+// 0/O and 1/l/I, with their positions, so the password can be read aloud
+// or retyped without mistakes
+func FindConfusables(password string) []Confusable {
+	positions := make(map[rune][]int)
+	for i, r := range []rune(password) {
+		positions[r] = append(positions[r], i+1)
+	}
+
+	var found []Confusable
+	for _, c := range confusableNames {
+		if pos, ok := positions[c.char]; ok {
+			found = append(found, Confusable{Char: c.char, Name: c.name, Positions: pos})
+		}
+	}
+	return found
+}
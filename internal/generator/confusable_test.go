@@ -0,0 +1,22 @@
+package generator
+
+import "testing"
+
+func TestFindConfusables(t *testing.T) {
+	found := FindConfusables("a0bO10l")
+	if len(found) != 4 {
+		t.Fatalf("Expected 4 confusable characters, got %d: %v", len(found), found)
+	}
+
+	if found[0].Char != '0' || len(found[0].Positions) != 2 || found[0].Positions[1] != 6 {
+		t.Errorf("Expected zero at positions 2 and 6, got %v", found[0])
+	}
+
+	if got := found[0].String(); got != "0 (zero) at 2, 6" {
+		t.Errorf("Unexpected annotation %q", got)
+	}
+
+	if found := FindConfusables("abcdef"); len(found) != 0 {
+		t.Errorf("Expected no confusable characters, got %v", found)
+	}
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// secondsPerYear is the length of a year used by crack time estimates
+const secondsPerYear = 31536000.0
+
+// maxCrackYears is how far ahead CrackableYear looks before giving up
+const maxCrackYears = 1000
+
+// AttackerModel describes how fast an attacker can guess passwords
+type AttackerModel struct {
+	Name             string
+	Description      string
+	GuessesPerSecond float64
+}
+
+// DefaultAttacker is the attacker crack times assume unless another is
+// chosen: an offline attack on a fast hash at a billion guesses a second
+const DefaultAttacker = "offline"
+
+// attackerModels are the attacker models that can be chosen by name
+var attackerModels = map[string]AttackerModel{
+	"online":       {"online", "throttled online login", 10},
+	"offline-slow": {"offline-slow", "offline, slow hash such as bcrypt", 1e4},
+	"offline":      {"offline", "offline, fast hash on one GPU", 1e9},
+	"offline-fast": {"offline-fast", "offline, fast hash on a GPU cluster", 1e12},
+	"nation-state": {"nation-state", "massively parallel cracking hardware", 1e15},
+}
+
+// CrackEstimate is the crack time of a password for one attacker model
+type CrackEstimate struct {
+	Attacker AttackerModel
+	Time     string
+}
+
+// CrackTimes estimates the crack time of a password of the given entropy
+// for every attacker model, slowest attacker first
+func CrackTimes(entropy float64) []CrackEstimate {
+	names := AttackerNames()
+	estimates := make([]CrackEstimate, 0, len(names))
+	for _, name := range names {
+		model := attackerModels[name]
+		estimates = append(estimates, CrackEstimate{Attacker: model, Time: model.CrackTime(entropy)})
+	}
+	return estimates
+}
+
+// Attacker returns the attacker model with the given name
+func Attacker(name string) (AttackerModel, error) {
+	if name == "" {
+		name = DefaultAttacker
+	}
+	model, ok := attackerModels[strings.ToLower(name)]
+	if !ok {
+		return AttackerModel{}, fmt.Errorf("unknown attacker model %q (want %s)", name, strings.Join(AttackerNames(), ", "))
+	}
+	return model, nil
+}
+
+// AttackerNames returns the names of the attacker models, slowest first
+func AttackerNames() []string {
+	names := make([]string, 0, len(attackerModels))
+	for name := range attackerModels {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return attackerModels[names[i]].GuessesPerSecond < attackerModels[names[j]].GuessesPerSecond
+	})
+	return names
+}
+
+// CrackTime estimates the average time the attacker needs to guess a
+// password of the given entropy with today's hardware
+func (a AttackerModel) CrackTime(entropy float64) string {
+	if entropy <= 0 {
+		return "Instantly"
+	}
+
+	// Average case: half of the combinations are tried
+	seconds := math.Pow(2, entropy) / (2 * a.GuessesPerSecond)
+	return formatCrackSeconds(seconds)
+}
+
+// CrackableYear returns the calendar year in which the attacker is likely
+// to guess a password of the given entropy within a year of effort, if
+// their hardware doubles in speed every doublingYears. ok is false when
+// that is more than maxCrackYears after now, or doublingYears is not
+// positive.
+func (a AttackerModel) CrackableYear(entropy, doublingYears float64, now time.Time) (year int, ok bool) {
+	if doublingYears <= 0 || a.GuessesPerSecond <= 0 {
+		return 0, false
+	}
+
+	// A year of guessing with today's hardware covers
+	// log2(speed*secondsPerYear) bits; the average case needs entropy-1.
+	// Each doubling adds one bit, so the shortfall in bits times the
+	// doubling period is the wait. Working in bits avoids overflowing
+	// math.Pow for long passwords.
+	shortfall := entropy - 1 - math.Log2(a.GuessesPerSecond*secondsPerYear)
+	if shortfall <= 0 {
+		return now.Year(), true
+	}
+
+	wait := shortfall * doublingYears
+	if wait > maxCrackYears {
+		return 0, false
+	}
+	return now.Year() + int(math.Ceil(wait)), true
+}
+
+// CrackOutlook describes when a password of the given entropy becomes
+// crackable as hardware improves, e.g. "Likely crackable by 2061"
+func (a AttackerModel) CrackOutlook(entropy, doublingYears float64, now time.Time) string {
+	year, ok := a.CrackableYear(entropy, doublingYears, now)
+	switch {
+	case !ok:
+		return fmt.Sprintf("Not crackable within %d years", maxCrackYears)
+	case year <= now.Year():
+		return "Crackable today"
+	}
+	return fmt.Sprintf("Likely crackable by %d", year)
+}
+
+// formatCrackSeconds formats a crack time for display
+func formatCrackSeconds(seconds float64) string {
+	switch {
+	case seconds < 1:
+		return "Instantly"
+	case seconds < 60:
+		return "Less than a minute"
+	case seconds < 3600:
+		return fmt.Sprintf("%.0f minutes", seconds/60)
+	case seconds < 86400:
+		return fmt.Sprintf("%.0f hours", seconds/3600)
+	case seconds < 2592000: // 30 days
+		return fmt.Sprintf("%.0f days", seconds/86400)
+	case seconds < secondsPerYear:
+		return fmt.Sprintf("%.0f months", seconds/2592000)
+	case seconds < 1000*secondsPerYear:
+		return fmt.Sprintf("%.0f years", seconds/secondsPerYear)
+	default:
+		return "Centuries"
+	}
+}
@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttacker(t *testing.T) {
+	a, err := Attacker("")
+	if err != nil || a.Name != DefaultAttacker {
+		t.Fatalf("Expected the default attacker, got %v, %v", a, err)
+	}
+	if _, err := Attacker("quantum"); err == nil {
+		t.Error("Expected an error for an unknown attacker model")
+	}
+
+	names := AttackerNames()
+	if len(names) != len(attackerModels) || names[0] != "online" || names[len(names)-1] != "nation-state" {
+		t.Errorf("Expected attacker names slowest first, got %v", names)
+	}
+}
+
+func TestAttackerCrackTimeMatchesAnalyzer(t *testing.T) {
+	a, _ := Attacker(DefaultAttacker)
+	analyzer := NewSecurityAnalyzer()
+	for _, bits := range []float64{0, 20, 40, 60, 80, 128} {
+		if got, want := a.CrackTime(bits), analyzer.estimateCrackTime(bits); got != want {
+			t.Errorf("CrackTime(%v) = %q, analyzer says %q", bits, got, want)
+		}
+	}
+}
+
+func TestCrackTimes(t *testing.T) {
+	estimates := CrackTimes(60)
+	if len(estimates) != len(attackerModels) {
+		t.Fatalf("Expected an estimate per attacker model, got %d", len(estimates))
+	}
+	for i, estimate := range estimates {
+		if want := estimate.Attacker.CrackTime(60); estimate.Time != want {
+			t.Errorf("%s: Time = %q, want %q", estimate.Attacker.Name, estimate.Time, want)
+		}
+		if i > 0 && estimate.Attacker.GuessesPerSecond <= estimates[i-1].Attacker.GuessesPerSecond {
+			t.Errorf("Expected estimates slowest attacker first, got %s after %s",
+				estimate.Attacker.Name, estimates[i-1].Attacker.Name)
+		}
+	}
+	if estimates[0].Time == estimates[len(estimates)-1].Time {
+		t.Errorf("Expected the attackers to differ, both say %q", estimates[0].Time)
+	}
+}
+
+func TestAnalyzerSetAttacker(t *testing.T) {
+	analyzer := NewSecurityAnalyzer()
+	if err := analyzer.SetAttacker("quantum"); err == nil {
+		t.Error("Expected an error for an unknown attacker model")
+	}
+	if err := analyzer.SetAttacker("online"); err != nil {
+		t.Fatalf("SetAttacker() error = %v", err)
+	}
+
+	online, _ := Attacker("online")
+	analysis := analyzer.Analyze("correct-horse")
+	if want := online.CrackTime(analysis.Entropy); analysis.CrackTime != want {
+		t.Errorf("CrackTime = %q, want the online attacker's %q", analysis.CrackTime, want)
+	}
+	if len(analysis.CrackTimes) != len(attackerModels) {
+		t.Errorf("Expected CrackTimes for every attacker model, got %d", len(analysis.CrackTimes))
+	}
+}
+
+func TestCrackableYear(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a, _ := Attacker("offline")
+
+	// 1e9 guesses/s for a year covers about 54.8 bits
+	if year, ok := a.CrackableYear(40, 2, now); !ok || year != 2026 {
+		t.Errorf("Expected 40 bits to be crackable now, got %d, %v", year, ok)
+	}
+
+	// 70 bits is about 14.2 bits short: 15 doublings of one year each
+	year, ok := a.CrackableYear(70, 1, now)
+	if !ok || year != 2041 {
+		t.Errorf("Expected 70 bits crackable in 2041, got %d, %v", year, ok)
+	}
+	// Slower improvement pushes the year out proportionally
+	if later, _ := a.CrackableYear(70, 2, now); later != 2055 {
+		t.Errorf("Expected 70 bits crackable in 2055 with 2-year doubling, got %d", later)
+	}
+
+	if _, ok := a.CrackableYear(700, 2, now); ok {
+		t.Error("Expected 700 bits to stay out of reach")
+	}
+	if _, ok := a.CrackableYear(70, 0, now); ok {
+		t.Error("Expected no year without hardware improvement")
+	}
+}
+
+func TestCrackOutlook(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	a, _ := Attacker("offline")
+
+	if got := a.CrackOutlook(30, 2, now); got != "Crackable today" {
+		t.Errorf("Expected crackable today, got %q", got)
+	}
+	if got := a.CrackOutlook(70, 2, now); !strings.Contains(got, "by 2055") {
+		t.Errorf("Expected a calendar year, got %q", got)
+	}
+	if got := a.CrackOutlook(2000, 2, now); !strings.HasPrefix(got, "Not crackable") {
+		t.Errorf("Expected out of reach, got %q", got)
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // Example demonstrates how to use all the password generators
-func ExampleGenerators() {
+func Example_generators() {
 	ctx := context.Background()
 	analyzer := NewSecurityAnalyzer()
 
@@ -88,7 +88,7 @@ func ExampleGenerators() {
 }
 
 // Example shows how to use custom character sets
-func ExampleCustomCharSets() {
+func Example_customCharSets() {
 	ctx := context.Background()
 	
 	// Password with only alphanumeric (no symbols)
@@ -110,7 +110,7 @@ func ExampleCustomCharSets() {
 }
 
 // Example shows different memorable passphrase configurations
-func ExampleMemorableVariations() {
+func Example_memorableVariations() {
 	ctx := context.Background()
 	wordlist := GetEFFWordlist()
 	
@@ -36,6 +36,12 @@ const (
 	Numbers
 	Symbols
 	Ambiguous // Characters that can be confused (0, O, l, 1, etc.)
+
+	// Locale letters, opt-in because many sites reject non-ASCII
+	German  // äöüß
+	French  // éàçœ and other French accents
+	Spanish // áéíñóú
+	Nordic  // åæø
 )
 
 // SecurityLevel represents password strength levels
@@ -54,7 +60,8 @@ const (
 type SecurityAnalysis struct {
 	Entropy       float64
 	Level         SecurityLevel
-	CrackTime     string
+	CrackTime     string          // For the analyzer's attacker model
+	CrackTimes    []CrackEstimate // For every attacker model, slowest first
 	Feedback      []string
 	CharsetSize   int
 	HasLowercase  bool
@@ -64,4 +71,5 @@ type SecurityAnalysis struct {
 	HasAmbiguous  bool
 	CommonWords   []string
 	IsCompromised bool
+	Segments      []Segment // The password's patterns and random parts, in order
 }
@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestKeyGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		encoding KeyEncoding
+		decode   func(string) ([]byte, error)
+		wantErr  bool
+	}{
+		{"Default hex", 0, KeyHex, hex.DecodeString, false},
+		{"128-bit base64", 16, KeyBase64, base64.StdEncoding.DecodeString, false},
+		{"512-bit base64url", 64, KeyBase64URL, base64.RawURLEncoding.DecodeString, false},
+		{"Negative size", -1, KeyHex, nil, true},
+		{"Too large", 2048, KeyHex, nil, true},
+		{"Unknown encoding", 32, KeyEncoding(42), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewKeyGenerator(tt.size, tt.encoding)
+			key, err := gen.Generate(context.Background())
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			raw, err := tt.decode(key)
+			if err != nil {
+				t.Fatalf("Key %s does not decode: %v", key, err)
+			}
+
+			wantSize := tt.size
+			if wantSize == 0 {
+				wantSize = DefaultKeyBytes
+			}
+			if len(raw) != wantSize {
+				t.Errorf("Expected %d raw bytes, got %d", wantSize, len(raw))
+			}
+			if gen.EstimateEntropy() != float64(wantSize*8) {
+				t.Errorf("Expected %d bits of entropy, got %.0f", wantSize*8, gen.EstimateEntropy())
+			}
+		})
+	}
+}
+
+func TestParseKeyEncoding(t *testing.T) {
+	for _, encoding := range KeyEncodings() {
+		parsed, err := ParseKeyEncoding(encoding.String())
+		if err != nil || parsed != encoding {
+			t.Errorf("ParseKeyEncoding(%s) = %v, %v", encoding, parsed, err)
+		}
+	}
+
+	if parsed, err := ParseKeyEncoding("HEX"); err != nil || parsed != KeyHex {
+		t.Errorf("Expected case-insensitive match, got %v, %v", parsed, err)
+	}
+
+	if _, err := ParseKeyEncoding("base32"); err == nil {
+		t.Error("Expected error for unknown encoding")
+	}
+}
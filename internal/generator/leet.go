@@ -0,0 +1,168 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LeetMode controls how leet-speak substitutions are applied to passphrases
+type LeetMode int
+
+const (
+	LeetOff    LeetMode = iota // No substitutions
+	LeetAll                    // Substitute every matching character
+	LeetRandom                 // Substitute each matching character with probability 1/2
+)
+
+// DefaultLeetSubstitutions is the default substitution spec
+const DefaultLeetSubstitutions = "a=@,e=3,i=1,o=0,s=$"
+
+// LeetModes lists all leet modes in display order
+func LeetModes() []LeetMode {
+	return []LeetMode{LeetOff, LeetAll, LeetRandom}
+}
+
+// String returns the mode name as accepted by ParseLeetMode
+func (l LeetMode) String() string {
+	switch l {
+	case LeetAll:
+		return "all"
+	case LeetRandom:
+		return "random"
+	default:
+		return "off"
+	}
+}
+
+// ParseLeetMode converts a mode name to a LeetMode
+func ParseLeetMode(name string) (LeetMode, error) {
+	for _, mode := range LeetModes() {
+		if strings.EqualFold(name, mode.String()) {
+			return mode, nil
+		}
+	}
+	return LeetOff, fmt.Errorf("unknown leet mode %q (use off, all or random)", name)
+}
+
+// LeetTransform applies character substitutions to a generated passphrase
+type LeetTransform struct {
+	Mode          LeetMode
+	Substitutions map[rune]rune
+}
+
+// NewLeetTransform creates a transform from a spec such as "a=@,e=3"
+func NewLeetTransform(mode LeetMode, spec string) (LeetTransform, error) {
+	substitutions, err := ParseLeetSubstitutions(spec)
+	if err != nil {
+		return LeetTransform{}, err
+	}
+	return LeetTransform{Mode: mode, Substitutions: substitutions}, nil
+}
+
+// ParseLeetSubstitutions parses a comma-separated list of from=to pairs.
+// Each side must be a single character and every target must be distinct
+// from all sources, so the substitution can be undone unambiguously.
+func ParseLeetSubstitutions(spec string) (map[rune]rune, error) {
+	substitutions := make(map[rune]rune)
+	if strings.TrimSpace(spec) == "" {
+		return substitutions, nil
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || utf8.RuneCountInString(from) != 1 || utf8.RuneCountInString(to) != 1 {
+			return nil, fmt.Errorf("invalid leet substitution %q (expected from=to)", pair)
+		}
+
+		fromRune, _ := utf8.DecodeRuneInString(from)
+		toRune, _ := utf8.DecodeRuneInString(to)
+		if _, exists := substitutions[fromRune]; exists {
+			return nil, fmt.Errorf("duplicate leet substitution for %q", from)
+		}
+		substitutions[fromRune] = toRune
+	}
+
+	for _, to := range substitutions {
+		if _, isSource := substitutions[to]; isSource {
+			return nil, fmt.Errorf("leet substitution target %q is also a source", to)
+		}
+	}
+
+	return substitutions, nil
+}
+
+// Apply substitutes characters in s according to the mode
+func (l LeetTransform) Apply(s string) (string, error) {
+	return l.apply(nil, s)
+}
+
+// apply substitutes characters in s, drawing random choices from src
+func (l LeetTransform) apply(src io.Reader, s string) (string, error) {
+	if l.Mode == LeetOff || len(l.Substitutions) == 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		to, ok := l.Substitutions[r]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		if l.Mode == LeetRandom {
+			coin, err := randomInt(src, big.NewInt(2))
+			if err != nil {
+				return "", fmt.Errorf("failed to generate random number: %w", err)
+			}
+			if coin.Int64() == 0 {
+				b.WriteRune(r)
+				continue
+			}
+		}
+		b.WriteRune(to)
+	}
+
+	return b.String(), nil
+}
+
+// ExtraEntropy returns the entropy added by the transform for a passphrase
+// of wordCount words from wordlist, capitalized or not. Substituting every
+// match is a fixed rule an attacker will try, so it adds nothing. Random
+// substitution adds one bit per substitutable character, averaged over the
+// wordlist; the first letter of a capitalized word only counts if its
+// capital is substituted.
+func (l LeetTransform) ExtraEntropy(wordlist []string, wordCount int, capitalize bool) float64 {
+	if l.Mode != LeetRandom || len(wordlist) == 0 {
+		return 0
+	}
+
+	matches := 0
+	for _, word := range wordlist {
+		for i, r := range word {
+			if i == 0 && capitalize {
+				r = unicode.ToUpper(r)
+			}
+			if _, ok := l.Substitutions[r]; ok {
+				matches++
+			}
+		}
+	}
+
+	return float64(matches) / float64(len(wordlist)) * float64(wordCount)
+}
+
+// String formats the substitutions back into spec form, sorted by source
+func (l LeetTransform) String() string {
+	pairs := make([]string, 0, len(l.Substitutions))
+	for from, to := range l.Substitutions {
+		pairs = append(pairs, string(from)+"="+string(to))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
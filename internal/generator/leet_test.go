@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseLeetSubstitutions(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    int
+		wantErr bool
+	}{
+		{"Default", DefaultLeetSubstitutions, 5, false},
+		{"Empty", "", 0, false},
+		{"Spaces", " a=@ , e=3 ", 2, false},
+		{"Missing target", "a=", 0, true},
+		{"Multi-character", "a=@@", 0, true},
+		{"Duplicate source", "a=@,a=4", 0, true},
+		{"Chained", "a=e,e=3", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs, err := ParseLeetSubstitutions(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLeetSubstitutions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(subs) != tt.want {
+				t.Errorf("Expected %d substitutions, got %d", tt.want, len(subs))
+			}
+		})
+	}
+}
+
+func TestLeetTransformApply(t *testing.T) {
+	all, err := NewLeetTransform(LeetAll, DefaultLeetSubstitutions)
+	if err != nil {
+		t.Fatalf("NewLeetTransform() error = %v", err)
+	}
+
+	got, err := all.Apply("passphrase items")
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got != "p@$$phr@$3 1t3m$" {
+		t.Errorf("Expected p@$$phr@$3 1t3m$, got %s", got)
+	}
+
+	random, _ := NewLeetTransform(LeetRandom, "a=@")
+	sawPlain, sawLeet := false, false
+	for i := 0; i < 100; i++ {
+		got, err := random.Apply("a")
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		sawPlain = sawPlain || got == "a"
+		sawLeet = sawLeet || got == "@"
+	}
+	if !sawPlain || !sawLeet {
+		t.Error("Random mode should produce both substituted and original characters")
+	}
+
+	off, _ := NewLeetTransform(LeetOff, DefaultLeetSubstitutions)
+	if got, _ := off.Apply("passphrase"); got != "passphrase" {
+		t.Errorf("Off mode changed the input: %s", got)
+	}
+}
+
+func TestLeetTransformExtraEntropy(t *testing.T) {
+	wordlist := []string{"aa", "bb"} // One substitutable char per word on average
+
+	all, _ := NewLeetTransform(LeetAll, "a=@")
+	if extra := all.ExtraEntropy(wordlist, 4, false); extra != 0 {
+		t.Errorf("Deterministic substitution should add no entropy, got %.2f", extra)
+	}
+
+	random, _ := NewLeetTransform(LeetRandom, "a=@")
+	if extra := random.ExtraEntropy(wordlist, 4, false); math.Abs(extra-4) > 1e-9 {
+		t.Errorf("Expected 4 extra bits, got %.2f", extra)
+	}
+
+	// Capitalized, "aa" becomes "Aa": only its second letter is substituted
+	if extra := random.ExtraEntropy(wordlist, 4, true); math.Abs(extra-2) > 1e-9 {
+		t.Errorf("Expected 2 extra bits for capitalized words, got %.2f", extra)
+	}
+	capitals, _ := NewLeetTransform(LeetRandom, "a=@,A=4")
+	if extra := capitals.ExtraEntropy(wordlist, 4, true); math.Abs(extra-4) > 1e-9 {
+		t.Errorf("Expected 4 extra bits when capitals are substituted, got %.2f", extra)
+	}
+}
+
+func TestMemorableGeneratorLeet(t *testing.T) {
+	gen := NewMemorableGenerator(4, " ", GetEFFWordlist())
+	base := gen.EstimateEntropy()
+
+	leet, _ := NewLeetTransform(LeetAll, DefaultLeetSubstitutions)
+	gen.SetLeet(leet)
+
+	passphrase, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if strings.ContainsAny(passphrase, "aeios") {
+		t.Errorf("Passphrase %q still contains substitutable characters", passphrase)
+	}
+	if gen.EstimateEntropy() != base {
+		t.Errorf("LeetAll should not change entropy: %.2f != %.2f", gen.EstimateEntropy(), base)
+	}
+
+	leet.Mode = LeetRandom
+	gen.SetLeet(leet)
+	if gen.EstimateEntropy() <= base {
+		t.Errorf("LeetRandom should add entropy: %.2f <= %.2f", gen.EstimateEntropy(), base)
+	}
+}
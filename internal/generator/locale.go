@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// localeLetters are the letters of each locale charset. They are only used
+// when asked for: many sites and systems reject or mangle non-ASCII
+// passwords.
+var localeLetters = map[CharSet]string{
+	German:  "äöüßÄÖÜ",
+	French:  "àâæçéèêëîïôœùûüÿÀÂÆÇÉÈÊËÎÏÔŒÙÛÜŸ",
+	Spanish: "áéíñóúüÁÉÍÑÓÚÜ",
+	Nordic:  "åäæöøÅÄÆÖØ",
+}
+
+// LocaleCharSets lists the locale charsets in display order
+var LocaleCharSets = []CharSet{German, French, Spanish, Nordic}
+
+// NonASCIIWarning is shown whenever passwords may contain locale letters
+const NonASCIIWarning = "Non-ASCII letters: many sites reject them or store them differently; test the password before relying on it"
+
+// ParseLocaleCharSet returns the locale charset with the given name, e.g.
+// "german"
+func ParseLocaleCharSet(name string) (CharSet, error) {
+	for _, cs := range LocaleCharSets {
+		if strings.EqualFold(name, CharSetToString(cs)) {
+			return cs, nil
+		}
+	}
+
+	names := make([]string, len(LocaleCharSets))
+	for i, cs := range LocaleCharSets {
+		names[i] = CharSetToString(cs)
+	}
+	return 0, fmt.Errorf("unknown locale charset %q (use %s)", name, strings.Join(names, ", "))
+}
+
+// IsLocale reports whether cs is a locale charset
+func (cs CharSet) IsLocale() bool {
+	_, ok := localeLetters[cs]
+	return ok
+}
+
+// UsesNonASCII reports whether passwords from r may contain characters
+// outside ASCII
+func (r *RandomGenerator) UsesNonASCII() bool {
+	return !isASCII(r.buildCharset())
+}
+
+// isASCII reports whether s contains only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
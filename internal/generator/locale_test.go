@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLocaleGenerationIsRuneBased(t *testing.T) {
+	gen := NewRandomGenerator(20, Lowercase, German)
+	for i := 0; i < 20; i++ {
+		password, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if !utf8.ValidString(password) {
+			t.Fatalf("Expected valid UTF-8, got %q", password)
+		}
+		if n := utf8.RuneCountInString(password); n != 20 {
+			t.Fatalf("Expected 20 characters, got %d in %q", n, password)
+		}
+		if !strings.ContainsAny(password, localeLetters[German]) {
+			t.Errorf("Expected a German letter in %q", password)
+		}
+	}
+}
+
+func TestLocaleEntropyCountsRunes(t *testing.T) {
+	gen := NewRandomGenerator(16, Lowercase, French)
+	size := 26 + utf8.RuneCountInString(localeLetters[French])
+	want := 16 * math.Log2(float64(size))
+	if got := gen.EstimateEntropy(); math.Abs(got-want) > 0.01 {
+		t.Errorf("Expected entropy %.2f, got %.2f", want, got)
+	}
+}
+
+func TestParseLocaleCharSet(t *testing.T) {
+	for _, cs := range LocaleCharSets {
+		got, err := ParseLocaleCharSet(strings.ToUpper(CharSetToString(cs)))
+		if err != nil || got != cs {
+			t.Errorf("ParseLocaleCharSet(%q) = %v, %v", CharSetToString(cs), got, err)
+		}
+		if !cs.IsLocale() {
+			t.Errorf("Expected %s to be a locale charset", CharSetToString(cs))
+		}
+	}
+	if _, err := ParseLocaleCharSet("klingon"); err == nil {
+		t.Error("Expected an error for an unknown locale")
+	}
+	if Lowercase.IsLocale() {
+		t.Error("Lowercase is not a locale charset")
+	}
+}
+
+func TestUsesNonASCII(t *testing.T) {
+	if NewRandomGenerator(12, Lowercase, Symbols).UsesNonASCII() {
+		t.Error("ASCII charsets should not report non-ASCII")
+	}
+	if !NewRandomGenerator(12, Lowercase, Nordic).UsesNonASCII() {
+		t.Error("Nordic letters should report non-ASCII")
+	}
+}
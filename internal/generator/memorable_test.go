@@ -127,8 +127,8 @@ func TestMemorableGeneratorEntropy(t *testing.T) {
 func TestMemorableGeneratorEFFWordlist(t *testing.T) {
 	wordlist := GetEFFWordlist()
 	
-	if len(wordlist) == 0 {
-		t.Error("EFF wordlist should not be empty")
+	if len(wordlist) != 7776 {
+		t.Errorf("EFF wordlist should contain 7776 words, got %d", len(wordlist))
 	}
 	
 	// Check that words are reasonable length
@@ -220,3 +220,137 @@ func containsString(slice []string, item string) bool {
 	}
 	return false
 }
+
+func TestEstimateMemorability(t *testing.T) {
+	short := EstimateMemorability([]string{"cat", "dog", "sun", "hat"})
+	long := EstimateMemorability([]string{"abdominal", "arbitrary", "anesthetic", "appreciate"})
+	hyphen := EstimateMemorability([]string{"cat", "dog", "sun", "yo-yo"})
+
+	if short != 100 {
+		t.Errorf("Expected short common words to score 100, got %d", short)
+	}
+	if long >= short {
+		t.Errorf("Expected long words (%d) to score below short words (%d)", long, short)
+	}
+	if hyphen >= short {
+		t.Errorf("Expected hyphenated words (%d) to score below plain words (%d)", hyphen, short)
+	}
+	if score := EstimateMemorability(nil); score != 0 {
+		t.Errorf("Expected empty passphrase to score 0, got %d", score)
+	}
+	if long < 0 || long > 100 {
+		t.Errorf("Score %d out of range", long)
+	}
+}
+
+func TestMemorableGeneratorTypoRobust(t *testing.T) {
+	gen := NewMemorableGenerator(6, " ", GetEFFWordlist())
+	gen.SetTypoRobust(true)
+
+	for i := 0; i < 50; i++ {
+		passphrase, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+
+		words := strings.Fields(passphrase)
+		if len(words) != 6 {
+			t.Fatalf("Expected 6 words, got %d", len(words))
+		}
+
+		for j, word := range words {
+			if isHomophone(word) {
+				t.Errorf("Passphrase %q contains confusable word %q", passphrase, word)
+			}
+			if !isTypoDistant(word, words[:j]) {
+				t.Errorf("Passphrase %q has words closer than %d edits", passphrase, MinTypoEditDistance)
+			}
+		}
+	}
+}
+
+func TestMemorableGeneratorTypoRobustEntropy(t *testing.T) {
+	gen := NewMemorableGenerator(4, " ", GetEFFWordlist())
+	full := gen.EstimateEntropy()
+
+	gen.SetTypoRobust(true)
+	robust := gen.EstimateEntropy()
+
+	if robust >= full {
+		t.Errorf("Expected typo-robust entropy %.2f to be below %.2f", robust, full)
+	}
+	if full-robust > 1 {
+		t.Errorf("Typo-robust filtering should cost under 1 bit for 4 EFF words, cost %.2f", full-robust)
+	}
+}
+
+func TestEditDistanceBelow(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  bool
+	}{
+		{"their", "there", 3, true},
+		{"kitten", "sitting", 3, false},
+		{"kitten", "sitting", 4, true},
+		{"abc", "abc", 1, true},
+		{"short", "muchlongerword", 3, false},
+		{"", "ab", 3, true},
+	}
+
+	for _, tt := range tests {
+		if got := editDistanceBelow(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistanceBelow(%q, %q, %d) = %v, want %v", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestMemorableGeneratorNoSeparator(t *testing.T) {
+	gen := NewMemorableGenerator(3, SeparatorNone, make100("apple"))
+
+	passphrase, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if passphrase != "appleappleapple" {
+		t.Errorf("Generate() = %q, want the words joined directly", passphrase)
+	}
+}
+
+func TestMemorableGeneratorCapitalizeAndDigit(t *testing.T) {
+	gen := NewMemorableGenerator(4, "-", make100("apple"))
+	plain := gen.EstimateEntropy()
+	gen.SetCapitalize(true)
+	gen.SetDigit(true)
+
+	for i := 0; i < 20; i++ {
+		passphrase, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		words := strings.Split(passphrase, "-")
+		digits := 0
+		for _, word := range words {
+			if !strings.HasPrefix(word, "Apple") {
+				t.Fatalf("Generate() = %q, want capitalized words", passphrase)
+			}
+			digits += len(word) - len("Apple")
+		}
+		if digits != 1 {
+			t.Fatalf("Generate() = %q, want exactly one digit", passphrase)
+		}
+	}
+
+	if got, want := gen.EstimateEntropy()-plain, logBase2(40); got < want-0.01 || got > want+0.01 {
+		t.Errorf("digit adds %.2f bits, want %.2f", got, want)
+	}
+}
+
+// make100 returns a wordlist of 100 copies of word, the smallest valid size
+func make100(word string) []string {
+	wordlist := make([]string, 100)
+	for i := range wordlist {
+		wordlist[i] = word
+	}
+	return wordlist
+}
@@ -2,16 +2,40 @@ package generator
 
 import (
 	"context"
-	"crypto/rand"
 	"errors"
 	"fmt"
-	"math/big"
+	"io"
 	"strings"
 )
 
 // PINGenerator generates numeric PIN codes
 type PINGenerator struct {
-	config Config
+	config    Config
+	groupSize int    // Digits per group of a formatted PIN, 0 for none
+	source    io.Reader // Randomness; nil uses crypto/rand
+}
+
+func init() {
+	Register(Registration{
+		Name:        "pin",
+		Title:       "PIN Code",
+		Description: "Random digits",
+		Options:     []Option{OptionLength, OptionGroupSize, OptionSeparator},
+		Order:       30,
+		New: func(opts Options) (Generator, error) {
+			length := opts.Length
+			if length == 0 {
+				length = 6
+			}
+			separator := opts.Separator
+			if separator == "" {
+				separator = "-"
+			}
+			gen := NewPINGenerator(length)
+			gen.SetFormat(opts.GroupSize, separator)
+			return gen, nil
+		},
+	})
 }
 
 // NewPINGenerator creates a new PIN generator
@@ -23,14 +47,39 @@ func NewPINGenerator(length int) *PINGenerator {
 	}
 }
 
-// Generate creates a cryptographically secure numeric PIN
+// SetRandomSource replaces the source of randomness, e.g. with fixed bytes
+// in tests. A nil source restores the default.
+func (p *PINGenerator) SetRandomSource(src io.Reader) {
+	p.source = src
+}
+
+// SetFormat makes Generate split PINs into groups of groupSize digits
+// joined by separator, e.g. "1234-5678". A group size of 0 or an empty
+// separator leaves PINs unformatted.
+func (p *PINGenerator) SetFormat(groupSize int, separator string) {
+	p.groupSize = groupSize
+	p.config.Separator = separator
+}
+
+// Generate creates a cryptographically secure numeric PIN, formatted as
+// set with SetFormat
 func (p *PINGenerator) Generate(ctx context.Context) (string, error) {
+	pin, err := p.digits(ctx)
+	if err != nil {
+		return "", err
+	}
+	return formatPIN(pin, p.config.Separator, p.groupSize), nil
+}
+
+// digits creates the digits of a PIN
+func (p *PINGenerator) digits(ctx context.Context) (string, error) {
 	if err := p.Validate(); err != nil {
 		return "", err
 	}
 
 	pin := make([]byte, p.config.Length)
-	ten := big.NewInt(10)
+	rng := newSampler(p.source)
+	defer rng.release()
 
 	for i := 0; i < p.config.Length; i++ {
 		select {
@@ -41,13 +90,13 @@ func (p *PINGenerator) Generate(ctx context.Context) (string, error) {
 		default:
 		}
 
-		randomDigit, err := rand.Int(rand.Reader, ten)
+		randomDigit, err := rng.intn(10)
 		if err != nil {
 			clearBytes(pin[:i])
 			return "", fmt.Errorf("failed to generate random number: %w", err)
 		}
 		
-		pin[i] = byte('0' + randomDigit.Int64())
+		pin[i] = byte('0' + randomDigit)
 	}
 
 	result := string(pin)
@@ -58,13 +107,17 @@ func (p *PINGenerator) Generate(ctx context.Context) (string, error) {
 
 // GenerateFormatted creates a PIN with optional formatting (e.g., "1234-5678")
 func (p *PINGenerator) GenerateFormatted(ctx context.Context, separator string, groupSize int) (string, error) {
-	pin, err := p.Generate(ctx)
+	pin, err := p.digits(ctx)
 	if err != nil {
 		return "", err
 	}
-	
+	return formatPIN(pin, separator, groupSize), nil
+}
+
+// formatPIN splits pin into groups of groupSize digits joined by separator
+func formatPIN(pin, separator string, groupSize int) string {
 	if separator == "" || groupSize <= 0 || groupSize >= len(pin) {
-		return pin, nil
+		return pin
 	}
 	
 	var formatted strings.Builder
@@ -74,8 +127,7 @@ func (p *PINGenerator) GenerateFormatted(ctx context.Context, separator string,
 		}
 		formatted.WriteRune(digit)
 	}
-	
-	return formatted.String(), nil
+	return formatted.String()
 }
 
 // EstimateEntropy calculates the theoretical entropy for numeric PINs
@@ -98,6 +150,14 @@ func (p *PINGenerator) Validate() error {
 	if p.config.Length > 50 {
 		return errors.New("PIN length too long (max 50)")
 	}
+
+	if p.groupSize < 0 {
+		return errors.New("PIN group size cannot be negative")
+	}
+
+	if strings.ContainsAny(p.config.Separator, "0123456789") {
+		return errors.New("PIN separator cannot contain digits")
+	}
 	
 	return nil
 }
@@ -239,3 +239,25 @@ func TestPINGeneratorContextCancellation(t *testing.T) {
 		t.Errorf("Expected context.Canceled, got %v", err)
 	}
 }
+
+func TestPINGeneratorSetFormat(t *testing.T) {
+	gen := NewPINGenerator(8)
+	gen.SetFormat(4, "-")
+	entropy := gen.EstimateEntropy()
+
+	pin, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(pin) != 9 || pin[4] != '-' {
+		t.Errorf("Generate() = %q, want 1234-5678 style", pin)
+	}
+	if gen.EstimateEntropy() != entropy {
+		t.Error("formatting changed the estimated entropy")
+	}
+
+	gen.SetFormat(4, "1")
+	if err := gen.Validate(); err == nil {
+		t.Error("Validate() accepted a digit as separator")
+	}
+}
@@ -2,16 +2,39 @@ package generator
 
 import (
 	"context"
-	"crypto/rand"
 	"errors"
 	"fmt"
-	"math/big"
+	"io"
+	"math"
+	"math/bits"
 	"strings"
 )
 
 // RandomGenerator generates cryptographically secure random passwords
 type RandomGenerator struct {
-	config Config
+	config   Config
+	source   io.Reader      // Randomness; nil uses crypto/rand
+	charsets []string       // Enabled sets, less excluded characters
+	alphabet randomAlphabet // Their characters, each once
+}
+
+func init() {
+	Register(Registration{
+		Name:        "random",
+		Title:       "Random Password",
+		Description: "Random characters from the chosen character sets",
+		Options:     []Option{OptionLength, OptionCharSets, OptionExclude},
+		Order:       10,
+		New: func(opts Options) (Generator, error) {
+			length := opts.Length
+			if length == 0 {
+				length = 16
+			}
+			gen := NewRandomGenerator(length, opts.CharSets...)
+			gen.SetExcludeChars(opts.Exclude)
+			return gen, nil
+		},
+	})
 }
 
 // NewRandomGenerator creates a new random password generator
@@ -20,12 +43,20 @@ func NewRandomGenerator(length int, charSets ...CharSet) *RandomGenerator {
 		charSets = []CharSet{Lowercase, Uppercase, Numbers}
 	}
 	
-	return &RandomGenerator{
+	gen := &RandomGenerator{
 		config: Config{
 			Length:   length,
 			CharSets: charSets,
 		},
 	}
+	gen.buildAlphabet()
+	return gen
+}
+
+// SetRandomSource replaces the source of randomness, e.g. with fixed bytes
+// in tests. A nil source restores the default.
+func (r *RandomGenerator) SetRandomSource(src io.Reader) {
+	r.source = src
 }
 
 // Generate creates a cryptographically secure random password
@@ -34,8 +65,7 @@ func (r *RandomGenerator) Generate(ctx context.Context) (string, error) {
 		return "", err
 	}
 
-	// Build individual charsets for each enabled character type
-	charsets := r.buildIndividualCharsets()
+	charsets, alphabet := r.charsets, r.alphabet
 	if len(charsets) == 0 {
 		return "", errors.New("no valid character sets")
 	}
@@ -46,78 +76,67 @@ func (r *RandomGenerator) Generate(ctx context.Context) (string, error) {
 		return "", errors.New("password length must be at least equal to number of enabled character types")
 	}
 
-	password := make([]byte, r.config.Length)
-
-	// First, ensure at least one character from each enabled character set
-	for i, charset := range charsets {
-		select {
-		case <-ctx.Done():
-			clearBytes(password[:i])
-			return "", ctx.Err()
-		default:
-		}
-
-		if len(charset) == 0 {
-			continue
-		}
-
-		charsetSize := big.NewInt(int64(len(charset)))
-		randomIndex, err := rand.Int(rand.Reader, charsetSize)
-		if err != nil {
-			clearBytes(password[:i])
-			return "", fmt.Errorf("failed to generate random number: %w", err)
-		}
-		
-		password[i] = charset[randomIndex.Int64()]
-	}
-
-	// Fill the remaining positions with random characters from all charsets
-	fullCharset := r.buildCharset()
-	if len(fullCharset) == 0 {
-		clearBytes(password)
+	if len(alphabet.runes) == 0 {
 		return "", errors.New("no valid characters in charset")
 	}
 
-	fullCharsetSize := big.NewInt(int64(len(fullCharset)))
-	
-	for i := len(charsets); i < r.config.Length; i++ {
+	// Runes rather than bytes, so locale letters are never split
+	password := make([]rune, r.config.Length)
+
+	// One buffered sampler serves every draw
+	rng := newSampler(r.source)
+	defer rng.release()
+
+	// Every enabled set must appear, so draw whole passwords from the
+	// full charset until one has them all. Unlike placing one character
+	// of each set and shuffling, this keeps every valid password equally
+	// likely, and EstimateEntropy can count them exactly. Most draws
+	// succeed at once, so the cap is only worked out on a miss.
+	maxAttempts := 1
+	for attempt := 0; attempt < maxAttempts; attempt++ {
 		select {
 		case <-ctx.Done():
-			clearBytes(password[:i])
+			clearRunes(password)
 			return "", ctx.Err()
 		default:
 		}
 
-		randomIndex, err := rand.Int(rand.Reader, fullCharsetSize)
-		if err != nil {
-			clearBytes(password[:i])
-			return "", fmt.Errorf("failed to generate random number: %w", err)
+		var covered uint
+		for i := range password {
+			randomIndex, err := rng.intn(len(alphabet.runes))
+			if err != nil {
+				clearRunes(password)
+				return "", fmt.Errorf("failed to generate random number: %w", err)
+			}
+			password[i] = alphabet.runes[randomIndex]
+			covered |= alphabet.sets[randomIndex]
+		}
+		if covered == alphabet.all {
+			result := string(password)
+			clearRunes(password) // Clear sensitive data from memory
+			return result, nil
+		}
+		if attempt == 0 {
+			maxAttempts = alphabet.maxAttempts(r.config.Length)
 		}
-		
-		password[i] = fullCharset[randomIndex.Int64()]
-	}
-
-	// Shuffle the password to randomize the positions
-	err := r.shufflePassword(password)
-	if err != nil {
-		clearBytes(password)
-		return "", fmt.Errorf("failed to shuffle password: %w", err)
 	}
 
-	result := string(password)
-	clearBytes(password) // Clear sensitive data from memory
-	
-	return result, nil
+	clearRunes(password)
+	return "", errors.New("random source never produced every character set")
 }
-
-// EstimateEntropy calculates the theoretical entropy for random passwords
+// EstimateEntropy calculates the entropy of random passwords exactly: the
+// log of how many passwords of the length use every enabled set, as each
+// is equally likely
 func (r *RandomGenerator) EstimateEntropy() float64 {
-	charset := r.buildCharset()
-	if len(charset) == 0 {
+	if len(r.alphabet.runes) == 0 || r.config.Length <= 0 {
 		return 0
 	}
-	
-	return float64(r.config.Length) * logBase2(float64(len(charset)))
+
+	entropy := float64(r.config.Length) * logBase2(float64(len(r.alphabet.runes)))
+	if fraction := r.alphabet.coveredFraction(r.config.Length); fraction > 0 {
+		entropy += logBase2(fraction)
+	}
+	return entropy
 }
 
 // GetName returns the generator name
@@ -145,6 +164,7 @@ func (r *RandomGenerator) Validate() error {
 // SetExcludeChars sets characters to exclude from generation
 func (r *RandomGenerator) SetExcludeChars(chars string) {
 	r.config.ExcludeChar = chars
+	r.buildAlphabet()
 }
 
 // buildIndividualCharsets builds separate charsets for each enabled character type
@@ -164,6 +184,8 @@ func (r *RandomGenerator) buildIndividualCharsets() []string {
 			charset = "!@#$%^&*()_+-=[]{}|;:,.<>?"
 		case Ambiguous:
 			charset = "0O1lI"
+		case German, French, Spanish, Nordic:
+			charset = localeLetters[cs]
 		}
 		
 		// Remove excluded characters
@@ -195,6 +217,8 @@ func (r *RandomGenerator) buildCharset() string {
 			charset.WriteString("!@#$%^&*()_+-=[]{}|;:,.<>?")
 		case Ambiguous:
 			charset.WriteString("0O1lI")
+		case German, French, Spanish, Nordic:
+			charset.WriteString(localeLetters[cs])
 		}
 	}
 	
@@ -208,22 +232,81 @@ func (r *RandomGenerator) buildCharset() string {
 	return result
 }
 
-// shufflePassword securely shuffles the password bytes using Fisher-Yates algorithm
-func (r *RandomGenerator) shufflePassword(password []byte) error {
-	n := len(password)
-	for i := n - 1; i > 0; i-- {
-		// Generate a random index from 0 to i
-		maxIndex := big.NewInt(int64(i + 1))
-		randomIndex, err := rand.Int(rand.Reader, maxIndex)
-		if err != nil {
-			return fmt.Errorf("failed to generate random index for shuffle: %w", err)
+// randomAlphabet is the characters a random password is drawn from, each
+// once, and the enabled sets each belongs to as a bit mask
+type randomAlphabet struct {
+	runes []rune
+	sets  []uint
+	all   uint // Mask of every set
+}
+
+// maxConstraintFailure bounds the chance that Generate gives up on a
+// healthy source: it tries until that is below e^-maxConstraintFailure
+const maxConstraintFailure = 50
+
+// buildAlphabet works out the sets and the alphabet passwords are drawn
+// from once per configuration, rather than on every Generate
+func (r *RandomGenerator) buildAlphabet() {
+	r.charsets = r.buildIndividualCharsets()
+	r.alphabet = newRandomAlphabet(r.charsets)
+}
+
+// newRandomAlphabet combines charsets into the alphabet passwords are
+// drawn from. A character in several sets, e.g. "0" in digits and
+// ambiguous, is listed once so it is no likelier than the others.
+func newRandomAlphabet(charsets []string) randomAlphabet {
+	var a randomAlphabet
+	index := make(map[rune]int)
+	for bit, charset := range charsets {
+		a.all |= 1 << bit
+		for _, char := range charset {
+			i, ok := index[char]
+			if !ok {
+				i = len(a.runes)
+				index[char] = i
+				a.runes = append(a.runes, char)
+				a.sets = append(a.sets, 0)
+			}
+			a.sets[i] |= 1 << bit
 		}
-		
-		j := randomIndex.Int64()
-		// Swap elements at positions i and j
-		password[i], password[j] = password[j], password[i]
 	}
-	return nil
+	return a
+}
+
+// coveredFraction returns the fraction of the passwords of length drawn
+// from the alphabet that use every set, by inclusion-exclusion over the
+// sets left out
+func (a randomAlphabet) coveredFraction(length int) float64 {
+	n := float64(len(a.runes))
+	fraction := 0.0
+	for missing := uint(0); missing <= a.all; missing++ {
+		outside := 0
+		for _, sets := range a.sets {
+			if sets&missing == 0 {
+				outside++
+			}
+		}
+		term := math.Pow(float64(outside)/n, float64(length))
+		if bits.OnesCount(missing)%2 == 1 {
+			term = -term
+		}
+		fraction += term
+	}
+	return fraction
+}
+
+// maxAttempts returns how many passwords Generate draws before giving up
+// on finding one that uses every set
+func (a randomAlphabet) maxAttempts(length int) int {
+	fraction := a.coveredFraction(length)
+	if fraction <= 0 {
+		return 1
+	}
+	attempts := math.Ceil(maxConstraintFailure / fraction)
+	if attempts > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(attempts)
 }
 
 // removeChars removes specified characters from the charset
@@ -249,3 +332,10 @@ func clearBytes(data []byte) {
 		data[i] = 0
 	}
 }
+
+// clearRunes securely clears sensitive characters from memory
+func clearRunes(data []rune) {
+	for i := range data {
+		data[i] = 0
+	}
+}
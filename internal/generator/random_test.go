@@ -2,6 +2,8 @@ package generator
 
 import (
 	"context"
+	"math"
+	mathrand "math/rand/v2"
 	"strings"
 	"testing"
 	"time"
@@ -210,3 +212,141 @@ func hasSymbols(s string) bool {
 	}
 	return false
 }
+
+// smallRandomGenerator limits charSets to the characters in keep, so every
+// password can be enumerated
+func smallRandomGenerator(length int, keep string, charSets ...CharSet) *RandomGenerator {
+	gen := NewRandomGenerator(length, charSets...)
+	var exclude strings.Builder
+	for _, char := range NewRandomGenerator(1, charSets...).buildCharset() {
+		if !strings.ContainsRune(keep, char) {
+			exclude.WriteRune(char)
+		}
+	}
+	gen.SetExcludeChars(exclude.String())
+	return gen
+}
+
+// validPasswords lists every password gen may produce: each string of its
+// length over its alphabet that uses every enabled set
+func validPasswords(gen *RandomGenerator) []string {
+	charsets, alphabet := gen.charsets, gen.alphabet
+	var valid []string
+	var build func(prefix string)
+	build = func(prefix string) {
+		if len([]rune(prefix)) == gen.config.Length {
+			for _, charset := range charsets {
+				if !strings.ContainsAny(prefix, charset) {
+					return
+				}
+			}
+			valid = append(valid, prefix)
+			return
+		}
+		for _, char := range alphabet.runes {
+			build(prefix + string(char))
+		}
+	}
+	build("")
+	return valid
+}
+
+func TestRandomGeneratorExactEntropy(t *testing.T) {
+	tests := []struct {
+		name     string
+		gen      *RandomGenerator
+		alphabet int
+	}{
+		{"two sets", smallRandomGenerator(3, "ab12", Lowercase, Numbers), 4},
+		{"uneven sets", smallRandomGenerator(4, "abc1", Lowercase, Numbers), 4},
+		{"three sets", smallRandomGenerator(4, "aB12", Lowercase, Uppercase, Numbers), 4},
+		{"overlapping sets", smallRandomGenerator(3, "012O", Numbers, Ambiguous), 4},
+		{"one set", smallRandomGenerator(3, "abc", Lowercase), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.gen.alphabet.runes); got != tt.alphabet {
+				t.Fatalf("Expected an alphabet of %d characters, got %d", tt.alphabet, got)
+			}
+			want := logBase2(float64(len(validPasswords(tt.gen))))
+			if got := tt.gen.EstimateEntropy(); got < want-1e-9 || got > want+1e-9 {
+				t.Errorf("Expected %.6f bits, got %.6f", want, got)
+			}
+		})
+	}
+
+	// Requiring every set only ever removes passwords
+	gen := NewRandomGenerator(12, Lowercase, Uppercase, Numbers, Symbols)
+	if unconstrained := 12 * logBase2(94); gen.EstimateEntropy() >= unconstrained {
+		t.Errorf("Expected less than %.2f bits, got %.2f", unconstrained, gen.EstimateEntropy())
+	}
+}
+
+func TestRandomGeneratorUniform(t *testing.T) {
+	// Placing one character of each set and shuffling would make "aa11"
+	// likelier than "aaa1"; every valid password must be equally likely
+	gen := smallRandomGenerator(4, "ab12", Lowercase, Numbers)
+	var key [32]byte
+	gen.SetRandomSource(mathrand.NewChaCha8(key))
+
+	valid := validPasswords(gen)
+	counts := make(map[string]int, len(valid))
+	for _, password := range valid {
+		counts[password] = 0
+	}
+	positions := make([]map[rune]int, 4)
+	for i := range positions {
+		positions[i] = make(map[rune]int)
+	}
+
+	draws := 200 * len(valid)
+	for i := 0; i < draws; i++ {
+		password, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, ok := counts[password]; !ok {
+			t.Fatalf("Generated %q, which misses a character set", password)
+		}
+		counts[password]++
+		for j, char := range password {
+			positions[j][char]++
+		}
+	}
+
+	chiSquare := func(counts []int, total int) (float64, float64) {
+		expected := float64(total) / float64(len(counts))
+		chi := 0.0
+		for _, c := range counts {
+			chi += (float64(c) - expected) * (float64(c) - expected) / expected
+		}
+		df := float64(len(counts) - 1)
+		return chi, df + 6*math.Sqrt(2*df)
+	}
+
+	var passwordCounts []int
+	for _, c := range counts {
+		passwordCounts = append(passwordCounts, c)
+	}
+	if chi, limit := chiSquare(passwordCounts, draws); chi > limit {
+		t.Errorf("Passwords are not equally likely: chi-square %.1f exceeds %.1f", chi, limit)
+	}
+	for i, position := range positions {
+		var charCounts []int
+		for _, char := range "ab12" {
+			charCounts = append(charCounts, position[char])
+		}
+		if chi, limit := chiSquare(charCounts, draws); chi > limit {
+			t.Errorf("Position %d is uneven %v: chi-square %.1f exceeds %.1f", i, position, chi, limit)
+		}
+	}
+}
+
+func TestRandomGeneratorGivesUpOnStuckSource(t *testing.T) {
+	// Zero bytes always pick "a", which never includes a digit
+	gen := NewRandomGenerator(8, Lowercase, Numbers)
+	gen.SetRandomSource(zeroReader{})
+	if _, err := gen.Generate(context.Background()); err == nil {
+		t.Error("Expected an error from a source that never covers every set")
+	}
+}
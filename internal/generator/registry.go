@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Option names a setting of Options that a generator uses
+type Option string
+
+// Settings generators can be configured with
+const (
+	OptionLength      Option = "length"
+	OptionCharSets    Option = "charsets"
+	OptionExclude     Option = "exclude"
+	OptionWords       Option = "words"
+	OptionSeparator   Option = "separator"
+	OptionCapitalize  Option = "capitalize"
+	OptionDigit       Option = "digit"
+	OptionGroupSize   Option = "group_size"
+	OptionWordlist    Option = "wordlist"
+	OptionTokenFormat Option = "token_format"
+	OptionPrefix      Option = "prefix"
+	OptionEncoding    Option = "encoding"
+	OptionBytes       Option = "bytes"
+)
+
+// Options are the settings a registered generator is built from. Each
+// generator reads only the options of its schema; zero values select its
+// defaults.
+type Options struct {
+	Length      int
+	CharSets    []CharSet
+	Exclude     string
+	Words       int
+	Separator   string // Between passphrase words ("" uses "-", SeparatorNone none) or PIN groups
+	Capitalize  bool
+	Digit       bool     // Add a random digit to the passphrase
+	GroupSize   int      // Digits per PIN group, 0 for an unformatted PIN
+	Wordlist    []string // Words of the passphrase; nil uses the default wordlist
+	TokenFormat TokenFormat
+	Prefix      string
+	Encoding    KeyEncoding
+	Bytes       int
+}
+
+// Registration describes a kind of generator to the menu, the CLI, job
+// files and the config, which all list the registry instead of naming
+// generators themselves
+type Registration struct {
+	Name        string   // Identifier in configs, job files and -type, e.g. "random"
+	Title       string   // Label of its menu entry, e.g. "Random Password"
+	Description string   // One line about what it generates
+	Options     []Option // Its config schema: the Options it reads
+	Order       int      // Position in listings, lowest first
+	New         func(Options) (Generator, error)
+}
+
+// Uses reports whether the generator reads option
+func (r Registration) Uses(option Option) bool {
+	for _, o := range r.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	registry   = make(map[string]Registration)
+	registryMu sync.RWMutex
+)
+
+// Register adds a generator to the registry. Generators register
+// themselves from init; registering a name twice panics.
+func Register(r Registration) {
+	if r.Name == "" || r.New == nil {
+		panic("generator: registration needs a name and a constructor")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[r.Name]; ok {
+		panic("generator: " + r.Name + " registered twice")
+	}
+	registry[r.Name] = r
+}
+
+// Lookup returns the registration of the generator called name, ignoring case
+func Lookup(name string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registry[strings.ToLower(name)]
+	return r, ok
+}
+
+// Registered returns every registered generator in display order
+func Registered() []Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	list := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Order != list[j].Order {
+			return list[i].Order < list[j].Order
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Names returns the names of the registered generators in display order
+func Names() []string {
+	var names []string
+	for _, r := range Registered() {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// NamesText lists the registered names for messages, e.g. "random,
+// memorable or pin"
+func NamesText() string {
+	names := Names()
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// New builds the generator called name from opts
+func New(name string, opts Options) (Generator, error) {
+	r, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q (use %s)", name, NamesText())
+	}
+	return r.New(opts)
+}
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRegisteredOrder(t *testing.T) {
+	want := []string{"random", "memorable", "pin", "totp", "token", "key"}
+	got := Names()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if text := NamesText(); text != "random, memorable, pin, totp, token or key" {
+		t.Errorf("NamesText() = %q", text)
+	}
+}
+
+func TestRegisteredDefaults(t *testing.T) {
+	for _, reg := range Registered() {
+		t.Run(reg.Name, func(t *testing.T) {
+			if reg.Title == "" || reg.Description == "" {
+				t.Errorf("%s has no title or description", reg.Name)
+			}
+
+			gen, err := reg.New(Options{})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if err := gen.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			secret, err := gen.Generate(context.Background())
+			if err != nil || secret == "" {
+				t.Fatalf("Generate() = %q, %v", secret, err)
+			}
+		})
+	}
+}
+
+func TestRegistryOptions(t *testing.T) {
+	gen, err := New("PIN", Options{Length: 8, Words: 3})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	pin, err := gen.Generate(context.Background())
+	if err != nil || len(pin) != 8 {
+		t.Errorf("Generate() = %q, %v; want 8 digits", pin, err)
+	}
+
+	reg, _ := Lookup("memorable")
+	if !reg.Uses(OptionWords) || reg.Uses(OptionLength) {
+		t.Errorf("memorable options = %v", reg.Options)
+	}
+}
+
+func TestRegistryUnknown(t *testing.T) {
+	if _, ok := Lookup("dice"); ok {
+		t.Error("Lookup() found an unregistered generator")
+	}
+	_, err := New("dice", Options{})
+	if err == nil || !strings.Contains(err.Error(), "use random") {
+		t.Errorf("New() error = %v, want the registered names", err)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() accepted a name twice")
+		}
+	}()
+	Register(Registration{Name: "random", New: func(Options) (Generator, error) { return nil, nil }})
+}
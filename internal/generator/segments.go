@@ -0,0 +1,263 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SegmentKind names what a part of a password matched
+type SegmentKind string
+
+// Kinds of segments, from the weakest patterns to no pattern at all
+const (
+	SegmentDictionary SegmentKind = "dictionary" // A common word or breached password, possibly in leet speak
+	SegmentKeyboard   SegmentKind = "keyboard"   // A run along a keyboard row, e.g. "asdf"
+	SegmentSequence   SegmentKind = "sequence"   // Consecutive letters or digits, e.g. "abc" or "987"
+	SegmentRepeat     SegmentKind = "repeat"     // A repeated character or block, e.g. "aaa" or "abab"
+	SegmentRandom     SegmentKind = "random"     // No pattern found
+)
+
+// minSegment is the shortest run of characters reported as a pattern
+const minSegment = 3
+
+// Segment is a part of an analyzed password and the entropy it
+// contributes. Start and End are rune offsets into the password, End
+// exclusive.
+type Segment struct {
+	Text    string
+	Start   int
+	End     int
+	Kind    SegmentKind
+	Match   string  // The word, keyboard run or block matched, lowercase; empty for random segments
+	Entropy float64 // Bits the segment contributes to a pattern-aware estimate
+}
+
+// Weak reports whether the segment matched a pattern an attacker tries
+// early, so it adds far less entropy than its length suggests
+func (s Segment) Weak() bool {
+	return s.Kind != SegmentRandom
+}
+
+// keyboardRows are the key runs a keyboard segment follows, either way
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "azertyuiop", "qwertzuiop"}
+
+// leetLetters undoes the common leet substitutions before dictionary
+// lookups
+var leetLetters = map[rune]rune{'@': 'a', '4': 'a', '3': 'e', '1': 'i', '!': 'i', '0': 'o', '$': 's', '5': 's', '7': 't'}
+
+// segment breaks password into the patterns it is made of and the random
+// parts between them, in order. The longest matches win where patterns
+// overlap, and of those the one an attacker guesses soonest.
+func (s *SecurityAnalyzer) segment(password string) []Segment {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return nil
+	}
+	lower := []rune(strings.ToLower(password))
+	if len(lower) != len(runes) {
+		lower = runes // Case mapping changed the length; match as typed
+	}
+
+	matches := s.dictionaryMatches(lower)
+	matches = append(matches, keyboardMatches(lower)...)
+	matches = append(matches, sequenceMatches(lower)...)
+	matches = append(matches, repeatMatches(lower)...)
+	sort.SliceStable(matches, func(i, j int) bool {
+		li, lj := matches[i].End-matches[i].Start, matches[j].End-matches[j].Start
+		if li != lj {
+			return li > lj
+		}
+		return matches[i].Entropy < matches[j].Entropy
+	})
+
+	taken := make([]bool, len(runes))
+	var chosen []Segment
+	for _, m := range matches {
+		if overlaps(taken, m.Start, m.End) {
+			continue
+		}
+		for i := m.Start; i < m.End; i++ {
+			taken[i] = true
+		}
+		chosen = append(chosen, m)
+	}
+	sort.Slice(chosen, func(i, j int) bool { return chosen[i].Start < chosen[j].Start })
+
+	// Fill the gaps between patterns with random segments
+	charsetBits := logBase2(float64(max(s.calculateCharsetSize(password), 2)))
+	var segments []Segment
+	pos := 0
+	for _, m := range append(chosen, Segment{Start: len(runes), End: len(runes)}) {
+		if m.Start > pos {
+			segments = append(segments, Segment{
+				Start: pos, End: m.Start, Kind: SegmentRandom,
+				Entropy: float64(m.Start-pos) * charsetBits,
+			})
+		}
+		if m.End > m.Start {
+			segments = append(segments, m)
+		}
+		pos = m.End
+	}
+	for i := range segments {
+		seg := &segments[i]
+		seg.Text = string(runes[seg.Start:seg.End])
+		if seg.Kind == SegmentDictionary && hasUpperRune(seg.Text) {
+			seg.Entropy++ // Capitalization is one more guess to try
+		}
+	}
+	return segments
+}
+
+// dictionaryMatches finds the common words and breached passwords in
+// lower, as typed or with leet substitutions undone
+func (s *SecurityAnalyzer) dictionaryMatches(lower []rune) []Segment {
+	unleet := make([]rune, len(lower))
+	for i, r := range lower {
+		if letter, ok := leetLetters[r]; ok {
+			unleet[i] = letter
+		} else {
+			unleet[i] = r
+		}
+	}
+
+	wordBits := logBase2(float64(max(len(s.commonWords), 2)))
+	breachedBits := logBase2(float64(max(CommonPasswordCount(), 2)))
+	var matches []Segment
+	for start := 0; start < len(lower); start++ {
+		for end := start + minSegment; end <= len(lower); end++ {
+			for _, candidate := range []struct {
+				text string
+				leet bool
+			}{{string(lower[start:end]), false}, {string(unleet[start:end]), true}} {
+				bits := 0.0
+				switch {
+				case s.isCommonWord(candidate.text):
+					bits = wordBits
+				case IsCommonPassword(candidate.text):
+					bits = breachedBits
+				default:
+					continue
+				}
+				if candidate.leet && candidate.text != string(lower[start:end]) {
+					bits++ // Undoing leet speak is one more guess to try
+				}
+				matches = append(matches, Segment{Start: start, End: end, Kind: SegmentDictionary, Match: candidate.text, Entropy: bits})
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// isCommonWord reports whether word is one of the analyzer's common words
+func (s *SecurityAnalyzer) isCommonWord(word string) bool {
+	for _, common := range s.commonWords {
+		if word == common {
+			return true
+		}
+	}
+	return false
+}
+
+// keyboardMatches finds runs of at least minSegment keys along a keyboard
+// row, in either direction
+func keyboardMatches(lower []rune) []Segment {
+	var matches []Segment
+	for _, row := range keyboardRows {
+		for _, keys := range []string{row, reverseString(row)} {
+			matches = append(matches, runMatches(lower, SegmentKeyboard, func(prev, next rune) bool {
+				i := strings.IndexRune(keys, prev)
+				return i >= 0 && i+1 < len(keys) && rune(keys[i+1]) == next
+			}, logBase2(float64(2*len(keys))))...)
+		}
+	}
+	return matches
+}
+
+// sequenceMatches finds runs of at least minSegment consecutive letters
+// or digits, up or down
+func sequenceMatches(lower []rune) []Segment {
+	var matches []Segment
+	for _, step := range []rune{1, -1} {
+		matches = append(matches, runMatches(lower, SegmentSequence, func(prev, next rune) bool {
+			return next == prev+step && (unicode.IsLetter(prev) && unicode.IsLetter(next) || unicode.IsDigit(prev) && unicode.IsDigit(next))
+		}, logBase2(36*2))...)
+	}
+	return matches
+}
+
+// runMatches finds the maximal runs of at least minSegment runes in
+// which each rune follows the one before. A run contributes the bits to
+// pick its start plus those to pick its length.
+func runMatches(lower []rune, kind SegmentKind, follows func(prev, next rune) bool, startBits float64) []Segment {
+	var matches []Segment
+	start := 0
+	for i := 1; i <= len(lower); i++ {
+		if i < len(lower) && follows(lower[i-1], lower[i]) {
+			continue
+		}
+		if i-start >= minSegment {
+			matches = append(matches, Segment{
+				Start: start, End: i, Kind: kind, Match: string(lower[start:i]),
+				Entropy: startBits + logBase2(float64(i-start)),
+			})
+		}
+		start = i
+	}
+	return matches
+}
+
+// repeatMatches finds a character repeated at least minSegment times and
+// blocks immediately repeated, e.g. "abab"
+func repeatMatches(lower []rune) []Segment {
+	matches := runMatches(lower, SegmentRepeat, func(prev, next rune) bool { return prev == next }, logBase2(95))
+	for start := 0; start < len(lower); start++ {
+		for size := 2; start+2*size <= len(lower); size++ {
+			block := string(lower[start : start+size])
+			end := start + size
+			for end+size <= len(lower) && string(lower[end:end+size]) == block {
+				end += size
+			}
+			if copies := (end - start) / size; copies > 1 {
+				matches = append(matches, Segment{
+					Start: start, End: end, Kind: SegmentRepeat, Match: block,
+					// The block is guessed once, the copies come nearly free
+					Entropy: float64(size)*logBase2(95) + logBase2(float64(copies)),
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// overlaps reports whether any rune in [start, end) is taken
+func overlaps(taken []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if taken[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUpperRune reports whether text has an uppercase letter
+func hasUpperRune(text string) bool {
+	for _, r := range text {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// reverseString returns s with its bytes in reverse order; it is only
+// used for ASCII keyboard rows
+func reverseString(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSegmentsCoverPassword(t *testing.T) {
+	analyzer := NewSecurityAnalyzer()
+	for _, password := range []string{"P@ssw0rd2024!", "xK9#mQ2$vL7p", "qwerty123abc", "ÄÖüxyz", "aaaaZ9abab"} {
+		segments := analyzer.Analyze(password).Segments
+		var joined strings.Builder
+		pos := 0
+		for _, seg := range segments {
+			if seg.Start != pos || seg.End <= seg.Start {
+				t.Errorf("%q: segment %+v does not follow offset %d", password, seg, pos)
+			}
+			if seg.Entropy <= 0 {
+				t.Errorf("%q: segment %q contributes no entropy", password, seg.Text)
+			}
+			joined.WriteString(seg.Text)
+			pos = seg.End
+		}
+		if joined.String() != password {
+			t.Errorf("Segments of %q join to %q", password, joined.String())
+		}
+	}
+	if segments := analyzer.Analyze("").Segments; len(segments) != 0 {
+		t.Errorf("Expected no segments for an empty password, got %v", segments)
+	}
+}
+
+func TestSegmentKinds(t *testing.T) {
+	analyzer := NewSecurityAnalyzer()
+	tests := []struct {
+		password string
+		text     string
+		kind     SegmentKind
+		match    string
+	}{
+		{"xK9#P@ssw0rd", "P@ssw0rd", SegmentDictionary, "p@ssw0rd"},
+		{"xK9#M0nk3y", "M0nk3y", SegmentDictionary, "monkey"},
+		{"#Rt2asdfgh", "asdfgh", SegmentKeyboard, "asdfgh"},
+		{"#Rt2lkjhg", "lkjhg", SegmentKeyboard, "lkjhg"},
+		{"#Rt2mnopq", "mnopq", SegmentSequence, "mnopq"},
+		{"#Rt2fedcb", "fedcb", SegmentSequence, "fedcb"},
+		{"#Rt2zzzzz", "zzzzz", SegmentRepeat, "zzzzz"},
+		{"#Rt2k7k7k7", "k7k7k7", SegmentRepeat, "k7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			var found *Segment
+			for _, seg := range analyzer.Analyze(tt.password).Segments {
+				if seg.Text == tt.text {
+					found = &seg
+				}
+			}
+			if found == nil {
+				t.Fatalf("No segment %q in %v", tt.text, analyzer.Analyze(tt.password).Segments)
+			}
+			if found.Kind != tt.kind || found.Match != tt.match || !found.Weak() {
+				t.Errorf("Segment %q = %s %q, want %s %q", tt.text, found.Kind, found.Match, tt.kind, tt.match)
+			}
+		})
+	}
+}
+
+func TestSegmentRandom(t *testing.T) {
+	segments := NewSecurityAnalyzer().Analyze("xK9#mQ2$vL7p").Segments
+	if len(segments) != 1 || segments[0].Kind != SegmentRandom || segments[0].Weak() {
+		t.Errorf("Expected one random segment, got %+v", segments)
+	}
+}
+
+func TestSegmentWeakPartsContributeLess(t *testing.T) {
+	analyzer := NewSecurityAnalyzer()
+	weak := analyzer.Analyze("qwertyuiop").Segments
+	random := analyzer.Analyze("q8#Lz!w2Rv").Segments
+	if len(weak) != 1 || len(random) != 1 || weak[0].Entropy >= random[0].Entropy {
+		t.Errorf("Expected a keyboard run to contribute less than random characters, got %+v and %+v", weak, random)
+	}
+}
@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// distributionZ is the standard normal quantile of the distribution check:
+// a healthy source fails it about once in 10,000 runs
+const distributionZ = 3.719
+
+// DistributionResult is the outcome of a chi-squared test of how evenly a
+// generator uses its alphabet
+type DistributionResult struct {
+	Symbols   int     `json:"symbols"` // Size of the alphabet
+	Samples   int     `json:"samples"` // Characters counted
+	ChiSquare float64 `json:"chi_square"`
+	Critical  float64 `json:"critical"` // Largest statistic accepted
+}
+
+// Passed reports whether the counts are consistent with a uniform choice
+func (r DistributionResult) Passed() bool {
+	return r.ChiSquare <= r.Critical
+}
+
+// String summarises the result for display
+func (r DistributionResult) String() string {
+	return fmt.Sprintf("χ² = %.1f over %d symbols and %d samples (limit %.1f)", r.ChiSquare, r.Symbols, r.Samples, r.Critical)
+}
+
+// CheckRandomSource reads from the source the generators use and fails if
+// it errors, returns only zeros or repeats itself
+func CheckRandomSource() error {
+	first := make([]byte, 32)
+	second := make([]byte, 32)
+	if err := randomBytes(nil, first); err != nil {
+		return fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	if err := randomBytes(nil, second); err != nil {
+		return fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	if bytes.Equal(first, make([]byte, 32)) {
+		return fmt.Errorf("random source returned only zero bytes")
+	}
+	if bytes.Equal(first, second) {
+		return fmt.Errorf("random source repeated itself")
+	}
+	return nil
+}
+
+// CheckDistribution generates passwords until samples characters are
+// counted and runs a chi-squared test of them against alphabet. Every
+// character must belong to alphabet, so the generator must not force
+// characters from several sets into each password.
+func CheckDistribution(ctx context.Context, gen Generator, alphabet string, samples int) (DistributionResult, error) {
+	counts := make(map[rune]int)
+	for _, r := range alphabet {
+		counts[r] = 0
+	}
+	result := DistributionResult{Symbols: len(counts)}
+	if result.Symbols < 2 {
+		return result, fmt.Errorf("alphabet needs at least 2 symbols")
+	}
+
+	for result.Samples < samples {
+		password, err := gen.Generate(ctx)
+		if err != nil {
+			return result, err
+		}
+		for _, r := range password {
+			if _, ok := counts[r]; !ok {
+				return result, fmt.Errorf("generated %q outside the alphabet", r)
+			}
+			counts[r]++
+			result.Samples++
+		}
+	}
+
+	expected := float64(result.Samples) / float64(result.Symbols)
+	for _, count := range counts {
+		diff := float64(count) - expected
+		result.ChiSquare += diff * diff / expected
+	}
+
+	// Wilson-Hilferty approximation of the chi-squared quantile
+	df := float64(result.Symbols - 1)
+	result.Critical = df * math.Pow(1-2/(9*df)+distributionZ*math.Sqrt(2/(9*df)), 3)
+	return result, nil
+}
+
+// CheckWordlist verifies a bundled wordlist: its number of words, and
+// that every word is valid UTF-8, free of spaces and unique
+func CheckWordlist(id string) error {
+	info, ok := findWordlistInfo(id)
+	if !ok {
+		return fmt.Errorf("unknown wordlist: %s", id)
+	}
+	words, err := GetBundledWordlist(id)
+	if err != nil {
+		return err
+	}
+
+	if info.size != 0 && len(words) != info.size {
+		return fmt.Errorf("%s has %d words, expected %d", id, len(words), info.size)
+	}
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		if word == "" || !utf8.ValidString(word) || strings.ContainsAny(word, " \t") {
+			return fmt.Errorf("%s contains the invalid word %q", id, word)
+		}
+		if seen[word] {
+			return fmt.Errorf("%s contains %q twice", id, word)
+		}
+		seen[word] = true
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckRandomSource(t *testing.T) {
+	if err := CheckRandomSource(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	saved := randomSource
+	defer func() { randomSource = saved }()
+
+	randomSource = zeroReader{}
+	if err := CheckRandomSource(); err == nil {
+		t.Error("Expected a source of zeros to fail")
+	}
+	randomSource = failingReader{}
+	if err := CheckRandomSource(); err == nil {
+		t.Error("Expected a failing source to fail")
+	}
+}
+
+func TestCheckDistribution(t *testing.T) {
+	ctx := context.Background()
+
+	result, err := CheckDistribution(ctx, NewPINGenerator(10), "0123456789", 10000)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Passed() {
+		t.Errorf("Expected crypto/rand to pass, got %s", result)
+	}
+	if result.Symbols != 10 || result.Samples != 10000 {
+		t.Errorf("Expected 10 symbols and 10000 samples, got %d and %d", result.Symbols, result.Samples)
+	}
+
+	biased := NewPINGenerator(10)
+	biased.SetRandomSource(zeroReader{})
+	if result, err := CheckDistribution(ctx, biased, "0123456789", 1000); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if result.Passed() {
+		t.Errorf("Expected a constant source to fail, got %s", result)
+	}
+
+	if _, err := CheckDistribution(ctx, NewPINGenerator(4), "abc", 100); err == nil {
+		t.Error("Expected an error for characters outside the alphabet")
+	}
+}
+
+func TestCheckWordlist(t *testing.T) {
+	for _, info := range BundledWordlists() {
+		if err := CheckWordlist(info.ID); err != nil {
+			t.Errorf("%s: %v", info.ID, err)
+		}
+	}
+	if err := CheckWordlist("nope"); err == nil {
+		t.Error("Expected an error for an unknown wordlist")
+	}
+}
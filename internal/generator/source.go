@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	mathrand "math/rand/v2"
+	"sync"
+)
+
+// InsecureSeedEnv must be set to "1" before an insecure seed is accepted
+const InsecureSeedEnv = "PASSMAN_ALLOW_INSECURE_SEED"
+
+// randomSource supplies the randomness for generators without their own
+// source. It is crypto/rand unless an insecure seed was set for test fixtures.
+var randomSource io.Reader = rand.Reader
+
+// insecureSeeded records whether randomSource is deterministic
+var insecureSeeded bool
+
+// SetInsecureSeed makes every generator deterministic, so the same seed
+// always produces the same output. This is for reproducible test fixtures
+// and documentation examples only: anything generated is predictable.
+func SetInsecureSeed(seed uint64) {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	randomSource = mathrand.NewChaCha8(key)
+	insecureSeeded = true
+}
+
+// InsecureSeeded reports whether SetInsecureSeed is in effect
+func InsecureSeeded() bool {
+	return insecureSeeded
+}
+
+// RandomSourceSetter is implemented by every generator. Tests use it to
+// supply deterministic bytes or a reader that fails.
+type RandomSourceSetter interface {
+	// SetRandomSource replaces the generator's randomness. A nil source
+	// restores the default.
+	SetRandomSource(src io.Reader)
+}
+
+// sourceOrDefault returns src, or the package source if src is nil
+func sourceOrDefault(src io.Reader) io.Reader {
+	if src == nil {
+		return randomSource
+	}
+	return src
+}
+
+// randomInt returns a uniform random integer in [0, max) read from src
+func randomInt(src io.Reader, max *big.Int) (*big.Int, error) {
+	return rand.Int(sourceOrDefault(src), max)
+}
+
+// randomBytes fills b with random bytes read from src
+func randomBytes(src io.Reader, b []byte) error {
+	_, err := io.ReadFull(sourceOrDefault(src), b)
+	return err
+}
+
+// samplerBufferSize is how many random bytes a sampler reads at a time
+const samplerBufferSize = 256
+
+// sampler draws uniform random integers from a source through a buffer,
+// so a long password costs a few reads instead of one rand.Int per
+// character. Release it when done: the buffer holds the bytes that chose
+// the secret.
+type sampler struct {
+	src io.Reader
+	buf [samplerBufferSize]byte
+	pos int // Next unread byte in buf
+	end int // Bytes of buf filled by the last read
+}
+
+// samplers keeps released samplers for reuse, so batch generation does
+// not allocate a buffer per secret
+var samplers = sync.Pool{New: func() any { return new(sampler) }}
+
+// newSampler returns a sampler reading src, or the package source if src
+// is nil. Release it when done.
+func newSampler(src io.Reader) *sampler {
+	s := samplers.Get().(*sampler)
+	s.src = sourceOrDefault(src)
+	return s
+}
+
+// intn returns a uniform random integer in [0, n), 0 < n <= 1<<32. It
+// reads the fewest whole bytes that can cover n and rejects values past
+// the largest multiple of n, which would otherwise favour small results.
+func (s *sampler) intn(n int) (int, error) {
+	if n <= 0 || uint64(n) > 1<<32 {
+		return 0, fmt.Errorf("sample range %d out of bounds", n)
+	}
+	size := 1
+	for uint64(1)<<(8*size) < uint64(n) {
+		size++
+	}
+	span := uint64(1) << (8 * size)
+	limit := span - span%uint64(n)
+	for {
+		var v uint64
+		for i := 0; i < size; i++ {
+			b, err := s.byte()
+			if err != nil {
+				return 0, err
+			}
+			v = v<<8 | uint64(b)
+		}
+		if v < limit {
+			return int(v % uint64(n)), nil
+		}
+	}
+}
+
+// byte returns the next random byte, refilling the buffer when it runs out
+func (s *sampler) byte() (byte, error) {
+	if s.pos == s.end {
+		n, err := io.ReadAtLeast(s.src, s.buf[:], 1)
+		if err != nil {
+			return 0, err
+		}
+		s.pos, s.end = 0, n
+	}
+	b := s.buf[s.pos]
+	s.buf[s.pos] = 0
+	s.pos++
+	return b, nil
+}
+
+// release wipes the bytes still buffered and returns the sampler to the
+// pool; it must not be used afterwards
+func (s *sampler) release() {
+	clearBytes(s.buf[s.pos:s.end])
+	s.src, s.pos, s.end = nil, 0, 0
+	samplers.Put(s)
+}
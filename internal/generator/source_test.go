@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	mathrand "math/rand/v2"
+	"testing"
+)
+
+func TestSetInsecureSeed(t *testing.T) {
+	saved := randomSource
+	defer func() {
+		randomSource = saved
+		insecureSeeded = false
+	}()
+
+	generate := func(seed uint64) string {
+		SetInsecureSeed(seed)
+		password, err := NewRandomGenerator(16, Lowercase, Uppercase, Numbers).Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return password
+	}
+
+	first := generate(42)
+	if !InsecureSeeded() {
+		t.Error("Expected InsecureSeeded to report true")
+	}
+	if second := generate(42); second != first {
+		t.Errorf("Expected the same seed to give the same password, got %q and %q", first, second)
+	}
+	if other := generate(43); other == first {
+		t.Errorf("Expected different seeds to give different passwords, both gave %q", first)
+	}
+}
+
+// zeroReader supplies an endless stream of zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// failingReader always fails, to reach the generators' error paths
+type failingReader struct{}
+
+var errSourceFailed = errors.New("source failed")
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errSourceFailed
+}
+
+// sourceGenerators returns one of each generator that draws randomness
+func sourceGenerators(t *testing.T) map[string]interface {
+	Generator
+	RandomSourceSetter
+} {
+	leet, err := NewLeetTransform(LeetRandom, "a=@,e=3,o=0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	wordlist, err := GetBundledWordlist(DefaultWordlistID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	memorable := NewMemorableGenerator(4, "-", wordlist)
+	memorable.SetLeet(leet)
+
+	return map[string]interface {
+		Generator
+		RandomSourceSetter
+	}{
+		"random":    NewRandomGenerator(16, Lowercase, Uppercase, Numbers),
+		"pin":       NewPINGenerator(6),
+		"memorable": memorable,
+		"key":       NewKeyGenerator(16, KeyHex),
+		"totp":      NewTOTPGenerator(0),
+		"uuid":      NewTokenGenerator(TokenUUID, 0),
+		"hex":       NewTokenGenerator(TokenHex, 0),
+		"apikey":    NewTokenGenerator(TokenAPIKey, 0),
+	}
+}
+
+func TestSetRandomSourceDeterministic(t *testing.T) {
+	for name, gen := range sourceGenerators(t) {
+		seeded := func() string {
+			gen.SetRandomSource(bytes.NewReader(bytes.Repeat([]byte{0x5a, 0x3c, 0x81, 0x07}, 256)))
+			result, err := gen.Generate(context.Background())
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+			return result
+		}
+		if first, second := seeded(), seeded(); first != second {
+			t.Errorf("%s: expected the same bytes to give the same output, got %q and %q", name, first, second)
+		}
+	}
+
+	pin := NewPINGenerator(4)
+	pin.SetRandomSource(zeroReader{})
+	if got, err := pin.Generate(context.Background()); err != nil || got != "0000" {
+		t.Errorf("Expected zero bytes to give PIN 0000, got %q (%v)", got, err)
+	}
+
+	key := NewKeyGenerator(4, KeyHex)
+	key.SetRandomSource(bytes.NewReader([]byte{0xde, 0xad, 0xbe, 0xef}))
+	if got, err := key.Generate(context.Background()); err != nil || got != "deadbeef" {
+		t.Errorf("Expected key deadbeef, got %q (%v)", got, err)
+	}
+}
+
+func TestSetRandomSourceFailure(t *testing.T) {
+	for name, gen := range sourceGenerators(t) {
+		gen.SetRandomSource(failingReader{})
+		result, err := gen.Generate(context.Background())
+		if !errors.Is(err, errSourceFailed) {
+			t.Errorf("%s: expected the source error to be wrapped, got %v", name, err)
+		}
+		if result != "" {
+			t.Errorf("%s: expected no output on failure, got %q", name, result)
+		}
+	}
+
+	// A source that runs dry partway is a failure too
+	key := NewKeyGenerator(32, KeyHex)
+	key.SetRandomSource(bytes.NewReader(make([]byte, 8)))
+	if _, err := key.Generate(context.Background()); err == nil {
+		t.Error("Expected an error from a short source")
+	}
+}
+
+func TestSetRandomSourceNilRestoresDefault(t *testing.T) {
+	pin := NewPINGenerator(32)
+	pin.SetRandomSource(failingReader{})
+	pin.SetRandomSource(nil)
+	if _, err := pin.Generate(context.Background()); err != nil {
+		t.Errorf("Expected the default source after resetting, got %v", err)
+	}
+}
+
+func TestSamplerRejectsBiasedBytes(t *testing.T) {
+	// 250 and up would favour 0 to 5 for n = 10, so they are skipped
+	rng := newSampler(bytes.NewReader([]byte{255, 250, 7, 249}))
+	for _, want := range []int{7, 9} {
+		got, err := rng.intn(10)
+		if err != nil || got != want {
+			t.Errorf("Expected %d, got %d (%v)", want, got, err)
+		}
+	}
+	if _, err := rng.intn(10); err == nil {
+		t.Error("Expected an error once the source runs dry")
+	}
+
+	// Ranges past a byte read two, big end first
+	rng = newSampler(bytes.NewReader([]byte{0x01, 0x02}))
+	if got, err := rng.intn(1000); err != nil || got != 0x0102 {
+		t.Errorf("Expected %d, got %d (%v)", 0x0102, got, err)
+	}
+
+	for _, n := range []int{0, -1} {
+		if _, err := newSampler(zeroReader{}).intn(n); err == nil {
+			t.Errorf("Expected an error for range %d", n)
+		}
+	}
+}
+
+func TestSamplerUniform(t *testing.T) {
+	var key [32]byte
+	rng := newSampler(mathrand.NewChaCha8(key))
+	defer rng.release()
+
+	// A chi-square test: 100,000 draws over ranges that do and do not
+	// divide 256 evenly
+	for _, n := range []int{10, 62, 94, 300} {
+		const draws = 100000
+		counts := make([]int, n)
+		for i := 0; i < draws; i++ {
+			v, err := rng.intn(n)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			counts[v]++
+		}
+		expected := float64(draws) / float64(n)
+		chi := 0.0
+		for _, c := range counts {
+			chi += (float64(c) - expected) * (float64(c) - expected) / expected
+		}
+		// Far beyond the 99.9th percentile for n-1 degrees of freedom
+		if limit := float64(n-1) + 6*math.Sqrt(2*float64(n-1)); chi > limit {
+			t.Errorf("n=%d: chi-square %.1f exceeds %.1f", n, chi, limit)
+		}
+	}
+}
+
+func TestSamplerRelease(t *testing.T) {
+	rng := newSampler(bytes.NewReader(bytes.Repeat([]byte{0xaa}, samplerBufferSize)))
+	if _, err := rng.intn(100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rng.release()
+	for i, b := range rng.buf {
+		if b != 0 {
+			t.Fatalf("Expected a wiped buffer, byte %d is %#x", i, b)
+		}
+	}
+	if rng.src != nil {
+		t.Error("Expected the released sampler to drop its source")
+	}
+}
@@ -0,0 +1,257 @@
+package generator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// TokenFormat selects the output format of a TokenGenerator
+type TokenFormat int
+
+const (
+	TokenUUID      TokenFormat = iota // Random (version 4) UUID
+	TokenHex                          // Lowercase hexadecimal
+	TokenBase64URL                    // URL-safe base64 without padding
+	TokenAPIKey                       // Optional prefix followed by random characters
+)
+
+// DefaultTokenLength is the default number of random characters in a token
+const DefaultTokenLength = 32
+
+// DefaultAPIKeyAlphabet is used for API keys when no alphabet is set
+const DefaultAPIKeyAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// TokenFormats lists all token formats in display order
+func TokenFormats() []TokenFormat {
+	return []TokenFormat{TokenUUID, TokenHex, TokenBase64URL, TokenAPIKey}
+}
+
+// String returns a human-readable name for the format
+func (f TokenFormat) String() string {
+	switch f {
+	case TokenUUID:
+		return "UUID v4"
+	case TokenHex:
+		return "Hex"
+	case TokenBase64URL:
+		return "Base64URL"
+	case TokenAPIKey:
+		return "API Key"
+	default:
+		return "Unknown"
+	}
+}
+
+// TokenGenerator generates UUIDs, random tokens and prefixed API keys
+type TokenGenerator struct {
+	format   TokenFormat
+	length   int       // Number of random characters; ignored for UUIDs
+	prefix   string    // Prepended to API keys, e.g. "pk_live_"
+	alphabet string    // Characters used for API keys
+	source   io.Reader // Randomness; nil uses crypto/rand
+}
+
+func init() {
+	Register(Registration{
+		Name:        "token",
+		Title:       "Token / API Key",
+		Description: "UUIDs, hex and base64url tokens and prefixed API keys",
+		Options:     []Option{OptionTokenFormat, OptionLength, OptionPrefix},
+		Order:       50,
+		New: func(opts Options) (Generator, error) {
+			gen := NewTokenGenerator(opts.TokenFormat, opts.Length)
+			gen.SetPrefix(opts.Prefix)
+			return gen, nil
+		},
+	})
+}
+
+// NewTokenGenerator creates a new token generator. A length of 0 selects
+// DefaultTokenLength.
+func NewTokenGenerator(format TokenFormat, length int) *TokenGenerator {
+	if length == 0 {
+		length = DefaultTokenLength
+	}
+
+	return &TokenGenerator{
+		format:   format,
+		length:   length,
+		alphabet: DefaultAPIKeyAlphabet,
+	}
+}
+
+// Generate creates a new token in the configured format
+func (t *TokenGenerator) Generate(ctx context.Context) (string, error) {
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	switch t.format {
+	case TokenUUID:
+		return generateUUID(t.source)
+	case TokenHex:
+		return t.generateEncoded((t.length+1)/2, hex.EncodeToString)
+	case TokenBase64URL:
+		return t.generateEncoded((t.length*3+3)/4, base64.RawURLEncoding.EncodeToString)
+	case TokenAPIKey:
+		return t.generateAPIKey(ctx)
+	}
+
+	return "", errors.New("unknown token format")
+}
+
+// EstimateEntropy calculates the entropy of generated tokens in bits
+func (t *TokenGenerator) EstimateEntropy() float64 {
+	switch t.format {
+	case TokenUUID:
+		return 122 // 128 bits minus the fixed version and variant bits
+	case TokenHex:
+		return float64(t.length) * 4
+	case TokenBase64URL:
+		return float64(t.length) * 6
+	case TokenAPIKey:
+		if len(t.alphabet) == 0 {
+			return 0
+		}
+		return float64(t.length) * logBase2(float64(len(t.alphabet)))
+	}
+	return 0
+}
+
+// GetName returns the generator name
+func (t *TokenGenerator) GetName() string {
+	return "Token (" + t.format.String() + ")"
+}
+
+// Validate checks if the configuration is valid
+func (t *TokenGenerator) Validate() error {
+	if t.format < TokenUUID || t.format > TokenAPIKey {
+		return errors.New("unknown token format")
+	}
+
+	if t.format == TokenUUID {
+		return nil
+	}
+
+	if t.length <= 0 {
+		return errors.New("token length must be positive")
+	}
+
+	if t.length > 1024 {
+		return errors.New("token length too long (max 1024)")
+	}
+
+	if t.format == TokenAPIKey {
+		if len(t.alphabet) < 2 {
+			return errors.New("API key alphabet must contain at least 2 characters")
+		}
+
+		seen := make(map[rune]bool)
+		for _, r := range t.alphabet {
+			if r > unicode.MaxASCII || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+				return errors.New("API key alphabet must contain printable ASCII characters only")
+			}
+			if seen[r] {
+				return fmt.Errorf("API key alphabet contains duplicate character %q", r)
+			}
+			seen[r] = true
+		}
+
+		if strings.ContainsFunc(t.prefix, unicode.IsSpace) {
+			return errors.New("API key prefix cannot contain whitespace")
+		}
+	}
+
+	return nil
+}
+
+// GetFormat returns the configured token format
+func (t *TokenGenerator) GetFormat() TokenFormat {
+	return t.format
+}
+
+// SetPrefix sets the prefix prepended to API keys
+func (t *TokenGenerator) SetPrefix(prefix string) {
+	t.prefix = prefix
+}
+
+// SetAlphabet sets the characters used for API keys. An empty alphabet
+// restores DefaultAPIKeyAlphabet.
+func (t *TokenGenerator) SetAlphabet(alphabet string) {
+	if alphabet == "" {
+		alphabet = DefaultAPIKeyAlphabet
+	}
+	t.alphabet = alphabet
+}
+
+// SetRandomSource replaces the source of randomness, e.g. with fixed bytes
+// in tests. A nil source restores the default.
+func (t *TokenGenerator) SetRandomSource(src io.Reader) {
+	t.source = src
+}
+
+// generateEncoded encodes n random bytes and trims the result to the token length
+func (t *TokenGenerator) generateEncoded(n int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, n)
+	if err := randomBytes(t.source, buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	result := encode(buf)
+	clearBytes(buf) // Clear sensitive data from memory
+
+	return result[:t.length], nil
+}
+
+// generateAPIKey picks each character uniformly from the alphabet
+func (t *TokenGenerator) generateAPIKey(ctx context.Context) (string, error) {
+	key := make([]byte, t.length)
+	alphabetSize := big.NewInt(int64(len(t.alphabet)))
+
+	for i := range key {
+		select {
+		case <-ctx.Done():
+			clearBytes(key[:i])
+			return "", ctx.Err()
+		default:
+		}
+
+		randomIndex, err := randomInt(t.source, alphabetSize)
+		if err != nil {
+			clearBytes(key[:i])
+			return "", fmt.Errorf("failed to generate random number: %w", err)
+		}
+		key[i] = t.alphabet[randomIndex.Int64()]
+	}
+
+	result := t.prefix + string(key)
+	clearBytes(key) // Clear sensitive data from memory
+
+	return result, nil
+}
+
+// generateUUID creates a random RFC 4122 version 4 UUID
+func generateUUID(src io.Reader) (string, error) {
+	var uuid [16]byte
+	if err := randomBytes(src, uuid[:]); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // Version 4
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
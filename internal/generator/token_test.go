@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestTokenGenerator(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  TokenFormat
+		length  int
+		wantLen int
+		wantErr bool
+	}{
+		{"UUID", TokenUUID, 0, 36, false},
+		{"Hex default", TokenHex, 0, 32, false},
+		{"Hex odd length", TokenHex, 7, 7, false},
+		{"Base64URL", TokenBase64URL, 43, 43, false},
+		{"API key", TokenAPIKey, 24, 24, false},
+		{"Negative length", TokenHex, -1, 0, true},
+		{"Too long", TokenBase64URL, 2000, 0, true},
+		{"Unknown format", TokenFormat(99), 16, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewTokenGenerator(tt.format, tt.length)
+			token, err := gen.Generate(context.Background())
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(token) != tt.wantLen {
+				t.Errorf("Expected token length %d, got %d (%s)", tt.wantLen, len(token), token)
+			}
+
+			switch tt.format {
+			case TokenUUID:
+				if !uuidV4Pattern.MatchString(token) {
+					t.Errorf("Token %s is not a version 4 UUID", token)
+				}
+			case TokenHex:
+				if _, err := hex.DecodeString(token + strings.Repeat("0", len(token)%2)); err != nil {
+					t.Errorf("Token %s is not hex: %v", token, err)
+				}
+			case TokenBase64URL:
+				if strings.ContainsAny(token, "+/=") {
+					t.Errorf("Token %s is not URL-safe", token)
+				}
+				if _, err := base64.RawURLEncoding.DecodeString(token); err != nil {
+					t.Errorf("Token %s is not base64url: %v", token, err)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenGeneratorAPIKey(t *testing.T) {
+	gen := NewTokenGenerator(TokenAPIKey, 16)
+	gen.SetPrefix("pk_live_")
+	gen.SetAlphabet("abc123")
+
+	key, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if !strings.HasPrefix(key, "pk_live_") {
+		t.Errorf("Expected pk_live_ prefix, got %s", key)
+	}
+
+	body := strings.TrimPrefix(key, "pk_live_")
+	if len(body) != 16 {
+		t.Errorf("Expected 16 random characters, got %d", len(body))
+	}
+	if strings.Trim(body, "abc123") != "" {
+		t.Errorf("Key %s contains characters outside the alphabet", key)
+	}
+}
+
+func TestTokenGeneratorValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		alphabet string
+		wantErr  bool
+	}{
+		{"Default alphabet", "", "", false},
+		{"Custom alphabet", "sk_", "ABCDEF", false},
+		{"Alphabet too small", "", "a", true},
+		{"Duplicate characters", "", "abca", true},
+		{"Whitespace in alphabet", "", "ab c", true},
+		{"Whitespace in prefix", "sk live", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewTokenGenerator(TokenAPIKey, 16)
+			gen.SetPrefix(tt.prefix)
+			gen.SetAlphabet(tt.alphabet)
+
+			err := gen.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenGeneratorEntropy(t *testing.T) {
+	tests := []struct {
+		format TokenFormat
+		length int
+		want   float64
+	}{
+		{TokenUUID, 0, 122},
+		{TokenHex, 32, 128},
+		{TokenBase64URL, 43, 258},
+		{TokenAPIKey, 10, 10 * logBase2(62)},
+	}
+
+	for _, tt := range tests {
+		gen := NewTokenGenerator(tt.format, tt.length)
+		if entropy := gen.EstimateEntropy(); entropy != tt.want {
+			t.Errorf("%s: expected %.2f bits, got %.2f", tt.format, tt.want, entropy)
+		}
+	}
+}
+
+func TestTokenGeneratorUniqueness(t *testing.T) {
+	gen := NewTokenGenerator(TokenUUID, 0)
+	seen := make(map[string]bool)
+
+	for i := 0; i < 100; i++ {
+		token, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if seen[token] {
+			t.Fatalf("Duplicate UUID generated: %s", token)
+		}
+		seen[token] = true
+	}
+}
@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default TOTP parameters as used by most authenticator apps (RFC 6238)
+const (
+	DefaultTOTPSecretBytes = 20 // 160 bits, as recommended by RFC 4226
+	DefaultTOTPDigits      = 6
+	DefaultTOTPPeriod      = 30
+)
+
+// totpEncoding is the unpadded base32 alphabet expected by authenticator apps
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPGenerator generates base32 secrets for time-based one-time passwords
+type TOTPGenerator struct {
+	secretBytes int
+	source      io.Reader // Randomness; nil uses crypto/rand
+}
+
+func init() {
+	Register(Registration{
+		Name:        "totp",
+		Title:       "TOTP Secret",
+		Description: "Base32 secret for an authenticator app",
+		Options:     []Option{OptionBytes},
+		Order:       40,
+		New: func(opts Options) (Generator, error) {
+			return NewTOTPGenerator(opts.Bytes), nil
+		},
+	})
+}
+
+// NewTOTPGenerator creates a new TOTP secret generator producing secrets of
+// the given size in bytes
+func NewTOTPGenerator(secretBytes int) *TOTPGenerator {
+	if secretBytes == 0 {
+		secretBytes = DefaultTOTPSecretBytes
+	}
+
+	return &TOTPGenerator{
+		secretBytes: secretBytes,
+	}
+}
+
+// SetRandomSource replaces the source of randomness, e.g. with fixed bytes
+// in tests. A nil source restores the default.
+func (t *TOTPGenerator) SetRandomSource(src io.Reader) {
+	t.source = src
+}
+
+// Generate creates a new random base32-encoded TOTP secret
+func (t *TOTPGenerator) Generate(ctx context.Context) (string, error) {
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	secret := make([]byte, t.secretBytes)
+	if err := randomBytes(t.source, secret); err != nil {
+		return "", fmt.Errorf("failed to generate random secret: %w", err)
+	}
+
+	result := totpEncoding.EncodeToString(secret)
+	clearBytes(secret) // Clear sensitive data from memory
+
+	return result, nil
+}
+
+// EstimateEntropy returns the entropy of the generated secret in bits
+func (t *TOTPGenerator) EstimateEntropy() float64 {
+	return float64(t.secretBytes * 8)
+}
+
+// GetName returns the generator name
+func (t *TOTPGenerator) GetName() string {
+	return "TOTP Secret"
+}
+
+// Validate checks if the configuration is valid
+func (t *TOTPGenerator) Validate() error {
+	if t.secretBytes < 10 {
+		return errors.New("TOTP secret too short (min 10 bytes)")
+	}
+
+	if t.secretBytes > 64 {
+		return errors.New("TOTP secret too long (max 64 bytes)")
+	}
+
+	return nil
+}
+
+// TOTPCode computes the one-time code for a base32 secret at the given time
+// using HMAC-SHA1 as described in RFC 6238
+func TOTPCode(secret string, at time.Time, digits, period int) (string, error) {
+	if digits < 6 || digits > 8 {
+		return "", errors.New("TOTP digits must be between 6 and 8")
+	}
+
+	if period <= 0 {
+		return "", errors.New("TOTP period must be positive")
+	}
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	defer clearBytes(key)
+
+	counter := uint64(at.Unix() / int64(period))
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	binCode := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, binCode%mod), nil
+}
+
+// TOTPRemaining returns the number of seconds until the current code expires
+func TOTPRemaining(at time.Time, period int) int {
+	if period <= 0 {
+		return 0
+	}
+	return period - int(at.Unix()%int64(period))
+}
+
+// TOTPURI builds an otpauth:// URI for provisioning authenticator apps
+func TOTPURI(secret, issuer, account string) string {
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", DefaultTOTPDigits))
+	params.Set("period", fmt.Sprintf("%d", DefaultTOTPPeriod))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: params.Encode(),
+	}
+
+	return u.String()
+}
+
+// decodeTOTPSecret decodes a base32 secret, tolerating spaces, lowercase and padding
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	cleaned := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	cleaned = strings.TrimRight(cleaned, "=")
+
+	key, err := totpEncoding.DecodeString(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base32 secret: %w", err)
+	}
+
+	if len(key) == 0 {
+		return nil, errors.New("TOTP secret cannot be empty")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"context"
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPGenerator(t *testing.T) {
+	tests := []struct {
+		name        string
+		secretBytes int
+		wantLen     int
+		wantErr     bool
+	}{
+		{"Default size", 0, 32, false},
+		{"Minimum size", 10, 16, false},
+		{"Too short", 5, 0, true},
+		{"Too long", 100, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewTOTPGenerator(tt.secretBytes)
+			secret, err := gen.Generate(context.Background())
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(secret) != tt.wantLen {
+				t.Errorf("Expected secret length %d, got %d", tt.wantLen, len(secret))
+			}
+			if strings.Contains(secret, "=") {
+				t.Error("Secret should not contain padding")
+			}
+			if _, err := totpEncoding.DecodeString(secret); err != nil {
+				t.Errorf("Secret is not valid base32: %v", err)
+			}
+		})
+	}
+}
+
+func TestTOTPGeneratorEntropy(t *testing.T) {
+	gen := NewTOTPGenerator(20)
+	if entropy := gen.EstimateEntropy(); entropy != 160 {
+		t.Errorf("Expected 160 bits of entropy, got %.2f", entropy)
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B test vectors for SHA1
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(secret, time.Unix(tt.unix, 0), 8, 30)
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+		if code != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, code, tt.want)
+		}
+	}
+
+	// Six digit codes are the last six digits of the eight digit code
+	code, err := TOTPCode(strings.ToLower(secret), time.Unix(59, 0), 6, 30)
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	if code != "287082" {
+		t.Errorf("Expected 287082, got %s", code)
+	}
+}
+
+func TestTOTPCodeErrors(t *testing.T) {
+	if _, err := TOTPCode("!!!", time.Now(), 6, 30); err == nil {
+		t.Error("Expected error for invalid base32")
+	}
+	if _, err := TOTPCode("JBSWY3DPEHPK3PXP", time.Now(), 4, 30); err == nil {
+		t.Error("Expected error for too few digits")
+	}
+	if _, err := TOTPCode("JBSWY3DPEHPK3PXP", time.Now(), 6, 0); err == nil {
+		t.Error("Expected error for zero period")
+	}
+}
+
+func TestTOTPRemaining(t *testing.T) {
+	if remaining := TOTPRemaining(time.Unix(59, 0), 30); remaining != 1 {
+		t.Errorf("Expected 1 second remaining, got %d", remaining)
+	}
+	if remaining := TOTPRemaining(time.Unix(60, 0), 30); remaining != 30 {
+		t.Errorf("Expected 30 seconds remaining, got %d", remaining)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("JBSWY3DPEHPK3PXP", "Example", "alice@example.com")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/Example:alice@example.com?") {
+		t.Errorf("Unexpected URI prefix: %s", uri)
+	}
+	for _, param := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Example", "digits=6", "period=30"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("URI %s missing %s", uri, param)
+		}
+	}
+}
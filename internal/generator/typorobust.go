@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MinTypoEditDistance is the minimum Levenshtein distance between any two
+// words of a typo-robust passphrase. Words closer than this could be turned
+// into each other by a single slip of the finger.
+const MinTypoEditDistance = 3
+
+// typoNeighborSampleSize is the number of words sampled when estimating how
+// many words each choice rules out
+const typoNeighborSampleSize = 256
+
+// homophoneGroups lists words that sound alike or are commonly confused.
+// Any word in a group is dropped from typo-robust passphrases, since the
+// user may type its twin instead.
+var homophoneGroups = [][]string{
+	{"accept", "except"},
+	{"affect", "effect"},
+	{"aloud", "allowed"},
+	{"bare", "bear"},
+	{"board", "bored"},
+	{"brake", "break"},
+	{"buy", "by", "bye"},
+	{"cell", "sell"},
+	{"cite", "sight", "site"},
+	{"complement", "compliment"},
+	{"dear", "deer"},
+	{"die", "dye"},
+	{"fair", "fare"},
+	{"flour", "flower"},
+	{"for", "four", "fore"},
+	{"hair", "hare"},
+	{"heal", "heel"},
+	{"hear", "here"},
+	{"hole", "whole"},
+	{"hour", "our"},
+	{"its", "it's"},
+	{"knew", "new"},
+	{"knight", "night"},
+	{"knot", "not"},
+	{"know", "no"},
+	{"lead", "led"},
+	{"loose", "lose"},
+	{"mail", "male"},
+	{"meat", "meet"},
+	{"pail", "pale"},
+	{"pair", "pare", "pear"},
+	{"passed", "past"},
+	{"peace", "piece"},
+	{"peak", "peek", "pique"},
+	{"plain", "plane"},
+	{"pole", "poll"},
+	{"pray", "prey"},
+	{"principal", "principle"},
+	{"rain", "reign", "rein"},
+	{"right", "rite", "write"},
+	{"road", "rode", "rowed"},
+	{"role", "roll"},
+	{"sail", "sale"},
+	{"scene", "seen"},
+	{"sea", "see"},
+	{"sew", "so", "sow"},
+	{"stair", "stare"},
+	{"stationary", "stationery"},
+	{"steal", "steel"},
+	{"suite", "sweet"},
+	{"tail", "tale"},
+	{"than", "then"},
+	{"their", "there", "they're"},
+	{"threw", "through"},
+	{"to", "too", "two"},
+	{"vain", "vane", "vein"},
+	{"waist", "waste"},
+	{"wait", "weight"},
+	{"weak", "week"},
+	{"wear", "where"},
+	{"weather", "whether"},
+	{"which", "witch"},
+	{"wood", "would"},
+	{"your", "you're"},
+}
+
+var (
+	homophoneSet     map[string]bool
+	homophoneSetOnce sync.Once
+
+	typoNeighborCache   = make(map[string]float64)
+	typoNeighborCacheMu sync.Mutex
+)
+
+// isHomophone reports whether word belongs to a group of confusable words
+func isHomophone(word string) bool {
+	homophoneSetOnce.Do(func() {
+		homophoneSet = make(map[string]bool)
+		for _, group := range homophoneGroups {
+			for _, w := range group {
+				homophoneSet[w] = true
+			}
+		}
+	})
+	return homophoneSet[strings.ToLower(word)]
+}
+
+// typoRobustPool returns the wordlist without confusable words
+func typoRobustPool(wordlist []string) []string {
+	pool := make([]string, 0, len(wordlist))
+	for _, word := range wordlist {
+		if !isHomophone(word) {
+			pool = append(pool, word)
+		}
+	}
+	return pool
+}
+
+// isTypoDistant reports whether word is at least MinTypoEditDistance edits
+// away from every word already chosen
+func isTypoDistant(word string, chosen []string) bool {
+	for _, other := range chosen {
+		if editDistanceBelow(word, other, MinTypoEditDistance) {
+			return false
+		}
+	}
+	return true
+}
+
+// editDistanceBelow reports whether the Levenshtein distance between a and b
+// is less than limit. It bails out early once the limit is exceeded.
+func editDistanceBelow(a, b string, limit int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff >= limit || -diff >= limit {
+		return false
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin >= limit {
+			return false
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)] < limit
+}
+
+// averageTypoNeighbors estimates how many other words in the pool lie within
+// MinTypoEditDistance of a word, by sampling evenly across the pool
+func averageTypoNeighbors(pool []string) float64 {
+	if len(pool) < 2 {
+		return 0
+	}
+
+	key := strconv.Itoa(len(pool)) + "\x00" + pool[0] + "\x00" + pool[len(pool)-1]
+	typoNeighborCacheMu.Lock()
+	defer typoNeighborCacheMu.Unlock()
+	if avg, ok := typoNeighborCache[key]; ok {
+		return avg
+	}
+
+	step := max(1, len(pool)/typoNeighborSampleSize)
+	samples, neighbors := 0, 0
+	for i := 0; i < len(pool); i += step {
+		for j, other := range pool {
+			if i != j && editDistanceBelow(pool[i], other, MinTypoEditDistance) {
+				neighbors++
+			}
+		}
+		samples++
+	}
+
+	avg := float64(neighbors) / float64(samples)
+	typoNeighborCache[key] = avg
+	return avg
+}
+
+// TypoRobustEntropy estimates the entropy of a typo-robust passphrase of
+// wordCount words drawn from wordlist. Confusable words are removed from the
+// pool, and each chosen word is assumed to rule out itself plus its average
+// number of close neighbours for every later position.
+func TypoRobustEntropy(wordlist []string, wordCount int) float64 {
+	pool := typoRobustPool(wordlist)
+	if len(pool) == 0 || wordCount <= 0 {
+		return 0
+	}
+
+	excludedPerWord := 1 + averageTypoNeighbors(pool)
+
+	entropy := 0.0
+	for i := 0; i < wordCount; i++ {
+		remaining := float64(len(pool)) - float64(i)*excludedPerWord
+		if remaining < 1 {
+			remaining = 1
+		}
+		entropy += logBase2(remaining)
+	}
+	return entropy
+}
@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"math"
+	"time"
 )
 
 // logBase2 calculates logarithm base 2
@@ -77,6 +78,14 @@ func CharSetToString(cs CharSet) string {
 		return "symbols"
 	case Ambiguous:
 		return "ambiguous"
+	case German:
+		return "german"
+	case French:
+		return "french"
+	case Spanish:
+		return "spanish"
+	case Nordic:
+		return "nordic"
 	default:
 		return "unknown"
 	}
@@ -102,6 +111,35 @@ func SecurityLevelToString(level SecurityLevel) string {
 	}
 }
 
+// ParseSecurityLevel converts the string representation of a SecurityLevel
+// back, reporting false if s names no level
+func ParseSecurityLevel(s string) (SecurityLevel, bool) {
+	for level := VeryWeak; level <= VeryStrong; level++ {
+		if SecurityLevelToString(level) == s {
+			return level, true
+		}
+	}
+	return VeryWeak, false
+}
+
+// EntropySecurityLevel maps an entropy estimate in bits to a security level
+func EntropySecurityLevel(entropy float64) SecurityLevel {
+	switch {
+	case entropy >= 80:
+		return VeryStrong
+	case entropy >= 60:
+		return Strong
+	case entropy >= 45:
+		return Good
+	case entropy >= 30:
+		return Fair
+	case entropy >= 20:
+		return Weak
+	default:
+		return VeryWeak
+	}
+}
+
 // GetSecurityLevelColor returns a color code for the security level (for UI)
 func GetSecurityLevelColor(level SecurityLevel) string {
 	switch level {
@@ -122,31 +160,24 @@ func GetSecurityLevelColor(level SecurityLevel) string {
 	}
 }
 
-// EstimateGenerationTime estimates how long it would take to generate passwords
-func EstimateGenerationTime(count int, generatorType string) string {
-	baseTimeNs := 1000 // Base time in nanoseconds
-	
-	switch generatorType {
-	case "Random Password":
-		baseTimeNs *= 10 // Random generation is relatively fast
-	case "Memorable Passphrase":
-		baseTimeNs *= 50 // Wordlist lookup takes more time
-	case "Numeric PIN":
-		baseTimeNs *= 5  // Numeric generation is fastest
-	default:
-		baseTimeNs *= 20
+// EstimateGenerationTime estimates how long generating count passwords
+// takes at perSecond, a rate measured by Benchmark. Without a measured
+// rate it returns "unknown".
+func EstimateGenerationTime(count int, perSecond float64) string {
+	if perSecond <= 0 {
+		return "unknown"
 	}
-	
-	totalNs := int64(count * baseTimeNs)
-	
-	if totalNs < 1000000 { // Less than 1ms
+	total := time.Duration(float64(count) / perSecond * float64(time.Second))
+
+	switch {
+	case total < time.Millisecond:
 		return "< 1ms"
-	} else if totalNs < 1000000000 { // Less than 1s
+	case total < time.Second:
 		return "< 1s"
-	} else {
-		seconds := totalNs / 1000000000
-		return fmt.Sprintf("%ds", seconds)
+	case total < time.Minute:
+		return fmt.Sprintf("%ds", int(total.Seconds()))
 	}
+	return total.Round(time.Second).String()
 }
 
 // ValidatePasswordStrength validates if a password meets minimum requirements
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+)
+
+// MinSafeEntropy is the entropy in bits below which a configuration is
+// flagged as too weak for an account without other protections
+const MinSafeEntropy = 60.0
+
+// EntropyWarning describes a configuration whose entropy is below
+// MinSafeEntropy, with ways to make up for it
+type EntropyWarning struct {
+	Bits        float64
+	Suggestions []string
+}
+
+// String formats the warning headline with the exact bit count
+func (w *EntropyWarning) String() string {
+	return fmt.Sprintf("Low entropy: %.1f bits, below the %.0f-bit safe minimum", w.Bits, MinSafeEntropy)
+}
+
+// CheckEntropy returns a warning if passwords from g would have less than
+// MinSafeEntropy bits, or nil if the configuration is safe. Suggestions
+// cover both fixing the configuration and, when a site policy forces it,
+// compensating controls.
+func CheckEntropy(g Generator) *EntropyWarning {
+	bits := g.EstimateEntropy()
+	if bits >= MinSafeEntropy {
+		return nil
+	}
+
+	w := &EntropyWarning{Bits: bits}
+	isPassword := true
+
+	switch gen := g.(type) {
+	case *RandomGenerator:
+		charsetSize := len(gen.alphabet.runes)
+		if charsetSize > 1 {
+			w.Suggestions = append(w.Suggestions,
+				fmt.Sprintf("Increase the length to at least %d characters", unitsNeeded(logBase2(float64(charsetSize)))))
+		}
+		if charsetSize < 62 {
+			w.Suggestions = append(w.Suggestions,
+				fmt.Sprintf("Enable more character types (only %d characters in use)", charsetSize))
+		}
+	case *PINGenerator:
+		w.Suggestions = append(w.Suggestions,
+			"Use PINs only where failed attempts are rate-limited or lock the account")
+	case *MemorableGenerator:
+		if len(gen.wordlist) > 1 && gen.config.WordCount > 0 {
+			perWord := bits / float64(gen.config.WordCount)
+			w.Suggestions = append(w.Suggestions,
+				fmt.Sprintf("Use at least %d words", unitsNeeded(perWord)))
+		}
+	case *KeyGenerator:
+		isPassword = false
+		w.Suggestions = append(w.Suggestions,
+			fmt.Sprintf("Use at least %d bytes", unitsNeeded(8)))
+	case *TokenGenerator:
+		isPassword = false
+		w.Suggestions = append(w.Suggestions, "Increase the token length")
+	}
+
+	// Compensating controls for when a site policy forces a weak password
+	if isPassword {
+		w.Suggestions = append(w.Suggestions,
+			"If a site policy forces this, enable two-factor authentication on the account",
+			"Rotate the password more often and never reuse it")
+	}
+
+	return w
+}
+
+// unitsNeeded returns how many characters, words or bytes of the given
+// entropy each are needed to reach MinSafeEntropy
+func unitsNeeded(bitsPerUnit float64) int {
+	if bitsPerUnit <= 0 {
+		return 0
+	}
+	return int(math.Ceil(MinSafeEntropy / bitsPerUnit))
+}
@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckEntropy(t *testing.T) {
+	// 8 digits is about 26.6 bits
+	w := CheckEntropy(NewRandomGenerator(8, Numbers))
+	if w == nil {
+		t.Fatal("Expected a warning for an 8-digit password")
+	}
+	if !strings.Contains(w.String(), "26.6 bits") {
+		t.Errorf("Expected the exact bit count in %q", w.String())
+	}
+	if !strings.Contains(w.Suggestions[0], "at least 19 characters") {
+		t.Errorf("Expected a length suggestion, got %q", w.Suggestions[0])
+	}
+
+	if w := CheckEntropy(NewRandomGenerator(16, Lowercase, Uppercase, Numbers)); w != nil {
+		t.Errorf("Expected no warning for a 16-character alphanumeric password, got %v", w)
+	}
+
+	if w := CheckEntropy(NewPINGenerator(6)); w == nil {
+		t.Error("Expected a warning for a 6-digit PIN")
+	}
+
+	if w := CheckEntropy(NewKeyGenerator(32, KeyHex)); w != nil {
+		t.Errorf("Expected no warning for a 256-bit key, got %v", w)
+	}
+}
@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//go:embed data/*.txt
+var wordlistData embed.FS
+
+// WordlistInfo describes a wordlist bundled with the generator package
+type WordlistInfo struct {
+	ID       string
+	Name     string
+	Language string
+	file     string // Embedded data file, empty for built-in lists
+	size     int    // Number of words, checked by CheckWordlist
+}
+
+// Default wordlist identifier
+const DefaultWordlistID = "eff-large"
+
+// bundledWordlists lists all wordlists available for passphrase generation
+var bundledWordlists = []WordlistInfo{
+	{ID: DefaultWordlistID, Name: "EFF Large", Language: "English", size: 7776},
+	{ID: "eff-short-2", Name: "EFF Short #2", Language: "English", file: "data/eff_short_wordlist_2_0.txt", size: 1296},
+	{ID: "bip39-en", Name: "BIP-39", Language: "English", file: "data/bip39_english.txt", size: 2048},
+	{ID: "bip39-fr", Name: "BIP-39", Language: "French", file: "data/bip39_french.txt", size: 2048},
+	{ID: "bip39-es", Name: "BIP-39", Language: "Spanish", file: "data/bip39_spanish.txt", size: 2048},
+	{ID: "diceware-de", Name: "Diceware", Language: "German", file: "data/de_diceware_wordlist.txt", size: 7776},
+}
+
+var (
+	wordlistCache   = make(map[string][]string)
+	wordlistCacheMu sync.Mutex
+)
+
+// BundledWordlists returns information about all bundled wordlists
+func BundledWordlists() []WordlistInfo {
+	result := make([]WordlistInfo, len(bundledWordlists))
+	copy(result, bundledWordlists)
+	return result
+}
+
+// GetBundledWordlist returns the words of the bundled wordlist with the given ID
+func GetBundledWordlist(id string) ([]string, error) {
+	info, ok := findWordlistInfo(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown wordlist: %s", id)
+	}
+
+	if info.file == "" {
+		return GetEFFWordlist(), nil
+	}
+
+	wordlistCacheMu.Lock()
+	defer wordlistCacheMu.Unlock()
+
+	if words, ok := wordlistCache[id]; ok {
+		return words, nil
+	}
+
+	words, err := parseWordlistFile(info.file)
+	if err != nil {
+		return nil, err
+	}
+
+	wordlistCache[id] = words
+	return words, nil
+}
+
+// WordlistEntropyPerWord returns the entropy in bits contributed by each word
+// chosen uniformly from the wordlist with the given ID
+func WordlistEntropyPerWord(id string) float64 {
+	words, err := GetBundledWordlist(id)
+	if err != nil {
+		return 0
+	}
+	return logBase2(float64(len(words)))
+}
+
+// DisplayName returns a human-readable name including the language
+func (w WordlistInfo) DisplayName() string {
+	if w.Language == "" || w.Language == "English" {
+		return w.Name
+	}
+	return fmt.Sprintf("%s (%s)", w.Name, w.Language)
+}
+
+// findWordlistInfo looks up a bundled wordlist by ID
+func findWordlistInfo(id string) (WordlistInfo, bool) {
+	for _, info := range bundledWordlists {
+		if info.ID == id {
+			return info, true
+		}
+	}
+	return WordlistInfo{}, false
+}
+
+// parseWordlistFile reads an embedded wordlist. Both diceware format
+// ("11111	word") and plain one-word-per-line files are supported.
+func parseWordlistFile(name string) ([]string, error) {
+	file, err := wordlistData.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded wordlist: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		words = append(words, fields[len(fields)-1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+
+	if len(words) == 0 {
+		return nil, fmt.Errorf("wordlist %s is empty", name)
+	}
+
+	return unique(words), nil
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBundledWordlists(t *testing.T) {
+	expectedSizes := map[string]int{
+		"eff-large":   7776,
+		"eff-short-2": 1296,
+		"bip39-en":    2048,
+		"bip39-fr":    2048,
+		"bip39-es":    2048,
+		"diceware-de": 7776,
+	}
+
+	for _, info := range BundledWordlists() {
+		t.Run(info.ID, func(t *testing.T) {
+			words, err := GetBundledWordlist(info.ID)
+			if err != nil {
+				t.Fatalf("Failed to load wordlist: %v", err)
+			}
+
+			if expected, ok := expectedSizes[info.ID]; ok && len(words) != expected {
+				t.Errorf("Expected %d words, got %d", expected, len(words))
+			}
+
+			for _, word := range words {
+				if word == "" || strings.ContainsAny(word, " \t") {
+					t.Errorf("Invalid word in wordlist: %q", word)
+					break
+				}
+			}
+
+			gen := NewMemorableGenerator(4, "-", words)
+			if _, err := gen.Generate(context.Background()); err != nil {
+				t.Errorf("Failed to generate passphrase: %v", err)
+			}
+		})
+	}
+}
+
+func TestBundledWordlistUnknown(t *testing.T) {
+	if _, err := GetBundledWordlist("klingon"); err == nil {
+		t.Error("Expected error for unknown wordlist")
+	}
+
+	if entropy := WordlistEntropyPerWord("klingon"); entropy != 0 {
+		t.Errorf("Expected 0 entropy for unknown wordlist, got %.2f", entropy)
+	}
+}
+
+func TestWordlistEntropyPerWord(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected float64
+	}{
+		{"eff-short-2", math.Log2(1296)},
+		{"bip39-en", 11},
+		{"diceware-de", math.Log2(7776)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			entropy := WordlistEntropyPerWord(tt.id)
+			if math.Abs(entropy-tt.expected) > 0.01 {
+				t.Errorf("Expected %.2f bits per word, got %.2f", tt.expected, entropy)
+			}
+		})
+	}
+}
+
+func TestWordlistDisplayName(t *testing.T) {
+	info := WordlistInfo{Name: "BIP-39", Language: "French"}
+	if name := info.DisplayName(); name != "BIP-39 (French)" {
+		t.Errorf("Unexpected display name: %s", name)
+	}
+
+	info = WordlistInfo{Name: "EFF Large", Language: "English"}
+	if name := info.DisplayName(); name != "EFF Large" {
+		t.Errorf("Unexpected display name: %s", name)
+	}
+}
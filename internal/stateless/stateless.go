@@ -0,0 +1,203 @@
+// Package stateless derives site passwords from a master passphrase, the
+// site, a login and a counter, so the same inputs always give the same
+// password and nothing has to be stored.
+//
+// It is kept apart from the random generators on purpose: every constant
+// here is part of the derivation, and changing one changes every password
+// ever derived. A new derivation gets a new Version instead.
+package stateless
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Version of the derivation, recorded in the salt
+const Version = 1
+
+// Argon2id parameters of the derivation. They are fixed: raising them
+// would change every derived password.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024 // KiB
+	argon2Threads = 4
+	keySize       = 32
+)
+
+// Length limits of a derived password
+const (
+	DefaultLength = 20
+	MinLength     = 8
+	MaxLength     = 64
+)
+
+// Character classes of a derived password, fixed for the same reason
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!#$%&*+-./:;<=>?@^_~"
+)
+
+// Profile is everything besides the master passphrase that a password is
+// derived from. Change Counter to rotate a site's password.
+type Profile struct {
+	Site    string
+	Login   string
+	Counter int // From 1
+	Length  int
+	Lower   bool
+	Upper   bool
+	Digits  bool
+	Symbols bool
+}
+
+// DefaultProfile returns a profile for site using every character class
+func DefaultProfile(site string) Profile {
+	return Profile{
+		Site:    site,
+		Counter: 1,
+		Length:  DefaultLength,
+		Lower:   true,
+		Upper:   true,
+		Digits:  true,
+		Symbols: true,
+	}
+}
+
+// NormalizeSite reduces a site to the form passwords are derived from, so
+// "https://www.Example.com/login" and "example.com" give the same password
+func NormalizeSite(site string) string {
+	site = strings.ToLower(strings.TrimSpace(site))
+	if _, rest, ok := strings.Cut(site, "://"); ok {
+		site = rest
+	}
+	site, _, _ = strings.Cut(site, "/")
+	return strings.TrimPrefix(site, "www.")
+}
+
+// Validate checks the profile before anything is derived
+func (p Profile) Validate() error {
+	if NormalizeSite(p.Site) == "" {
+		return fmt.Errorf("site is required")
+	}
+	if p.Counter < 1 {
+		return fmt.Errorf("counter must be at least 1")
+	}
+	if p.Length < MinLength || p.Length > MaxLength {
+		return fmt.Errorf("length must be between %d and %d", MinLength, MaxLength)
+	}
+	if len(p.classes()) == 0 {
+		return fmt.Errorf("at least one character class is required")
+	}
+	return nil
+}
+
+// classes returns the character classes the profile uses
+func (p Profile) classes() []string {
+	var classes []string
+	if p.Lower {
+		classes = append(classes, lowerChars)
+	}
+	if p.Upper {
+		classes = append(classes, upperChars)
+	}
+	if p.Digits {
+		classes = append(classes, digitChars)
+	}
+	if p.Symbols {
+		classes = append(classes, symbolChars)
+	}
+	return classes
+}
+
+// info describes the password's template for the key expansion, so each
+// counter, length and class choice gives an unrelated password
+func (p Profile) info() string {
+	flags := ""
+	for _, on := range []bool{p.Lower, p.Upper, p.Digits, p.Symbols} {
+		if on {
+			flags += "1"
+		} else {
+			flags += "0"
+		}
+	}
+	return fmt.Sprintf("passman-stateless-v%d password %d %d %s", Version, p.Counter, p.Length, flags)
+}
+
+// Derive returns the password of the profile. The master passphrase is
+// stretched with Argon2id salted by the site and login, and the result is
+// expanded with HKDF-SHA256 into the password's characters. It takes about
+// as long as opening an Argon2id history.
+func Derive(passphrase string, p Profile) (string, error) {
+	if passphrase == "" {
+		return "", fmt.Errorf("master passphrase is required")
+	}
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	salt := sha256.Sum256([]byte(fmt.Sprintf("passman-stateless-v%d\x00%s\x00%s", Version, NormalizeSite(p.Site), p.Login)))
+	key := argon2.IDKey([]byte(passphrase), salt[:], argon2Time, argon2Memory, argon2Threads, keySize)
+	defer clear(key)
+
+	stream := hkdf.Expand(sha256.New, key, []byte(p.info()))
+	classes := p.classes()
+	all := strings.Join(classes, "")
+
+	password := make([]byte, p.Length)
+	defer clear(password)
+	// One character of every class, then any, then shuffled together
+	for i := range password {
+		chars := all
+		if i < len(classes) {
+			chars = classes[i]
+		}
+		n, err := uniform(stream, len(chars))
+		if err != nil {
+			return "", err
+		}
+		password[i] = chars[n]
+	}
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := uniform(stream, i+1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// uniform reads a number in [0, n) from stream without modulo bias, for n
+// up to 256
+func uniform(stream io.Reader, n int) (int, error) {
+	limit := 256 - 256%n
+	var b [1]byte
+	for {
+		if _, err := io.ReadFull(stream, b[:]); err != nil {
+			return 0, fmt.Errorf("key expansion failed: %w", err)
+		}
+		if int(b[0]) < limit {
+			return int(b[0]) % n, nil
+		}
+	}
+}
+
+// Fingerprint returns a short code of the master passphrase, the same on
+// every machine, to show next to derived passwords: a different code
+// means the passphrase was mistyped. It reveals too little to guess the
+// passphrase from.
+func Fingerprint(passphrase string) string {
+	salt := sha256.Sum256([]byte("passman-stateless-v" + strconv.Itoa(Version) + " fingerprint"))
+	key := argon2.IDKey([]byte(passphrase), salt[:], argon2Time, argon2Memory, argon2Threads, keySize)
+	defer clear(key)
+	return hex.EncodeToString(key[:2]) // 16 bits: enough to spot a typo
+}
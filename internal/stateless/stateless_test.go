@@ -0,0 +1,113 @@
+package stateless
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+func TestDeriveKnownAnswer(t *testing.T) {
+	// Pinned: a change here changes every password users have derived
+	profile := DefaultProfile("example.com")
+	profile.Login = "alice"
+
+	tests := []struct {
+		counter int
+		want    string
+	}{
+		{1, "kx>w0/?9@rXdWR7-pVZK"},
+		{2, "_wFq&;tiJUPV=;f!0041"},
+	}
+	for _, tt := range tests {
+		profile.Counter = tt.counter
+		got, err := Derive(testPassphrase, profile)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Counter %d: expected %q, got %q", tt.counter, tt.want, got)
+		}
+	}
+
+	if got := Fingerprint(testPassphrase); got != "6ef9" {
+		t.Errorf("Expected fingerprint 6ef9, got %s", got)
+	}
+}
+
+func TestDeriveInputsMatter(t *testing.T) {
+	base := DefaultProfile("example.com")
+	want, err := Derive(testPassphrase, base)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	same := DefaultProfile("https://www.Example.com/login")
+	if got, _ := Derive(testPassphrase, same); got != want {
+		t.Errorf("Expected the normalized site to give %q, got %q", want, got)
+	}
+
+	changes := map[string]func(p *Profile){
+		"site":    func(p *Profile) { p.Site = "example.org" },
+		"login":   func(p *Profile) { p.Login = "bob" },
+		"counter": func(p *Profile) { p.Counter = 2 },
+		"symbols": func(p *Profile) { p.Symbols = false },
+	}
+	for name, change := range changes {
+		profile := base
+		change(&profile)
+		if got, _ := Derive(testPassphrase, profile); got == want {
+			t.Errorf("Expected a different %s to give a different password", name)
+		}
+	}
+	if got, _ := Derive("another passphrase", base); got == want {
+		t.Error("Expected a different passphrase to give a different password")
+	}
+}
+
+func TestDeriveClasses(t *testing.T) {
+	profile := DefaultProfile("example.com")
+	profile.Length = MinLength
+	profile.Symbols = false
+
+	for counter := 1; counter <= 5; counter++ {
+		profile.Counter = counter
+		password, err := Derive(testPassphrase, profile)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(password) != MinLength {
+			t.Errorf("Expected length %d, got %d", MinLength, len(password))
+		}
+		for _, class := range []string{lowerChars, upperChars, digitChars} {
+			if !strings.ContainsAny(password, class) {
+				t.Errorf("Expected %q to contain one of %q", password, class)
+			}
+		}
+		if strings.ContainsAny(password, symbolChars) {
+			t.Errorf("Expected %q to have no symbols", password)
+		}
+	}
+}
+
+func TestDeriveInvalid(t *testing.T) {
+	valid := DefaultProfile("example.com")
+	if _, err := Derive("", valid); err == nil {
+		t.Error("Expected an error for an empty passphrase")
+	}
+
+	invalid := map[string]func(p *Profile){
+		"no site":    func(p *Profile) { p.Site = " https:// " },
+		"counter 0":  func(p *Profile) { p.Counter = 0 },
+		"too short":  func(p *Profile) { p.Length = MinLength - 1 },
+		"too long":   func(p *Profile) { p.Length = MaxLength + 1 },
+		"no classes": func(p *Profile) { p.Lower, p.Upper, p.Digits, p.Symbols = false, false, false, false },
+	}
+	for name, change := range invalid {
+		profile := valid
+		change(&profile)
+		if _, err := Derive(testPassphrase, profile); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
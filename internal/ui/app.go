@@ -0,0 +1,339 @@
+package ui
+
+import (
+	"reflect"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// Screen represents different app screens
+type Screen int
+
+const (
+	MenuScreen Screen = iota
+	RandomScreen
+	MemorableScreen
+	PINScreen
+	TOTPScreen
+	TokenScreen
+	KeyScreen
+	HistoryScreen
+	AuditScreen
+	PickScreen
+	ScratchpadScreen
+	ProfilesScreen
+	SettingsScreen
+	WhatsNewScreen
+	TutorialScreen
+	KeybindingsScreen
+	WizardScreen
+	CompareScreen
+)
+
+// keepsState reports whether a screen is kept when left, so that lengths
+// typed into a generator or the history's filter and page are still there
+// on coming back. Other screens start afresh on every visit.
+func keepsState(screen Screen) bool {
+	switch screen {
+	case MenuScreen, RandomScreen, MemorableScreen, PINScreen, TokenScreen, KeyScreen, HistoryScreen, SettingsScreen:
+		return true
+	}
+	return false
+}
+
+// holdsHistory reports whether a screen shows decrypted history, and so
+// must not outlive a lock or a switch to another profile
+func holdsHistory(screen Screen) bool {
+	switch screen {
+	case HistoryScreen, AuditScreen, PickScreen, ScratchpadScreen:
+		return true
+	}
+	return false
+}
+
+// newScreen creates the model of a screen
+func newScreen(screen Screen, manager *utils.Manager, width, height int) tea.Model {
+	switch screen {
+	case RandomScreen:
+		return NewGeneratorModelWithSize("random", manager, width, height)
+	case MemorableScreen:
+		return NewGeneratorModelWithSize("memorable", manager, width, height)
+	case PINScreen:
+		return NewGeneratorModelWithSize("pin", manager, width, height)
+	case TOTPScreen:
+		return NewTOTPModelWithSize(manager, width, height)
+	case TokenScreen:
+		return NewTokenModelWithSize(manager, width, height)
+	case KeyScreen:
+		return NewKeyModelWithSize(manager, width, height)
+	case HistoryScreen:
+		return NewHistoryModelWithSize(manager, width, height)
+	case AuditScreen:
+		return NewAuditModelWithSize(manager, width, height)
+	case PickScreen:
+		return NewPickModelWithSize(manager, width, height)
+	case ScratchpadScreen:
+		return NewScratchpadModelWithSize(manager, width, height)
+	case ProfilesScreen:
+		return NewProfilesModelWithSize(manager, width, height)
+	case SettingsScreen:
+		return NewSettingsModelWithSize(manager, width, height)
+	case WhatsNewScreen:
+		return NewWhatsNewModelWithSize(manager, width, height)
+	case TutorialScreen:
+		return NewTutorialModelWithSize(manager, width, height)
+	case KeybindingsScreen:
+		return NewKeybindingsModelWithSize(manager, width, height)
+	case WizardScreen:
+		return NewWizardModelWithSize(manager, width, height)
+	case CompareScreen:
+		return NewCompareModelWithSize(manager, width, height)
+	}
+	return NewMenuModelWithSize(manager, width, height)
+}
+
+// navigateMsg asks the app to show another screen
+type navigateMsg struct {
+	screen Screen
+	back   bool    // Return to the screen shown before instead
+	then   tea.Msg // Sent to the screen once it is shown
+}
+
+// navigate returns the command that shows screen
+func navigate(screen Screen) tea.Cmd {
+	return func() tea.Msg {
+		return navigateMsg{screen: screen}
+	}
+}
+
+// navigateWith returns the command that shows screen and then sends it
+// msg, such as a preset to load
+func navigateWith(screen Screen, msg tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return navigateMsg{screen: screen, then: msg}
+	}
+}
+
+// navigateBack returns the command that goes back to the screen shown
+// before, for screens opened from several places such as the scratchpad
+func navigateBack() tea.Cmd {
+	return func() tea.Msg {
+		return navigateMsg{back: true}
+	}
+}
+
+// screenMsg is a message produced by a command of a screen. It goes back
+// to that screen even if another is shown by then, so a generation that
+// finishes after leaving still lands on its generator.
+type screenMsg struct {
+	screen Screen
+	msg    tea.Msg
+}
+
+// teaPkgPath is where Bubble Tea's own messages, such as a quit or a
+// batch, come from; those are for the program and are never routed
+var teaPkgPath = reflect.TypeOf(tea.QuitMsg{}).PkgPath()
+
+// tag marks the messages of cmd as coming from screen
+func tag(screen Screen, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg := cmd()
+		switch msg := msg.(type) {
+		case nil, navigateMsg:
+			return msg
+		case confirmMsg:
+			// Whatever is confirmed still belongs to the screen that asked
+			msg.yes = tag(screen, msg.yes)
+			return msg
+		case tea.BatchMsg:
+			tagged := make(tea.BatchMsg, len(msg))
+			for i, c := range msg {
+				tagged[i] = tag(screen, c)
+			}
+			return tagged
+		}
+		if t := reflect.TypeOf(msg); t.PkgPath() == teaPkgPath ||
+			t.Kind() == reflect.Pointer && t.Elem().PkgPath() == teaPkgPath {
+			return msg
+		}
+		return screenMsg{screen: screen, msg: msg}
+	}
+}
+
+// refresher is a kept screen that re-reads what may have changed while it
+// was hidden, such as entries added to the history
+type refresher interface {
+	refresh()
+}
+
+// AppModel is the root of the TUI. It owns the screens and routes every
+// message to the one shown; screens move between each other by returning
+// navigate commands rather than building each other.
+type AppModel struct {
+	manager  *utils.Manager
+	screen   Screen
+	previous Screen
+	screens  map[Screen]tea.Model
+	dialog   *confirmDialog // Shown over the screen until answered
+	width    int
+	height   int
+}
+
+// NewAppModel creates the app showing screen first
+func NewAppModel(manager *utils.Manager, screen Screen) *AppModel {
+	return &AppModel{
+		manager:  manager,
+		screen:   screen,
+		previous: MenuScreen,
+		screens:  make(map[Screen]tea.Model),
+	}
+}
+
+// Screen returns the screen shown
+func (m *AppModel) Screen() Screen {
+	return m.screen
+}
+
+// current returns the model of the screen shown, creating it if needed
+func (m *AppModel) current() tea.Model {
+	model, ok := m.screens[m.screen]
+	if !ok {
+		model = newScreen(m.screen, m.manager, m.width, m.height)
+		m.screens[m.screen] = model
+	}
+	return model
+}
+
+func (m *AppModel) Init() tea.Cmd {
+	return tag(m.screen, m.current().Init())
+}
+
+func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		// Hidden screens are laid out again too, to come back at the right size
+		var cmds []tea.Cmd
+		for screen, model := range m.screens {
+			var cmd tea.Cmd
+			m.screens[screen], cmd = model.Update(msg)
+			cmds = append(cmds, tag(screen, cmd))
+		}
+		return m, tea.Batch(cmds...)
+
+	case screenMsg:
+		if msg.screen == m.screen {
+			return m, m.forward(msg.msg)
+		}
+		// A hidden screen that was kept still gets it; one that was closed
+		// is gone, and so is anything it was waiting for
+		model, ok := m.screens[msg.screen]
+		if !ok {
+			return m, nil
+		}
+		model, cmd := model.Update(msg.msg)
+		m.screens[msg.screen] = model
+		return m, tag(msg.screen, cmd)
+
+	case confirmMsg:
+		m.dialog = &confirmDialog{prompt: msg.prompt, yes: msg.yes}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.dialog != nil {
+			answered, cmd := m.dialog.update(msg)
+			if answered {
+				m.dialog = nil
+			}
+			return m, cmd
+		}
+
+	case tea.MouseMsg:
+		if m.dialog != nil {
+			return m, nil
+		}
+
+	case navigateMsg:
+		if msg.back {
+			return m, m.show(m.previous)
+		}
+		cmd := m.show(msg.screen)
+		if msg.then != nil {
+			cmd = tea.Batch(cmd, m.forward(msg.then))
+		}
+		return m, cmd
+
+	case ConfigReloadedMsg:
+		// Hidden screens may hold settings or, after a profile switch,
+		// another profile's history; they are rebuilt on the next visit
+		for screen := range m.screens {
+			if screen != m.screen {
+				delete(m.screens, screen)
+			}
+		}
+	}
+
+	return m, m.forward(msg)
+}
+
+// forward passes msg to the screen shown
+func (m *AppModel) forward(msg tea.Msg) tea.Cmd {
+	model, cmd := m.current().Update(msg)
+	m.screens[m.screen] = model
+	return tag(m.screen, cmd)
+}
+
+// show switches to screen. A kept screen comes back as it was left,
+// refreshed and resized; any other is created anew.
+func (m *AppModel) show(screen Screen) tea.Cmd {
+	if screen == m.screen {
+		return nil
+	}
+	if !keepsState(m.screen) {
+		delete(m.screens, m.screen)
+	}
+	m.previous, m.screen = m.screen, screen
+
+	model, ok := m.screens[screen]
+	if !ok {
+		return tag(screen, m.current().Init())
+	}
+	if r, ok := model.(refresher); ok {
+		r.refresh()
+	}
+	return m.forward(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+}
+
+// lock closes the screens holding decrypted history before the manager
+// locks, saving the scratchpad first, and falls back to the menu if one
+// of them was shown
+func (m *AppModel) lock() {
+	// What was asked may have been about the history
+	m.dialog = nil
+	if pad, ok := m.screens[ScratchpadScreen].(*ScratchpadModel); ok {
+		pad.save()
+	}
+	for screen := range m.screens {
+		if holdsHistory(screen) {
+			delete(m.screens, screen)
+		}
+	}
+	if holdsHistory(m.screen) {
+		m.screen = MenuScreen
+	}
+	if holdsHistory(m.previous) {
+		m.previous = MenuScreen
+	}
+}
+
+func (m *AppModel) View() string {
+	if m.dialog != nil {
+		return m.dialog.view(m.width, m.height)
+	}
+	return m.current().View()
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// AuditModel lists the passwords the history uses more than once. Reuse
+// is the biggest practical risk passman can see: one leak exposes every
+// account sharing the password.
+type AuditModel struct {
+	width     int
+	height    int
+	manager   *utils.Manager
+	groups    []utils.ReuseGroup
+	total     int // Entries scanned
+	cursor    int
+	masked    bool
+	statusMsg string
+}
+
+// NewAuditModel scans the history for reused passwords
+func NewAuditModel(manager *utils.Manager) *AuditModel {
+	m := &AuditModel{manager: manager, masked: masksPasswords(manager)}
+	m.scan()
+	return m
+}
+
+// NewAuditModelWithSize creates the reuse audit with specified dimensions
+func NewAuditModelWithSize(manager *utils.Manager, width, height int) *AuditModel {
+	model := NewAuditModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+// scan loads the history and groups the entries sharing a password
+func (m *AuditModel) scan() {
+	if m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.statusMsg = "History is disabled"
+		return
+	}
+	entries, err := m.manager.History.LoadHistory()
+	if err != nil {
+		m.statusMsg = "Failed to load history: " + err.Error()
+		return
+	}
+	m.total = len(entries)
+	m.groups = utils.FindReuse(entries)
+}
+
+func (m *AuditModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *AuditModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionBack), keys.Matches(msg, ActionQuit):
+			return m, navigate(HistoryScreen)
+		case keys.Matches(msg, ActionUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case keys.Matches(msg, ActionDown):
+			if m.cursor < len(m.groups)-1 {
+				m.cursor++
+			}
+		case keys.Matches(msg, ActionReveal):
+			m.masked = !m.masked
+		}
+	}
+	return m, nil
+}
+
+// groupLines renders a reuse group: the password, then its entries
+func (m *AuditModel) groupLines(group utils.ReuseGroup, selected bool) []string {
+	password := maskSecret(group.Password, m.masked)
+	if selected && !m.masked {
+		password = colorizeSecret(group.Password)
+	}
+
+	summary := fmt.Sprintf("used by %d entries", len(group.Entries))
+	if group.Unacknowledged() <= 1 {
+		summary += " · all linked"
+	}
+	lines := []string{checkbox("", selected) + password + "  " + subtleStyle.Render(summary)}
+
+	for _, entry := range group.Entries {
+		line := "    " + entry.Label() + subtleStyle.Render(" · "+entry.CreatedAt.Format("Jan 2 2006"))
+		if entry.Linked() {
+			line += subtleStyle.Render(" · ↪ linked")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// groupsView renders the groups, scrolled to keep the selected one visible
+func (m *AuditModel) groupsView() string {
+	var lines []string
+	selectedStart, selectedEnd := 0, 0
+	for i, group := range m.groups {
+		if i == m.cursor {
+			selectedStart = len(lines)
+		}
+		lines = append(lines, m.groupLines(group, i == m.cursor)...)
+		if i == m.cursor {
+			selectedEnd = len(lines)
+		}
+		lines = append(lines, "")
+	}
+
+	visible := m.height - 14
+	if visible < 5 {
+		visible = 5
+	}
+	if len(lines) <= visible {
+		return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	}
+
+	start := 0
+	if selectedEnd > visible {
+		start = selectedEnd - visible
+	}
+	if selectedStart < start {
+		start = selectedStart
+	}
+	end := start + visible
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return strings.TrimRight(strings.Join(lines[start:end], "\n"), "\n") +
+		"\n" + subtleStyle.Render(fmt.Sprintf("group %d of %d", m.cursor+1, len(m.groups)))
+}
+
+func (m *AuditModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("🔁 Password Reuse Audit")
+
+	sections := []string{title}
+
+	switch {
+	case m.statusMsg != "":
+		sections = append(sections, lipgloss.NewStyle().Foreground(theme.Accent).Render(m.statusMsg))
+	case len(m.groups) == 0:
+		sections = append(sections, lipgloss.NewStyle().Foreground(theme.Text).Render(
+			fmt.Sprintf("No reused passwords among %d entries.", m.total)))
+	default:
+		warning := lipgloss.NewStyle().Foreground(theme.Warning).Bold(true).Render(
+			fmt.Sprintf("⚠ %d passwords are shared by %d of %d entries", len(m.groups), utils.ReusedEntryCount(m.groups), m.total))
+		advice := subtleStyle.Render("A leak of one exposes the others. Rotate them, or link entries that share a password on purpose.")
+		sections = append(sections, warning+"\n"+advice, m.groupsView())
+	}
+
+	help := subtleStyle.Render(keys.Label(ActionUp)+"/"+keys.Label(ActionDown)+": navigate") + dotStyle +
+		keyHelp(ActionReveal, "reveal or mask") + dotStyle +
+		keyHelp(ActionBack, "back to history")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
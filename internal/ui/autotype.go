@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// autoTypeDoneMsg reports the result of a delayed auto-type
+type autoTypeDoneMsg struct {
+	historyID string
+	err       error
+}
+
+// usesAutoType reports whether copy should type the secret instead of using
+// the clipboard
+func usesAutoType(manager *utils.Manager) bool {
+	return manager != nil && manager.Config != nil && manager.Config.CopyMethod == "type"
+}
+
+// startAutoType waits for the configured delay so the user can focus the
+// target window, then types text into it. It returns the command and a
+// status message telling the user what to do.
+func startAutoType(manager *utils.Manager, text, historyID string) (tea.Cmd, string) {
+	if manager == nil || manager.AutoType == nil || !manager.AutoType.IsAvailable() {
+		return nil, "Auto-type not available: " + utils.ErrNoAutoTypeBackend.Error()
+	}
+
+	delay := 5
+	if manager.Config != nil && manager.Config.AutoTypeDelay > 0 {
+		delay = manager.Config.AutoTypeDelay
+	}
+
+	done := startTask(manager, "auto-type")
+	cmd := tea.Tick(time.Duration(delay)*time.Second, func(time.Time) tea.Msg {
+		defer done()
+		err := manager.AutoType.Type(text)
+		if err != nil {
+			notify(manager, utils.EventAutoType, "Auto-type failed", err.Error())
+		} else {
+			notify(manager, utils.EventAutoType, "Auto-type finished", "The secret was typed into the focused window")
+		}
+		return autoTypeDoneMsg{historyID: historyID, err: err}
+	})
+	return cmd, fmt.Sprintf("Typing in %ds — focus the target window now", delay)
+}
+
+// autoTypeStatus records a successful auto-type and returns the status message
+func autoTypeStatus(manager *utils.Manager, msg autoTypeDoneMsg) string {
+	if msg.err != nil {
+		return "Auto-type failed: " + msg.err.Error()
+	}
+	recordUsage(manager, msg.historyID, utils.UsageCopied)
+	return "Typed into the focused window!"
+}
+
+// notify sends the configured notification for a finished background task.
+// Failures are ignored so that a missing notifier never blocks the task.
+func notify(manager *utils.Manager, event, title, message string) {
+	if manager == nil || manager.Notifier == nil {
+		return
+	}
+	_ = manager.Notifier.Notify(event, "passman: "+title, message)
+}
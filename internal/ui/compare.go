@@ -0,0 +1,436 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// compareColumnWidth is the width of each side's column in the comparison
+const compareColumnWidth = 26
+
+// compareConfig is a generator configuration a side of the comparison can
+// be set to instead of a typed password
+type compareConfig struct {
+	name   string
+	preset config.Preset
+}
+
+// compareSide is one of the two things compared: a typed password, or a
+// generator configuration and a sample it generated
+type compareSide struct {
+	input  textinput.Model
+	config int // Index into the configurations, -1 for the typed password
+	sample string
+	err    error
+}
+
+// compareStats are the figures shown for a side
+type compareStats struct {
+	source      string
+	secret      string
+	length      int
+	charsetSize int
+	classes     string
+	entropy     float64
+	level       generator.SecurityLevel
+	crackTime   string
+	breached    bool
+	weakParts   []generator.Segment
+}
+
+// CompareModel analyzes two passwords or generator configurations side by
+// side, to help choose between e.g. a passphrase and a random string
+type CompareModel struct {
+	width    int
+	height   int
+	manager  *utils.Manager
+	sides    [2]compareSide
+	focus    int
+	configs  []compareConfig
+	revealed bool
+	analyzer *generator.SecurityAnalyzer
+}
+
+// NewCompareModel creates the comparison screen with both sides empty
+func NewCompareModel(manager *utils.Manager) *CompareModel {
+	m := &CompareModel{manager: manager, analyzer: generator.NewSecurityAnalyzer()}
+	if manager != nil && manager.Config != nil {
+		m.configs = compareConfigs(manager.Config)
+		_ = m.analyzer.SetAttacker(manager.Config.CrackAttacker)
+	}
+	for i, placeholder := range []string{"first password", "second password"} {
+		input := textinput.New()
+		input.Placeholder = placeholder
+		input.Prompt = "> "
+		input.CharLimit = 256
+		input.Width = compareColumnWidth
+		input.EchoMode = textinput.EchoPassword
+		m.sides[i] = compareSide{input: input, config: -1}
+	}
+	m.sides[0].input.Focus()
+	return m
+}
+
+// NewCompareModelWithSize creates the comparison screen with specified dimensions
+func NewCompareModelWithSize(manager *utils.Manager, width, height int) *CompareModel {
+	model := NewCompareModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+// compareConfigs returns the configurations sides can be set to: the
+// config's defaults for each generator screen, then the saved presets
+func compareConfigs(cfg *config.Config) []compareConfig {
+	var charsets []string
+	for _, set := range []struct {
+		on   bool
+		name string
+	}{
+		{cfg.DefaultIncludeLowercase, "lower"},
+		{cfg.DefaultIncludeUppercase, "upper"},
+		{cfg.DefaultIncludeNumbers, "digits"},
+		{cfg.DefaultIncludeSymbols, "symbols"},
+	} {
+		if set.on {
+			charsets = append(charsets, set.name)
+		}
+	}
+
+	configs := []compareConfig{
+		{"Random password", config.Preset{Type: "random", Length: cfg.DefaultLength, Charsets: charsets}},
+		{"Passphrase", config.Preset{Type: "memorable", Words: cfg.DefaultPassphraseWords,
+			Separator: cfg.DefaultPassphraseSeparator, Capitalize: cfg.DefaultPassphraseCapitalize, Digit: cfg.DefaultPassphraseDigit}},
+		{"PIN", config.Preset{Type: "pin", Length: cfg.DefaultPinLength,
+			GroupSize: cfg.DefaultPinGroupSize, Separator: cfg.DefaultPinSeparator}},
+	}
+	for _, name := range cfg.PresetNames() {
+		if preset := cfg.Presets[name]; isGeneratorModelType(preset.Type) {
+			configs = append(configs, compareConfig{"Preset: " + name, preset})
+		}
+	}
+	return configs
+}
+
+func (m *CompareModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *CompareModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionBack):
+			return m, navigateBack()
+		case keys.Matches(msg, ActionFocus):
+			m.sides[m.focus].input.Blur()
+			m.focus = 1 - m.focus
+			return m, m.sides[m.focus].input.Focus()
+		case keys.Matches(msg, ActionCompareConfig):
+			m.nextConfig()
+			return m, nil
+		case keys.Matches(msg, ActionCompareReveal):
+			m.revealed = !m.revealed
+			mode := textinput.EchoPassword
+			if m.revealed {
+				mode = textinput.EchoNormal
+			}
+			for i := range m.sides {
+				m.sides[i].input.EchoMode = mode
+			}
+			return m, nil
+		}
+
+		// Typing compares the typed password again
+		side := &m.sides[m.focus]
+		side.config = -1
+		var cmd tea.Cmd
+		side.input, cmd = side.input.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// nextConfig sets the focused side to the next generator configuration,
+// with a new sample, or back to its typed password after the last
+func (m *CompareModel) nextConfig() {
+	side := &m.sides[m.focus]
+	side.config++
+	if side.config >= len(m.configs) {
+		side.config = -1
+		return
+	}
+
+	task := presetTask(m.configs[side.config].preset)
+	gen, err := task.BuildGenerator()
+	if err == nil {
+		side.sample, err = gen.Generate(context.Background())
+	}
+	side.err = err
+}
+
+// presetTask returns the task that generates what preset describes
+func presetTask(preset config.Preset) utils.JobTask {
+	return utils.JobTask{
+		Type:       preset.Type,
+		Length:     preset.Length,
+		GroupSize:  preset.GroupSize,
+		Charsets:   preset.Charsets,
+		Words:      preset.Words,
+		Separator:  preset.Separator,
+		Wordlist:   preset.Wordlist,
+		Capitalize: preset.Capitalize,
+		Digit:      preset.Digit,
+	}
+}
+
+// stats analyzes a side. A configuration is rated by the entropy its
+// generator guarantees, a typed password by the analyzer's estimate.
+func (m *CompareModel) stats(side compareSide) (compareStats, bool) {
+	if side.config < 0 {
+		password := side.input.Value()
+		if password == "" {
+			return compareStats{}, false
+		}
+		analysis := m.analyzer.Analyze(password)
+		stats := m.secretStats("Typed", password, analysis)
+		for _, seg := range analysis.Segments {
+			if seg.Weak() {
+				stats.weakParts = append(stats.weakParts, seg)
+			}
+		}
+		return stats, true
+	}
+
+	if side.err != nil {
+		return compareStats{}, false
+	}
+	cfg := m.configs[side.config]
+	task := presetTask(cfg.preset)
+	gen, err := task.BuildGenerator()
+	if err != nil {
+		return compareStats{}, false
+	}
+	stats := m.secretStats(cfg.name, side.sample, m.analyzer.Analyze(side.sample))
+	stats.entropy = gen.EstimateEntropy()
+	stats.level = generator.EntropySecurityLevel(stats.entropy)
+	stats.crackTime = m.attacker().CrackTime(stats.entropy)
+	// A generated sample being common is chance, not a property of the configuration
+	stats.breached = false
+	return stats, true
+}
+
+// secretStats fills in the figures of a secret from its analysis
+func (m *CompareModel) secretStats(source, secret string, analysis generator.SecurityAnalysis) compareStats {
+	// The charset is the pool of the character classes used, which is
+	// what an attacker has to try
+	var classes []string
+	charsetSize := 0
+	for _, class := range []struct {
+		has  bool
+		name string
+		size int
+	}{
+		{analysis.HasLowercase, "a-z", 26},
+		{analysis.HasUppercase, "A-Z", 26},
+		{analysis.HasNumbers, "0-9", 10},
+		{analysis.HasSymbols, "#$%", 32},
+	} {
+		if class.has {
+			classes = append(classes, class.name)
+			charsetSize += class.size
+		}
+	}
+	return compareStats{
+		source:      source,
+		secret:      secret,
+		length:      utf8.RuneCountInString(secret),
+		charsetSize: charsetSize,
+		classes:     strings.Join(classes, " "),
+		entropy:     analysis.Entropy,
+		level:       analysis.Level,
+		crackTime:   analysis.CrackTime,
+		breached:    analysis.IsCompromised,
+	}
+}
+
+// attacker returns the configured attacker model
+func (m *CompareModel) attacker() generator.AttackerModel {
+	name := generator.DefaultAttacker
+	if m.manager != nil && m.manager.Config != nil {
+		name = m.manager.Config.CrackAttacker
+	}
+	attacker, err := generator.Attacker(name)
+	if err != nil {
+		attacker, _ = generator.Attacker(generator.DefaultAttacker)
+	}
+	return attacker
+}
+
+func (m *CompareModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("⚖️  Compare Strength")
+
+	var inputs []string
+	for i, side := range m.sides {
+		label := fmt.Sprintf("%c: ", 'A'+i)
+		line := label + side.input.View()
+		if side.config >= 0 {
+			line = label + m.configs[side.config].name + subtleStyle.Render(" ("+m.configs[side.config].preset.Summary()+")")
+			if side.err != nil {
+				line += "\n   " + lipgloss.NewStyle().Foreground(theme.Warning).Render(side.err.Error())
+			}
+		}
+		if i == m.focus {
+			line = checkboxStyle.Render("› ") + line
+		} else {
+			line = "  " + line
+		}
+		inputs = append(inputs, line)
+	}
+
+	sections := []string{title, subtleStyle.Render("Type two passwords, or set either side to a generator configuration"), strings.Join(inputs, "\n")}
+
+	a, okA := m.stats(m.sides[0])
+	b, okB := m.stats(m.sides[1])
+	if okA || okB {
+		sections = append(sections, m.table(a, okA, b, okB))
+		if okA && okB {
+			sections = append(sections, lipgloss.NewStyle().Foreground(theme.Text).Render(compareVerdict(a, b)))
+		}
+	}
+
+	help := subtleStyle.Render(keys.Label(ActionFocus)+": switch side") + dotStyle +
+		keyHelp(ActionCompareConfig, "generator configuration") + dotStyle +
+		keyHelp(ActionCompareReveal, "reveal") + dotStyle +
+		keyHelp(ActionBack, "back")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// table lays the figures of both sides out in columns, with the
+// difference B makes
+func (m *CompareModel) table(a compareStats, okA bool, b compareStats, okB bool) string {
+	cell := func(ok bool, value string) string {
+		if !ok {
+			value = "—"
+		}
+		if runes := []rune(value); len(runes) > compareColumnWidth-1 {
+			value = string(runes[:compareColumnWidth-2]) + "…"
+		}
+		return fmt.Sprintf("%-*s", compareColumnWidth, value)
+	}
+	both := okA && okB
+	delta := func(text string) string {
+		if !both {
+			return ""
+		}
+		return text
+	}
+	shown := func(s compareStats) string {
+		if m.revealed {
+			return s.secret
+		}
+		return maskSecret(s.secret, true)
+	}
+	weak := func(s compareStats) string {
+		var parts []string
+		for _, seg := range s.weakParts {
+			if m.revealed {
+				parts = append(parts, fmt.Sprintf("%s %q", seg.Kind, seg.Text))
+			} else {
+				parts = append(parts, fmt.Sprintf("%s (%d chars)", seg.Kind, seg.End-seg.Start))
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	yesNo := func(v bool) string {
+		if v {
+			return "yes"
+		}
+		return "no"
+	}
+
+	rows := []struct {
+		label, a, b, delta string
+	}{
+		{"Source", a.source, b.source, ""},
+		{"Password", shown(a), shown(b), ""},
+		{"Length", fmt.Sprint(a.length), fmt.Sprint(b.length), delta(fmt.Sprintf("%+d", b.length-a.length))},
+		{"Charset", fmt.Sprintf("%d (%s)", a.charsetSize, a.classes), fmt.Sprintf("%d (%s)", b.charsetSize, b.classes),
+			delta(fmt.Sprintf("%+d", b.charsetSize-a.charsetSize))},
+		{"Entropy", fmt.Sprintf("%.1f bits", a.entropy), fmt.Sprintf("%.1f bits", b.entropy), delta(fmt.Sprintf("%+.1f bits", b.entropy-a.entropy))},
+		{"Strength", generator.SecurityLevelToString(a.level), generator.SecurityLevelToString(b.level), ""},
+		{"Crack time", a.crackTime, b.crackTime, delta(crackFactor(b.entropy - a.entropy))},
+		{"Breached", yesNo(a.breached), yesNo(b.breached), ""},
+		{"Weak parts", weak(a), weak(b), ""},
+	}
+
+	header := fmt.Sprintf("%-11s %-*s %-*s", "", compareColumnWidth, "A", compareColumnWidth, "B")
+	if both {
+		header += " B − A"
+	}
+	lines := []string{lipgloss.NewStyle().Foreground(theme.Text).Bold(true).Render(header)}
+	for _, row := range rows {
+		line := fmt.Sprintf("%-11s %s %s %s", row.label, cell(okA, row.a), cell(okB, row.b), row.delta)
+		lines = append(lines, lipgloss.NewStyle().Foreground(theme.Text).Render(strings.TrimRight(line, " ")))
+	}
+	lines = append(lines, subtleStyle.Render("Crack times assume "+m.attacker().Description))
+	return strings.Join(lines, "\n")
+}
+
+// crackFactor describes how many times longer B takes to crack than A
+// given the difference in entropy, e.g. "×1024" or "÷8"
+func crackFactor(bits float64) string {
+	factor := math.Pow(2, math.Abs(bits))
+	var text string
+	switch {
+	case factor < 1.05:
+		return "same"
+	case factor < 1e6:
+		text = fmt.Sprintf("%.0f", factor)
+	default:
+		text = fmt.Sprintf("10^%.0f", math.Abs(bits)*math.Log10(2))
+	}
+	if bits < 0 {
+		return "÷" + text
+	}
+	return "×" + text
+}
+
+// compareVerdict says which side is stronger and by how much
+func compareVerdict(a, b compareStats) string {
+	diff := b.entropy - a.entropy
+	switch {
+	case a.breached != b.breached && a.breached:
+		return "B is stronger: A is on the list of breached passwords"
+	case a.breached != b.breached:
+		return "A is stronger: B is on the list of breached passwords"
+	case math.Abs(diff) < 1:
+		return "A and B are about as strong"
+	case diff > 0:
+		return fmt.Sprintf("B is stronger by %.1f bits: cracking it takes %s as long", diff, strings.TrimPrefix(crackFactor(diff), "×")+" times")
+	}
+	return fmt.Sprintf("A is stronger by %.1f bits: cracking it takes %s as long", -diff, strings.TrimPrefix(crackFactor(-diff), "×")+" times")
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// confirmMsg asks the app to show a confirmation dialog over the screen
+type confirmMsg struct {
+	prompt string
+	yes    tea.Cmd // Run once confirmed
+}
+
+// confirm returns the command that asks prompt and runs yes only if the
+// answer is yes. Messages of yes go to the screen that asked.
+func confirm(prompt string, yes tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		return confirmMsg{prompt: prompt, yes: yes}
+	}
+}
+
+// confirmsExit reports whether leaving passman needs confirming
+func confirmsExit(manager *utils.Manager) bool {
+	return manager != nil && manager.Config != nil && manager.Config.ConfirmBeforeExit
+}
+
+// confirmDialog is a yes/no question shown instead of the screen until
+// it is answered; every key but the answers is ignored meanwhile
+type confirmDialog struct {
+	prompt string
+	yes    tea.Cmd
+}
+
+// update handles a key, returning whether the dialog was answered and
+// the command to run for the answer
+func (d *confirmDialog) update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		return true, d.yes
+	case "n", "N", "esc", "ctrl+c":
+		return true, nil
+	}
+	return false, nil
+}
+
+// view renders the dialog in the middle of the terminal
+func (d *confirmDialog) view(width, height int) string {
+	prompt := lipgloss.NewStyle().Foreground(theme.Text).Bold(true).Render(d.prompt)
+	help := subtleStyle.Render("y: yes") + dotStyle + subtleStyle.Render("n/esc: no")
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Accent).
+		Padding(1, 2).
+		Render(prompt + "\n\n" + help)
+	if width == 0 || height == 0 {
+		return mainStyle.Render("\n" + box)
+	}
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
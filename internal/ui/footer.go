@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// footerHeight is the number of lines the footer takes from every screen
+const footerHeight = 1
+
+// footerTickMsg refreshes the footer's countdowns
+type footerTickMsg struct{}
+
+// footerTick schedules the next footer refresh
+func footerTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return footerTickMsg{}
+	})
+}
+
+// footerEnabled reports whether the session footer is shown
+func footerEnabled(manager *utils.Manager) bool {
+	return manager != nil && manager.Config != nil && manager.Config.ShowFooter && manager.Session != nil
+}
+
+// footerView renders the session strip shown under every screen:
+// generations this session, the clipboard auto-clear countdown, the idle
+// lock countdown if canLock, running background tasks, the last history
+// sync and the active profile, cut to fit width
+func footerView(manager *utils.Manager, width int, now time.Time, canLock bool) string {
+	segments := []string{fmt.Sprintf("%d generated", manager.Session.Generations())}
+
+	if clearAt, ok := manager.Clipboard.ClearsAt(); ok {
+		segments = append(segments, "clipboard clears in "+formatCountdown(clearAt.Sub(now)))
+	}
+
+	if manager.History != nil && manager.History.IsLocked() {
+		segments = append(segments, "locked")
+	} else if lockAt, ok := autoLockAt(manager); ok && canLock {
+		segments = append(segments, "locks in "+formatCountdown(lockAt.Sub(now)))
+	}
+
+	if tasks := manager.Session.PendingTasks(); len(tasks) > 0 {
+		segments = append(segments, fmt.Sprintf("%d running: %s", len(tasks), strings.Join(tasks, ", ")))
+	}
+
+	if syncedAt, err := manager.Session.LastSync(); err != nil {
+		segments = append(segments, "sync failed")
+	} else if !syncedAt.IsZero() {
+		segments = append(segments, "synced "+formatAgo(now.Sub(syncedAt)))
+	}
+
+	if profile := config.ActiveProfile(); profile != config.DefaultProfile {
+		segments = append(segments, "profile "+profile)
+	}
+
+	line := " " + strings.Join(segments, dotChar) + dotChar + keys.Label(ActionToggleFooter) + ": hide"
+	if width > 0 {
+		if runes := []rune(line); len(runes) > width {
+			line = string(runes[:width-1]) + "…"
+		}
+	}
+	return subtleStyle.Render(line)
+}
+
+// formatCountdown formats a remaining duration as m:ss
+func formatCountdown(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// formatAgo formats the time since something happened, to the minute
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+}
+
+// startTask records a background task for the footer and returns the
+// function to call when it finishes
+func startTask(manager *utils.Manager, name string) (done func()) {
+	if manager == nil || manager.Session == nil {
+		return func() {}
+	}
+	return manager.Session.StartTask(name)
+}
+
+// countGeneration counts a generated secret of kind for the footer and
+// the usage metrics
+func countGeneration(manager *utils.Manager, kind string) {
+	if manager == nil {
+		return
+	}
+	if manager.Session != nil {
+		manager.Session.RecordGeneration()
+	}
+	manager.RecordMetric(utils.MetricGenerated, kind)
+}
@@ -6,7 +6,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -24,10 +26,14 @@ type GeneratorModel struct {
 	lengthInput     textinput.Model
 	wordCountInput  textinput.Model
 	spinner         spinner.Model
+	strengthBar     progress.Model
 	generating      bool
 	currentPassword string
+	historyID       string // History entry of currentPassword, for the usage audit trail
+	recentIndex     int    // Position of currentPassword among this session's passwords
+	masked          bool   // Show passwords as dots until revealed with v
 	strength        string
-	statusMsg       string
+	status          StatusBar
 	width           int
 	height          int
 
@@ -36,6 +42,30 @@ type GeneratorModel struct {
 	includeUpper    bool
 	includeNumbers  bool
 	includeSymbols  bool
+	localeCharset   generator.CharSet // Opt-in locale letters, 0 for ASCII only
+
+	// Passphrase wordlist selection
+	wordlists       []generator.WordlistInfo
+	wordlistIndex   int
+	typoRobust      bool
+	leetMode        generator.LeetMode
+	separator       string // Between passphrase words ("none" joins them) or PIN groups
+	capitalize      bool
+	digit           bool
+	groupSize       int    // PIN digits per group, 0 for none
+
+	// Saving the settings as a named preset
+	savingPreset    bool
+	presetInput     textinput.Model
+
+	// Passphrase candidate carousel
+	candidates        []string
+	candidateIndex    int
+	candidateEntropy  float64
+	showingCandidates bool
+
+	// Large-print display of the current password
+	largePrint        bool
 	
 	// Manager for history and other utilities
 	manager         *utils.Manager
@@ -46,6 +76,16 @@ type generateMsg struct {
 	strength string
 }
 
+type candidatesMsg struct {
+	candidates []string
+	entropy    float64
+	err        error
+}
+
+// defaultCandidateCount is the number of candidates offered when the
+// config does not set one
+const defaultCandidateCount = 5
+
 // NewGeneratorModel creates a new generator model
 func NewGeneratorModel(genType string, manager *utils.Manager) *GeneratorModel {
 	lengthInput := textinput.New()
@@ -64,28 +104,66 @@ func NewGeneratorModel(genType string, manager *utils.Manager) *GeneratorModel {
 	lengthInput.Width = 10
 	// Don't focus by default so character toggles work immediately
 
+	wordCount, separator, groupSize := "4", "-", 0
+	if manager != nil && manager.Config != nil {
+		wordCount = strconv.Itoa(manager.Config.DefaultPassphraseWords)
+		separator = manager.Config.DefaultPassphraseSeparator
+		if genType == "pin" {
+			separator, groupSize = manager.Config.DefaultPinSeparator, manager.Config.DefaultPinGroupSize
+		}
+	}
 	wordCountInput := textinput.New()
-	wordCountInput.Placeholder = "4"
-	wordCountInput.SetValue("4")
+	wordCountInput.Placeholder = wordCount
+	wordCountInput.SetValue(wordCount)
 	wordCountInput.CharLimit = 2
 	wordCountInput.Width = 10
 
+	presetInput := textinput.New()
+	presetInput.Placeholder = "GitHub 20 chars no symbols"
+	presetInput.CharLimit = 40
+	presetInput.Width = 30
+
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF10F0"))
+	s.Style = lipgloss.NewStyle().Foreground(theme.Spinner)
 
-	return &GeneratorModel{
+	leetMode := generator.LeetOff
+	var capitalize, digit bool
+	if manager != nil && manager.Config != nil {
+		if mode, err := generator.ParseLeetMode(manager.Config.DefaultPassphraseLeet); err == nil {
+			leetMode = mode
+		}
+		capitalize, digit = manager.Config.DefaultPassphraseCapitalize, manager.Config.DefaultPassphraseDigit
+	}
+
+	model := &GeneratorModel{
 		generatorType:   genType,
+		leetMode:        leetMode,
+		capitalize:      capitalize,
+		digit:           digit,
+		masked:          masksPasswords(manager),
 		lengthInput:     lengthInput,
 		wordCountInput:  wordCountInput,
+		presetInput:     presetInput,
+		separator:       separator,
+		groupSize:       groupSize,
 		spinner:         s,
+		strengthBar:     progress.New(progress.WithoutPercentage()),
 		includeLower:    true,
 		includeUpper:    true,
 		includeNumbers:  true,
 		includeSymbols:  true,
-		statusMsg:       "",
+		wordlists:       generator.BundledWordlists(),
 		manager:         manager,
 	}
+
+	// Come back to the settings last generated with rather than the placeholders
+	if manager != nil && manager.Config != nil {
+		if settings, ok := manager.Config.GeneratorSettings[genType]; ok {
+			model.applyPreset(settings)
+		}
+	}
+	return model
 }
 
 func (m *GeneratorModel) Init() tea.Cmd {
@@ -101,6 +179,14 @@ func NewGeneratorModelWithSize(genType string, manager *utils.Manager, width, he
 }
 
 func (m *GeneratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.status.Update(msg) {
+		return m, nil
+	}
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, m.status.Cmd())
+}
+
+func (m *GeneratorModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -110,35 +196,51 @@ func (m *GeneratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return NewMenuModelWithSize(m.manager, m.width, m.height), nil
-		case "esc":
-			return NewMenuModelWithSize(m.manager, m.width, m.height), nil
-		case "enter", "g":
+		if m.largePrint {
+			// Any of these keys leave large-print mode; everything else is ignored
+			switch {
+			case keys.Matches(msg, ActionLargePrint), keys.Matches(msg, ActionBack),
+				keys.Matches(msg, ActionSelect), keys.Matches(msg, ActionQuit):
+				m.largePrint = false
+			case msg.String() == "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			return m, nil
+		}
+
+		if m.savingPreset {
+			return m, m.updatePresetName(msg)
+		}
+
+		if m.showingCandidates {
+			if handled, cmd := m.updateCandidates(msg); handled {
+				return m, cmd
+			}
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionBack):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionScratchpad):
+			return m, navigate(ScratchpadScreen)
+		case keys.Matches(msg, ActionGenerate):
 			if !m.generating {
 				m.generating = true
-				m.statusMsg = "Generating password..."
+				m.status.Hint("Generating password...")
 				return m, tea.Batch(m.generatePassword(), m.spinner.Tick)
 			}
-		case "c":
-			if m.currentPassword != "" && !strings.HasPrefix(m.currentPassword, "Error:") {
-				// Try to copy to clipboard using the manager
-				if m.manager != nil && m.manager.Clipboard != nil {
-					if err := m.manager.Clipboard.Copy(m.currentPassword); err != nil {
-						m.statusMsg = "Failed to copy to clipboard: " + err.Error()
-					} else {
-						m.statusMsg = "Password copied to clipboard!"
-					}
-				} else {
-					m.statusMsg = "Clipboard not available"
-				}
-			} else if m.currentPassword == "" {
-				m.statusMsg = "No password to copy. Generate one first!"
-			} else {
-				m.statusMsg = "Cannot copy error message to clipboard"
+		case keys.Matches(msg, ActionCopy), keys.Matches(msg, ActionAutoType):
+			if cmd := m.copyPassword(keys.Matches(msg, ActionAutoType)); cmd != nil {
+				return m, cmd
 			}
-		case "tab":
+		case keys.Matches(msg, ActionUndo):
+			// Step back to a password generated earlier this session
+			m.stepRecent(-1)
+		case keys.Matches(msg, ActionRedo):
+			m.stepRecent(1)
+		case keys.Matches(msg, ActionFocus):
 			// Toggle focus between inputs based on generator type
 			if m.generatorType == "memorable" {
 				// For memorable passphrase, toggle word count input focus
@@ -155,49 +257,156 @@ func (m *GeneratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.lengthInput.Focus()
 				}
 			}
-		case "n":
+		case keys.Matches(msg, ActionToggleNumbers):
 			// Only toggle if input is not focused
 			if !m.lengthInput.Focused() && !(m.generatorType == "memorable" && m.wordCountInput.Focused()) {
 				m.includeNumbers = !m.includeNumbers
 			}
-		case "s":
+		case keys.Matches(msg, ActionToggleSymbols):
 			// Only toggle if input is not focused
 			if !m.lengthInput.Focused() && !(m.generatorType == "memorable" && m.wordCountInput.Focused()) {
 				m.includeSymbols = !m.includeSymbols
 			}
-		case "l":
+		case keys.Matches(msg, ActionToggleLower):
 			// Only toggle if input is not focused
 			if !m.lengthInput.Focused() && !(m.generatorType == "memorable" && m.wordCountInput.Focused()) {
 				m.includeLower = !m.includeLower
 			}
-		case "u":
+		case keys.Matches(msg, ActionToggleUpper):
 			// Only toggle if input is not focused
 			if !m.lengthInput.Focused() && !(m.generatorType == "memorable" && m.wordCountInput.Focused()) {
 				m.includeUpper = !m.includeUpper
 			}
+		case keys.Matches(msg, ActionLocaleLetters):
+			// Cycle through locale letters: off, German, French, Spanish, Nordic
+			if m.generatorType == "random" && !m.lengthInput.Focused() {
+				m.localeCharset = nextLocaleCharset(m.localeCharset)
+				m.status.Info("Locale letters: " + localeCharsetLabel(m.localeCharset))
+			}
+		case keys.Matches(msg, ActionNextWordlist):
+			// Cycle through bundled wordlists for passphrases
+			if m.generatorType == "memorable" && !m.wordCountInput.Focused() && len(m.wordlists) > 0 {
+				m.wordlistIndex = (m.wordlistIndex + 1) % len(m.wordlists)
+				m.status.Info("Wordlist: " + m.selectedWordlist().DisplayName())
+			}
+		case keys.Matches(msg, ActionLargePrint):
+			// Show the current password in large print
+			if !m.lengthInput.Focused() && !m.wordCountInput.Focused() && !m.generating {
+				if m.currentPassword != "" && !strings.HasPrefix(m.currentPassword, "Error:") {
+					m.showingCandidates = false
+					m.largePrint = true
+				} else {
+					m.status.Error("No password to show. Generate one first!")
+				}
+			}
+		case keys.Matches(msg, ActionReveal):
+			// Reveal or re-mask the password
+			if !m.lengthInput.Focused() && !m.wordCountInput.Focused() {
+				m.masked = !m.masked
+				if !m.masked && m.currentPassword != "" && !m.showingCandidates {
+					recordUsage(m.manager, m.historyID, utils.UsageRevealed)
+				}
+			}
+		case keys.Matches(msg, ActionTypoRobust):
+			// Toggle typo-robust word selection for passphrases
+			if m.generatorType == "memorable" && !m.wordCountInput.Focused() {
+				m.typoRobust = !m.typoRobust
+				if m.typoRobust {
+					m.status.Info("Typo-robust words: on")
+				} else {
+					m.status.Info("Typo-robust words: off")
+				}
+			}
+		case keys.Matches(msg, ActionLeet):
+			// Cycle leet-speak substitution modes for passphrases
+			if m.generatorType == "memorable" && !m.wordCountInput.Focused() {
+				m.leetMode = (m.leetMode + 1) % generator.LeetMode(len(generator.LeetModes()))
+				m.status.Info("Leet substitutions: " + m.leetMode.String())
+			}
+		case keys.Matches(msg, ActionSeparator):
+			// Cycle the separator between passphrase words or PIN groups
+			if (m.generatorType == "memorable" && !m.wordCountInput.Focused()) ||
+				(m.generatorType == "pin" && !m.lengthInput.Focused()) {
+				m.separator = nextSeparator(m.separator, m.separatorChoices())
+				m.status.Info("Separator: " + separatorLabel(m.separator))
+			}
+		case keys.Matches(msg, ActionPINGroups):
+			// Cycle the PIN group size: off, 2, 3, 4
+			if m.generatorType == "pin" && !m.lengthInput.Focused() {
+				m.groupSize = nextGroupSize(m.groupSize)
+				m.status.Info("PIN groups: " + groupSizeLabel(m.groupSize))
+			}
+		case keys.Matches(msg, ActionCapitalize):
+			if m.generatorType == "memorable" && !m.wordCountInput.Focused() {
+				m.capitalize = !m.capitalize
+			}
+		case keys.Matches(msg, ActionDigit):
+			if m.generatorType == "memorable" && !m.wordCountInput.Focused() {
+				m.digit = !m.digit
+			}
+		case keys.Matches(msg, ActionSavePreset):
+			// Save the settings under a name, listed in the menu
+			if !m.lengthInput.Focused() && !m.wordCountInput.Focused() {
+				m.startSavingPreset()
+			}
+		case keys.Matches(msg, ActionCandidates):
+			// Generate several candidates to choose from
+			if !m.lengthInput.Focused() && !m.wordCountInput.Focused() && !m.generating {
+				m.generating = true
+				m.status.Hint("Generating candidates...")
+				return m, tea.Batch(m.generateCandidates(), m.spinner.Tick)
+			}
+		}
+
+	case tea.MouseMsg:
+		if mouseClicked(msg) && !m.largePrint {
+			m.toggleAt(msg)
+		}
+
+	case autoTypeDoneMsg:
+		m.status.Result(autoTypeStatus(m.manager, msg), msg.err)
+
+	case applyPresetMsg:
+		m.applyPreset(msg.preset)
+		m.status.Info(fmt.Sprintf("Preset %q loaded", msg.name))
+		return m, nil
+
+	case savePresetMsg:
+		m.savePreset(msg.name, msg.preset)
+		return m, nil
+
+	case candidatesMsg:
+		m.generating = false
+		if msg.err != nil {
+			m.status.Error("Failed to generate candidates: " + msg.err.Error())
+			break
 		}
+		m.rememberSettings()
+		m.candidates = msg.candidates
+		m.candidateIndex = 0
+		m.candidateEntropy = msg.entropy
+		m.showingCandidates = true
+		m.status.Hint(fmt.Sprintf("Use %s/%s to compare candidates, %s to copy one",
+			keys.Label(ActionPrevCandidate), keys.Label(ActionNextCandidate), keys.Label(ActionSelect)))
 
 	case generateMsg:
 		m.generating = false
+		m.showingCandidates = false
 		m.currentPassword = msg.password
 		m.strength = msg.strength
-		m.statusMsg = "Password generated successfully!"
+		if strings.HasPrefix(msg.password, "Error:") {
+			m.status.Error("Password generation failed")
+		} else {
+			m.status.Success("Password generated successfully!")
+			countGeneration(m.manager, m.generatorType)
+			m.rememberSettings()
+		}
 		
-		// Save to history if manager is available and password is valid
-		if m.manager != nil && m.manager.History != nil && m.manager.History.IsEnabled() && msg.password != "" && !strings.HasPrefix(msg.password, "Error:") {
-			settings := m.buildSettingsString()
-			entry := utils.HistoryEntry{
-				Password:    msg.password,
-				Length:      len(msg.password),
-				Type:        m.generatorType,
-				Settings:    settings,
-				Description: fmt.Sprintf("%s password", strings.Title(m.generatorType)),
-			}
-			if err := m.manager.History.AddEntry(entry); err != nil {
-				// Don't fail the UI if history fails, just log it
-				m.statusMsg = "Password generated successfully! (History save failed)"
-			}
+		if err := m.saveToHistory(msg.password); err != nil {
+			// Don't fail the UI if history fails, just log it
+			m.status.Error("Password generated successfully! (History save failed)")
 		}
+		m.addRecent()
 
 	case spinner.TickMsg:
 		if m.generating {
@@ -220,73 +429,379 @@ func (m *GeneratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-func (m *GeneratorModel) generatePassword() tea.Cmd {
+// updateCandidates handles keys while the candidate list is shown. It
+// returns false for keys that should fall through to normal handling.
+func (m *GeneratorModel) updateCandidates(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch {
+	case keys.Matches(msg, ActionPrevCandidate):
+		if m.candidateIndex > 0 {
+			m.candidateIndex--
+		}
+	case keys.Matches(msg, ActionNextCandidate):
+		if m.candidateIndex < len(m.candidates)-1 {
+			m.candidateIndex++
+		}
+	case keys.Matches(msg, ActionSelect):
+		// Only the chosen candidate is kept; the others are never saved
+		picked := m.candidates[m.candidateIndex]
+		countGeneration(m.manager, m.generatorType)
+		m.currentPassword = picked
+		m.strength = strengthLabel(picked)
+		m.showingCandidates = false
+		m.candidates = nil
+		historyErr := m.saveToHistory(picked)
+		m.addRecent()
+
+		cmd := m.copyPassword(false)
+		if historyErr != nil {
+			m.status.Error(m.status.Text() + " (History save failed)")
+		}
+		return true, cmd
+	case keys.Matches(msg, ActionBack):
+		m.showingCandidates = false
+		m.candidates = nil
+		m.status.Info("Candidates discarded")
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// copyPassword copies the current password, or types it when typeIt is
+// set or the configured copy method is auto-type
+func (m *GeneratorModel) copyPassword(typeIt bool) tea.Cmd {
+	if m.currentPassword == "" {
+		m.status.Error("No password to copy. Generate one first!")
+		return nil
+	}
+	if strings.HasPrefix(m.currentPassword, "Error:") {
+		m.status.Error("Cannot copy error message to clipboard")
+		return nil
+	}
+
+	// Type the password instead when asked to or configured to
+	if typeIt || usesAutoType(m.manager) {
+		cmd, status := startAutoType(m.manager, m.currentPassword, m.historyID)
+		if cmd == nil {
+			m.status.Error(status)
+		} else {
+			m.status.Hint(status)
+		}
+		return cmd
+	}
+
+	// Try to copy to clipboard using the manager
+	if m.manager != nil && m.manager.Clipboard != nil {
+		if err := m.manager.Clipboard.Copy(m.currentPassword); err != nil {
+			m.status.Error("Failed to copy to clipboard: " + err.Error())
+		} else {
+			m.status.Success("Password copied to clipboard!")
+			recordUsage(m.manager, m.historyID, utils.UsageCopied)
+		}
+	} else {
+		m.status.Error("Clipboard not available")
+	}
+	return nil
+}
+
+// candidateCount returns how many candidates to generate at once
+func (m *GeneratorModel) candidateCount() int {
+	if m.manager != nil && m.manager.Config != nil && m.manager.Config.CandidateCount > 0 {
+		return m.manager.Config.CandidateCount
+	}
+	return defaultCandidateCount
+}
+
+// generateCandidates creates several passwords at once for the candidate list
+func (m *GeneratorModel) generateCandidates() tea.Cmd {
+	count := m.candidateCount()
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		var gen generator.Generator
-		var password string
-		var err error
+		gen, err := m.buildGenerator()
+		if err != nil {
+			return candidatesMsg{err: err}
+		}
 
-		switch m.generatorType {
-		case "random":
-			length, _ := strconv.Atoi(m.lengthInput.Value())
-			if length <= 0 {
-				length = 16
+		candidates := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			candidate, err := gen.Generate(ctx)
+			if err != nil {
+				return candidatesMsg{err: err}
 			}
+			candidates = append(candidates, candidate)
+		}
 
-			var charSets []generator.CharSet
-			if m.includeLower {
-				charSets = append(charSets, generator.Lowercase)
-			}
-			if m.includeUpper {
-				charSets = append(charSets, generator.Uppercase)
-			}
-			if m.includeNumbers {
-				charSets = append(charSets, generator.Numbers)
-			}
-			if m.includeSymbols {
-				charSets = append(charSets, generator.Symbols)
-			}
+		return candidatesMsg{candidates: candidates, entropy: gen.EstimateEntropy()}
+	}
+}
 
-			gen = generator.NewRandomGenerator(length, charSets...)
-			password, err = gen.Generate(ctx)
+// addRecent keeps the current password in the session's undo ring
+func (m *GeneratorModel) addRecent() {
+	if m.manager == nil || m.manager.Recent == nil || m.currentPassword == "" || strings.HasPrefix(m.currentPassword, "Error:") {
+		return
+	}
+	m.manager.Recent.Add(utils.RecentPassword{
+		Password:  m.currentPassword,
+		Type:      m.generatorType,
+		HistoryID: m.historyID,
+	})
+	m.recentIndex = len(m.manager.Recent.List(m.generatorType)) - 1
+}
 
-		case "memorable":
-			wordCount, _ := strconv.Atoi(m.wordCountInput.Value())
-			if wordCount <= 0 {
-				wordCount = 4
-			}
-			gen = generator.NewMemorableGenerator(wordCount, " ", generator.GetEFFWordlist())
-			password, err = gen.Generate(ctx)
+// stepRecent moves back (-1) or forward (1) through the passwords of this
+// generator type generated during the session
+func (m *GeneratorModel) stepRecent(step int) {
+	if m.manager == nil || m.manager.Recent == nil || m.generating {
+		return
+	}
 
-		case "pin":
-			length, _ := strconv.Atoi(m.lengthInput.Value())
-			if length <= 0 {
-				length = m.manager.Config.DefaultPinLength
-			}
-			gen = generator.NewPINGenerator(length)
-			password, err = gen.Generate(ctx)
+	recent := m.manager.Recent.List(m.generatorType)
+	if len(recent) == 0 {
+		m.status.Info("No passwords generated this session")
+		return
+	}
+
+	index := m.recentIndex + step
+	if m.currentPassword == "" {
+		// Nothing shown yet on this screen, so start from the newest
+		index = len(recent) - 1
+	}
+	if index < 0 || index >= len(recent) {
+		if step < 0 {
+			m.status.Info("Already at the oldest password of this session")
+		} else {
+			m.status.Info("Already at the newest password")
+		}
+		return
+	}
+
+	picked := recent[index]
+	m.recentIndex = index
+	m.currentPassword = picked.Password
+	m.historyID = picked.HistoryID
+	m.strength = strengthLabel(picked.Password)
+	m.showingCandidates = false
+	m.status.Info(fmt.Sprintf("Password %d of %d from this session (generated %s)",
+		index+1, len(recent), picked.GeneratedAt.Format("15:04:05")))
+}
+
+// saveToHistory records a generated password if history is available
+func (m *GeneratorModel) saveToHistory(password string) error {
+	m.historyID = ""
+	if m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() || password == "" || strings.HasPrefix(password, "Error:") {
+		return nil
+	}
+
+	entry := utils.HistoryEntry{
+		ID:          m.manager.History.NewID(),
+		Password:    password,
+		Length:      utf8.RuneCountInString(password),
+		Type:        m.generatorType,
+		Settings:    m.buildSettingsString(),
+		Description: fmt.Sprintf("%s password", strings.Title(m.generatorType)),
+	}
+	if err := m.manager.History.AddEntry(entry); err != nil {
+		return err
+	}
+	m.historyID = entry.ID
+	return nil
+}
+
+func (m *GeneratorModel) generatePassword() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		gen, err := m.buildGenerator()
+		if err != nil {
+			return generateMsg{password: "Error: " + err.Error(), strength: "Error"}
 		}
 
+		password, err := gen.Generate(ctx)
 		if err != nil {
 			return generateMsg{password: "Error: " + err.Error(), strength: "Error"}
 		}
 
-		// Calculate strength
-		strength := "Strong"
-		if len(password) < 8 {
-			strength = "Weak"
-		} else if len(password) < 12 {
-			strength = "Medium"
+		return generateMsg{password: password, strength: strengthLabel(password)}
+	}
+}
+
+// buildGenerator creates a generator configured from the current settings
+func (m *GeneratorModel) buildGenerator() (generator.Generator, error) {
+	switch m.generatorType {
+	case "random":
+		length, _ := strconv.Atoi(m.lengthInput.Value())
+		if length <= 0 {
+			length = 16
+		}
+
+		var charSets []generator.CharSet
+		if m.includeLower {
+			charSets = append(charSets, generator.Lowercase)
+		}
+		if m.includeUpper {
+			charSets = append(charSets, generator.Uppercase)
+		}
+		if m.includeNumbers {
+			charSets = append(charSets, generator.Numbers)
 		}
+		if m.includeSymbols {
+			charSets = append(charSets, generator.Symbols)
+		}
+		if m.localeCharset != 0 {
+			charSets = append(charSets, m.localeCharset)
+		}
+
+		return generator.NewRandomGenerator(length, charSets...), nil
+
+	case "memorable":
+		return m.buildMemorableGenerator()
 
-		return generateMsg{password: password, strength: strength}
+	case "pin":
+		length, _ := strconv.Atoi(m.lengthInput.Value())
+		if length <= 0 {
+			length = m.manager.Config.DefaultPinLength
+		}
+		gen := generator.NewPINGenerator(length)
+		gen.SetFormat(m.groupSize, m.separator)
+		return gen, nil
 	}
+
+	return nil, fmt.Errorf("unknown generator type %q", m.generatorType)
+}
+
+// buildMemorableGenerator creates a passphrase generator from the current settings
+func (m *GeneratorModel) buildMemorableGenerator() (*generator.MemorableGenerator, error) {
+	wordCount, _ := strconv.Atoi(m.wordCountInput.Value())
+	if wordCount <= 0 {
+		wordCount = 4
+	}
+
+	wordlist, err := generator.GetBundledWordlist(m.selectedWordlist().ID)
+	if err != nil {
+		return nil, err
+	}
+
+	leet, err := m.leetTransform()
+	if err != nil {
+		return nil, err
+	}
+
+	gen := generator.NewMemorableGenerator(wordCount, m.separator, wordlist)
+	gen.SetCapitalize(m.capitalize)
+	gen.SetDigit(m.digit)
+	gen.SetTypoRobust(m.typoRobust)
+	gen.SetLeet(leet)
+	return gen, nil
+}
+
+// strengthGaugeMaxBits is the entropy at which the strength gauge is full
+const strengthGaugeMaxBits = 128.0
+
+// strengthGaugeView renders a progress bar of the estimated entropy of the
+// configured generator, colored by security level
+func (m *GeneratorModel) strengthGaugeView() string {
+	textStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	gen, err := m.buildGenerator()
+	if err == nil {
+		err = gen.Validate()
+	}
+	if err != nil {
+		return textStyle.Render("Estimated strength: n/a (" + err.Error() + ")")
+	}
+
+	entropy := gen.EstimateEntropy()
+	level := generator.EntropySecurityLevel(entropy)
+
+	bar := m.strengthBar
+	bar.FullColor = theme.StrengthColor(level)
+	if theme.NoColor {
+		bar.EmptyColor = ""
+	}
+	bar.Width = 30
+	if m.width > 0 && m.width < 60 {
+		bar.Width = max(10, m.width-16)
+	}
+
+	percent := entropy / strengthGaugeMaxBits
+	if percent > 1 {
+		percent = 1
+	}
+
+	label := fmt.Sprintf("Estimated strength: %s (%.1f bits)", generator.SecurityLevelToString(level), entropy)
+	return textStyle.Render(label) + "\n" + bar.ViewAs(percent) + "\n" + m.crackTimeView(entropy)
+}
+
+// crackTimeView lists how long each attacker model needs to crack a
+// password of the given entropy, marking the configured one, and, when
+// hardware improvement is modelled, the year the configured attacker
+// likely cracks it
+func (m *GeneratorModel) crackTimeView(entropy float64) string {
+	name, doubling := generator.DefaultAttacker, 0
+	if m.manager != nil && m.manager.Config != nil {
+		name, doubling = m.manager.Config.CrackAttacker, m.manager.Config.CrackDoublingYears
+	}
+	attacker, err := generator.Attacker(name)
+	if err != nil {
+		attacker, _ = generator.Attacker(generator.DefaultAttacker)
+	}
+
+	lines := []string{"Crack time:"}
+	for _, estimate := range generator.CrackTimes(entropy) {
+		marker := " "
+		if estimate.Attacker.Name == attacker.Name {
+			marker = "›"
+		}
+		lines = append(lines, fmt.Sprintf("%s %-13s %-18s %s",
+			marker, estimate.Attacker.Name, estimate.Time, estimate.Attacker.Description))
+	}
+	if doubling > 0 {
+		lines = append(lines, fmt.Sprintf("%s for %s if hardware doubles every %d years",
+			attacker.CrackOutlook(entropy, float64(doubling), time.Now()), attacker.Name, doubling))
+	}
+	return subtleStyle.Render(strings.Join(lines, "\n"))
+}
+
+// nextLocaleCharset returns the locale charset after cs, cycling back to
+// none after the last
+func nextLocaleCharset(cs generator.CharSet) generator.CharSet {
+	for i, locale := range generator.LocaleCharSets {
+		if locale == cs {
+			if i+1 < len(generator.LocaleCharSets) {
+				return generator.LocaleCharSets[i+1]
+			}
+			return 0
+		}
+	}
+	return generator.LocaleCharSets[0]
+}
+
+// localeCharsetLabel names a locale charset for display, e.g. "German"
+func localeCharsetLabel(cs generator.CharSet) string {
+	if cs == 0 {
+		return "off"
+	}
+	return strings.Title(generator.CharSetToString(cs))
+}
+
+// strengthLabel gives a quick length-based strength label
+func strengthLabel(password string) string {
+	if n := utf8.RuneCountInString(password); n < 8 {
+		return "Weak"
+	} else if n < 12 {
+		return "Medium"
+	}
+	return "Strong"
 }
 
 func (m *GeneratorModel) View() string {
+	if m.largePrint {
+		return m.largePrintView()
+	}
+
 	var title string
 	switch m.generatorType {
 	case "random":
@@ -298,7 +813,7 @@ func (m *GeneratorModel) View() string {
 	}
 
 	titleStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
+		Foreground(theme.Text).
 		Bold(true).
 		Align(lipgloss.Center)
 
@@ -329,6 +844,7 @@ func (m *GeneratorModel) View() string {
 			}
 		}
 		
+		labels := m.typeLabels()
 		var settingsContent string
 		if m.width < 60 {
 			// Compact layout for small terminals  
@@ -336,10 +852,10 @@ func (m *GeneratorModel) View() string {
 Types: %s %s %s %s`,
 				m.lengthInput.View(),
 				focusHint,
-				checkbox("L", m.includeLower),
-				checkbox("U", m.includeUpper),
-				checkbox("N", m.includeNumbers),
-				checkbox("S", m.includeSymbols))
+				checkbox(labels[0], m.includeLower),
+				checkbox(labels[1], m.includeUpper),
+				checkbox(labels[2], m.includeNumbers),
+				checkbox(labels[3], m.includeSymbols))
 		} else if m.width < 90 {
 			// Medium compact layout for most terminals
 			settingsContent = fmt.Sprintf(`Settings:
@@ -348,10 +864,10 @@ Types: %s %s
        %s %s`,
 				m.lengthInput.View(),
 				focusHint,
-				checkbox("Lower(l)", m.includeLower),
-				checkbox("Upper(u)", m.includeUpper),
-				checkbox("Nums(n)", m.includeNumbers),
-				checkbox("Syms(s)", m.includeSymbols))
+				checkbox(labels[0], m.includeLower),
+				checkbox(labels[1], m.includeUpper),
+				checkbox(labels[2], m.includeNumbers),
+				checkbox(labels[3], m.includeSymbols))
 		} else {
 			// Full layout for very large terminals only
 			settingsContent = fmt.Sprintf(`Settings:
@@ -364,12 +880,16 @@ Character Types:
 %s`,
 				m.lengthInput.View(),
 				focusHint,
-				checkbox("Lowercase (l)", m.includeLower),
-				checkbox("Uppercase (u)", m.includeUpper),
-				checkbox("Numbers (n)", m.includeNumbers),
-				checkbox("Symbols (s)", m.includeSymbols))
+				checkbox(labels[0], m.includeLower),
+				checkbox(labels[1], m.includeUpper),
+				checkbox(labels[2], m.includeNumbers),
+				checkbox(labels[3], m.includeSymbols))
+		}
+		if m.width >= 60 {
+			settingsContent += fmt.Sprintf("\nLocale letters: %s (%s to change)",
+				localeCharsetLabel(m.localeCharset), keys.Label(ActionLocaleLetters))
 		}
-		settings = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Render(settingsContent)
+		settings = lipgloss.NewStyle().Foreground(theme.Text).Render(settingsContent)
 	} else if m.generatorType == "memorable" {
 		var focusHint string
 		if m.wordCountInput.Focused() {
@@ -379,35 +899,67 @@ Character Types:
 		}
 		
 		settingsContent := fmt.Sprintf(`Settings:
-Word Count: %s%s`, m.wordCountInput.View(), focusHint)
-		settings = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Render(settingsContent)
+Word Count: %s%s
+Separator: %s (%s to change)
+Wordlist: %s (%s to change)
+%s
+%s
+%s
+%s
+Leet substitutions: %s (%s to change)
+Press %s for %d candidates`, m.wordCountInput.View(), focusHint, separatorLabel(m.separator), keys.Label(ActionSeparator),
+			m.selectedWordlist().DisplayName(), keys.Label(ActionNextWordlist),
+			m.wordlistEntropyInfo(), checkbox(capitalizeLabel(), m.capitalize), checkbox(digitLabel(), m.digit),
+			checkbox(typoRobustLabel(), m.typoRobust),
+			m.leetMode, keys.Label(ActionLeet), keys.Label(ActionCandidates), m.candidateCount())
+		settings = lipgloss.NewStyle().Foreground(theme.Text).Render(settingsContent)
 	} else if m.generatorType == "pin" {
 		settingsContent := fmt.Sprintf(`Settings:
-PIN Length: %s`, m.lengthInput.View())
-		settings = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Render(settingsContent)
+PIN Length: %s
+Groups: %s (%s to change)
+Separator: %s (%s to change)`, m.lengthInput.View(), groupSizeLabel(m.groupSize), keys.Label(ActionPINGroups),
+			separatorLabel(m.separator), keys.Label(ActionSeparator))
+		settings = lipgloss.NewStyle().Foreground(theme.Text).Render(settingsContent)
+	}
+
+	// Live strength gauge, estimated from the settings before generating
+	if m.manager != nil && m.manager.Config != nil && m.manager.Config.ShowStrengthMeter {
+		settings += "\n\n" + m.strengthGaugeView()
+	}
+
+	// Warn when the settings cannot reach a safe entropy, whatever the meter setting
+	if gen, err := m.buildGenerator(); err == nil && gen.Validate() == nil {
+		if warning := entropyWarningView(gen); warning != "" {
+			settings += "\n\n" + warning
+		}
+		if random, ok := gen.(*generator.RandomGenerator); ok && random.UsesNonASCII() {
+			settings += "\n\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render("⚠ "+generator.NonASCIIWarning)
+		}
 	}
 
 	// Password output with word wrapping for long passphrases
 	var passwordDisplay string
 	if m.generating {
 		passwordDisplay = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("15")).
+			Foreground(theme.Text).
 			Render(fmt.Sprintf("%s Generating...", m.spinner.View()))
+	} else if m.showingCandidates {
+		passwordDisplay = m.candidatesView()
 	} else if m.currentPassword != "" {
 		// Use the current password as-is for now, will wrap after width calculation
-		passwordDisplay = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("15")).
-			Bold(true).
-			Render(m.currentPassword)
+		passwordDisplay = m.renderPassword(m.displayPassword())
+		if callout := m.confusableCallout(); callout != "" {
+			passwordDisplay += "\n" + subtleStyle.Render(callout)
+		}
 		// Only show strength if enabled in settings
 		if m.strength != "" && m.manager != nil && m.manager.Config != nil && m.manager.Config.ShowStrengthMeter {
 			passwordDisplay += "\nStrength: " + lipgloss.NewStyle().
-				Foreground(lipgloss.Color("15")).
+				Foreground(theme.Text).
 				Render(m.strength)
 		}
 	} else {
 		passwordDisplay = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(theme.Subtle).
 			Render("Press Enter to generate a password")
 	}
 
@@ -415,30 +967,36 @@ PIN Length: %s`, m.lengthInput.View())
 	var help string
 	if m.width < 60 {
 		// Compact help for small terminals
-		help = subtleStyle.Render("g: gen") + dotStyle +
-			subtleStyle.Render("c: copy") + dotStyle +
-			subtleStyle.Render("esc: back")
+		help = keyHelp(ActionGenerate, "gen") + dotStyle +
+			keyHelp(ActionCopy, "copy") + dotStyle +
+			keyHelp(ActionBack, "back")
 	} else if m.width < 90 {
 		// Medium help
-		help = subtleStyle.Render("enter: generate") + dotStyle +
-			subtleStyle.Render("tab: focus") + dotStyle +
-			subtleStyle.Render("c: copy") + dotStyle +
-			subtleStyle.Render("esc: back")
+		help = keyHelp(ActionGenerate, "generate") + dotStyle +
+			keyHelp(ActionFocus, "focus") + dotStyle +
+			keyHelp(ActionCopy, "copy") + dotStyle +
+			keyHelp(ActionBack, "back")
 	} else {
 		// Full help for larger terminals
-		help = subtleStyle.Render("enter/g: generate") + dotStyle +
-			subtleStyle.Render("tab: toggle focus") + dotStyle +
-			subtleStyle.Render("l/u/n/s: toggle types") + dotStyle +
-			subtleStyle.Render("c: copy") + dotStyle +
-			subtleStyle.Render("esc: back")
+		help = keyHelp(ActionGenerate, "generate") + dotStyle +
+			keyHelp(ActionFocus, "toggle focus") + dotStyle +
+			subtleStyle.Render(keys.Label(ActionToggleLower)+"/"+keys.Label(ActionToggleUpper)+"/"+
+				keys.Label(ActionToggleNumbers)+"/"+keys.Label(ActionToggleSymbols)+": toggle types") + dotStyle +
+			keyHelp(ActionCopy, "copy") + dotStyle +
+			keyHelp(ActionAutoType, "type") + dotStyle +
+			keyHelp(ActionReveal, "reveal") + dotStyle +
+			keyHelp(ActionLargePrint, "large print") + dotStyle +
+			keyHelp(ActionCandidates, "candidates") + dotStyle +
+			keyHelp(ActionSavePreset, "save preset") + dotStyle +
+			subtleStyle.Render(keys.Label(ActionUndo)+"/"+keys.Label(ActionRedo)+": undo/redo") + dotStyle +
+			keyHelp(ActionBack, "back")
 	}
 
 	// Status
-	status := ""
-	if m.statusMsg != "" {
-		status = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("15")).
-			Render(m.statusMsg)
+	status := m.status.View()
+	if m.savingPreset {
+		status = "Preset name: " + m.presetInput.View()
+		help = subtleStyle.Render("enter: save") + dotStyle + subtleStyle.Render("esc: cancel")
 	}
 
 	// Calculate responsive box sizes based on terminal width
@@ -502,12 +1060,12 @@ PIN Length: %s`, m.lengthInput.View())
 		// Extremely minimal styling for tiny terminals
 		settingsBoxStyle = lipgloss.NewStyle().
 			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")).
+			BorderForeground(theme.Border).
 			Padding(0).
 			Width(settingsWidth)
 		passwordBoxStyle = lipgloss.NewStyle().
 			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")).
+			BorderForeground(theme.Border).
 			Padding(0).
 			Width(passwordWidth).
 			Height(passwordHeight).
@@ -516,12 +1074,12 @@ PIN Length: %s`, m.lengthInput.View())
 		// Minimal styling for small terminals
 		settingsBoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("15")).
+			BorderForeground(theme.Text).
 			Padding(0, 1).
 			Width(settingsWidth)
 		passwordBoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("15")).
+			BorderForeground(theme.Text).
 			Padding(0, 1).
 			Width(passwordWidth).
 			Height(passwordHeight).
@@ -530,12 +1088,12 @@ PIN Length: %s`, m.lengthInput.View())
 		// Normal styling for larger terminals
 		settingsBoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("15")).
+			BorderForeground(theme.Text).
 			Padding(1, 2).
 			Width(settingsWidth)
 		passwordBoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("15")).
+			BorderForeground(theme.Text).
 			Padding(1, 2).
 			Width(passwordWidth).
 			Height(passwordHeight).
@@ -543,21 +1101,22 @@ PIN Length: %s`, m.lengthInput.View())
 	}
 
 	// Apply word wrapping for long passwords (all types, not just memorable)
-	if m.currentPassword != "" {
+	if m.currentPassword != "" && !m.generating && !m.showingCandidates {
 		wrapWidth := passwordWidth - 8 // Conservative padding for borders and alignment
 		if wrapWidth < 10 {
 			wrapWidth = 10 // Minimum wrap width
 		}
 		
+		shown := m.displayPassword()
 		var wrappedPassword string
 		if m.generatorType == "memorable" {
 			// Use word-based wrapping for memorable passphrases
-			wrappedPassword = wrapText(m.currentPassword, wrapWidth)
-		} else if len(m.currentPassword) > wrapWidth {
+			wrappedPassword = wrapText(shown, wrapWidth)
+		} else if len([]rune(shown)) > wrapWidth {
 			// Use character-based wrapping for random passwords and PINs
-			wrappedPassword = wrapPasswordChars(m.currentPassword, wrapWidth)
+			wrappedPassword = wrapPasswordChars(shown, wrapWidth)
 		} else {
-			wrappedPassword = m.currentPassword
+			wrappedPassword = shown
 		}
 		
 		// Calculate how many lines the wrapped text will have
@@ -571,7 +1130,7 @@ PIN Length: %s`, m.lengthInput.View())
 				if m.width < 30 {
 					passwordBoxStyle = lipgloss.NewStyle().
 						BorderStyle(lipgloss.NormalBorder()).
-						BorderForeground(lipgloss.Color("240")).
+						BorderForeground(theme.Border).
 						Padding(0).
 						Width(passwordWidth).
 						Height(passwordHeight).
@@ -579,7 +1138,7 @@ PIN Length: %s`, m.lengthInput.View())
 				} else if m.width < 60 {
 					passwordBoxStyle = lipgloss.NewStyle().
 						Border(lipgloss.NormalBorder()).
-						BorderForeground(lipgloss.Color("15")).
+						BorderForeground(theme.Text).
 						Padding(0, 1).
 						Width(passwordWidth).
 						Height(passwordHeight).
@@ -587,7 +1146,7 @@ PIN Length: %s`, m.lengthInput.View())
 				} else {
 					passwordBoxStyle = lipgloss.NewStyle().
 						Border(lipgloss.RoundedBorder()).
-						BorderForeground(lipgloss.Color("15")).
+						BorderForeground(theme.Text).
 						Padding(1, 2).
 						Width(passwordWidth).
 						Height(passwordHeight).
@@ -596,14 +1155,14 @@ PIN Length: %s`, m.lengthInput.View())
 			}
 		}
 		
-		passwordDisplay = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("15")).
-			Bold(true).
-			Render(wrappedPassword)
+		passwordDisplay = m.renderPassword(wrappedPassword)
+		if callout := m.confusableCallout(); callout != "" {
+			passwordDisplay += "\n" + subtleStyle.Render(wrapText(callout, wrapWidth))
+		}
 		// Re-add strength if enabled
 		if m.strength != "" && m.manager != nil && m.manager.Config != nil && m.manager.Config.ShowStrengthMeter {
 			passwordDisplay += "\nStrength: " + lipgloss.NewStyle().
-				Foreground(lipgloss.Color("15")).
+				Foreground(theme.Text).
 				Render(m.strength)
 		}
 	}
@@ -638,6 +1197,9 @@ PIN Length: %s`, m.lengthInput.View())
 	// Combine everything like main menu - always reserve space for status
 	var contentParts []string
 	contentParts = append(contentParts, titleStyle.Render(title))
+	if banner := insecureSeedBanner(); banner != "" {
+		contentParts = append(contentParts, banner)
+	}
 	
 	// Responsive spacing between sections
 	if m.height < 15 {
@@ -693,19 +1255,243 @@ PIN Length: %s`, m.lengthInput.View())
 	return mainStyle.Render(topSpacing + content + bottomSpacing)
 }
 
+// typeLabels returns the labels of the character type checkboxes in the
+// layout for the terminal width: lowercase, uppercase, numbers, symbols
+func (m *GeneratorModel) typeLabels() []string {
+	switch {
+	case m.width < 60:
+		return []string{"L", "U", "N", "S"}
+	case m.width < 90:
+		return []string{
+			"Lower(" + keys.Label(ActionToggleLower) + ")",
+			"Upper(" + keys.Label(ActionToggleUpper) + ")",
+			"Nums(" + keys.Label(ActionToggleNumbers) + ")",
+			"Syms(" + keys.Label(ActionToggleSymbols) + ")",
+		}
+	}
+	return []string{
+		"Lowercase (" + keys.Label(ActionToggleLower) + ")",
+		"Uppercase (" + keys.Label(ActionToggleUpper) + ")",
+		"Numbers (" + keys.Label(ActionToggleNumbers) + ")",
+		"Symbols (" + keys.Label(ActionToggleSymbols) + ")",
+	}
+}
+
+// typoRobustLabel is the label of the typo-robust words checkbox
+func typoRobustLabel() string {
+	return "Typo-robust words (" + keys.Label(ActionTypoRobust) + ")"
+}
+
+// capitalizeLabel is the label of the capitalized words checkbox
+func capitalizeLabel() string {
+	return "Capitalize words (" + keys.Label(ActionCapitalize) + ")"
+}
+
+// digitLabel is the label of the added digit checkbox
+func digitLabel() string {
+	return "Add a digit (" + keys.Label(ActionDigit) + ")"
+}
+
+// passphraseSeparators are the separators the passphrase screen cycles through
+var passphraseSeparators = []string{"-", "_", ".", " ", generator.SeparatorNone}
+
+// pinSeparators are the separators the PIN screen cycles through
+var pinSeparators = []string{"-", " ", "."}
+
+// pinGroupSizes are the group sizes the PIN screen cycles through
+var pinGroupSizes = []int{0, 2, 3, 4}
+
+// separatorChoices returns the separators of the screen's generator
+func (m *GeneratorModel) separatorChoices() []string {
+	if m.generatorType == "pin" {
+		return pinSeparators
+	}
+	return passphraseSeparators
+}
+
+// nextSeparator returns the choice after separator, starting over from
+// the first after the last or a custom one from the config
+func nextSeparator(separator string, choices []string) string {
+	for i, s := range choices {
+		if s == separator {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
+
+// nextGroupSize returns the PIN group size after size
+func nextGroupSize(size int) int {
+	for i, s := range pinGroupSizes {
+		if s == size {
+			return pinGroupSizes[(i+1)%len(pinGroupSizes)]
+		}
+	}
+	return pinGroupSizes[0]
+}
+
+// groupSizeLabel describes a PIN group size, e.g. "4 digits"
+func groupSizeLabel(size int) string {
+	if size <= 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%d digits", size)
+}
+
+// separatorLabel shows a separator, naming the ones that cannot be seen
+func separatorLabel(separator string) string {
+	switch separator {
+	case " ":
+		return "space"
+	case generator.SeparatorNone:
+		return "none"
+	}
+	return fmt.Sprintf("%q", separator)
+}
+
+// toggleAt flips the checkbox under a mouse click, if there is one
+func (m *GeneratorModel) toggleAt(msg tea.MouseMsg) {
+	var labels []string
+	var flags []*bool
+	switch m.generatorType {
+	case "random":
+		labels = m.typeLabels()
+		flags = []*bool{&m.includeLower, &m.includeUpper, &m.includeNumbers, &m.includeSymbols}
+	case "memorable":
+		labels = []string{capitalizeLabel(), digitLabel(), typoRobustLabel()}
+		flags = []*bool{&m.capitalize, &m.digit, &m.typoRobust}
+	}
+	if i := checkboxAt(m.View(), m.height, msg, labels); i >= 0 {
+		*flags[i] = !*flags[i]
+	}
+}
+
 // buildSettingsString creates a string representation of current settings
 func (m *GeneratorModel) buildSettingsString() string {
 	if m.generatorType == "random" {
-		return fmt.Sprintf("Length: %s, Lower: %t, Upper: %t, Numbers: %t, Symbols: %t",
+		settings := fmt.Sprintf("Length: %s, Lower: %t, Upper: %t, Numbers: %t, Symbols: %t",
 			m.lengthInput.Value(), m.includeLower, m.includeUpper, m.includeNumbers, m.includeSymbols)
+		if m.localeCharset != 0 {
+			settings += ", Locale: " + generator.CharSetToString(m.localeCharset)
+		}
+		return settings
 	} else if m.generatorType == "memorable" {
-		return fmt.Sprintf("Word Count: %s", m.wordCountInput.Value())
+		return fmt.Sprintf("Word Count: %s, Separator: %s, Capitalize: %t, Digit: %t, Wordlist: %s, Typo-robust: %t, Leet: %s",
+			m.wordCountInput.Value(), separatorLabel(m.separator), m.capitalize, m.digit, m.selectedWordlist().ID, m.typoRobust, m.leetMode)
 	} else if m.generatorType == "pin" {
-		return fmt.Sprintf("PIN Length: %s", m.lengthInput.Value())
+		settings := fmt.Sprintf("PIN Length: %s", m.lengthInput.Value())
+		if m.groupSize > 0 {
+			settings += fmt.Sprintf(", Groups: %d, Separator: %q", m.groupSize, m.separator)
+		}
+		return settings
 	}
 	return ""
 }
 
+// largePrintView renders the current password in big block characters
+// filling the screen, for reading it from across the room
+func (m *GeneratorModel) largePrintView() string {
+	width := m.width - 4
+	if width < largePrintFace.Advance {
+		width = 80
+	}
+
+	big := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render(renderLargePrint(m.currentPassword, width))
+
+	help := subtleStyle.Render(keys.Label(ActionLargePrint)+"/"+keys.Label(ActionBack)+": exit large print") + dotStyle +
+		subtleStyle.Render("ctrl+c: menu")
+
+	content := big + "\n\n" + help
+	if m.width > 0 && m.height > 0 {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+	}
+	return mainStyle.Render("\n" + content + "\n")
+}
+
+// candidatesView renders the candidate list with the selected one highlighted
+func (m *GeneratorModel) candidatesView() string {
+	if len(m.candidates) == 0 {
+		return ""
+	}
+
+	header := lipgloss.NewStyle().
+		Foreground(theme.Subtle).
+		Render(fmt.Sprintf("Candidate %d of %d · Entropy: %.1f bits each", m.candidateIndex+1, len(m.candidates), m.candidateEntropy))
+
+	lines := []string{header}
+	for i, candidate := range m.candidates {
+		shown := maskSecret(candidate, m.masked)
+		if m.generatorType == "random" && m.manager != nil && m.manager.Config != nil {
+			shown = groupChars(shown, m.manager.Config.PasswordGroupSize)
+		}
+
+		if i == m.candidateIndex {
+			lines = append(lines, checkboxStyle.Render("› ")+renderSecret(shown, m.masked))
+		} else {
+			lines = append(lines, "  "+subtleStyle.Render(shown))
+		}
+	}
+
+	selected := m.candidates[m.candidateIndex]
+	details := "Strength: " + strengthLabel(selected)
+	if m.generatorType == "memorable" {
+		details += fmt.Sprintf(" · Memorability: %d/100", generator.EstimateMemorability(strings.Fields(selected)))
+	}
+	lines = append(lines, lipgloss.NewStyle().Foreground(theme.Text).Render(details))
+
+	return strings.Join(lines, "\n")
+}
+
+// leetTransform builds the leet-speak transform from the configured substitutions
+func (m *GeneratorModel) leetTransform() (generator.LeetTransform, error) {
+	spec := generator.DefaultLeetSubstitutions
+	if m.manager != nil && m.manager.Config != nil && m.manager.Config.LeetSubstitutions != "" {
+		spec = m.manager.Config.LeetSubstitutions
+	}
+	return generator.NewLeetTransform(m.leetMode, spec)
+}
+
+// selectedWordlist returns the wordlist currently chosen for passphrases
+func (m *GeneratorModel) selectedWordlist() generator.WordlistInfo {
+	if m.wordlistIndex < 0 || m.wordlistIndex >= len(m.wordlists) {
+		return generator.WordlistInfo{ID: generator.DefaultWordlistID, Name: "EFF Large"}
+	}
+	return m.wordlists[m.wordlistIndex]
+}
+
+// wordlistEntropyInfo describes the size and entropy of the selected wordlist
+func (m *GeneratorModel) wordlistEntropyInfo() string {
+	id := m.selectedWordlist().ID
+	words, err := generator.GetBundledWordlist(id)
+	if err != nil {
+		return "Entropy: unavailable"
+	}
+
+	wordCount, _ := strconv.Atoi(m.wordCountInput.Value())
+	if wordCount <= 0 {
+		wordCount = 4
+	}
+
+	perWord := generator.WordlistEntropyPerWord(id)
+	info := fmt.Sprintf("Entropy: %d words, %.1f bits/word, %.1f bits total",
+		len(words), perWord, perWord*float64(wordCount))
+
+	if m.typoRobust {
+		robust := generator.TypoRobustEntropy(words, wordCount)
+		info += fmt.Sprintf("\nTypo-robust: %.1f bits total (-%.2f bits)", robust, perWord*float64(wordCount)-robust)
+	}
+
+	if leet, err := m.leetTransform(); err != nil {
+		info += "\nLeet: " + err.Error()
+	} else if m.leetMode != generator.LeetOff {
+		info += fmt.Sprintf("\nLeet (%s): +%.1f bits", m.leetMode, leet.ExtraEntropy(words, wordCount, m.capitalize))
+	}
+	return info
+}
+
 // wrapText wraps text to fit within the specified width
 func wrapText(text string, width int) string {
 	if width <= 0 {
@@ -746,19 +1532,117 @@ func wrapText(text string, width int) string {
 	return strings.Join(lines, "\n")
 }
 
+// displayPassword returns the current password as shown on screen: masked
+// if reveal is off, and grouped for random passwords
+func (m *GeneratorModel) displayPassword() string {
+	if strings.HasPrefix(m.currentPassword, "Error:") {
+		return m.currentPassword
+	}
+	shown := maskSecret(m.currentPassword, m.masked)
+	if m.generatorType == "random" && m.manager != nil && m.manager.Config != nil {
+		shown = groupChars(shown, m.manager.Config.PasswordGroupSize)
+	}
+	return shown
+}
+
+// confusableCallout spells out look-alike characters in the current
+// password, if enabled and the password is visible
+func (m *GeneratorModel) confusableCallout() string {
+	if m.masked || strings.HasPrefix(m.currentPassword, "Error:") ||
+		m.manager == nil || m.manager.Config == nil || !m.manager.Config.AnnotateConfusables {
+		return ""
+	}
+	return confusableCallout(m.currentPassword)
+}
+
+// confusableCallout lists the look-alike characters in a secret as plain
+// text, or returns an empty string if there are none
+func confusableCallout(secret string) string {
+	found := generator.FindConfusables(secret)
+	if len(found) == 0 {
+		return ""
+	}
+
+	notes := make([]string, len(found))
+	for i, c := range found {
+		notes[i] = c.String()
+	}
+	return "Look-alikes: " + strings.Join(notes, dotChar)
+}
+
+// passwordGroupSeparator separates display groups. A thin space reads as a
+// gap without looking like part of the password.
+const passwordGroupSeparator = "\u2009"
+
+// groupChars splits text into blocks of size characters for display only.
+// A size of zero or less leaves it unchanged.
+func groupChars(text string, size int) string {
+	chars := []rune(text)
+	if size <= 0 || len(chars) <= size {
+		return text
+	}
+
+	var b strings.Builder
+	for i, r := range chars {
+		if i > 0 && i%size == 0 {
+			b.WriteString(passwordGroupSeparator)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// renderPassword renders the shown password, colorized unless it is masked
+// or an error message
+func (m *GeneratorModel) renderPassword(shown string) string {
+	return renderSecret(shown, m.masked || strings.HasPrefix(m.currentPassword, "Error:"))
+}
+
+// renderSecret colors each character of a secret by class. Plain text is
+// rendered in the text color, for masked secrets and messages.
+func renderSecret(text string, plain bool) string {
+	if plain {
+		return lipgloss.NewStyle().
+			Foreground(theme.Text).
+			Bold(true).
+			Render(text)
+	}
+	return colorizeSecret(text)
+}
+
+// maskSecret replaces every character but spaces with a dot, keeping the
+// shape of the secret so wrapping still works
+func maskSecret(secret string, masked bool) string {
+	if !masked {
+		return secret
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' {
+			return r
+		}
+		return '•'
+	}, secret)
+}
+
+// masksPasswords reports whether secrets start masked
+func masksPasswords(manager *utils.Manager) bool {
+	return manager != nil && manager.Config != nil && manager.Config.MaskPasswords
+}
+
 // wrapPasswordChars wraps passwords character by character for random/PIN passwords
 func wrapPasswordChars(password string, width int) string {
-	if width <= 0 || len(password) <= width {
+	chars := []rune(password)
+	if width <= 0 || len(chars) <= width {
 		return password
 	}
 	
 	var lines []string
-	for i := 0; i < len(password); i += width {
+	for i := 0; i < len(chars); i += width {
 		end := i + width
-		if end > len(password) {
-			end = len(password)
+		if end > len(chars) {
+			end = len(chars)
 		}
-		lines = append(lines, password[i:end])
+		lines = append(lines, string(chars[i:end]))
 	}
 	
 	return strings.Join(lines, "\n")
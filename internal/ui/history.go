@@ -1,12 +1,15 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mshnjffr/passman/internal/utils"
@@ -14,9 +17,20 @@ import (
 
 var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("240"))
+	BorderForeground(theme.Border)
 
-type clearStatusMsg struct{}
+// exportDoneMsg reports the result of a background export
+type exportDoneMsg struct {
+	status string
+	err    error
+}
+
+// rotatedMsg reports that an entry was marked as replacing another
+type rotatedMsg struct {
+	previous string // Label of the replaced entry
+	flagged  int    // Linked entries flagged for updating
+	err      error
+}
 
 // HistoryModel represents the password history screen
 type HistoryModel struct {
@@ -24,10 +38,42 @@ type HistoryModel struct {
 	manager     *utils.Manager
 	width       int
 	height      int
-	statusMsg   string
-	filterType  string // "all", "random", "memorable", "pin"
+	status      StatusBar
+	filterType  string // "all", "random", "memorable", "pin", "unused", "expired", "tag"
+	tagFilter   string // Tag shown when filterType is "tag"
+	showDetail  bool   // Show the detail view of the selected entry
+	sharing     bool   // Asking who the selected entry was shared with
+	shareInput  textinput.Model
+	linking     bool   // Asking which entry the selected entry shares a password with
+	linkInput   textinput.Model
+	tagging     bool   // Editing the tags of the selected entry
+	tagInput    textinput.Model
+	editing     bool     // Editing the description, site and username of the selected entry
+	editStep    int      // Field being edited, an index into detailFields
+	editValues  []string // Values entered so far
+	editInput   textinput.Model
+	clearing    bool // Asking to type the confirmation for clearing the history
+	clearInput  textinput.Model
+	marked      map[string]bool // IDs of the entries marked for export
+	choosingFormat bool         // Asking the format of the marked entries' export
 	allEntries  []utils.HistoryEntry // Cache all entries
 	displayedEntries []utils.HistoryEntry // Currently displayed entries for copying
+	filtered    []utils.HistoryEntry // Entries matching the filter, on all pages
+	page        int                  // Page of filtered entries shown in the table
+	dirty       bool                 // The table rows need rebuilding
+	summary     historySummary       // Stats and warnings over all entries
+	columnNames []string             // Columns of the table, from history_columns
+	strengths   map[string]entryStrength // Passwords without a stored strength, analysed once
+}
+
+// historySummary is what the history screen reports about all entries,
+// worked out once per load rather than on every redraw
+type historySummary struct {
+	stats        utils.UsageStats
+	expired      int // Shared entries past their expiry
+	stale        int // Linked entries whose primary was rotated
+	reused       int // Passwords reused by unlinked entries
+	linkWarnings []utils.LinkWarning
 }
 
 // NewHistoryModel creates a new history model
@@ -49,23 +95,53 @@ func NewHistoryModel(manager *utils.Manager) *HistoryModel {
 	s := table.DefaultStyles()
 	s.Header = s.Header.
 		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(theme.Border).
 		BorderBottom(true).
 		Bold(false).
-		Foreground(lipgloss.Color("15"))
+		Foreground(theme.Text)
 	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
+		Foreground(theme.SelectedFg).
+		Background(theme.SelectedBg).
 		Bold(false)
-	s.Cell = s.Cell.Foreground(lipgloss.Color("15"))
+	s.Cell = s.Cell.Foreground(theme.Text)
 	t.SetStyles(s)
 
+	shareInput := textinput.New()
+	shareInput.Placeholder = "recipient"
+	shareInput.CharLimit = 64
+	shareInput.Width = 30
+
+	linkInput := textinput.New()
+	linkInput.Placeholder = "entry name or ID, empty to unlink"
+	linkInput.CharLimit = 64
+	linkInput.Width = 36
+
+	tagInput := textinput.New()
+	tagInput.Placeholder = "work, personal, throwaway"
+	tagInput.CharLimit = 128
+	tagInput.Width = 36
+
+	editInput := textinput.New()
+	editInput.CharLimit = 128
+	editInput.Width = 36
+
+	clearInput := textinput.New()
+	clearInput.Placeholder = utils.ClearConfirmation
+	clearInput.CharLimit = 16
+	clearInput.Width = 16
+
 	model := &HistoryModel{
 		table:      t,
+		editInput:  editInput,
+		clearInput: clearInput,
+		shareInput: shareInput,
+		linkInput:  linkInput,
+		tagInput:   tagInput,
 		manager:    manager,
 		width:      40,  // Conservative default for small terminals
 		height:     12,  // Conservative default for small terminals
 		filterType: "all", // Show all types by default
+		columnNames: []string{"time", "password", "length", "type"},
 	}
 	
 	return model
@@ -83,6 +159,68 @@ func NewHistoryModelWithSize(manager *utils.Manager, width, height int) *History
 // RefreshCache clears the cached entries to force a reload
 func (m *HistoryModel) RefreshCache() {
 	m.allEntries = nil
+	m.dirty = true
+}
+
+// refresh reloads the entries when the screen is shown again, as some may
+// have been generated meanwhile
+func (m *HistoryModel) refresh() {
+	m.RefreshCache()
+}
+
+// setFilter shows the entries of filterType from the first page
+func (m *HistoryModel) setFilter(filterType string) {
+	m.filterType = filterType
+	m.page = 0
+	m.dirty = true
+	m.table.SetCursor(0)
+}
+
+// pageSize returns how many entries fit in the table
+func (m *HistoryModel) pageSize() int {
+	if size := m.table.Height(); size > 0 {
+		return size
+	}
+	return 1
+}
+
+// pageCount returns the number of pages of filtered entries
+func (m *HistoryModel) pageCount() int {
+	if len(m.filtered) == 0 {
+		return 1
+	}
+	return (len(m.filtered) + m.pageSize() - 1) / m.pageSize()
+}
+
+// turnPage moves delta pages and puts the cursor on the first row, or on
+// the last one when atEnd
+func (m *HistoryModel) turnPage(delta int, atEnd bool) {
+	page := m.page + delta
+	if page < 0 || page >= m.pageCount() {
+		return
+	}
+	m.page = page
+	m.dirty = true
+	m.loadHistoryData()
+	if atEnd {
+		m.table.SetCursor(len(m.displayedEntries) - 1)
+	} else {
+		m.table.SetCursor(0)
+	}
+}
+
+// moveCursor moves the selection delta rows, continuing on the next or
+// previous page past either end of this one
+func (m *HistoryModel) moveCursor(delta int) {
+	cursor := m.table.Cursor() + delta
+	switch {
+	case cursor < 0:
+		m.turnPage(-1, true)
+	case cursor >= len(m.displayedEntries):
+		m.turnPage(1, false)
+	default:
+		m.table.SetCursor(cursor)
+	}
 }
 
 func (m *HistoryModel) Init() tea.Cmd {
@@ -90,6 +228,14 @@ func (m *HistoryModel) Init() tea.Cmd {
 }
 
 func (m *HistoryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.status.Update(msg) {
+		return m, nil
+	}
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, m.status.Cmd())
+}
+
+func (m *HistoryModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -99,60 +245,1021 @@ func (m *HistoryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return NewMenuModelWithSize(m.manager, m.width, m.height), nil
-		case "esc":
-			return NewMenuModelWithSize(m.manager, m.width, m.height), nil
-		case "enter":
+		if m.sharing {
+			switch msg.String() {
+			case "enter":
+				m.sharing = false
+				m.recordShare(m.shareInput.Value())
+				return m, nil
+			case "esc":
+				m.sharing = false
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			m.shareInput, cmd = m.shareInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.linking {
+			switch msg.String() {
+			case "enter":
+				m.linking = false
+				m.linkEntry(m.linkInput.Value())
+				return m, nil
+			case "esc":
+				m.linking = false
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			m.linkInput, cmd = m.linkInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.tagging {
+			switch msg.String() {
+			case "enter":
+				m.tagging = false
+				m.saveTags(m.tagInput.Value())
+				return m, nil
+			case "esc":
+				m.tagging = false
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			m.tagInput, cmd = m.tagInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.editing {
+			switch msg.String() {
+			case "enter":
+				m.editValues = append(m.editValues, m.editInput.Value())
+				if m.editStep+1 < len(detailFields) {
+					m.editStep++
+					m.promptDetail()
+					return m, nil
+				}
+				m.editing = false
+				return m, m.saveDetails(m.editValues)
+			case "esc":
+				m.editing = false
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			m.editInput, cmd = m.editInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.choosingFormat {
+			format, chosen := utils.ExportFormat(""), true
+			switch msg.String() {
+			case "t":
+				format = utils.FormatText
+			case "j":
+				format = utils.FormatJSON
+			case "c":
+				format = utils.FormatCSV
+			case "k":
+				format = utils.FormatHTML
+			case "enter":
+				if m.manager != nil && m.manager.Config != nil {
+					format = utils.ExportFormat(m.manager.Config.DefaultExportFormat)
+				}
+			case "esc":
+				m.choosingFormat = false
+				m.status.Info("Export cancelled")
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			default:
+				chosen = false
+			}
+			if !chosen {
+				return m, nil
+			}
+			m.choosingFormat = false
+			return m, m.exportMarked(format)
+		}
+
+		if m.clearing {
+			switch msg.String() {
+			case "enter":
+				m.clearing = false
+				m.clearHistory(m.clearInput.Value())
+				return m, nil
+			case "esc":
+				m.clearing = false
+				m.status.Info("History not cleared")
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			m.clearInput, cmd = m.clearInput.Update(msg)
+			return m, cmd
+		}
+
+		if field, ok := copyFieldAction(msg); ok {
+			m.copyField(field)
+			return m, nil
+		}
+
+		if m.showDetail {
+			switch {
+			case keys.Matches(msg, ActionBack), keys.Matches(msg, ActionDetails), keys.Matches(msg, ActionQuit):
+				m.showDetail = false
+			case keys.Matches(msg, ActionShare):
+				// Record who this secret was shared with
+				m.sharing = true
+				m.shareInput.Reset()
+				m.shareInput.Focus()
+				return m, textinput.Blink
+			case keys.Matches(msg, ActionLink):
+				// Record that this entry uses the same password as another
+				m.linking = true
+				m.linkInput.Reset()
+				m.linkInput.Focus()
+				return m, textinput.Blink
+			case keys.Matches(msg, ActionTags):
+				return m, m.startTagging()
+			case keys.Matches(msg, ActionEditDetails):
+				return m, m.startEditing()
+			case keys.Matches(msg, ActionRevoke):
+				// Tick off the revocation checklist once the secret is rotated
+				m.markRotated()
+				if m.filterType == "expired" {
+					// The entry has left the checklist
+					m.showDetail = false
+				}
+				return m, nil
+			case msg.String() == "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			return m, nil
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionBack):
+			// The first esc drops the marks, so they are not lost by accident
+			if len(m.marked) > 0 {
+				m.marked = nil
+				m.dirty = true
+				m.status.Info("Marks cleared")
+				return m, nil
+			}
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionMark):
+			m.toggleMark()
+			return m, nil
+		case keys.Matches(msg, ActionExportMarked):
+			if len(m.markedEntries()) == 0 {
+				m.status.Info(fmt.Sprintf("Mark entries with %s first", keys.Label(ActionMark)))
+				return m, nil
+			}
+			m.choosingFormat = true
+			return m, nil
+		case keys.Matches(msg, ActionPick):
+			return m, navigate(PickScreen)
+		case keys.Matches(msg, ActionSelect):
 			// Copy selected password to clipboard (full password, not truncated)
 			selectedIndex := m.table.Cursor()
 			if selectedIndex >= 0 && selectedIndex < len(m.displayedEntries) && m.manager != nil && m.manager.Clipboard != nil {
 				fullPassword := m.displayedEntries[selectedIndex].Password
 				if err := m.manager.Clipboard.Copy(fullPassword); err == nil {
-					m.statusMsg = "Password copied to clipboard!"
-					return m, tea.Batch(cmd, m.clearStatusAfter(2*time.Second))
+					m.status.Success("Password copied to clipboard!")
+					recordUsage(m.manager, m.displayedEntries[selectedIndex].ID, utils.UsageCopied)
+					m.RefreshCache()
+					return m, cmd
 				} else {
-					m.statusMsg = "Failed to copy to clipboard"
-					return m, tea.Batch(cmd, m.clearStatusAfter(3*time.Second))
+					m.status.Error("Failed to copy to clipboard")
+					return m, cmd
 				}
 			}
-		case "a":
+		case keys.Matches(msg, ActionFilterAll):
 			// Show all types
-			m.filterType = "all"
-			m.statusMsg = "Showing all password types"
-			return m, tea.Batch(cmd, m.clearStatusAfter(2*time.Second))
-		case "r":
+			m.setFilter("all")
+			m.status.Info("Showing all password types")
+			return m, cmd
+		case keys.Matches(msg, ActionFilterRandom):
 			// Filter by random passwords
-			m.filterType = "random"
-			m.statusMsg = "Filtering by Random passwords"
-			return m, tea.Batch(cmd, m.clearStatusAfter(2*time.Second))
-		case "m":
+			m.setFilter("random")
+			m.status.Info("Filtering by Random passwords")
+			return m, cmd
+		case keys.Matches(msg, ActionFilterMemorable):
 			// Filter by memorable passwords  
-			m.filterType = "memorable"
-			m.statusMsg = "Filtering by Memorable passwords"
-			return m, tea.Batch(cmd, m.clearStatusAfter(2*time.Second))
-		case "p":
+			m.setFilter("memorable")
+			m.status.Info("Filtering by Memorable passwords")
+			return m, cmd
+		case keys.Matches(msg, ActionFilterPIN):
 			// Filter by PIN codes
-			m.filterType = "pin"
-			m.statusMsg = "Filtering by PIN codes"
-			return m, tea.Batch(cmd, m.clearStatusAfter(2*time.Second))
+			m.setFilter("pin")
+			m.status.Info("Filtering by PIN codes")
+			return m, cmd
+		case keys.Matches(msg, ActionFilterUnused):
+			// Audit: entries never copied or revealed are cleanup candidates
+			m.setFilter("unused")
+			m.status.Info("Showing never-used entries (cleanup candidates)")
+			return m, cmd
+		case keys.Matches(msg, ActionFilterExpired):
+			// Revocation checklist: shared entries past their expiry
+			m.setFilter("expired")
+			m.status.Info(fmt.Sprintf("Showing expired shares to rotate (%s then %s once rotated)",
+				keys.Label(ActionDetails), keys.Label(ActionRevoke)))
+			return m, cmd
+		case keys.Matches(msg, ActionFilterTag):
+			// Cycle through the tags in use, then back to all entries
+			m.status.Info(m.nextTagFilter())
+			return m, cmd
+		case keys.Matches(msg, ActionTags):
+			return m, m.startTagging()
+		case keys.Matches(msg, ActionDetails):
+			// Show details of the selected entry, which reveals the full password
+			selectedIndex := m.table.Cursor()
+			if selectedIndex >= 0 && selectedIndex < len(m.displayedEntries) {
+				m.showDetail = true
+				recordUsage(m.manager, m.displayedEntries[selectedIndex].ID, utils.UsageRevealed)
+				m.RefreshCache()
+			}
+			return m, nil
+		case keys.Matches(msg, ActionExportUnique):
+			// Export each unique password once, for importing elsewhere
+			path := m.uniqueExportPath()
+			if path != "" {
+				return m, m.confirmExport(path, m.exportUniqueCmd(path))
+			}
+			return m, m.exportUniqueCmd(path)
+		case keys.Matches(msg, ActionClearHistory):
+			// Delete every entry, once the confirmation is typed
+			if len(m.allEntries) == 0 {
+				m.status.Info("The history is already empty")
+				return m, nil
+			}
+			m.clearing = true
+			m.clearInput.Reset()
+			m.clearInput.Focus()
+			return m, textinput.Blink
+		case keys.Matches(msg, ActionReuseAudit):
+			// Passwords shared by several entries
+			return m, navigate(AuditScreen)
+		case keys.Matches(msg, ActionSort):
+			// Cycle through the sort columns
+			by, reverse := m.sortOrder()
+			next := historySorts[0]
+			for i, s := range historySorts {
+				if s == by && i+1 < len(historySorts) {
+					next = historySorts[i+1]
+				}
+			}
+			m.setSortOrder(next, reverse)
+			m.status.Info(sortDescription(next, reverse))
+			return m, nil
+		case keys.Matches(msg, ActionReverseSort):
+			by, reverse := m.sortOrder()
+			m.setSortOrder(by, !reverse)
+			m.status.Info(sortDescription(by, !reverse))
+			return m, nil
+		case keys.Matches(msg, ActionPrevPage):
+			m.turnPage(-1, false)
+			return m, nil
+		case keys.Matches(msg, ActionNextPage):
+			m.turnPage(1, false)
+			return m, nil
+		case key.Matches(msg, m.table.KeyMap.LineUp) && m.table.Cursor() == 0 && m.page > 0:
+			// Moving past the top of the page continues on the previous one
+			m.turnPage(-1, true)
+			return m, nil
+		case key.Matches(msg, m.table.KeyMap.LineDown) && m.table.Cursor() == len(m.displayedEntries)-1 && m.page < m.pageCount()-1:
+			m.turnPage(1, false)
+			return m, nil
 		}
-	case clearStatusMsg:
-		m.statusMsg = ""
+	case tea.MouseMsg:
+		// The wheel scrolls the table, on through the pages, unless a prompt
+		// about the selected entry is open
+		prompting := m.sharing || m.linking || m.tagging || m.editing || m.clearing || m.choosingFormat
+		if step := mouseWheel(msg); step != 0 && !prompting {
+			m.moveCursor(step)
+		}
+		return m, nil
+
+	case exportDoneMsg:
+		m.status.Result(msg.status, msg.err)
+		return m, nil
+
+	case rotatedMsg:
+		if msg.err != nil {
+			m.status.Error("Failed to record the rotation: " + msg.err.Error())
+			return m, nil
+		}
+		m.RefreshCache()
+		status := fmt.Sprintf("%q marked as replaced", msg.previous)
+		if msg.flagged > 0 {
+			status += fmt.Sprintf("; %d linked entries need updating", msg.flagged)
+		}
+		m.status.Success(status)
 		return m, nil
 	}
 	m.table, cmd = m.table.Update(msg)
 	return m, cmd
 }
 
-func (m *HistoryModel) clearStatusAfter(d time.Duration) tea.Cmd {
-	return tea.Tick(d, func(time.Time) tea.Msg {
-		return clearStatusMsg{}
-	})
+// exportUniqueCmd runs the unique export in the background. The notification
+// is sent from the task itself, so it fires even after leaving this screen.
+func (m *HistoryModel) exportUniqueCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		done := startTask(m.manager, "export")
+		defer done()
+		status, err := m.exportUnique(path)
+		notify(m.manager, utils.EventExport, "History export", status)
+		return exportDoneMsg{status: status, err: err}
+	}
+}
+
+// toggleMark marks the entry under the cursor for export, or unmarks it,
+// and moves on to the next entry
+func (m *HistoryModel) toggleMark() {
+	entry, ok := m.selectedEntry()
+	if !ok {
+		return
+	}
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	if m.marked[entry.ID] {
+		delete(m.marked, entry.ID)
+	} else {
+		m.marked[entry.ID] = true
+	}
+	m.dirty = true
+	m.loadHistoryData()
+	m.moveCursor(1)
+	m.status.Info(fmt.Sprintf("%d marked · %s: export them", len(m.marked), keys.Label(ActionExportMarked)))
+}
+
+// markedEntries returns the marked entries still in the history, in
+// history order
+func (m *HistoryModel) markedEntries() []utils.HistoryEntry {
+	var marked []utils.HistoryEntry
+	for _, entry := range m.allEntries {
+		if m.marked[entry.ID] {
+			marked = append(marked, entry)
+		}
+	}
+	return marked
+}
+
+// exportMarked exports the marked entries in format
+func (m *HistoryModel) exportMarked(format utils.ExportFormat) tea.Cmd {
+	if m.manager == nil || m.manager.Export == nil || m.manager.Config == nil {
+		m.status.Error("Export is unavailable")
+		return nil
+	}
+	base := "selected_passwords"
+	if format == utils.FormatHTML {
+		base = "emergency_kit"
+	}
+	path := m.manager.Config.GetExportPath(m.manager.Export.GetSuggestedFilename(format, base))
+	return m.confirmExport(path, m.exportMarkedCmd(m.markedEntries(), format, path))
+}
+
+// confirmExport runs export once the user agrees to passwords being
+// written unencrypted to path, or to an existing file being replaced.
+// An encrypted export to a new file runs straight away.
+func (m *HistoryModel) confirmExport(path string, export tea.Cmd) tea.Cmd {
+	_, err := os.Stat(path)
+	exists := err == nil
+	if tool := m.manager.Export.Encryption(); tool != "" {
+		if exists {
+			return confirm("Overwrite "+path+"?", export)
+		}
+		m.status.Hint("Exporting, encrypted with " + tool + "...")
+		return export
+	}
+
+	prompt := "Passwords are written unencrypted to " + path
+	if exists {
+		prompt += ", replacing it"
+	}
+	return confirm(prompt+". Export?", export)
+}
+
+// exportMarkedCmd writes entries to path in the background, like the
+// unique export
+func (m *HistoryModel) exportMarkedCmd(entries []utils.HistoryEntry, format utils.ExportFormat, path string) tea.Cmd {
+	return func() tea.Msg {
+		done := startTask(m.manager, "export")
+		defer done()
+
+		exported := make([]utils.PasswordEntry, 0, len(entries))
+		for _, entry := range entries {
+			exported = append(exported, utils.PasswordEntry{
+				Password:    entry.Password,
+				Length:      entry.Length,
+				Type:        entry.Type,
+				CreatedAt:   entry.CreatedAt,
+				Description: entry.Description,
+			})
+		}
+
+		status := fmt.Sprintf("Exported %d marked passwords to %s", len(exported), path)
+		err := m.manager.Export.Export(exported, format, path)
+		if err != nil {
+			status = "Export failed: " + err.Error()
+		}
+		notify(m.manager, utils.EventExport, "History export", status)
+		return exportDoneMsg{status: status, err: err}
+	}
+}
+
+// uniqueExportPath is where the unique export goes, or "" when history
+// is disabled
+func (m *HistoryModel) uniqueExportPath() string {
+	if m.manager == nil || m.manager.Export == nil || m.manager.Config == nil {
+		return ""
+	}
+	format := utils.ExportFormat(m.manager.Config.DefaultExportFormat)
+	return m.manager.Config.GetExportPath(m.manager.Export.GetSuggestedFilename(format, "unique_passwords"))
+}
+
+// exportUnique writes the deduplicated history to path and returns a
+// status message, with the error if it failed
+func (m *HistoryModel) exportUnique(path string) (string, error) {
+	if m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() || m.manager.Export == nil {
+		return "History is disabled", errors.New("history is disabled")
+	}
+
+	entries, err := m.manager.History.LoadHistory()
+	if err != nil {
+		return "Failed to load history: " + err.Error(), err
+	}
+	if len(entries) == 0 {
+		return "No passwords to export", nil
+	}
+
+	unique := utils.UniquePasswords(entries, utils.DedupOptions{KeepFirstSeen: true, MergeLabels: true})
+
+	format := utils.ExportFormat(m.manager.Config.DefaultExportFormat)
+	if err := m.manager.Export.Export(unique, format, path); err != nil {
+		return "Export failed: " + err.Error(), err
+	}
+	return fmt.Sprintf("Exported %d unique of %d passwords to %s", len(unique), len(entries), path), nil
+}
+
+// selectedEntry returns the entry under the cursor
+func (m *HistoryModel) selectedEntry() (utils.HistoryEntry, bool) {
+	selectedIndex := m.table.Cursor()
+	if selectedIndex < 0 || selectedIndex >= len(m.displayedEntries) {
+		return utils.HistoryEntry{}, false
+	}
+	return m.displayedEntries[selectedIndex], true
+}
+
+// recordShare records that the selected entry was shared with recipient,
+// expiring after the configured number of days
+func (m *HistoryModel) recordShare(recipient string) {
+	entry, ok := m.selectedEntry()
+	if !ok || m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.status.Error("History is disabled")
+		return
+	}
+
+	days := 0
+	if m.manager.Config != nil {
+		days = m.manager.Config.ShareExpiryDays
+	}
+	if err := m.manager.History.RecordShare(entry.ID, recipient, "manual", time.Duration(days)*24*time.Hour); err != nil {
+		m.status.Error("Failed to record share: " + err.Error())
+		return
+	}
+	m.RefreshCache()
+
+	if days == 0 {
+		m.status.Success(fmt.Sprintf("Recorded share with %s", strings.TrimSpace(recipient)))
+		return
+	}
+	m.status.Success(fmt.Sprintf("Recorded share with %s; rotate after %d days", strings.TrimSpace(recipient), days))
+}
+
+// markRotated records that the selected entry's password was changed: its
+// shares are marked revoked and the entries linked to it are flagged for
+// updating.
+func (m *HistoryModel) markRotated() {
+	entry, ok := m.selectedEntry()
+	if !ok || m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.status.Error("History is disabled")
+		return
+	}
+
+	flagged, err := m.manager.History.MarkRotated(entry.ID)
+	if err != nil {
+		m.status.Error("Failed to record rotation: " + err.Error())
+		return
+	}
+	m.RefreshCache()
+
+	var status []string
+	if entry.IsShared() {
+		if err := m.manager.History.RevokeShares(entry.ID); err != nil {
+			m.status.Error("Failed to revoke shares: " + err.Error())
+			return
+		}
+		status = append(status, "Shares marked revoked")
+	}
+	if flagged > 0 {
+		status = append(status, fmt.Sprintf("%d linked entries flagged to update", flagged))
+	}
+	if len(status) == 0 {
+		m.status.Success("Rotation recorded")
+		return
+	}
+	m.status.Success(strings.Join(status, "; "))
+}
+
+// linkEntry links the selected entry to the entry named ref, or unlinks it
+// when ref is empty
+func (m *HistoryModel) linkEntry(ref string) {
+	entry, ok := m.selectedEntry()
+	if !ok || m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.status.Error("History is disabled")
+		return
+	}
+
+	if strings.TrimSpace(ref) == "" {
+		if !entry.Linked() {
+			m.status.Info("This entry is not linked")
+			return
+		}
+		if err := m.manager.History.UnlinkEntry(entry.ID); err != nil {
+			m.status.Error("Failed to unlink: " + err.Error())
+			return
+		}
+		m.RefreshCache()
+		m.status.Success("Link removed")
+		return
+	}
+
+	if err := m.manager.History.LinkEntry(entry.ID, ref); err != nil {
+		m.status.Error("Failed to link: " + err.Error())
+		return
+	}
+	m.RefreshCache()
+	m.status.Success(fmt.Sprintf("Linked: uses the same password as %s", strings.TrimSpace(ref)))
+}
+
+// detailFields are the fields the details editor asks for, in order
+var detailFields = []utils.EntryField{utils.FieldDescription, utils.FieldSite, utils.FieldUsername}
+
+// copyFieldAction returns the field a key copies, other than the password
+func copyFieldAction(msg tea.KeyMsg) (utils.EntryField, bool) {
+	switch {
+	case keys.Matches(msg, ActionCopyUsername):
+		return utils.FieldUsername, true
+	case keys.Matches(msg, ActionCopySite):
+		return utils.FieldSite, true
+	case keys.Matches(msg, ActionCopyDescription):
+		return utils.FieldDescription, true
+	}
+	return "", false
+}
+
+// copyField copies one field of the selected entry. Only password copies count in the usage audit trail.
+func (m *HistoryModel) copyField(field utils.EntryField) {
+	entry, ok := m.selectedEntry()
+	if !ok || m.manager == nil || m.manager.Clipboard == nil {
+		m.status.Error("Nothing to copy")
+		return
+	}
+
+	value := entry.Field(field)
+	if value == "" {
+		m.status.Info(fmt.Sprintf("This entry has no %s — press %s in its details to add one", field, keys.Label(ActionEditDetails)))
+		return
+	}
+	if err := m.manager.Clipboard.Copy(value); err != nil {
+		m.status.Error("Failed to copy to clipboard")
+		return
+	}
+	m.status.Success(strings.Title(string(field)) + " copied to clipboard!")
+}
+
+// startEditing opens the details editor on the selected entry
+func (m *HistoryModel) startEditing() tea.Cmd {
+	if _, ok := m.selectedEntry(); !ok {
+		return nil
+	}
+	m.editing = true
+	m.editStep = 0
+	m.editValues = nil
+	m.promptDetail()
+	m.editInput.Focus()
+	return textinput.Blink
+}
+
+// promptDetail fills the details editor with the current value of the
+// field being edited
+func (m *HistoryModel) promptDetail() {
+	entry, _ := m.selectedEntry()
+	field := detailFields[m.editStep]
+	m.editInput.Placeholder = map[utils.EntryField]string{
+		utils.FieldDescription: "what the password is for",
+		utils.FieldSite:        "example.com",
+		utils.FieldUsername:    "me@example.com",
+	}[field]
+	m.editInput.SetValue(entry.Field(field))
+	m.editInput.CursorEnd()
+}
+
+// saveDetails stores the description, site and username entered in the
+// details editor. When an older entry has the same site or description,
+// it asks whether this entry's password replaces that one's.
+func (m *HistoryModel) saveDetails(values []string) tea.Cmd {
+	entry, ok := m.selectedEntry()
+	if !ok || m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.status.Error("History is disabled")
+		return nil
+	}
+
+	details := utils.EntryDetails{Description: values[0], Site: values[1], Username: values[2]}
+	if err := m.manager.History.SetDetails(entry.ID, details); err != nil {
+		m.status.Error("Failed to save details: " + err.Error())
+		return nil
+	}
+	m.RefreshCache()
+	m.status.Success("Details saved")
+
+	if entry.Replaces != "" {
+		return nil
+	}
+	entries, err := m.manager.History.LoadHistory()
+	if err != nil {
+		return nil
+	}
+	if entry, err = utils.FindEntry(entries, entry.ID); err != nil {
+		return nil
+	}
+	previous, found := utils.FindPredecessor(entries, entry)
+	if !found {
+		return nil
+	}
+	prompt := fmt.Sprintf("Does this password replace %q from %s?", previous.Label(), previous.CreatedAt.Format("Jan 2 2006"))
+	return confirm(prompt, m.rotateCmd(entry.ID, previous))
+}
+
+// rotateCmd marks the entry id as replacing previous
+func (m *HistoryModel) rotateCmd(id string, previous utils.HistoryEntry) tea.Cmd {
+	return func() tea.Msg {
+		flagged, err := m.manager.History.RotateEntry(id, previous.ID)
+		return rotatedMsg{previous: previous.Label(), flagged: flagged, err: err}
+	}
+}
+
+// startTagging opens the tag editor on the selected entry's tags
+func (m *HistoryModel) startTagging() tea.Cmd {
+	entry, ok := m.selectedEntry()
+	if !ok {
+		return nil
+	}
+	m.tagging = true
+	m.tagInput.SetValue(strings.Join(entry.Tags, ", "))
+	m.tagInput.CursorEnd()
+	m.tagInput.Focus()
+	return textinput.Blink
+}
+
+// saveTags replaces the tags of the selected entry with the comma- or
+// space-separated tags in input
+func (m *HistoryModel) saveTags(input string) {
+	entry, ok := m.selectedEntry()
+	if !ok || m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.status.Error("History is disabled")
+		return
+	}
+
+	tags, err := utils.ParseTags(input)
+	if err != nil {
+		m.status.Error("Invalid tags: " + err.Error())
+		return
+	}
+	if err := m.manager.History.SetTags(entry.ID, tags); err != nil {
+		m.status.Error("Failed to save tags: " + err.Error())
+		return
+	}
+	m.RefreshCache()
+
+	if len(tags) == 0 {
+		m.status.Success("Tags removed")
+		return
+	}
+	m.status.Success("Tagged " + formatTags(tags))
+}
+
+// clearHistory deletes every entry if input is the typed confirmation,
+// backing the history up first when backup_before_clear is set
+func (m *HistoryModel) clearHistory(input string) {
+	if strings.TrimSpace(input) != utils.ClearConfirmation {
+		m.status.Info(fmt.Sprintf("History not cleared: type %q to confirm", utils.ClearConfirmation))
+		return
+	}
+	if m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.status.Error("History is disabled")
+		return
+	}
+
+	count := len(m.allEntries)
+	if m.manager.Config != nil && m.manager.Config.BackupBeforeClear {
+		path := m.manager.Config.GetExportPath(utils.DefaultBackupName(time.Now()))
+		info, err := m.manager.History.ClearWithBackup(path)
+		if err != nil {
+			m.status.Error("History not cleared: " + err.Error())
+			return
+		}
+		m.status.Success(fmt.Sprintf("History cleared; %d entries backed up to %s", info.Entries, info.Path))
+	} else {
+		if err := m.manager.History.ClearHistory(); err != nil {
+			m.status.Error("Failed to clear history: " + err.Error())
+			return
+		}
+		m.status.Success(fmt.Sprintf("History cleared; %d entries deleted", count))
+	}
+
+	m.page = 0
+	m.table.SetCursor(0)
+	m.RefreshCache()
+}
+
+// nextTagFilter moves the tag filter to the next tag in use, or back to
+// all entries after the last one, and returns a status message
+func (m *HistoryModel) nextTagFilter() string {
+	tags := utils.AllTags(m.allEntries)
+	if len(tags) == 0 {
+		return fmt.Sprintf("No tagged entries yet — press %s to tag one", keys.Label(ActionTags))
+	}
+
+	next := tags[0]
+	if m.filterType == "tag" {
+		next = ""
+		for i, tag := range tags {
+			if tag == m.tagFilter && i+1 < len(tags) {
+				next = tags[i+1]
+			}
+		}
+	}
+	if next == "" {
+		m.setFilter("all")
+		return "Showing all password types"
+	}
+	m.tagFilter = next
+	m.setFilter("tag")
+	return "Filtering by #" + next
+}
+
+// shareBadge returns the marker shown next to shared entries: ⇄ while a
+// share is active, ⚠ once one has expired and the secret needs rotating
+func shareBadge(entry utils.HistoryEntry) string {
+	switch {
+	case entry.NeedsRotation():
+		return "⚠"
+	case entry.IsShared():
+		return "⇄"
+	}
+	return ""
+}
+
+// linkBadge returns the marker shown next to linked entries: ↪ for an
+// entry reusing another's password, ⚠ once that password was rotated
+func linkBadge(entry utils.HistoryEntry) string {
+	switch {
+	case entry.NeedsUpdate():
+		return "⚠"
+	case entry.Linked():
+		return "↪"
+	}
+	return ""
+}
+
+// linkDetails renders the links of entry: the entry it reuses the password
+// of, or the tree of entries reusing its password
+func (m *HistoryModel) linkDetails(entry utils.HistoryEntry) string {
+	warning := lipgloss.NewStyle().Foreground(theme.Warning).Bold(true)
+
+	if entry.Linked() {
+		primary := "a deleted entry"
+		if p, err := utils.FindEntry(m.allEntries, entry.LinkedTo); err == nil {
+			primary = fmt.Sprintf("%q", p.Label())
+		}
+		details := lipgloss.NewStyle().Foreground(theme.Accent).Render("↪ Uses the same password as " + primary)
+		if entry.NeedsUpdate() {
+			details += "\n" + warning.Render(fmt.Sprintf("⚠ %s was rotated %s — change this password too, then press %s",
+				primary, entry.PrimaryRotatedAt.Format("Jan 2 2006"), keys.Label(ActionRevoke)))
+		}
+		return details
+	}
+
+	linked := utils.LinkedEntries(m.allEntries, entry.ID)
+	if len(linked) == 0 {
+		return ""
+	}
+
+	details := lipgloss.NewStyle().Foreground(theme.Accent).Render(fmt.Sprintf("Password reused by %d entries", len(linked)))
+	for i, l := range linked {
+		branch := "├─ "
+		if i == len(linked)-1 {
+			branch = "└─ "
+		}
+		line := branch + l.Label()
+		if l.NeedsUpdate() {
+			line += " " + warning.Render("⚠ needs update")
+		}
+		details += "\n  " + line
+	}
+	if len(linked) > utils.MaxLinksPerEntry {
+		details += "\n" + warning.Render("⚠ Heavily linked: a leak exposes all of these entries")
+	}
+	return details
+}
+
+// rotationDetails renders the chain of entries whose passwords replaced
+// one another through entry, oldest first, or "" if it was never rotated
+func (m *HistoryModel) rotationDetails(entry utils.HistoryEntry) string {
+	chain := utils.RotationChain(m.allEntries, entry.ID)
+	if len(chain) < 2 {
+		return ""
+	}
+
+	details := lipgloss.NewStyle().Foreground(theme.Accent).Render(fmt.Sprintf("Rotation chain (%d passwords, oldest first)", len(chain)))
+	for i, c := range chain {
+		branch := "├─ "
+		if i == len(chain)-1 {
+			branch = "└─ "
+		}
+		line := fmt.Sprintf("%s%s, %s", branch, c.Label(), c.CreatedAt.Format("Jan 2 2006"))
+		if c.SupersededAt != nil {
+			line += fmt.Sprintf(", replaced %s", c.SupersededAt.Format("Jan 2 2006"))
+		}
+		if c.ID == entry.ID {
+			line = lipgloss.NewStyle().Bold(true).Render(line + "  ← this entry")
+		}
+		details += "\n  " + line
+	}
+	if entry.Superseded() {
+		details += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Bold(true).Render("⚠ Superseded: this password is no longer current")
+	}
+	return details
+}
+
+// detailView renders all fields and the usage audit trail of the selected entry
+func (m *HistoryModel) detailView() string {
+	selectedIndex := m.table.Cursor()
+	if selectedIndex < 0 || selectedIndex >= len(m.displayedEntries) {
+		m.showDetail = false
+		return m.View()
+	}
+	entry := m.displayedEntries[selectedIndex]
+
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("Password Details")
+
+	lastUsed := func(count int, at *time.Time) string {
+		if count == 0 || at == nil {
+			return "never"
+		}
+		return fmt.Sprintf("%d times, last %s", count, at.Format("Jan 2 2006 15:04"))
+	}
+
+	details := fmt.Sprintf(`Password:    %s
+Type:        %s
+Length:      %d
+Strength:    %s
+Created:     %s
+Settings:    %s
+Description: %s
+Site:        %s
+Username:    %s
+Tags:        %s
+
+Copied:      %s
+Revealed:    %s`,
+		colorizeSecret(entry.Password),
+		strings.Title(entry.Type),
+		entry.Length,
+		strengthDetail(m.strength(entry)),
+		entry.CreatedAt.Format("Jan 2 2006 15:04"),
+		entry.Settings,
+		entry.Description,
+		entry.Site,
+		entry.Username,
+		formatTags(entry.Tags),
+		lastUsed(entry.CopyCount, entry.LastCopiedAt),
+		lastUsed(entry.RevealCount, entry.LastRevealedAt))
+
+	if m.manager != nil && m.manager.Config != nil && m.manager.Config.AnnotateConfusables {
+		if callout := confusableCallout(entry.Password); callout != "" {
+			details += "\n\n" + subtleStyle.Render(callout)
+		}
+	}
+
+	if entry.NeverUsed() {
+		details += "\n\n" + subtleStyle.Render("Never copied — a candidate for cleanup")
+	}
+
+	if len(entry.Shares) > 0 {
+		badgeColor := theme.Accent
+		if entry.NeedsRotation() {
+			badgeColor = theme.Warning
+		}
+		badge := lipgloss.NewStyle().Foreground(badgeColor).Bold(true).Render(shareBadge(entry) + " SHARED")
+		if !entry.IsShared() {
+			badge = subtleStyle.Render("Shares revoked")
+		}
+		details += "\n\n" + badge
+		for _, share := range entry.Shares {
+			details += "\n  " + share.String()
+		}
+	}
+
+	if links := m.linkDetails(entry); links != "" {
+		details += "\n\n" + links
+	}
+
+	if rotations := m.rotationDetails(entry); rotations != "" {
+		details += "\n\n" + rotations
+	}
+
+	if key := entry.SSHKey; key != nil {
+		fingerprint := key.Fingerprint
+		if fingerprint == "" {
+			fingerprint = "not created by passman"
+		}
+		details += "\n\n" + lipgloss.NewStyle().Foreground(theme.Accent).Render("Protects SSH key "+key.Path) +
+			fmt.Sprintf("\n  %s, %s\n  %s", key.Type, key.Comment, fingerprint)
+	}
+
+	content := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Border).
+		Padding(0, 1).
+		Foreground(theme.Text).
+		Render(details)
+
+	help := subtleStyle.Render(keys.Label(ActionDetails)+"/"+keys.Label(ActionBack)+": back to list") + dotStyle +
+		keyHelp(ActionShare, "record share") + dotStyle +
+		keyHelp(ActionRevoke, "rotated") + dotStyle +
+		keyHelp(ActionLink, "link") + dotStyle +
+		keyHelp(ActionTags, "tags") + dotStyle +
+		keyHelp(ActionEditDetails, "edit") + dotStyle +
+		subtleStyle.Render(keys.Label(ActionCopyUsername)+"/"+keys.Label(ActionCopySite)+"/"+
+			keys.Label(ActionCopyDescription)+": copy username/site/description") + dotStyle +
+		subtleStyle.Render("ctrl+c: menu")
+
+	sections := []string{title, content}
+	if m.sharing {
+		sections = append(sections, "Shared with: "+m.shareInput.View())
+		help = subtleStyle.Render("enter: record") + dotStyle + subtleStyle.Render("esc: cancel")
+	} else if m.linking {
+		sections = append(sections, "Same password as: "+m.linkInput.View())
+		help = subtleStyle.Render("enter: link") + dotStyle + subtleStyle.Render("esc: cancel")
+	} else if m.tagging {
+		sections = append(sections, "Tags: "+m.tagInput.View())
+		help = subtleStyle.Render("enter: save") + dotStyle + subtleStyle.Render("esc: cancel")
+	} else if m.editing {
+		label := strings.Title(string(detailFields[m.editStep]))
+		sections = append(sections, fmt.Sprintf("%s (%d/%d): %s", label, m.editStep+1, len(detailFields), m.editInput.View()))
+		next := "enter: next"
+		if m.editStep == len(detailFields)-1 {
+			next = "enter: save"
+		}
+		help = subtleStyle.Render(next) + dotStyle + subtleStyle.Render("esc: cancel")
+	} else if status := m.status.View(); status != "" {
+		sections = append(sections, status)
+	}
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n")
+}
+
+// recordUsage records a copy or reveal in the history audit trail, and a
+// copy in the usage metrics. Failures are ignored so that auditing never
+// blocks the action itself.
+func recordUsage(manager *utils.Manager, id string, kind utils.UsageKind) {
+	if manager != nil && kind == utils.UsageCopied {
+		manager.RecordMetric(utils.MetricCopied, "")
+	}
+	if id == "" || manager == nil || manager.History == nil || !manager.History.IsEnabled() {
+		return
+	}
+	_ = manager.History.RecordUsage(id, kind)
 }
 
 func (m *HistoryModel) updateTableSize() {
+	// Keep the selected entry in view when the page size changes
+	selected := m.page*m.pageSize() + m.table.Cursor()
+
 	// Adjust table size based on terminal dimensions
 	tableWidth := m.width - 4  // Account for padding
 	tableHeight := m.height - 8 // Account for title, help, and padding
@@ -164,47 +1271,72 @@ func (m *HistoryModel) updateTableSize() {
 		tableHeight = 15
 	}
 
-	// Calculate responsive column widths
-	var timeWidth, lengthWidth, typeWidth, passwordWidth int
+	// Calculate responsive column widths: fixed columns by terminal size,
+	// the password and description share what is left
+	var widths map[string]int
+	minPasswordWidth := 0
 
 	if m.width < 60 {
 		// Very small terminals
-		timeWidth = 8
-		lengthWidth = 4
-		typeWidth = 8
-		passwordWidth = tableWidth - timeWidth - lengthWidth - typeWidth - 8
-		if passwordWidth < 12 {
-			passwordWidth = 12
-		}
+		widths = map[string]int{"time": 8, "length": 4, "type": 8, "strength": 8}
+		minPasswordWidth = 12
 	} else if m.width < 100 {
 		// Medium terminals
-		timeWidth = 11
-		lengthWidth = 6
-		typeWidth = 10
-		passwordWidth = tableWidth - timeWidth - lengthWidth - typeWidth - 8
-		if passwordWidth < 20 {
-			passwordWidth = 20
-		}
+		widths = map[string]int{"time": 11, "length": 6, "type": 10, "strength": 11}
+		minPasswordWidth = 20
 	} else {
 		// Large terminals
-		timeWidth = 12
-		lengthWidth = 8
-		typeWidth = 12
-		passwordWidth = tableWidth - timeWidth - lengthWidth - typeWidth - 8
-		if passwordWidth < 30 {
-			passwordWidth = 30
+		widths = map[string]int{"time": 12, "length": 8, "type": 12, "strength": 12}
+		minPasswordWidth = 30
+	}
+
+	names := m.columns()
+	remaining, flexible := tableWidth, 0
+	for _, name := range names {
+		remaining -= 2 // Cell padding
+		if width, ok := widths[name]; ok {
+			remaining -= width
+		} else {
+			flexible++
+		}
+	}
+	for _, name := range names {
+		if _, ok := widths[name]; ok {
+			continue
+		}
+		width := remaining / flexible
+		if name == "password" && width < minPasswordWidth {
+			width = minPasswordWidth
+		} else if width < 10 {
+			width = 10
 		}
+		widths[name] = width
 	}
 
-	columns := []table.Column{
-		{Title: "Time", Width: timeWidth},
-		{Title: "Password", Width: passwordWidth},
-		{Title: "Length", Width: lengthWidth},
-		{Title: "Type", Width: typeWidth},
+	sortBy, reverse := m.sortOrder()
+	columns := make([]table.Column, len(names))
+	for i, name := range names {
+		title := historyColumnTitles[name]
+		if name == sortBy {
+			if sortDescending(sortBy, reverse) {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		columns[i] = table.Column{Title: title, Width: widths[name]}
 	}
 
+	// Rows of the old columns would not fit the new ones
+	m.table.SetRows(nil)
 	m.table.SetColumns(columns)
+	m.columnNames = names
 	m.table.SetHeight(tableHeight)
+
+	m.page = selected / m.pageSize()
+	m.dirty = true
+	m.loadHistoryData()
+	m.table.SetCursor(selected % m.pageSize())
 }
 
 func (m *HistoryModel) loadHistoryData() {
@@ -213,77 +1345,111 @@ func (m *HistoryModel) loadHistoryData() {
 	}
 
 	// Load all entries if not cached or refresh cache
-	if len(m.allEntries) == 0 {
+	if m.allEntries == nil {
 		entries, err := m.manager.History.LoadHistory() // Get ALL entries, not just recent
 		if err != nil {
 			return
 		}
 		m.allEntries = entries
+		m.summary = summarizeHistory(entries)
+		m.dirty = true
+	}
+
+	// Rows are only rebuilt when the entries, filter, page or size change,
+	// so large histories don't slow down every keypress
+	if !m.dirty {
+		return
 	}
+	m.dirty = false
 
 	// Filter entries based on current filter
 	var filteredEntries []utils.HistoryEntry
 	for _, entry := range m.allEntries {
-		if m.filterType == "all" || strings.ToLower(entry.Type) == m.filterType {
+		if m.filterType == "all" || strings.ToLower(entry.Type) == m.filterType ||
+			(m.filterType == "unused" && entry.NeverUsed()) ||
+			(m.filterType == "expired" && entry.NeedsRotation()) ||
+			(m.filterType == "tag" && entry.HasTag(m.tagFilter)) {
 			filteredEntries = append(filteredEntries, entry)
 		}
 	}
+	m.sortEntries(filteredEntries)
+	m.filtered = filteredEntries
+
+	// Only the current page goes into the table
+	if m.page >= m.pageCount() {
+		m.page = m.pageCount() - 1
+	}
+	start := m.page * m.pageSize()
+	end := start + m.pageSize()
+	if end > len(filteredEntries) {
+		end = len(filteredEntries)
+	}
+	if start > end {
+		start = end
+	}
+	filteredEntries = filteredEntries[start:end]
 
 	// Store displayed entries for copying (full passwords)
 	m.displayedEntries = filteredEntries
 
-	// Calculate password display width based on current column width
-	passwordColumnWidth := 20 // Default fallback
-	if len(m.table.Columns()) > 1 {
-		passwordColumnWidth = m.table.Columns()[1].Width
-	}
-
-	// Convert to table rows
+	// Convert to table rows, in the configured columns
+	columns := m.table.Columns()
 	var rows []table.Row
 	for _, entry := range filteredEntries {
-		timeStr := entry.CreatedAt.Format("Jan 2 15:04")
-		
-		// Handle password display based on available width
-		password := entry.Password
-		if passwordColumnWidth < 15 {
-			// Very small width - show just first few chars
-			if len(password) > 8 {
-				password = password[:5] + "..."
-			}
-		} else if len(password) > passwordColumnWidth-3 {
-			// Normal truncation for medium/large widths
-			truncateAt := passwordColumnWidth - 6
-			if truncateAt < 5 {
-				truncateAt = 5
-			}
-			password = password[:truncateAt] + "..."
+		row := make(table.Row, len(columns))
+		for i, name := range m.columnNames {
+			row[i] = m.cell(entry, name, columns[i].Width)
 		}
-		
-		typeStr := strings.Title(entry.Type)
-		lengthStr := strconv.Itoa(entry.Length)
-
-		rows = append(rows, table.Row{
-			timeStr,
-			password,
-			lengthStr,
-			typeStr,
-		})
+		if m.marked[entry.ID] && len(row) > 0 {
+			row[0] = "● " + row[0]
+		}
+		rows = append(rows, row)
 	}
 
 	m.table.SetRows(rows)
 }
 
+// summarizeHistory works out the stats and warnings shown under the table
+func summarizeHistory(entries []utils.HistoryEntry) historySummary {
+	summary := historySummary{
+		stats:        utils.ComputeUsageStats(entries),
+		expired:      len(utils.RevocationChecklist(entries)),
+		linkWarnings: utils.LinkAudit(entries),
+	}
+	for _, entry := range entries {
+		if entry.NeedsUpdate() {
+			summary.stale++
+		}
+	}
+	for _, group := range utils.FindReuse(entries) {
+		if group.Unacknowledged() > 1 {
+			summary.reused++
+		}
+	}
+	return summary
+}
+
 func (m *HistoryModel) View() string {
 	// Load fresh data each time we render
 	m.loadHistoryData()
 
+	if m.showDetail {
+		return m.detailView()
+	}
+
 	// Title with filter indicator
 	titleText := "Password History"
-	if m.filterType != "all" {
+	if m.filterType == "unused" {
+		titleText += " - Never Used"
+	} else if m.filterType == "expired" {
+		titleText += " - Revocation Checklist"
+	} else if m.filterType == "tag" {
+		titleText += " - #" + m.tagFilter
+	} else if m.filterType != "all" {
 		titleText += " - " + strings.Title(m.filterType) + " Only"
 	}
 	title := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
+		Foreground(theme.Text).
 		Bold(true).
 		Render(titleText)
 
@@ -291,23 +1457,50 @@ func (m *HistoryModel) View() string {
 	var content string
 	if m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
 		content = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("15")).
+			Foreground(theme.Text).
 			Render("History is disabled.\n\nEnable it in settings to track your generated passwords.")
 	} else {
-		entries, _ := m.manager.History.GetRecentEntries(1)
-		if len(entries) == 0 {
+		if len(m.allEntries) == 0 {
 			content = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("15")).
+				Foreground(theme.Text).
 				Render("No passwords in history yet.\n\nGenerate some passwords to see them here!")
 		} else {
-			content = baseStyle.Render(m.table.View())
+			content = baseStyle.Render(colorStrengths(m.table.View()))
+
+			if pages := m.pageCount(); pages > 1 {
+				content += "\n" + subtleStyle.Render(fmt.Sprintf("Page %d of %d", m.page+1, pages)) + dotStyle +
+					keyHelp(ActionPrevPage, "previous page") + dotStyle + keyHelp(ActionNextPage, "next page")
+			}
+
+			stats := m.summary.stats
+			content += "\n" + subtleStyle.Render(fmt.Sprintf("%d entries · %d copied (%d copies) · %d revealed · %d never used",
+				stats.Total, stats.Copied, stats.Copies, stats.Revealed, stats.NeverUsed))
+
+			// Prompt rotation of secrets whose shares have expired
+			if m.summary.expired > 0 && m.filterType != "expired" {
+				content += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render(
+					fmt.Sprintf("⚠ %d shared passwords past their expiry — press %s for the revocation checklist", m.summary.expired, keys.Label(ActionFilterExpired)))
+			}
+
+			// Linked entries whose primary was rotated, and heavy password reuse
+			if m.summary.stale > 0 {
+				content += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render(
+					fmt.Sprintf("⚠ %d linked entries share a password that was rotated — update them", m.summary.stale))
+			}
+			if m.summary.reused > 0 {
+				content += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render(
+					fmt.Sprintf("⚠ %d passwords are reused by unlinked entries — press %s for the reuse audit", m.summary.reused, keys.Label(ActionReuseAudit)))
+			}
+			for _, warning := range m.summary.linkWarnings {
+				content += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render("⚠ "+warning.String())
+			}
 			
 			// Add count information when filtering
 			if m.filterType != "all" {
-				filteredCount := len(m.table.Rows())
+				filteredCount := len(m.filtered)
 				totalCount := len(m.allEntries)
 				countInfo := lipgloss.NewStyle().
-					Foreground(lipgloss.Color("241")).
+					Foreground(theme.Subtle).
 					Render(fmt.Sprintf("Showing %d of %d entries", filteredCount, totalCount))
 				content += "\n" + countInfo
 			}
@@ -316,22 +1509,51 @@ func (m *HistoryModel) View() string {
 
 	// Help text with filter shortcuts
 	help := subtleStyle.Render("↑/↓: navigate") + dotStyle +
-		subtleStyle.Render("enter: copy") + dotStyle +
-		subtleStyle.Render("a/r/m/p: filter") + dotStyle +
-		subtleStyle.Render("esc: back") + dotStyle +
-		subtleStyle.Render("q: quit")
+		keyHelp(ActionSelect, "copy") + dotStyle +
+		keyHelp(ActionPick, "pick") + dotStyle +
+		subtleStyle.Render(keys.Label(ActionCopyUsername)+"/"+keys.Label(ActionCopySite)+"/"+
+			keys.Label(ActionCopyDescription)+": copy username/site/description") + dotStyle +
+		keyHelp(ActionDetails, "details") + dotStyle +
+		subtleStyle.Render(keys.Label(ActionFilterAll)+"/"+keys.Label(ActionFilterRandom)+"/"+
+			keys.Label(ActionFilterMemorable)+"/"+keys.Label(ActionFilterPIN)+": filter") + dotStyle +
+		keyHelp(ActionFilterUnused, "never used") + dotStyle +
+		keyHelp(ActionFilterExpired, "expired shares") + dotStyle +
+		keyHelp(ActionFilterTag, "tag filter") + dotStyle +
+		keyHelp(ActionTags, "tags") + dotStyle +
+		keyHelp(ActionExportUnique, "export unique") + dotStyle +
+		keyHelp(ActionMark, "mark") + dotStyle +
+		keyHelp(ActionExportMarked, "export marked") + dotStyle +
+		keyHelp(ActionReuseAudit, "reuse audit") + dotStyle +
+		keyHelp(ActionClearHistory, "clear all") + dotStyle +
+		keyHelp(ActionSort, "sort") + dotStyle +
+		keyHelp(ActionReverseSort, "reverse") + dotStyle +
+		keyHelp(ActionBack, "back") + dotStyle +
+		keyHelp(ActionQuit, "quit")
 
 	// Status message
-	status := ""
-	if m.statusMsg != "" {
-		status = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("15")).
-			Render(m.statusMsg)
-	}
+	status := m.status.View()
 
 	// Combine everything
 	sections := []string{title, content}
-	if status != "" {
+	if m.tagging {
+		sections = append(sections, "Tags: "+m.tagInput.View())
+		help = subtleStyle.Render("enter: save") + dotStyle + subtleStyle.Render("esc: cancel")
+	} else if m.choosingFormat {
+		sections = append(sections, fmt.Sprintf("Export %d marked entries as:", len(m.markedEntries())))
+		help = subtleStyle.Render("t: txt") + dotStyle + subtleStyle.Render("j: json") + dotStyle +
+			subtleStyle.Render("c: csv") + dotStyle + subtleStyle.Render("k: emergency kit") + dotStyle +
+			subtleStyle.Render("enter: default format") + dotStyle +
+			subtleStyle.Render("esc: cancel")
+	} else if m.clearing {
+		prompt := fmt.Sprintf("This deletes all %d entries", len(m.allEntries))
+		if m.manager != nil && m.manager.Config != nil && m.manager.Config.BackupBeforeClear {
+			prompt += " after backing them up to the export directory"
+		}
+		sections = append(sections,
+			lipgloss.NewStyle().Foreground(theme.Warning).Render(prompt)+"\n"+
+				fmt.Sprintf("Type %q to confirm: ", utils.ClearConfirmation)+m.clearInput.View())
+		help = subtleStyle.Render("enter: clear") + dotStyle + subtleStyle.Render("esc: cancel")
+	} else if status != "" {
 		sections = append(sections, status)
 	}
 	sections = append(sections, help)
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// historyColumnTitles are the headers of the history table's columns
+var historyColumnTitles = map[string]string{
+	"time":        "Time",
+	"password":    "Password",
+	"length":      "Length",
+	"type":        "Type",
+	"strength":    "Strength",
+	"description": "Description",
+	"site":        "Site",
+	"username":    "Username",
+	"tags":        "Tags",
+}
+
+// historySorts are the orders the history table cycles through
+var historySorts = []string{"time", "length", "type", "strength"}
+
+// entryStrength is the estimated strength of a history entry's password
+type entryStrength struct {
+	entropy float64
+	level   generator.SecurityLevel
+}
+
+// columns returns the configured columns of the history table
+func (m *HistoryModel) columns() []string {
+	value := config.Default().HistoryColumns
+	if m.manager != nil && m.manager.Config != nil {
+		value = m.manager.Config.HistoryColumns
+	}
+	columns, err := config.ParseHistoryColumns(value)
+	if err != nil {
+		columns, _ = config.ParseHistoryColumns(config.Default().HistoryColumns)
+	}
+	return columns
+}
+
+// sortOrder returns the configured sort column and whether it is reversed
+func (m *HistoryModel) sortOrder() (string, bool) {
+	if m.manager == nil || m.manager.Config == nil || m.manager.Config.HistorySort == "" {
+		return "time", false
+	}
+	return m.manager.Config.HistorySort, m.manager.Config.HistorySortReverse
+}
+
+// setSortOrder changes the sort order and saves it in the config, so the
+// table comes back sorted the same way next time
+func (m *HistoryModel) setSortOrder(by string, reverse bool) {
+	if m.manager != nil && m.manager.Config != nil {
+		m.manager.Config.HistorySort = by
+		m.manager.Config.HistorySortReverse = reverse
+		_ = m.manager.Config.Save()
+	}
+	m.page = 0
+	m.dirty = true
+	m.table.SetCursor(0)
+	m.updateTableSize()
+}
+
+// formatTags shows tags as #work #personal
+func formatTags(tags []string) string {
+	shown := make([]string, len(tags))
+	for i, tag := range tags {
+		shown[i] = "#" + tag
+	}
+	return strings.Join(shown, " ")
+}
+
+// sortDescription describes a sort order for the status line
+func sortDescription(by string, reverse bool) string {
+	orders := map[string][2]string{
+		"time":     {"newest first", "oldest first"},
+		"length":   {"longest first", "shortest first"},
+		"type":     {"A to Z", "Z to A"},
+		"strength": {"strongest first", "weakest first"},
+	}
+	direction := orders[by][0]
+	if reverse {
+		direction = orders[by][1]
+	}
+	return fmt.Sprintf("Sorted by %s, %s", by, direction)
+}
+
+// sortDescending reports whether the values of the sort column decrease
+// down the table, for the arrow in its header
+func sortDescending(by string, reverse bool) bool {
+	return (by != "type") != reverse
+}
+
+// strength returns the strength stored with entry. Entries saved before
+// strengths were stored are analysed once and remembered, as analysing
+// thousands of entries on every sort would be slow.
+func (m *HistoryModel) strength(entry utils.HistoryEntry) entryStrength {
+	if level, ok := generator.ParseSecurityLevel(entry.Strength); ok {
+		return entryStrength{entropy: entry.Entropy, level: level}
+	}
+	if s, ok := m.strengths[entry.Password]; ok {
+		return s
+	}
+	if m.strengths == nil {
+		m.strengths = make(map[string]entryStrength)
+	}
+	level, entropy := entry.SecurityLevel()
+	s := entryStrength{entropy: entropy, level: level}
+	m.strengths[entry.Password] = s
+	return s
+}
+
+// strengthMarker starts a strength cell. The table can't hold colors, as
+// it counts escape codes as text, so the marker is swapped for a colored
+// dot once the table is rendered (see colorStrengths).
+const strengthMarker = '\ue000'
+
+// colorStrengths replaces the strength markers in a rendered table with a
+// dot and label in the color of the level
+func colorStrengths(view string) string {
+	if !strings.ContainsRune(view, strengthMarker) {
+		return view
+	}
+	for level := generator.VeryWeak; level <= generator.VeryStrong; level++ {
+		marker := string(strengthMarker + rune(level))
+		label := generator.SecurityLevelToString(level)
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.StrengthColor(level)))
+		// A label cut short by a narrow column keeps only the colored dot
+		view = strings.ReplaceAll(view, marker+" "+label, style.Render("● "+label))
+		view = strings.ReplaceAll(view, marker, style.Render("●"))
+	}
+	return view
+}
+
+// strengthDetail renders a strength for the detail view, e.g. Strong (62 bits)
+func strengthDetail(s entryStrength) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.StrengthColor(s.level)))
+	return style.Render(fmt.Sprintf("● %s", generator.SecurityLevelToString(s.level))) +
+		fmt.Sprintf(" (%.0f bits)", s.entropy)
+}
+
+// sortEntries sorts entries, newest first in the history, by the
+// configured column. Ties keep the newest first.
+func (m *HistoryModel) sortEntries(entries []utils.HistoryEntry) {
+	by, reverse := m.sortOrder()
+	if by == "time" && !reverse {
+		return
+	}
+
+	less := func(a, b utils.HistoryEntry) bool {
+		switch by {
+		case "length":
+			return a.Length > b.Length
+		case "type":
+			return strings.ToLower(a.Type) < strings.ToLower(b.Type)
+		case "strength":
+			return m.strength(a).entropy > m.strength(b).entropy
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if reverse {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
+
+// cell renders column of entry for the history table
+func (m *HistoryModel) cell(entry utils.HistoryEntry, column string, width int) string {
+	switch column {
+	case "time":
+		return entry.CreatedAt.Format("Jan 2 15:04")
+	case "password":
+		// Truncate by character so locale letters are never split
+		password := entry.Password
+		chars := []rune(password)
+		if width < 15 {
+			// Very small width - show just first few chars
+			if len(chars) > 8 {
+				password = string(chars[:5]) + "..."
+			}
+		} else if len(chars) > width-3 {
+			// Normal truncation for medium/large widths
+			truncateAt := width - 6
+			if truncateAt < 5 {
+				truncateAt = 5
+			}
+			password = string(chars[:truncateAt]) + "..."
+		}
+		return password
+	case "length":
+		return strconv.Itoa(entry.Length)
+	case "type":
+		typeStr := strings.Title(entry.Type)
+		if badge := shareBadge(entry); badge != "" {
+			typeStr = badge + " " + typeStr
+		}
+		if badge := linkBadge(entry); badge != "" {
+			typeStr = badge + " " + typeStr
+		}
+		return typeStr
+	case "strength":
+		level := m.strength(entry).level
+		return string(strengthMarker+rune(level)) + " " + generator.SecurityLevelToString(level)
+	case "description":
+		return entry.Description
+	case "site":
+		return entry.Site
+	case "username":
+		return entry.Username
+	case "tags":
+		return formatTags(entry.Tags)
+	}
+	return ""
+}
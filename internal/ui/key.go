@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// keySizePresets are common key sizes in bytes, cycled with s
+var keySizePresets = []int{16, 24, 32, 64}
+
+// KeyModel represents the raw encryption key generation screen
+type KeyModel struct {
+	width         int
+	height        int
+	manager       *utils.Manager
+	sizeInput     textinput.Model
+	encodings     []generator.KeyEncoding
+	encodingIndex int
+	key           string
+	historyID     string
+	masked        bool
+	statusMsg     string
+}
+
+// NewKeyModel creates a new key model
+func NewKeyModel(manager *utils.Manager) *KeyModel {
+	sizeInput := textinput.New()
+	sizeInput.Placeholder = strconv.Itoa(generator.DefaultKeyBytes)
+	sizeInput.SetValue(strconv.Itoa(generator.DefaultKeyBytes))
+	sizeInput.CharLimit = 4
+	sizeInput.Width = 10
+
+	return &KeyModel{
+		manager:   manager,
+		sizeInput: sizeInput,
+		encodings: generator.KeyEncodings(),
+		masked:    masksPasswords(manager),
+	}
+}
+
+// NewKeyModelWithSize creates a new key model with specified dimensions
+func NewKeyModelWithSize(manager *utils.Manager, width, height int) *KeyModel {
+	model := NewKeyModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+func (m *KeyModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *KeyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.sizeInput.Focused() {
+			switch msg.String() {
+			case "ctrl+c", "esc", "tab", "enter":
+				m.sizeInput.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.sizeInput, cmd = m.sizeInput.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit), keys.Matches(msg, ActionBack):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionScratchpad):
+			return m, navigate(ScratchpadScreen)
+		case keys.Matches(msg, ActionFocus):
+			return m, m.sizeInput.Focus()
+		case keys.Matches(msg, ActionKeySize):
+			m.nextSizePreset()
+		case keys.Matches(msg, ActionKeyEncoding):
+			m.encodingIndex = (m.encodingIndex + 1) % len(m.encodings)
+			m.key = ""
+			m.statusMsg = "Encoding: " + m.encoding().String()
+		case keys.Matches(msg, ActionGenerate):
+			m.generateKey()
+		case keys.Matches(msg, ActionCopy):
+			if usesAutoType(m.manager) {
+				return m, m.typeKey()
+			}
+			m.copyKey()
+		case keys.Matches(msg, ActionAutoType):
+			return m, m.typeKey()
+		case keys.Matches(msg, ActionReveal):
+			m.masked = !m.masked
+			if !m.masked && m.key != "" {
+				recordUsage(m.manager, m.historyID, utils.UsageRevealed)
+			}
+		}
+
+	case autoTypeDoneMsg:
+		m.statusMsg = autoTypeStatus(m.manager, msg)
+	}
+
+	return m, nil
+}
+
+func (m *KeyModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("🗝  Generate Encryption Key")
+
+	textStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	size, _ := strconv.Atoi(strings.TrimSpace(m.sizeInput.Value()))
+
+	var encodingItems []string
+	for i, encoding := range m.encodings {
+		encodingItems = append(encodingItems, checkbox(encoding.String(), i == m.encodingIndex))
+	}
+
+	options := fmt.Sprintf("Size (bytes): %s (%d bits, s for presets)\n\nEncoding (e to change):\n%s",
+		m.sizeInput.View(), size*8, strings.Join(encodingItems, "\n"))
+
+	if banner := insecureSeedBanner(); banner != "" {
+		title += "\n" + banner
+	}
+
+	sections := []string{title, textStyle.Render(options)}
+	if size > 0 {
+		if warning := entropyWarningView(generator.NewKeyGenerator(size, m.encoding())); warning != "" {
+			sections = append(sections, warning)
+		}
+	}
+
+	if m.key == "" {
+		sections = append(sections, subtleStyle.Render("Press "+keys.Label(ActionGenerate)+" to generate a key"))
+	} else {
+		width := m.width - 10
+		if width < 20 {
+			width = 64
+		}
+		output := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(theme.Text).
+			Padding(0, 1).
+			Foreground(theme.Text).
+			Bold(true).
+			Render(renderSecret(wrapPasswordChars(maskSecret(m.key, m.masked), width), m.masked))
+		sections = append(sections, output)
+	}
+
+	if m.statusMsg != "" {
+		sections = append(sections, textStyle.Render(m.statusMsg))
+	}
+
+	help := keyHelp(ActionGenerate, "generate") + dotStyle +
+		keyHelp(ActionFocus, "edit size") + dotStyle +
+		keyHelp(ActionKeySize, "size preset") + dotStyle +
+		keyHelp(ActionKeyEncoding, "encoding") + dotStyle +
+		keyHelp(ActionCopy, "copy") + dotStyle +
+		keyHelp(ActionAutoType, "type") + dotStyle +
+		keyHelp(ActionReveal, "reveal") + dotStyle +
+		keyHelp(ActionBack, "back")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// generateKey creates a new key and records it in history
+func (m *KeyModel) generateKey() {
+	size, err := strconv.Atoi(strings.TrimSpace(m.sizeInput.Value()))
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Error: invalid key size %q", m.sizeInput.Value())
+		return
+	}
+
+	gen := generator.NewKeyGenerator(size, m.encoding())
+	key, err := gen.Generate(context.Background())
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return
+	}
+
+	m.key = key
+	m.statusMsg = "Key generated!"
+	countGeneration(m.manager, "key")
+	m.historyID = ""
+
+	if m.manager != nil && m.manager.History != nil && m.manager.History.IsEnabled() {
+		entry := utils.HistoryEntry{
+			ID:       m.manager.History.NewID(),
+			Password: key,
+			Length:   len(key),
+			Type:     "key",
+			Settings: fmt.Sprintf("Size: %d bytes, Encoding: %s", size, m.encoding()),
+		}
+		if err := m.manager.History.AddEntry(entry); err != nil {
+			m.statusMsg = "Key generated! (History save failed)"
+		} else {
+			m.historyID = entry.ID
+		}
+	}
+}
+
+// copyKey copies the current key to the clipboard
+func (m *KeyModel) copyKey() {
+	if m.key == "" {
+		m.statusMsg = "No key to copy. Generate one first!"
+		return
+	}
+
+	if m.manager == nil || m.manager.Clipboard == nil {
+		m.statusMsg = "Clipboard not available"
+		return
+	}
+
+	if err := m.manager.Clipboard.Copy(m.key); err != nil {
+		m.statusMsg = "Failed to copy to clipboard: " + err.Error()
+		return
+	}
+	m.statusMsg = "Key copied to clipboard!"
+	recordUsage(m.manager, m.historyID, utils.UsageCopied)
+}
+
+// typeKey types the current key into the focused window after a delay
+func (m *KeyModel) typeKey() tea.Cmd {
+	if m.key == "" {
+		m.statusMsg = "No key to type. Generate one first!"
+		return nil
+	}
+	cmd, status := startAutoType(m.manager, m.key, m.historyID)
+	m.statusMsg = status
+	return cmd
+}
+
+// nextSizePreset advances the size input to the next preset
+func (m *KeyModel) nextSizePreset() {
+	current, _ := strconv.Atoi(strings.TrimSpace(m.sizeInput.Value()))
+	next := keySizePresets[0]
+	for _, size := range keySizePresets {
+		if size > current {
+			next = size
+			break
+		}
+	}
+	m.sizeInput.SetValue(strconv.Itoa(next))
+	m.key = ""
+}
+
+// encoding returns the selected key encoding
+func (m *KeyModel) encoding() generator.KeyEncoding {
+	return m.encodings[m.encodingIndex]
+}
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// KeybindingsModel lists the active keybindings of every screen, generated
+// from the keymap so that it always matches the configuration
+type KeybindingsModel struct {
+	width    int
+	height   int
+	manager  *utils.Manager
+	viewport viewport.Model
+}
+
+// NewKeybindingsModel creates a new keybindings help model
+func NewKeybindingsModel(manager *utils.Manager) *KeybindingsModel {
+	vp := viewport.New(60, 12)
+	vp.SetContent(keymapHelp())
+
+	return &KeybindingsModel{
+		manager:  manager,
+		viewport: vp,
+	}
+}
+
+// NewKeybindingsModelWithSize creates a new keybindings help model with specified dimensions
+func NewKeybindingsModelWithSize(manager *utils.Manager, width, height int) *KeybindingsModel {
+	model := NewKeybindingsModel(manager)
+	model.resize(width, height)
+	return model
+}
+
+func (m *KeybindingsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *KeybindingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit), keys.Matches(msg, ActionBack),
+			keys.Matches(msg, ActionSelect), keys.Matches(msg, ActionHelp):
+			return m, navigate(MenuScreen)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *KeybindingsModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("⌨  Keybindings")
+
+	var sections []string
+	sections = append(sections, title)
+	if keymapErr != nil {
+		sections = append(sections, lipgloss.NewStyle().
+			Foreground(theme.Warning).
+			Render("⚠ keys in config ignored, using defaults: "+keymapErr.Error()))
+	}
+
+	sections = append(sections, lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Render(m.viewport.View()))
+
+	help := subtleStyle.Render("↑/↓: scroll") + dotStyle +
+		keyHelp(ActionBack, "back")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// resize fits the viewport to the terminal dimensions
+func (m *KeybindingsModel) resize(width, height int) {
+	m.width = width
+	m.height = height
+
+	vpWidth := width - 4
+	if vpWidth < 20 {
+		vpWidth = 20
+	}
+	vpHeight := height - 8
+	if vpHeight < 5 {
+		vpHeight = 5
+	}
+
+	m.viewport.Width = vpWidth
+	m.viewport.Height = vpHeight
+	m.viewport.SetContent(keymapHelp())
+}
+
+// keymapHelp lists the active keys of each screen with the action names
+// used in the config's keys section
+func keymapHelp() string {
+	var b strings.Builder
+	for i, screen := range keymapScreens {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(screen + "\n")
+		for _, binding := range defaultBindings {
+			if containsString(binding.screens, screen) {
+				// Keys are listed as they are written in the config
+				bound := strings.Join(keys.Keys(binding.action), " ")
+				if bound == " " {
+					bound = "space"
+				}
+				fmt.Fprintf(&b, "  %-16s %-26s %s\n", bound, binding.help, binding.action)
+			}
+		}
+	}
+	b.WriteString("\nctrl+c always leaves the current screen. Remap keys in the \"keys\"\n")
+	b.WriteString("section of the config, e.g. \"keys\": {\"copy\": [\"y\"]}.")
+	return b.String()
+}
@@ -0,0 +1,365 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action names a command that can be bound to keys in the config's keys section
+type Action string
+
+// Actions shared by several screens
+const (
+	ActionQuit         Action = "quit"
+	ActionBack         Action = "back"
+	ActionUp           Action = "up"
+	ActionDown         Action = "down"
+	ActionSelect       Action = "select"
+	ActionHelp         Action = "help"
+	ActionScratchpad   Action = "scratchpad"
+	ActionProfiles     Action = "profiles"
+	ActionPick         Action = "pick"
+	ActionToggleFooter Action = "toggle_footer"
+	ActionLock         Action = "lock"
+	ActionGenerate     Action = "generate"
+	ActionCopy         Action = "copy"
+	ActionAutoType     Action = "autotype"
+	ActionReveal       Action = "reveal"
+	ActionFocus        Action = "focus"
+)
+
+// Actions of a single screen
+const (
+	ActionToggleSetting   Action = "toggle_setting"
+	ActionDecrease        Action = "decrease"
+	ActionIncrease        Action = "increase"
+	ActionLargePrint      Action = "large_print"
+	ActionCandidates      Action = "candidates"
+	ActionSavePreset      Action = "save_preset"
+	ActionPrevCandidate   Action = "prev_candidate"
+	ActionNextCandidate   Action = "next_candidate"
+	ActionUndo            Action = "undo"
+	ActionRedo            Action = "redo"
+	ActionToggleLower     Action = "toggle_lower"
+	ActionToggleUpper     Action = "toggle_upper"
+	ActionToggleNumbers   Action = "toggle_numbers"
+	ActionToggleSymbols   Action = "toggle_symbols"
+	ActionNextWordlist    Action = "next_wordlist"
+	ActionTypoRobust      Action = "typo_robust"
+	ActionLeet            Action = "leet"
+	ActionSeparator       Action = "separator"
+	ActionCapitalize      Action = "capitalize"
+	ActionDigit           Action = "digit"
+	ActionPINGroups       Action = "pin_groups"
+	ActionLocaleLetters   Action = "locale_letters"
+	ActionTokenFormat     Action = "token_format"
+	ActionKeySize         Action = "key_size"
+	ActionKeyEncoding     Action = "key_encoding"
+	ActionCopyCode        Action = "copy_code"
+	ActionShowQR          Action = "show_qr"
+	ActionInvertQR        Action = "invert_qr"
+	ActionFilterAll       Action = "filter_all"
+	ActionFilterRandom    Action = "filter_random"
+	ActionFilterMemorable Action = "filter_memorable"
+	ActionFilterPIN       Action = "filter_pin"
+	ActionFilterUnused    Action = "filter_unused"
+	ActionFilterExpired   Action = "filter_expired"
+	ActionDetails         Action = "details"
+	ActionExportUnique    Action = "export_unique"
+	ActionReuseAudit      Action = "reuse_audit"
+	ActionPrevPage        Action = "prev_page"
+	ActionNextPage        Action = "next_page"
+	ActionSort            Action = "sort"
+	ActionReverseSort     Action = "reverse_sort"
+	ActionTags            Action = "tags"
+	ActionFilterTag       Action = "filter_tag"
+	ActionCopyUsername    Action = "copy_username"
+	ActionCopySite        Action = "copy_site"
+	ActionCopyDescription Action = "copy_description"
+	ActionClearHistory    Action = "clear_history"
+	ActionMark            Action = "mark"
+	ActionExportMarked    Action = "export_marked"
+	ActionEditDetails     Action = "edit_details"
+	ActionShare           Action = "share"
+	ActionRevoke          Action = "revoke"
+	ActionLink            Action = "link"
+	ActionNewProfile      Action = "new_profile"
+	ActionCompareConfig   Action = "compare_config"
+	ActionCompareReveal   Action = "compare_reveal"
+)
+
+// Screens group the actions that are active together. Two actions of the
+// same screen may not share a key.
+const (
+	screenMenu       = "Menu"
+	screenGenerator  = "Generator"
+	screenCandidates = "Candidates"
+	screenToken      = "Token"
+	screenKey        = "Key"
+	screenTOTP       = "TOTP"
+	screenHistory    = "History"
+	screenDetail     = "History details"
+	screenAudit      = "Reuse audit"
+	screenSettings   = "Settings"
+	screenProfiles   = "Profiles"
+	screenWizard     = "Setup"
+	screenCompare    = "Compare"
+)
+
+// keymapScreens lists the screens in the order the keybindings help shows them
+var keymapScreens = []string{
+	screenMenu, screenGenerator, screenCandidates, screenToken, screenKey,
+	screenTOTP, screenHistory, screenDetail, screenAudit, screenSettings,
+	screenProfiles, screenWizard, screenCompare,
+}
+
+// binding is the default keys and description of an action
+type binding struct {
+	action  Action
+	keys    []string
+	help    string
+	screens []string
+}
+
+// generatorScreens are the screens that generate a secret
+var generatorScreens = []string{screenGenerator, screenToken, screenKey, screenTOTP}
+
+// defaultBindings is the registry of remappable actions. ctrl+c is not
+// remappable and always leaves the screen, so there is a way out of any
+// keymap. Text inputs and the scratchpad editor keep their fixed keys.
+var defaultBindings = []binding{
+	{ActionQuit, []string{"q"}, "quit or leave the screen", append([]string{screenMenu, screenHistory, screenDetail, screenAudit, screenSettings, screenProfiles}, generatorScreens...)},
+	{ActionBack, []string{"esc"}, "go back", append([]string{screenCandidates, screenHistory, screenDetail, screenAudit, screenSettings, screenProfiles, screenWizard, screenCompare}, generatorScreens...)},
+	{ActionUp, []string{"up", "k"}, "move up", []string{screenMenu, screenAudit, screenSettings, screenProfiles, screenWizard}},
+	{ActionDown, []string{"down", "j"}, "move down", []string{screenMenu, screenAudit, screenSettings, screenProfiles, screenWizard}},
+	{ActionSelect, []string{"enter"}, "select, copy or confirm", []string{screenMenu, screenCandidates, screenHistory, screenSettings, screenProfiles, screenWizard}},
+	{ActionHelp, []string{"?"}, "show keybindings", []string{screenMenu}},
+	{ActionScratchpad, []string{"ctrl+o"}, "open the scratchpad", []string{screenMenu, screenGenerator, screenToken, screenKey}},
+	{ActionProfiles, []string{"p"}, "switch profile", []string{screenMenu}},
+	{ActionPick, []string{"/"}, "fuzzy-find a password", []string{screenMenu, screenHistory}},
+	{ActionToggleFooter, []string{"f2"}, "show or hide the footer", keymapScreens},
+	{ActionLock, []string{"ctrl+l"}, "lock now", keymapScreens},
+	{ActionGenerate, []string{"g", "enter"}, "generate", generatorScreens},
+	{ActionCopy, []string{"c"}, "copy", generatorScreens},
+	{ActionAutoType, []string{"a"}, "auto-type", []string{screenGenerator, screenToken, screenKey}},
+	{ActionReveal, []string{"v"}, "reveal or mask", []string{screenGenerator, screenToken, screenKey, screenAudit}},
+	{ActionFocus, []string{"tab"}, "edit options or switch side", append([]string{screenCompare}, generatorScreens...)},
+	{ActionToggleSetting, []string{" "}, "change setting", []string{screenSettings}},
+	{ActionDecrease, []string{"left", "h"}, "decrease or previous choice", []string{screenSettings}},
+	{ActionIncrease, []string{"right", "l"}, "increase or next choice", []string{screenSettings}},
+	{ActionLargePrint, []string{"b"}, "large print", []string{screenGenerator}},
+	{ActionCandidates, []string{"m"}, "candidates", []string{screenGenerator}},
+	{ActionSavePreset, []string{"P"}, "save settings as a preset", []string{screenGenerator}},
+	{ActionPrevCandidate, []string{"up", "k", "left", "h"}, "previous candidate", []string{screenCandidates}},
+	{ActionNextCandidate, []string{"down", "j", "right", "l"}, "next candidate", []string{screenCandidates}},
+	{ActionUndo, []string{"ctrl+z"}, "undo", []string{screenGenerator}},
+	{ActionRedo, []string{"ctrl+y"}, "redo", []string{screenGenerator}},
+	{ActionToggleLower, []string{"l"}, "toggle lowercase", []string{screenGenerator}},
+	{ActionToggleUpper, []string{"u"}, "toggle uppercase", []string{screenGenerator}},
+	{ActionToggleNumbers, []string{"n"}, "toggle numbers", []string{screenGenerator}},
+	{ActionToggleSymbols, []string{"s"}, "toggle symbols", []string{screenGenerator}},
+	{ActionNextWordlist, []string{"w"}, "next wordlist", []string{screenGenerator}},
+	{ActionTypoRobust, []string{"t"}, "typo-robust words", []string{screenGenerator}},
+	{ActionLeet, []string{"x"}, "leet substitutions", []string{screenGenerator}},
+	{ActionSeparator, []string{"-"}, "passphrase or PIN separator", []string{screenGenerator}},
+	{ActionCapitalize, []string{"C"}, "capitalize passphrase words", []string{screenGenerator}},
+	{ActionDigit, []string{"d"}, "add a digit to passphrases", []string{screenGenerator}},
+	{ActionPINGroups, []string{"G"}, "PIN group size", []string{screenGenerator}},
+	{ActionLocaleLetters, []string{"e"}, "locale letters", []string{screenGenerator}},
+	{ActionTokenFormat, []string{"f"}, "token format", []string{screenToken}},
+	{ActionKeySize, []string{"s"}, "size preset", []string{screenKey}},
+	{ActionKeyEncoding, []string{"e"}, "encoding", []string{screenKey}},
+	{ActionCopyCode, []string{"o"}, "copy code", []string{screenTOTP}},
+	{ActionShowQR, []string{"r"}, "QR code on/off", []string{screenTOTP}},
+	{ActionInvertQR, []string{"i"}, "invert QR code", []string{screenTOTP}},
+	{ActionFilterAll, []string{"a"}, "show all", []string{screenHistory}},
+	{ActionFilterRandom, []string{"r"}, "filter random", []string{screenHistory}},
+	{ActionFilterMemorable, []string{"m"}, "filter memorable", []string{screenHistory}},
+	{ActionFilterPIN, []string{"p"}, "filter PINs", []string{screenHistory}},
+	{ActionFilterUnused, []string{"n"}, "never used", []string{screenHistory}},
+	{ActionFilterExpired, []string{"e"}, "expired shares", []string{screenHistory}},
+	{ActionDetails, []string{"i"}, "details", []string{screenHistory, screenDetail}},
+	{ActionExportUnique, []string{"u"}, "export unique", []string{screenHistory}},
+	{ActionReuseAudit, []string{"d"}, "reuse audit", []string{screenHistory}},
+	{ActionPrevPage, []string{"pgup", "left"}, "previous page", []string{screenHistory}},
+	{ActionNextPage, []string{"pgdown", "right"}, "next page", []string{screenHistory}},
+	{ActionSort, []string{"s"}, "sort by time, length, type or strength", []string{screenHistory}},
+	{ActionReverseSort, []string{"S"}, "reverse the sort order", []string{screenHistory}},
+	{ActionTags, []string{"t"}, "edit tags", []string{screenHistory, screenDetail}},
+	{ActionFilterTag, []string{"#"}, "filter by tag", []string{screenHistory}},
+	{ActionCopyUsername, []string{"U"}, "copy the username", []string{screenHistory, screenDetail}},
+	{ActionCopySite, []string{"W"}, "copy the site", []string{screenHistory, screenDetail}},
+	{ActionCopyDescription, []string{"D"}, "copy the description", []string{screenHistory, screenDetail}},
+	{ActionClearHistory, []string{"X"}, "clear the whole history", []string{screenHistory}},
+	{ActionMark, []string{" "}, "mark or unmark for export", []string{screenHistory}},
+	{ActionExportMarked, []string{"E"}, "export the marked entries", []string{screenHistory}},
+	{ActionEditDetails, []string{"e"}, "edit description, site and username", []string{screenDetail}},
+	{ActionShare, []string{"s"}, "record share", []string{screenDetail}},
+	{ActionRevoke, []string{"x"}, "rotated: revoke shares, flag links", []string{screenDetail}},
+	{ActionLink, []string{"l"}, "link to another entry", []string{screenDetail}},
+	{ActionNewProfile, []string{"n"}, "new profile", []string{screenProfiles}},
+	{ActionCompareConfig, []string{"ctrl+n"}, "compare a generator configuration", []string{screenCompare}},
+	{ActionCompareReveal, []string{"ctrl+r"}, "reveal or mask", []string{screenCompare}},
+}
+
+// Keymap maps actions to the keys that trigger them
+type Keymap struct {
+	keys map[Action][]string
+}
+
+// keys is the active keymap
+var keys = DefaultKeymap()
+
+// keymapErr is why the configured keymap was rejected, shown on the
+// keybindings screen
+var keymapErr error
+
+// DefaultKeymap returns the built-in keybindings
+func DefaultKeymap() Keymap {
+	km := Keymap{keys: make(map[Action][]string, len(defaultBindings))}
+	for _, b := range defaultBindings {
+		km.keys[b.action] = append([]string(nil), b.keys...)
+	}
+	return km
+}
+
+// LoadKeymap applies overrides (action name to keys) on top of the
+// defaults and checks that no two actions of a screen share a key
+func LoadKeymap(overrides map[string][]string) (Keymap, error) {
+	km := DefaultKeymap()
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		action := Action(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := km.keys[action]; !ok {
+			return DefaultKeymap(), fmt.Errorf("unknown action %q in keys", name)
+		}
+		if len(overrides[name]) == 0 {
+			return DefaultKeymap(), fmt.Errorf("action %q has no keys", name)
+		}
+
+		var bound []string
+		for _, key := range overrides[name] {
+			key = normalizeKey(key)
+			switch key {
+			case "":
+				return DefaultKeymap(), fmt.Errorf("action %q has an empty key", name)
+			case "ctrl+c":
+				return DefaultKeymap(), fmt.Errorf("action %q: ctrl+c is reserved", name)
+			}
+			bound = append(bound, key)
+		}
+		km.keys[action] = bound
+	}
+
+	if err := km.validate(); err != nil {
+		return DefaultKeymap(), err
+	}
+	return km, nil
+}
+
+// normalizeKey converts a configured key to the form tea.KeyMsg.String()
+// reports, e.g. "Ctrl+Z" to "ctrl+z" and "space" to " "
+func normalizeKey(key string) string {
+	if key == " " {
+		return key
+	}
+	key = strings.TrimSpace(key)
+	if strings.EqualFold(key, "space") {
+		return " "
+	}
+	if len([]rune(key)) == 1 {
+		// Single characters are case-sensitive: "G" is shift+g
+		return key
+	}
+	return strings.ToLower(key)
+}
+
+// validate reports the first key bound to two actions of the same screen
+func (k Keymap) validate() error {
+	for _, screen := range keymapScreens {
+		owner := make(map[string]Action)
+		for _, b := range defaultBindings {
+			if !containsString(b.screens, screen) {
+				continue
+			}
+			for _, key := range k.keys[b.action] {
+				if other, ok := owner[key]; ok && other != b.action {
+					return fmt.Errorf("key %q is bound to both %s and %s on the %s screen", key, other, b.action, screen)
+				}
+				owner[key] = b.action
+			}
+		}
+	}
+	return nil
+}
+
+// SetKeymap loads the configured keybindings and makes them active. On an
+// error the defaults stay active and the error is shown on the keybindings
+// screen.
+func SetKeymap(overrides map[string][]string) error {
+	km, err := LoadKeymap(overrides)
+	keys = km
+	keymapErr = err
+	return err
+}
+
+// Matches reports whether the key pressed is bound to action
+func (k Keymap) Matches(msg tea.KeyMsg, action Action) bool {
+	return containsString(k.keys[action], msg.String())
+}
+
+// Keys returns the keys bound to action
+func (k Keymap) Keys(action Action) []string {
+	return append([]string(nil), k.keys[action]...)
+}
+
+// Label returns the keys of an action for help lines, e.g. "g/enter"
+func (k Keymap) Label(action Action) string {
+	var names []string
+	for _, key := range k.keys[action] {
+		names = append(names, keyName(key))
+	}
+	return strings.Join(names, "/")
+}
+
+// keyName spells out keys that do not print, such as space, and shows
+// arrow keys as arrows
+func keyName(key string) string {
+	switch key {
+	case " ":
+		return "space"
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	}
+	return key
+}
+
+// keyHelp renders one help line item for an action with the active keys
+func keyHelp(action Action, desc string) string {
+	return subtleStyle.Render(keys.Label(action) + ": " + desc)
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
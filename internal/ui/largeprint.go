@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"image"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// largePrintFace is the bitmap font used for large-print rendering
+var largePrintFace = basicfont.Face7x13
+
+// renderLargePrint renders text as big block characters so it can be read
+// from a distance. Each character is drawn from a 7x13 bitmap font and two
+// pixel rows are packed into one terminal row with half-block glyphs.
+// Characters are wrapped so that no line is wider than maxWidth columns.
+func renderLargePrint(text string, maxWidth int) string {
+	glyphWidth := largePrintFace.Advance
+	perLine := maxWidth / glyphWidth
+	if perLine < 1 {
+		perLine = 1
+	}
+
+	runes := []rune(text)
+	var blocks []string
+	for start := 0; start < len(runes); start += perLine {
+		end := start + perLine
+		if end > len(runes) {
+			end = len(runes)
+		}
+		blocks = append(blocks, renderLargePrintLine(string(runes[start:end])))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// renderLargePrintLine renders a single line of text without wrapping
+func renderLargePrintLine(text string) string {
+	metrics := largePrintFace.Metrics()
+	width := largePrintFace.Advance * len([]rune(text))
+	height := largePrintFace.Height
+
+	img := image.NewAlpha(image.Rect(0, 0, width, height))
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.Opaque,
+		Face: largePrintFace,
+		Dot:  fixed.P(0, metrics.Ascent.Ceil()),
+	}
+	drawer.DrawString(text)
+
+	lit := func(x, y int) bool {
+		return y < height && img.AlphaAt(x, y).A > 0
+	}
+
+	var rows []string
+	for y := 0; y < height; y += 2 {
+		var row strings.Builder
+		for x := 0; x < width; x++ {
+			top, bottom := lit(x, y), lit(x, y+1)
+			switch {
+			case top && bottom:
+				row.WriteRune('█')
+			case top:
+				row.WriteRune('▀')
+			case bottom:
+				row.WriteRune('▄')
+			default:
+				row.WriteRune(' ')
+			}
+		}
+		rows = append(rows, row.String())
+	}
+
+	// Drop blank rows above and below the glyphs
+	for len(rows) > 0 && strings.TrimSpace(rows[0]) == "" {
+		rows = rows[1:]
+	}
+	for len(rows) > 0 && strings.TrimSpace(rows[len(rows)-1]) == "" {
+		rows = rows[:len(rows)-1]
+	}
+
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// lockScreen replaces every screen while the TUI is locked, so no secret,
+// history entry or scratchpad stays on display. It asks for the history
+// passphrase to resume.
+type lockScreen struct {
+	input     textinput.Model
+	statusMsg string
+	pending   *ConfigReloadedMsg // Config changed on disk while locked
+}
+
+// newLockScreen creates the passphrase prompt
+func newLockScreen() *lockScreen {
+	input := textinput.New()
+	input.Placeholder = "history passphrase"
+	input.EchoMode = textinput.EchoPassword
+	input.EchoCharacter = '•'
+	input.Width = 40
+	input.Focus()
+	return &lockScreen{input: input}
+}
+
+// lockable reports whether there is a passphrase to unlock with. The
+// setup wizard is never locked; it is choosing the passphrase.
+func lockable(manager *utils.Manager, screen tea.Model) bool {
+	if app, ok := screen.(*AppModel); ok && app.Screen() == WizardScreen {
+		return false
+	}
+	if _, ok := screen.(*WizardModel); ok {
+		return false
+	}
+	return manager != nil && manager.Config != nil && manager.History != nil && manager.History.CanLock()
+}
+
+// autoLockAt returns when the TUI locks if the user stays idle, and
+// false if auto-lock is off
+func autoLockAt(manager *utils.Manager) (time.Time, bool) {
+	if manager == nil || manager.Config == nil || manager.Session == nil || manager.Config.AutoLockMinutes <= 0 {
+		return time.Time{}, false
+	}
+	return manager.Session.LastActivity().Add(time.Duration(manager.Config.AutoLockMinutes) * time.Minute), true
+}
+
+// lock locks the manager and shows the lock screen. Screens holding
+// decrypted history (the history, the reuse audit and the fuzzy finder)
+// are closed rather than kept behind the lock; the scratchpad is saved
+// first. A failed lock is shown in the status bar.
+func (m *reloadModel) lock() {
+	if app, ok := m.screen.(*AppModel); ok {
+		app.lock()
+	}
+
+	if err := m.manager.Lock(); err != nil {
+		m.status.Error("Failed to lock: " + err.Error())
+		return
+	}
+	m.status.Clear()
+	m.locked = newLockScreen()
+}
+
+// updateLocked handles messages while locked: keys go to the passphrase
+// prompt, a reloaded config waits for the unlock and everything else
+// still reaches the screen underneath
+func (m *reloadModel) updateLocked(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case footerTickMsg:
+		return m, footerTick()
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m.forward(m.screenSize())
+
+	case ConfigReloadedMsg:
+		// Applying it now would replace the locked history
+		m.locked.pending = &msg
+		return m, nil
+
+	case tea.MouseMsg:
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			return m.unlock()
+		}
+		var cmd tea.Cmd
+		m.locked.input, cmd = m.locked.input.Update(msg)
+		m.locked.statusMsg = ""
+		return m, cmd
+	}
+
+	return m.forward(msg)
+}
+
+// unlock resumes with the entered passphrase, then applies a config
+// that changed while locked
+func (m *reloadModel) unlock() (tea.Model, tea.Cmd) {
+	err := m.manager.Unlock(m.locked.input.Value())
+	if errors.Is(err, utils.ErrWrongPassphrase) {
+		m.locked.statusMsg = "Wrong passphrase"
+		m.locked.input.Reset()
+		return m, nil
+	}
+	if err != nil {
+		m.locked.statusMsg = err.Error()
+		return m, nil
+	}
+
+	pending := m.locked.pending
+	m.locked = nil
+	m.manager.Session.RecordActivity(time.Now())
+	if pending != nil {
+		return m.Update(*pending)
+	}
+	return m, nil
+}
+
+// view renders the lock screen
+func (l *lockScreen) view() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("🔒 passman is locked")
+
+	sections := []string{
+		title,
+		"Passphrase: " + l.input.View(),
+	}
+	if l.statusMsg != "" {
+		sections = append(sections, lipgloss.NewStyle().Foreground(theme.Accent).Render(l.statusMsg))
+	}
+	sections = append(sections, subtleStyle.Render("enter: unlock")+dotStyle+subtleStyle.Render("ctrl+c: quit"))
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
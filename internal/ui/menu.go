@@ -6,28 +6,20 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
 	"github.com/mshnjffr/passman/internal/utils"
 )
 
 // Styling constants following the views example
 var (
-	checkboxStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
-	subtleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	checkboxStyle = lipgloss.NewStyle().Foreground(theme.Accent)
+	subtleStyle   = lipgloss.NewStyle().Foreground(theme.Subtle)
 	dotChar       = " • "
-	dotStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("236")).Render(dotChar)
+	dotStyle      = lipgloss.NewStyle().Foreground(theme.Dim).Render(dotChar)
 	mainStyle     = lipgloss.NewStyle().MarginLeft(2)
 )
 
-// Screen represents different app screens
-type Screen int
-
-const (
-	MenuScreen Screen = iota
-	GenerateScreen
-	HistoryScreen
-	SettingsScreen
-)
-
 // MenuModel represents the main menu state
 type MenuModel struct {
 	choices  []string
@@ -39,31 +31,92 @@ type MenuModel struct {
 	manager  *utils.Manager
 }
 
+// generatorMenuScreens are the screens of the registered generators; the menu
+// lists every registered generator that has one
+var generatorMenuScreens = map[string]Screen{
+	"random":    RandomScreen,
+	"memorable": MemorableScreen,
+	"pin":       PINScreen,
+	"totp":      TOTPScreen,
+	"token":     TokenScreen,
+	"key":       KeyScreen,
+}
+
 // NewMenuModel creates a new menu model
 func NewMenuModel(manager *utils.Manager) *MenuModel {
-	choices := []string{
-		"Generate Random Password",
-		"Generate Memorable Passphrase",
-		"Generate PIN Code",
+	choices, actions := menuChoices(manager)
+
+	// Start on the generator chosen in the config
+	cursor := 0
+	if manager != nil && manager.Config != nil {
+		for i, action := range actions {
+			if action == manager.Config.DefaultGenerator {
+				cursor = i
+			}
+		}
+	}
+
+	return &MenuModel{
+		choices: choices,
+		actions: actions,
+		cursor:  cursor,
+		manager: manager,
+	}
+}
+
+// menuChoices returns the menu entries and their actions: the registered
+// generators, the saved presets, then everything else
+func menuChoices(manager *utils.Manager) (choices, actions []string) {
+	for _, reg := range generator.Registered() {
+		if _, ok := generatorMenuScreens[reg.Name]; ok {
+			choices = append(choices, "Generate "+reg.Title)
+			actions = append(actions, reg.Name)
+		}
+	}
+	if manager != nil {
+		presetChoices, presetActions := presetMenuChoices(manager.Config)
+		choices = append(choices, presetChoices...)
+		actions = append(actions, presetActions...)
+	}
+
+	choices = append(choices,
 		"View Password History",
+		"Pick Password",
+		"Compare Strength",
+		"Scratchpad",
+		"Switch Profile",
 		"Settings",
+		"What's New",
+		"Tutorial",
+		"Keybindings",
 		"Quit",
-	}
+	)
 
-	actions := []string{
-		"random",
-		"memorable", 
-		"pin",
+	actions = append(actions,
 		"history",
+		"pick",
+		"compare",
+		"scratchpad",
+		"profiles",
 		"settings",
+		"whatsnew",
+		"tutorial",
+		"keybindings",
 		"quit",
-	}
+	)
+	return choices, actions
+}
 
-	return &MenuModel{
-		choices: choices,
-		actions: actions,
-		cursor:  0,
-		manager: manager,
+// refresh lists presets saved since the menu was last shown, keeping the
+// cursor on the same entry
+func (m *MenuModel) refresh() {
+	selected := m.actions[m.cursor]
+	m.choices, m.actions = menuChoices(m.manager)
+	m.cursor = 0
+	for i, action := range m.actions {
+		if action == selected {
+			m.cursor = i
+		}
 	}
 }
 
@@ -87,34 +140,43 @@ func (m *MenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			m.quitting = true
-			return m, tea.Quit
-		case "up", "k":
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit):
+			return m, m.quit()
+		case keys.Matches(msg, ActionScratchpad):
+			return m, navigate(ScratchpadScreen)
+		case keys.Matches(msg, ActionHelp):
+			return m, navigate(KeybindingsScreen)
+		case keys.Matches(msg, ActionProfiles):
+			return m, navigate(ProfilesScreen)
+		case keys.Matches(msg, ActionPick):
+			return m, navigate(PickScreen)
+		case keys.Matches(msg, ActionUp):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down", "j":
+		case keys.Matches(msg, ActionDown):
 			if m.cursor < len(m.choices)-1 {
 				m.cursor++
 			}
-		case "enter":
-			action := m.actions[m.cursor]
-			switch action {
-			case "quit":
-				m.quitting = true
-				return m, tea.Quit
-			case "random":
-				return NewGeneratorModelWithSize("random", m.manager, m.width, m.height), nil
-			case "memorable":
-				return NewGeneratorModelWithSize("memorable", m.manager, m.width, m.height), nil
-			case "pin":
-				return NewGeneratorModelWithSize("pin", m.manager, m.width, m.height), nil
-			case "history":
-				return NewHistoryModelWithSize(m.manager, m.width, m.height), nil
-			case "settings":
-				return NewSettingsModelWithSize(m.manager, m.width, m.height), nil
+		case keys.Matches(msg, ActionSelect):
+			return m, m.choose()
+		}
+
+	case quitConfirmedMsg:
+		m.quitting = true
+		return m, tea.Quit
+
+	case tea.MouseMsg:
+		// The wheel moves the cursor and a click opens the choice under it
+		if step := mouseWheel(msg); step != 0 {
+			if cursor := m.cursor + step; cursor >= 0 && cursor < len(m.choices) {
+				m.cursor = cursor
+			}
+		} else if mouseClicked(msg) {
+			if i := checkboxAt(m.View(), m.height, msg, m.choices); i >= 0 {
+				m.cursor = i
+				return m, m.choose()
 			}
 		}
 	}
@@ -122,6 +184,51 @@ func (m *MenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// quitConfirmedMsg quits once leaving has been confirmed
+type quitConfirmedMsg struct{}
+
+// quit leaves passman, asking first when confirm_before_exit is set
+func (m *MenuModel) quit() tea.Cmd {
+	if confirmsExit(m.manager) {
+		return confirm("Quit passman?", func() tea.Msg { return quitConfirmedMsg{} })
+	}
+	m.quitting = true
+	return tea.Quit
+}
+
+// choose opens the choice under the cursor
+func (m *MenuModel) choose() tea.Cmd {
+	switch m.actions[m.cursor] {
+	case "quit":
+		return m.quit()
+	case "history":
+		return navigate(HistoryScreen)
+	case "pick":
+		return navigate(PickScreen)
+	case "compare":
+		return navigate(CompareScreen)
+	case "scratchpad":
+		return navigate(ScratchpadScreen)
+	case "profiles":
+		return navigate(ProfilesScreen)
+	case "settings":
+		return navigate(SettingsScreen)
+	case "whatsnew":
+		return navigate(WhatsNewScreen)
+	case "tutorial":
+		return navigate(TutorialScreen)
+	case "keybindings":
+		return navigate(KeybindingsScreen)
+	}
+	if screen, ok := generatorMenuScreens[m.actions[m.cursor]]; ok {
+		return navigate(screen)
+	}
+	if name, ok := strings.CutPrefix(m.actions[m.cursor], presetMenuPrefix); ok {
+		return openPreset(m.manager.Config, name)
+	}
+	return nil
+}
+
 func (m *MenuModel) View() string {
 	if m.quitting {
 		return "\n  Thanks for using Password Generator TUI! 👋\n\n"
@@ -129,13 +236,19 @@ func (m *MenuModel) View() string {
 
 	// Title with white color
 	title := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
+		Foreground(theme.Text).
 		Bold(true).
 		Render("Password Generator TUI")
 
 	subtitle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
+		Foreground(theme.Text).
 		Render("What would you like to do today?")
+	if profile := config.ActiveProfile(); profile != config.DefaultProfile {
+		subtitle = subtleStyle.Render("Profile: "+profile) + "\n" + subtitle
+	}
+	if banner := insecureSeedBanner(); banner != "" {
+		subtitle = banner + "\n\n" + subtitle
+	}
 
 	// Build the checkbox-style menu exactly like the views example
 	var menuItems []string
@@ -146,9 +259,13 @@ func (m *MenuModel) View() string {
 	menu := strings.Join(menuItems, "\n")
 
 	// Footer with arrows and cleaner formatting like the help example
-	help := subtleStyle.Render("↑/↓: navigate") + dotStyle +
-		subtleStyle.Render("enter: select") + dotStyle +
-		subtleStyle.Render("q: quit")
+	help := subtleStyle.Render(keys.Label(ActionUp)+"/"+keys.Label(ActionDown)+": navigate") + dotStyle +
+		keyHelp(ActionSelect, "select") + dotStyle +
+		keyHelp(ActionScratchpad, "scratchpad") + dotStyle +
+		keyHelp(ActionProfiles, "profile") + dotStyle +
+		keyHelp(ActionPick, "pick") + dotStyle +
+		keyHelp(ActionHelp, "keys") + dotStyle +
+		keyHelp(ActionQuit, "quit")
 
 	// Combine everything
 	content := fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
@@ -162,6 +279,18 @@ func (m *MenuModel) View() string {
 	return mainStyle.Render("\n" + content + "\n\n")
 }
 
+// insecureSeedBanner warns that generators are deterministic, so nothing
+// generated in a seeded session is mistaken for a real secret
+func insecureSeedBanner() string {
+	if !generator.InsecureSeeded() {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Warning).
+		Bold(true).
+		Render("⚠ INSECURE SEEDED MODE: output is predictable, for test fixtures only")
+}
+
 // checkbox renders a checkbox with label, exactly like the views example
 func checkbox(label string, checked bool) string {
 	if checked {
@@ -169,5 +298,3 @@ func checkbox(label string, checked bool) string {
 	}
 	return fmt.Sprintf("[ ] %s", label)
 }
-
-
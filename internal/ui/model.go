@@ -5,12 +5,25 @@ import (
 	"github.com/mshnjffr/passman/internal/utils"
 )
 
-// NewModel creates and returns the initial menu model
+// NewModel creates and returns the app, starting on the menu
 func NewModel() tea.Model {
-	return NewMenuModel(nil)
+	return NewAppModel(nil, MenuScreen)
 }
 
-// NewModelWithManager creates and returns the initial menu model with manager
+// NewModelWithManager creates and returns the app with manager, starting
+// on the menu
 func NewModelWithManager(manager *utils.Manager) tea.Model {
-	return NewMenuModel(manager)
+	return NewAppModel(manager, MenuScreen)
+}
+
+// NewWhatsNewModelWithManager creates the app starting on the what's new
+// screen shown after an upgrade
+func NewWhatsNewModelWithManager(manager *utils.Manager) tea.Model {
+	return NewAppModel(manager, WhatsNewScreen)
+}
+
+// NewWizardModelWithManager creates the app starting on the setup wizard
+// shown on first launch
+func NewWizardModelWithManager(manager *utils.Manager) tea.Model {
+	return NewAppModel(manager, WizardScreen)
 }
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// mouseClicked reports whether msg is a press of the left button
+func mouseClicked(msg tea.MouseMsg) bool {
+	return msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+}
+
+// mouseWheel returns -1 when the wheel is turned up, 1 when it is turned
+// down and 0 for any other mouse event
+func mouseWheel(msg tea.MouseMsg) int {
+	if msg.Action != tea.MouseActionPress {
+		return 0
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		return -1
+	case tea.MouseButtonWheelDown:
+		return 1
+	}
+	return 0
+}
+
+// viewLine returns row y of a view shown in height rows, without styling.
+// A view taller than the terminal loses its top lines, as the renderer
+// keeps the bottom ones.
+func viewLine(view string, height, y int) string {
+	lines := strings.Split(view, "\n")
+	if height > 0 && len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	if y < 0 || y >= len(lines) {
+		return ""
+	}
+	return ansi.Strip(lines[y])
+}
+
+// checkboxAt returns the index of the checkbox of labels under a click on
+// view, or -1. Boxes are found by their text, so the layouts of every
+// terminal width need no coordinates of their own.
+func checkboxAt(view string, height int, msg tea.MouseMsg, labels []string) int {
+	line := viewLine(view, height, msg.Y)
+	for i, label := range labels {
+		for _, box := range []string{"[ ] " + label, "[x] " + label} {
+			at := strings.Index(line, box)
+			if at < 0 {
+				continue
+			}
+			start := ansi.StringWidth(line[:at])
+			if msg.X >= start && msg.X < start+ansi.StringWidth(box) {
+				return i
+			}
+		}
+	}
+	return -1
+}
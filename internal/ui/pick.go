@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// PickModel is the fuzzy finder over the history: typing narrows the list
+// by description, site, username, tags and type, and enter picks the
+// selected password. Inside the TUI the password is copied; run
+// standalone by passman pick, the program quits and the caller prints or
+// copies it.
+type PickModel struct {
+	width      int
+	height     int
+	manager    *utils.Manager
+	input      textinput.Model
+	entries    []utils.HistoryEntry
+	matches    []utils.HistoryEntry
+	cursor     int
+	standalone bool
+	picked     *utils.HistoryEntry
+	statusMsg  string
+}
+
+// NewPickModel creates a new fuzzy finder for the TUI, which goes back to
+// the screen it was opened from when left
+func NewPickModel(manager *utils.Manager) *PickModel {
+	input := textinput.New()
+	input.Placeholder = "site, username, description or #tag"
+	input.Prompt = "> "
+	input.CharLimit = 128
+	input.Width = 40
+	input.Focus()
+
+	m := &PickModel{
+		manager: manager,
+		input:   input,
+	}
+	m.loadEntries()
+	return m
+}
+
+// NewPickModelWithSize creates a new fuzzy finder with specified dimensions
+func NewPickModelWithSize(manager *utils.Manager, width, height int) *PickModel {
+	model := NewPickModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+// NewStandalonePickModel creates a fuzzy finder that quits once an entry
+// is picked, for passman pick
+func NewStandalonePickModel(manager *utils.Manager) *PickModel {
+	model := NewPickModel(manager)
+	model.standalone = true
+	return model
+}
+
+// Picked returns the entry picked in a standalone finder, and false if
+// the finder was left without picking one
+func (m *PickModel) Picked() (utils.HistoryEntry, bool) {
+	if m.picked == nil {
+		return utils.HistoryEntry{}, false
+	}
+	return *m.picked, true
+}
+
+// SetQuery fills in the search, as passman pick -query does
+func (m *PickModel) SetQuery(query string) {
+	m.input.SetValue(query)
+	m.input.CursorEnd()
+	m.filter()
+}
+
+// loadEntries reads the history and shows all of it, newest first
+func (m *PickModel) loadEntries() {
+	if m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.statusMsg = "History is disabled"
+		return
+	}
+	entries, err := m.manager.History.LoadHistory()
+	if err != nil {
+		m.statusMsg = "Failed to load history: " + err.Error()
+		return
+	}
+	m.entries = entries
+	m.filter()
+}
+
+// filter ranks the entries by the query and moves the cursor to the best
+// match
+func (m *PickModel) filter() {
+	m.matches = utils.FuzzyFilter(m.entries, m.input.Value())
+	m.cursor = 0
+}
+
+func (m *PickModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *PickModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		// The query takes every printable key, so the list is driven by
+		// fixed keys like in fzf
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			if m.standalone {
+				return m, tea.Quit
+			}
+			return m, navigateBack()
+		case "up", "ctrl+p", "ctrl+k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		case "down", "ctrl+n", "ctrl+j", "tab":
+			if m.cursor < len(m.matches)-1 {
+				m.cursor++
+			}
+			return m, nil
+		case "enter":
+			return m, m.pick()
+		}
+
+		query := m.input.Value()
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		if m.input.Value() != query {
+			m.filter()
+			m.statusMsg = ""
+		}
+		return m, cmd
+
+	case autoTypeDoneMsg:
+		m.statusMsg = autoTypeStatus(m.manager, msg)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// pick hands the selected entry to passman pick, or copies its password
+// inside the TUI
+func (m *PickModel) pick() tea.Cmd {
+	if m.cursor >= len(m.matches) {
+		return nil
+	}
+	entry := m.matches[m.cursor]
+
+	if m.standalone {
+		m.picked = &entry
+		return tea.Quit
+	}
+
+	if usesAutoType(m.manager) {
+		cmd, status := startAutoType(m.manager, entry.Password, entry.ID)
+		m.statusMsg = status
+		return cmd
+	}
+	if m.manager == nil || m.manager.Clipboard == nil {
+		m.statusMsg = "Clipboard not available"
+		return nil
+	}
+	if err := m.manager.Clipboard.Copy(entry.Password); err != nil {
+		m.statusMsg = "Failed to copy to clipboard"
+		return nil
+	}
+	m.statusMsg = "Password copied to clipboard!"
+	recordUsage(m.manager, entry.ID, utils.UsageCopied)
+	return nil
+}
+
+// visibleRows is how many matches fit on the screen
+func (m *PickModel) visibleRows() int {
+	if m.height <= 0 {
+		return 10
+	}
+	return max(m.height-12, 3)
+}
+
+func (m *PickModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("🔎 Pick a Password")
+
+	textStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	count := subtleStyle.Render(fmt.Sprintf("%d/%d", len(m.matches), len(m.entries)))
+	sections := []string{title, m.input.View() + "  " + count}
+
+	// Scroll so the cursor stays in view
+	rows := m.visibleRows()
+	start := 0
+	if m.cursor >= rows {
+		start = m.cursor - rows + 1
+	}
+	end := min(start+rows, len(m.matches))
+
+	var list []string
+	for i := start; i < end; i++ {
+		entry := m.matches[i]
+		label := entry.PickLabel()
+		if m.width > 30 {
+			label = truncateRunes(label, m.width-30)
+		}
+		when := subtleStyle.Render("  " + entry.CreatedAt.Format("Jan 2 15:04"))
+		if i == m.cursor {
+			list = append(list, checkboxStyle.Render("▸ "+label)+when)
+			continue
+		}
+		list = append(list, "  "+label+when)
+	}
+	switch {
+	case len(m.entries) == 0 && m.statusMsg == "":
+		list = append(list, subtleStyle.Render("No passwords in history yet"))
+	case len(m.matches) == 0 && len(m.entries) > 0:
+		list = append(list, subtleStyle.Render("No matches"))
+	}
+	if len(list) > 0 {
+		sections = append(sections, strings.Join(list, "\n"))
+	}
+
+	if m.statusMsg != "" {
+		sections = append(sections, textStyle.Render(m.statusMsg))
+	}
+
+	action, leave := "copy", "back"
+	if m.standalone {
+		action, leave = "pick", "cancel"
+	} else if usesAutoType(m.manager) {
+		action = "type"
+	}
+	help := subtleStyle.Render("type to search") + dotStyle +
+		subtleStyle.Render("↑/↓: move") + dotStyle +
+		subtleStyle.Render("enter: "+action) + dotStyle +
+		subtleStyle.Render("esc: "+leave)
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// truncateRunes shortens s to width characters, ending in an ellipsis
+func truncateRunes(s string, width int) string {
+	chars := []rune(s)
+	if len(chars) <= width || width < 2 {
+		return s
+	}
+	return string(chars[:width-1]) + "…"
+}
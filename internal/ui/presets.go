@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// presetMenuPrefix starts the menu actions that open a preset
+const presetMenuPrefix = "preset:"
+
+// applyPresetMsg loads a preset into the generator screen it is sent to
+type applyPresetMsg struct {
+	name   string
+	preset config.Preset
+}
+
+// savePresetMsg saves the settings of a generator screen as a preset
+// once replacing an existing one has been confirmed
+type savePresetMsg struct {
+	name   string
+	preset config.Preset
+}
+
+// presetMenuChoices returns the menu entries and actions of the saved
+// presets whose generator has a screen of its own
+func presetMenuChoices(cfg *config.Config) (choices, actions []string) {
+	if cfg == nil {
+		return nil, nil
+	}
+	for _, name := range cfg.PresetNames() {
+		preset := cfg.Presets[name]
+		if !isGeneratorModelType(preset.Type) {
+			continue
+		}
+		choices = append(choices, fmt.Sprintf("Preset: %s (%s)", name, preset.Summary()))
+		actions = append(actions, presetMenuPrefix+name)
+	}
+	return choices, actions
+}
+
+// isGeneratorModelType reports whether the generator screen of kind can
+// load presets
+func isGeneratorModelType(kind string) bool {
+	return kind == "random" || kind == "memorable" || kind == "pin"
+}
+
+// openPreset shows the generator screen of the preset called name with
+// the preset loaded
+func openPreset(cfg *config.Config, name string) tea.Cmd {
+	preset, ok := cfg.Presets[name]
+	if !ok {
+		return nil
+	}
+	return navigateWith(generatorMenuScreens[preset.Type], applyPresetMsg{name: name, preset: preset})
+}
+
+// startSavingPreset asks for the name to save the current settings under
+func (m *GeneratorModel) startSavingPreset() {
+	if m.manager == nil || m.manager.Config == nil {
+		m.status.Error("Presets need a config")
+		return
+	}
+	m.savingPreset = true
+	m.presetInput.SetValue("")
+	m.presetInput.Focus()
+}
+
+// updatePresetName handles a key while the preset name is typed
+func (m *GeneratorModel) updatePresetName(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		name := strings.TrimSpace(m.presetInput.Value())
+		if err := config.ValidatePresetName(name); err != nil {
+			m.status.Error(err.Error())
+			return nil
+		}
+		m.savingPreset = false
+		m.presetInput.Blur()
+
+		preset := m.currentPreset()
+		if _, exists := m.manager.Config.Presets[name]; exists {
+			return confirm(fmt.Sprintf("Replace the preset %q?", name), func() tea.Msg {
+				return savePresetMsg{name: name, preset: preset}
+			})
+		}
+		m.savePreset(name, preset)
+		return nil
+	case "esc":
+		m.savingPreset = false
+		m.presetInput.Blur()
+		return nil
+	case "ctrl+c":
+		return navigate(MenuScreen)
+	}
+	var cmd tea.Cmd
+	m.presetInput, cmd = m.presetInput.Update(msg)
+	return cmd
+}
+
+// savePreset stores preset under name in the config
+func (m *GeneratorModel) savePreset(name string, preset config.Preset) {
+	cfg := m.manager.Config
+	if cfg.Presets == nil {
+		cfg.Presets = make(map[string]config.Preset)
+	}
+	cfg.Presets[name] = preset
+	if err := cfg.Save(); err != nil {
+		m.status.Error("Failed to save preset: " + err.Error())
+		return
+	}
+	m.status.Success(fmt.Sprintf("Saved preset %q; it is listed in the menu", name))
+}
+
+// rememberSettings stores the settings of the screen in the config, to
+// restore them the next time it opens. The config is only written when
+// they changed.
+func (m *GeneratorModel) rememberSettings() {
+	if m.manager == nil || m.manager.Config == nil {
+		return
+	}
+	cfg := m.manager.Config
+	current := m.currentPreset()
+	if last, ok := cfg.GeneratorSettings[m.generatorType]; ok && reflect.DeepEqual(last, current) {
+		return
+	}
+	if cfg.GeneratorSettings == nil {
+		cfg.GeneratorSettings = make(map[string]config.Preset)
+	}
+	cfg.GeneratorSettings[m.generatorType] = current
+	_ = cfg.Save()
+}
+
+// currentPreset returns the settings of the screen as a preset
+func (m *GeneratorModel) currentPreset() config.Preset {
+	preset := config.Preset{Type: m.generatorType}
+	switch m.generatorType {
+	case "random":
+		preset.Length, _ = strconv.Atoi(m.lengthInput.Value())
+		for _, set := range []struct {
+			on   bool
+			name string
+		}{
+			{m.includeLower, "lower"},
+			{m.includeUpper, "upper"},
+			{m.includeNumbers, "digits"},
+			{m.includeSymbols, "symbols"},
+		} {
+			if set.on {
+				preset.Charsets = append(preset.Charsets, set.name)
+			}
+		}
+		if m.localeCharset != 0 {
+			preset.Charsets = append(preset.Charsets, generator.CharSetToString(m.localeCharset))
+		}
+	case "memorable":
+		preset.Words, _ = strconv.Atoi(m.wordCountInput.Value())
+		preset.Separator = m.separator
+		preset.Wordlist = m.selectedWordlist().ID
+		preset.Capitalize, preset.Digit = m.capitalize, m.digit
+	case "pin":
+		preset.Length, _ = strconv.Atoi(m.lengthInput.Value())
+		preset.GroupSize, preset.Separator = m.groupSize, m.separator
+	}
+	return preset
+}
+
+// applyPreset loads the settings of preset; what it leaves out is kept
+func (m *GeneratorModel) applyPreset(preset config.Preset) {
+	if preset.Length > 0 {
+		m.lengthInput.SetValue(strconv.Itoa(preset.Length))
+	}
+	if preset.Words > 0 {
+		m.wordCountInput.SetValue(strconv.Itoa(preset.Words))
+	}
+	if preset.Separator != "" {
+		m.separator = preset.Separator
+	}
+	if preset.Type == "memorable" {
+		m.capitalize, m.digit = preset.Capitalize, preset.Digit
+	}
+	if preset.Type == "pin" {
+		m.groupSize = preset.GroupSize
+	}
+	for i, wordlist := range m.wordlists {
+		if wordlist.ID == preset.Wordlist {
+			m.wordlistIndex = i
+		}
+	}
+	if len(preset.Charsets) == 0 {
+		return
+	}
+
+	m.includeLower, m.includeUpper, m.includeNumbers, m.includeSymbols = false, false, false, false
+	m.localeCharset = 0
+	for _, name := range preset.Charsets {
+		switch strings.ToLower(name) {
+		case "lower", "lowercase":
+			m.includeLower = true
+		case "upper", "uppercase":
+			m.includeUpper = true
+		case "digits", "numbers":
+			m.includeNumbers = true
+		case "symbols":
+			m.includeSymbols = true
+		default:
+			if cs, err := generator.ParseLocaleCharSet(name); err == nil {
+				m.localeCharset = cs
+			}
+		}
+	}
+}
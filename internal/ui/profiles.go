@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// ProfilesModel lists the config profiles and switches between them. Each
+// profile has its own defaults, export path and history.
+type ProfilesModel struct {
+	width     int
+	height    int
+	manager   *utils.Manager
+	names     []string
+	cursor    int
+	creating  bool // Asking for the name of a new profile
+	nameInput textinput.Model
+	statusMsg string
+}
+
+// NewProfilesModel creates a new profile switcher
+func NewProfilesModel(manager *utils.Manager) *ProfilesModel {
+	nameInput := textinput.New()
+	nameInput.Placeholder = "e.g. work"
+	nameInput.CharLimit = 32
+	nameInput.Width = 32
+
+	m := &ProfilesModel{
+		manager:   manager,
+		nameInput: nameInput,
+	}
+	m.loadNames()
+	return m
+}
+
+// NewProfilesModelWithSize creates a new profile switcher with specified dimensions
+func NewProfilesModelWithSize(manager *utils.Manager, width, height int) *ProfilesModel {
+	model := NewProfilesModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+func (m *ProfilesModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *ProfilesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.creating {
+			switch msg.String() {
+			case "enter":
+				m.creating = false
+				return m, m.createProfile(strings.TrimSpace(m.nameInput.Value()))
+			case "esc":
+				m.creating = false
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			var cmd tea.Cmd
+			m.nameInput, cmd = m.nameInput.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit), keys.Matches(msg, ActionBack):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case keys.Matches(msg, ActionDown):
+			if m.cursor < len(m.names)-1 {
+				m.cursor++
+			}
+		case keys.Matches(msg, ActionSelect):
+			if m.cursor < len(m.names) {
+				return m, m.switchTo(m.names[m.cursor])
+			}
+		case keys.Matches(msg, ActionNewProfile):
+			m.creating = true
+			m.nameInput.Reset()
+			m.nameInput.Focus()
+			m.statusMsg = ""
+		}
+	}
+
+	return m, nil
+}
+
+// loadNames reads the profile list and puts the cursor on the active one
+func (m *ProfilesModel) loadNames() {
+	names, err := config.ProfileNames()
+	if err != nil {
+		m.statusMsg = "Cannot list profiles: " + err.Error()
+		names = []string{config.DefaultProfile}
+	}
+	m.names = names
+
+	m.cursor = 0
+	for i, name := range names {
+		if name == config.ActiveProfile() {
+			m.cursor = i
+		}
+	}
+}
+
+// switchTo selects a profile and applies its config through the same path
+// as a config edited on disk. A profile whose config does not load is
+// not switched to.
+func (m *ProfilesModel) switchTo(name string) tea.Cmd {
+	previous := config.ActiveProfile()
+	if name == previous {
+		m.statusMsg = "Already using profile " + name
+		return nil
+	}
+
+	if err := config.SetProfile(name); err != nil {
+		m.statusMsg = err.Error()
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		_ = config.SetProfile(previous)
+		m.statusMsg = "Profile " + name + " not switched to:\n" + err.Error()
+		return nil
+	}
+	cfg.Validate()
+	if cfg.HistoryEnabled {
+		// The TUI can't ask on the terminal, so only a cached passphrase will do
+		if err := utils.ResolveHistoryPassphrase(&cfg, nil); err != nil {
+			_ = config.SetProfile(previous)
+			m.statusMsg = "Profile " + name + " asks for its history passphrase;\nstart passman with --profile " + name + " to enter it"
+			return nil
+		}
+	}
+
+	m.statusMsg = "Switched to profile " + name
+	return func() tea.Msg {
+		return ConfigReloadedMsg{Config: cfg}
+	}
+}
+
+// createProfile creates a profile and switches to it
+func (m *ProfilesModel) createProfile(name string) tea.Cmd {
+	if name == "" {
+		return nil
+	}
+	if err := config.CreateProfile(name); err != nil {
+		m.statusMsg = err.Error()
+		return nil
+	}
+	m.loadNames()
+	for i, n := range m.names {
+		if n == name {
+			m.cursor = i
+		}
+	}
+	return m.switchTo(name)
+}
+
+func (m *ProfilesModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("👤 Profiles")
+
+	subtitle := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Render("Each profile has its own settings, export path and history")
+
+	var items []string
+	for i, name := range m.names {
+		label := name
+		if name == config.ActiveProfile() {
+			label += " (active)"
+		}
+		items = append(items, checkbox(label, m.cursor == i))
+	}
+
+	sections := []string{title, subtitle, strings.Join(items, "\n")}
+
+	if m.creating {
+		sections = append(sections, "New profile name: "+m.nameInput.View())
+	}
+
+	if m.statusMsg != "" {
+		sections = append(sections, lipgloss.NewStyle().Foreground(theme.Accent).Render(m.statusMsg))
+	}
+
+	var help string
+	if m.creating {
+		help = subtleStyle.Render("enter: create and switch") + dotStyle +
+			subtleStyle.Render("esc: cancel")
+	} else {
+		help = subtleStyle.Render(keys.Label(ActionUp)+"/"+keys.Label(ActionDown)+": navigate") + dotStyle +
+			keyHelp(ActionSelect, "switch") + dotStyle +
+			keyHelp(ActionNewProfile, "new profile") + dotStyle +
+			keyHelp(ActionBack, "back")
+	}
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// ConfigReloadedMsg carries a config that changed on disk while the TUI runs
+type ConfigReloadedMsg struct {
+	Config config.Config
+}
+
+// reloadModel wraps the active screen and applies reloaded configs on the
+// UI goroutine, so screens never see the manager change mid-update. It
+// also draws the session footer under every screen, locks the TUI when
+// idle and syncs the history in the background.
+type reloadModel struct {
+	screen  tea.Model
+	manager *utils.Manager
+	width   int
+	height  int
+	locked  *lockScreen // Set while locked
+	syncing bool        // A background sync is running or scheduled
+	status  StatusBar   // Errors of its own, such as a failed lock
+}
+
+// WithConfigReload wraps the first screen so that sending it a
+// ConfigReloadedMsg updates the manager, theme and keymap and then lets the
+// active screen refresh anything it copied from the old config
+func WithConfigReload(model tea.Model, manager *utils.Manager) tea.Model {
+	return &reloadModel{screen: model, manager: manager}
+}
+
+func (m *reloadModel) Init() tea.Cmd {
+	return tea.Batch(m.screen.Init(), footerTick(), m.startSync())
+}
+
+// startSync syncs the history now if background sync is on and no sync is
+// running or scheduled
+func (m *reloadModel) startSync() tea.Cmd {
+	if _, ok := syncInterval(m.manager); !ok || m.syncing || m.manager.History.IsLocked() {
+		return nil
+	}
+	m.syncing = true
+	return syncHistory(m.manager)
+}
+
+func (m *reloadModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Clears are also meant for the screens' own status bars, so they go on
+	m.status.Update(msg)
+
+	// Background syncs carry on while locked; a sync due then is skipped
+	switch msg.(type) {
+	case syncTickMsg:
+		m.syncing = false
+		return m, m.startSync()
+	case syncDoneMsg:
+		// The footer shows the outcome, recorded by the manager
+		m.syncing = false
+		if cmd := scheduleSync(m.manager); cmd != nil {
+			m.syncing = true
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	if m.locked != nil {
+		return m.updateLocked(msg)
+	}
+	footerShown := footerEnabled(m.manager)
+
+	switch msg := msg.(type) {
+	case footerTickMsg:
+		// Redraws the countdowns; screens don't need to see it
+		if at, ok := autoLockAt(m.manager); ok && !time.Now().Before(at) && lockable(m.manager, m.screen) {
+			m.lock()
+		}
+		return m, tea.Batch(footerTick(), m.status.Cmd())
+
+	case tea.MouseMsg:
+		if m.manager != nil && m.manager.Session != nil {
+			m.manager.Session.RecordActivity(time.Now())
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m.forward(m.screenSize())
+
+	case tea.KeyMsg:
+		if m.manager != nil && m.manager.Session != nil {
+			m.manager.Session.RecordActivity(time.Now())
+		}
+		if keys.Matches(msg, ActionLock) && lockable(m.manager, m.screen) {
+			m.lock()
+			return m, m.status.Cmd()
+		}
+		if keys.Matches(msg, ActionToggleFooter) && m.manager != nil && m.manager.Config != nil {
+			m.manager.Config.ShowFooter = !m.manager.Config.ShowFooter
+			_ = m.manager.Config.Save()
+			return m.forward(m.screenSize())
+		}
+
+	case ConfigReloadedMsg:
+		if m.manager != nil {
+			cfg := msg.Config
+			// A prompted or keychain passphrase is never in the file; keep the
+			// one in use
+			if !cfg.StoresPassphrase() && cfg.HistoryEncryptionKey == "" && m.manager.Config != nil {
+				cfg.HistoryEncryptionKey = m.manager.Config.HistoryEncryptionKey
+			}
+			if err := m.manager.UpdateConfig(&cfg); err != nil {
+				return m, nil
+			}
+			_ = SetTheme(cfg.Theme)
+			// A conflicting keymap keeps the defaults and shows the error on the
+			// keybindings screen, as at startup
+			_ = SetKeymap(cfg.Keys)
+		}
+	}
+
+	model, cmd := m.forward(msg)
+	// Settings may have turned background sync on
+	cmd = tea.Batch(cmd, m.startSync())
+	if footerEnabled(m.manager) != footerShown {
+		var resizeCmd tea.Cmd
+		model, resizeCmd = m.forward(m.screenSize())
+		cmd = tea.Batch(cmd, resizeCmd)
+	}
+	return model, cmd
+}
+
+// forward passes msg to the active screen
+func (m *reloadModel) forward(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.screen, cmd = m.screen.Update(msg)
+	return m, cmd
+}
+
+// screenSize is the terminal size left for the screen under the footer
+func (m *reloadModel) screenSize() tea.WindowSizeMsg {
+	height := m.height
+	if footerEnabled(m.manager) && height > footerHeight {
+		height -= footerHeight
+	}
+	return tea.WindowSizeMsg{Width: m.width, Height: height}
+}
+
+func (m *reloadModel) View() string {
+	view := m.screen.View()
+	if m.locked != nil {
+		view = m.locked.view()
+	}
+	if status := m.status.View(); status != "" {
+		view += "\n" + status
+	}
+	if !footerEnabled(m.manager) {
+		return view
+	}
+
+	// Keep the footer on the bottom line when the screen is shorter
+	if lines := strings.Count(view, "\n") + 1; lines < m.screenSize().Height {
+		view += strings.Repeat("\n", m.screenSize().Height-lines)
+	}
+	return view + "\n" + footerView(m.manager, m.width, time.Now(), lockable(m.manager, m.screen))
+}
@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// scratchpadCheckInterval is how often an open scratchpad checks for expiry
+const scratchpadCheckInterval = 10 * time.Second
+
+// scratchpadTickMsg triggers the auto-clear check
+type scratchpadTickMsg struct{}
+
+// ScratchpadModel represents the encrypted scratchpad screen
+type ScratchpadModel struct {
+	width     int
+	height    int
+	manager   *utils.Manager
+	editor    textarea.Model
+	saved     string    // Text as last saved, to detect unsaved changes
+	updatedAt time.Time // When the text was last saved
+	available bool
+	statusMsg string
+}
+
+// NewScratchpadModel creates a new scratchpad model and loads the saved text
+func NewScratchpadModel(manager *utils.Manager) *ScratchpadModel {
+	editor := textarea.New()
+	editor.Placeholder = "Park secrets here temporarily..."
+	editor.ShowLineNumbers = true
+	editor.SetWidth(60)
+	editor.SetHeight(10)
+	editor.Focus()
+
+	m := &ScratchpadModel{
+		manager: manager,
+		editor:  editor,
+	}
+
+	if manager == nil || manager.History == nil || !manager.History.IsEnabled() {
+		m.statusMsg = "The scratchpad is stored with the encrypted history. Enable history in Settings to use it."
+		return m
+	}
+	m.available = true
+
+	pad, err := manager.History.LoadScratchpad(m.clearAfter())
+	if err != nil {
+		m.statusMsg = "Failed to load scratchpad: " + err.Error()
+		return m
+	}
+	m.editor.SetValue(pad.Text)
+	m.saved = pad.Text
+	m.updatedAt = pad.UpdatedAt
+	return m
+}
+
+// NewScratchpadModelWithSize creates a new scratchpad model with specified dimensions
+func NewScratchpadModelWithSize(manager *utils.Manager, width, height int) *ScratchpadModel {
+	model := NewScratchpadModel(manager)
+	model.width = width
+	model.height = height
+	model.resize()
+	return model
+}
+
+func (m *ScratchpadModel) Init() tea.Cmd {
+	return tea.Batch(textarea.Blink, m.tick())
+}
+
+func (m *ScratchpadModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resize()
+		return m, nil
+
+	case scratchpadTickMsg:
+		m.checkExpiry()
+		return m, m.tick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			// Leaving always saves, so nothing parked here is lost
+			m.save()
+			return m, navigateBack()
+		case "ctrl+s":
+			if m.save() {
+				m.statusMsg = "Scratchpad saved"
+			}
+			return m, nil
+		case "ctrl+r":
+			m.promoteLine()
+			return m, nil
+		case "ctrl+x":
+			m.editor.Reset()
+			if m.save() {
+				m.statusMsg = "Scratchpad cleared"
+			}
+			return m, nil
+		}
+	}
+
+	if !m.available {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.editor, cmd = m.editor.Update(msg)
+	return m, cmd
+}
+
+func (m *ScratchpadModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("📝 Scratchpad")
+
+	info := "Encrypted with your history key."
+	if clearAfter := m.clearAfter(); clearAfter > 0 {
+		info += fmt.Sprintf(" Cleared %s after the last save.", clearAfter)
+	}
+	if m.editor.Value() != m.saved {
+		info += " (unsaved)"
+	}
+
+	sections := []string{title, subtleStyle.Render(info), m.editor.View()}
+
+	if m.statusMsg != "" {
+		sections = append(sections, lipgloss.NewStyle().Foreground(theme.Text).Render(m.statusMsg))
+	}
+
+	help := subtleStyle.Render("ctrl+s: save") + dotStyle +
+		subtleStyle.Render("ctrl+r: promote line to history") + dotStyle +
+		subtleStyle.Render("ctrl+x: clear") + dotStyle +
+		subtleStyle.Render("esc: save & back")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// save writes the buffer to the encrypted scratchpad if it changed
+func (m *ScratchpadModel) save() bool {
+	if !m.available {
+		return false
+	}
+	if m.editor.Value() == m.saved {
+		// Unchanged text keeps its timestamp, so visiting doesn't postpone the auto-clear
+		return true
+	}
+
+	pad, err := m.manager.History.SaveScratchpad(m.editor.Value())
+	if err != nil {
+		m.statusMsg = "Failed to save scratchpad: " + err.Error()
+		return false
+	}
+	m.saved = pad.Text
+	m.updatedAt = pad.UpdatedAt
+	return true
+}
+
+// promoteLine saves the line under the cursor as a history entry
+func (m *ScratchpadModel) promoteLine() {
+	if !m.available {
+		return
+	}
+
+	lines := strings.Split(m.editor.Value(), "\n")
+	row := m.editor.Line()
+	if row < 0 || row >= len(lines) || strings.TrimSpace(lines[row]) == "" {
+		m.statusMsg = "Nothing to promote on this line"
+		return
+	}
+
+	secret := strings.TrimSpace(lines[row])
+	entry := utils.HistoryEntry{
+		Password:    secret,
+		Length:      len(secret),
+		Type:        "scratchpad",
+		Settings:    "Promoted from scratchpad",
+		Description: "Promoted from scratchpad",
+	}
+	if err := m.manager.History.AddEntry(entry); err != nil {
+		m.statusMsg = "Failed to promote line: " + err.Error()
+		return
+	}
+	m.statusMsg = fmt.Sprintf("Line %d saved to history", row+1)
+}
+
+// checkExpiry clears the scratchpad once it has been idle past the limit.
+// Unsaved edits count as activity, so nothing is cleared mid-edit.
+func (m *ScratchpadModel) checkExpiry() {
+	pad := utils.Scratchpad{Text: m.saved, UpdatedAt: m.updatedAt}
+	if !m.available || m.editor.Value() != m.saved || !pad.IsExpired(m.clearAfter()) {
+		return
+	}
+
+	m.editor.Reset()
+	if err := m.manager.History.ClearScratchpad(); err != nil {
+		m.statusMsg = "Failed to clear scratchpad: " + err.Error()
+		return
+	}
+	m.saved = ""
+	m.statusMsg = "Scratchpad auto-cleared"
+}
+
+// clearAfter returns the configured auto-clear time, zero for never
+func (m *ScratchpadModel) clearAfter() time.Duration {
+	if m.manager == nil || m.manager.Config == nil {
+		return 0
+	}
+	return time.Duration(m.manager.Config.ScratchpadClearAfter) * time.Minute
+}
+
+// tick schedules the next auto-clear check
+func (m *ScratchpadModel) tick() tea.Cmd {
+	return tea.Tick(scratchpadCheckInterval, func(time.Time) tea.Msg {
+		return scratchpadTickMsg{}
+	})
+}
+
+// resize fits the editor to the window
+func (m *ScratchpadModel) resize() {
+	if m.width > 20 {
+		m.editor.SetWidth(min(m.width-6, 100))
+	}
+	if m.height > 16 {
+		m.editor.SetHeight(min(m.height-14, 20))
+	}
+}
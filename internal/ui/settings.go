@@ -2,85 +2,282 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
 	"github.com/mshnjffr/passman/internal/utils"
 )
 
+// Setting categories, in display order
+const (
+	categoryGeneration = "Generation"
+	categoryPassphrase = "Passphrase"
+	categoryClipboard  = "Clipboard"
+	categoryExport     = "Export"
+	categoryHistory    = "History"
+	categoryUI         = "Interface"
+	categoryAdvanced   = "Advanced"
+)
+
 // SettingsModel represents the settings screen
 type SettingsModel struct {
-	width    int
-	height   int
-	manager  *utils.Manager
-	cursor   int
-	settings []SettingItem
+	width     int
+	height    int
+	manager   *utils.Manager
+	config    *config.Config // The manager's config, or defaults kept in memory
+	cursor    int
+	offset    int // First visible row of the scrolled list
+	settings  []SettingItem
+	editing   bool // Editing a number, text or path in the editor
+	editor    textinput.Model
+	status    StatusBar
+
+	actionInputs []string // Answers given so far to the prompts of an action
 }
 
 // SettingItem represents a configurable setting
 type SettingItem struct {
+	Category    string
 	Name        string
 	Description string
-	Type        string // "toggle", "number", "text"
-	Value       interface{}
-	Key         string // Config key
+	Type        string   // "toggle", "number", "text", "path", "choice", "info" or "action"
+	Key         string   // Config key, or the name of an action
+	Options     []string // Values of a choice
+	Min, Max    int      // Range of a number
+	ZeroLabel   string   // Shown instead of a zero number or empty text, e.g. "Never"; text may then be empty
+
+	ref interface{} // *bool, *int or *string in the config, or a mapEntry
+}
+
+// mapEntry refers to one value of a string map in the config
+type mapEntry struct {
+	values map[string]string
+	key    string
+}
+
+// Value returns the current value of the setting from the config
+func (s SettingItem) Value() interface{} {
+	switch ref := s.ref.(type) {
+	case *bool:
+		return *ref
+	case *int:
+		return *ref
+	case *string:
+		return *ref
+	case mapEntry:
+		return ref.values[ref.key]
+	}
+	return nil
+}
+
+// set stores a new value of the setting in the config
+func (s SettingItem) set(value interface{}) {
+	switch ref := s.ref.(type) {
+	case *bool:
+		*ref = value.(bool)
+	case *int:
+		*ref = value.(int)
+	case *string:
+		*ref = value.(string)
+	case mapEntry:
+		ref.values[ref.key] = value.(string)
+	}
 }
 
 // NewSettingsModel creates a new settings model
 func NewSettingsModel(manager *utils.Manager) *SettingsModel {
-	// Load current values from manager/config
-	historyEnabled := false
-	autoCopy := true
-	defaultLength := 16
-	showStrength := true
-	
-	if manager != nil {
-		if manager.History != nil {
-			historyEnabled = manager.History.IsEnabled()
-		}
-		if manager.Config != nil {
-			autoCopy = manager.Config.AutoCopyToClipboard
-			defaultLength = manager.Config.DefaultLength
-			showStrength = manager.Config.ShowStrengthMeter
-		}
-	}
-	
-	settings := []SettingItem{
-		{
-			Name:        "Password History",
-			Description: "Save generated passwords to encrypted history",
-			Type:        "toggle",
-			Value:       historyEnabled,
-			Key:         "history_enabled",
-		},
-		{
-			Name:        "Auto Copy to Clipboard",
-			Description: "Automatically copy generated passwords",
-			Type:        "toggle",
-			Value:       autoCopy,
-			Key:         "auto_copy_to_clipboard",
-		},
-		{
-			Name:        "Default Password Length",
-			Description: "Default length for random passwords",
-			Type:        "number",
-			Value:       defaultLength,
-			Key:         "default_length",
-		},
-		{
-			Name:        "Show Strength Meter",
-			Description: "Display password strength analysis",
-			Type:        "toggle",
-			Value:       showStrength,
-			Key:         "show_strength_meter",
-		},
-	}
-	
-	return &SettingsModel{
-		manager:  manager,
-		cursor:   0,
-		settings: settings,
+	editor := textinput.New()
+	editor.CharLimit = 256
+	editor.Width = 40
+
+	m := &SettingsModel{
+		manager: manager,
+		cursor:  0,
+		editor:  editor,
+	}
+	m.bindConfig()
+	return m
+}
+
+// bindConfig points the settings at the manager's current config
+func (m *SettingsModel) bindConfig() {
+	// Without a manager, changes apply to defaults for this session only
+	cfg := config.Default()
+	if m.manager != nil && m.manager.Config != nil {
+		cfg = *m.manager.Config
+		m.manager.Config = &cfg
+	}
+	if cfg.Notifications == nil {
+		cfg.Notifications = make(map[string]string)
+	}
+	if m.manager != nil && m.manager.History != nil {
+		cfg.HistoryEnabled = m.manager.History.IsEnabled()
+	}
+
+	m.config = &cfg
+	m.settings = settingItems(&cfg)
+}
+
+// settingItems lists every setting of the config, grouped by category
+func settingItems(cfg *config.Config) []SettingItem {
+	themes := append([]string{AutoThemeName}, ThemeNames()...)
+	notifyMethods := utils.NotifyMethods()
+
+	return []SettingItem{
+		{Category: categoryGeneration, Name: "Default Generator", Description: "Menu entry selected when passman starts",
+			Type: "choice", Key: "default_generator", Options: generator.Names(), ref: &cfg.DefaultGenerator},
+		{Category: categoryGeneration, Name: "Default Password Length", Description: "Default length for random passwords",
+			Type: "number", Key: "default_length", Min: 1, Max: 512, ref: &cfg.DefaultLength},
+		{Category: categoryGeneration, Name: "Include Lowercase", Description: "Use lowercase letters by default",
+			Type: "toggle", Key: "default_include_lowercase", ref: &cfg.DefaultIncludeLowercase},
+		{Category: categoryGeneration, Name: "Include Uppercase", Description: "Use uppercase letters by default",
+			Type: "toggle", Key: "default_include_uppercase", ref: &cfg.DefaultIncludeUppercase},
+		{Category: categoryGeneration, Name: "Include Numbers", Description: "Use digits by default",
+			Type: "toggle", Key: "default_include_numbers", ref: &cfg.DefaultIncludeNumbers},
+		{Category: categoryGeneration, Name: "Include Symbols", Description: "Use symbols by default",
+			Type: "toggle", Key: "default_include_symbols", ref: &cfg.DefaultIncludeSymbols},
+		{Category: categoryGeneration, Name: "Exclude Similar", Description: "Leave out look-alike characters such as 0/O and 1/l/I",
+			Type: "toggle", Key: "default_exclude_similar", ref: &cfg.DefaultExcludeSimilar},
+		{Category: categoryGeneration, Name: "Exclude Ambiguous", Description: "Leave out symbols that are hard to type or read",
+			Type: "toggle", Key: "default_exclude_ambiguous", ref: &cfg.DefaultExcludeAmbiguous},
+		{Category: categoryGeneration, Name: "Default PIN Length", Description: "Default number of digits for PIN codes",
+			Type: "number", Key: "default_pin_length", Min: 1, Max: 50, ref: &cfg.DefaultPinLength},
+		{Category: categoryGeneration, Name: "PIN Groups", Description: "Digits per PIN group, e.g. 4 for 1234-5678; 0 for none",
+			Type: "number", Key: "default_pin_group_size", Min: 0, Max: 10, ref: &cfg.DefaultPinGroupSize},
+		{Category: categoryGeneration, Name: "PIN Separator", Description: "Text placed between PIN groups",
+			Type: "text", Key: "default_pin_separator", ref: &cfg.DefaultPinSeparator},
+		{Category: categoryGeneration, Name: "Candidates", Description: "How many candidates m offers to pick from",
+			Type: "number", Key: "candidate_count", Min: 5, Max: 10, ref: &cfg.CandidateCount},
+
+		{Category: categoryPassphrase, Name: "Words", Description: "Default number of words in a passphrase",
+			Type: "number", Key: "default_passphrase_words", Min: 1, Max: 20, ref: &cfg.DefaultPassphraseWords},
+		{Category: categoryPassphrase, Name: "Separator", Description: "Text placed between passphrase words; none joins them",
+			Type: "text", Key: "default_passphrase_separator", ref: &cfg.DefaultPassphraseSeparator},
+		{Category: categoryPassphrase, Name: "Capitalize Words", Description: "Start every passphrase word with a capital",
+			Type: "toggle", Key: "default_passphrase_capitalize", ref: &cfg.DefaultPassphraseCapitalize},
+		{Category: categoryPassphrase, Name: "Add a Digit", Description: "Put a random digit after one passphrase word",
+			Type: "toggle", Key: "default_passphrase_digit", ref: &cfg.DefaultPassphraseDigit},
+		{Category: categoryPassphrase, Name: "Leet Substitutions", Description: "Default leet-speak mode for passphrases",
+			Type: "choice", Key: "default_passphrase_leet", Options: []string{"off", "all", "random"}, ref: &cfg.DefaultPassphraseLeet},
+		{Category: categoryPassphrase, Name: "Leet Map", Description: "Substitutions used by leet-speak, e.g. a=@,e=3",
+			Type: "text", Key: "leet_substitutions", ref: &cfg.LeetSubstitutions},
+
+		{Category: categoryClipboard, Name: "Auto Copy to Clipboard", Description: "Automatically copy generated passwords",
+			Type: "toggle", Key: "auto_copy_to_clipboard", ref: &cfg.AutoCopyToClipboard},
+		{Category: categoryClipboard, Name: "Clear Clipboard After (s)", Description: "Clear copied secrets after this many seconds",
+			Type: "number", Key: "clear_clipboard_after_seconds", Min: 0, Max: 3600, ZeroLabel: "Never", ref: &cfg.ClearClipboardAfter},
+		{Category: categoryClipboard, Name: "Confirm Copies", Description: "Show a message after copying",
+			Type: "toggle", Key: "show_clipboard_success", ref: &cfg.ShowClipboardSuccess},
+		{Category: categoryClipboard, Name: "Copy Method", Description: "Copy to the clipboard, or type into the focused window after a delay",
+			Type: "choice", Key: "copy_method", Options: []string{"clipboard", "type"}, ref: &cfg.CopyMethod},
+		{Category: categoryClipboard, Name: "Auto-type Delay (s)", Description: "Time to focus the target window before typing",
+			Type: "number", Key: "auto_type_delay_seconds", Min: 1, Max: 60, ref: &cfg.AutoTypeDelay},
+		{Category: categoryClipboard, Name: "Sensitive Copy", Description: "Keep copies out of clipboard managers and clear after one paste",
+			Type: "toggle", Key: "sensitive_copy", ref: &cfg.SensitiveCopy},
+		{Category: categoryClipboard, Name: "Copy Command", Description: "Command that reads the text to copy on stdin, e.g. wl-copy or tmux load-buffer -",
+			Type: "text", Key: "clipboard_copy_command", ZeroLabel: "Automatic", ref: &cfg.ClipboardCopyCommand},
+		{Category: categoryClipboard, Name: "Paste Command", Description: "Command that prints the clipboard, e.g. wl-paste or tmux save-buffer -",
+			Type: "text", Key: "clipboard_paste_command", ZeroLabel: "Automatic", ref: &cfg.ClipboardPasteCommand},
+
+		{Category: categoryExport, Name: "Export Format", Description: "Default format of exported files",
+			Type: "choice", Key: "default_export_format", Options: []string{"txt", "json", "csv"}, ref: &cfg.DefaultExportFormat},
+		{Category: categoryExport, Name: "Export Directory", Description: "Where exports are written; ~ is your home directory",
+			Type: "path", Key: "default_export_path", ref: &cfg.DefaultExportPath},
+		{Category: categoryExport, Name: "Timestamp in Filename", Description: "Add the date and time to exported file names",
+			Type: "toggle", Key: "include_timestamp_in_name", ref: &cfg.IncludeTimestampInName},
+		{Category: categoryExport, Name: "Export Permissions", Description: "Who may read exported files: 0600 is only you",
+			Type: "choice", Key: "export_file_mode", Options: []string{"0600", "0640", "0644"}, ref: &cfg.ExportFileMode},
+		{Category: categoryExport, Name: "Export Encryption", Description: "Encrypt exports to your own key with age or gpg instead of writing plaintext",
+			Type: "choice", Key: "export_encryption", Options: []string{"off", "age", "gpg"}, ref: &cfg.ExportEncryption},
+		{Category: categoryExport, Name: "Export Recipient", Description: "age recipients or gpg key IDs (comma-separated) or a file of them that exports are encrypted to",
+			Type: "text", Key: "export_recipient", ZeroLabel: "None", ref: &cfg.ExportRecipient},
+
+		{Category: categoryHistory, Name: "Password History", Description: "Save generated passwords to encrypted history",
+			Type: "toggle", Key: "history_enabled", ref: &cfg.HistoryEnabled},
+		{Category: categoryHistory, Name: "Max Entries", Description: "Oldest entries are dropped beyond this many (applies on restart)",
+			Type: "number", Key: "history_max_entries", Min: 1, Max: 10000, ref: &cfg.HistoryMaxEntries},
+		{Category: categoryHistory, Name: "Dedupe Passwords", Description: "A password saved again replaces its older entry, keeping its usage and shares",
+			Type: "toggle", Key: "history_dedupe", ref: &cfg.HistoryDedupe},
+		{Category: categoryHistory, Name: "Keep (days)", Description: "Entries older than this are purged when a new one is saved; shared entries are kept",
+			Type: "number", Key: "history_retention_days", Min: 0, Max: 3650, ZeroLabel: "Forever", ref: &cfg.HistoryRetentionDays},
+		{Category: categoryHistory, Name: "Max Size (KB)", Description: "The oldest entries are dropped to keep the history file under this size",
+			Type: "number", Key: "history_max_size_kb", Min: 0, Max: 102400, ZeroLabel: "No cap", ref: &cfg.HistoryMaxSizeKB},
+		{Category: categoryHistory, Name: "Passphrase", Description: "stored keeps it in the config file; prompt asks for it and never writes it; keychain keeps it in the OS keychain; hardware is set by passman hardware-key (applies on restart)",
+			Type: "choice", Key: "history_passphrase", Options: []string{config.PassphraseStored, config.PassphrasePrompt, config.PassphraseKeychain}, ref: &cfg.HistoryPassphrase},
+		{Category: categoryHistory, Name: "Passphrase Cache (min)", Description: "Remember a prompted passphrase for this long between runs, in the kernel keyring",
+			Type: "number", Key: "passphrase_cache_minutes", Min: 0, Max: 1440, ZeroLabel: "Off", ref: &cfg.PassphraseCacheMinutes},
+		{Category: categoryHistory, Name: "Key Derivation", Description: "Change with passman migrate, which re-encrypts the history",
+			Type: "info", Key: "history_kdf", ref: &cfg.HistoryKDF},
+		{Category: categoryHistory, Name: "Sync Remote", Description: "WebDAV (https://), S3 (s3://) or git (git+) URL the encrypted history is synced with",
+			Type: "text", Key: "sync_remote", ZeroLabel: "Off", ref: &cfg.SyncRemote},
+		{Category: categoryHistory, Name: "Sync Every (min)", Description: "Sync the history in the background while passman runs; passman sync syncs by hand",
+			Type: "number", Key: "sync_interval_minutes", Min: 0, Max: 1440, ZeroLabel: "Manual", ref: &cfg.SyncIntervalMinutes},
+		{Category: categoryHistory, Name: "History Columns", Description: "Comma-separated columns of the history table: time, password, length, type, strength, description, site, username, tags",
+			Type: "text", Key: "history_columns", ref: &cfg.HistoryColumns},
+		{Category: categoryHistory, Name: "Sort History By", Description: "Order of the history table; s on the history screen cycles it",
+			Type: "choice", Key: "history_sort", Options: []string{"time", "length", "type", "strength"}, ref: &cfg.HistorySort},
+		{Category: categoryHistory, Name: "Reverse Sort", Description: "Oldest, shortest or weakest first; S on the history screen toggles it",
+			Type: "toggle", Key: "history_sort_reverse", ref: &cfg.HistorySortReverse},
+		{Category: categoryHistory, Name: "Back Up History", Description: "Write the history to one file encrypted with a backup passphrase, in the export directory",
+			Type: "action", Key: actionBackup},
+		{Category: categoryHistory, Name: "Restore Backup", Description: "Merge a backup file into the history; the current history is copied aside first",
+			Type: "action", Key: actionRestore},
+		{Category: categoryHistory, Name: "Back Up Before Clear", Description: "Clearing the history first writes a backup encrypted with the history passphrase to the export directory",
+			Type: "toggle", Key: "backup_before_clear", ref: &cfg.BackupBeforeClear},
+		{Category: categoryHistory, Name: "Clear Scratchpad After (min)", Description: "Clear the scratchpad after this many idle minutes",
+			Type: "number", Key: "scratchpad_clear_after_minutes", Min: 0, Max: 1440, ZeroLabel: "Never", ref: &cfg.ScratchpadClearAfter},
+		{Category: categoryHistory, Name: "Auto-lock (min)", Description: "Lock the screen after this many idle minutes; unlock with the history passphrase",
+			Type: "number", Key: "auto_lock_minutes", Min: 0, Max: 1440, ZeroLabel: "Never", ref: &cfg.AutoLockMinutes},
+		{Category: categoryHistory, Name: "Share Expiry (days)", Description: "Shared secrets should be rotated after this many days",
+			Type: "number", Key: "share_expiry_days", Min: 0, Max: 365, ZeroLabel: "Never", ref: &cfg.ShareExpiryDays},
+		{Category: categoryHistory, Name: "Session Undo", Description: "Generated passwords kept in memory for ctrl+z (applies on restart)",
+			Type: "number", Key: "recent_passwords", Min: 1, Max: 100, ref: &cfg.RecentPasswords},
+
+		{Category: categoryUI, Name: "Theme", Description: "Color scheme used by every screen",
+			Type: "choice", Key: "theme", Options: themes, ref: &cfg.Theme},
+		{Category: categoryUI, Name: "Show Strength Meter", Description: "Display password strength analysis",
+			Type: "toggle", Key: "show_strength_meter", ref: &cfg.ShowStrengthMeter},
+		{Category: categoryUI, Name: "Mask Passwords", Description: "Show generated secrets as dots until revealed with " + keys.Label(ActionReveal),
+			Type: "toggle", Key: "mask_passwords", ref: &cfg.MaskPasswords},
+		{Category: categoryUI, Name: "Password Grouping", Description: "Show random passwords in blocks of this many characters (display only)",
+			Type: "number", Key: "password_group_size", Min: 0, Max: 16, ZeroLabel: "Off", ref: &cfg.PasswordGroupSize},
+		{Category: categoryUI, Name: "Crack Time Attacker", Description: "Attacker assumed by the crack time under the strength meter",
+			Type: "choice", Key: "crack_attacker", Options: generator.AttackerNames(), ref: &cfg.CrackAttacker},
+		{Category: categoryUI, Name: "Hardware Doubling (years)", Description: "Show the year a password becomes crackable if attacker hardware doubles this often",
+			Type: "number", Key: "crack_doubling_years", Min: 0, Max: 20, ZeroLabel: "Off", ref: &cfg.CrackDoublingYears},
+		{Category: categoryUI, Name: "Annotate Look-alikes", Description: "Spell out confusable characters such as 0/O and 1/l/I",
+			Type: "toggle", Key: "annotate_confusables", ref: &cfg.AnnotateConfusables},
+		{Category: categoryUI, Name: "Show Generation Time", Description: "Show how long generation took",
+			Type: "toggle", Key: "show_generation_time", ref: &cfg.ShowGenerationTime},
+		{Category: categoryUI, Name: "Confirm Before Exit", Description: "Ask before quitting",
+			Type: "toggle", Key: "confirm_before_exit", ref: &cfg.ConfirmBeforeExit},
+		{Category: categoryUI, Name: "Session Footer", Description: "Show generations, clipboard countdown and running tasks at the bottom (" + keys.Label(ActionToggleFooter) + " toggles)",
+			Type: "toggle", Key: "show_footer", ref: &cfg.ShowFooter},
+		{Category: categoryUI, Name: "Notify: Auto-type", Description: "How to tell you a delayed auto-type finished",
+			Type: "choice", Key: "notifications.auto_type", Options: notifyMethods, ref: mapEntry{cfg.Notifications, utils.EventAutoType}},
+		{Category: categoryUI, Name: "Notify: Export", Description: "How to tell you a history export finished",
+			Type: "choice", Key: "notifications.export", Options: notifyMethods, ref: mapEntry{cfg.Notifications, utils.EventExport}},
+		{Category: categoryUI, Name: "Notify: Agent Quota", Description: "How to warn you that a client of passman agent or serve asked for more secrets than its quota",
+			Type: "choice", Key: "notifications.agent_quota", Options: notifyMethods, ref: mapEntry{cfg.Notifications, utils.EventAgentQuota}},
+
+		{Category: categoryAdvanced, Name: "Wordlist Update (days)", Description: "How often the cached EFF wordlist is downloaded again at startup, checked against its pinned SHA-256; 0 for only with passman wordlist update",
+			Type: "number", Key: "wordlist_update_interval_days", Min: 0, Max: 365, ZeroLabel: "Never", ref: &cfg.WordlistUpdateInterval},
+		{Category: categoryAdvanced, Name: "Agent Clients", Description: "Programs allowed on the passman agent socket, comma-separated paths or names, e.g. /usr/bin/socat,rofi (applies when the agent starts)",
+			Type: "text", Key: "agent_allowed_clients", ZeroLabel: "Any of yours", ref: &cfg.AgentAllowedClients},
+		{Category: categoryAdvanced, Name: "Agent Quota (/min)", Description: "Secrets each client of passman agent or serve may get a minute, generated or from the history (applies when the agent starts)",
+			Type: "number", Key: "agent_quota_per_minute", Min: 1, Max: 10000, ref: &cfg.AgentQuotaPerMinute},
+		{Category: categoryAdvanced, Name: "Usage Metrics", Description: "Count commands, generations and copies on this machine only, never sent anywhere (passman stats shows them)",
+			Type: "toggle", Key: "enable_telemetry", ref: &cfg.EnableTelemetry},
+		{Category: categoryAdvanced, Name: "Debug Logging", Description: "Write debug information to the log (applies on restart)",
+			Type: "toggle", Key: "debug", ref: &cfg.Debug},
 	}
 }
 
@@ -97,29 +294,92 @@ func (m *SettingsModel) Init() tea.Cmd {
 }
 
 func (m *SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.status.Update(msg) {
+		return m, nil
+	}
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, m.status.Cmd())
+}
+
+func (m *SettingsModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.scroll()
+		return m, nil
+
+	case ConfigReloadedMsg:
+		// The config file was edited outside passman
+		m.bindConfig()
+		m.status.Info("Config reloaded from file")
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return NewMenuModelWithSize(m.manager, m.width, m.height), nil
-		case "esc":
-			return NewMenuModelWithSize(m.manager, m.width, m.height), nil
-		case "up", "k":
+		if m.editing {
+			switch msg.String() {
+			case "enter":
+				m.commitEdit()
+				return m, nil
+			case "esc":
+				m.editing = false
+				m.editor.Blur()
+				m.actionInputs = nil
+				m.editor.SetValue("")
+				m.editor.EchoMode = textinput.EchoNormal
+				m.status.Info("Edit cancelled")
+				return m, nil
+			case "ctrl+c":
+				return m, navigate(MenuScreen)
+			}
+			var cmd tea.Cmd
+			m.editor, cmd = m.editor.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionBack):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionUp):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down", "j":
+			m.scroll()
+		case keys.Matches(msg, ActionDown):
 			if m.cursor < len(m.settings)-1 {
 				m.cursor++
 			}
-		case "enter", " ":
+			m.scroll()
+		case keys.Matches(msg, ActionDecrease):
+			m.stepSetting(m.cursor, -1)
+		case keys.Matches(msg, ActionIncrease):
+			m.stepSetting(m.cursor, 1)
+		case keys.Matches(msg, ActionSelect), keys.Matches(msg, ActionToggleSetting):
 			// Toggle or modify the selected setting
-			m.toggleSetting(m.cursor)
+			return m, m.toggleSetting(m.cursor)
+		}
+
+	case tea.MouseMsg:
+		if m.editing {
+			return m, nil
+		}
+		// The wheel moves the cursor and a click changes the setting under it
+		if step := mouseWheel(msg); step != 0 {
+			if cursor := m.cursor + step; cursor >= 0 && cursor < len(m.settings) {
+				m.cursor = cursor
+				m.scroll()
+			}
+		} else if mouseClicked(msg) {
+			labels := make([]string, len(m.settings))
+			for i, setting := range m.settings {
+				labels[i] = m.label(setting)
+			}
+			if i := checkboxAt(m.View(), m.height, msg, labels); i >= 0 {
+				m.cursor = i
+				return m, m.toggleSetting(i)
+			}
 		}
 	}
 
@@ -129,131 +389,462 @@ func (m *SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *SettingsModel) View() string {
 	// Title with white text like main menu
 	title := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
+		Foreground(theme.Text).
 		Bold(true).
 		Render("Settings")
 
 	subtitle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
-		Render("Use ↑/↓ to navigate, Enter to change settings")
+		Foreground(theme.Text).
+		Render(fmt.Sprintf("Use %s/%s to navigate, %s to change settings, %s/%s to adjust",
+			keys.Label(ActionUp), keys.Label(ActionDown), keys.Label(ActionSelect),
+			keys.Label(ActionDecrease), keys.Label(ActionIncrease)))
+
+	// Build the settings list like main menu, with a header per category
+	rows, _ := m.rows()
+	visible := m.visibleRows()
+	end := m.offset + visible
+	if end > len(rows) {
+		end = len(rows)
+	}
+	settingsList := strings.Join(rows[m.offset:end], "\n")
+	if m.offset > 0 {
+		settingsList = subtleStyle.Render("  ↑ more") + "\n" + settingsList
+	}
+	if end < len(rows) {
+		settingsList += "\n" + subtleStyle.Render("  ↓ more")
+	}
+
+	sections := []string{title, subtitle, settingsList}
+
+	selected := m.settings[m.cursor]
+	details := subtleStyle.Render(selected.Description + " (" + selected.Key + ")")
+	if selected.Type == "action" {
+		details = subtleStyle.Render(selected.Description)
+	}
+	if m.editing {
+		details += "\n" + m.editor.View()
+	}
+	sections = append(sections, details)
+
+	if status := m.status.View(); status != "" {
+		sections = append(sections, status)
+	}
+
+	// Helper commands like main menu
+	var help string
+	if m.editing {
+		help = subtleStyle.Render("enter: save") + dotStyle + subtleStyle.Render("esc: cancel")
+	} else {
+		help = subtleStyle.Render(keys.Label(ActionUp)+"/"+keys.Label(ActionDown)+": navigate") + dotStyle +
+			keyHelp(ActionSelect, "change") + dotStyle +
+			subtleStyle.Render(keys.Label(ActionDecrease)+"/"+keys.Label(ActionIncrease)+": adjust") + dotStyle +
+			keyHelp(ActionBack, "back") + dotStyle +
+			keyHelp(ActionQuit, "quit")
+	}
+	sections = append(sections, help)
+
+	// Apply main style like the main menu
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
 
-	// Build the settings list like main menu
-	var settingsItems []string
+// rows renders the settings list with category headers and returns the row
+// of each setting
+func (m *SettingsModel) rows() ([]string, []int) {
+	var rows []string
+	settingRows := make([]int, len(m.settings))
+	headerStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+
+	category := ""
 	for i, setting := range m.settings {
-		var valueStr string
-		switch setting.Type {
-		case "toggle":
-			if val, ok := setting.Value.(bool); ok && val {
-				valueStr = "Enabled"
-			} else {
-				valueStr = "Disabled"
+		if setting.Category != category {
+			category = setting.Category
+			if len(rows) > 0 {
+				rows = append(rows, "")
 			}
-		case "number":
-			valueStr = fmt.Sprintf("%v", setting.Value)
-		default:
-			valueStr = fmt.Sprintf("%v", setting.Value)
+			rows = append(rows, headerStyle.Render(category))
 		}
-		
-		line := fmt.Sprintf("%s: %s", setting.Name, valueStr)
-		settingsItems = append(settingsItems, checkbox(line, m.cursor == i))
+		settingRows[i] = len(rows)
+		rows = append(rows, checkbox(m.label(setting), m.cursor == i))
 	}
+	return rows, settingRows
+}
 
-	settingsList := strings.Join(settingsItems, "\n")
+// label is the text of a setting's row in the list
+func (m *SettingsModel) label(setting SettingItem) string {
+	if setting.Type == "action" {
+		return setting.Name + " …"
+	}
+	return fmt.Sprintf("%s: %s", setting.Name, m.displayValue(setting))
+}
 
-	// Helper commands like main menu
-	help := subtleStyle.Render("↑/↓: navigate") + dotStyle +
-		subtleStyle.Render("enter: change") + dotStyle +
-		subtleStyle.Render("esc: back") + dotStyle +
-		subtleStyle.Render("q: quit")
-
-	// Combine everything like main menu
-	content := fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
-		title,
-		subtitle,
-		settingsList,
-		help,
-	)
+// displayValue formats the value of a setting for the list
+func (m *SettingsModel) displayValue(setting SettingItem) string {
+	switch val := setting.Value().(type) {
+	case bool:
+		if val {
+			return "Enabled"
+		}
+		return "Disabled"
+	case int:
+		if val == 0 && setting.ZeroLabel != "" {
+			return setting.ZeroLabel
+		}
+		return strconv.Itoa(val)
+	case string:
+		if setting.Key == "theme" && (val == "" || val == "default") {
+			return AutoThemeName
+		}
+		if val == "" && setting.ZeroLabel != "" {
+			return setting.ZeroLabel
+		}
+		if val == "" {
+			return "(empty)"
+		}
+		if setting.Type == "text" {
+			return strconv.Quote(val)
+		}
+		return val
+	}
+	return ""
+}
 
-	// Apply main style like the main menu
-	return mainStyle.Render("\n" + content + "\n\n")
+// visibleRows is how many list rows fit on the screen
+func (m *SettingsModel) visibleRows() int {
+	// Title, subtitle, details, status, help and spacing take about 14 lines
+	rows := m.height - 14
+	if m.height == 0 || rows > 1000 {
+		rows = 1000
+	}
+	if rows < 5 {
+		rows = 5
+	}
+	return rows
+}
+
+// scroll keeps the selected setting, and its category header when it is
+// the first of its category, inside the visible rows
+func (m *SettingsModel) scroll() {
+	rows, settingRows := m.rows()
+	visible := m.visibleRows()
+
+	top := settingRows[m.cursor]
+	if m.cursor == 0 || m.settings[m.cursor-1].Category != m.settings[m.cursor].Category {
+		top-- // Include the header
+	}
+	if top < m.offset {
+		m.offset = top
+	}
+	if bottom := settingRows[m.cursor]; bottom >= m.offset+visible {
+		m.offset = bottom - visible + 1
+	}
+
+	if max := len(rows) - visible; m.offset > max {
+		m.offset = max
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
 }
 
-// toggleSetting handles toggling or modifying settings values
-func (m *SettingsModel) toggleSetting(index int) {
+// toggleSetting handles toggling or modifying settings values. Numbers,
+// text and paths open the editor.
+func (m *SettingsModel) toggleSetting(index int) tea.Cmd {
 	if index < 0 || index >= len(m.settings) {
-		return
+		return nil
 	}
-	
-	setting := &m.settings[index]
-	var newValue interface{}
-	
+
+	setting := m.settings[index]
 	switch setting.Type {
 	case "toggle":
-		if val, ok := setting.Value.(bool); ok {
-			newValue = !val
-			setting.Value = newValue
+		if val, ok := setting.Value().(bool); ok {
+			m.applySetting(setting, !val)
+		}
+	case "choice":
+		m.stepSetting(index, 1)
+	case "number", "text", "path":
+		m.editing = true
+		m.editor.Prompt = setting.Name + ": "
+		m.editor.SetValue(fmt.Sprintf("%v", setting.Value()))
+		m.editor.CursorEnd()
+		m.status.Clear()
+		return m.editor.Focus()
+	case "info":
+		m.status.Hint(setting.Description)
+	case "action":
+		m.actionInputs = nil
+		return m.promptAction(setting)
+	}
+	return nil
+}
+
+// stepSetting moves a number or choice one step in direction dir; toggles
+// flip whatever the direction
+func (m *SettingsModel) stepSetting(index, dir int) {
+	setting := m.settings[index]
+
+	switch setting.Type {
+	case "toggle":
+		if val, ok := setting.Value().(bool); ok {
+			m.applySetting(setting, !val)
 		}
 	case "number":
-		// For now, cycle through common values for password length
-		if setting.Key == "default_length" {
-			lengths := []int{8, 12, 16, 20, 24, 32}
-			if val, ok := setting.Value.(int); ok {
-				for i, length := range lengths {
-					if length == val {
-						newValue = lengths[(i+1)%len(lengths)]
-						setting.Value = newValue
-						break
-					}
-				}
+		val, _ := setting.Value().(int)
+		next := val + dir
+		if next < setting.Min || next > setting.Max {
+			return
+		}
+		m.applySetting(setting, next)
+	case "choice":
+		if len(setting.Options) == 0 {
+			return
+		}
+		current, _ := setting.Value().(string)
+		if setting.Key == "theme" && (current == "" || current == "default") {
+			current = AutoThemeName
+		}
+		next := 0
+		for i, option := range setting.Options {
+			if option == current {
+				next = (i + dir + len(setting.Options)) % len(setting.Options)
+				break
 			}
 		}
+		m.applySetting(setting, setting.Options[next])
 	}
-	
-	// Apply the setting change to the manager/config
-	m.applySetting(setting.Key, newValue)
 }
 
-// applySetting applies a setting change to the manager and config
-func (m *SettingsModel) applySetting(key string, value interface{}) {
-	if m.manager == nil || m.manager.Config == nil {
+// commitEdit validates the editor's value and applies it
+func (m *SettingsModel) commitEdit() {
+	setting := m.settings[m.cursor]
+	input := m.editor.Value()
+
+	var value interface{}
+	switch setting.Type {
+	case "action":
+		m.commitAction(setting, input)
 		return
+	case "number":
+		n, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || n < setting.Min || n > setting.Max {
+			m.status.Error(fmt.Sprintf("%s must be a number from %d to %d", setting.Name, setting.Min, setting.Max))
+			return
+		}
+		value = n
+	case "text":
+		if input == "" && setting.ZeroLabel == "" {
+			m.status.Error(setting.Name + " cannot be empty")
+			return
+		}
+		if setting.Key == "sync_remote" && input != "" && !config.ValidSyncRemote(input) {
+			m.status.Error("Sync remote must be an http(s)://, s3:// or git+ URL")
+			return
+		}
+		if setting.Key == "history_columns" {
+			if _, err := config.ParseHistoryColumns(input); err != nil {
+				m.status.Error("Invalid columns: " + err.Error())
+				return
+			}
+		}
+		if setting.Key == "clipboard_copy_command" || setting.Key == "clipboard_paste_command" {
+			if _, err := config.SplitCommand(input); err != nil {
+				m.status.Error("Invalid command: " + err.Error())
+				return
+			}
+		}
+		if setting.Key == "leet_substitutions" {
+			if _, err := generator.ParseLeetSubstitutions(input); err != nil {
+				m.status.Error("Invalid leet map: " + err.Error())
+				return
+			}
+		}
+		value = input
+	case "path":
+		path, err := expandPath(strings.TrimSpace(input))
+		if err != nil {
+			m.status.Error(err.Error())
+			return
+		}
+		value = path
+	default:
+		return
+	}
+
+	m.editing = false
+	m.editor.Blur()
+	m.applySetting(setting, value)
+}
+
+// Actions of the settings screen
+const (
+	actionBackup  = "backup"
+	actionRestore = "restore"
+)
+
+// actionPrompt is one input an action asks for
+type actionPrompt struct {
+	label  string
+	secret bool
+}
+
+// actionPrompts lists what an action asks for, in order
+func actionPrompts(action string) []actionPrompt {
+	switch action {
+	case actionBackup:
+		return []actionPrompt{{"Backup passphrase", true}, {"Repeat passphrase", true}}
+	case actionRestore:
+		return []actionPrompt{{"Backup file", false}, {"Backup passphrase", true}}
 	}
-	
-	// Update the config with the new value
-	switch key {
+	return nil
+}
+
+// promptAction opens the editor for the next input of an action
+func (m *SettingsModel) promptAction(setting SettingItem) tea.Cmd {
+	prompt := actionPrompts(setting.Key)[len(m.actionInputs)]
+
+	m.editing = true
+	m.editor.Prompt = prompt.label + ": "
+	m.editor.SetValue("")
+	m.editor.EchoMode = textinput.EchoNormal
+	if prompt.secret {
+		m.editor.EchoMode = textinput.EchoPassword
+	} else if setting.Key == actionRestore {
+		m.editor.SetValue(m.config.DefaultExportPath + string(filepath.Separator))
+		m.editor.CursorEnd()
+	}
+	m.status.Clear()
+	return m.editor.Focus()
+}
+
+// commitAction records an answer to an action's prompt, and runs the
+// action once every prompt is answered
+func (m *SettingsModel) commitAction(setting SettingItem, input string) {
+	if input == "" {
+		m.status.Error(strings.TrimSuffix(m.editor.Prompt, ": ") + " cannot be empty")
+		return
+	}
+	m.actionInputs = append(m.actionInputs, input)
+	if len(m.actionInputs) < len(actionPrompts(setting.Key)) {
+		m.promptAction(setting)
+		return
+	}
+
+	inputs := m.actionInputs
+	m.actionInputs = nil
+	m.editing = false
+	m.editor.SetValue("")
+	m.editor.EchoMode = textinput.EchoNormal
+	m.editor.Blur()
+
+	if m.manager == nil || m.manager.History == nil || !m.manager.History.IsEnabled() {
+		m.status.Error("History is disabled")
+		return
+	}
+
+	switch setting.Key {
+	case actionBackup:
+		if inputs[0] != inputs[1] {
+			m.status.Error("The passphrases don't match; nothing was written")
+			return
+		}
+		path := m.config.GetExportPath(utils.DefaultBackupName(time.Now()))
+		info, err := m.manager.History.ExportBackup(path, inputs[0])
+		if err != nil {
+			m.status.Error("Backup failed: " + err.Error())
+			return
+		}
+		m.status.Success(fmt.Sprintf("Backed up %d entries to %s", info.Entries, info.Path))
+	case actionRestore:
+		path, err := expandPath(strings.TrimSpace(inputs[0]))
+		if err != nil {
+			m.status.Error(err.Error())
+			return
+		}
+		report, err := m.manager.History.ImportBackup(path, inputs[1], false)
+		if err != nil {
+			m.status.Error("Restore failed: " + err.Error())
+			return
+		}
+		m.status.Success(report.String())
+	}
+}
+
+// expandPath cleans a directory path typed in the editor, expanding ~
+func expandPath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path cannot be empty")
+	}
+	// Windows users may type ~\ as well as ~/
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~`+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot expand ~: %w", err)
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	return filepath.Clean(path), nil
+}
+
+// applySetting stores a setting change in the config, applies it to the
+// running utilities and saves the config
+func (m *SettingsModel) applySetting(setting SettingItem, value interface{}) {
+	// The passphrase in use goes to the keychain before the config file
+	// stops keeping it, or the history would not open on the next start
+	if setting.Key == "history_passphrase" && value == config.PassphraseKeychain &&
+		m.manager != nil && m.manager.Config != nil && m.config.HistoryEncryptionKey != "" {
+		if err := utils.StoreKeychainPassphrase(m.config.HistoryEncryptionKey); err != nil {
+			m.status.Error("Not changed: " + err.Error())
+			return
+		}
+	}
+
+	setting.set(value)
+
+	switch setting.Key {
+	case "theme":
+		// The theme applies immediately, even without a config to save it to
+		if val, ok := value.(string); ok {
+			_ = SetTheme(val)
+		}
 	case "history_enabled":
-		if val, ok := value.(bool); ok {
-			m.manager.Config.HistoryEnabled = val
-			// Also update the history manager
-			if m.manager.History != nil {
-				m.manager.History.SetEnabled(val)
-				// Ensure passphrase is set when enabling history
-				if val && m.manager.Config.HistoryEncryptionKey != "" {
-					m.manager.History.SetPassphrase(m.manager.Config.HistoryEncryptionKey)
-				} else if val {
-					// Set a default passphrase if none exists
-					m.manager.Config.HistoryEncryptionKey = "default-encryption-key"
-					m.manager.History.SetPassphrase(m.manager.Config.HistoryEncryptionKey)
-				}
+		if val, ok := value.(bool); ok && m.manager != nil && m.manager.History != nil {
+			m.manager.History.SetEnabled(val)
+			// Ensure passphrase is set when enabling history
+			if val && m.config.HistoryEncryptionKey != "" {
+				m.manager.History.SetPassphrase(m.config.HistoryEncryptionKey)
+			} else if val {
+				// Set a default passphrase if none exists
+				m.config.HistoryEncryptionKey = "default-encryption-key"
+				m.manager.History.SetPassphrase(m.config.HistoryEncryptionKey)
 			}
 		}
-	case "auto_copy_to_clipboard":
-		if val, ok := value.(bool); ok {
-			m.manager.Config.AutoCopyToClipboard = val
+	case "sensitive_copy":
+		if val, ok := value.(bool); ok && m.manager != nil && m.manager.Clipboard != nil {
+			m.manager.Clipboard.SetSensitive(val)
 		}
-	case "default_length":
-		if val, ok := value.(int); ok {
-			m.manager.Config.DefaultLength = val
+	case "clipboard_copy_command", "clipboard_paste_command":
+		if m.manager != nil && m.manager.Clipboard != nil {
+			_ = m.manager.Clipboard.SetCommands(m.config.ClipboardCopyCommand, m.config.ClipboardPasteCommand)
 		}
-	case "show_strength_meter":
-		if val, ok := value.(bool); ok {
-			m.manager.Config.ShowStrengthMeter = val
+	case "export_file_mode", "export_encryption", "export_recipient":
+		if m.manager != nil && m.manager.Export != nil {
+			m.manager.Export.SetFileMode(m.config.ExportMode())
+			m.manager.Export.SetEncryption(m.config.ExportEncryption, m.config.ExportRecipient)
 		}
+	case "notifications.auto_type", "notifications.export":
+		if m.manager != nil {
+			m.manager.Notifier = utils.NewNotifier(m.config.Notifications)
+		}
+	}
+
+	if m.manager == nil || m.manager.Config == nil {
+		m.status.Info(setting.Name + " changed for this session")
+		return
 	}
-	
+
 	// Save the updated config to file
-	if err := m.manager.Config.Save(); err != nil {
-		// If save fails, we could show an error message in the future
-		// For now, changes are still applied in memory
+	if err := m.config.Save(); err != nil {
+		m.status.Error("Changed, but not saved: " + err.Error())
+		return
 	}
+	m.status.Success(setting.Name + " saved")
 }
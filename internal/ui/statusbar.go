@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// StatusLevel is how a status message is shown and how long it stays
+type StatusLevel int
+
+const (
+	StatusInfo StatusLevel = iota
+	StatusSuccess
+	StatusError
+)
+
+// statusTimeouts is how long a message of each level stays on screen.
+// Errors stay longest so there is time to read them.
+var statusTimeouts = map[StatusLevel]time.Duration{
+	StatusInfo:    3 * time.Second,
+	StatusSuccess: 2 * time.Second,
+	StatusError:   5 * time.Second,
+}
+
+// statusSeq numbers status messages across every screen, so a clear
+// scheduled for one message never removes a newer one
+var statusSeq int
+
+// statusClearMsg clears the status message it was scheduled for
+type statusClearMsg struct {
+	id int
+}
+
+// StatusBar is the status line shared by the screens. Setting a message
+// schedules its clear; the screen picks the command up with Cmd at the
+// end of its Update and passes messages through Update.
+type StatusBar struct {
+	text    string
+	level   StatusLevel
+	id      int
+	sticky  bool // Stays until replaced, for hints and work in progress
+	pending bool // Set since the last Cmd
+}
+
+// Info shows a neutral message, such as a changed filter
+func (s *StatusBar) Info(text string) {
+	s.set(StatusInfo, text, false)
+}
+
+// Success shows that something worked
+func (s *StatusBar) Success(text string) {
+	s.set(StatusSuccess, text, false)
+}
+
+// Error shows that something failed
+func (s *StatusBar) Error(text string) {
+	s.set(StatusError, text, false)
+}
+
+// Result shows text as an error if err is set, and as a success otherwise
+func (s *StatusBar) Result(text string, err error) {
+	if err != nil {
+		s.Error(text)
+		return
+	}
+	s.Success(text)
+}
+
+// Hint shows a neutral message that stays until another replaces it, for
+// instructions and work in progress
+func (s *StatusBar) Hint(text string) {
+	s.set(StatusInfo, text, true)
+}
+
+// Clear removes the message
+func (s *StatusBar) Clear() {
+	s.text = ""
+	s.pending = false
+}
+
+// Text returns the message shown, or "" if there is none
+func (s StatusBar) Text() string {
+	return s.text
+}
+
+// Level returns the level of the message shown
+func (s StatusBar) Level() StatusLevel {
+	return s.level
+}
+
+func (s *StatusBar) set(level StatusLevel, text string, sticky bool) {
+	statusSeq++
+	s.id = statusSeq
+	s.text = text
+	s.level = level
+	s.sticky = sticky
+	s.pending = !sticky && text != ""
+}
+
+// Cmd returns the command that clears a message set since the last call,
+// or nil if there is none
+func (s *StatusBar) Cmd() tea.Cmd {
+	if !s.pending {
+		return nil
+	}
+	s.pending = false
+	id := s.id
+	return tea.Tick(statusTimeouts[s.level], func(time.Time) tea.Msg {
+		return statusClearMsg{id: id}
+	})
+}
+
+// Update clears the message when its time is up. It reports whether msg
+// was a clear, which the screen need not handle further.
+func (s *StatusBar) Update(msg tea.Msg) bool {
+	clear, ok := msg.(statusClearMsg)
+	if !ok {
+		return false
+	}
+	if clear.id == s.id && !s.sticky {
+		s.text = ""
+	}
+	return true
+}
+
+// View renders the message in the color of its level: successes in the
+// theme's strong-password color and errors in its warning color
+func (s StatusBar) View() string {
+	if s.text == "" {
+		return ""
+	}
+	style := lipgloss.NewStyle().Foreground(theme.Text)
+	switch s.level {
+	case StatusSuccess:
+		style = style.Foreground(lipgloss.Color(theme.StrengthColor(generator.VeryStrong)))
+	case StatusError:
+		style = style.Foreground(theme.Warning).Bold(true)
+	}
+	return style.Render(s.text)
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// syncTickMsg starts a background sync of the history
+type syncTickMsg struct{}
+
+// syncDoneMsg reports a finished background sync
+type syncDoneMsg struct {
+	report *utils.SyncReport
+	err    error
+}
+
+// syncInterval returns how often the history is synced in the background,
+// or false if it isn't
+func syncInterval(manager *utils.Manager) (time.Duration, bool) {
+	if manager == nil || manager.Config == nil || manager.History == nil || !manager.History.IsEnabled() {
+		return 0, false
+	}
+	cfg := manager.Config
+	if cfg.SyncRemote == "" || cfg.SyncIntervalMinutes <= 0 {
+		return 0, false
+	}
+	return time.Duration(cfg.SyncIntervalMinutes) * time.Minute, true
+}
+
+// scheduleSync schedules the next background sync
+func scheduleSync(manager *utils.Manager) tea.Cmd {
+	interval, ok := syncInterval(manager)
+	if !ok {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return syncTickMsg{}
+	})
+}
+
+// syncHistory syncs the history in the background, shown as a running
+// task in the footer
+func syncHistory(manager *utils.Manager) tea.Cmd {
+	done := startTask(manager, "sync")
+	return func() tea.Msg {
+		defer done()
+		report, err := manager.SyncHistory(context.Background())
+		return syncDoneMsg{report: report, err: err}
+	}
+}
@@ -0,0 +1,301 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// DefaultThemeName is the theme used on dark terminals when the theme is auto
+const DefaultThemeName = "neon"
+
+// AutoThemeName picks a theme from NO_COLOR and the terminal background
+const AutoThemeName = "auto"
+
+// Theme holds every color used by the UI
+type Theme struct {
+	Name       string
+	Text       lipgloss.Color // Titles, body text and highlighted borders
+	Subtle     lipgloss.Color // Help lines and secondary text
+	Dim        lipgloss.Color // Separators between help items
+	Accent     lipgloss.Color // Checkboxes and the selected menu item
+	Border     lipgloss.Color // Unfocused borders
+	SelectedFg lipgloss.Color // Selected table row text
+	SelectedBg lipgloss.Color // Selected table row background
+	Spinner    lipgloss.Color // Generation spinner
+	Warning    lipgloss.Color // Security warnings
+
+	// Character class colors for generated secrets, so that look-alikes
+	// such as O/0 and l/1 stand apart. Empty entries use Text.
+	Upper  lipgloss.Color
+	Lower  lipgloss.Color
+	Digit  lipgloss.Color
+	Symbol lipgloss.Color
+
+	// Strength holds the gauge color for each security level, from very
+	// weak to very strong. Empty entries use the generator's defaults.
+	Strength [6]lipgloss.Color
+
+	// NoColor disables every color, including the strength gauge
+	NoColor bool
+}
+
+// themes is the registry of built-in themes, in display order
+var themes = []Theme{
+	{
+		Name:       "neon",
+		Text:       "15",
+		Subtle:     "241",
+		Dim:        "236",
+		Accent:     "212",
+		Border:     "240",
+		SelectedFg: "229",
+		SelectedBg: "57",
+		Spinner:    "#FF10F0",
+		Warning:    "196",
+		Upper:      "81",
+		Lower:      "15",
+		Digit:      "214",
+		Symbol:     "212",
+	},
+	{
+		Name:       "solarized",
+		Text:       "#eee8d5",
+		Subtle:     "#839496",
+		Dim:        "#586e75",
+		Accent:     "#268bd2",
+		Border:     "#586e75",
+		SelectedFg: "#fdf6e3",
+		SelectedBg: "#073642",
+		Spinner:    "#b58900",
+		Warning:    "#dc322f",
+		Upper:      "#268bd2",
+		Lower:      "#eee8d5",
+		Digit:      "#b58900",
+		Symbol:     "#d33682",
+		Strength:   [6]lipgloss.Color{"#dc322f", "#cb4b16", "#b58900", "#2aa198", "#859900", "#859900"},
+	},
+	{
+		Name:       "dracula",
+		Text:       "#f8f8f2",
+		Subtle:     "#6272a4",
+		Dim:        "#44475a",
+		Accent:     "#ff79c6",
+		Border:     "#6272a4",
+		SelectedFg: "#f8f8f2",
+		SelectedBg: "#44475a",
+		Spinner:    "#bd93f9",
+		Warning:    "#ff5555",
+		Upper:      "#8be9fd",
+		Lower:      "#f8f8f2",
+		Digit:      "#ffb86c",
+		Symbol:     "#ff79c6",
+		Strength:   [6]lipgloss.Color{"#ff5555", "#ffb86c", "#f1fa8c", "#8be9fd", "#50fa7b", "#50fa7b"},
+	},
+	{
+		Name:       "monochrome",
+		Text:       "252",
+		Subtle:     "245",
+		Dim:        "240",
+		Accent:     "255",
+		Border:     "242",
+		SelectedFg: "232",
+		SelectedBg: "250",
+		Spinner:    "250",
+		Warning:    "255",
+		Upper:      "255",
+		Lower:      "248",
+		Digit:      "252",
+		Symbol:     "244",
+		Strength:   [6]lipgloss.Color{"240", "243", "246", "249", "252", "255"},
+	},
+	{
+		Name:       "high-contrast",
+		Text:       "15",
+		Subtle:     "15",
+		Dim:        "15",
+		Accent:     "11",
+		Border:     "15",
+		SelectedFg: "0",
+		SelectedBg: "11",
+		Spinner:    "11",
+		Warning:    "9",
+		Upper:      "14",
+		Lower:      "15",
+		Digit:      "11",
+		Symbol:     "13",
+		Strength:   [6]lipgloss.Color{"9", "9", "11", "11", "10", "10"},
+	},
+	{
+		Name:       "light",
+		Text:       "235",
+		Subtle:     "243",
+		Dim:        "250",
+		Accent:     "162",
+		Border:     "245",
+		SelectedFg: "231",
+		SelectedBg: "62",
+		Spinner:    "162",
+		Warning:    "160",
+		Upper:      "25",
+		Lower:      "235",
+		Digit:      "130",
+		Symbol:     "125",
+		Strength:   [6]lipgloss.Color{"160", "166", "136", "31", "28", "22"},
+	},
+	{
+		// Empty colors render as plain text
+		Name:    "no-color",
+		NoColor: true,
+	},
+}
+
+// theme is the active theme
+var theme = themes[0]
+
+// ThemeNames lists the built-in themes in display order
+func ThemeNames() []string {
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// LookupTheme finds a built-in theme by name. "auto", "default" and an
+// empty name detect a suitable theme for the terminal.
+func LookupTheme(name string) (Theme, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "default" || name == AutoThemeName {
+		name = detectThemeName()
+	}
+	for _, t := range themes {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+}
+
+// SetTheme makes the named theme active. Screens pick up the new colors the
+// next time they render.
+func SetTheme(name string) error {
+	t, err := LookupTheme(name)
+	if err != nil {
+		return err
+	}
+	theme = t
+	applyTheme()
+	return nil
+}
+
+// CurrentTheme returns the active theme
+func CurrentTheme() Theme {
+	return theme
+}
+
+// StrengthColor returns the gauge color for a security level
+func (t Theme) StrengthColor(level generator.SecurityLevel) string {
+	if t.NoColor {
+		return ""
+	}
+	if int(level) >= 0 && int(level) < len(t.Strength) && t.Strength[level] != "" {
+		return string(t.Strength[level])
+	}
+	return generator.GetSecurityLevelColor(level)
+}
+
+// entropyWarningView renders a low-entropy warning with its suggestions,
+// or an empty string if the generator is safe
+func entropyWarningView(gen generator.Generator) string {
+	w := generator.CheckEntropy(gen)
+	if w == nil {
+		return ""
+	}
+
+	warning := lipgloss.NewStyle().
+		Foreground(theme.Warning).
+		Bold(true).
+		Render("⚠ " + w.String())
+	for _, suggestion := range w.Suggestions {
+		warning += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render("  • "+suggestion)
+	}
+	return warning
+}
+
+// colorizeSecret renders a secret with each character colored by its class.
+// Newlines are kept so wrapped secrets stay wrapped.
+func colorizeSecret(secret string) string {
+	styles := map[string]lipgloss.Style{}
+	style := func(c lipgloss.Color) lipgloss.Style {
+		if c == "" {
+			c = theme.Text
+		}
+		key := string(c)
+		if st, ok := styles[key]; ok {
+			return st
+		}
+		st := lipgloss.NewStyle().Foreground(c).Bold(true)
+		styles[key] = st
+		return st
+	}
+
+	var b strings.Builder
+	for _, r := range secret {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteRune(r)
+		case unicode.IsUpper(r):
+			b.WriteString(style(theme.Upper).Render(string(r)))
+		case unicode.IsLower(r):
+			b.WriteString(style(theme.Lower).Render(string(r)))
+		case unicode.IsDigit(r):
+			b.WriteString(style(theme.Digit).Render(string(r)))
+		default:
+			b.WriteString(style(theme.Symbol).Render(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// detectThemeName picks a theme for the terminal. A non-empty NO_COLOR
+// disables colors (see https://no-color.org); otherwise light backgrounds
+// get the light theme, since white text is unreadable on them.
+func detectThemeName() string {
+	if os.Getenv("NO_COLOR") != "" {
+		return "no-color"
+	}
+	if isLightBackground() {
+		return "light"
+	}
+	return DefaultThemeName
+}
+
+// isLightBackground reports whether the terminal background is light. The
+// COLORFGBG variable set by many terminals is checked first, as it needs no
+// round trip to the terminal.
+func isLightBackground() bool {
+	if fgbg := os.Getenv("COLORFGBG"); fgbg != "" {
+		parts := strings.Split(fgbg, ";")
+		if bg, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+			// Colors 7 and 9-15 are the light half of the 16-color palette
+			return bg == 7 || (bg >= 9 && bg <= 15)
+		}
+	}
+	return !lipgloss.HasDarkBackground()
+}
+
+// applyTheme rebuilds the shared styles from the active theme
+func applyTheme() {
+	checkboxStyle = lipgloss.NewStyle().Foreground(theme.Accent)
+	subtleStyle = lipgloss.NewStyle().Foreground(theme.Subtle)
+	dotStyle = lipgloss.NewStyle().Foreground(theme.Dim).Render(dotChar)
+	baseStyle = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(theme.Border)
+}
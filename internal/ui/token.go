@@ -0,0 +1,338 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// TokenModel represents the UUID/token/API key generation screen
+type TokenModel struct {
+	width         int
+	height        int
+	manager       *utils.Manager
+	formats       []generator.TokenFormat
+	formatIndex   int
+	lengthInput   textinput.Model
+	prefixInput   textinput.Model
+	alphabetInput textinput.Model
+	token         string
+	entropy       float64
+	historyID     string
+	masked        bool
+	statusMsg     string
+}
+
+// NewTokenModel creates a new token model
+func NewTokenModel(manager *utils.Manager) *TokenModel {
+	lengthInput := textinput.New()
+	lengthInput.Placeholder = strconv.Itoa(generator.DefaultTokenLength)
+	lengthInput.SetValue(strconv.Itoa(generator.DefaultTokenLength))
+	lengthInput.CharLimit = 4
+	lengthInput.Width = 10
+
+	prefixInput := textinput.New()
+	prefixInput.Placeholder = "e.g. pk_live_"
+	prefixInput.CharLimit = 32
+	prefixInput.Width = 20
+
+	alphabetInput := textinput.New()
+	alphabetInput.Placeholder = "A-Z a-z 0-9"
+	alphabetInput.CharLimit = 128
+	alphabetInput.Width = 30
+
+	return &TokenModel{
+		manager:       manager,
+		formats:       generator.TokenFormats(),
+		lengthInput:   lengthInput,
+		prefixInput:   prefixInput,
+		alphabetInput: alphabetInput,
+		masked:        masksPasswords(manager),
+	}
+}
+
+// NewTokenModelWithSize creates a new token model with specified dimensions
+func NewTokenModelWithSize(manager *utils.Manager, width, height int) *TokenModel {
+	model := NewTokenModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+func (m *TokenModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *TokenModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		// While editing an option, only navigation keys are handled here
+		if input := m.focusedInput(); input != nil {
+			switch msg.String() {
+			case "ctrl+c", "esc":
+				input.Blur()
+				return m, nil
+			case "tab", "enter":
+				return m, m.cycleFocus()
+			}
+			var cmd tea.Cmd
+			*input, cmd = input.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit), keys.Matches(msg, ActionBack):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionScratchpad):
+			return m, navigate(ScratchpadScreen)
+		case keys.Matches(msg, ActionFocus):
+			return m, m.cycleFocus()
+		case keys.Matches(msg, ActionTokenFormat):
+			m.formatIndex = (m.formatIndex + 1) % len(m.formats)
+			m.token = ""
+			m.statusMsg = "Format: " + m.format().String()
+		case keys.Matches(msg, ActionGenerate):
+			m.generateToken()
+		case keys.Matches(msg, ActionCopy):
+			if usesAutoType(m.manager) {
+				return m, m.typeToken()
+			}
+			m.copyToken()
+		case keys.Matches(msg, ActionAutoType):
+			return m, m.typeToken()
+		case keys.Matches(msg, ActionReveal):
+			m.masked = !m.masked
+			if !m.masked && m.token != "" {
+				recordUsage(m.manager, m.historyID, utils.UsageRevealed)
+			}
+		}
+
+	case autoTypeDoneMsg:
+		m.statusMsg = autoTypeStatus(m.manager, msg)
+	}
+
+	return m, nil
+}
+
+func (m *TokenModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("🔑 Generate Token / API Key")
+
+	textStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	var formatItems []string
+	for i, format := range m.formats {
+		formatItems = append(formatItems, checkbox(format.String(), i == m.formatIndex))
+	}
+
+	options := "Format (f to change):\n" + strings.Join(formatItems, "\n")
+	if m.format() != generator.TokenUUID {
+		options += "\n\nLength:   " + m.lengthInput.View()
+	}
+	if m.format() == generator.TokenAPIKey {
+		options += "\nPrefix:   " + m.prefixInput.View() +
+			"\nAlphabet: " + m.alphabetInput.View()
+	}
+
+	if banner := insecureSeedBanner(); banner != "" {
+		title += "\n" + banner
+	}
+
+	sections := []string{title, textStyle.Render(options)}
+
+	if m.token == "" {
+		sections = append(sections, subtleStyle.Render("Press "+keys.Label(ActionGenerate)+" to generate a token"))
+	} else {
+		output := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(theme.Text).
+			Padding(0, 1).
+			Foreground(theme.Text).
+			Bold(true).
+			Render(renderSecret(maskSecret(m.token, m.masked), m.masked))
+		sections = append(sections, output, textStyle.Render(fmt.Sprintf("Entropy: %.1f bits", m.entropy)))
+		if gen, err := m.newGenerator(); err == nil {
+			if warning := entropyWarningView(gen); warning != "" {
+				sections = append(sections, warning)
+			}
+		}
+	}
+
+	if m.statusMsg != "" {
+		sections = append(sections, textStyle.Render(m.statusMsg))
+	}
+
+	help := keyHelp(ActionGenerate, "generate") + dotStyle +
+		keyHelp(ActionTokenFormat, "format") + dotStyle +
+		keyHelp(ActionFocus, "edit options") + dotStyle +
+		keyHelp(ActionCopy, "copy") + dotStyle +
+		keyHelp(ActionAutoType, "type") + dotStyle +
+		keyHelp(ActionReveal, "reveal") + dotStyle +
+		keyHelp(ActionBack, "back")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// newGenerator builds a token generator from the current options
+func (m *TokenModel) newGenerator() (*generator.TokenGenerator, error) {
+	length := 0
+	if m.format() != generator.TokenUUID {
+		value := strings.TrimSpace(m.lengthInput.Value())
+		if value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid length %q", value)
+			}
+			length = parsed
+		}
+	}
+
+	gen := generator.NewTokenGenerator(m.format(), length)
+	if m.format() == generator.TokenAPIKey {
+		gen.SetPrefix(strings.TrimSpace(m.prefixInput.Value()))
+		gen.SetAlphabet(m.alphabetInput.Value())
+	}
+	return gen, nil
+}
+
+// generateToken creates a new token and records it in history
+func (m *TokenModel) generateToken() {
+	gen, err := m.newGenerator()
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return
+	}
+
+	token, err := gen.Generate(context.Background())
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return
+	}
+
+	m.token = token
+	m.entropy = gen.EstimateEntropy()
+	m.statusMsg = "Token generated!"
+	countGeneration(m.manager, "token")
+	m.historyID = ""
+
+	if m.manager != nil && m.manager.History != nil && m.manager.History.IsEnabled() {
+		entry := utils.HistoryEntry{
+			ID:       m.manager.History.NewID(),
+			Password: token,
+			Length:   len(token),
+			Type:     "token",
+			Settings: m.buildSettingsString(),
+		}
+		if err := m.manager.History.AddEntry(entry); err != nil {
+			m.statusMsg = "Token generated! (History save failed)"
+		} else {
+			m.historyID = entry.ID
+		}
+	}
+}
+
+// copyToken copies the current token to the clipboard
+func (m *TokenModel) copyToken() {
+	if m.token == "" {
+		m.statusMsg = "No token to copy. Generate one first!"
+		return
+	}
+
+	if m.manager == nil || m.manager.Clipboard == nil {
+		m.statusMsg = "Clipboard not available"
+		return
+	}
+
+	if err := m.manager.Clipboard.Copy(m.token); err != nil {
+		m.statusMsg = "Failed to copy to clipboard: " + err.Error()
+		return
+	}
+	m.statusMsg = "Token copied to clipboard!"
+	recordUsage(m.manager, m.historyID, utils.UsageCopied)
+}
+
+// typeToken types the current token into the focused window after a delay
+func (m *TokenModel) typeToken() tea.Cmd {
+	if m.token == "" {
+		m.statusMsg = "No token to type. Generate one first!"
+		return nil
+	}
+	cmd, status := startAutoType(m.manager, m.token, m.historyID)
+	m.statusMsg = status
+	return cmd
+}
+
+// buildSettingsString creates a string representation of current settings
+func (m *TokenModel) buildSettingsString() string {
+	switch m.format() {
+	case generator.TokenUUID:
+		return "Format: " + m.format().String()
+	case generator.TokenAPIKey:
+		return fmt.Sprintf("Format: %s, Length: %s, Prefix: %s",
+			m.format().String(), m.lengthInput.Value(), strings.TrimSpace(m.prefixInput.Value()))
+	default:
+		return fmt.Sprintf("Format: %s, Length: %s", m.format().String(), m.lengthInput.Value())
+	}
+}
+
+// format returns the selected token format
+func (m *TokenModel) format() generator.TokenFormat {
+	return m.formats[m.formatIndex]
+}
+
+// editableInputs returns the inputs that apply to the selected format
+func (m *TokenModel) editableInputs() []*textinput.Model {
+	switch m.format() {
+	case generator.TokenUUID:
+		return nil
+	case generator.TokenAPIKey:
+		return []*textinput.Model{&m.lengthInput, &m.prefixInput, &m.alphabetInput}
+	default:
+		return []*textinput.Model{&m.lengthInput}
+	}
+}
+
+// focusedInput returns the input currently being edited, if any
+func (m *TokenModel) focusedInput() *textinput.Model {
+	for _, input := range m.editableInputs() {
+		if input.Focused() {
+			return input
+		}
+	}
+	return nil
+}
+
+// cycleFocus moves focus through the editable inputs and then back to none
+func (m *TokenModel) cycleFocus() tea.Cmd {
+	inputs := m.editableInputs()
+	for i, input := range inputs {
+		if input.Focused() {
+			input.Blur()
+			if i+1 < len(inputs) {
+				return inputs[i+1].Focus()
+			}
+			return nil
+		}
+	}
+
+	if len(inputs) > 0 {
+		return inputs[0].Focus()
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/skip2/go-qrcode"
+
+	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+type totpTickMsg time.Time
+
+// TOTPModel represents the TOTP secret generation screen
+type TOTPModel struct {
+	width        int
+	height       int
+	manager      *utils.Manager
+	issuerInput  textinput.Model
+	accountInput textinput.Model
+	secret       string
+	code         string
+	remaining    int
+	showQR       bool
+	invertQR     bool
+	historyID    string
+	statusMsg    string
+}
+
+// NewTOTPModel creates a new TOTP model
+func NewTOTPModel(manager *utils.Manager) *TOTPModel {
+	issuerInput := textinput.New()
+	issuerInput.Placeholder = "Issuer (e.g. Gitea)"
+	issuerInput.CharLimit = 64
+	issuerInput.Width = 30
+
+	accountInput := textinput.New()
+	accountInput.Placeholder = "Account (e.g. admin@example.com)"
+	accountInput.CharLimit = 128
+	accountInput.Width = 30
+
+	return &TOTPModel{
+		manager:      manager,
+		issuerInput:  issuerInput,
+		accountInput: accountInput,
+		showQR:       true,
+	}
+}
+
+// NewTOTPModelWithSize creates a new TOTP model with specified dimensions
+func NewTOTPModelWithSize(manager *utils.Manager, width, height int) *TOTPModel {
+	model := NewTOTPModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+func (m *TOTPModel) Init() tea.Cmd {
+	return m.tick()
+}
+
+func (m *TOTPModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case totpTickMsg:
+		m.refreshCode(time.Time(msg))
+		return m, m.tick()
+
+	case tea.KeyMsg:
+		// While editing a label, only navigation keys are handled here
+		if m.issuerInput.Focused() || m.accountInput.Focused() {
+			switch msg.String() {
+			case "ctrl+c", "esc":
+				m.issuerInput.Blur()
+				m.accountInput.Blur()
+				return m, nil
+			case "tab", "enter":
+				return m, m.cycleFocus()
+			}
+			return m, m.updateInputs(msg)
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit), keys.Matches(msg, ActionBack):
+			return m, navigate(MenuScreen)
+		case keys.Matches(msg, ActionFocus):
+			return m, m.cycleFocus()
+		case keys.Matches(msg, ActionGenerate):
+			m.generateSecret()
+		case keys.Matches(msg, ActionCopy):
+			m.copyValue(m.secret, "Secret")
+		case keys.Matches(msg, ActionCopyCode):
+			m.copyValue(m.code, "Current code")
+		case keys.Matches(msg, ActionShowQR):
+			m.showQR = !m.showQR
+		case keys.Matches(msg, ActionInvertQR):
+			m.invertQR = !m.invertQR
+		}
+	}
+
+	return m, nil
+}
+
+func (m *TOTPModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("⏱  Generate TOTP Secret")
+
+	textStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	labels := textStyle.Render(fmt.Sprintf("Issuer:  %s\nAccount: %s",
+		m.issuerInput.View(), m.accountInput.View()))
+
+	if banner := insecureSeedBanner(); banner != "" {
+		title += "\n" + banner
+	}
+
+	sections := []string{title, labels}
+
+	if m.secret == "" {
+		sections = append(sections, subtleStyle.Render("Press "+keys.Label(ActionGenerate)+" to generate a new secret"))
+	} else {
+		details := fmt.Sprintf("Secret: %s\nCode:   %s (%ds left)\n\n%s",
+			groupString(m.secret, 4, " "), m.code, m.remaining, subtleStyle.Render(m.uri()))
+		sections = append(sections, textStyle.Render(details))
+
+		if m.showQR {
+			qr, err := renderQR(m.uri(), m.invertQR)
+			if err != nil {
+				sections = append(sections, textStyle.Render("QR code unavailable: "+err.Error()))
+			} else {
+				sections = append(sections, qr)
+			}
+		}
+	}
+
+	if m.statusMsg != "" {
+		sections = append(sections, textStyle.Render(m.statusMsg))
+	}
+
+	help := keyHelp(ActionGenerate, "generate") + dotStyle +
+		keyHelp(ActionFocus, "edit labels") + dotStyle +
+		keyHelp(ActionCopy, "copy secret") + dotStyle +
+		keyHelp(ActionCopyCode, "copy code") + dotStyle +
+		subtleStyle.Render(keys.Label(ActionShowQR)+"/"+keys.Label(ActionInvertQR)+": QR on/invert") + dotStyle +
+		keyHelp(ActionBack, "back")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// generateSecret creates a new secret and records it in history
+func (m *TOTPModel) generateSecret() {
+	gen := generator.NewTOTPGenerator(generator.DefaultTOTPSecretBytes)
+	secret, err := gen.Generate(context.Background())
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return
+	}
+
+	m.secret = secret
+	m.refreshCode(time.Now())
+	m.statusMsg = "TOTP secret generated!"
+	countGeneration(m.manager, "totp")
+	m.historyID = ""
+
+	if m.manager != nil && m.manager.History != nil && m.manager.History.IsEnabled() {
+		entry := utils.HistoryEntry{
+			ID:          m.manager.History.NewID(),
+			Password:    secret,
+			Length:      len(secret),
+			Type:        "totp",
+			Settings:    fmt.Sprintf("Digits: %d, Period: %ds", generator.DefaultTOTPDigits, generator.DefaultTOTPPeriod),
+			Description: strings.TrimSpace("TOTP secret " + m.label()),
+		}
+		if err := m.manager.History.AddEntry(entry); err != nil {
+			m.statusMsg = "TOTP secret generated! (History save failed)"
+		} else {
+			m.historyID = entry.ID
+		}
+	}
+}
+
+// refreshCode recomputes the current one-time code
+func (m *TOTPModel) refreshCode(now time.Time) {
+	if m.secret == "" {
+		return
+	}
+
+	code, err := generator.TOTPCode(m.secret, now, generator.DefaultTOTPDigits, generator.DefaultTOTPPeriod)
+	if err != nil {
+		m.code = "error"
+		return
+	}
+	m.code = code
+	m.remaining = generator.TOTPRemaining(now, generator.DefaultTOTPPeriod)
+}
+
+// copyValue copies a value to the clipboard and reports the result
+func (m *TOTPModel) copyValue(value, name string) {
+	if value == "" {
+		m.statusMsg = "Nothing to copy. Generate a secret first!"
+		return
+	}
+
+	if m.manager == nil || m.manager.Clipboard == nil {
+		m.statusMsg = "Clipboard not available"
+		return
+	}
+
+	if err := m.manager.Clipboard.Copy(value); err != nil {
+		m.statusMsg = "Failed to copy to clipboard: " + err.Error()
+		return
+	}
+	m.statusMsg = name + " copied to clipboard!"
+	if value == m.secret {
+		recordUsage(m.manager, m.historyID, utils.UsageCopied)
+	}
+}
+
+// uri returns the otpauth URI for the current secret and labels
+func (m *TOTPModel) uri() string {
+	account := strings.TrimSpace(m.accountInput.Value())
+	if account == "" {
+		account = "passman"
+	}
+	return generator.TOTPURI(m.secret, strings.TrimSpace(m.issuerInput.Value()), account)
+}
+
+// label returns a human-readable issuer:account label
+func (m *TOTPModel) label() string {
+	issuer := strings.TrimSpace(m.issuerInput.Value())
+	account := strings.TrimSpace(m.accountInput.Value())
+	switch {
+	case issuer != "" && account != "":
+		return issuer + ":" + account
+	case issuer != "":
+		return issuer
+	default:
+		return account
+	}
+}
+
+// cycleFocus moves focus issuer -> account -> none
+func (m *TOTPModel) cycleFocus() tea.Cmd {
+	switch {
+	case m.issuerInput.Focused():
+		m.issuerInput.Blur()
+		return m.accountInput.Focus()
+	case m.accountInput.Focused():
+		m.accountInput.Blur()
+		return nil
+	default:
+		return m.issuerInput.Focus()
+	}
+}
+
+// updateInputs forwards a message to the focused label input
+func (m *TOTPModel) updateInputs(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	if m.issuerInput.Focused() {
+		m.issuerInput, cmd = m.issuerInput.Update(msg)
+	} else if m.accountInput.Focused() {
+		m.accountInput, cmd = m.accountInput.Update(msg)
+	}
+	return cmd
+}
+
+func (m *TOTPModel) tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return totpTickMsg(t)
+	})
+}
+
+// renderQR renders content as a QR code using half-block characters
+func renderQR(content string, inverse bool) (string, error) {
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+	return code.ToSmallString(inverse), nil
+}
+
+// groupString splits s into groups of size n joined by sep
+func groupString(s string, n int, sep string) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+
+	var groups []string
+	for i := 0; i < len(s); i += n {
+		end := i + n
+		if end > len(s) {
+			end = len(s)
+		}
+		groups = append(groups, s[i:end])
+	}
+	return strings.Join(groups, sep)
+}
@@ -0,0 +1,236 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// tutorialStep describes one step of the guided tutorial
+type tutorialStep struct {
+	Title       string
+	Instruction string
+	Action      Action // Action the user must perform to complete the step
+	Done        string // Status shown after completing the step
+}
+
+// TutorialModel walks new users through the main features using simulated prompts.
+// Nothing done in the tutorial touches the clipboard, history or config.
+type TutorialModel struct {
+	width     int
+	height    int
+	manager   *utils.Manager
+	steps     []tutorialStep
+	current   int
+	completed []bool
+	sample    string
+	statusMsg string
+}
+
+// NewTutorialModel creates a new tutorial model
+func NewTutorialModel(manager *utils.Manager) *TutorialModel {
+	steps := []tutorialStep{
+		{
+			Title:       "Generate a random password",
+			Instruction: fmt.Sprintf("Random passwords mix letters, numbers and symbols.\nIn the generator screen, press %s to create one.", keys.Label(ActionGenerate)),
+			Action:      ActionGenerate,
+			Done:        "Random password generated!",
+		},
+		{
+			Title:       "Generate a memorable passphrase",
+			Instruction: fmt.Sprintf("Passphrases join random words from a wordlist.\nPress %s to pick a wordlist, then %s to generate.", keys.Label(ActionNextWordlist), keys.Label(ActionGenerate)),
+			Action:      ActionGenerate,
+			Done:        "Passphrase generated!",
+		},
+		{
+			Title:       "Generate a PIN code",
+			Instruction: fmt.Sprintf("PINs are random digits for phones and cards.\nPress %s to generate one.", keys.Label(ActionGenerate)),
+			Action:      ActionGenerate,
+			Done:        "PIN generated!",
+		},
+		{
+			Title:       "Copy to the clipboard",
+			Instruction: fmt.Sprintf("After generating, press %s to copy the result.\n(The tutorial does not touch your real clipboard.)", keys.Label(ActionCopy)),
+			Action:      ActionCopy,
+			Done:        "Password copied to clipboard! (simulated)",
+		},
+		{
+			Title:       "View your history",
+			Instruction: fmt.Sprintf("Generated passwords are kept in encrypted history.\nSelect View Password History from the menu and press %s.", keys.Label(ActionSelect)),
+			Action:      ActionSelect,
+			Done:        "Opened password history (simulated)",
+		},
+		{
+			Title:       "Change a setting",
+			Instruction: fmt.Sprintf("Settings are changed with %s on the settings screen.\nPress %s to toggle Auto Copy to Clipboard.", keys.Label(ActionSelect), keys.Label(ActionSelect)),
+			Action:      ActionSelect,
+			Done:        "Auto Copy to Clipboard: Disabled (simulated)",
+		},
+	}
+
+	return &TutorialModel{
+		manager:   manager,
+		steps:     steps,
+		completed: make([]bool, len(steps)),
+	}
+}
+
+// NewTutorialModelWithSize creates a new tutorial model with specified dimensions
+func NewTutorialModelWithSize(manager *utils.Manager, width, height int) *TutorialModel {
+	model := NewTutorialModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+func (m *TutorialModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *TutorialModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		key := msg.String()
+		if key == "ctrl+c" || keys.Matches(msg, ActionQuit) || keys.Matches(msg, ActionBack) {
+			return m, navigate(MenuScreen)
+		}
+		switch key {
+		case "right", "tab":
+			m.advance()
+			return m, nil
+		case "left", "shift+tab":
+			if m.current > 0 {
+				m.current--
+				m.sample = ""
+				m.statusMsg = ""
+			}
+			return m, nil
+		}
+
+		if m.finished() {
+			if key == "enter" {
+				return m, navigate(MenuScreen)
+			}
+			return m, nil
+		}
+
+		step := m.steps[m.current]
+		if keys.Matches(msg, step.Action) {
+			m.completed[m.current] = true
+			m.sample = m.simulate(m.current)
+			m.statusMsg = step.Done
+			m.current++
+		} else {
+			m.statusMsg = fmt.Sprintf("Try pressing %s", keys.Label(step.Action))
+		}
+	}
+
+	return m, nil
+}
+
+func (m *TutorialModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("Tutorial")
+
+	// Step list with the current step highlighted
+	var stepItems []string
+	for i, step := range m.steps {
+		label := fmt.Sprintf("%d. %s", i+1, step.Title)
+		switch {
+		case i == m.current:
+			stepItems = append(stepItems, checkboxStyle.Render("▸ "+label))
+		case m.completed[i]:
+			stepItems = append(stepItems, subtleStyle.Render("✓ "+label))
+		default:
+			stepItems = append(stepItems, "  "+label)
+		}
+	}
+	stepList := strings.Join(stepItems, "\n")
+
+	var body string
+	if m.finished() {
+		body = "You're all set! Press enter to return to the menu."
+	} else {
+		step := m.steps[m.current]
+		prompt := checkboxStyle.Render(fmt.Sprintf("› press %s", keys.Label(step.Action)))
+		body = step.Instruction + "\n\n" + prompt
+	}
+
+	if m.sample != "" {
+		body += "\n\n" + lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(theme.Border).
+			Padding(0, 1).
+			Render(m.sample)
+	}
+
+	bodyStyle := lipgloss.NewStyle().Foreground(theme.Text)
+
+	sections := []string{title, stepList, bodyStyle.Render(body)}
+	if m.statusMsg != "" {
+		sections = append(sections, bodyStyle.Render(m.statusMsg))
+	}
+
+	help := subtleStyle.Render("←/→: previous/skip") + dotStyle +
+		keyHelp(ActionBack, "exit tutorial")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// advance skips to the next step without completing the current one
+func (m *TutorialModel) advance() {
+	if !m.finished() {
+		m.current++
+		m.sample = ""
+		m.statusMsg = ""
+	}
+}
+
+// finished reports whether all steps have been passed
+func (m *TutorialModel) finished() bool {
+	return m.current >= len(m.steps)
+}
+
+// simulate produces the mock screen output shown after completing a step
+func (m *TutorialModel) simulate(step int) string {
+	ctx := context.Background()
+
+	var gen generator.Generator
+	switch step {
+	case 0:
+		gen = generator.NewRandomGenerator(16, generator.Lowercase, generator.Uppercase, generator.Numbers, generator.Symbols)
+	case 1:
+		gen = generator.NewMemorableGenerator(4, " ", generator.GetEFFWordlist())
+	case 2:
+		gen = generator.NewPINGenerator(6)
+	case 3:
+		return "📋 Copied"
+	case 4:
+		return "Time         Password          Type\n" +
+			"Jan 2 15:04  correct horse...  Memorable\n" +
+			"Jan 2 15:03  k9#Tq2!xLm...     Random"
+	case 5:
+		return checkbox("Auto Copy to Clipboard: Disabled", true)
+	default:
+		return ""
+	}
+
+	sample, err := gen.Generate(ctx)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return sample
+}
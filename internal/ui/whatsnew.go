@@ -0,0 +1,133 @@
+package ui
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+//go:embed CHANGELOG.md
+var changelog string
+
+// WhatsNewModel shows the changes in the latest release
+type WhatsNewModel struct {
+	width    int
+	height   int
+	manager  *utils.Manager
+	version  string
+	notes    string
+	viewport viewport.Model
+}
+
+// NewWhatsNewModel creates a new what's new model for the latest changelog entry
+func NewWhatsNewModel(manager *utils.Manager) *WhatsNewModel {
+	version, notes := latestChangelogEntry(changelog)
+
+	vp := viewport.New(60, 12)
+	vp.SetContent(notes)
+
+	return &WhatsNewModel{
+		manager:  manager,
+		version:  version,
+		notes:    notes,
+		viewport: vp,
+	}
+}
+
+// NewWhatsNewModelWithSize creates a new what's new model with specified dimensions
+func NewWhatsNewModelWithSize(manager *utils.Manager, width, height int) *WhatsNewModel {
+	model := NewWhatsNewModel(manager)
+	model.resize(width, height)
+	return model
+}
+
+func (m *WhatsNewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *WhatsNewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case msg.String() == "ctrl+c", keys.Matches(msg, ActionQuit), keys.Matches(msg, ActionBack),
+			keys.Matches(msg, ActionSelect):
+			return m, navigate(MenuScreen)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *WhatsNewModel) View() string {
+	titleText := "What's New"
+	if m.version != "" {
+		titleText = fmt.Sprintf("What's new in %s", m.version)
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render(titleText)
+
+	notes := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Render(m.viewport.View())
+
+	help := subtleStyle.Render("↑/↓: scroll") + dotStyle +
+		subtleStyle.Render(keys.Label(ActionSelect)+"/"+keys.Label(ActionBack)+": dismiss")
+
+	content := fmt.Sprintf("%s\n\n%s\n\n%s", title, notes, help)
+
+	return mainStyle.Render("\n" + content + "\n\n")
+}
+
+// resize fits the viewport to the terminal dimensions
+func (m *WhatsNewModel) resize(width, height int) {
+	m.width = width
+	m.height = height
+
+	vpWidth := width - 4
+	if vpWidth < 20 {
+		vpWidth = 20
+	}
+	vpHeight := height - 8
+	if vpHeight < 5 {
+		vpHeight = 5
+	}
+
+	m.viewport.Width = vpWidth
+	m.viewport.Height = vpHeight
+	m.viewport.SetContent(m.notes)
+}
+
+// latestChangelogEntry returns the version and notes of the first changelog section
+func latestChangelogEntry(text string) (string, string) {
+	var version string
+	var lines []string
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			if version != "" {
+				break
+			}
+			version = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			continue
+		}
+		if version != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return version, strings.TrimSpace(strings.Join(lines, "\n"))
+}
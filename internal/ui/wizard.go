@@ -0,0 +1,435 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/utils"
+)
+
+// minPassphraseLength is the shortest history passphrase the wizard accepts
+const minPassphraseLength = 8
+
+// Wizard steps, in order
+const (
+	wizardHistory = iota
+	wizardPassphrase
+	wizardGenerator
+	wizardClipboard
+	wizardTheme
+	wizardSummary
+)
+
+// wizardQuestions are the steps answered by choosing an option
+var wizardQuestions = []int{wizardHistory, wizardGenerator, wizardClipboard, wizardTheme}
+
+// wizardOption is one answer to a wizard question
+type wizardOption struct {
+	label       string
+	description string
+	apply       func(cfg *config.Config)
+}
+
+// WizardModel sets passman up on first launch: history and its
+// passphrase, the default generator, clipboard behavior and theme. The
+// config file is only written when the wizard finishes.
+type WizardModel struct {
+	width     int
+	height    int
+	manager   *utils.Manager
+	step      int
+	options   map[int][]wizardOption
+	choices   map[int]int // Chosen option of each step
+	saved     bool        // Config written, waiting for it to be applied
+	statusMsg string
+
+	passphrase   textinput.Model
+	confirmation textinput.Model
+}
+
+// NewWizardModel creates the first-run setup wizard
+func NewWizardModel(manager *utils.Manager) *WizardModel {
+	newInput := func(placeholder string) textinput.Model {
+		input := textinput.New()
+		input.Placeholder = placeholder
+		input.EchoMode = textinput.EchoPassword
+		input.EchoCharacter = '•'
+		input.Width = 40
+		return input
+	}
+
+	return &WizardModel{
+		manager:      manager,
+		options:      wizardOptions(),
+		choices:      make(map[int]int),
+		passphrase:   newInput("passphrase"),
+		confirmation: newInput("repeat the passphrase"),
+	}
+}
+
+// NewWizardModelWithSize creates the setup wizard with specified dimensions
+func NewWizardModelWithSize(manager *utils.Manager, width, height int) *WizardModel {
+	model := NewWizardModel(manager)
+	model.width = width
+	model.height = height
+	return model
+}
+
+// wizardOptions lists the answers of each question step
+func wizardOptions() map[int][]wizardOption {
+	var themeOptions []wizardOption
+	for _, name := range append([]string{AutoThemeName}, ThemeNames()...) {
+		name := name
+		description := ""
+		if name == AutoThemeName {
+			description = "Match the terminal, and respect NO_COLOR"
+		}
+		themeOptions = append(themeOptions, wizardOption{name, description, func(cfg *config.Config) {
+			cfg.Theme = name
+		}})
+	}
+
+	var generatorOptions []wizardOption
+	for _, reg := range generator.Registered() {
+		name := reg.Name
+		generatorOptions = append(generatorOptions, wizardOption{reg.Title, reg.Description, func(cfg *config.Config) {
+			cfg.DefaultGenerator = name
+		}})
+	}
+
+	return map[int][]wizardOption{
+		wizardHistory: {
+			{"Keep an encrypted history", "Generated passwords are saved, encrypted with a passphrase you choose next",
+				func(cfg *config.Config) { cfg.HistoryEnabled = true }},
+			{"Don't keep a history", "Nothing you generate is written to disk",
+				func(cfg *config.Config) {
+					cfg.HistoryEnabled = false
+					cfg.HistoryEncryptionKey = ""
+				}},
+		},
+		wizardGenerator: generatorOptions,
+		wizardClipboard: {
+			{"Copy, and clear the clipboard after 30 seconds", "",
+				func(cfg *config.Config) {
+					cfg.AutoCopyToClipboard = true
+					cfg.ClearClipboardAfter = 30
+				}},
+			{"Copy, hidden from clipboard managers", "Cleared after one paste or 30 seconds",
+				func(cfg *config.Config) {
+					cfg.AutoCopyToClipboard = true
+					cfg.ClearClipboardAfter = 30
+					cfg.SensitiveCopy = true
+				}},
+			{"Copy, and leave it on the clipboard", "",
+				func(cfg *config.Config) {
+					cfg.AutoCopyToClipboard = true
+					cfg.ClearClipboardAfter = 0
+				}},
+			{"Only copy when I press " + keys.Label(ActionCopy), "Cleared after 30 seconds",
+				func(cfg *config.Config) {
+					cfg.AutoCopyToClipboard = false
+					cfg.ClearClipboardAfter = 30
+				}},
+		},
+		wizardTheme: themeOptions,
+	}
+}
+
+func (m *WizardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *WizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case ConfigReloadedMsg:
+		// The manager now has the new config, so the menu starts on the
+		// chosen generator
+		if m.saved {
+			return m, navigate(MenuScreen)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		// Leaving without finishing writes nothing, so the wizard runs again
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.saved {
+			return m, nil
+		}
+		if m.step == wizardPassphrase {
+			return m.updatePassphrase(msg)
+		}
+
+		switch {
+		case keys.Matches(msg, ActionBack):
+			if m.step == wizardHistory {
+				// Skipping keeps the defaults, without a history
+				m.choices[wizardHistory] = 1
+				return m, m.finish([]int{wizardHistory})
+			}
+			m.previousStep()
+		case keys.Matches(msg, ActionUp):
+			m.moveCursor(-1)
+		case keys.Matches(msg, ActionDown):
+			m.moveCursor(1)
+		case keys.Matches(msg, ActionSelect):
+			if m.step == wizardSummary {
+				return m, m.finish(wizardQuestions)
+			}
+			m.nextStep()
+		}
+	}
+
+	return m, nil
+}
+
+// updatePassphrase handles the passphrase step, which uses fixed keys
+// like every text input
+func (m *WizardModel) updatePassphrase(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.previousStep()
+		return m, nil
+	case "tab", "shift+tab", "up", "down":
+		m.focusPassphrase(!m.passphrase.Focused())
+		return m, nil
+	case "enter":
+		if m.passphrase.Focused() {
+			if m.checkPassphrase(false) {
+				m.focusPassphrase(false)
+			}
+			return m, nil
+		}
+		if m.checkPassphrase(true) {
+			m.nextStep()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.passphrase.Focused() {
+		m.passphrase, cmd = m.passphrase.Update(msg)
+	} else {
+		m.confirmation, cmd = m.confirmation.Update(msg)
+	}
+	m.statusMsg = ""
+	return m, cmd
+}
+
+// checkPassphrase validates the passphrase and, if confirmed is set, that
+// the confirmation matches it
+func (m *WizardModel) checkPassphrase(confirmed bool) bool {
+	switch {
+	case len([]rune(m.passphrase.Value())) < minPassphraseLength:
+		m.statusMsg = fmt.Sprintf("Use at least %d characters", minPassphraseLength)
+		m.focusPassphrase(true)
+		return false
+	case confirmed && m.confirmation.Value() != m.passphrase.Value():
+		m.statusMsg = "The passphrases don't match"
+		m.confirmation.Reset()
+		return false
+	}
+	m.statusMsg = ""
+	return true
+}
+
+// focusPassphrase focuses the passphrase input, or the confirmation
+func (m *WizardModel) focusPassphrase(first bool) {
+	if first {
+		m.passphrase.Focus()
+		m.confirmation.Blur()
+	} else {
+		m.passphrase.Blur()
+		m.confirmation.Focus()
+	}
+}
+
+// historyEnabled reports whether the user chose to keep a history
+func (m *WizardModel) historyEnabled() bool {
+	return m.choices[wizardHistory] == 0
+}
+
+// nextStep moves on, asking for a passphrase only for a history
+func (m *WizardModel) nextStep() {
+	m.step++
+	if m.step == wizardPassphrase && !m.historyEnabled() {
+		m.step++
+	}
+	m.enterStep()
+}
+
+// previousStep goes back one question, keeping the answers given so far
+func (m *WizardModel) previousStep() {
+	m.step--
+	if m.step == wizardPassphrase && !m.historyEnabled() {
+		m.step--
+	}
+	m.enterStep()
+}
+
+// enterStep prepares the inputs of the current step
+func (m *WizardModel) enterStep() {
+	m.statusMsg = ""
+	if m.step == wizardPassphrase {
+		m.focusPassphrase(true)
+		return
+	}
+	m.passphrase.Blur()
+	m.confirmation.Blur()
+	if m.step == wizardTheme {
+		m.previewTheme()
+	}
+}
+
+// moveCursor changes the chosen option of the current step
+func (m *WizardModel) moveCursor(delta int) {
+	options := m.options[m.step]
+	choice := m.choices[m.step] + delta
+	if choice < 0 || choice >= len(options) {
+		return
+	}
+	m.choices[m.step] = choice
+	if m.step == wizardTheme {
+		m.previewTheme()
+	}
+}
+
+// previewTheme applies the highlighted theme so the wizard shows it
+func (m *WizardModel) previewTheme() {
+	_ = SetTheme(m.options[wizardTheme][m.choices[wizardTheme]].label)
+}
+
+// buildConfig applies the answers of steps to the current config
+func (m *WizardModel) buildConfig(steps []int) config.Config {
+	cfg := config.Default()
+	if m.manager != nil && m.manager.Config != nil {
+		cfg = *m.manager.Config
+	}
+
+	for _, step := range steps {
+		m.options[step][m.choices[step]].apply(&cfg)
+	}
+	if m.historyEnabled() {
+		cfg.HistoryEncryptionKey = m.passphrase.Value()
+	}
+	cfg.Validate()
+	return cfg
+}
+
+// finish writes the config with the answers of steps and applies it
+// through the same path as a config edited on disk
+func (m *WizardModel) finish(steps []int) tea.Cmd {
+	cfg := m.buildConfig(steps)
+	if err := cfg.Save(); err != nil {
+		m.statusMsg = "Cannot save the config: " + err.Error()
+		return nil
+	}
+
+	m.saved = true
+	return func() tea.Msg {
+		return ConfigReloadedMsg{Config: cfg}
+	}
+}
+
+func (m *WizardModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Render("👋 Welcome to passman")
+
+	questions := map[int]string{
+		wizardHistory:    "Should passman keep a history of what you generate?",
+		wizardPassphrase: "Choose the passphrase that encrypts your history",
+		wizardGenerator:  "Which generator do you use most?",
+		wizardClipboard:  "What should happen when you generate a password?",
+		wizardTheme:      "Pick a color theme",
+		wizardSummary:    "Ready to save these settings?",
+	}
+	question := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Render(fmt.Sprintf("Step %d of %d: %s", m.step+1, wizardSummary+1, questions[m.step]))
+
+	sections := []string{title, question}
+
+	switch m.step {
+	case wizardPassphrase:
+		sections = append(sections,
+			"Passphrase: "+m.passphrase.View()+"\n"+
+				"Confirm:    "+m.confirmation.View(),
+			subtleStyle.Render("It can't be recovered; without it the history can't be read.\n"+
+				"It is stored in the config file, readable only by you."))
+	case wizardSummary:
+		sections = append(sections, m.summaryView())
+	default:
+		var items []string
+		for i, option := range m.options[m.step] {
+			item := checkbox(option.label, m.choices[m.step] == i)
+			if option.description != "" && m.choices[m.step] == i {
+				item += "\n    " + subtleStyle.Render(option.description)
+			}
+			items = append(items, item)
+		}
+		sections = append(sections, strings.Join(items, "\n"))
+	}
+
+	if m.statusMsg != "" {
+		sections = append(sections, lipgloss.NewStyle().Foreground(theme.Accent).Render(m.statusMsg))
+	}
+
+	var help string
+	switch m.step {
+	case wizardPassphrase:
+		help = subtleStyle.Render("tab: switch field") + dotStyle +
+			subtleStyle.Render("enter: continue") + dotStyle +
+			subtleStyle.Render("esc: back")
+	case wizardHistory:
+		help = subtleStyle.Render(keys.Label(ActionUp)+"/"+keys.Label(ActionDown)+": choose") + dotStyle +
+			keyHelp(ActionSelect, "continue") + dotStyle +
+			keyHelp(ActionBack, "skip setup")
+	case wizardSummary:
+		help = keyHelp(ActionSelect, "save and start") + dotStyle +
+			keyHelp(ActionBack, "back")
+	default:
+		help = subtleStyle.Render(keys.Label(ActionUp)+"/"+keys.Label(ActionDown)+": choose") + dotStyle +
+			keyHelp(ActionSelect, "continue") + dotStyle +
+			keyHelp(ActionBack, "back")
+	}
+	help += dotStyle + subtleStyle.Render("ctrl+c: quit without saving")
+	sections = append(sections, help)
+
+	return mainStyle.Render("\n" + strings.Join(sections, "\n\n") + "\n\n")
+}
+
+// summaryView lists the chosen answers
+func (m *WizardModel) summaryView() string {
+	history := m.options[wizardHistory][m.choices[wizardHistory]].label
+	if m.historyEnabled() {
+		history += ", passphrase set"
+	}
+
+	path, err := config.GetConfigPath()
+	if err != nil {
+		path = "the config file"
+	}
+
+	rows := []string{
+		"History:   " + history,
+		"Generator: " + m.options[wizardGenerator][m.choices[wizardGenerator]].label,
+		"Clipboard: " + m.options[wizardClipboard][m.choices[wizardClipboard]].label,
+		"Theme:     " + m.options[wizardTheme][m.choices[wizardTheme]].label,
+		"",
+		subtleStyle.Render("Saved to " + path + "; change anything later in Settings."),
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,553 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// Agent methods
+const (
+	AgentPing     = "ping"
+	AgentGenerate = "generate"
+	AgentAnalyze  = "analyze"
+	AgentHistory  = "history"
+)
+
+// maxAgentRequest limits the size of a single request line
+const maxAgentRequest = 1 << 20
+
+// revealDelay is how long the reveals of history requests wait to be
+// written together, so a client searching repeatedly doesn't rewrite the
+// encrypted history for every request
+const revealDelay = 10 * time.Second
+
+// AgentRequest is one request to the agent. Requests and responses are
+// JSON objects, one per line.
+type AgentRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"` // Echoed in the response
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// AgentResponse answers a request with either a result or an error
+type AgentResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+
+	overQuota bool // The request was refused for the client's quota
+}
+
+// agentGenerateParams are the options of a job file task, plus whether to
+// save the secrets to the history
+type agentGenerateParams struct {
+	JobTask
+	Save bool `json:"save"`
+}
+
+// agentGenerateResult lists the generated secrets
+type agentGenerateResult struct {
+	Secrets []string `json:"secrets"`
+	Entropy float64  `json:"entropy"`
+}
+
+// agentAnalyzeParams holds the password to analyze
+type agentAnalyzeParams struct {
+	Password string `json:"password"`
+}
+
+// agentAnalyzeResult is the strength analysis of a password
+type agentAnalyzeResult struct {
+	Entropy     float64          `json:"entropy"`
+	Level       string           `json:"level"`
+	CrackTime   string           `json:"crack_time"` // For the configured attacker model
+	CrackTimes  []agentCrackTime `json:"crack_times"`
+	Segments    []agentSegment   `json:"segments"`
+	Feedback    []string         `json:"feedback,omitempty"`
+	Compromised bool             `json:"compromised"`
+}
+
+// agentSegment is a pattern or random part of an analyzed password; start
+// and end count characters, end exclusive
+type agentSegment struct {
+	Text    string  `json:"text"`
+	Start   int     `json:"start"`
+	End     int     `json:"end"`
+	Kind    string  `json:"kind"`
+	Match   string  `json:"match,omitempty"`
+	Entropy float64 `json:"entropy"`
+}
+
+// agentCrackTime is the crack time of a password for one attacker model
+type agentCrackTime struct {
+	Attacker         string  `json:"attacker"`
+	Description      string  `json:"description"`
+	GuessesPerSecond float64 `json:"guesses_per_second"`
+	Time             string  `json:"time"`
+}
+
+// agentHistoryParams filters the history
+type agentHistoryParams struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"` // 0 = all
+}
+
+// Agent serves generate, analyze and history requests on a Unix socket,
+// so other local tools don't pay passman's startup cost or decrypt the
+// history for every request. The decrypted history is kept in memory and
+// reread only when the file changes. Its policy limits which programs
+// may connect and how many secrets each may get. The secrets it hands
+// out are recorded as reveals in batches; FlushReveals writes any left.
+type Agent struct {
+	history  *HistoryManager
+	version  string
+	analyzer *generator.SecurityAnalyzer
+	policy   AgentPolicy
+	quota    *agentQuota
+
+	mu       sync.Mutex // Guards the history and the cache
+	entries  []HistoryEntry
+	loadedAt time.Time // Modification time of the cached history file
+
+	revealMu    sync.Mutex  // Guards the pending reveals
+	reveals     []string    // IDs of the entries revealed since the last write
+	revealTimer *time.Timer // Writes the pending reveals after revealDelay
+}
+
+// NewAgent creates an agent serving the given history
+func NewAgent(history *HistoryManager, version string) *Agent {
+	return &Agent{history: history, version: version, analyzer: generator.NewSecurityAnalyzer()}
+}
+
+// SetPolicy sets which programs may use the socket and the quota of
+// secrets each client may get. An allowlist the platform can't enforce is
+// refused, leaving the policy as it was.
+func (a *Agent) SetPolicy(policy AgentPolicy) error {
+	if err := policy.check(); err != nil {
+		return err
+	}
+	a.policy = policy
+	a.quota = newAgentQuota(policy.QuotaPerMinute)
+	return nil
+}
+
+// SetCrackAttacker chooses the attacker model, by name, that the
+// crack_time of analyze results is estimated for
+func (a *Agent) SetCrackAttacker(name string) error {
+	return a.analyzer.SetAttacker(name)
+}
+
+// AgentSocketPath returns where the agent of the active profile listens:
+// in $XDG_RUNTIME_DIR when it is set, otherwise in the data directory
+func AgentSocketPath() (string, error) {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "passman", config.ActiveProfile()+".sock"), nil
+	}
+	paths, err := config.ResolvePaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(paths.Data, "agent.sock"), nil
+}
+
+// ListenAgent creates the agent socket, accessible only to the current
+// user: its directory is created owner-only and the socket itself is
+// 0600. A socket left behind by an agent that is no longer running is
+// replaced. Closing the listener removes the socket.
+func ListenAgent(socketPath string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("an agent is already listening on %s", socketPath)
+	}
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Serve answers requests on listener until ctx is cancelled, then closes
+// it and writes the reveals still pending
+func (a *Agent) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		if err := a.FlushReveals(); err != nil {
+			log.Printf("passman agent: %v", err)
+		}
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn answers the requests of one connection in order, if the
+// program that connected may use the agent
+func (a *Agent) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	client, err := a.admit(conn)
+	if err != nil {
+		log.Printf("passman agent: refused a connection: %v", err)
+		_ = json.NewEncoder(conn).Encode(AgentResponse{Error: "not allowed: " + err.Error()})
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxAgentRequest)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req AgentRequest
+		var resp AgentResponse
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			resp.Error = "invalid request: " + err.Error()
+		} else {
+			resp = a.Handle(ctx, client, req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// admit checks who connected against the policy: the process must run as
+// the agent's user and, with an allowlist, be one of its programs. It
+// returns the name the client's quota and log lines go by.
+func (a *Agent) admit(conn net.Conn) (string, error) {
+	peer, err := peerCredentials(conn)
+	if errors.Is(err, errNoPeerCredentials) {
+		if len(a.policy.AllowedClients) > 0 {
+			return "", fmt.Errorf("agent_allowed_clients is set, but %w", err)
+		}
+		return "socket client", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if peer.UID != os.Getuid() {
+		return "", fmt.Errorf("%s runs as uid %d, not as the agent's user", peer, peer.UID)
+	}
+	if !a.policy.allows(peer.Exe) {
+		return "", fmt.Errorf("%s is not in agent_allowed_clients", peer)
+	}
+	// Quotas go by program, so a client can't start afresh by reconnecting
+	if peer.Exe != "" {
+		return peer.Exe, nil
+	}
+	return peer.String(), nil
+}
+
+// Handle answers a single request from client, the name its quota goes by
+func (a *Agent) Handle(ctx context.Context, client string, req AgentRequest) AgentResponse {
+	result, err := a.dispatch(ctx, client, req)
+	resp := AgentResponse{ID: req.ID, Result: result}
+	if err != nil {
+		resp.Result = nil
+		resp.Error = err.Error()
+		resp.overQuota = errors.Is(err, errQuotaExceeded)
+	}
+	return resp
+}
+
+// dispatch runs the method of a request
+func (a *Agent) dispatch(ctx context.Context, client string, req AgentRequest) (interface{}, error) {
+	switch req.Method {
+	case AgentPing:
+		return map[string]interface{}{"version": a.version, "pid": os.Getpid()}, nil
+
+	case AgentGenerate:
+		var params agentGenerateParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return a.generate(ctx, client, params)
+
+	case AgentAnalyze:
+		var params agentAnalyzeParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.Password == "" {
+			return nil, fmt.Errorf("password is required")
+		}
+		analysis := a.analyzer.Analyze(params.Password)
+		result := agentAnalyzeResult{
+			Entropy:     analysis.Entropy,
+			Level:       generator.SecurityLevelToString(analysis.Level),
+			CrackTime:   analysis.CrackTime,
+			Feedback:    analysis.Feedback,
+			Compromised: analysis.IsCompromised,
+		}
+		for _, estimate := range analysis.CrackTimes {
+			result.CrackTimes = append(result.CrackTimes, agentCrackTime{
+				Attacker:         estimate.Attacker.Name,
+				Description:      estimate.Attacker.Description,
+				GuessesPerSecond: estimate.Attacker.GuessesPerSecond,
+				Time:             estimate.Time,
+			})
+		}
+		for _, seg := range analysis.Segments {
+			result.Segments = append(result.Segments, agentSegment{
+				Text:    seg.Text,
+				Start:   seg.Start,
+				End:     seg.End,
+				Kind:    string(seg.Kind),
+				Match:   seg.Match,
+				Entropy: seg.Entropy,
+			})
+		}
+		return result, nil
+
+	case AgentHistory:
+		var params agentHistoryParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return a.searchHistory(client, params)
+
+	case "":
+		return nil, fmt.Errorf("method is required")
+	default:
+		return nil, fmt.Errorf("unknown method %q (use ping, generate, analyze or history)", req.Method)
+	}
+}
+
+// decodeParams decodes request parameters, rejecting unknown ones
+func decodeParams(raw json.RawMessage, params interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
+
+// generate creates count secrets with the task's generator, saving them
+// to the history if asked to. They count against client's quota.
+func (a *Agent) generate(ctx context.Context, client string, params agentGenerateParams) (interface{}, error) {
+	count := params.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > maxTaskCount {
+		return nil, fmt.Errorf("count must be between 1 and %d", maxTaskCount)
+	}
+
+	gen, err := params.BuildGenerator()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.spend(client, count); err != nil {
+		return nil, err
+	}
+
+	result := agentGenerateResult{Entropy: gen.EstimateEntropy()}
+	for i := 0; i < count; i++ {
+		secret, err := gen.Generate(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("generation failed: %w", err)
+		}
+		result.Secrets = append(result.Secrets, secret)
+	}
+
+	if params.Save {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for _, secret := range result.Secrets {
+			entry := HistoryEntry{
+				Password:    secret,
+				Length:      len([]rune(secret)),
+				Type:        strings.ToLower(params.Type),
+				Description: params.Description,
+			}
+			if err := a.history.AddEntry(entry); err != nil {
+				return nil, fmt.Errorf("failed to save to history: %w", err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// searchHistory returns the matching history entries, newest first. They
+// count against client's quota, and each is recorded as revealed in its
+// audit trail.
+func (a *Agent) searchHistory(client string, params agentHistoryParams) (interface{}, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries, err := a.cachedHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []HistoryEntry{}
+	for _, entry := range entries {
+		if params.Query != "" && !a.history.matchesQuery(entry, params.Query) {
+			continue
+		}
+		matches = append(matches, entry)
+		if params.Limit > 0 && len(matches) == params.Limit {
+			break
+		}
+	}
+
+	if err := a.spend(client, len(matches)); err != nil {
+		return nil, err
+	}
+	// Only entries handing out a secret count as revealed
+	var ids []string
+	for _, entry := range matches {
+		if entry.Password != "" {
+			ids = append(ids, entry.ID)
+		}
+	}
+	a.queueReveals(ids)
+	return matches, nil
+}
+
+// queueReveals records the reveals of the entries with ids at the next
+// write of the pending reveals, revealDelay from the first one queued
+func (a *Agent) queueReveals(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	a.revealMu.Lock()
+	defer a.revealMu.Unlock()
+
+	a.reveals = append(a.reveals, ids...)
+	if a.revealTimer == nil {
+		a.revealTimer = time.AfterFunc(revealDelay, func() {
+			if err := a.FlushReveals(); err != nil {
+				log.Printf("passman agent: %v", err)
+			}
+		})
+	}
+}
+
+// FlushReveals writes the reveals still waiting to be recorded, for
+// before the agent exits
+func (a *Agent) FlushReveals() error {
+	a.revealMu.Lock()
+	ids := a.reveals
+	a.reveals = nil
+	if a.revealTimer != nil {
+		a.revealTimer.Stop()
+		a.revealTimer = nil
+	}
+	a.revealMu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.history.recordReveals(ids); err != nil {
+		return fmt.Errorf("failed to record the reveals of %d entries: %w", len(ids), err)
+	}
+	return nil
+}
+
+// Entries returns the decrypted history, newest first, reread only if
+// the file changed since it was last read
+func (a *Agent) Entries() ([]HistoryEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cachedHistory()
+}
+
+// recordReveal adds a reveal to the audit trail of the entry with id
+func (a *Agent) recordReveal(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.history.RecordUsage(id, UsageRevealed)
+}
+
+// cachedHistory returns the decrypted history, rereading the file only if
+// it changed since it was last read. Call with a.mu held.
+func (a *Agent) cachedHistory() ([]HistoryEntry, error) {
+	if !a.history.IsEnabled() {
+		return nil, fmt.Errorf("history is disabled")
+	}
+
+	path, err := a.history.getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if a.entries == nil || !info.ModTime().Equal(a.loadedAt) {
+		entries, err := a.history.LoadHistory()
+		if err != nil {
+			return nil, err
+		}
+		a.entries = entries
+		a.loadedAt = info.ModTime()
+	}
+	return a.entries, nil
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+func TestAgentHistoryQuotaAndReveals(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example", "c.example")
+
+	agent := NewAgent(h, "test")
+	if err := agent.SetPolicy(AgentPolicy{QuotaPerMinute: 3}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	resp := agent.Handle(ctx, "client", AgentRequest{Method: AgentHistory, Params: json.RawMessage(`{"limit": 2}`)})
+	if resp.Error != "" {
+		t.Fatalf("history failed: %s", resp.Error)
+	}
+	if got := len(resp.Result.([]HistoryEntry)); got != 2 {
+		t.Fatalf("Expected 2 entries, got %d", got)
+	}
+
+	// The reveals wait to be written together
+	entries, err := h.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].RevealCount != 0 {
+		t.Error("Expected the reveals to wait for the next write")
+	}
+	if err := agent.FlushReveals(); err != nil {
+		t.Fatalf("FlushReveals failed: %v", err)
+	}
+	entries, err = h.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, entry := range entries {
+		want := 0
+		if i < 2 {
+			want = 1
+		}
+		if entry.RevealCount != want || (want == 1) != (entry.LastRevealedAt != nil) {
+			t.Errorf("Entry %d: expected %d reveals, got %d", i, want, entry.RevealCount)
+		}
+	}
+
+	resp = agent.Handle(ctx, "client", AgentRequest{Method: AgentHistory, Params: json.RawMessage(`{"limit": 2}`)})
+	if !resp.overQuota || !strings.Contains(resp.Error, "quota exceeded") {
+		t.Errorf("Expected the second request to be over the quota, got %q", resp.Error)
+	}
+	resp = agent.Handle(ctx, "client", AgentRequest{Method: AgentGenerate, Params: json.RawMessage(`{"type": "pin"}`)})
+	if resp.Error != "" {
+		t.Errorf("Expected the last secret of the quota to be generated, got %q", resp.Error)
+	}
+	resp = agent.Handle(ctx, "other", AgentRequest{Method: AgentHistory})
+	if resp.Error != "" {
+		t.Errorf("Expected another client to have its own quota, got %q", resp.Error)
+	}
+}
+
+func TestAgentAllowlist(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("clients of a Unix socket can't be identified on " + runtime.GOOS)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		want    string // Error prefix; empty = allowed
+	}{
+		{"Listed", []string{filepath.Base(exe)}, ""},
+		{"Not listed", []string{"socat"}, "not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.ConfigDirEnv, t.TempDir())
+			agent := NewAgent(NewHistoryManager(false, "", 100), "test")
+			if err := agent.SetPolicy(AgentPolicy{AllowedClients: tt.allowed}); err != nil {
+				t.Fatal(err)
+			}
+
+			listener, err := ListenAgent(filepath.Join(t.TempDir(), "agent.sock"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go agent.Serve(ctx, listener)
+
+			conn, err := net.Dial("unix", listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if _, err := conn.Write([]byte(`{"method": "ping"}` + "\n")); err != nil {
+				t.Fatal(err)
+			}
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				t.Fatalf("No response: %v", err)
+			}
+
+			var resp AgentResponse
+			if err := json.Unmarshal([]byte(line), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" && resp.Error != "" {
+				t.Errorf("Expected the ping to be answered, got %q", resp.Error)
+			}
+			if tt.want != "" && !strings.HasPrefix(resp.Error, tt.want) {
+				t.Errorf("Expected %q..., got %q", tt.want, resp.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// PeerCredentials identify the process at the other end of the agent
+// socket, as the kernel reports it
+type PeerCredentials struct {
+	UID int
+	PID int
+	Exe string // Its program, only the file name on macOS; empty if it could not be found
+}
+
+// String describes the process for log lines, e.g. "pid 4242 (/usr/bin/socat)"
+func (p PeerCredentials) String() string {
+	if p.Exe == "" {
+		return fmt.Sprintf("pid %d", p.PID)
+	}
+	return fmt.Sprintf("pid %d (%s)", p.PID, p.Exe)
+}
+
+// errNoPeerCredentials is returned where the platform can't tell who
+// connected to a Unix socket
+var errNoPeerCredentials = errors.New("the clients of a Unix socket can't be identified on this platform")
+
+// errQuotaExceeded is returned for a request over the client's quota
+var errQuotaExceeded = errors.New("quota exceeded")
+
+// AgentPolicy limits which programs may use the agent and how many
+// secrets each may get, so that a compromised process running as the
+// user can't quietly empty the history or generate secrets in bulk
+// through it
+type AgentPolicy struct {
+	AllowedClients []string  // Programs allowed on the socket, by path or file name; empty = any of the user's
+	QuotaPerMinute int       // Secrets, generated or from the history, a client may get a minute; 0 = no limit
+	Notifier       *Notifier // Alerts about quota breaches, as EventAgentQuota
+}
+
+// AgentPolicyFromConfig returns the agent policy set in cfg
+func AgentPolicyFromConfig(cfg *config.Config) AgentPolicy {
+	var allowed []string
+	for _, client := range strings.Split(cfg.AgentAllowedClients, ",") {
+		if client = strings.TrimSpace(client); client != "" {
+			allowed = append(allowed, client)
+		}
+	}
+	return AgentPolicy{
+		AllowedClients: allowed,
+		QuotaPerMinute: cfg.AgentQuotaPerMinute,
+		Notifier:       NewNotifier(cfg.Notifications),
+	}
+}
+
+// check refuses an allowlist the platform can't enforce as written
+func (p AgentPolicy) check() error {
+	for _, allowed := range p.AllowedClients {
+		if err := checkAllowedClient(allowed); err != nil {
+			return fmt.Errorf("agent_allowed_clients: %w", err)
+		}
+	}
+	return nil
+}
+
+// allows reports whether the program exe may use the agent socket. An
+// entry with a path must match exe exactly; a bare name matches the file
+// name of exe wherever it is installed.
+func (p AgentPolicy) allows(exe string) bool {
+	if len(p.AllowedClients) == 0 {
+		return true
+	}
+	if exe == "" {
+		return false
+	}
+	for _, allowed := range p.AllowedClients {
+		if strings.ContainsRune(allowed, '/') || strings.ContainsRune(allowed, filepath.Separator) {
+			if filepath.Clean(allowed) == exe {
+				return true
+			}
+		} else if filepath.Base(exe) == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// agentQuota is a token bucket per client: each holds up to a minute's
+// quota of secrets and refills evenly over the minute
+type agentQuota struct {
+	mu        sync.Mutex
+	perMinute int
+	clients   map[string]*quotaBucket
+}
+
+// quotaBucket is the quota left to one client
+type quotaBucket struct {
+	tokens  float64
+	updated time.Time
+	alerted time.Time // Last breach alert, so a client retrying doesn't flood the desktop
+}
+
+// newAgentQuota creates the quotas; perMinute 0 is no limit
+func newAgentQuota(perMinute int) *agentQuota {
+	return &agentQuota{perMinute: perMinute, clients: make(map[string]*quotaBucket)}
+}
+
+// take spends n of client's quota and reports whether there was enough
+// left; a refused request spends nothing. alert reports whether a refusal
+// should be alerted about, at most once a minute for each client.
+func (q *agentQuota) take(client string, n int, now time.Time) (ok, alert bool) {
+	if q == nil || q.perMinute <= 0 {
+		return true, false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	bucket, found := q.clients[client]
+	if !found {
+		bucket = &quotaBucket{tokens: float64(q.perMinute), updated: now}
+		q.clients[client] = bucket
+	}
+	refill := now.Sub(bucket.updated).Minutes() * float64(q.perMinute)
+	bucket.tokens = min(float64(q.perMinute), bucket.tokens+refill)
+	bucket.updated = now
+
+	if float64(n) <= bucket.tokens {
+		bucket.tokens -= float64(n)
+		return true, false
+	}
+	if now.Sub(bucket.alerted) < time.Minute {
+		return false, false
+	}
+	bucket.alerted = now
+	return false, true
+}
+
+// spend charges client for n secrets, refusing the request if that is
+// over its quota. Refusals are logged, and alerted about with the
+// configured notification.
+func (a *Agent) spend(client string, n int) error {
+	ok, alert := a.quota.take(client, n, time.Now())
+	if ok {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s asked for %d secrets, over its quota of %d a minute", client, n, a.policy.QuotaPerMinute)
+	log.Printf("passman agent: refused: %s", message)
+	if alert {
+		go func() {
+			if err := a.policy.Notifier.Notify(EventAgentQuota, "passman agent quota exceeded", message); err != nil {
+				log.Printf("passman agent: %v", err)
+			}
+		}()
+	}
+	if n > a.policy.QuotaPerMinute {
+		return fmt.Errorf("%w: at most %d secrets a minute; ask for fewer, e.g. with limit", errQuotaExceeded, a.policy.QuotaPerMinute)
+	}
+	return fmt.Errorf("%w: at most %d secrets a minute; try again later", errQuotaExceeded, a.policy.QuotaPerMinute)
+}
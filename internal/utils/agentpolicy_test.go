@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestAgentPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		exe     string
+		want    bool
+	}{
+		{"No allowlist", nil, "/usr/bin/socat", true},
+		{"Path", []string{"/usr/bin/socat"}, "/usr/bin/socat", true},
+		{"Other path", []string{"/usr/bin/socat"}, "/tmp/socat", false},
+		{"Name", []string{"rofi", "socat"}, "/usr/local/bin/socat", true},
+		{"Other name", []string{"rofi"}, "/usr/bin/socat", false},
+		{"Unknown program", []string{"socat"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := AgentPolicy{AllowedClients: tt.allowed}
+			if got := policy.allows(tt.exe); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.exe, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgentPolicyCheck(t *testing.T) {
+	policy := AgentPolicy{AllowedClients: []string{"rofi", "/usr/bin/socat"}}
+	err := policy.check()
+	if runtime.GOOS == "darwin" && err == nil {
+		t.Error("Expected macOS to refuse a path, which it can't check")
+	}
+	if runtime.GOOS != "darwin" && err != nil {
+		t.Errorf("Expected the allowlist to be accepted: %v", err)
+	}
+}
+
+func TestAgentQuota(t *testing.T) {
+	quota := newAgentQuota(60)
+	now := time.Now()
+
+	if ok, _ := quota.take("a", 50, now); !ok {
+		t.Fatal("Expected 50 of 60 secrets to be allowed")
+	}
+	ok, alert := quota.take("a", 20, now)
+	if ok || !alert {
+		t.Errorf("Expected 20 more to be refused with an alert, got ok=%v alert=%v", ok, alert)
+	}
+	if _, alert := quota.take("a", 20, now.Add(time.Second)); alert {
+		t.Error("Expected a second refusal within the minute not to alert again")
+	}
+	if ok, _ := quota.take("b", 60, now); !ok {
+		t.Error("Expected another client to have its own quota")
+	}
+	// 10 left, refilled at one a second
+	if ok, _ := quota.take("a", 20, now.Add(10*time.Second)); !ok {
+		t.Error("Expected the quota to refill over the minute")
+	}
+	if ok, _ := quota.take("a", 61, now.Add(time.Hour)); ok {
+		t.Error("Expected a request larger than the whole quota to be refused")
+	}
+
+	if ok, _ := newAgentQuota(0).take("a", 1000, now); !ok {
+		t.Error("Expected quota 0 to be no limit")
+	}
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// DefaultAPIAddress is where passman serve listens unless told otherwise
+const DefaultAPIAddress = "127.0.0.1:7845"
+
+// apiTokenBytes is the size of a generated API token
+const apiTokenBytes = 32
+
+// APIServer answers the agent's requests over HTTP, for integrations
+// that speak HTTP more easily than a Unix socket, e.g. launcher scripts
+// and editor plugins. Every request must carry the API token as
+// "Authorization: Bearer TOKEN".
+//
+//	GET  /v1/ping
+//	POST /v1/generate   the params of the agent's generate
+//	POST /v1/analyze    {"password": "..."}
+//	GET  /v1/history?query=...&limit=N
+//
+// Responses are the agent's, without the id: {"result": ...} with status
+// 200, or {"error": "..."} with a 4xx status.
+type APIServer struct {
+	agent *Agent
+	token string
+	mux   *http.ServeMux
+}
+
+// NewAPIServer serves agent to clients presenting token
+func NewAPIServer(agent *Agent, token string) *APIServer {
+	s := &APIServer{agent: agent, token: token, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /v1/ping", s.method(AgentPing))
+	s.mux.HandleFunc("POST /v1/generate", s.method(AgentGenerate))
+	s.mux.HandleFunc("POST /v1/analyze", s.method(AgentAnalyze))
+	s.mux.HandleFunc("GET /v1/history", s.history)
+	return s
+}
+
+// ServeHTTP checks the token, then routes the request
+func (s *APIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="passman"`)
+		writeAPIResponse(w, http.StatusUnauthorized, AgentResponse{Error: "missing or wrong API token"})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// method answers with the agent's method, taking its params from the
+// request body
+func (s *APIServer) method(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params json.RawMessage
+		if r.Method == http.MethodPost {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAgentRequest))
+			if err != nil {
+				writeAPIResponse(w, http.StatusRequestEntityTooLarge, AgentResponse{Error: err.Error()})
+				return
+			}
+			if len(strings.TrimSpace(string(body))) > 0 {
+				params = body
+			}
+		}
+		s.answer(w, r, AgentRequest{Method: name, Params: params})
+	}
+}
+
+// history answers a history search given in the query string, so it can
+// be tried with a plain curl
+func (s *APIServer) history(w http.ResponseWriter, r *http.Request) {
+	params := agentHistoryParams{Query: r.URL.Query().Get("query")}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			writeAPIResponse(w, http.StatusBadRequest, AgentResponse{Error: fmt.Sprintf("invalid limit %q", limit)})
+			return
+		}
+		params.Limit = n
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		writeAPIResponse(w, http.StatusInternalServerError, AgentResponse{Error: err.Error()})
+		return
+	}
+	s.answer(w, r, AgentRequest{Method: AgentHistory, Params: raw})
+}
+
+// answer runs req on the agent and writes its response. Quotas go by the
+// client's address, so all local clients share one.
+func (s *APIServer) answer(w http.ResponseWriter, r *http.Request, req AgentRequest) {
+	client := "http client " + r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		client = "http client " + host
+	}
+	resp := s.agent.Handle(r.Context(), client, req)
+	status := http.StatusOK
+	switch {
+	case resp.overQuota:
+		status = http.StatusTooManyRequests
+	case resp.Error != "":
+		status = http.StatusBadRequest
+	}
+	writeAPIResponse(w, status, resp)
+}
+
+// writeAPIResponse writes resp as JSON. Secrets are never cached.
+func writeAPIResponse(w http.ResponseWriter, status int, resp AgentResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// IsLoopbackAddress reports whether the listen address addr, host:port,
+// only accepts connections from this machine. An empty host listens on
+// every interface.
+func IsLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// APITokenPath returns the file the API token of the active profile is
+// kept in
+func APITokenPath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "api-token"), nil
+}
+
+// LoadAPIToken returns the API token, creating one readable only by the
+// current user if there is none yet or renew is set. created reports
+// whether a new token was written.
+func LoadAPIToken(renew bool) (token string, created bool, err error) {
+	path, err := APITokenPath()
+	if err != nil {
+		return "", false, err
+	}
+	if !renew {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.TrimSpace(string(data)) != "" {
+			return strings.TrimSpace(string(data)), false, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", false, err
+		}
+	}
+
+	raw := make([]byte, apiTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", false, err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", false, err
+	}
+	if err := writeFileAtomic(path, []byte(token+"\n")); err != nil {
+		return "", false, err
+	}
+	return token, true, nil
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoAutoTypeBackend is returned when no keystroke tool is installed
+var ErrNoAutoTypeBackend = errors.New("no auto-type tool found (install xdotool, wtype or ydotool)")
+
+// autoTypeBackend is an external tool that types text into the focused window.
+// The text is always passed on stdin so it never shows up in the process list.
+type autoTypeBackend struct {
+	name  string
+	args  []string
+	input func(text string) string
+}
+
+// AutoTypeManager types secrets as keystrokes into whatever window has focus,
+// for VM consoles and remote desktops where the clipboard is not shared
+type AutoTypeManager struct{}
+
+// NewAutoTypeManager creates a new auto-type manager instance
+func NewAutoTypeManager() *AutoTypeManager {
+	return &AutoTypeManager{}
+}
+
+// Type sends text as keystrokes to the focused window
+func (a *AutoTypeManager) Type(text string) error {
+	if text == "" {
+		return errors.New("cannot type empty text")
+	}
+
+	backend, err := a.backend()
+	if err != nil {
+		return err
+	}
+
+	input := text
+	if backend.input != nil {
+		input = backend.input(text)
+	}
+
+	cmd := exec.Command(backend.name, backend.args...)
+	cmd.Stdin = strings.NewReader(input)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", backend.name, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// IsAvailable checks if an auto-type tool is installed
+func (a *AutoTypeManager) IsAvailable() bool {
+	_, err := a.backend()
+	return err == nil
+}
+
+// backend picks the keystroke tool for the current platform and session
+func (a *AutoTypeManager) backend() (autoTypeBackend, error) {
+	var candidates []autoTypeBackend
+
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = append(candidates, autoTypeBackend{name: "osascript", input: appleScriptKeystroke})
+	case "windows":
+		candidates = append(candidates, autoTypeBackend{
+			name: "powershell",
+			args: []string{"-NoProfile", "-NonInteractive", "-Command",
+				"Add-Type -AssemblyName System.Windows.Forms; " +
+					"$t = [Console]::In.ReadToEnd(); " +
+					"[System.Windows.Forms.SendKeys]::SendWait(($t -replace '[+^%~(){}\\[\\]]', '{$0}'))"},
+		})
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates,
+				autoTypeBackend{name: "wtype", args: []string{"-"}},
+				autoTypeBackend{name: "ydotool", args: []string{"type", "--file", "-"}})
+		}
+		if os.Getenv("DISPLAY") != "" {
+			candidates = append(candidates, autoTypeBackend{name: "xdotool", args: []string{"type", "--clearmodifiers", "--file", "-"}})
+		}
+		// ydotool talks to uinput directly, so it also works on a bare console
+		candidates = append(candidates, autoTypeBackend{name: "ydotool", args: []string{"type", "--file", "-"}})
+	}
+
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate.name); err == nil {
+			return candidate, nil
+		}
+	}
+	return autoTypeBackend{}, ErrNoAutoTypeBackend
+}
+
+// appleScriptKeystroke builds an AppleScript that types text via System Events
+func appleScriptKeystroke(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+	return `tell application "System Events" to keystroke "` + escaped + `"`
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// backupMagic starts every backup file, ahead of the encrypted payload
+var backupMagic = []byte("PMBACKUP1\n")
+
+// BackupExtension is the file extension of history backups
+const BackupExtension = ".pmbak"
+
+// backupVersion is the version of the backup payload
+const backupVersion = 1
+
+// backupPayload is the JSON encrypted into a backup file
+type backupPayload struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Entries   []HistoryEntry `json:"entries"`
+}
+
+// BackupInfo describes a backup file that was written or read
+type BackupInfo struct {
+	Path      string
+	CreatedAt time.Time
+	Entries   int
+}
+
+// RestoreReport is the result of restoring a backup
+type RestoreReport struct {
+	Backup     BackupInfo
+	Added      int    // Backup entries not in the history yet
+	Skipped    int    // Backup entries already in the history
+	Dropped    int    // Oldest entries left out to stay within the maximum
+	BackupPath string // Copy of the history as it was before the restore
+}
+
+// DefaultBackupName returns a timestamped file name for a backup
+func DefaultBackupName(now time.Time) string {
+	return "passman-backup-" + now.Format("20060102-150405") + BackupExtension
+}
+
+// ExportBackup writes the whole history to a single file encrypted with
+// passphrase, which need not be the history passphrase. Backups always
+// use Argon2id, since they are meant to travel to other machines.
+func (h *HistoryManager) ExportBackup(path, passphrase string) (*BackupInfo, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("backup passphrase not set")
+	}
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := backupPayload{Version: backupVersion, CreatedAt: time.Now(), Entries: entries}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup: %w", err)
+	}
+
+	sealer := &HistoryManager{passphrase: passphrase, kdf: KDFArgon2id}
+	encryptedData, err := sealer.encrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := writeFileAtomic(path, append(append([]byte{}, backupMagic...), encryptedData...)); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return &BackupInfo{Path: path, CreatedAt: payload.CreatedAt, Entries: len(entries)}, nil
+}
+
+// ClearWithBackup writes a backup of the history to path, encrypted with
+// the history passphrase so it restores without another secret, and then
+// clears the history. Nothing is cleared if the backup fails.
+func (h *HistoryManager) ClearWithBackup(path string) (*BackupInfo, error) {
+	info, err := h.ExportBackup(path, h.passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("backup before clearing failed: %w", err)
+	}
+	if err := h.ClearHistory(); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// readBackup decrypts and parses a backup file
+func readBackup(path, passphrase string) (*backupPayload, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if !bytes.HasPrefix(data, backupMagic) {
+		return nil, fmt.Errorf("%s is not a passman backup", path)
+	}
+
+	opener := &HistoryManager{passphrase: passphrase}
+	plaintext, err := opener.decrypt(data[len(backupMagic):])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup (wrong passphrase?): %w", err)
+	}
+
+	var payload backupPayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse backup: %w", err)
+	}
+	if payload.Version > backupVersion {
+		return nil, fmt.Errorf("backup version %d is newer than this passman supports", payload.Version)
+	}
+	return &payload, nil
+}
+
+// ImportBackup restores a backup written by ExportBackup into the history.
+// Backup entries are merged with the current ones by ID, newest first;
+// with replace the current history is discarded instead. The current
+// history file is copied to a timestamped .bak file before it is
+// overwritten.
+func (h *HistoryManager) ImportBackup(path, passphrase string, replace bool) (*RestoreReport, error) {
+	if !h.enabled {
+		return nil, fmt.Errorf("history is disabled")
+	}
+
+	payload, err := readBackup(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var current []HistoryEntry
+	if !replace {
+		if current, err = h.LoadHistory(); err != nil {
+			return nil, err
+		}
+	} else if err := h.requirePassphrase(); err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{Backup: BackupInfo{Path: path, CreatedAt: payload.CreatedAt, Entries: len(payload.Entries)}}
+
+	seen := make(map[string]bool, len(current))
+	for _, entry := range current {
+		seen[entry.ID] = true
+	}
+	merged := append([]HistoryEntry{}, current...)
+	for _, entry := range payload.Entries {
+		if entry.ID != "" && seen[entry.ID] {
+			report.Skipped++
+			continue
+		}
+		if entry.ID == "" {
+			entry.ID = h.generateID()
+		}
+		seen[entry.ID] = true
+		merged = append(merged, entry)
+		report.Added++
+	}
+
+	known := chainHashes(merged, payload.Entries)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+	if len(merged) > h.maxEntries {
+		report.Dropped = len(merged) - h.maxEntries
+		merged = merged[:h.maxEntries]
+	}
+	relinkEntries(merged, known)
+
+	historyPath, err := h.getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(historyPath); err == nil {
+		report.BackupPath = fmt.Sprintf("%s.bak-%s", historyPath, time.Now().Format("20060102-150405"))
+		if err := copyFile(historyPath, report.BackupPath); err != nil {
+			return nil, fmt.Errorf("failed to back up the current history: %w", err)
+		}
+	}
+
+	if err := h.saveHistory(merged); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// String summarises the restore for display
+func (r RestoreReport) String() string {
+	s := fmt.Sprintf("Restored %d of %d entries from the backup of %s", r.Added, r.Backup.Entries,
+		r.Backup.CreatedAt.Format("Jan 2 2006 15:04"))
+	if r.Skipped > 0 {
+		s += fmt.Sprintf(", %d already in the history", r.Skipped)
+	}
+	if r.Dropped > 0 {
+		s += fmt.Sprintf(", %d oldest dropped to stay within history_max_entries", r.Dropped)
+	}
+	return s
+}
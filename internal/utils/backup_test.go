@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example", "c.example")
+	want := historyPasswords(t, h)
+	path := filepath.Join(t.TempDir(), DefaultBackupName(time.Now()))
+
+	info, err := h.ExportBackup(path, "backup passphrase")
+	if err != nil {
+		t.Fatalf("ExportBackup failed: %v", err)
+	}
+	if info.Entries != 3 {
+		t.Errorf("Expected 3 entries in the backup, got %d", info.Entries)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, backupMagic) || DetectKDF(data[len(backupMagic):]) != KDFArgon2id {
+		t.Errorf("Expected an Argon2id backup")
+	}
+	if bytes.Contains(data, []byte("pw-a.example")) {
+		t.Error("Expected the backup to be encrypted")
+	}
+
+	if _, err := h.ImportBackup(path, "wrong passphrase", false); err == nil {
+		t.Error("Expected a wrong passphrase to be refused")
+	}
+
+	if err := h.ClearHistory(); err != nil {
+		t.Fatalf("ClearHistory failed: %v", err)
+	}
+	report, err := h.ImportBackup(path, "backup passphrase", false)
+	if err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+	if report.Added != 3 || report.Skipped != 0 {
+		t.Errorf("Expected 3 entries restored, got %+v", report)
+	}
+	got := historyPasswords(t, h)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries after the restore, got %d", len(want), len(got))
+	}
+	for id, password := range want {
+		if got[id] != password {
+			t.Errorf("Entry %s: expected %q, got %q", id, password, got[id])
+		}
+	}
+	chain, err := h.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if !chain.OK() {
+		t.Errorf("Expected an intact chain after the restore, got %+v", chain.Breaks)
+	}
+
+	// Restoring again over the same entries adds nothing, and keeps a copy
+	// of the history it overwrote
+	addTestEntries(t, h, "d.example")
+	report, err = h.ImportBackup(path, "backup passphrase", false)
+	if err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+	if report.Added != 0 || report.Skipped != 3 {
+		t.Errorf("Expected the 3 entries to be skipped, got %+v", report)
+	}
+	if got := len(historyPasswords(t, h)); got != 4 {
+		t.Errorf("Expected 4 entries, got %d", got)
+	}
+	if _, err := os.Stat(report.BackupPath); err != nil {
+		t.Errorf("Expected a copy of the previous history: %v", err)
+	}
+}
+
+func TestBackupRestoreOnAnotherMachine(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example")
+	want := historyPasswords(t, h)
+	path := filepath.Join(t.TempDir(), "history"+BackupExtension)
+	if _, err := h.ExportBackup(path, "backup passphrase"); err != nil {
+		t.Fatalf("ExportBackup failed: %v", err)
+	}
+
+	// Another data directory and history passphrase, with an entry of its own
+	other := newTestHistory(t)
+	other.passphrase = "another passphrase"
+	addTestEntries(t, other, "z.example")
+
+	report, err := other.ImportBackup(path, "backup passphrase", true)
+	if err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+	if report.Added != 2 {
+		t.Errorf("Expected 2 entries restored, got %+v", report)
+	}
+	got := historyPasswords(t, other)
+	if len(got) != len(want) {
+		t.Fatalf("Expected the history to be replaced by the backup, got %v", got)
+	}
+	for id, password := range want {
+		if got[id] != password {
+			t.Errorf("Entry %s: expected %q, got %q", id, password, got[id])
+		}
+	}
+}
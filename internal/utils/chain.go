@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// EntryHash returns the chain hash of an entry: SHA-256 over its previous
+// hash and the fields fixed when it was generated. Usage, shares, links,
+// tags and descriptions change over an entry's life and are not covered.
+func EntryHash(entry HistoryEntry) string {
+	fields := []string{
+		entry.PrevHash,
+		entry.ID,
+		entry.Password,
+		strconv.Itoa(entry.Length),
+		entry.Type,
+		entry.Settings,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// linkEntries fills in the previous hash of the entries added since the
+// last save, the unlinked ones before headID, the entry that was newest
+// then. Without a recorded head, a history saved before it was chained,
+// where no entry has a previous hash, is linked whole. Entries are newest
+// first, so each links to the one after it. Any other link, missing or
+// not, is left alone, so an edit made outside passman stays detectable.
+func linkEntries(entries []HistoryEntry, headID string) {
+	added := 0
+	for added < len(entries) && entries[added].ID != headID && entries[added].PrevHash == "" {
+		added++
+	}
+	if headID == "" && unlinked(entries[added:]) {
+		added = len(entries)
+	}
+	for i := min(added, len(entries)-1) - 1; i >= 0; i-- {
+		entries[i].PrevHash = EntryHash(entries[i+1])
+	}
+}
+
+// unlinked reports whether no entry but the oldest, whose link is never
+// checked, has a previous hash: a history saved before it was chained
+func unlinked(entries []HistoryEntry) bool {
+	for i := 0; i < len(entries)-1; i++ {
+		if entries[i].PrevHash != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// relinkEntries rebuilds the chain over entries, for passman's own
+// rewrites that remove or reorder entries: dedupe, purges, merges and
+// repairs. Only links to entries in known, the chain hashes of the
+// entries the rewrite started from (see chainHashes), are rebuilt: a link
+// that was already broken or cleared, by an edit outside passman, stays
+// that way, so a rewrite never hides it from VerifyChain. Entries just
+// added are linked when the history is saved.
+func relinkEntries(entries []HistoryEntry, known map[string]bool) {
+	if len(entries) > 0 {
+		entries[len(entries)-1].PrevHash = ""
+	}
+	for i := len(entries) - 2; i >= 0; i-- {
+		if known[entries[i].PrevHash] {
+			entries[i].PrevHash = EntryHash(entries[i+1])
+		}
+	}
+}
+
+// chainHashes returns the chain hash of every entry of lists, before a
+// rewrite changes them, for relinkEntries. The missing links of a list
+// saved before chaining count as known, so merging it links its entries.
+func chainHashes(lists ...[]HistoryEntry) map[string]bool {
+	known := make(map[string]bool)
+	for _, entries := range lists {
+		for _, entry := range entries {
+			known[EntryHash(entry)] = true
+		}
+		if len(entries) > 1 && unlinked(entries) {
+			known[""] = true
+		}
+	}
+	return known
+}
+
+// chainHead returns the newest entry of the history file at path when
+// passman last saved it, and false if none was recorded
+func chainHead(path string) (config.ChainHead, bool, error) {
+	state, err := config.LoadState()
+	if err != nil {
+		return config.ChainHead{}, false, fmt.Errorf("failed to load the chain head: %w", err)
+	}
+	head, ok := state.ChainHeads[path]
+	return head, ok, nil
+}
+
+// recordChainHead records the newest of the entries just saved to the
+// history file at path, and how many there are, for VerifyChain
+func recordChainHead(path string, entries []HistoryEntry) error {
+	state, err := config.LoadState()
+	if err != nil {
+		return fmt.Errorf("failed to record the chain head: %w", err)
+	}
+	head := config.ChainHead{Entries: len(entries)}
+	if len(entries) > 0 {
+		head.ID, head.Hash = entries[0].ID, EntryHash(entries[0])
+	}
+	if state.ChainHeads == nil {
+		state.ChainHeads = make(map[string]config.ChainHead)
+	}
+	state.ChainHeads[path] = head
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("failed to record the chain head: %w", err)
+	}
+	return nil
+}
+
+// ChainBreak is an entry that does not link to the entry before it
+type ChainBreak struct {
+	Index     int // Position in the history, newest first
+	ID        string
+	CreatedAt time.Time
+}
+
+// String describes the break for display
+func (b ChainBreak) String() string {
+	return fmt.Sprintf("entry %d (%s, created %s) does not follow the entry before it: an entry was edited, inserted or removed",
+		b.Index+1, b.ID, b.CreatedAt.Format("Jan 2 2006 15:04"))
+}
+
+// ChainReport is the result of verifying the history's hash chain
+type ChainReport struct {
+	Entries  int
+	Linked   int // Links checked and intact
+	Unlinked int // Entries without a previous hash, saved before chaining
+	Breaks   []ChainBreak
+	Head     string // How the newest entries differ from the last save, empty if they don't
+}
+
+// OK reports whether no break was found
+func (r ChainReport) OK() bool {
+	return len(r.Breaks) == 0 && r.Head == ""
+}
+
+// VerifyChain checks that every entry's previous hash matches the entry
+// before it, and that the newest entry and the number of entries are
+// those passman last saved. The oldest entry's link is not checked, as
+// retention drops the oldest entries. The chain shows edits made to the
+// decrypted file outside passman; anyone with the passphrase can still
+// rebuild it.
+func (h *HistoryManager) VerifyChain() (*ChainReport, error) {
+	historyPath, err := h.getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+	report := verifyChain(entries)
+
+	head, ok, err := chainHead(historyPath)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		report.checkHead(entries, head)
+	}
+	return report, nil
+}
+
+// checkHead compares the newest of entries with the head recorded at the
+// last save, which shows the edits and removals the chain can't: nothing
+// links to the newest entry
+func (r *ChainReport) checkHead(entries []HistoryEntry, head config.ChainHead) {
+	switch {
+	case len(entries) != head.Entries:
+		r.Head = fmt.Sprintf("the history has %d entries, passman saved %d", len(entries), head.Entries)
+	case len(entries) > 0 && EntryHash(entries[0]) != head.Hash:
+		r.Head = fmt.Sprintf("the newest entry (%s) is not the one passman saved last (%s): it was edited, inserted or removed", entries[0].ID, head.ID)
+	}
+}
+
+// verifyChain checks the links of entries, newest first. An entry without
+// a previous hash was saved before chaining only if no older one has one
+// either; otherwise its link was cleared.
+func verifyChain(entries []HistoryEntry) *ChainReport {
+	report := &ChainReport{Entries: len(entries)}
+	for i := 0; i < len(entries)-1; i++ {
+		entry := entries[i]
+		switch {
+		case entry.PrevHash == "" && unlinked(entries[i:]):
+			report.Unlinked++
+		case entry.PrevHash == EntryHash(entries[i+1]):
+			report.Linked++
+		default:
+			report.Breaks = append(report.Breaks, ChainBreak{Index: i, ID: entry.ID, CreatedAt: entry.CreatedAt})
+		}
+	}
+	return report
+}
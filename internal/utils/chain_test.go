@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// editEntry applies edit to the entry for site in the history file, as an
+// edit of the decrypted file outside passman would
+func editEntry(t *testing.T, h *HistoryManager, site string, edit func(entry *HistoryEntry)) {
+	t.Helper()
+	historyPath, _ := h.getHistoryPath()
+	records := readRecords(t, h, historyPath)
+	for i, record := range records {
+		var entry HistoryEntry
+		if err := json.Unmarshal(record, &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Site != site {
+			continue
+		}
+		edit(&entry)
+		data, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records[i] = data
+		writeRecords(t, h, records)
+		return
+	}
+	t.Fatalf("No entry for %s", site)
+}
+
+// tamperEntry edits the password of the entry for site
+func tamperEntry(t *testing.T, h *HistoryManager, site string) {
+	t.Helper()
+	editEntry(t, h, site, func(entry *HistoryEntry) { entry.Password = "tampered" })
+}
+
+// entryID returns the ID of the entry for site
+func entryID(t *testing.T, h *HistoryManager, site string) string {
+	t.Helper()
+	entries, err := h.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Site == site {
+			return entry.ID
+		}
+	}
+	t.Fatalf("No entry for %s", site)
+	return ""
+}
+
+func TestVerifyChainDetectsTampering(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example", "c.example")
+
+	report, err := h.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if !report.OK() || report.Linked != 2 {
+		t.Fatalf("Expected 2 intact links, got %+v", report)
+	}
+
+	tamperEntry(t, h, "b.example")
+	report, err = h.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if len(report.Breaks) != 1 || report.Breaks[0].ID != entryID(t, h, "c.example") {
+		t.Errorf("Expected the entry after the edited one to break the chain, got %+v", report.Breaks)
+	}
+}
+
+func TestVerifyChainChecksHead(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, h *HistoryManager)
+	}{
+		{"Edit newest", func(t *testing.T, h *HistoryManager) {
+			tamperEntry(t, h, "c.example")
+		}},
+		{"Remove newest", func(t *testing.T, h *HistoryManager) {
+			historyPath, _ := h.getHistoryPath()
+			writeRecords(t, h, readRecords(t, h, historyPath)[1:])
+		}},
+		{"Clear newest link", func(t *testing.T, h *HistoryManager) {
+			editEntry(t, h, "c.example", func(entry *HistoryEntry) { entry.PrevHash = "" })
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHistory(t)
+			addTestEntries(t, h, "a.example", "b.example", "c.example")
+			tt.tamper(t, h)
+
+			report, err := h.VerifyChain()
+			if err != nil {
+				t.Fatalf("VerifyChain failed: %v", err)
+			}
+			if report.OK() || report.Head == "" {
+				t.Errorf("Expected the newest entries to differ from the last save, got %+v", report)
+			}
+		})
+	}
+}
+
+func TestVerifyChainClearedLink(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example", "c.example", "d.example")
+	editEntry(t, h, "c.example", func(entry *HistoryEntry) { entry.PrevHash = "" })
+
+	// The cleared entry breaks the chain, and so does the one linking to
+	// it; saving again doesn't fill the link in
+	for _, add := range []bool{false, true} {
+		if add {
+			addTestEntries(t, h, "e.example")
+		}
+		report, err := h.VerifyChain()
+		if err != nil {
+			t.Fatalf("VerifyChain failed: %v", err)
+		}
+		if report.Unlinked != 0 || len(report.Breaks) != 2 ||
+			report.Breaks[0].ID != entryID(t, h, "d.example") || report.Breaks[1].ID != entryID(t, h, "c.example") {
+			t.Errorf("Expected the cleared link to break the chain, got %+v", report)
+		}
+	}
+}
+
+func TestLinkEntriesSavedBeforeChaining(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example", "c.example")
+	for _, site := range []string{"b.example", "c.example"} {
+		editEntry(t, h, site, func(entry *HistoryEntry) { entry.PrevHash = "" })
+	}
+	if err := (config.State{}).Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := h.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if !report.OK() || report.Unlinked != 2 {
+		t.Fatalf("Expected 2 entries saved before chaining, got %+v", report)
+	}
+
+	addTestEntries(t, h, "d.example")
+	report, err = h.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if !report.OK() || report.Linked != 3 {
+		t.Errorf("Expected the next save to link every entry, got %+v", report)
+	}
+}
+
+func TestDeleteEntryRelinksChain(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example", "c.example")
+
+	if err := h.DeleteEntry(entryID(t, h, "b.example")); err != nil {
+		t.Fatalf("DeleteEntry failed: %v", err)
+	}
+	report, err := h.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if !report.OK() || report.Linked != 1 {
+		t.Errorf("Expected the chain to be rebuilt over the gap, got %+v", report)
+	}
+}
+
+func TestRewritesKeepTamperedLinksBroken(t *testing.T) {
+	tests := []struct {
+		name    string
+		rewrite func(t *testing.T, h *HistoryManager)
+	}{
+		{"Delete oldest", func(t *testing.T, h *HistoryManager) {
+			if err := h.DeleteEntry(entryID(t, h, "a.example")); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"Delete newest", func(t *testing.T, h *HistoryManager) {
+			if err := h.DeleteEntry(entryID(t, h, "d.example")); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"Dedupe", func(t *testing.T, h *HistoryManager) {
+			h.SetRetention(RetentionPolicy{Dedupe: true})
+			if err := h.AddEntry(HistoryEntry{Password: "pw-a.example", Length: 12, Type: "random"}); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"Repair", func(t *testing.T, h *HistoryManager) {
+			historyPath, _ := h.getHistoryPath()
+			records := readRecords(t, h, historyPath)
+			records[len(records)-1] = json.RawMessage(`{"id": 42}`)
+			writeRecords(t, h, records)
+			if _, err := h.Fsck(true); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHistory(t)
+			addTestEntries(t, h, "a.example", "b.example", "c.example", "d.example")
+			tamperEntry(t, h, "b.example")
+
+			tt.rewrite(t, h)
+
+			report, err := h.VerifyChain()
+			if err != nil {
+				t.Fatalf("VerifyChain failed: %v", err)
+			}
+			if len(report.Breaks) != 1 || report.Breaks[0].ID != entryID(t, h, "c.example") {
+				t.Errorf("Expected the edit to stay detectable, got %+v", report.Breaks)
+			}
+		})
+	}
+}
@@ -1,36 +1,186 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/atotto/clipboard"
+
+	"github.com/mshnjffr/passman/internal/config"
 )
 
 // ClipboardManager handles cross-platform clipboard operations
-type ClipboardManager struct{}
+type ClipboardManager struct {
+	sensitive    bool     // Keep copies out of clipboard history where supported
+	copyCommand  []string // Program and arguments reading the text to copy on stdin; nil = automatic
+	pasteCommand []string // Program and arguments printing the clipboard; nil = automatic
+
+	mu         sync.Mutex
+	clearAfter time.Duration // 0 = never clear
+	clearTimer *time.Timer
+	clearAt    time.Time // When the pending clear fires, zero if none
+}
 
 // NewClipboardManager creates a new clipboard manager instance
 func NewClipboardManager() *ClipboardManager {
 	return &ClipboardManager{}
 }
 
+// SetSensitive enables sensitive copy mode. Copies are then marked as
+// concealed for clipboard managers and, where the platform allows it,
+// cleared after the first paste.
+func (c *ClipboardManager) SetSensitive(sensitive bool) {
+	c.sensitive = sensitive
+}
+
+// IsSensitive reports whether sensitive copy mode is enabled
+func (c *ClipboardManager) IsSensitive() bool {
+	return c.sensitive
+}
+
+// SetCommands makes the clipboard shell out to the given command lines,
+// e.g. "xclip -selection clipboard" to copy and "xclip -selection
+// clipboard -o" to paste, for setups the automatic detection misses, such
+// as tmux or a headless terminal. An empty line keeps automatic detection.
+// A copy command also clears the clipboard, by copying nothing, and
+// replaces sensitive copy mode.
+func (c *ClipboardManager) SetCommands(copyLine, pasteLine string) error {
+	copyCommand, err := config.SplitCommand(copyLine)
+	if err != nil {
+		return fmt.Errorf("invalid copy command: %w", err)
+	}
+	pasteCommand, err := config.SplitCommand(pasteLine)
+	if err != nil {
+		return fmt.Errorf("invalid paste command: %w", err)
+	}
+	c.copyCommand, c.pasteCommand = copyCommand, pasteCommand
+	return nil
+}
+
+// SetClearAfter sets how long copied text stays on the clipboard; 0
+// keeps it until something else is copied
+func (c *ClipboardManager) SetClearAfter(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearAfter = d
+}
+
+// ClearsAt returns when the clipboard will be cleared, and false when no
+// clear is pending
+func (c *ClipboardManager) ClearsAt() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.clearAt, !c.clearAt.IsZero()
+}
+
+// WaitForClear blocks until the pending clear has run, so a command that
+// copies a secret doesn't exit and leave it on the clipboard. If ctx is
+// done first, the clipboard is cleared at once.
+func (c *ClipboardManager) WaitForClear(ctx context.Context) {
+	for {
+		at, ok := c.ClearsAt()
+		if !ok {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			if c.clearTimer != nil {
+				c.clearTimer.Stop()
+				c.clearTimer = nil
+				c.clearAt = time.Time{}
+			}
+			c.mu.Unlock()
+			_ = c.Clear()
+			return
+		case <-time.After(max(time.Until(at), 0) + 100*time.Millisecond):
+		}
+	}
+}
+
 // Copy copies the given text to the system clipboard
 func (c *ClipboardManager) Copy(text string) error {
 	if text == "" {
 		return errors.New("cannot copy empty text to clipboard")
 	}
 
+	if c.copyCommand != nil {
+		if err := c.runCopyCommand(text); err != nil {
+			return err
+		}
+		c.scheduleClear(text)
+		return nil
+	}
+
+	if c.sensitive {
+		if cmd := sensitiveCopyCommand(); cmd != nil {
+			cmd.Stdin = strings.NewReader(text)
+			if runtime.GOOS == "darwin" {
+				cmd.Stdin = strings.NewReader(concealedPasteboardScript(text))
+			}
+			if err := cmd.Run(); err == nil {
+				c.scheduleClear(text)
+				return nil
+			}
+		}
+		// Fall back to a plain copy when no tool supports sensitive copies
+	}
+
 	err := clipboard.WriteAll(text)
 	if err != nil {
 		return fmt.Errorf("failed to copy to clipboard: %w", err)
 	}
 
+	c.scheduleClear(text)
 	return nil
 }
 
+// scheduleClear clears the clipboard after the configured delay, unless
+// something else has been copied since. A new copy replaces the pending
+// clear.
+func (c *ClipboardManager) scheduleClear(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.clearTimer != nil {
+		c.clearTimer.Stop()
+		c.clearTimer = nil
+		c.clearAt = time.Time{}
+	}
+	if c.clearAfter <= 0 {
+		return
+	}
+
+	c.clearAt = time.Now().Add(c.clearAfter)
+	var timer *time.Timer
+	timer = time.AfterFunc(c.clearAfter, func() {
+		if current, err := c.Paste(); err != nil || current == text {
+			_ = c.Clear()
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.clearTimer == timer {
+			c.clearTimer = nil
+			c.clearAt = time.Time{}
+		}
+	})
+	c.clearTimer = timer
+}
+
 // Paste retrieves text from the system clipboard
 func (c *ClipboardManager) Paste() (string, error) {
+	if c.pasteCommand != nil {
+		return c.runPasteCommand()
+	}
+
 	text, err := clipboard.ReadAll()
 	if err != nil {
 		return "", fmt.Errorf("failed to read from clipboard: %w", err)
@@ -39,14 +189,115 @@ func (c *ClipboardManager) Paste() (string, error) {
 	return text, nil
 }
 
-// IsAvailable checks if clipboard functionality is available
+// IsAvailable checks if clipboard functionality is available. With
+// custom commands, it checks that their programs are installed.
 func (c *ClipboardManager) IsAvailable() bool {
-	// Try to read from clipboard to test availability
-	_, err := clipboard.ReadAll()
-	return err == nil
+	if c.copyCommand == nil && c.pasteCommand == nil {
+		return clipboardAvailable()
+	}
+	for _, command := range [][]string{c.copyCommand, c.pasteCommand} {
+		if command == nil {
+			if !clipboardAvailable() {
+				return false
+			}
+		} else if _, err := exec.LookPath(command[0]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// runCopyCommand copies text with the custom copy command, writing it to
+// the command's stdin. Its output is not captured: tools such as xclip
+// leave a child serving the selection, which would hold the pipe open.
+func (c *ClipboardManager) runCopyCommand(text string) error {
+	cmd := exec.Command(c.copyCommand[0], c.copyCommand[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy to clipboard with %s: %w", c.copyCommand[0], err)
+	}
+	return nil
+}
+
+// runPasteCommand reads the clipboard from the stdout of the custom paste
+// command. One trailing newline is dropped, as tools such as wl-paste
+// add one.
+func (c *ClipboardManager) runPasteCommand() (string, error) {
+	cmd := exec.Command(c.pasteCommand[0], c.pasteCommand[1:]...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read from clipboard with %s: %w%s", c.pasteCommand[0], err, commandOutput(stderr.String()))
+	}
+	text := strings.TrimSuffix(string(output), "\n")
+	return strings.TrimSuffix(text, "\r"), nil
+}
+
+// commandOutput formats what a failed command printed for an error
+// message, or nothing if it printed nothing
+func commandOutput(output string) string {
+	if output = strings.TrimSpace(output); output == "" {
+		return ""
+	}
+	return ": " + output
+}
+
+// sensitiveCopyCommand returns a command that copies stdin to the clipboard
+// without it entering clipboard history, or nil if none is available
+func sensitiveCopyCommand() *exec.Cmd {
+	var name string
+	var args []string
+
+	switch runtime.GOOS {
+	case "darwin":
+		// The script on stdin adds the nspasteboard.org concealed and transient markers
+		name = "osascript"
+	case "windows":
+		name, args = "powershell", []string{"-NoProfile", "-NonInteractive", "-STA", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; " +
+				"$d = New-Object System.Windows.Forms.DataObject; " +
+				"$d.SetText([Console]::In.ReadToEnd()); " +
+				"$zero = New-Object System.IO.MemoryStream(,[byte[]](0,0,0,0)); " +
+				"$d.SetData('CanIncludeInClipboardHistory', $zero); " +
+				"$d.SetData('CanUploadToCloudClipboard', $zero); " +
+				"[System.Windows.Forms.Clipboard]::SetDataObject($d, $true)"}
+	default:
+		switch {
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			// Serves a single paste, then clears the clipboard
+			name, args = "wl-copy", []string{"--paste-once"}
+		case os.Getenv("DISPLAY") != "":
+			// Serves a single paste from the background, then exits
+			name, args = "xclip", []string{"-selection", "clipboard", "-loops", "1"}
+		}
+	}
+
+	if name == "" {
+		return nil
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return nil
+	}
+	return exec.Command(name, args...)
+}
+
+// concealedPasteboardScript builds an AppleScript that copies text and
+// marks it concealed and transient, so clipboard managers skip it
+func concealedPasteboardScript(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+	return `use framework "AppKit"
+set pb to current application's NSPasteboard's generalPasteboard()
+pb's clearContents()
+pb's setString:"` + escaped + `" forType:"public.utf8-plain-text"
+pb's setString:"" forType:"org.nspasteboard.ConcealedType"
+pb's setString:"" forType:"org.nspasteboard.TransientType"`
 }
 
 // Clear clears the clipboard (platform-dependent)
 func (c *ClipboardManager) Clear() error {
+	if c.copyCommand != nil {
+		return c.runCopyCommand("")
+	}
 	return clipboard.WriteAll("")
 }
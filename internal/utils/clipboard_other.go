@@ -0,0 +1,16 @@
+//go:build !windows
+
+package utils
+
+import "github.com/atotto/clipboard"
+
+// clipboardAvailable reports whether the clipboard can be used: a copy
+// tool was found (xclip, xsel, wl-clipboard or pbcopy) and it can reach a
+// display
+func clipboardAvailable() bool {
+	if clipboard.Unsupported {
+		return false
+	}
+	_, err := clipboard.ReadAll()
+	return err == nil
+}
@@ -0,0 +1,8 @@
+package utils
+
+// clipboardAvailable reports whether the clipboard can be used. Windows
+// always has one, and reading it fails while it holds no text, so a read
+// can't tell.
+func clipboardAvailable() bool {
+	return true
+}
@@ -0,0 +1,803 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The parts of the D-Bus wire protocol the Secret Service provider and
+// the keychain client need: connecting to the session bus, and
+// exchanging method calls, replies, errors and signals with their
+// arguments marshalled by signature.
+// Arguments are Go values by type code: byte (y), bool (b), int16 (n),
+// uint16 (q), int32 (i), uint32 (u), int64 (x), uint64 (t), float64 (d),
+// string (s), dbusObjectPath (o), dbusSignature (g) and dbusVariant (v).
+// Arrays are []interface{}, or []byte, []string, []dbusObjectPath and,
+// for dictionaries, map[string]string and map[string]dbusVariant; structs
+// and dictionary entries are []interface{} of their fields. Decoding gives
+// []byte for ay and []interface{} for every other array.
+
+// Message types
+const (
+	dbusMethodCall   = 1
+	dbusMethodReturn = 2
+	dbusError        = 3
+	dbusSignal       = 4
+)
+
+// dbusNoReplyExpected is the message flag of calls wanting no reply
+const dbusNoReplyExpected = 0x1
+
+// Header fields
+const (
+	dbusFieldPath        = 1
+	dbusFieldInterface   = 2
+	dbusFieldMember      = 3
+	dbusFieldErrorName   = 4
+	dbusFieldReplySerial = 5
+	dbusFieldDestination = 6
+	dbusFieldSender      = 7
+	dbusFieldSignature   = 8
+)
+
+// Limits: the longest message accepted, well below the protocol's 128
+// MiB, and the deepest nesting of containers
+const (
+	maxDBusMessage = 4 << 20
+	maxDBusDepth   = 64
+)
+
+// dbusObjectPath is an object path argument
+type dbusObjectPath string
+
+// dbusSignature is a signature argument
+type dbusSignature string
+
+// dbusVariant is a value with its own signature
+type dbusVariant struct {
+	Sig   string
+	Value interface{}
+}
+
+// dbusMessage is one message on the bus
+type dbusMessage struct {
+	Type        byte
+	Flags       byte
+	Serial      uint32
+	Path        dbusObjectPath
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   string
+	Body        []interface{}
+}
+
+// dbusCallError is an error message, or an error to answer a call with
+type dbusCallError struct {
+	Name    string
+	Message string
+}
+
+func (e *dbusCallError) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// dbusAlignment returns the alignment of the type starting with code
+func dbusAlignment(code byte) int {
+	switch code {
+	case 'n', 'q':
+		return 2
+	case 'b', 'i', 'u', 's', 'o', 'a', 'h':
+		return 4
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 1
+}
+
+// dbusSplitSignature splits off the first complete type of sig
+func dbusSplitSignature(sig string) (first, rest string, err error) {
+	if sig == "" {
+		return "", "", fmt.Errorf("empty signature")
+	}
+	switch sig[0] {
+	case 'a':
+		elem, rest, err := dbusSplitSignature(sig[1:])
+		if err != nil {
+			return "", "", err
+		}
+		return "a" + elem, rest, nil
+	case '(', '{':
+		closer := byte(')')
+		if sig[0] == '{' {
+			closer = '}'
+		}
+		depth := 0
+		for i := 0; i < len(sig); i++ {
+			switch sig[i] {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+				if depth == 0 {
+					if sig[i] != closer || i == 1 {
+						return "", "", fmt.Errorf("invalid signature %q", sig)
+					}
+					return sig[:i+1], sig[i+1:], nil
+				}
+			}
+		}
+		return "", "", fmt.Errorf("unbalanced signature %q", sig)
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'x', 't', 'd', 's', 'o', 'g', 'v', 'h':
+		return sig[:1], sig[1:], nil
+	}
+	return "", "", fmt.Errorf("unknown type %q in signature", sig[0])
+}
+
+// dbusSplitTypes splits sig into its complete types
+func dbusSplitTypes(sig string) ([]string, error) {
+	var types []string
+	for sig != "" {
+		first, rest, err := dbusSplitSignature(sig)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, first)
+		sig = rest
+	}
+	return types, nil
+}
+
+// dbusEncoder marshals values in little-endian byte order. Alignment is
+// relative to the start of buf, which must be the start of the message
+// or of its body.
+type dbusEncoder struct {
+	buf []byte
+}
+
+func (e *dbusEncoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *dbusEncoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+// encode appends v, of the single complete type sig
+func (e *dbusEncoder) encode(sig string, v interface{}) error {
+	mismatch := fmt.Errorf("cannot encode %T as %s", v, sig)
+	switch sig[0] {
+	case 'y':
+		b, ok := v.(byte)
+		if !ok {
+			return mismatch
+		}
+		e.buf = append(e.buf, b)
+	case 'b':
+		b, ok := v.(bool)
+		if !ok {
+			return mismatch
+		}
+		if b {
+			e.uint32(1)
+		} else {
+			e.uint32(0)
+		}
+	case 'n', 'q':
+		var n uint16
+		switch x := v.(type) {
+		case int16:
+			n = uint16(x)
+		case uint16:
+			n = x
+		default:
+			return mismatch
+		}
+		e.align(2)
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, n)
+	case 'i', 'u', 'h':
+		var n uint32
+		switch x := v.(type) {
+		case int32:
+			n = uint32(x)
+		case uint32:
+			n = x
+		default:
+			return mismatch
+		}
+		e.uint32(n)
+	case 'x', 't', 'd':
+		var n uint64
+		switch x := v.(type) {
+		case int64:
+			n = uint64(x)
+		case uint64:
+			n = x
+		case float64:
+			n = math.Float64bits(x)
+		default:
+			return mismatch
+		}
+		e.align(8)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, n)
+	case 's', 'o':
+		var s string
+		switch x := v.(type) {
+		case string:
+			s = x
+		case dbusObjectPath:
+			s = string(x)
+		default:
+			return mismatch
+		}
+		e.uint32(uint32(len(s)))
+		e.buf = append(append(e.buf, s...), 0)
+	case 'g':
+		var s string
+		switch x := v.(type) {
+		case string:
+			s = x
+		case dbusSignature:
+			s = string(x)
+		default:
+			return mismatch
+		}
+		if len(s) > 255 {
+			return fmt.Errorf("signature %q is too long", s)
+		}
+		e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+	case 'v':
+		variant, ok := v.(dbusVariant)
+		if !ok {
+			return mismatch
+		}
+		if err := e.encode("g", variant.Sig); err != nil {
+			return err
+		}
+		return e.encode(variant.Sig, variant.Value)
+	case 'a':
+		return e.encodeArray(sig[1:], v, mismatch)
+	case '(', '{':
+		fields, ok := v.([]interface{})
+		if !ok {
+			return mismatch
+		}
+		types, err := dbusSplitTypes(sig[1 : len(sig)-1])
+		if err != nil {
+			return err
+		}
+		if len(fields) != len(types) {
+			return fmt.Errorf("%s needs %d fields, got %d", sig, len(types), len(fields))
+		}
+		e.align(8)
+		for i, field := range fields {
+			if err := e.encode(types[i], field); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode type %s", sig)
+	}
+	return nil
+}
+
+// encodeArray appends an array of elements of type elem
+func (e *dbusEncoder) encodeArray(elem string, v interface{}, mismatch error) error {
+	var items []interface{}
+	switch x := v.(type) {
+	case []interface{}:
+		items = x
+	case []byte:
+		for _, b := range x {
+			items = append(items, b)
+		}
+	case []string:
+		for _, s := range x {
+			items = append(items, s)
+		}
+	case []dbusObjectPath:
+		for _, p := range x {
+			items = append(items, p)
+		}
+	case map[string]string:
+		for _, k := range sortedKeys(x) {
+			items = append(items, []interface{}{k, x[k]})
+		}
+	case map[string]dbusVariant:
+		for _, k := range sortedKeys(x) {
+			items = append(items, []interface{}{k, x[k]})
+		}
+	default:
+		return mismatch
+	}
+
+	e.uint32(0) // Patched below, once the length is known
+	lengthAt := len(e.buf) - 4
+	e.align(dbusAlignment(elem[0]))
+	start := len(e.buf)
+	for _, item := range items {
+		if err := e.encode(elem, item); err != nil {
+			return err
+		}
+	}
+	binary.LittleEndian.PutUint32(e.buf[lengthAt:], uint32(len(e.buf)-start))
+	return nil
+}
+
+// sortedKeys returns the keys of m in order, so dictionaries marshal the
+// same way every time
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dbusDecoder unmarshals values; like dbusEncoder, alignment is relative
+// to the start of buf
+type dbusDecoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+	depth int
+}
+
+var errDBusShort = errors.New("message ends early")
+
+func (d *dbusDecoder) align(n int) error {
+	for d.pos%n != 0 {
+		if d.pos >= len(d.buf) {
+			return errDBusShort
+		}
+		d.pos++
+	}
+	return nil
+}
+
+func (d *dbusDecoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errDBusShort
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *dbusDecoder) uint32() (uint32, error) {
+	if err := d.align(4); err != nil {
+		return 0, err
+	}
+	b, err := d.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint32(b), nil
+}
+
+// decode reads a value of the single complete type sig
+func (d *dbusDecoder) decode(sig string) (interface{}, error) {
+	switch sig[0] {
+	case 'y':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		n, err := d.uint32()
+		return n != 0, err
+	case 'n', 'q':
+		if err := d.align(2); err != nil {
+			return nil, err
+		}
+		b, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'n' {
+			return int16(d.order.Uint16(b)), nil
+		}
+		return d.order.Uint16(b), nil
+	case 'i':
+		n, err := d.uint32()
+		return int32(n), err
+	case 'u', 'h':
+		return d.uint32()
+	case 'x', 't', 'd':
+		if err := d.align(8); err != nil {
+			return nil, err
+		}
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		n := d.order.Uint64(b)
+		switch sig[0] {
+		case 'x':
+			return int64(n), nil
+		case 'd':
+			return math.Float64frombits(n), nil
+		}
+		return n, nil
+	case 's', 'o':
+		n, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.take(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'o' {
+			return dbusObjectPath(b[:n]), nil
+		}
+		return string(b[:n]), nil
+	case 'g':
+		n, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.take(int(n[0]) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return dbusSignature(b[:n[0]]), nil
+	}
+
+	// Containers
+	if d.depth++; d.depth > maxDBusDepth {
+		return nil, fmt.Errorf("containers nested too deeply")
+	}
+	defer func() { d.depth-- }()
+	switch sig[0] {
+	case 'v':
+		s, err := d.decode("g")
+		if err != nil {
+			return nil, err
+		}
+		inner, rest, err := dbusSplitSignature(string(s.(dbusSignature)))
+		if err != nil || rest != "" {
+			return nil, fmt.Errorf("invalid variant signature %q", s)
+		}
+		value, err := d.decode(inner)
+		return dbusVariant{Sig: inner, Value: value}, err
+	case 'a':
+		n, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.align(dbusAlignment(sig[1])); err != nil {
+			return nil, err
+		}
+		end := d.pos + int(n)
+		if end > len(d.buf) {
+			return nil, errDBusShort
+		}
+		if sig[1] == 'y' {
+			b, _ := d.take(int(n))
+			return append([]byte{}, b...), nil
+		}
+		items := []interface{}{}
+		for d.pos < end {
+			item, err := d.decode(sig[1:])
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if d.pos != end {
+			return nil, fmt.Errorf("array elements overrun its length")
+		}
+		return items, nil
+	case '(', '{':
+		if err := d.align(8); err != nil {
+			return nil, err
+		}
+		types, err := dbusSplitTypes(sig[1 : len(sig)-1])
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]interface{}, 0, len(types))
+		for _, t := range types {
+			field, err := d.decode(t)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field)
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("cannot decode type %s", sig)
+}
+
+// marshal encodes the message, little-endian
+func (m *dbusMessage) marshal() ([]byte, error) {
+	types, err := dbusSplitTypes(m.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if len(types) != len(m.Body) {
+		return nil, fmt.Errorf("signature %q needs %d arguments, got %d", m.Signature, len(types), len(m.Body))
+	}
+	body := &dbusEncoder{}
+	for i, t := range types {
+		if err := body.encode(t, m.Body[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var fields []interface{}
+	addField := func(code byte, sig string, value interface{}) {
+		fields = append(fields, []interface{}{code, dbusVariant{Sig: sig, Value: value}})
+	}
+	if m.Path != "" {
+		addField(dbusFieldPath, "o", m.Path)
+	}
+	if m.Interface != "" {
+		addField(dbusFieldInterface, "s", m.Interface)
+	}
+	if m.Member != "" {
+		addField(dbusFieldMember, "s", m.Member)
+	}
+	if m.ErrorName != "" {
+		addField(dbusFieldErrorName, "s", m.ErrorName)
+	}
+	if m.ReplySerial != 0 {
+		addField(dbusFieldReplySerial, "u", m.ReplySerial)
+	}
+	if m.Destination != "" {
+		addField(dbusFieldDestination, "s", m.Destination)
+	}
+	if m.Signature != "" {
+		addField(dbusFieldSignature, "g", m.Signature)
+	}
+
+	header := &dbusEncoder{buf: []byte{'l', m.Type, m.Flags, 1}}
+	header.uint32(uint32(len(body.buf)))
+	header.uint32(m.Serial)
+	if err := header.encode("a(yv)", fields); err != nil {
+		return nil, err
+	}
+	header.align(8)
+	return append(header.buf, body.buf...), nil
+}
+
+// readDBusMessage reads and decodes one message
+func readDBusMessage(r io.Reader) (*dbusMessage, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid byte order %q", fixed[0])
+	}
+	bodyLen, fieldsLen := order.Uint32(fixed[4:]), order.Uint32(fixed[12:])
+	headerLen := (16 + int(fieldsLen) + 7) &^ 7
+	if bodyLen > maxDBusMessage || fieldsLen > maxDBusMessage || headerLen+int(bodyLen) > maxDBusMessage {
+		return nil, fmt.Errorf("message too large")
+	}
+	buf := make([]byte, headerLen+int(bodyLen))
+	copy(buf, fixed)
+	if _, err := io.ReadFull(r, buf[16:]); err != nil {
+		return nil, err
+	}
+
+	m := &dbusMessage{Type: fixed[1], Flags: fixed[2], Serial: order.Uint32(fixed[8:])}
+	header := &dbusDecoder{buf: buf[:16+fieldsLen], pos: 12, order: order}
+	raw, err := header.decode("a(yv)")
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	for _, f := range raw.([]interface{}) {
+		field := f.([]interface{})
+		value := field[1].(dbusVariant).Value
+		switch field[0].(byte) {
+		case dbusFieldPath:
+			m.Path, _ = value.(dbusObjectPath)
+		case dbusFieldInterface:
+			m.Interface, _ = value.(string)
+		case dbusFieldMember:
+			m.Member, _ = value.(string)
+		case dbusFieldErrorName:
+			m.ErrorName, _ = value.(string)
+		case dbusFieldReplySerial:
+			m.ReplySerial, _ = value.(uint32)
+		case dbusFieldDestination:
+			m.Destination, _ = value.(string)
+		case dbusFieldSender:
+			m.Sender, _ = value.(string)
+		case dbusFieldSignature:
+			sig, _ := value.(dbusSignature)
+			m.Signature = string(sig)
+		}
+	}
+
+	types, err := dbusSplitTypes(m.Signature)
+	if err != nil {
+		return nil, err
+	}
+	body := &dbusDecoder{buf: buf[headerLen:], order: order}
+	for _, t := range types {
+		arg, err := body.decode(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body: %w", err)
+		}
+		m.Body = append(m.Body, arg)
+	}
+	return m, nil
+}
+
+// dbusConn is a connection to a message bus. It is not safe for
+// concurrent use.
+type dbusConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	serial uint32
+	name   string // Unique name assigned by the bus
+}
+
+// dialSessionBus connects to the session bus of $DBUS_SESSION_BUS_ADDRESS,
+// or $XDG_RUNTIME_DIR/bus, and authenticates as the current user
+func dialSessionBus() (*dbusConn, error) {
+	address := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if address == "" {
+		if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+			address = "unix:path=" + filepath.Join(runtimeDir, "bus")
+		}
+	}
+	if address == "" {
+		return nil, fmt.Errorf("no session bus: DBUS_SESSION_BUS_ADDRESS is not set")
+	}
+
+	var errs []error
+	for _, entry := range strings.Split(address, ";") {
+		socket, err := dbusSocketPath(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c := &dbusConn{conn: conn, r: bufio.NewReader(conn)}
+		if err := c.auth(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		reply, err := c.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", "")
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if len(reply) == 1 {
+			c.name, _ = reply[0].(string)
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("cannot connect to the session bus: %w", errors.Join(errs...))
+}
+
+// dbusSocketPath returns the Unix socket of a bus address entry, e.g.
+// unix:path=/run/user/1000/bus
+func dbusSocketPath(entry string) (string, error) {
+	transport, params, ok := strings.Cut(entry, ":")
+	if !ok || transport != "unix" {
+		return "", fmt.Errorf("unsupported bus address %q", entry)
+	}
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(param, "=")
+		value, err := url.PathUnescape(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid bus address %q", entry)
+		}
+		switch key {
+		case "path":
+			return value, nil
+		case "abstract":
+			return "@" + value, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported bus address %q", entry)
+}
+
+// auth runs the EXTERNAL authentication, which the bus checks against
+// the credentials of the socket
+func (c *dbusConn) auth() error {
+	uid := os.Getuid()
+	if uid < 0 {
+		return fmt.Errorf("D-Bus authentication is not supported on this platform")
+	}
+	id := hex.EncodeToString([]byte(strconv.Itoa(uid)))
+	if _, err := io.WriteString(c.conn, "\x00AUTH EXTERNAL "+id+"\r\n"); err != nil {
+		return err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("D-Bus authentication failed: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("D-Bus authentication rejected: %s", strings.TrimSpace(line))
+	}
+	_, err = io.WriteString(c.conn, "BEGIN\r\n")
+	return err
+}
+
+// send writes m with the next serial number
+func (c *dbusConn) send(m *dbusMessage) error {
+	c.serial++
+	m.Serial = c.serial
+	data, err := m.marshal()
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(data)
+	return err
+}
+
+// read reads the next message
+func (c *dbusConn) read() (*dbusMessage, error) {
+	return readDBusMessage(c.r)
+}
+
+// call sends a method call and waits for its reply, dropping any other
+// message that arrives in between. The provider only uses it while
+// setting up, before serving calls.
+func (c *dbusConn) call(dest string, path dbusObjectPath, iface, member, sig string, args ...interface{}) ([]interface{}, error) {
+	m := &dbusMessage{Type: dbusMethodCall, Path: path, Interface: iface, Member: member, Destination: dest, Signature: sig, Body: args}
+	if err := c.send(m); err != nil {
+		return nil, err
+	}
+	for {
+		reply, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		if reply.ReplySerial != m.Serial {
+			continue
+		}
+		if reply.Type == dbusError {
+			msg := ""
+			if len(reply.Body) > 0 {
+				msg, _ = reply.Body[0].(string)
+			}
+			return nil, &dbusCallError{Name: reply.ErrorName, Message: msg}
+		}
+		return reply.Body, nil
+	}
+}
+
+// Close closes the connection
+func (c *dbusConn) Close() error {
+	return c.conn.Close()
+}
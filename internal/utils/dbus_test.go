@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// encodeDBus marshals values of the complete types in sig one after the
+// other, as a message body is
+func encodeDBus(t *testing.T, sig string, values ...interface{}) []byte {
+	t.Helper()
+	types, err := dbusSplitTypes(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(types) != len(values) {
+		t.Fatalf("%s needs %d values, got %d", sig, len(types), len(values))
+	}
+	e := &dbusEncoder{}
+	for i, typ := range types {
+		if err := e.encode(typ, values[i]); err != nil {
+			t.Fatalf("encode(%s) failed: %v", typ, err)
+		}
+	}
+	return e.buf
+}
+
+func TestDBusCodecRoundTrip(t *testing.T) {
+	secret := []interface{}{dbusObjectPath("/s/1"), []byte{1, 2}, []byte("pw"), "text/plain"}
+
+	tests := []struct {
+		name  string
+		sig   string
+		value interface{}
+		want  interface{} // As decoded; nil = value
+	}{
+		{"Byte", "y", byte(0xfe), nil},
+		{"Bool", "b", true, nil},
+		{"Int16", "n", int16(-2), nil},
+		{"Uint16", "q", uint16(0xbeef), nil},
+		{"Int32", "i", int32(-70000), nil},
+		{"Uint32", "u", uint32(0xdeadbeef), nil},
+		{"Int64", "x", int64(-1 << 40), nil},
+		{"Uint64", "t", uint64(1 << 63), nil},
+		{"Double", "d", 2.5, nil},
+		{"String", "s", "héllo", nil},
+		{"Empty string", "s", "", nil},
+		{"Object path", "o", dbusObjectPath("/org/freedesktop/secrets"), nil},
+		{"Signature", "g", "a{sv}", dbusSignature("a{sv}")},
+		{"Variant", "v", dbusVariant{Sig: "t", Value: uint64(7)}, nil},
+		{"Variant of bytes", "v", dbusVariant{Sig: "ay", Value: []byte{0, 1}}, nil},
+		{"Variant of strings", "v", dbusVariant{Sig: "as", Value: []string{"a"}}, dbusVariant{Sig: "as", Value: []interface{}{"a"}}},
+		{"Bytes", "ay", []byte("secret"), nil},
+		{"Empty bytes", "ay", []byte{}, nil},
+		{"Strings", "as", []string{"ab", "c"}, []interface{}{"ab", "c"}},
+		{"Object paths", "ao", []dbusObjectPath{"/a", "/b"}, []interface{}{dbusObjectPath("/a"), dbusObjectPath("/b")}},
+		{"Empty object paths", "ao", []dbusObjectPath{}, []interface{}{}},
+		{"String dictionary", "a{ss}", map[string]string{"site": "a.example", "id": "1"},
+			[]interface{}{[]interface{}{"id", "1"}, []interface{}{"site", "a.example"}}},
+		{"Variant dictionary", "a{sv}", map[string]dbusVariant{"Label": {Sig: "s", Value: "x"}, "Locked": {Sig: "b", Value: false}},
+			[]interface{}{[]interface{}{"Label", dbusVariant{Sig: "s", Value: "x"}}, []interface{}{"Locked", dbusVariant{Sig: "b", Value: false}}}},
+		{"Empty variant dictionary", "a{sv}", map[string]dbusVariant{}, []interface{}{}},
+		{"Secret", "(oayays)", secret, nil},
+		{"Secrets", "a{o(oayays)}", []interface{}{[]interface{}{dbusObjectPath("/i/1"), secret}}, nil},
+		{"Header fields", "a(yv)", []interface{}{
+			[]interface{}{byte(dbusFieldPath), dbusVariant{Sig: "o", Value: dbusObjectPath("/")}},
+			[]interface{}{byte(dbusFieldReplySerial), dbusVariant{Sig: "u", Value: uint32(3)}},
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		// Each value follows a byte, so that it has to be aligned
+		t.Run(tt.name, func(t *testing.T) {
+			buf := encodeDBus(t, "y"+tt.sig, byte(1), tt.value)
+
+			d := &dbusDecoder{buf: buf, pos: 1, order: binary.LittleEndian}
+			got, err := d.decode(tt.sig)
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.value
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %#v, got %#v", want, got)
+			}
+			if d.pos != len(buf) {
+				t.Errorf("Expected all %d bytes to be read, got %d", len(buf), d.pos)
+			}
+		})
+	}
+}
+
+func TestDBusEncodingLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		sig    string
+		values []interface{}
+		want   string // Hex
+	}{
+		{"Uint16 padding", "yq", []interface{}{byte(1), uint16(2)}, "01000200"},
+		{"Uint64 padding", "yt", []interface{}{byte(1), uint64(2)}, "01000000000000000200000000000000"},
+		{"Struct padding", "y(yu)", []interface{}{byte(1), []interface{}{byte(5), uint32(6)}},
+			"0100000000000000" + "05000000" + "06000000"},
+		{"Variant", "yv", []interface{}{byte(1), dbusVariant{Sig: "t", Value: uint64(1)}},
+			"01" + "017400" + "00000000" + "0100000000000000"},
+		{"String array", "as", []interface{}{[]string{"ab", "c"}},
+			"0e000000" + "02000000" + "616200" + "00" + "01000000" + "6300"},
+		// The padding before the first entry is not part of the length,
+		// and is there even with no entries
+		{"Empty dictionary", "ya{sv}", []interface{}{byte(1), map[string]dbusVariant{}}, "01000000" + "00000000"},
+		{"Empty dictionary padded", "a{sv}", []interface{}{map[string]dbusVariant{}}, "00000000" + "00000000"},
+		{"Dictionary", "a{sv}", []interface{}{map[string]dbusVariant{"a": {Sig: "u", Value: uint32(7)}}},
+			"10000000" + "00000000" + "01000000" + "6100" + "017500" + "000000" + "07000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hex.EncodeToString(encodeDBus(t, tt.sig, tt.values...))
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDBusEncodeMismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		sig   string
+		value interface{}
+	}{
+		{"Wrong type", "u", "1"},
+		{"Wrong array", "as", []int{1}},
+		{"Missing struct field", "(oayays)", []interface{}{dbusObjectPath("/"), []byte{}}},
+		{"Variant with wrong value", "v", dbusVariant{Sig: "s", Value: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (&dbusEncoder{}).encode(tt.sig, tt.value); err == nil {
+				t.Errorf("Expected encoding %#v as %s to fail", tt.value, tt.sig)
+			}
+		})
+	}
+}
+
+func TestDBusDecodeMalformed(t *testing.T) {
+	nested := bytes.Repeat([]byte("\x01v\x00"), maxDBusDepth+1)
+
+	tests := []struct {
+		name string
+		sig  string
+		data string // Hex
+	}{
+		{"Short", "u", "0100"},
+		{"String past the end", "s", "05000000616200"},
+		{"Array past the end", "ay", "0a000000" + "0102"},
+		{"Array overrun", "aq", "03000000" + "01000200"},
+		{"Invalid variant signature", "v", "02" + "737300" + "00"},
+		{"Too deep", "v", hex.EncodeToString(nested)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := &dbusDecoder{buf: data, order: binary.LittleEndian}
+			if got, err := d.decode(tt.sig); err == nil {
+				t.Errorf("Expected an error, got %#v", got)
+			}
+		})
+	}
+}
+
+func TestDBusSplitSignature(t *testing.T) {
+	tests := []struct {
+		sig  string
+		want []string // nil = invalid
+	}{
+		{"sv", []string{"s", "v"}},
+		{"a{sv}s", []string{"a{sv}", "s"}},
+		{"a{o(oayays)}", []string{"a{o(oayays)}"}},
+		{"(oayays)b", []string{"(oayays)", "b"}},
+		{"aoao", []string{"ao", "ao"}},
+		{"a", nil},
+		{"(s", nil},
+		{"()", nil},
+		{"{ss)", nil},
+		{"z", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sig, func(t *testing.T) {
+			got, err := dbusSplitTypes(tt.sig)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("Expected %q to be invalid, got %q", tt.sig, got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestDBusMessageRoundTrip(t *testing.T) {
+	sent := &dbusMessage{
+		Type:        dbusMethodCall,
+		Flags:       dbusNoReplyExpected,
+		Serial:      42,
+		Path:        secretCollectionPath,
+		Interface:   ifaceSecretCollection,
+		Member:      "SearchItems",
+		Destination: secretServiceName,
+		Signature:   "a{ss}",
+		Body:        []interface{}{map[string]string{"site": "a.example"}},
+	}
+	data, err := sent.marshal()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	got, err := readDBusMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readDBusMessage failed: %v", err)
+	}
+	want := *sent
+	want.Body = []interface{}{[]interface{}{[]interface{}{"site", "a.example"}}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("Expected %+v, got %+v", want, *got)
+	}
+
+	reply := &dbusMessage{Type: dbusError, Serial: 43, ReplySerial: 42, ErrorName: "org.freedesktop.DBus.Error.Failed"}
+	data, err = reply.marshal()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	got, err = readDBusMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readDBusMessage failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, reply) {
+		t.Errorf("Expected %+v, got %+v", reply, got)
+	}
+
+	if _, err := readDBusMessage(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Error("Expected a truncated message to be rejected")
+	}
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// Statuses of a diagnostic check
+const (
+	DiagnosticPass = "pass"
+	DiagnosticWarn = "warn" // Works, but not as it should for real secrets
+	DiagnosticFail = "fail"
+	DiagnosticSkip = "skip" // Not available here, e.g. no clipboard
+)
+
+// distributionSamples is how many characters each distribution check counts
+const distributionSamples = 20000
+
+// DiagnosticCheck is the outcome of one check of "passman test"
+type DiagnosticCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// DiagnosticReport is the outcome of every check, in the order they ran
+type DiagnosticReport struct {
+	StartedAt time.Time         `json:"started_at"`
+	Checks    []DiagnosticCheck `json:"checks"`
+	Failed    int               `json:"failed"`
+}
+
+// RunDiagnostics checks that passman can be trusted on this machine: the
+// config, the random source and the evenness of what is drawn from it,
+// every generator, the history encryption, the clipboard and the bundled
+// wordlists. Nothing is written to disk, and the clipboard is restored.
+func RunDiagnostics(ctx context.Context) *DiagnosticReport {
+	report := &DiagnosticReport{StartedAt: time.Now()}
+
+	cfg, err := config.Load()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		report.add("config", DiagnosticFail, err.Error())
+		cfg = config.Default()
+	} else {
+		report.add("config", DiagnosticPass, "")
+	}
+
+	switch err := generator.CheckRandomSource(); {
+	case err != nil:
+		report.add("random source", DiagnosticFail, err.Error())
+	case generator.InsecureSeeded():
+		report.add("random source", DiagnosticWarn, "an insecure seed is in use; output is predictable")
+	default:
+		report.add("random source", DiagnosticPass, "crypto/rand")
+	}
+
+	report.addDistribution(ctx, "distribution (random)", generator.NewRandomGenerator(50, generator.Lowercase), "abcdefghijklmnopqrstuvwxyz")
+	report.addDistribution(ctx, "distribution (pin)", generator.NewPINGenerator(50), "0123456789")
+
+	report.add(checkGenerators(ctx))
+	report.add(checkHistoryEncryption(cfg.HistoryKDF))
+	report.add(checkClipboard(&cfg))
+
+	for _, info := range generator.BundledWordlists() {
+		if err := generator.CheckWordlist(info.ID); err != nil {
+			report.add("wordlist "+info.ID, DiagnosticFail, err.Error())
+		} else {
+			report.add("wordlist "+info.ID, DiagnosticPass, "")
+		}
+	}
+
+	if _, err := NewManager(&cfg); err != nil {
+		report.add("utilities", DiagnosticFail, err.Error())
+	} else {
+		report.add("utilities", DiagnosticPass, "")
+	}
+	return report
+}
+
+// add records the outcome of a check
+func (r *DiagnosticReport) add(name, status, detail string) {
+	r.Checks = append(r.Checks, DiagnosticCheck{Name: name, Status: status, Detail: detail})
+	if status == DiagnosticFail {
+		r.Failed++
+	}
+}
+
+// addDistribution runs a chi-squared test of gen over alphabet
+func (r *DiagnosticReport) addDistribution(ctx context.Context, name string, gen generator.Generator, alphabet string) {
+	result, err := generator.CheckDistribution(ctx, gen, alphabet, distributionSamples)
+	switch {
+	case err != nil:
+		r.add(name, DiagnosticFail, err.Error())
+	case !result.Passed():
+		r.add(name, DiagnosticFail, "uneven output: "+result.String())
+	default:
+		r.add(name, DiagnosticPass, result.String())
+	}
+}
+
+// checkGenerators generates one secret of every generator type
+func checkGenerators(ctx context.Context) (string, string, string) {
+	types := generator.Names()
+	for _, name := range types {
+		task := JobTask{Type: name}
+		gen, err := task.BuildGenerator()
+		if err == nil {
+			err = gen.Validate()
+		}
+		var secret string
+		if err == nil {
+			secret, err = gen.Generate(ctx)
+		}
+		if err == nil && secret == "" {
+			err = fmt.Errorf("empty output")
+		}
+		if err != nil {
+			return "generators", DiagnosticFail, fmt.Sprintf("%s: %v", name, err)
+		}
+	}
+	return "generators", DiagnosticPass, fmt.Sprintf("%d types", len(types))
+}
+
+// checkHistoryEncryption encrypts and decrypts a sample with a throwaway
+// passphrase and the configured KDF, and checks that another passphrase
+// cannot decrypt it
+func checkHistoryEncryption(kdf string) (string, string, string) {
+	const name = "history encryption"
+	sample := []byte(`[{"password":"diagnostics"}]`)
+
+	history := NewHistoryManager(true, "passman-diagnostics", 0)
+	history.SetKDF(kdf)
+	sealed, err := history.encrypt(sample)
+	if err != nil {
+		return name, DiagnosticFail, err.Error()
+	}
+	if bytes.Contains(sealed, sample) {
+		return name, DiagnosticFail, "plaintext visible in the encrypted data"
+	}
+	opened, err := history.decrypt(sealed)
+	if err != nil {
+		return name, DiagnosticFail, err.Error()
+	}
+	if !bytes.Equal(opened, sample) {
+		return name, DiagnosticFail, "decrypted data differs from the original"
+	}
+
+	history.SetPassphrase("another passphrase")
+	if _, err := history.decrypt(sealed); err == nil {
+		return name, DiagnosticFail, "a wrong passphrase decrypted the data"
+	}
+	return name, DiagnosticPass, history.KDF()
+}
+
+// checkClipboard copies a random value, reads it back and puts back what
+// was on the clipboard before, with the configured copy and paste
+// commands if any
+func checkClipboard(cfg *config.Config) (string, string, string) {
+	const name = "clipboard"
+	clipboard := NewClipboardManager()
+	if err := clipboard.SetCommands(cfg.ClipboardCopyCommand, cfg.ClipboardPasteCommand); err != nil {
+		return name, DiagnosticFail, err.Error()
+	}
+	if !clipboard.IsAvailable() {
+		return name, DiagnosticSkip, "no clipboard available"
+	}
+
+	previous, _ := clipboard.Paste()
+	defer func() {
+		if previous == "" {
+			_ = clipboard.Clear()
+		} else {
+			_ = clipboard.Copy(previous)
+		}
+	}()
+
+	probe := fmt.Sprintf("passman-diagnostics-%d", time.Now().UnixNano())
+	if err := clipboard.Copy(probe); err != nil {
+		return name, DiagnosticFail, err.Error()
+	}
+	pasted, err := clipboard.Paste()
+	if err != nil {
+		return name, DiagnosticFail, err.Error()
+	}
+	if pasted != probe {
+		return name, DiagnosticFail, "read back something other than what was copied"
+	}
+	return name, DiagnosticPass, ""
+}
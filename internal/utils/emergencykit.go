@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// kitGroupSize is how many characters of a secret are printed together
+const kitGroupSize = 4
+
+// kitQRSize is the width of each QR code in pixels
+const kitQRSize = 256
+
+// kitEntry is one secret of the emergency kit as the page shows it
+type kitEntry struct {
+	Description string
+	Type        string
+	Created     string
+	Length      int
+	Groups      []string
+	QRCode      template.URL // PNG data URI, empty when the secret is too long for a code
+}
+
+// kitPage is the template of the emergency kit. It is a single page with
+// inline styles and images, so it prints the same anywhere and loads
+// nothing from the network.
+var kitPage = template.Must(template.New("kit").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Passman emergency kit</title>
+<style>
+  body { font-family: sans-serif; color: #000; background: #fff; margin: 2em; }
+  h1 { font-size: 1.4em; margin-bottom: 0.2em; }
+  .meta { color: #444; font-size: 0.9em; }
+  .note { border: 1px solid #000; padding: 0.6em 1em; margin: 1em 0 1.5em; font-size: 0.9em; }
+  .entry { display: flex; gap: 1.5em; align-items: center; border-top: 1px solid #999; padding: 1em 0; break-inside: avoid; page-break-inside: avoid; }
+  .entry img { width: 3.2cm; height: 3.2cm; flex: none; }
+  .entry h2 { font-size: 1.1em; margin: 0 0 0.3em; }
+  .secret { font-family: "DejaVu Sans Mono", Menlo, Consolas, monospace; font-size: 1.35em; letter-spacing: 0.08em; word-spacing: 0.6em; margin: 0.4em 0; }
+  .secret span { white-space: pre; }
+  .field { font-size: 0.85em; color: #444; }
+  .written { margin-top: 0.6em; font-size: 0.85em; }
+  @media print {
+    body { margin: 1cm; }
+    .noprint { display: none; }
+  }
+</style>
+</head>
+<body>
+<h1>Passman emergency kit</h1>
+<div class="meta">Printed {{.Created}} &middot; {{len .Entries}} secrets</div>
+<div class="note">
+  Keep this sheet somewhere safe and offline, like a locked drawer or a safe.
+  Secrets are printed in groups of {{.GroupSize}} characters; the spaces between
+  groups are not part of them, and &#9251; marks a real space. Each QR code holds
+  the exact secret.
+  <span class="noprint">Delete this file once it is printed.</span>
+</div>
+{{range .Entries}}
+<div class="entry">
+  {{if .QRCode}}<img src="{{.QRCode}}" alt="QR code">{{end}}
+  <div>
+    <h2>{{if .Description}}{{.Description}}{{else}}Untitled{{end}}</h2>
+    <div class="secret">{{range .Groups}}<span>{{.}}</span> {{end}}</div>
+    <div class="field">{{.Type}} &middot; {{.Length}} characters &middot; created {{.Created}}</div>
+    <div class="written">Last changed: ____________________</div>
+  </div>
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// groupSecret splits a secret into groups of kitGroupSize characters to
+// copy by hand, with spaces made visible
+func groupSecret(secret string) []string {
+	runes := []rune(strings.ReplaceAll(secret, " ", "␣"))
+	var groups []string
+	for len(runes) > 0 {
+		n := min(kitGroupSize, len(runes))
+		groups = append(groups, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return groups
+}
+
+// exportKit exports entries as a printable HTML emergency kit
+func (e *ExportManager) exportKit(data *exportData, w io.Writer) error {
+	page := struct {
+		Created   string
+		GroupSize int
+		Entries   []kitEntry
+	}{
+		Created:   time.Now().Format("January 2, 2006"),
+		GroupSize: kitGroupSize,
+	}
+
+	for _, entry := range data.entries {
+		kit := kitEntry{
+			Description: entry.Description,
+			Type:        entry.Type,
+			Created:     entry.CreatedAt.Format("January 2, 2006"),
+			Length:      len([]rune(entry.Password)),
+			Groups:      groupSecret(entry.Password),
+		}
+		png, err := qrcode.Encode(entry.Password, qrcode.Medium, kitQRSize)
+		if err == nil {
+			kit.QRCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+		}
+		page.Entries = append(page.Entries, kit)
+	}
+
+	if err := kitPage.Execute(w, page); err != nil {
+		return fmt.Errorf("failed to write emergency kit: %w", err)
+	}
+	return nil
+}
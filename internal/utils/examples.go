@@ -102,18 +102,19 @@ func Example() error {
 		})
 	}
 
-	// Export in different formats
-	formats := []ExportFormat{FormatText, FormatJSON, FormatCSV}
-	for _, format := range formats {
+	// Export in different formats at once
+	var targets []ExportTarget
+	for _, format := range []ExportFormat{FormatText, FormatJSON, FormatCSV} {
 		filename := manager.Export.GetSuggestedFilename(format, "example")
-		fullPath := cfg.GetExportPath(filename)
-		
-		if err := manager.Export.Export(entries, format, fullPath); err != nil {
-			fmt.Printf("Warning: Failed to export as %s: %v\n", format, err)
+		targets = append(targets, ExportTarget{Format: format, Path: cfg.GetExportPath(filename)})
+	}
+	manager.Export.ExportAll(entries, targets, func(p ExportProgress) {
+		if p.Result.Err != nil {
+			fmt.Printf("Warning: Failed to export as %s: %v\n", p.Result.Target.Format, p.Result.Err)
 		} else {
-			fmt.Printf("Exported passwords to %s\n", fullPath)
+			fmt.Printf("[%d/%d] Exported passwords to %s\n", p.Done, p.Total, p.Result.Target.Path)
 		}
-	}
+	})
 
 	// Display system information
 	fmt.Println("\nSystem Information:")
@@ -3,10 +3,14 @@ package utils
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,8 +21,13 @@ const (
 	FormatText ExportFormat = "txt"
 	FormatJSON ExportFormat = "json"
 	FormatCSV  ExportFormat = "csv"
+	FormatHTML ExportFormat = "html" // Printable emergency kit with QR codes
 )
 
+// StdoutPath is the export path that writes to standard output, so
+// exports can be piped to other programs
+const StdoutPath = "-"
+
 // PasswordEntry represents a password entry for export
 type PasswordEntry struct {
 	Password    string    `json:"password"`
@@ -26,14 +35,34 @@ type PasswordEntry struct {
 	Type        string    `json:"type"`
 	CreatedAt   time.Time `json:"created_at"`
 	Description string    `json:"description,omitempty"`
+	Occurrences int       `json:"occurrences,omitempty"` // Times seen in history when deduplicated
+}
+
+// DedupOptions controls how duplicate history entries are merged for export
+type DedupOptions struct {
+	KeepFirstSeen bool // Date each password by its earliest generation instead of its latest
+	MergeLabels   bool // Combine the distinct descriptions of all duplicates
 }
 
+// DefaultExportMode is the permissions of exported files unless
+// SetFileMode changes them: readable by their owner only
+const DefaultExportMode os.FileMode = 0600
+
 // ExportManager handles password export operations
-type ExportManager struct{}
+type ExportManager struct {
+	fileMode    os.FileMode
+	encryptTool string   // age or gpg, empty for plaintext
+	recipients  []string // Who encrypted exports are for
+}
 
 // NewExportManager creates a new export manager instance
 func NewExportManager() *ExportManager {
-	return &ExportManager{}
+	return &ExportManager{fileMode: DefaultExportMode}
+}
+
+// SetFileMode sets the permissions of the files exports write
+func (e *ExportManager) SetFileMode(mode os.FileMode) {
+	e.fileMode = mode.Perm()
 }
 
 // ExportSingle exports a single password to a file
@@ -49,71 +78,366 @@ func (e *ExportManager) ExportSingle(password, description string, format Export
 	return e.Export([]PasswordEntry{entry}, format, filePath)
 }
 
+// UniquePasswords converts history entries to export entries with each
+// password appearing once, in order of first appearance in entries.
+// Repeated test generations collapse into a single entry whose
+// Occurrences records how often the password was seen.
+func UniquePasswords(entries []HistoryEntry, opts DedupOptions) []PasswordEntry {
+	var unique []PasswordEntry
+	index := make(map[string]int)
+	labels := make(map[string]map[string]bool)
+
+	for _, entry := range entries {
+		i, seen := index[entry.Password]
+		if !seen {
+			index[entry.Password] = len(unique)
+			labels[entry.Password] = map[string]bool{entry.Description: true}
+			unique = append(unique, PasswordEntry{
+				Password:    entry.Password,
+				Length:      len(entry.Password),
+				Type:        entry.Type,
+				CreatedAt:   entry.CreatedAt,
+				Description: entry.Description,
+				Occurrences: 1,
+			})
+			continue
+		}
+
+		merged := &unique[i]
+		merged.Occurrences++
+
+		earlier := entry.CreatedAt.Before(merged.CreatedAt)
+		if earlier == opts.KeepFirstSeen && !entry.CreatedAt.Equal(merged.CreatedAt) {
+			merged.CreatedAt = entry.CreatedAt
+			if !opts.MergeLabels {
+				merged.Type = entry.Type
+				merged.Description = entry.Description
+			}
+		}
+
+		if opts.MergeLabels && entry.Description != "" && !labels[entry.Password][entry.Description] {
+			labels[entry.Password][entry.Description] = true
+			if merged.Description == "" {
+				merged.Description = entry.Description
+			} else {
+				merged.Description += "; " + entry.Description
+			}
+		}
+	}
+
+	return unique
+}
+
 // Export exports multiple password entries to a file
 func (e *ExportManager) Export(entries []PasswordEntry, format ExportFormat, filePath string) error {
+	data, err := serializeEntries(entries)
+	if err != nil {
+		return err
+	}
+	return e.export(data, format, filePath)
+}
+
+// ExportTo writes entries to w in the given format, e.g. to a pipe or a
+// network connection instead of a file
+func (e *ExportManager) ExportTo(w io.Writer, entries []PasswordEntry, format ExportFormat) error {
+	data, err := serializeEntries(entries)
+	if err != nil {
+		return err
+	}
+	return e.write(data, format, w)
+}
+
+// export writes serialized entries to a file in the given format, or to
+// standard output when filePath is StdoutPath
+func (e *ExportManager) export(data *exportData, format ExportFormat, filePath string) error {
+	if !format.valid() {
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+	if filePath == StdoutPath {
+		return e.write(data, format, os.Stdout)
+	}
+
 	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	// Write a temporary file beside the target and rename it into place,
+	// so the export is never seen half written or with loose permissions
+	file, err := os.CreateTemp(dir, "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath) // Fails harmlessly once renamed
+
+	if err := file.Chmod(e.fileMode); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := e.write(data, format, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// write writes serialized entries to w in the given format, encrypted
+// when SetEncryption chose a tool
+func (e *ExportManager) write(data *exportData, format ExportFormat, w io.Writer) error {
+	if e.encryptTool != "" {
+		return e.encrypt(w, func(plain io.Writer) error {
+			return e.writePlain(data, format, plain)
+		})
+	}
+	return e.writePlain(data, format, w)
+}
+
+// writePlain writes serialized entries to w in the given format
+func (e *ExportManager) writePlain(data *exportData, format ExportFormat, w io.Writer) error {
 	switch format {
 	case FormatText:
-		return e.exportText(entries, filePath)
+		return e.exportText(data, w)
 	case FormatJSON:
-		return e.exportJSON(entries, filePath)
+		return e.exportJSON(data, w)
 	case FormatCSV:
-		return e.exportCSV(entries, filePath)
+		return e.exportCSV(data, w)
+	case FormatHTML:
+		return e.exportKit(data, w)
 	default:
 		return fmt.Errorf("unsupported export format: %s", format)
 	}
 }
 
-// exportText exports entries as plain text
-func (e *ExportManager) exportText(entries []PasswordEntry, filePath string) error {
-	file, err := os.Create(filePath)
+// valid reports whether entries can be exported in the format
+func (f ExportFormat) valid() bool {
+	switch f {
+	case FormatText, FormatJSON, FormatCSV, FormatHTML:
+		return true
+	}
+	return false
+}
+
+// ExportTarget is one file of a multi-format export
+type ExportTarget struct {
+	Format ExportFormat
+	Path   string
+}
+
+// ExportTargetResult is the outcome of writing one target
+type ExportTargetResult struct {
+	Target ExportTarget
+	Err    error
+}
+
+// ExportProgress reports that a target of ExportAll has finished
+type ExportProgress struct {
+	Done   int // Targets finished so far, including this one
+	Failed int // Targets failed so far
+	Total  int
+	Result ExportTargetResult
+}
+
+// MultiExportReport is the outcome of ExportAll, in the order of the targets
+type MultiExportReport struct {
+	Results []ExportTargetResult
+}
+
+// Failed returns the results of the targets that could not be written
+func (r *MultiExportReport) Failed() []ExportTargetResult {
+	var failed []ExportTargetResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err combines the errors of every failed target, or returns nil when all
+// targets were written
+func (r *MultiExportReport) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Target.Path, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// ExportAll writes the same entries to several targets at once, e.g. txt,
+// json and csv copies. The entries are serialized once and the targets are
+// written concurrently. A failed target does not stop the others; each
+// outcome is in the report. progress, if set, is called as each target
+// finishes, never concurrently.
+func (e *ExportManager) ExportAll(entries []PasswordEntry, targets []ExportTarget, progress func(ExportProgress)) *MultiExportReport {
+	report := &MultiExportReport{Results: make([]ExportTargetResult, len(targets))}
+	for i, target := range targets {
+		report.Results[i].Target = target
+	}
+
+	data, err := serializeEntries(entries)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		for i := range report.Results {
+			report.Results[i].Err = err
+		}
+		return report
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		done   int
+		failed int
+	)
+
+	// Two targets writing one file would interleave; only the first is written
+	seenPath := make(map[string]bool)
+	duplicate := make([]bool, len(targets))
+	for i, target := range targets {
+		path := filepath.Clean(target.Path)
+		if seenPath[path] {
+			duplicate[i] = true
+			report.Results[i].Err = fmt.Errorf("duplicate export path")
+			done++
+			failed++
+			if progress != nil {
+				progress(ExportProgress{Done: done, Failed: failed, Total: len(targets), Result: report.Results[i]})
+			}
+		}
+		seenPath[path] = true
+	}
+
+	for i, target := range targets {
+		if duplicate[i] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, target ExportTarget) {
+			defer wg.Done()
+			err := e.export(data, target.Format, target.Path)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Results[i].Err = err
+			done++
+			if err != nil {
+				failed++
+			}
+			if progress != nil {
+				progress(ExportProgress{Done: done, Failed: failed, Total: len(targets), Result: report.Results[i]})
+			}
+		}(i, target)
 	}
-	defer file.Close()
+	wg.Wait()
+
+	return report
+}
+
+// exportData holds entries serialized once, shared by every format written
+type exportData struct {
+	entries []PasswordEntry
+	rows    []exportRow
+	json    json.RawMessage // The entries as a JSON array
+}
+
+// exportRow holds the formatted fields of one entry
+type exportRow struct {
+	length  string
+	created string
+}
 
+// serializeEntries formats the entries for export
+func serializeEntries(entries []PasswordEntry) (*exportData, error) {
+	data := &exportData{
+		entries: entries,
+		rows:    make([]exportRow, len(entries)),
+	}
 	for i, entry := range entries {
+		data.rows[i] = exportRow{
+			length:  strconv.Itoa(entry.Length),
+			created: entry.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	encoded, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	data.json = encoded
+
+	return data, nil
+}
+
+// exportText exports entries as plain text
+func (e *ExportManager) exportText(data *exportData, w io.Writer) error {
+	file := &errWriter{w: w}
+	for i, entry := range data.entries {
 		if i > 0 {
 			fmt.Fprintln(file, "---")
 		}
 		
 		fmt.Fprintf(file, "Password: %s\n", entry.Password)
-		fmt.Fprintf(file, "Length: %d\n", entry.Length)
+		fmt.Fprintf(file, "Length: %s\n", data.rows[i].length)
 		fmt.Fprintf(file, "Type: %s\n", entry.Type)
-		fmt.Fprintf(file, "Created: %s\n", entry.CreatedAt.Format(time.RFC3339))
+		fmt.Fprintf(file, "Created: %s\n", data.rows[i].created)
 		
 		if entry.Description != "" {
 			fmt.Fprintf(file, "Description: %s\n", entry.Description)
 		}
+		if entry.Occurrences > 1 {
+			fmt.Fprintf(file, "Occurrences: %d\n", entry.Occurrences)
+		}
 		fmt.Fprintln(file)
 	}
 
+	if file.err != nil {
+		return fmt.Errorf("failed to write text: %w", file.err)
+	}
 	return nil
 }
 
-// exportJSON exports entries as JSON
-func (e *ExportManager) exportJSON(entries []PasswordEntry, filePath string) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+// errWriter keeps the first error of a series of writes, so a text export
+// to a closed pipe fails instead of silently losing its end
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Write writes p unless an earlier write failed
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
 	}
-	defer file.Close()
+	n, err := ew.w.Write(p)
+	ew.err = err
+	return n, err
+}
 
-	encoder := json.NewEncoder(file)
+// exportJSON exports entries as JSON
+func (e *ExportManager) exportJSON(data *exportData, w io.Writer) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	
 	exportData := struct {
 		ExportedAt time.Time       `json:"exported_at"`
 		Count      int             `json:"count"`
-		Entries    []PasswordEntry `json:"entries"`
+		Entries    json.RawMessage `json:"entries"`
 	}{
 		ExportedAt: time.Now(),
-		Count:      len(entries),
-		Entries:    entries,
+		Count:      len(data.entries),
+		Entries:    data.json,
 	}
 
 	if err := encoder.Encode(exportData); err != nil {
@@ -124,15 +448,8 @@ func (e *ExportManager) exportJSON(entries []PasswordEntry, filePath string) err
 }
 
 // exportCSV exports entries as CSV
-func (e *ExportManager) exportCSV(entries []PasswordEntry, filePath string) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+func (e *ExportManager) exportCSV(data *exportData, w io.Writer) error {
+	writer := csv.NewWriter(w)
 
 	// Write header
 	if err := writer.Write([]string{
@@ -142,12 +459,12 @@ func (e *ExportManager) exportCSV(entries []PasswordEntry, filePath string) erro
 	}
 
 	// Write entries
-	for _, entry := range entries {
+	for i, entry := range data.entries {
 		record := []string{
 			entry.Password,
-			fmt.Sprintf("%d", entry.Length),
+			data.rows[i].length,
 			entry.Type,
-			entry.CreatedAt.Format(time.RFC3339),
+			data.rows[i].created,
 			entry.Description,
 		}
 		
@@ -156,6 +473,10 @@ func (e *ExportManager) exportCSV(entries []PasswordEntry, filePath string) erro
 		}
 	}
 
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
 	return nil
 }
 
@@ -167,6 +488,9 @@ func (e *ExportManager) GetSuggestedFilename(format ExportFormat, baseName strin
 	
 	timestamp := time.Now().Format("20060102_150405")
 	filename := fmt.Sprintf("%s_%s.%s", baseName, timestamp, string(format))
+	if e.encryptTool != "" {
+		filename += "." + e.encryptTool
+	}
 	
 	// Sanitize filename
 	filename = strings.ReplaceAll(filename, " ", "_")
@@ -180,16 +504,22 @@ func (e *ExportManager) ValidateExportPath(filePath string, format ExportFormat)
 	if filePath == "" {
 		return fmt.Errorf("export path cannot be empty")
 	}
+	if filePath == StdoutPath {
+		return nil
+	}
 
 	// Check if we can write to the directory
 	dir := filepath.Dir(filePath)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
+		if err := os.MkdirAll(dir, 0700); err != nil {
 			return fmt.Errorf("cannot create directory %s: %w", dir, err)
 		}
 	}
 
 	// Validate format matches extension
+	if e.encryptTool != "" {
+		filePath = strings.TrimSuffix(filePath, "."+e.encryptTool)
+	}
 	ext := strings.ToLower(filepath.Ext(filePath))
 	expectedExt := "." + string(format)
 	
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EntryField names a field of a history entry that can be copied on its own
+type EntryField string
+
+// Copyable fields of a history entry
+const (
+	FieldPassword    EntryField = "password"
+	FieldUsername    EntryField = "username"
+	FieldSite        EntryField = "site"
+	FieldDescription EntryField = "description"
+)
+
+// Field returns the value of field, empty if the entry has none
+func (e HistoryEntry) Field(field EntryField) string {
+	switch field {
+	case FieldPassword:
+		return e.Password
+	case FieldUsername:
+		return e.Username
+	case FieldSite:
+		return e.Site
+	case FieldDescription:
+		return e.Description
+	}
+	return ""
+}
+
+// EntryDetails are the fields of an entry describing what it is for
+type EntryDetails struct {
+	Description string
+	Site        string
+	Username    string
+}
+
+// SetDetails replaces the description, site and username of the entry
+// with the given ID
+func (h *HistoryManager) SetDetails(id string, details EntryDetails) error {
+	if !h.enabled {
+		return fmt.Errorf("history is disabled")
+	}
+
+	now := time.Now()
+	return h.updateEntry(id, func(entry *HistoryEntry) {
+		entry.Description = strings.TrimSpace(details.Description)
+		entry.Site = strings.TrimSpace(details.Site)
+		entry.Username = strings.TrimSpace(details.Username)
+		entry.EditedAt = &now
+	})
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockHistory takes an exclusive advisory lock on the history, held across
+// a read-modify-write so that two passman instances can't lose each
+// other's changes. Readers don't lock: files are replaced atomically, so
+// they always see a whole file. Call the returned function to release it.
+func (h *HistoryManager) lockHistory() (unlock func(), err error) {
+	historyPath, err := h.getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return lockFile(historyPath + ".lock")
+}
+
+// lockFile blocks until it holds an exclusive lock on the lock file at
+// path, creating it if needed
+func lockFile(path string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockExclusive(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", filepath.Base(path), err)
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockExclusive takes an exclusive lock on the first byte of f with
+// LockFileEx, waiting for other holders
+func lockExclusive(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &windows.Overlapped{})
+}
+
+// unlockFile releases the lock on f
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
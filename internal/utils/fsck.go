@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Fsck issue severities
+const (
+	FsckError   = "error"   // Record is unusable and is quarantined by repair
+	FsckWarning = "warning" // Record is usable but looks suspicious
+)
+
+// historyHeaderSize is the salt and nonce prefix of the encrypted history file
+const historyHeaderSize = 16 + 12
+
+// gcmTagSize is the size of the AES-GCM authentication tag
+const gcmTagSize = 16
+
+// maxClockSkew is how far in the future a timestamp may be before it is flagged
+const maxClockSkew = 24 * time.Hour
+
+// FsckIssue describes a single problem found in the history store
+type FsckIssue struct {
+	Severity string
+	Index    int // Position of the record in the store, -1 for file-level issues
+	EntryID  string
+	Message  string
+}
+
+// String formats the issue for display
+func (i FsckIssue) String() string {
+	if i.Index < 0 {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	if i.EntryID != "" {
+		return fmt.Sprintf("%s: record %d (%s): %s", i.Severity, i.Index, i.EntryID, i.Message)
+	}
+	return fmt.Sprintf("%s: record %d: %s", i.Severity, i.Index, i.Message)
+}
+
+// FsckReport is the result of checking the history store
+type FsckReport struct {
+	Path           string
+	Records        int
+	ValidRecords   int
+	Issues         []FsckIssue
+	Repaired       bool
+	Quarantined    int
+	QuarantinePath string
+}
+
+// HasErrors reports whether any error-level issue was found
+func (r *FsckReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == FsckError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *FsckReport) addIssue(severity string, index int, id, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, FsckIssue{
+		Severity: severity,
+		Index:    index,
+		EntryID:  id,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Fsck verifies the encrypted history store: header size, the AES-GCM
+// authentication tag, that every record decodes, ID uniqueness and
+// timestamp sanity. With repair set, unusable records are moved to an
+// encrypted quarantine file and the remaining records are written back.
+// A file that fails authentication is moved aside as a whole.
+func (h *HistoryManager) Fsck(repair bool) (*FsckReport, error) {
+	if h.passphrase == "" {
+		return nil, fmt.Errorf("history passphrase not set")
+	}
+
+	historyPath, err := h.getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FsckReport{Path: historyPath}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	encryptedData, err := os.ReadFile(historyPath)
+	if os.IsNotExist(err) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	// Header and authentication checks cover the whole file
+	if len(encryptedData) < historyHeaderSize+gcmTagSize {
+		report.addIssue(FsckError, -1, "", "file too short for header (%d bytes)", len(encryptedData))
+		return report, h.quarantineFile(report, repair)
+	}
+
+	plaintext, err := h.decrypt(encryptedData)
+	if err != nil {
+		report.addIssue(FsckError, -1, "", "authentication failed (wrong key or corrupted file)")
+		return report, h.quarantineFile(report, repair)
+	}
+
+	var records []json.RawMessage
+	if err := json.Unmarshal(plaintext, &records); err != nil {
+		report.addIssue(FsckError, -1, "", "decrypted data is not a list of records: %v", err)
+		return report, h.quarantineFile(report, repair)
+	}
+
+	report.Records = len(records)
+
+	// Per-record checks
+	var good, decoded []HistoryEntry
+	var bad []json.RawMessage
+	seen := make(map[string]int)
+	now := time.Now()
+	var previous time.Time
+
+	for i, record := range records {
+		var entry HistoryEntry
+		if err := json.Unmarshal(record, &entry); err != nil {
+			report.addIssue(FsckError, i, "", "record does not decode: %v", err)
+			bad = append(bad, record)
+			continue
+		}
+		decoded = append(decoded, entry)
+
+		usable := true
+		switch {
+		case entry.ID == "":
+			report.addIssue(FsckError, i, "", "missing ID")
+			usable = false
+		case seen[entry.ID] > 0:
+			report.addIssue(FsckError, i, entry.ID, "duplicate ID (first seen at record %d)", seen[entry.ID]-1)
+			usable = false
+		}
+
+		if entry.Password == "" {
+			report.addIssue(FsckError, i, entry.ID, "empty password")
+			usable = false
+		}
+
+		switch {
+		case entry.CreatedAt.IsZero():
+			report.addIssue(FsckError, i, entry.ID, "missing timestamp")
+			usable = false
+		case entry.CreatedAt.After(now.Add(maxClockSkew)):
+			report.addIssue(FsckError, i, entry.ID, "timestamp %s is in the future", entry.CreatedAt.Format(time.RFC3339))
+			usable = false
+		case !previous.IsZero() && entry.CreatedAt.After(previous):
+			report.addIssue(FsckWarning, i, entry.ID, "out of order (newer than the record before it)")
+		}
+
+		if usable && entry.Length != len(entry.Password) {
+			report.addIssue(FsckWarning, i, entry.ID, "stored length %d does not match password length %d", entry.Length, len(entry.Password))
+		}
+
+		if !usable {
+			bad = append(bad, record)
+			continue
+		}
+
+		seen[entry.ID] = i + 1
+		previous = entry.CreatedAt
+		good = append(good, entry)
+	}
+
+	report.ValidRecords = len(good)
+
+	if repair && len(bad) > 0 {
+		if err := h.quarantineRecords(report, bad); err != nil {
+			return report, err
+		}
+		relinkEntries(good, chainHashes(decoded))
+		// The bad records are quarantined already; don't let saveHistory
+		// quarantine those it skipped on an earlier load a second time
+		h.cache.invalidate()
+		if err := h.saveHistory(good); err != nil {
+			return report, err
+		}
+		report.Repaired = true
+	}
+
+	return report, nil
+}
+
+// quarantineFile moves an unreadable history file aside so a fresh one can be started
+func (h *HistoryManager) quarantineFile(report *FsckReport, repair bool) error {
+	if !repair {
+		return nil
+	}
+
+	report.QuarantinePath = fmt.Sprintf("%s.corrupt-%s", report.Path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(report.Path, report.QuarantinePath); err != nil {
+		return fmt.Errorf("failed to move corrupt history aside: %w", err)
+	}
+
+	report.Repaired = true
+	return nil
+}
+
+// quarantineRecords appends bad records to the encrypted quarantine file
+func (h *HistoryManager) quarantineRecords(report *FsckReport, records []json.RawMessage) error {
+	quarantinePath := filepath.Join(filepath.Dir(report.Path), "history.quarantine.enc")
+
+	var existing []json.RawMessage
+	if data, err := os.ReadFile(quarantinePath); err == nil {
+		plaintext, err := h.decrypt(data)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt existing quarantine file: %w", err)
+		}
+		if err := json.Unmarshal(plaintext, &existing); err != nil {
+			return fmt.Errorf("failed to parse existing quarantine file: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(append(existing, records...), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantined records: %w", err)
+	}
+
+	encryptedData, err := h.encrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt quarantined records: %w", err)
+	}
+
+	if err := writeFileAtomic(quarantinePath, encryptedData); err != nil {
+		return fmt.Errorf("failed to write quarantine file: %w", err)
+	}
+
+	report.Quarantined = len(records)
+	report.QuarantinePath = quarantinePath
+	return nil
+}
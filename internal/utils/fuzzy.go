@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scores of a fuzzy match. Matches that run on or start a word count for
+// more than scattered ones, so "gh" ranks github.com above "the graph".
+const (
+	fuzzyMatchScore       = 1
+	fuzzyConsecutiveBonus = 4
+	fuzzyWordStartBonus   = 6
+	fuzzyGapPenalty       = 1
+	fuzzyMaxGapPenalty    = 6
+)
+
+// FuzzyMatch reports whether the letters of pattern appear in text in
+// order, ignoring case, and scores how well they do. An empty pattern
+// matches everything with a score of 0.
+func FuzzyMatch(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+
+	score := 0
+	last := -1
+	for i := 0; i < len(p); i++ {
+		found := -1
+		for j := last + 1; j < len(t); j++ {
+			if t[j] == p[i] {
+				found = j
+				break
+			}
+		}
+		if found < 0 {
+			return 0, false
+		}
+
+		score += fuzzyMatchScore
+		switch {
+		case found == last+1 && last >= 0:
+			score += fuzzyConsecutiveBonus
+		case last >= 0:
+			score -= min(found-last-1, fuzzyMaxGapPenalty) * fuzzyGapPenalty
+		}
+		if found == 0 || !unicode.IsLetter(t[found-1]) && !unicode.IsDigit(t[found-1]) {
+			score += fuzzyWordStartBonus
+		}
+		last = found
+	}
+	return score, true
+}
+
+// PickLabel describes an entry for the fuzzy finder: its description,
+// site, username, tags and type. The password is never part of it, so
+// typing a search can't reveal one.
+func (e HistoryEntry) PickLabel() string {
+	var parts []string
+	for _, part := range []string{e.Description, e.Site, e.Username, formatPickTags(e.Tags), e.Type} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatPickTags shows tags as #work #personal
+func formatPickTags(tags []string) string {
+	shown := make([]string, len(tags))
+	for i, tag := range tags {
+		shown[i] = "#" + tag
+	}
+	return strings.Join(shown, " ")
+}
+
+// FuzzyFilter returns the entries whose label matches every word of
+// query, best match first. Entries that match equally well keep their
+// order, newest first in the history.
+func FuzzyFilter(entries []HistoryEntry, query string) []HistoryEntry {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return entries
+	}
+
+	type match struct {
+		entry HistoryEntry
+		score int
+	}
+	var matches []match
+	for _, entry := range entries {
+		label := entry.PickLabel()
+		total := 0
+		ok := true
+		for _, term := range terms {
+			score, matched := FuzzyMatch(term, label)
+			if !matched {
+				ok = false
+				break
+			}
+			total += score
+		}
+		if ok {
+			matches = append(matches, match{entry, total})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	filtered := make([]HistoryEntry, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.entry
+	}
+	return filtered
+}
@@ -0,0 +1,337 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mshnjffr/passman/internal/config"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Hardware tokens the history passphrase can be wrapped with
+const (
+	HardwareYubiKey = "yubikey" // HMAC-SHA1 challenge-response in an OTP slot, through ykchalresp
+	HardwareFIDO2   = "fido2"   // A credential's hmac-secret extension, through fido2-cred and fido2-assert
+)
+
+// ErrNoHardwareKey is returned when no hardware key has been enrolled
+var ErrNoHardwareKey = errors.New("no hardware key enrolled; run passman hardware-key enroll")
+
+// hardwareKeyVersion is the version of the wrapped passphrase file
+const hardwareKeyVersion = 1
+
+// hardwareRPID is the relying party of the FIDO2 credentials passman
+// creates; they are not resident, so they take no room on the token
+const hardwareRPID = "passman"
+
+// hardwareSaltBytes is the size of challenges and hmac-secret salts
+const hardwareSaltBytes = 32
+
+// HardwareKey is the history passphrase wrapped with a hardware token:
+// encrypted with AES-256-GCM under a key derived from the token's answer
+// to Salt, which only the token can give, and only when touched
+type HardwareKey struct {
+	Version    int    `json:"version"`
+	Token      string `json:"token"`                // yubikey or fido2
+	Slot       int    `json:"slot,omitempty"`       // YubiKey OTP slot, 1 or 2
+	Device     string `json:"device,omitempty"`     // FIDO2 device path; empty = the first one found
+	Credential []byte `json:"credential,omitempty"` // FIDO2 credential ID
+	Salt       []byte `json:"salt"`                 // Challenge or hmac-secret salt
+	Nonce      []byte `json:"nonce"`
+	Wrapped    []byte `json:"wrapped"`
+}
+
+// HardwareKeyPath returns the file the wrapped history passphrase of the
+// active profile is kept in
+func HardwareKeyPath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "hardware-key.json"), nil
+}
+
+// LoadHardwareKey reads the wrapped history passphrase, or returns
+// ErrNoHardwareKey
+func LoadHardwareKey() (*HardwareKey, error) {
+	path, err := HardwareKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoHardwareKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key HardwareKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	if key.Version > hardwareKeyVersion {
+		return nil, fmt.Errorf("%s version %d is newer than this passman supports", filepath.Base(path), key.Version)
+	}
+	return &key, nil
+}
+
+// EnrollHardwareKey wraps passphrase with a token and writes the result,
+// replacing any earlier enrollment. A FIDO2 token gets a new credential;
+// either token must be touched, for the FIDO2 one twice.
+func EnrollHardwareKey(token string, slot int, device, passphrase string) (*HardwareKey, error) {
+	key := &HardwareKey{Version: hardwareKeyVersion, Token: token}
+	switch token {
+	case HardwareYubiKey:
+		if slot != 1 && slot != 2 {
+			return nil, fmt.Errorf("YubiKey slot must be 1 or 2")
+		}
+		key.Slot = slot
+	case HardwareFIDO2:
+		key.Device = device
+		credential, err := fido2Credential(device)
+		if err != nil {
+			return nil, err
+		}
+		key.Credential = credential
+	default:
+		return nil, fmt.Errorf("unknown hardware token %q; use %s or %s", token, HardwareYubiKey, HardwareFIDO2)
+	}
+	if err := key.Wrap(passphrase); err != nil {
+		return nil, err
+	}
+	return key, key.Save()
+}
+
+// Wrap encrypts passphrase under a fresh salt, asking the token for the
+// key; the token must be touched
+func (k *HardwareKey) Wrap(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase is required")
+	}
+	salt := make([]byte, hardwareSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := k.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	k.Salt = salt
+	k.Nonce = nonce
+	k.Wrapped = aead.Seal(nil, nonce, []byte(passphrase), []byte(k.Token))
+	return nil
+}
+
+// Unwrap decrypts the passphrase, asking the token for the key; the
+// token must be touched
+func (k *HardwareKey) Unwrap() (string, error) {
+	aead, err := k.cipher(k.Salt)
+	if err != nil {
+		return "", err
+	}
+	if len(k.Nonce) != aead.NonceSize() {
+		return "", fmt.Errorf("invalid hardware key file")
+	}
+	passphrase, err := aead.Open(nil, k.Nonce, k.Wrapped, []byte(k.Token))
+	if err != nil {
+		return "", fmt.Errorf("the hardware token did not give the key the passphrase was wrapped with; is it the enrolled one?")
+	}
+	return string(passphrase), nil
+}
+
+// Save writes the wrapped passphrase, readable only by the current user
+func (k *HardwareKey) Save() error {
+	path, err := HardwareKeyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// String describes the token, e.g. "YubiKey slot 2"
+func (k *HardwareKey) String() string {
+	if k.Token == HardwareYubiKey {
+		return fmt.Sprintf("YubiKey slot %d", k.Slot)
+	}
+	if k.Device != "" {
+		return "FIDO2 token " + k.Device
+	}
+	return "FIDO2 token"
+}
+
+// cipher asks the token to answer salt and derives the wrapping cipher
+// from the answer
+func (k *HardwareKey) cipher(salt []byte) (cipher.AEAD, error) {
+	var response []byte
+	var err error
+	switch k.Token {
+	case HardwareYubiKey:
+		response, err = yubiKeyResponse(k.Slot, salt)
+	case HardwareFIDO2:
+		response, err = fido2HMACSecret(k.Device, k.Credential, salt)
+	default:
+		return nil, fmt.Errorf("unknown hardware token %q", k.Token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, response, salt, []byte("passman history passphrase")), key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// yubiKeyResponse sends challenge to a YubiKey slot configured for
+// HMAC-SHA1 challenge-response and returns its 20-byte answer
+func yubiKeyResponse(slot int, challenge []byte) ([]byte, error) {
+	output, err := runTokenTool("ykchalresp", "yubikey-personalization", nil,
+		fmt.Sprintf("-%d", slot), "-x", hex.EncodeToString(challenge))
+	if err != nil {
+		return nil, err
+	}
+	response, err := hex.DecodeString(lastLine(output))
+	if err != nil || len(response) != 20 {
+		return nil, fmt.Errorf("unexpected answer from ykchalresp: %q", lastLine(output))
+	}
+	return response, nil
+}
+
+// fido2Credential creates a credential with the hmac-secret extension on
+// a FIDO2 token and returns its ID
+func fido2Credential(device string) ([]byte, error) {
+	device, err := fido2Device(device)
+	if err != nil {
+		return nil, err
+	}
+	clientData, userID := make([]byte, 32), make([]byte, 32)
+	if _, err := rand.Read(clientData); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(userID); err != nil {
+		return nil, err
+	}
+	input := strings.Join([]string{
+		base64.StdEncoding.EncodeToString(clientData),
+		hardwareRPID,
+		"passman",
+		base64.StdEncoding.EncodeToString(userID),
+	}, "\n") + "\n"
+	output, err := runTokenTool("fido2-cred", "libfido2", strings.NewReader(input), "-M", "-h", device)
+	if err != nil {
+		return nil, err
+	}
+
+	// Client data hash, relying party, format, authenticator data,
+	// credential ID, then the attestation
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 5 {
+		return nil, fmt.Errorf("unexpected output from fido2-cred")
+	}
+	credential, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[4]))
+	if err != nil || len(credential) == 0 {
+		return nil, fmt.Errorf("unexpected credential ID from fido2-cred")
+	}
+	return credential, nil
+}
+
+// fido2HMACSecret asks a FIDO2 token for the hmac-secret of credential
+// for salt
+func fido2HMACSecret(device string, credential, salt []byte) ([]byte, error) {
+	device, err := fido2Device(device)
+	if err != nil {
+		return nil, err
+	}
+	clientData := make([]byte, 32)
+	if _, err := rand.Read(clientData); err != nil {
+		return nil, err
+	}
+	input := strings.Join([]string{
+		base64.StdEncoding.EncodeToString(clientData),
+		hardwareRPID,
+		base64.StdEncoding.EncodeToString(credential),
+		base64.StdEncoding.EncodeToString(salt),
+	}, "\n") + "\n"
+	output, err := runTokenTool("fido2-assert", "libfido2", strings.NewReader(input), "-G", "-h", device)
+	if err != nil {
+		return nil, err
+	}
+
+	// The hmac-secret comes last, after the assertion
+	secret, err := base64.StdEncoding.DecodeString(lastLine(output))
+	if err != nil || len(secret) != 32 {
+		return nil, fmt.Errorf("unexpected hmac-secret from fido2-assert")
+	}
+	return secret, nil
+}
+
+// fido2Device returns device, or the first FIDO2 token fido2-token finds
+func fido2Device(device string) (string, error) {
+	if device != "" {
+		return device, nil
+	}
+	output, err := runTokenTool("fido2-token", "libfido2", nil, "-L")
+	if err != nil {
+		return "", err
+	}
+	// Each line is "PATH: vendor=..., product=... (NAME)"
+	for _, line := range strings.Split(output, "\n") {
+		if path, _, ok := strings.Cut(line, ": "); ok && path != "" {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no FIDO2 token found; plug one in")
+}
+
+// runTokenTool runs a token's command line tool, from package, and
+// returns what it printed
+func runTokenTool(name, pkg string, stdin io.Reader, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%s not found (install %s)", name, pkg)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// lastLine returns the last non-empty line of output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
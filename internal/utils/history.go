@@ -4,7 +4,6 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -13,7 +12,7 @@ import (
 	"strings"
 	"time"
 
-	"golang.org/x/crypto/pbkdf2"
+	"github.com/mshnjffr/passman/internal/config"
 )
 
 // HistoryEntry represents a password generation history entry
@@ -25,6 +24,42 @@ type HistoryEntry struct {
 	Settings    string    `json:"settings"`
 	CreatedAt   time.Time `json:"created_at"`
 	Description string    `json:"description,omitempty"`
+	Site        string    `json:"site,omitempty"`     // Where the password is used, e.g. github.com
+	Username    string    `json:"username,omitempty"` // Account the password belongs to
+
+	// Usage audit trail
+	CopyCount      int        `json:"copy_count,omitempty"`
+	LastCopiedAt   *time.Time `json:"last_copied_at,omitempty"`
+	RevealCount    int        `json:"reveal_count,omitempty"`
+	LastRevealedAt *time.Time `json:"last_revealed_at,omitempty"`
+
+	// Who the secret was shared with, for the revocation checklist
+	Shares []ShareRecord `json:"shares,omitempty"`
+
+	// Links to the entry whose password this one reuses
+	LinkedTo         string     `json:"linked_to,omitempty"`          // ID of the primary entry
+	PrimaryRotatedAt *time.Time `json:"primary_rotated_at,omitempty"` // Set when the primary was rotated
+
+	// Rotation chain: the entry whose password this one replaced, and the
+	// entry that replaced this one's (see RotateEntry)
+	Replaces     string     `json:"replaces,omitempty"`
+	SupersededBy string     `json:"superseded_by,omitempty"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+
+	// Strength estimated by the security analyzer when the entry was saved
+	Entropy  float64 `json:"entropy,omitempty"`
+	Strength string  `json:"strength,omitempty"` // Security level, e.g. "Strong"
+
+	// Tags organising entries by purpose, e.g. work or throwaway
+	Tags     []string   `json:"tags,omitempty"`
+	TaggedAt *time.Time `json:"tagged_at,omitempty"` // Last tag edit, so sync keeps the newest tags
+	EditedAt *time.Time `json:"edited_at,omitempty"` // Last edit of the description, site or username
+
+	// The SSH key pair the passphrase protects, for "passman sshkey" entries
+	SSHKey *SSHKeyInfo `json:"ssh_key,omitempty"`
+
+	// Hash of the entry before this one, making edits detectable (see EntryHash)
+	PrevHash string `json:"prev_hash,omitempty"`
 }
 
 // HistoryManager handles encrypted password history
@@ -32,6 +67,16 @@ type HistoryManager struct {
 	enabled    bool
 	passphrase string
 	maxEntries int
+	kdf        string // Key derivation for files written from now on
+	retention  RetentionPolicy
+
+	// While locked the passphrase is dropped; the verifier checks it on unlock
+	locked       bool
+	verifier     []byte
+	verifierSalt []byte
+
+	// Decrypted entries, reused while the file is unchanged
+	cache historyCache
 }
 
 // NewHistoryManager creates a new history manager
@@ -44,6 +89,7 @@ func NewHistoryManager(enabled bool, passphrase string, maxEntries int) *History
 		enabled:    enabled,
 		passphrase: passphrase,
 		maxEntries: maxEntries,
+		kdf:        KDFPBKDF2,
 	}
 }
 
@@ -53,9 +99,15 @@ func (h *HistoryManager) AddEntry(entry HistoryEntry) error {
 		return fmt.Errorf("history is disabled")
 	}
 
-	if h.passphrase == "" {
-		return fmt.Errorf("history passphrase not set")
+	if err := h.requirePassphrase(); err != nil {
+		return err
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
 	entries, err := h.LoadHistory()
 	if err != nil && !os.IsNotExist(err) {
@@ -72,12 +124,14 @@ func (h *HistoryManager) AddEntry(entry HistoryEntry) error {
 		entry.CreatedAt = time.Now()
 	}
 
-	// Add new entry at the beginning
+	// Add new entry at the beginning, linked to the current newest
+	entry.PrevHash = ""
 	entries = append([]HistoryEntry{entry}, entries...)
 
-	// Trim to max entries
-	if len(entries) > h.maxEntries {
-		entries = entries[:h.maxEntries]
+	// Dedupe, purge and trim to max entries and size
+	entries, err = h.applyRetention(entries, time.Now())
+	if err != nil {
+		return err
 	}
 
 	return h.saveHistory(entries)
@@ -89,8 +143,8 @@ func (h *HistoryManager) LoadHistory() ([]HistoryEntry, error) {
 		return nil, fmt.Errorf("history is disabled")
 	}
 
-	if h.passphrase == "" {
-		return nil, fmt.Errorf("history passphrase not set")
+	if err := h.requirePassphrase(); err != nil {
+		return nil, err
 	}
 
 	historyPath, err := h.getHistoryPath()
@@ -99,9 +153,15 @@ func (h *HistoryManager) LoadHistory() ([]HistoryEntry, error) {
 	}
 
 	// Check if file exists
-	if _, err := os.Stat(historyPath); os.IsNotExist(err) {
+	info, err := os.Stat(historyPath)
+	if os.IsNotExist(err) {
 		return []HistoryEntry{}, nil
 	}
+	if err == nil {
+		if entries, ok := h.cache.get(historyPath, h.passphrase, info); ok {
+			return entries, nil
+		}
+	}
 
 	// Read encrypted data
 	encryptedData, err := os.ReadFile(historyPath)
@@ -115,12 +175,32 @@ func (h *HistoryManager) LoadHistory() ([]HistoryEntry, error) {
 		return nil, fmt.Errorf("failed to decrypt history: %w", err)
 	}
 
-	// Parse JSON
-	var entries []HistoryEntry
-	if err := json.Unmarshal(decryptedData, &entries); err != nil {
+	// Parse JSON record by record so one bad record doesn't hide the rest.
+	// Skipped records are kept with the cached entries, so that the next
+	// write quarantines them rather than dropping them (see saveHistory),
+	// and are reported by SkippedRecords and Fsck.
+	var records []json.RawMessage
+	if err := json.Unmarshal(decryptedData, &records); err != nil {
 		return nil, fmt.Errorf("failed to parse history data: %w", err)
 	}
 
+	entries := make([]HistoryEntry, 0, len(records))
+	var unreadable []json.RawMessage
+	for _, record := range records {
+		var entry HistoryEntry
+		if err := json.Unmarshal(record, &entry); err != nil {
+			unreadable = append(unreadable, record)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// Only cache what was read from the file that was stat'ed, in case it
+	// was replaced in between
+	if after, err := os.Stat(historyPath); err == nil && info != nil && os.SameFile(info, after) {
+		h.cache.put(historyPath, h.passphrase, after, entries, unreadable)
+	}
+
 	return entries, nil
 }
 
@@ -136,6 +216,26 @@ func (h *HistoryManager) saveHistory(entries []HistoryEntry) error {
 		return fmt.Errorf("failed to create history directory: %w", err)
 	}
 
+	// Chain new entries to the ones before them
+	head, _, err := chainHead(historyPath)
+	if err != nil {
+		return err
+	}
+	linkEntries(entries, head.ID)
+
+	// Rate the strength of new entries once, rather than on every display
+	analyzeEntries(entries)
+
+	// Records of the file being replaced that did not decode aren't in
+	// entries; move them to the quarantine file first so they aren't lost
+	if info, err := os.Stat(historyPath); err == nil {
+		if unreadable, ok := h.cache.getUnreadable(historyPath, h.passphrase, info); ok && len(unreadable) > 0 {
+			if err := h.quarantineRecords(&FsckReport{Path: historyPath}, unreadable); err != nil {
+				return fmt.Errorf("failed to quarantine unreadable history records: %w", err)
+			}
+		}
+	}
+
 	// Marshal to JSON
 	data, err := json.MarshalIndent(entries, "", "  ")
 	if err != nil {
@@ -148,14 +248,26 @@ func (h *HistoryManager) saveHistory(entries []HistoryEntry) error {
 		return fmt.Errorf("failed to encrypt history data: %w", err)
 	}
 
-	// Write to file with restricted permissions
-	if err := os.WriteFile(historyPath, encryptedData, 0600); err != nil {
+	// Write to a temporary file with restricted permissions and rename it
+	// into place, so a crash or a reader never sees a partial file
+	if err := writeFileAtomic(historyPath, encryptedData); err != nil {
+		h.cache.invalidate()
 		return fmt.Errorf("failed to write history file: %w", err)
 	}
 
-	return nil
+	if info, err := os.Stat(historyPath); err == nil {
+		h.cache.put(historyPath, h.passphrase, info, entries, nil)
+	} else {
+		h.cache.invalidate()
+	}
+
+	return recordChainHead(historyPath, entries)
 }
 
+// ClearConfirmation is the word typed to confirm clearing the history,
+// in the TUI and on the command line
+const ClearConfirmation = "clear"
+
 // ClearHistory removes all history entries
 func (h *HistoryManager) ClearHistory() error {
 	if !h.enabled {
@@ -167,12 +279,77 @@ func (h *HistoryManager) ClearHistory() error {
 		return err
 	}
 
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Remove the file
+	h.cache.invalidate()
 	if err := os.Remove(historyPath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove history file: %w", err)
 	}
 
-	return nil
+	return recordChainHead(historyPath, nil)
+}
+
+// DeleteEntry removes the entry with the given ID. Entries linked to it
+// lose their link, its rotation chain closes over it, and the hash chain
+// is rebuilt over the gap. A deletion is not synced, so another device may
+// bring the entry back.
+func (h *HistoryManager) DeleteEntry(id string) error {
+	if !h.enabled {
+		return fmt.Errorf("history is disabled")
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	var deleted *HistoryEntry
+	for i := range entries {
+		if entries[i].ID == id {
+			deleted = &entries[i]
+			break
+		}
+	}
+	if deleted == nil {
+		return fmt.Errorf("history entry %s not found", id)
+	}
+	replaces, supersededBy := deleted.Replaces, deleted.SupersededBy
+	known := chainHashes(entries)
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.ID == id {
+			continue
+		}
+		if entry.LinkedTo == id {
+			entry.LinkedTo = ""
+			entry.PrimaryRotatedAt = nil
+		}
+		if entry.Replaces == id {
+			entry.Replaces = replaces
+		}
+		if entry.SupersededBy == id {
+			entry.SupersededBy = supersededBy
+			if supersededBy == "" {
+				entry.SupersededAt = nil
+			}
+		}
+		kept = append(kept, entry)
+	}
+
+	relinkEntries(kept, known)
+	return h.saveHistory(kept)
 }
 
 // GetRecentEntries returns the most recent entries
@@ -212,16 +389,25 @@ func (h *HistoryManager) matchesQuery(entry HistoryEntry, query string) bool {
 	
 	return strings.Contains(strings.ToLower(entry.Type), query) ||
 		   strings.Contains(strings.ToLower(entry.Description), query) ||
-		   strings.Contains(strings.ToLower(entry.Settings), query)
+		   strings.Contains(strings.ToLower(entry.Settings), query) ||
+		   strings.Contains(strings.ToLower(entry.Site), query) ||
+		   strings.Contains(strings.ToLower(entry.Username), query) ||
+		   entry.HasTag(query)
 }
 
 // getHistoryPath returns the path to the history file
 func (h *HistoryManager) getHistoryPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	dataDir, err := config.GetDataDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(homeDir, ".config", "passman", "history.enc"), nil
+	return filepath.Join(dataDir, "history.enc"), nil
+}
+
+// NewID returns a fresh entry ID, for callers that need to refer to an
+// entry after adding it
+func (h *HistoryManager) NewID() string {
+	return h.generateID()
 }
 
 // generateID generates a unique ID for history entries
@@ -232,6 +418,8 @@ func (h *HistoryManager) generateID() string {
 
 // encrypt encrypts data using AES-GCM with a key derived from passphrase
 func (h *HistoryManager) encrypt(data []byte) ([]byte, error) {
+	params := defaultKDFParams(h.kdf)
+
 	// Generate salt
 	salt := make([]byte, 16)
 	if _, err := rand.Read(salt); err != nil {
@@ -239,7 +427,7 @@ func (h *HistoryManager) encrypt(data []byte) ([]byte, error) {
 	}
 
 	// Derive key from passphrase
-	key := pbkdf2.Key([]byte(h.passphrase), salt, 100000, 32, sha256.New)
+	key := params.deriveKey(h.passphrase, salt)
 
 	// Create AES cipher
 	block, err := aes.NewCipher(key)
@@ -262,8 +450,10 @@ func (h *HistoryManager) encrypt(data []byte) ([]byte, error) {
 	// Encrypt data
 	ciphertext := gcm.Seal(nil, nonce, data, nil)
 
-	// Combine salt + nonce + ciphertext
-	result := make([]byte, 0, len(salt)+len(nonce)+len(ciphertext))
+	// Combine KDF header + salt + nonce + ciphertext
+	header := params.header()
+	result := make([]byte, 0, len(header)+len(salt)+len(nonce)+len(ciphertext))
+	result = append(result, header...)
 	result = append(result, salt...)
 	result = append(result, nonce...)
 	result = append(result, ciphertext...)
@@ -271,8 +461,22 @@ func (h *HistoryManager) encrypt(data []byte) ([]byte, error) {
 	return result, nil
 }
 
-// decrypt decrypts data using AES-GCM
+// decrypt decrypts data using AES-GCM. The key derivation function is
+// detected from the file, so files in either format can be read.
 func (h *HistoryManager) decrypt(encryptedData []byte) ([]byte, error) {
+	params, body := parseKDFHeader(encryptedData)
+	plaintext, err := h.decryptWith(params, body)
+	if err != nil && params.kdf != KDFPBKDF2 {
+		// A PBKDF2 salt can start with the Argon2id magic by chance
+		if fallback, fallbackErr := h.decryptWith(kdfParams{kdf: KDFPBKDF2}, encryptedData); fallbackErr == nil {
+			return fallback, nil
+		}
+	}
+	return plaintext, err
+}
+
+// decryptWith decrypts salt + nonce + ciphertext using the given KDF
+func (h *HistoryManager) decryptWith(params kdfParams, encryptedData []byte) ([]byte, error) {
 	if len(encryptedData) < 16+12 { // salt + nonce minimum
 		return nil, fmt.Errorf("encrypted data too short")
 	}
@@ -283,7 +487,7 @@ func (h *HistoryManager) decrypt(encryptedData []byte) ([]byte, error) {
 	ciphertext := encryptedData[28:]
 
 	// Derive key from passphrase
-	key := pbkdf2.Key([]byte(h.passphrase), salt, 100000, 32, sha256.New)
+	key := params.deriveKey(h.passphrase, salt)
 
 	// Create AES cipher
 	block, err := aes.NewCipher(key)
@@ -316,11 +520,41 @@ func (h *HistoryManager) SetEnabled(enabled bool) {
 	h.enabled = enabled
 }
 
+// SetKDF sets the key derivation function used when writing files.
+// Existing files are read whatever function they were written with.
+func (h *HistoryManager) SetKDF(kdf string) {
+	if kdf != KDFArgon2id {
+		kdf = KDFPBKDF2
+	}
+	h.kdf = kdf
+}
+
+// KDF returns the key derivation function used when writing files
+func (h *HistoryManager) KDF() string {
+	return h.kdf
+}
+
 // SetPassphrase sets the encryption passphrase
 func (h *HistoryManager) SetPassphrase(passphrase string) {
 	h.passphrase = passphrase
 }
 
+// SkippedRecords returns how many records of the history file did not
+// decode when it was last loaded. They are left out of LoadHistory, and
+// the next write moves them to the quarantine file.
+func (h *HistoryManager) SkippedRecords() int {
+	historyPath, err := h.getHistoryPath()
+	if err != nil {
+		return 0
+	}
+	info, err := os.Stat(historyPath)
+	if err != nil {
+		return 0
+	}
+	unreadable, _ := h.cache.getUnreadable(historyPath, h.passphrase, info)
+	return len(unreadable)
+}
+
 // GetEntryCount returns the number of entries in history
 func (h *HistoryManager) GetEntryCount() (int, error) {
 	entries, err := h.LoadHistory()
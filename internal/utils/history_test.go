@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+// newTestHistory returns a history manager whose files are kept in a
+// temporary data directory
+func newTestHistory(t *testing.T) *HistoryManager {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	return NewHistoryManager(true, testPassphrase, 100)
+}
+
+// addTestEntries adds entries for the given sites, oldest first
+func addTestEntries(t *testing.T, h *HistoryManager, sites ...string) {
+	t.Helper()
+	created := time.Now().Add(-time.Hour)
+	for i, site := range sites {
+		entry := HistoryEntry{
+			Password:  "pw-" + site,
+			Length:    len("pw-" + site),
+			Type:      "random",
+			Site:      site,
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+		if err := h.AddEntry(entry); err != nil {
+			t.Fatalf("AddEntry(%s) failed: %v", site, err)
+		}
+	}
+}
+
+// historyPasswords returns the passwords of the history by entry ID
+func historyPasswords(t *testing.T, h *HistoryManager) map[string]string {
+	t.Helper()
+	entries, err := h.LoadHistory()
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	passwords := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		passwords[entry.ID] = entry.Password
+	}
+	return passwords
+}
+
+// readRecords decrypts an encrypted file written with h's passphrase
+func readRecords(t *testing.T, h *HistoryManager, path string) []json.RawMessage {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", filepath.Base(path), err)
+	}
+	plaintext, err := h.decrypt(data)
+	if err != nil {
+		t.Fatalf("Failed to decrypt %s: %v", filepath.Base(path), err)
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal(plaintext, &records); err != nil {
+		t.Fatalf("Failed to parse %s: %v", filepath.Base(path), err)
+	}
+	return records
+}
+
+// writeRecords encrypts records over the history file
+func writeRecords(t *testing.T, h *HistoryManager, records []json.RawMessage) {
+	t.Helper()
+	path, err := h.getHistoryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := h.encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, encrypted); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddEntryQuarantinesUnreadableRecords(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example")
+
+	historyPath, _ := h.getHistoryPath()
+	records := readRecords(t, h, historyPath)
+	bad := json.RawMessage(`{"id": 42, "password": "lost?"}`)
+	records[1] = bad
+	writeRecords(t, h, records)
+
+	// A fresh manager, as another passman run would be
+	h = NewHistoryManager(true, testPassphrase, 100)
+	entries, err := h.LoadHistory()
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 readable entry, got %d", len(entries))
+	}
+	if got := h.SkippedRecords(); got != 1 {
+		t.Errorf("Expected 1 skipped record, got %d", got)
+	}
+
+	addTestEntries(t, h, "c.example")
+
+	if got := len(readRecords(t, h, historyPath)); got != 2 {
+		t.Errorf("Expected 2 records in the history after the write, got %d", got)
+	}
+	quarantine := readRecords(t, h, filepath.Join(filepath.Dir(historyPath), "history.quarantine.enc"))
+	var kept struct{ Password string }
+	if len(quarantine) != 1 || json.Unmarshal(quarantine[0], &kept) != nil || kept.Password != "lost?" {
+		t.Fatalf("Expected the unreadable record in quarantine, got %s", quarantine)
+	}
+	if got := h.SkippedRecords(); got != 0 {
+		t.Errorf("Expected no skipped records after the write, got %d", got)
+	}
+
+	// A repair afterwards finds nothing left to quarantine
+	report, err := h.Fsck(true)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if report.HasErrors() {
+		t.Errorf("Expected a clean history, got %v", report.Issues)
+	}
+}
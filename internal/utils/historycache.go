@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// historyCache holds the decrypted history so that screens redrawing on
+// every keypress don't decrypt and parse the whole file each time. It is
+// valid while the file on disk is the one it was read from: history files
+// are always replaced by a rename, so a write by another passman instance
+// shows up as a different file.
+type historyCache struct {
+	mu         sync.Mutex
+	path       string
+	passphrase string
+	info       os.FileInfo
+	entries    []HistoryEntry
+	unreadable []json.RawMessage // Records of the file that did not decode
+}
+
+// get returns a copy of the cached entries if they were read from the file
+// described by info with passphrase
+func (c *historyCache) get(path, passphrase string, info os.FileInfo) ([]HistoryEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.holds(path, passphrase, info) {
+		return nil, false
+	}
+	return cloneEntries(c.entries), true
+}
+
+// getUnreadable returns the records that did not decode if the cache
+// holds the file described by info
+func (c *historyCache) getUnreadable(path, passphrase string, info os.FileInfo) ([]json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.holds(path, passphrase, info) {
+		return nil, false
+	}
+	return c.unreadable, true
+}
+
+// holds reports whether the cache was read from the file described by
+// info with passphrase
+func (c *historyCache) holds(path, passphrase string, info os.FileInfo) bool {
+	return c.info != nil && c.path == path && c.passphrase == passphrase &&
+		os.SameFile(c.info, info) && c.info.ModTime().Equal(info.ModTime()) && c.info.Size() == info.Size()
+}
+
+// put caches a copy of entries, and the records that did not decode, as
+// the contents of the file described by info
+func (c *historyCache) put(path, passphrase string, info os.FileInfo, entries []HistoryEntry, unreadable []json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.path = path
+	c.passphrase = passphrase
+	c.info = info
+	c.entries = cloneEntries(entries)
+	c.unreadable = unreadable
+}
+
+// invalidate drops the cached entries
+func (c *historyCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.info = nil
+	c.entries = nil
+	c.unreadable = nil
+	c.passphrase = ""
+}
+
+// cloneEntries copies entries deeply enough that changing the copy, as the
+// history updates do, leaves the original alone
+func cloneEntries(entries []HistoryEntry) []HistoryEntry {
+	clone := make([]HistoryEntry, len(entries))
+	copy(clone, entries)
+	for i := range clone {
+		if clone[i].Tags != nil {
+			clone[i].Tags = append([]string(nil), clone[i].Tags...)
+		}
+		if clone[i].Shares != nil {
+			clone[i].Shares = append([]ShareRecord(nil), clone[i].Shares...)
+		}
+		if clone[i].SSHKey != nil {
+			key := *clone[i].SSHKey
+			clone[i].SSHKey = &key
+		}
+	}
+	return clone
+}
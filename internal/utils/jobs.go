@@ -0,0 +1,473 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// Task types of a job file, one per generator
+const (
+	TaskRandom    = "random"
+	TaskMemorable = "memorable"
+	TaskPIN       = "pin"
+	TaskToken     = "token"
+	TaskKey       = "key"
+	TaskTOTP      = "totp"
+)
+
+// maxTaskCount limits how many secrets a single task may generate
+const maxTaskCount = 10000
+
+// Exit codes of a job run
+const (
+	JobExitOK      = 0 // Every task succeeded
+	JobExitFailed  = 1 // At least one task failed
+	JobExitInvalid = 2 // The job file could not be read or is invalid
+)
+
+// JobFile describes a batch of generation tasks run headless by
+// "passman run", e.g. to provision many credentials in one audited run
+type JobFile struct {
+	Description string    `yaml:"description" json:"description"`
+	Tasks       []JobTask `yaml:"tasks" json:"tasks"`
+
+	path string // File the job was loaded from
+}
+
+// JobTask is one generation task of a job file. Only the options of its
+// generator type are used; zero values select the generator defaults.
+type JobTask struct {
+	Name        string `yaml:"name" json:"name"`
+	Type        string `yaml:"type" json:"type"`
+	Count       int    `yaml:"count" json:"count"` // Secrets to generate, default 1
+	Description string `yaml:"description" json:"description"`
+
+	// Output file, relative to the job file, and its export format
+	Output    string `yaml:"output" json:"output"`
+	Format    string `yaml:"format" json:"format"`       // txt, json or csv; default json
+	Overwrite bool   `yaml:"overwrite" json:"overwrite"` // Replace an existing output file
+
+	// random and pin
+	Length    int      `yaml:"length" json:"length"`
+	GroupSize int      `yaml:"group_size" json:"group_size"` // PIN digits per group, joined by separator
+	Charsets  []string `yaml:"charsets" json:"charsets"`     // lower, upper, digits, symbols, german, french, spanish, nordic
+	Exclude   string   `yaml:"exclude" json:"exclude"`
+
+	// memorable
+	Words      int    `yaml:"words" json:"words"`
+	Separator  string `yaml:"separator" json:"separator"` // none joins the words directly
+	Wordlist   string `yaml:"wordlist" json:"wordlist"`
+	Capitalize bool   `yaml:"capitalize" json:"capitalize"`
+	Digit      bool   `yaml:"digit" json:"digit"` // Add a random digit
+
+	// token and key
+	TokenFormat string `yaml:"token_format" json:"token_format"` // uuid, hex, base64url or apikey
+	Prefix      string `yaml:"prefix" json:"prefix"`
+	Encoding    string `yaml:"encoding" json:"encoding"` // Key encoding: hex, base64 or base64url
+	Bytes       int    `yaml:"bytes" json:"bytes"`       // Key and TOTP secret size
+}
+
+// JobResult is the outcome of one task. It never contains the secrets, so
+// it can be kept as an audit record.
+type JobResult struct {
+	Task      string `json:"task"`
+	Type      string `json:"type"`
+	Requested int    `json:"requested"`
+	Generated int    `json:"generated"`
+	Output    string `json:"output,omitempty"`
+	Format    string `json:"format,omitempty"`
+	ExitCode  int    `json:"exit_code"`
+	Error     string `json:"error,omitempty"`
+}
+
+// JobReport is the audit record of a job run
+type JobReport struct {
+	File       string      `json:"file"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt time.Time   `json:"finished_at"`
+	DryRun     bool        `json:"dry_run"`
+	Results    []JobResult `json:"results"`
+	ExitCode   int         `json:"exit_code"`
+}
+
+// LoadJobFile reads and validates a job file. Files ending in .json are
+// parsed as JSON and everything else as YAML. Unknown keys are rejected so
+// that a misspelt option cannot silently fall back to a default.
+func LoadJobFile(path string) (*JobFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job file: %w", err)
+	}
+
+	job := &JobFile{path: path}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(job)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(job)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse job file: %w", err)
+	}
+
+	if err := job.validate(); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// validate applies defaults and checks every task before anything runs
+func (j *JobFile) validate() error {
+	if len(j.Tasks) == 0 {
+		return fmt.Errorf("job file has no tasks")
+	}
+
+	names := make(map[string]bool)
+	outputs := make(map[string]string)
+	for i := range j.Tasks {
+		task := &j.Tasks[i]
+		if task.Name == "" {
+			task.Name = fmt.Sprintf("task-%d", i+1)
+		}
+		if names[task.Name] {
+			return fmt.Errorf("duplicate task name %q", task.Name)
+		}
+		names[task.Name] = true
+
+		if task.Count == 0 {
+			task.Count = 1
+		}
+		if task.Count < 1 || task.Count > maxTaskCount {
+			return fmt.Errorf("task %q: count must be between 1 and %d", task.Name, maxTaskCount)
+		}
+
+		if task.Format == "" {
+			task.Format = string(FormatJSON)
+		}
+		switch ExportFormat(task.Format) {
+		case FormatText, FormatJSON, FormatCSV:
+		default:
+			return fmt.Errorf("task %q: unknown output format %q (use txt, json or csv)", task.Name, task.Format)
+		}
+
+		if task.Output == "" {
+			return fmt.Errorf("task %q: output is required", task.Name)
+		}
+		task.Output = j.resolvePath(task.Output)
+		if other, ok := outputs[task.Output]; ok {
+			return fmt.Errorf("tasks %q and %q write the same output %s", other, task.Name, task.Output)
+		}
+		outputs[task.Output] = task.Name
+
+		gen, err := task.BuildGenerator()
+		if err != nil {
+			return fmt.Errorf("task %q: %w", task.Name, err)
+		}
+		if err := gen.Validate(); err != nil {
+			return fmt.Errorf("task %q: %w", task.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolvePath makes an output path relative to the job file's directory
+func (j *JobFile) resolvePath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(filepath.Dir(j.path), path)
+}
+
+// BuildGenerator creates the generator configured by the task from the
+// generator registry, converting only the options its type reads
+func (t *JobTask) BuildGenerator() (generator.Generator, error) {
+	if t.Type == "" {
+		return nil, fmt.Errorf("type is required")
+	}
+	reg, ok := generator.Lookup(t.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q (use %s)", t.Type, generator.NamesText())
+	}
+
+	opts := generator.Options{
+		Length:     t.Length,
+		Exclude:    t.Exclude,
+		Words:      t.Words,
+		Separator:  t.Separator,
+		Capitalize: t.Capitalize,
+		Digit:      t.Digit,
+		GroupSize:  t.GroupSize,
+		Prefix:     t.Prefix,
+		Bytes:      t.Bytes,
+	}
+	if reg.Uses(generator.OptionCharSets) {
+		for _, name := range t.Charsets {
+			charSet, err := parseJobCharSet(name)
+			if err != nil {
+				return nil, err
+			}
+			opts.CharSets = append(opts.CharSets, charSet)
+		}
+	}
+	if reg.Uses(generator.OptionWordlist) {
+		id := t.Wordlist
+		if id == "" {
+			id = generator.DefaultWordlistID
+		}
+		wordlist, err := generator.GetBundledWordlist(id)
+		if err != nil {
+			return nil, err
+		}
+		opts.Wordlist = wordlist
+	}
+	if reg.Uses(generator.OptionTokenFormat) {
+		format, err := parseJobTokenFormat(t.TokenFormat)
+		if err != nil {
+			return nil, err
+		}
+		opts.TokenFormat = format
+	}
+	if reg.Uses(generator.OptionEncoding) && t.Encoding != "" {
+		encoding, err := generator.ParseKeyEncoding(t.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		opts.Encoding = encoding
+	}
+	return reg.New(opts)
+}
+
+// ConfigTask returns the task generating what the saved preset called
+// presetName would, or with no preset the config's defaults for genType
+// (default_generator if empty). Options the preset leaves unset come from
+// the config, as with passman generate -preset.
+func ConfigTask(cfg *config.Config, presetName, genType string) (JobTask, error) {
+	task := JobTask{Type: strings.ToLower(genType)}
+	if presetName != "" {
+		preset, ok := cfg.FindPreset(presetName)
+		if !ok {
+			return JobTask{}, fmt.Errorf("no preset %q", presetName)
+		}
+		if task.Type != "" && task.Type != preset.Type {
+			return JobTask{}, fmt.Errorf("preset %q generates %s, not %s", presetName, preset.Type, task.Type)
+		}
+		task.Type, task.Charsets, task.Wordlist = preset.Type, preset.Charsets, preset.Wordlist
+		task.Length, task.Words = preset.Length, preset.Words
+		task.Separator, task.GroupSize = preset.Separator, preset.GroupSize
+		task.Capitalize, task.Digit = preset.Capitalize, preset.Digit
+	}
+	if task.Type == "" {
+		task.Type = cfg.DefaultGenerator
+	}
+
+	// A preset's false is kept; its empty separator and sizes are not
+	switch task.Type {
+	case TaskRandom:
+		if task.Length == 0 {
+			task.Length = cfg.DefaultLength
+		}
+		if len(task.Charsets) == 0 {
+			for _, set := range []struct {
+				on   bool
+				name string
+			}{
+				{cfg.DefaultIncludeLowercase, "lower"},
+				{cfg.DefaultIncludeUppercase, "upper"},
+				{cfg.DefaultIncludeNumbers, "digits"},
+				{cfg.DefaultIncludeSymbols, "symbols"},
+			} {
+				if set.on {
+					task.Charsets = append(task.Charsets, set.name)
+				}
+			}
+		}
+	case TaskMemorable:
+		if task.Words == 0 {
+			task.Words = cfg.DefaultPassphraseWords
+		}
+		if task.Separator == "" {
+			task.Separator = cfg.DefaultPassphraseSeparator
+		}
+		if presetName == "" {
+			task.Capitalize, task.Digit = cfg.DefaultPassphraseCapitalize, cfg.DefaultPassphraseDigit
+		}
+	case TaskPIN:
+		if task.Length == 0 {
+			task.Length = cfg.DefaultPinLength
+		}
+		if task.Separator == "" {
+			task.Separator = cfg.DefaultPinSeparator
+		}
+		if presetName == "" {
+			task.GroupSize = cfg.DefaultPinGroupSize
+		}
+	}
+	return task, nil
+}
+
+// parseJobCharSet converts a character set name of a job file
+func parseJobCharSet(name string) (generator.CharSet, error) {
+	switch strings.ToLower(name) {
+	case "lower", "lowercase":
+		return generator.Lowercase, nil
+	case "upper", "uppercase":
+		return generator.Uppercase, nil
+	case "digits", "numbers":
+		return generator.Numbers, nil
+	case "symbols":
+		return generator.Symbols, nil
+	default:
+		if cs, err := generator.ParseLocaleCharSet(name); err == nil {
+			return cs, nil
+		}
+		return 0, fmt.Errorf("unknown charset %q (use lower, upper, digits, symbols, german, french, spanish or nordic)", name)
+	}
+}
+
+// parseJobTokenFormat converts a token format name of a job file
+func parseJobTokenFormat(name string) (generator.TokenFormat, error) {
+	switch strings.ToLower(name) {
+	case "", "uuid":
+		return generator.TokenUUID, nil
+	case "hex":
+		return generator.TokenHex, nil
+	case "base64url":
+		return generator.TokenBase64URL, nil
+	case "apikey", "api-key":
+		return generator.TokenAPIKey, nil
+	default:
+		return 0, fmt.Errorf("unknown token format %q (use uuid, hex, base64url or apikey)", name)
+	}
+}
+
+// RunJobs runs every task of the job in order. A failed task does not stop
+// the others; its error is recorded in its result. A dry run builds and
+// validates every task without generating or writing anything.
+func RunJobs(ctx context.Context, job *JobFile, dryRun bool) *JobReport {
+	report := &JobReport{
+		File:      job.path,
+		StartedAt: time.Now(),
+		DryRun:    dryRun,
+	}
+
+	for _, task := range job.Tasks {
+		result := JobResult{
+			Task:      task.Name,
+			Type:      strings.ToLower(task.Type),
+			Requested: task.Count,
+			Output:    task.Output,
+			Format:    task.Format,
+		}
+
+		if !dryRun {
+			generated, err := runJobTask(ctx, task)
+			result.Generated = generated
+			if err != nil {
+				result.ExitCode = JobExitFailed
+				result.Error = err.Error()
+				report.ExitCode = JobExitFailed
+			}
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	report.FinishedAt = time.Now()
+	return report
+}
+
+// runJobTask generates the task's secrets and writes them to its output
+// with owner-only permissions, returning how many were written
+func runJobTask(ctx context.Context, task JobTask) (int, error) {
+	gen, err := task.BuildGenerator()
+	if err != nil {
+		return 0, err
+	}
+
+	description := task.Description
+	if description == "" {
+		description = task.Name
+	}
+
+	entries := make([]PasswordEntry, 0, task.Count)
+	for i := 0; i < task.Count; i++ {
+		password, err := gen.Generate(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("generation failed: %w", err)
+		}
+		entries = append(entries, PasswordEntry{
+			Password:    password,
+			Length:      len(password),
+			Type:        strings.ToLower(task.Type),
+			CreatedAt:   time.Now(),
+			Description: description,
+		})
+	}
+
+	if err := os.MkdirAll(filepath.Dir(task.Output), 0700); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Create the file as owner-only before the export writes into it, and
+	// refuse to replace earlier credentials unless asked to
+	flags := os.O_WRONLY | os.O_CREATE
+	if !task.Overwrite {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(task.Output, flags, 0600)
+	if os.IsExist(err) {
+		return 0, fmt.Errorf("%s already exists; set overwrite: true to replace it", task.Output)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create output: %w", err)
+	}
+	file.Close()
+	if err := os.Chmod(task.Output, 0600); err != nil {
+		return 0, fmt.Errorf("failed to restrict output permissions: %w", err)
+	}
+
+	if err := NewExportManager().Export(entries, ExportFormat(task.Format), task.Output); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// String summarises the result for display
+func (r JobResult) String() string {
+	if r.Error != "" {
+		return fmt.Sprintf("FAIL %s (%s): %s", r.Task, r.Type, r.Error)
+	}
+	if r.Generated == 0 {
+		return fmt.Sprintf("OK   %s (%s): would write %d to %s", r.Task, r.Type, r.Requested, r.Output)
+	}
+	return fmt.Sprintf("OK   %s (%s): wrote %d to %s", r.Task, r.Type, r.Generated, r.Output)
+}
+
+// WriteReport writes the audit report as JSON. The report contains no
+// secrets, only what was generated where.
+func (r *JobReport) WriteReport(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Key derivation functions for the encrypted history files
+const (
+	KDFPBKDF2   = "pbkdf2"   // PBKDF2-HMAC-SHA256, the original format
+	KDFArgon2id = "argon2id" // Memory-hard, resists GPU cracking
+)
+
+// pbkdf2Iterations is the PBKDF2 work factor of the original format
+const pbkdf2Iterations = 100000
+
+// Argon2id parameters for newly written files. Files record the parameters
+// they were written with, so these can be raised without breaking old files.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024 // KiB
+	argon2Threads = 4
+)
+
+// argon2Magic starts every Argon2id file. PBKDF2 files have no header and
+// begin directly with the salt.
+var argon2Magic = []byte("PMA2")
+
+// argon2HeaderSize is the magic followed by time, memory and threads
+const argon2HeaderSize = 4 + 4 + 4 + 1
+
+// KDFNames lists the supported key derivation functions
+func KDFNames() []string {
+	return []string{KDFPBKDF2, KDFArgon2id}
+}
+
+// ParseKDF validates a key derivation function name
+func ParseKDF(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, kdf := range KDFNames() {
+		if name == kdf {
+			return kdf, nil
+		}
+	}
+	return "", fmt.Errorf("unknown key derivation function %q (use %s)", name, strings.Join(KDFNames(), " or "))
+}
+
+// kdfParams holds a key derivation function and its work factors
+type kdfParams struct {
+	kdf     string
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+// defaultKDFParams returns the parameters used when writing with kdf
+func defaultKDFParams(kdf string) kdfParams {
+	if kdf == KDFArgon2id {
+		return kdfParams{kdf: KDFArgon2id, time: argon2Time, memory: argon2Memory, threads: argon2Threads}
+	}
+	return kdfParams{kdf: KDFPBKDF2}
+}
+
+// header returns the bytes written before the salt
+func (p kdfParams) header() []byte {
+	if p.kdf != KDFArgon2id {
+		return nil
+	}
+	header := append([]byte{}, argon2Magic...)
+	header = binary.BigEndian.AppendUint32(header, p.time)
+	header = binary.BigEndian.AppendUint32(header, p.memory)
+	return append(header, p.threads)
+}
+
+// deriveKey derives a 32-byte AES key from the passphrase and salt
+func (p kdfParams) deriveKey(passphrase string, salt []byte) []byte {
+	if p.kdf == KDFArgon2id {
+		return argon2.IDKey([]byte(passphrase), salt, p.time, p.memory, p.threads, 32)
+	}
+	return pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, 32, sha256.New)
+}
+
+// parseKDFHeader splits an encrypted file into its KDF parameters and the
+// salt, nonce and ciphertext that follow. Files without a valid Argon2id
+// header are PBKDF2.
+func parseKDFHeader(data []byte) (kdfParams, []byte) {
+	if len(data) < argon2HeaderSize || !bytes.HasPrefix(data, argon2Magic) {
+		return kdfParams{kdf: KDFPBKDF2}, data
+	}
+
+	p := kdfParams{
+		kdf:     KDFArgon2id,
+		time:    binary.BigEndian.Uint32(data[4:8]),
+		memory:  binary.BigEndian.Uint32(data[8:12]),
+		threads: data[12],
+	}
+	// Refuse parameters that would hang or exhaust memory
+	if p.time < 1 || p.time > 16 || p.memory < 8*1024 || p.memory > 1024*1024 || p.threads < 1 {
+		return kdfParams{kdf: KDFPBKDF2}, data
+	}
+	return p, data[argon2HeaderSize:]
+}
+
+// DetectKDF reports which key derivation function an encrypted file uses
+func DetectKDF(data []byte) string {
+	p, _ := parseKDFHeader(data)
+	return p.kdf
+}
@@ -0,0 +1,43 @@
+package utils
+
+import "errors"
+
+// ErrNotInKeychain is returned when the OS keychain holds no history
+// passphrase for the active profile
+var ErrNotInKeychain = errors.New("no history passphrase in the keychain")
+
+// keychainLabel is what the keychain entries are listed as
+const keychainLabel = "passman history passphrase"
+
+// KeychainPassphrase returns the history passphrase of the active profile
+// from the OS keychain: the macOS Keychain, the Windows Credential Manager,
+// or elsewhere the Secret Service, e.g. GNOME Keyring or KWallet.
+// ErrNotInKeychain means there is none yet.
+func KeychainPassphrase() (string, error) {
+	name, err := passphraseCacheName()
+	if err != nil {
+		return "", err
+	}
+	return keychainLoad(name)
+}
+
+// StoreKeychainPassphrase adds the history passphrase of the active
+// profile to the OS keychain, replacing any there
+func StoreKeychainPassphrase(passphrase string) error {
+	name, err := passphraseCacheName()
+	if err != nil {
+		return err
+	}
+	return keychainStore(name, passphrase)
+}
+
+// DeleteKeychainPassphrase removes the history passphrase of the active
+// profile from the OS keychain. Removing one that isn't there is not an
+// error.
+func DeleteKeychainPassphrase() error {
+	name, err := passphraseCacheName()
+	if err != nil {
+		return err
+	}
+	return keychainRemove(name)
+}
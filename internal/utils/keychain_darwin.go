@@ -0,0 +1,97 @@
+//go:build darwin
+
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// On macOS the keychain is the login keychain, used through the security
+// tool as a generic password of service passman with the name as account
+
+// keychainService is the service of the keychain items
+const keychainService = "passman"
+
+// securityNotFound is the exit status of security when there is no such
+// item
+const securityNotFound = 44
+
+// keychainStore adds the item name, replacing any there. The command
+// goes to security on stdin rather than as arguments, so the secret
+// never shows in the process list.
+func keychainStore(name, secret string) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %s -X %s\n",
+		securityQuote(keychainService), securityQuote(name), securityQuote(keychainLabel), hex.EncodeToString([]byte(secret)))
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("security: %v: %s", err, bytes.TrimSpace(out))
+	}
+
+	// security -i reports failed commands without failing itself
+	stored, err := keychainLoad(name)
+	if err != nil || stored != secret {
+		return fmt.Errorf("security did not add the item: %s", bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// keychainLoad reads the item name. macOS may ask the user to allow
+// passman to read it.
+func keychainLoad(name string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", name, "-g")
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return "", ErrNotInKeychain
+	}
+	if err != nil {
+		return "", fmt.Errorf("security: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return parseSecurityPassword(stderr.String())
+}
+
+// keychainRemove deletes the item name
+func keychainRemove(name string) error {
+	out, err := exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", name).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("security: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// parseSecurityPassword finds the password in the output of security
+// find-generic-password -g: `password: "text"`, or `password: 0xHEX
+// "text"` for one that isn't printable ASCII
+func parseSecurityPassword(out string) (string, error) {
+	for _, line := range strings.Split(out, "\n") {
+		value, ok := strings.CutPrefix(line, "password: ")
+		if !ok {
+			continue
+		}
+		if digits, ok := strings.CutPrefix(value, "0x"); ok {
+			digits, _, _ = strings.Cut(digits, " ")
+			secret, err := hex.DecodeString(digits)
+			return string(secret), err
+		}
+		return strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`), nil
+	}
+	return "", fmt.Errorf("no password in the output of security")
+}
+
+// securityQuote quotes s as one argument of a security -i command
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
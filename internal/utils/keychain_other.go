@@ -0,0 +1,263 @@
+//go:build !darwin && !windows
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Elsewhere the keychain is the Secret Service of the session bus, as
+// GNOME Keyring, KWallet and KeePassXC provide it. The passphrase is an
+// item of the default collection, found again by its attributes.
+
+// ifaceSecretPrompt is the interface of the prompts a locked collection
+// asks to be unlocked with
+const ifaceSecretPrompt = "org.freedesktop.Secret.Prompt"
+
+// secretNoPrompt is the path returned when no prompt is needed
+const secretNoPrompt = dbusObjectPath("/")
+
+// errNoSecretService is returned when nothing on the session bus
+// provides the Secret Service
+var errNoSecretService = errors.New("no keychain: nothing provides the Secret Service (org.freedesktop.secrets) on the session bus")
+
+// keychainAttributes are the lookup attributes of the passphrase named
+// name
+func keychainAttributes(name string) map[string]string {
+	return map[string]string{"application": "passman", "profile": name}
+}
+
+// secretClient is a connection to the Secret Service with a session
+// open, which passes secrets in the clear: the bus is local to the user
+type secretClient struct {
+	conn    *dbusConn
+	session dbusObjectPath
+}
+
+// openSecretClient connects to the Secret Service and opens a session
+func openSecretClient() (*secretClient, error) {
+	conn, err := dialSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.call(secretServiceName, secretServicePath, ifaceSecretService, "OpenSession", "sv",
+		secretAlgorithmPlain, dbusVariant{Sig: "s", Value: ""})
+	var callErr *dbusCallError
+	if errors.As(err, &callErr) && callErr.Name == "org.freedesktop.DBus.Error.ServiceUnknown" {
+		err = errNoSecretService
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	session, ok := replyPath(reply, 1)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected OpenSession reply from the Secret Service")
+	}
+	return &secretClient{conn: conn, session: session}, nil
+}
+
+// Close closes the session and the connection
+func (c *secretClient) Close() error {
+	_, _ = c.conn.call(secretServiceName, c.session, ifaceSecretSession, "Close", "")
+	return c.conn.Close()
+}
+
+// search returns the items with attrs, unlocked and locked
+func (c *secretClient) search(attrs map[string]string) (unlocked, locked []dbusObjectPath, err error) {
+	reply, err := c.conn.call(secretServiceName, secretServicePath, ifaceSecretService, "SearchItems", "a{ss}", attrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlocked, ok1 := replyPaths(reply, 0)
+	locked, ok2 := replyPaths(reply, 1)
+	if !ok1 || !ok2 {
+		return nil, nil, fmt.Errorf("unexpected SearchItems reply from the Secret Service")
+	}
+	return unlocked, locked, nil
+}
+
+// unlock unlocks items or collections, prompting the user if the
+// Secret Service asks to
+func (c *secretClient) unlock(paths []dbusObjectPath) error {
+	reply, err := c.conn.call(secretServiceName, secretServicePath, ifaceSecretService, "Unlock", "ao", paths)
+	if err != nil {
+		return err
+	}
+	prompt, ok := replyPath(reply, 1)
+	if !ok {
+		return fmt.Errorf("unexpected Unlock reply from the Secret Service")
+	}
+	return c.prompt(prompt)
+}
+
+// prompt shows a prompt of the Secret Service, e.g. for the keyring
+// password, and waits until the user answers it. The no-prompt path "/"
+// returns at once.
+func (c *secretClient) prompt(path dbusObjectPath) error {
+	if path == secretNoPrompt || path == "" {
+		return nil
+	}
+	rule := fmt.Sprintf("type='signal',interface='%s',member='Completed',path='%s'", ifaceSecretPrompt, path)
+	if _, err := c.conn.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", rule); err != nil {
+		return err
+	}
+	if _, err := c.conn.call(secretServiceName, path, ifaceSecretPrompt, "Prompt", "s", ""); err != nil {
+		return err
+	}
+	for {
+		m, err := c.conn.read()
+		if err != nil {
+			return err
+		}
+		if m.Type != dbusSignal || m.Path != path || m.Member != "Completed" {
+			continue
+		}
+		if len(m.Body) > 0 && m.Body[0] == true {
+			return fmt.Errorf("the keychain prompt was dismissed")
+		}
+		return nil
+	}
+}
+
+// keychainStore adds the item name to the default collection, replacing
+// one with the same attributes
+func keychainStore(name, secret string) error {
+	c, err := openSecretClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	reply, err := c.conn.call(secretServiceName, secretServicePath, ifaceSecretService, "ReadAlias", "s", "default")
+	if err != nil {
+		return err
+	}
+	collection, ok := replyPath(reply, 0)
+	if !ok || collection == secretNoPrompt {
+		return fmt.Errorf("the keychain has no default collection")
+	}
+	if err := c.unlock([]dbusObjectPath{collection}); err != nil {
+		return err
+	}
+
+	props := map[string]dbusVariant{
+		ifaceSecretItem + ".Label":      {Sig: "s", Value: keychainLabel},
+		ifaceSecretItem + ".Attributes": {Sig: "a{ss}", Value: keychainAttributes(name)},
+	}
+	value := []interface{}{c.session, []byte{}, []byte(secret), "text/plain"}
+	reply, err = c.conn.call(secretServiceName, collection, ifaceSecretCollection, "CreateItem", "a{sv}(oayays)b", props, value, true)
+	if err != nil {
+		return err
+	}
+	prompt, ok := replyPath(reply, 1)
+	if !ok {
+		return fmt.Errorf("unexpected CreateItem reply from the Secret Service")
+	}
+	return c.prompt(prompt)
+}
+
+// keychainLoad reads the item name, unlocking it if needed
+func keychainLoad(name string) (string, error) {
+	c, err := openSecretClient()
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	unlocked, locked, err := c.search(keychainAttributes(name))
+	if err != nil {
+		return "", err
+	}
+	var item dbusObjectPath
+	switch {
+	case len(unlocked) > 0:
+		item = unlocked[0]
+	case len(locked) > 0:
+		item = locked[0]
+		if err := c.unlock(locked[:1]); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrNotInKeychain
+	}
+
+	reply, err := c.conn.call(secretServiceName, item, ifaceSecretItem, "GetSecret", "o", c.session)
+	if err != nil {
+		return "", err
+	}
+	var secret []byte
+	if len(reply) == 1 {
+		if fields, ok := reply[0].([]interface{}); ok && len(fields) == 4 {
+			secret, _ = fields[2].([]byte)
+		}
+	}
+	if secret == nil {
+		return "", fmt.Errorf("unexpected GetSecret reply from the Secret Service")
+	}
+	return string(secret), nil
+}
+
+// keychainRemove deletes every item name
+func keychainRemove(name string) error {
+	c, err := openSecretClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	unlocked, locked, err := c.search(keychainAttributes(name))
+	if err != nil {
+		return err
+	}
+	if len(locked) > 0 {
+		if err := c.unlock(locked); err != nil {
+			return err
+		}
+	}
+	for _, item := range append(unlocked, locked...) {
+		reply, err := c.conn.call(secretServiceName, item, ifaceSecretItem, "Delete", "")
+		if err != nil {
+			return err
+		}
+		prompt, ok := replyPath(reply, 0)
+		if !ok {
+			return fmt.Errorf("unexpected Delete reply from the Secret Service")
+		}
+		if err := c.prompt(prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replyPath returns the object path argument i of a reply
+func replyPath(reply []interface{}, i int) (dbusObjectPath, bool) {
+	if i >= len(reply) {
+		return "", false
+	}
+	path, ok := reply[i].(dbusObjectPath)
+	return path, ok
+}
+
+// replyPaths returns the object path array argument i of a reply
+func replyPaths(reply []interface{}, i int) ([]dbusObjectPath, bool) {
+	if i >= len(reply) {
+		return nil, false
+	}
+	items, ok := reply[i].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	paths := make([]dbusObjectPath, 0, len(items))
+	for _, item := range items {
+		path, ok := item.(dbusObjectPath)
+		if !ok {
+			return nil, false
+		}
+		paths = append(paths, path)
+	}
+	return paths, true
+}
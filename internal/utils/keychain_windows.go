@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// On Windows the keychain is the Credential Manager, where the item is a
+// generic credential with the name as target
+
+var (
+	advapi32       = windows.NewLazySystemDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// Credential type and persistence: kept for the user on this computer,
+// not roamed with the profile
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+// credential is the CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// keychainStore writes the credential name, replacing any there
+func keychainStore(name, secret string) error {
+	target, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	comment, err := windows.UTF16PtrFromString(keychainLabel)
+	if err != nil {
+		return err
+	}
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		Comment:            comment,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	if ok, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// keychainLoad reads the credential name
+func keychainLoad(name string) (string, error) {
+	target, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	ok, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		if errors.Is(err, windows.ERROR_NOT_FOUND) {
+			return "", ErrNotInKeychain
+		}
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+// keychainRemove deletes the credential name
+func keychainRemove(name string) error {
+	target, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	ok, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if ok == 0 && !errors.Is(err, windows.ERROR_NOT_FOUND) {
+		return err
+	}
+	return nil
+}
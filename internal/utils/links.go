@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxLinksPerEntry is how many entries may reuse one password before the
+// link audit warns about it
+const MaxLinksPerEntry = 3
+
+// Linked reports whether the entry reuses the password of another entry
+func (e HistoryEntry) Linked() bool {
+	return e.LinkedTo != ""
+}
+
+// NeedsUpdate reports whether the primary entry was rotated after this
+// entry was linked to it, so the password must be changed here too
+func (e HistoryEntry) NeedsUpdate() bool {
+	return e.PrimaryRotatedAt != nil
+}
+
+// Label names the entry for link listings: its description, or its
+// creation time when it has none
+func (e HistoryEntry) Label() string {
+	if e.Description != "" {
+		return e.Description
+	}
+	return fmt.Sprintf("%s %s", e.Type, e.CreatedAt.Format("Jan 2 2006 15:04"))
+}
+
+// FindEntry returns the entry whose ID or description matches ref. The
+// description match ignores case, so entries can be referred to by name,
+// e.g. "home wifi".
+func FindEntry(entries []HistoryEntry, ref string) (HistoryEntry, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return HistoryEntry{}, fmt.Errorf("entry name cannot be empty")
+	}
+
+	var matches []HistoryEntry
+	for _, entry := range entries {
+		if entry.ID == ref {
+			return entry, nil
+		}
+		if strings.EqualFold(entry.Description, ref) {
+			matches = append(matches, entry)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return HistoryEntry{}, fmt.Errorf("no entry named %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return HistoryEntry{}, fmt.Errorf("%d entries are named %q; use the entry ID", len(matches), ref)
+}
+
+// LinkedEntries returns the entries that reuse the password of the entry
+// with the given ID
+func LinkedEntries(entries []HistoryEntry, id string) []HistoryEntry {
+	var linked []HistoryEntry
+	for _, entry := range entries {
+		if entry.LinkedTo == id {
+			linked = append(linked, entry)
+		}
+	}
+	return linked
+}
+
+// LinkEntry records that the entry with the given ID uses the same password
+// as primary, which may be given by ID or description. Linking to an entry
+// that is itself linked links to its primary instead, so links are never
+// more than one level deep.
+func (h *HistoryManager) LinkEntry(id, primary string) error {
+	if id == "" {
+		return fmt.Errorf("entry ID cannot be empty")
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	target, err := FindEntry(entries, primary)
+	if err != nil {
+		return err
+	}
+	if target.Linked() {
+		if target, err = FindEntry(entries, target.LinkedTo); err != nil {
+			return fmt.Errorf("primary of %q: %w", primary, err)
+		}
+	}
+	if target.ID == id {
+		return fmt.Errorf("an entry cannot be linked to itself")
+	}
+
+	for i := range entries {
+		if entries[i].ID != id {
+			continue
+		}
+		if len(LinkedEntries(entries, id)) > 0 {
+			return fmt.Errorf("other entries are linked to this one; unlink them first")
+		}
+		entries[i].LinkedTo = target.ID
+		entries[i].PrimaryRotatedAt = nil
+		return h.saveHistory(entries)
+	}
+
+	return fmt.Errorf("history entry %s not found", id)
+}
+
+// UnlinkEntry removes the link of the entry with the given ID
+func (h *HistoryManager) UnlinkEntry(id string) error {
+	if id == "" {
+		return fmt.Errorf("entry ID cannot be empty")
+	}
+
+	return h.updateEntry(id, func(entry *HistoryEntry) {
+		entry.LinkedTo = ""
+		entry.PrimaryRotatedAt = nil
+	})
+}
+
+// MarkRotated records that the password of the entry with the given ID was
+// changed. Every entry linked to it is flagged for updating, and the
+// entry's own flag is cleared. It returns how many entries were flagged.
+func (h *HistoryManager) MarkRotated(id string) (int, error) {
+	if id == "" {
+		return 0, fmt.Errorf("entry ID cannot be empty")
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	found, flagged := false, 0
+	for i := range entries {
+		switch {
+		case entries[i].ID == id:
+			found = true
+			entries[i].PrimaryRotatedAt = nil
+		case entries[i].LinkedTo == id:
+			entries[i].PrimaryRotatedAt = &now
+			flagged++
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("history entry %s not found", id)
+	}
+
+	return flagged, h.saveHistory(entries)
+}
+
+// LinkWarning reports an entry whose password is reused by too many others
+type LinkWarning struct {
+	Entry HistoryEntry
+	Links int
+}
+
+// String formats the warning for display
+func (w LinkWarning) String() string {
+	return fmt.Sprintf("%q is reused by %d entries; a leak exposes all of them", w.Entry.Label(), w.Links)
+}
+
+// LinkAudit returns the entries linked to by more than MaxLinksPerEntry
+// others, most linked first
+func LinkAudit(entries []HistoryEntry) []LinkWarning {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		if entry.Linked() {
+			counts[entry.LinkedTo]++
+		}
+	}
+
+	var warnings []LinkWarning
+	for _, entry := range entries {
+		if n := counts[entry.ID]; n > MaxLinksPerEntry {
+			warnings = append(warnings, LinkWarning{Entry: entry, Links: n})
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Links > warnings[j].Links
+	})
+	return warnings
+}
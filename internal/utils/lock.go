@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// ErrHistoryLocked is returned by history operations while the history is
+// locked
+var ErrHistoryLocked = errors.New("history is locked")
+
+// ErrWrongPassphrase is returned when unlocking with the wrong passphrase
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// requirePassphrase checks that the history can be encrypted and decrypted
+func (h *HistoryManager) requirePassphrase() error {
+	if h.locked {
+		return ErrHistoryLocked
+	}
+	if h.passphrase == "" {
+		return fmt.Errorf("history passphrase not set")
+	}
+	return nil
+}
+
+// CanLock reports whether the history has a passphrase to lock it with
+func (h *HistoryManager) CanLock() bool {
+	return h.enabled && (h.locked || h.passphrase != "")
+}
+
+// IsLocked reports whether the history is locked
+func (h *HistoryManager) IsLocked() bool {
+	return h.locked
+}
+
+// Lock drops the passphrase until Unlock is called with it. Only a salted
+// PBKDF2 verifier is kept to check it; nothing can be read or written
+// while locked.
+func (h *HistoryManager) Lock() error {
+	if h.locked {
+		return nil
+	}
+	if !h.CanLock() {
+		return fmt.Errorf("history has no passphrase to lock with")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	h.verifierSalt = salt
+	h.verifier = kdfParams{kdf: KDFPBKDF2}.deriveKey(h.passphrase, salt)
+	h.passphrase = ""
+	h.cache.invalidate()
+	h.locked = true
+	return nil
+}
+
+// Unlock restores the passphrase dropped by Lock, if it matches
+func (h *HistoryManager) Unlock(passphrase string) error {
+	if !h.locked {
+		return nil
+	}
+
+	key := kdfParams{kdf: KDFPBKDF2}.deriveKey(passphrase, h.verifierSalt)
+	if subtle.ConstantTimeCompare(key, h.verifier) != 1 {
+		return ErrWrongPassphrase
+	}
+
+	h.passphrase = passphrase
+	h.verifier = nil
+	h.verifierSalt = nil
+	h.locked = false
+	return nil
+}
+
+// Lock locks the history and drops the config's copy of its passphrase,
+// and the cached one, so passman runs elsewhere ask for it too
+func (m *Manager) Lock() error {
+	if err := m.History.Lock(); err != nil {
+		return err
+	}
+	m.Config.HistoryEncryptionKey = ""
+	_ = ForgetPassphrase()
+	return nil
+}
+
+// Unlock unlocks the history with its passphrase and puts the passphrase
+// back in the config, and in the cache if it is enabled
+func (m *Manager) Unlock(passphrase string) error {
+	if err := m.History.Unlock(passphrase); err != nil {
+		return err
+	}
+	m.Config.HistoryEncryptionKey = passphrase
+	if m.Config.HistoryPassphrase == config.PassphrasePrompt && m.Config.PassphraseCacheMinutes > 0 {
+		_ = CachePassphrase(passphrase, time.Duration(m.Config.PassphraseCacheMinutes)*time.Minute)
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 	"github.com/mshnjffr/passman/internal/config"
 )
 
@@ -10,9 +13,13 @@ import (
 type Manager struct {
 	Config    *config.Config
 	Clipboard *ClipboardManager
+	AutoType  *AutoTypeManager
 	Export    *ExportManager
 	Wordlist  *WordlistManager
 	History   *HistoryManager
+	Recent    *RecentPasswords // Passwords generated this session, for undo
+	Notifier  *Notifier
+	Session   *SessionStats // Counters for the footer
 }
 
 // NewManager creates a new utilities manager with initialized components
@@ -28,7 +35,13 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 
 	// Initialize components
 	clipboard := NewClipboardManager()
+	clipboard.SetSensitive(cfg.SensitiveCopy)
+	clipboard.SetClearAfter(time.Duration(cfg.ClearClipboardAfter) * time.Second)
+	_ = clipboard.SetCommands(cfg.ClipboardCopyCommand, cfg.ClipboardPasteCommand) // Validate dropped invalid ones
+	autoType := NewAutoTypeManager()
 	export := NewExportManager()
+	export.SetFileMode(cfg.ExportMode())
+	export.SetEncryption(cfg.ExportEncryption, cfg.ExportRecipient)
 	wordlist := NewWordlistManager()
 	
 	// Initialize history manager with encryption if enabled
@@ -42,13 +55,19 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 	} else {
 		history = NewHistoryManager(false, "", 0)
 	}
+	history.SetKDF(cfg.HistoryKDF)
+	history.SetRetention(RetentionFromConfig(cfg))
 
 	manager := &Manager{
 		Config:    cfg,
 		Clipboard: clipboard,
+		AutoType:  autoType,
 		Export:    export,
 		Wordlist:  wordlist,
 		History:   history,
+		Recent:    NewRecentPasswords(cfg.RecentPasswords),
+		Notifier:  NewNotifier(cfg.Notifications),
+		Session:   NewSessionStats(),
 	}
 
 	// Load wordlist if needed
@@ -84,18 +103,44 @@ func (m *Manager) UpdateConfig(newConfig *config.Config) error {
 	// Reinitialize history if settings changed
 	if oldConfig.HistoryEnabled != newConfig.HistoryEnabled ||
 		oldConfig.HistoryMaxEntries != newConfig.HistoryMaxEntries ||
-		oldConfig.HistoryEncryptionKey != newConfig.HistoryEncryptionKey {
+		oldConfig.HistoryEncryptionKey != newConfig.HistoryEncryptionKey ||
+		oldConfig.HistoryKDF != newConfig.HistoryKDF {
 		
 		m.History = NewHistoryManager(
 			newConfig.HistoryEnabled,
 			newConfig.HistoryEncryptionKey,
 			newConfig.HistoryMaxEntries,
 		)
+		m.History.SetKDF(newConfig.HistoryKDF)
 	}
+	m.History.SetRetention(RetentionFromConfig(newConfig))
+
+	// Components that read their settings once at startup
+	m.Clipboard.SetSensitive(newConfig.SensitiveCopy)
+	m.Clipboard.SetClearAfter(time.Duration(newConfig.ClearClipboardAfter) * time.Second)
+	_ = m.Clipboard.SetCommands(newConfig.ClipboardCopyCommand, newConfig.ClipboardPasteCommand)
+	m.Notifier = NewNotifier(newConfig.Notifications)
+	m.Export.SetFileMode(newConfig.ExportMode())
+	m.Export.SetEncryption(newConfig.ExportEncryption, newConfig.ExportRecipient)
 
 	return nil
 }
 
+// SyncHistory syncs the history with the configured sync_remote and
+// records the outcome for the footer
+func (m *Manager) SyncHistory(ctx context.Context) (*SyncReport, error) {
+	remote, err := ParseSyncRemote(m.Config.SyncRemote)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := m.History.Sync(ctx, remote)
+	if m.Session != nil {
+		m.Session.RecordSync(time.Now(), err)
+	}
+	return report, err
+}
+
 // GetSystemInfo returns information about the utility systems
 func (m *Manager) GetSystemInfo() map[string]interface{} {
 	info := map[string]interface{}{
@@ -142,8 +187,12 @@ func (m *Manager) TestSystems() map[string]error {
 		results["wordlist"] = fmt.Errorf("wordlist not loaded")
 	}
 
-	// Test export
-	tempPath := "/tmp/test_export.txt"
+	// Test export, to a scratch file in the platform's temp directory
+	tempDir, err := config.GetTempDir()
+	if err != nil {
+		tempDir = os.TempDir()
+	}
+	tempPath := filepath.Join(tempDir, fmt.Sprintf("passman_test_export_%d.txt", time.Now().UnixNano()))
 	if err := m.Export.ExportSingle("test-password", "test", FormatText, tempPath); err != nil {
 		results["export"] = err
 	} else {
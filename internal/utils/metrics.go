@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// MetricKind is what a usage metric counts
+type MetricKind string
+
+const (
+	MetricCommand   MetricKind = "command"   // A command line command ran, by name
+	MetricGenerated MetricKind = "generated" // A secret was generated, by generator type
+	MetricCopied    MetricKind = "copied"    // A secret was copied to the clipboard
+	MetricLaunch    MetricKind = "launch"    // The TUI was started
+)
+
+// Metrics are the usage counts kept while enable_telemetry is on. They are
+// counts only: never a secret, site, username or time beyond the day
+// counting began, and they stay in the data directory. Nothing is sent
+// anywhere; passman stats shows them.
+type Metrics struct {
+	Since     string         `json:"since"` // Day counting began, YYYY-MM-DD
+	Commands  map[string]int `json:"commands,omitempty"`
+	Generated map[string]int `json:"generated,omitempty"`
+	Copies    int            `json:"copies,omitempty"`
+	Launches  int            `json:"launches,omitempty"`
+}
+
+// Empty reports whether nothing has been counted
+func (m Metrics) Empty() bool {
+	return len(m.Commands) == 0 && len(m.Generated) == 0 && m.Copies == 0 && m.Launches == 0
+}
+
+// MetricCount is one named count, for listing a map of counts in order
+type MetricCount struct {
+	Name  string
+	Count int
+}
+
+// SortedCounts returns counts with the largest first, then by name
+func SortedCounts(counts map[string]int) []MetricCount {
+	list := make([]MetricCount, 0, len(counts))
+	for name, count := range counts {
+		list = append(list, MetricCount{Name: name, Count: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// MetricsPath returns the file the usage counts are kept in
+func MetricsPath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "metrics.json"), nil
+}
+
+// LoadMetrics reads the usage counts, returning none if nothing was counted
+func LoadMetrics() (Metrics, error) {
+	path, err := MetricsPath()
+	if err != nil {
+		return Metrics{}, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Metrics{}, nil
+	}
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	var metrics Metrics
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return Metrics{}, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return metrics, nil
+}
+
+// RecordMetric adds one to a usage count when cfg has enable_telemetry on,
+// and does nothing otherwise. name is the command or generator type; other
+// kinds ignore it.
+func RecordMetric(cfg *config.Config, kind MetricKind, name string) error {
+	if cfg == nil || !cfg.EnableTelemetry {
+		return nil
+	}
+	path, err := MetricsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Two passman instances may count at once
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	metrics, err := LoadMetrics()
+	if err != nil {
+		return err
+	}
+	if metrics.Since == "" {
+		metrics.Since = time.Now().Format("2006-01-02")
+	}
+	switch kind {
+	case MetricCommand:
+		metrics.Commands = addCount(metrics.Commands, name)
+	case MetricGenerated:
+		metrics.Generated = addCount(metrics.Generated, name)
+	case MetricCopied:
+		metrics.Copies++
+	case MetricLaunch:
+		metrics.Launches++
+	default:
+		return fmt.Errorf("unknown metric kind: %s", kind)
+	}
+
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// ResetMetrics deletes the usage counts; counting starts over if
+// enable_telemetry stays on
+func ResetMetrics() error {
+	path, err := MetricsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RecordMetric adds one to a usage count if the config allows it. Failures
+// are ignored so that counting never blocks the action itself.
+func (m *Manager) RecordMetric(kind MetricKind, name string) {
+	_ = RecordMetric(m.Config, kind, name)
+}
+
+// addCount adds one to counts[name], creating the map if needed
+func addCount(counts map[string]int, name string) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[name]++
+	return counts
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage formats for the history. Only the single encrypted JSON file
+// exists today; the others are named so that asking for them gives a clear
+// error instead of an unknown-format one.
+const (
+	StorageJSON     = "json"      // One encrypted JSON array per file
+	StoragePerEntry = "per-entry" // Not implemented
+	StorageSQLite   = "sqlite"    // Not implemented
+)
+
+// MigrateOptions selects what a migration converts the history files to
+type MigrateOptions struct {
+	KDF           string // Target key derivation function; empty keeps the current one
+	Format        string // Target storage format; empty keeps JSON
+	NewPassphrase string // Re-encrypt under this passphrase; empty keeps the current one
+	DryRun        bool   // Convert and verify in memory without writing anything
+}
+
+// MigratedFile describes one file handled by a migration
+type MigratedFile struct {
+	Path       string
+	BackupPath string // Copy of the original, encrypted with the old passphrase
+	FromKDF    string
+	ToKDF      string
+	Records    int // Number of history records, -1 for files that are not record lists
+}
+
+// MigrateReport is the result of a migration
+type MigrateReport struct {
+	Files    []MigratedFile
+	Verified bool
+	DryRun   bool
+}
+
+// migrationFiles are the encrypted files that share the history key
+var migrationFiles = []string{"history.enc", "scratchpad.enc", "history.quarantine.enc"}
+
+// Migrate converts every encrypted history file to the target key
+// derivation function and passphrase. Each file is decrypted, re-encrypted
+// and verified by decrypting it again with the target settings before
+// anything is written. The originals are then copied to timestamped
+// backups and replaced atomically.
+func (h *HistoryManager) Migrate(opts MigrateOptions) (*MigrateReport, error) {
+	if h.passphrase == "" {
+		return nil, fmt.Errorf("history passphrase not set")
+	}
+
+	switch opts.Format {
+	case "", StorageJSON:
+	case StoragePerEntry, StorageSQLite:
+		return nil, fmt.Errorf("storage format %q is not supported yet; the history is stored as %s", opts.Format, StorageJSON)
+	default:
+		return nil, fmt.Errorf("unknown storage format %q", opts.Format)
+	}
+
+	target := &HistoryManager{
+		enabled:    h.enabled,
+		passphrase: h.passphrase,
+		maxEntries: h.maxEntries,
+		kdf:        h.kdf,
+	}
+	if opts.KDF != "" {
+		kdf, err := ParseKDF(opts.KDF)
+		if err != nil {
+			return nil, err
+		}
+		target.kdf = kdf
+	}
+	if opts.NewPassphrase != "" {
+		target.passphrase = opts.NewPassphrase
+	}
+
+	historyPath, err := h.getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(historyPath)
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	report := &MigrateReport{DryRun: opts.DryRun}
+	converted := make(map[string][]byte)
+
+	// Convert and verify everything before touching the disk
+	for _, name := range migrationFiles {
+		path := filepath.Join(dir, name)
+		encryptedData, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		plaintext, err := h.decrypt(encryptedData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", name, err)
+		}
+
+		reencrypted, err := target.encrypt(plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", name, err)
+		}
+
+		if err := verifyMigration(target, reencrypted, plaintext); err != nil {
+			return nil, fmt.Errorf("verification of %s failed: %w", name, err)
+		}
+
+		file := MigratedFile{
+			Path:    path,
+			FromKDF: DetectKDF(encryptedData),
+			ToKDF:   target.kdf,
+			Records: -1,
+		}
+		if name == "history.enc" {
+			var records []json.RawMessage
+			if err := json.Unmarshal(plaintext, &records); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+			}
+			file.Records = len(records)
+		}
+
+		report.Files = append(report.Files, file)
+		converted[path] = reencrypted
+	}
+	report.Verified = true
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	// Back up every original before replacing any of them, so a failure
+	// part way leaves a complete set of old files to restore from
+	stamp := time.Now().Format("20060102-150405")
+	for i := range report.Files {
+		file := &report.Files[i]
+		file.BackupPath = fmt.Sprintf("%s.bak-%s", file.Path, stamp)
+		if err := copyFile(file.Path, file.BackupPath); err != nil {
+			return report, fmt.Errorf("failed to back up %s: %w", filepath.Base(file.Path), err)
+		}
+	}
+
+	for _, file := range report.Files {
+		if err := writeFileAtomic(file.Path, converted[file.Path]); err != nil {
+			return report, fmt.Errorf("failed to write %s (originals are in the .bak-%s files): %w",
+				filepath.Base(file.Path), stamp, err)
+		}
+	}
+
+	h.passphrase = target.passphrase
+	h.kdf = target.kdf
+	return report, nil
+}
+
+// verifyMigration decrypts converted data with the target settings and
+// checks that it matches the original plaintext exactly
+func verifyMigration(target *HistoryManager, encryptedData, plaintext []byte) error {
+	if DetectKDF(encryptedData) != target.kdf {
+		return fmt.Errorf("written as %s instead of %s", DetectKDF(encryptedData), target.kdf)
+	}
+
+	params, body := parseKDFHeader(encryptedData)
+	roundTrip, err := target.decryptWith(params, body)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(roundTrip, plaintext) {
+		return fmt.Errorf("decrypted content differs from the original")
+	}
+	return nil
+}
+
+// copyFile copies src to a new file dst with owner-only permissions
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0600)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partly written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// String summarises the migrated file for display
+func (f MigratedFile) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s -> %s", filepath.Base(f.Path), f.FromKDF, f.ToKDF)
+	if f.Records >= 0 {
+		fmt.Fprintf(&b, ", %d records", f.Records)
+	}
+	if f.BackupPath != "" {
+		fmt.Fprintf(&b, ", backup %s", f.BackupPath)
+	}
+	return b.String()
+}
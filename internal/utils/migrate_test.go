@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    MigrateOptions
+		wantKDF string
+		wantKey string
+	}{
+		{"PBKDF2 to Argon2id", MigrateOptions{KDF: KDFArgon2id}, KDFArgon2id, testPassphrase},
+		{"New passphrase", MigrateOptions{NewPassphrase: "a new passphrase"}, KDFPBKDF2, "a new passphrase"},
+		{"Dry run", MigrateOptions{KDF: KDFArgon2id, DryRun: true}, KDFPBKDF2, testPassphrase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHistory(t)
+			addTestEntries(t, h, "a.example", "b.example", "c.example")
+			want := historyPasswords(t, h)
+			historyPath, _ := h.getHistoryPath()
+			original, err := os.ReadFile(historyPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := h.Migrate(tt.opts)
+			if err != nil {
+				t.Fatalf("Migrate failed: %v", err)
+			}
+			if !report.Verified || len(report.Files) != 1 || report.Files[0].Records != 3 {
+				t.Fatalf("Expected the 3 records of history.enc to be verified, got %+v", report)
+			}
+
+			data, err := os.ReadFile(historyPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectKDF(data); got != tt.wantKDF {
+				t.Errorf("Expected the history in %s, got %s", tt.wantKDF, got)
+			}
+			if tt.opts.DryRun {
+				if string(data) != string(original) || report.Files[0].BackupPath != "" {
+					t.Error("Expected a dry run to leave the history alone")
+				}
+				return
+			}
+			backup, err := os.ReadFile(report.Files[0].BackupPath)
+			if err != nil || string(backup) != string(original) {
+				t.Errorf("Expected the original in %s: %v", report.Files[0].BackupPath, err)
+			}
+
+			// Another passman run with the migrated settings reads every
+			// entry; the old passphrase no longer does
+			reopened := NewHistoryManager(true, tt.wantKey, 100)
+			reopened.SetKDF(tt.wantKDF)
+			got := historyPasswords(t, reopened)
+			for id, password := range want {
+				if got[id] != password {
+					t.Errorf("Entry %s: expected %q, got %q", id, password, got[id])
+				}
+			}
+			if tt.wantKey != testPassphrase {
+				if _, err := NewHistoryManager(true, testPassphrase, 100).LoadHistory(); err == nil {
+					t.Error("Expected the old passphrase to fail")
+				}
+			}
+		})
+	}
+}
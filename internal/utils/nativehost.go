@@ -0,0 +1,423 @@
+package utils
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// NativeHostName is the name browser extensions connect to passman by;
+// browsers allow only lowercase letters, digits, dots and underscores
+const NativeHostName = "com.mshnjffr.passman"
+
+// NativePresets lists the saved presets; the native host also answers
+// AgentPing and AgentGenerate, but never reads the history
+const NativePresets = "presets"
+
+// Browsers a native messaging manifest can be installed for
+const (
+	BrowserChrome   = "chrome"
+	BrowserChromium = "chromium"
+	BrowserFirefox  = "firefox"
+)
+
+// Message size limits of the native messaging protocol: browsers accept
+// at most 1 MiB from a host, and requests are capped to the agent's limit
+const (
+	maxNativeReply   = 1 << 20
+	maxNativeRequest = maxAgentRequest
+)
+
+// chromeExtensionID matches the 32-letter IDs of Chrome extensions
+var chromeExtensionID = regexp.MustCompile(`^[a-p]{32}$`)
+
+// nativeGenerateParams choose what to generate, a saved preset or the
+// config's defaults for a type, and whether to save it to the history
+// for the site the extension is on
+type nativeGenerateParams struct {
+	Preset      string `json:"preset"`
+	Type        string `json:"type"` // Default: the preset's, or default_generator
+	Site        string `json:"site"`
+	Username    string `json:"username"`
+	Description string `json:"description"`
+	Save        bool   `json:"save"`
+}
+
+// nativeGenerateResult is one generated password
+type nativeGenerateResult struct {
+	Password string  `json:"password"`
+	Type     string  `json:"type"`
+	Entropy  float64 `json:"entropy"`
+	Warning  string  `json:"warning,omitempty"` // Set below generator.MinSafeEntropy
+	Saved    bool    `json:"saved"`
+}
+
+// nativePreset describes a saved preset to the extension
+type nativePreset struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Summary string `json:"summary"`
+}
+
+// NativeHost answers a browser extension over the native messaging
+// protocol of Chrome and Firefox: the browser starts passman and sends
+// agent requests on its standard input, each a JSON object preceded by
+// its length as a 32-bit integer in native byte order, and reads the
+// responses from its standard output the same way. The extension can
+// list the presets and generate passwords with them, but cannot read the
+// history. The config is read for every request, so presets saved while
+// the extension is connected are offered straight away.
+type NativeHost struct {
+	version string
+}
+
+// NewNativeHost creates a native messaging host
+func NewNativeHost(version string) *NativeHost {
+	return &NativeHost{version: version}
+}
+
+// Serve answers the messages read from r on w until r is closed, which
+// is how the browser disconnects
+func (h *NativeHost) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	for {
+		msg, err := ReadNativeMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req AgentRequest
+		var resp AgentResponse
+		if err := json.Unmarshal(msg, &req); err != nil {
+			resp.Error = "invalid request: " + err.Error()
+		} else {
+			resp = h.Handle(ctx, req)
+		}
+		if err := WriteNativeMessage(w, resp); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadNativeMessage reads one length-prefixed message, returning io.EOF
+// if r ends before it starts
+func ReadNativeMessage(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.NativeEndian, &size); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated message length")
+		}
+		return nil, err
+	}
+	if size > maxNativeRequest {
+		return nil, fmt.Errorf("message of %d bytes is larger than %d", size, maxNativeRequest)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, fmt.Errorf("truncated message: %w", err)
+	}
+	return msg, nil
+}
+
+// WriteNativeMessage writes value as one length-prefixed JSON message
+func WriteNativeMessage(w io.Writer, value interface{}) error {
+	msg, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if len(msg) > maxNativeReply {
+		return fmt.Errorf("response of %d bytes is larger than browsers accept", len(msg))
+	}
+	if err := binary.Write(w, binary.NativeEndian, uint32(len(msg))); err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	return err
+}
+
+// Handle answers a single request
+func (h *NativeHost) Handle(ctx context.Context, req AgentRequest) AgentResponse {
+	result, err := h.dispatch(ctx, req)
+	resp := AgentResponse{ID: req.ID, Result: result}
+	if err != nil {
+		resp.Result = nil
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+// dispatch runs the method of a request
+func (h *NativeHost) dispatch(ctx context.Context, req AgentRequest) (interface{}, error) {
+	switch req.Method {
+	case AgentPing:
+		return map[string]interface{}{"version": h.version, "profile": config.ActiveProfile()}, nil
+
+	case NativePresets:
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		presets := []nativePreset{}
+		for _, name := range cfg.PresetNames() {
+			preset := cfg.Presets[name]
+			presets = append(presets, nativePreset{Name: name, Type: preset.Type, Summary: preset.Summary()})
+		}
+		return presets, nil
+
+	case AgentGenerate:
+		var params nativeGenerateParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return h.generate(ctx, params)
+
+	case "":
+		return nil, fmt.Errorf("method is required")
+	default:
+		return nil, fmt.Errorf("unknown method %q (use ping, presets or generate)", req.Method)
+	}
+}
+
+// generate creates a password with a preset or the config's defaults,
+// saving it to the history if asked to. The history passphrase cannot be
+// asked for here, so with history_passphrase: prompt it must be cached.
+func (h *NativeHost) generate(ctx context.Context, params nativeGenerateParams) (interface{}, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	task, err := ConfigTask(&cfg, params.Preset, params.Type)
+	if err != nil {
+		return nil, err
+	}
+	gen, err := task.BuildGenerator()
+	if err == nil {
+		err = gen.Validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+	password, err := gen.Generate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+	_ = RecordMetric(&cfg, MetricGenerated, task.Type)
+
+	result := nativeGenerateResult{Password: password, Type: task.Type, Entropy: gen.EstimateEntropy()}
+	if warning := generator.CheckEntropy(gen); warning != nil {
+		result.Warning = warning.String()
+	}
+	if !params.Save {
+		return result, nil
+	}
+
+	if !cfg.HistoryEnabled {
+		return nil, fmt.Errorf("history is disabled")
+	}
+	if err := ResolveHistoryPassphrase(&cfg, nil); err != nil {
+		return nil, fmt.Errorf("cannot save: %w; enter it in passman first", err)
+	}
+	history := NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+	history.SetKDF(cfg.HistoryKDF)
+	history.SetRetention(RetentionFromConfig(&cfg))
+	entry := HistoryEntry{
+		ID:          history.NewID(),
+		Password:    password,
+		Length:      len([]rune(password)),
+		Type:        task.Type,
+		Description: params.Description,
+		Site:        strings.TrimSpace(params.Site),
+		Username:    strings.TrimSpace(params.Username),
+	}
+	if entry.Description == "" {
+		entry.Description = entry.Site
+	}
+	if err := history.AddEntry(entry); err != nil {
+		return nil, fmt.Errorf("failed to save to history: %w", err)
+	}
+	result.Saved = true
+	return result, nil
+}
+
+// nativeManifest is the file telling a browser which program serves
+// NativeHostName and which extensions may start it
+type nativeManifest struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Path              string   `json:"path"`
+	Type              string   `json:"type"`
+	AllowedOrigins    []string `json:"allowed_origins,omitempty"`    // Chrome and Chromium
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"` // Firefox
+}
+
+// newNativeManifest returns the manifest letting the extension with the
+// given ID start the program at path. Chrome IDs may also be given as
+// chrome-extension:// origins.
+func newNativeManifest(browser, extensionID, path string) (nativeManifest, error) {
+	manifest := nativeManifest{
+		Name:        NativeHostName,
+		Description: "passman password generator",
+		Path:        path,
+		Type:        "stdio",
+	}
+	switch browser {
+	case BrowserChrome, BrowserChromium:
+		id := strings.TrimSuffix(strings.TrimPrefix(extensionID, "chrome-extension://"), "/")
+		if !chromeExtensionID.MatchString(id) {
+			return nativeManifest{}, fmt.Errorf("invalid extension ID %q: want the 32 letters a-p shown on chrome://extensions", extensionID)
+		}
+		manifest.AllowedOrigins = []string{"chrome-extension://" + id + "/"}
+	case BrowserFirefox:
+		if extensionID == "" || strings.ContainsAny(extensionID, " \t\r\n") {
+			return nativeManifest{}, fmt.Errorf("invalid extension ID %q: want the add-on's ID, e.g. passman@example.org", extensionID)
+		}
+		manifest.AllowedExtensions = []string{extensionID}
+	default:
+		return nativeManifest{}, fmt.Errorf("unknown browser %q (use %s, %s or %s)", browser, BrowserChrome, BrowserChromium, BrowserFirefox)
+	}
+	return manifest, nil
+}
+
+// nativeManifestDir returns the directory a browser reads user-level
+// native messaging manifests from. Windows finds them through the
+// registry instead, so there it is passman's data directory.
+func nativeManifestDir(browser string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return config.GetDataDir()
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		support := filepath.Join(home, "Library", "Application Support")
+		switch browser {
+		case BrowserChrome:
+			return filepath.Join(support, "Google", "Chrome", "NativeMessagingHosts"), nil
+		case BrowserChromium:
+			return filepath.Join(support, "Chromium", "NativeMessagingHosts"), nil
+		}
+		return filepath.Join(support, "Mozilla", "NativeMessagingHosts"), nil
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	switch browser {
+	case BrowserChrome:
+		return filepath.Join(configHome, "google-chrome", "NativeMessagingHosts"), nil
+	case BrowserChromium:
+		return filepath.Join(configHome, "chromium", "NativeMessagingHosts"), nil
+	}
+	return filepath.Join(home, ".mozilla", "native-messaging-hosts"), nil
+}
+
+// nativeRegistryKeys are where Windows browsers look up native hosts
+var nativeRegistryKeys = map[string]string{
+	BrowserChrome:   `HKCU\Software\Google\Chrome\NativeMessagingHosts\` + NativeHostName,
+	BrowserChromium: `HKCU\Software\Chromium\NativeMessagingHosts\` + NativeHostName,
+	BrowserFirefox:  `HKCU\Software\Mozilla\NativeMessagingHosts\` + NativeHostName,
+}
+
+// InstallNativeHost registers passman as the native messaging host of
+// browser for the extension with the given ID and returns the manifest
+// written. Browsers start the manifest's program without arguments of
+// passman's choosing, so a launcher script running executable native-host
+// with the active profile and PASSMAN_CONFIG_DIR is written to the data
+// directory first. Only one profile can serve a browser; installing
+// again switches it.
+func InstallNativeHost(browser, extensionID, executable string) (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	launcher, script := filepath.Join(dataDir, "native-host"), nativeLauncher(executable)
+	if runtime.GOOS == "windows" {
+		launcher += ".bat"
+	}
+	manifest, err := newNativeManifest(browser, extensionID, launcher)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(launcher, []byte(script)); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(launcher, 0700); err != nil {
+		return "", err
+	}
+
+	dir, err := nativeManifestDir(browser)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	manifestPath := filepath.Join(dir, NativeHostName+".json")
+	if runtime.GOOS == "windows" {
+		manifestPath = filepath.Join(dir, NativeHostName+"."+browser+".json")
+	}
+	if err := writeFileAtomic(manifestPath, append(data, '\n')); err != nil {
+		return "", err
+	}
+
+	if runtime.GOOS == "windows" {
+		cmd := exec.Command("reg", "add", nativeRegistryKeys[browser], "/ve", "/t", "REG_SZ", "/d", manifestPath, "/f")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to register %s: %v: %s", manifestPath, err, strings.TrimSpace(string(output)))
+		}
+	}
+	return manifestPath, nil
+}
+
+// nativeLauncher returns the script running executable as the native
+// host of the active profile and config directory
+func nativeLauncher(executable string) string {
+	args := []string{"native-host"}
+	if profile := config.ActiveProfile(); profile != config.DefaultProfile {
+		args = append([]string{"--profile", profile}, args...)
+	}
+	configDir := os.Getenv(config.ConfigDirEnv)
+	if configDir != "" {
+		configDir, _ = filepath.Abs(configDir)
+	}
+
+	if runtime.GOOS == "windows" {
+		var b strings.Builder
+		b.WriteString("@echo off\r\n")
+		if configDir != "" {
+			fmt.Fprintf(&b, "set \"%s=%s\"\r\n", config.ConfigDirEnv, configDir)
+		}
+		fmt.Fprintf(&b, "\"%s\" %s %%*\r\n", executable, strings.Join(args, " "))
+		return b.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	if configDir != "" {
+		fmt.Fprintf(&b, "%s=%s\nexport %s\n", config.ConfigDirEnv, shellQuote(configDir), config.ConfigDirEnv)
+	}
+	fmt.Fprintf(&b, "exec %s %s \"$@\"\n", shellQuote(executable), strings.Join(args, " "))
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Notification methods, configured per event
+const (
+	NotifyOff     = "off"
+	NotifyBell    = "bell"    // Ring the terminal bell
+	NotifyDesktop = "desktop" // notify-send, osascript or a Windows balloon tip
+	NotifyBoth    = "both"
+)
+
+// Events that can notify on completion
+const (
+	EventAutoType = "auto_type" // A delayed auto-type finished
+	EventExport   = "export"    // A history export finished
+
+	// A client of passman agent or serve asked for more secrets than its quota
+	EventAgentQuota = "agent_quota"
+)
+
+// NotifyEvents lists the events that can be configured
+func NotifyEvents() []string {
+	return []string{EventAutoType, EventExport, EventAgentQuota}
+}
+
+// NotifyMethods lists the valid notification methods
+func NotifyMethods() []string {
+	return []string{NotifyOff, NotifyBell, NotifyDesktop, NotifyBoth}
+}
+
+// Notifier tells the user that a background task finished, for when they
+// are on another screen or in another window. Messages must never contain
+// secrets: desktop notifications are logged by most notification daemons.
+type Notifier struct {
+	methods map[string]string // Event to method; missing events are off
+	bell    io.Writer
+}
+
+// NewNotifier creates a notifier using the given method for each event
+func NewNotifier(methods map[string]string) *Notifier {
+	return &Notifier{methods: methods, bell: os.Stderr}
+}
+
+// Method returns the notification method configured for an event
+func (n *Notifier) Method(event string) string {
+	if n == nil {
+		return NotifyOff
+	}
+	if method, ok := n.methods[event]; ok {
+		return method
+	}
+	return NotifyOff
+}
+
+// Notify notifies about a finished event using its configured method
+func (n *Notifier) Notify(event, title, message string) error {
+	method := n.Method(event)
+
+	if method == NotifyBell || method == NotifyBoth {
+		// Written to stderr so it does not interleave with the UI on stdout
+		if _, err := io.WriteString(n.bell, "\a"); err != nil {
+			return fmt.Errorf("failed to ring bell: %w", err)
+		}
+	}
+
+	if method == NotifyDesktop || method == NotifyBoth {
+		return desktopNotify(title, message)
+	}
+
+	return nil
+}
+
+// desktopNotify shows a desktop notification with the platform's tool
+func desktopNotify(title, message string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+		script := fmt.Sprintf(`display notification "%s" with title "%s"`, quote.Replace(message), quote.Replace(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		quote := strings.NewReplacer(`'`, `''`)
+		script := fmt.Sprintf("Add-Type -AssemblyName System.Windows.Forms; "+
+			"$n = New-Object System.Windows.Forms.NotifyIcon; "+
+			"$n.Icon = [System.Drawing.SystemIcons]::Information; "+
+			"$n.Visible = $true; "+
+			"$n.ShowBalloonTip(5000, '%s', '%s', 'Info'); "+
+			"Start-Sleep -Seconds 5; $n.Dispose()", quote.Replace(title), quote.Replace(message))
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=passman", title, message)
+	}
+
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		return fmt.Errorf("desktop notifications not available: %w", err)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notification failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
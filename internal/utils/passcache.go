@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// ErrNoPassphraseCache is returned where the platform has no passphrase cache
+var ErrNoPassphraseCache = errors.New("passphrase caching is not supported on this platform")
+
+// PassphrasePrompter asks the user for the history passphrase. confirm
+// asks twice, for a history that doesn't exist yet.
+type PassphrasePrompter func(confirm bool) (string, error)
+
+// passphraseCacheName names the cached passphrase of the active profile's
+// history, so profiles and PASSMAN_CONFIG_DIR setups never share one
+func passphraseCacheName() (string, error) {
+	paths, err := config.ResolvePaths()
+	if err != nil {
+		return "", err
+	}
+	return "passman:" + paths.Data, nil
+}
+
+// CachePassphrase remembers the history passphrase of the active profile
+// for ttl, so passman runs in that time don't ask for it again. There is
+// no daemon: the passphrase is kept by the operating system, in the Linux
+// kernel keyring, which drops it when ttl runs out.
+func CachePassphrase(passphrase string, ttl time.Duration) error {
+	name, err := passphraseCacheName()
+	if err != nil {
+		return err
+	}
+	return cacheStore(name, passphrase, ttl)
+}
+
+// CachedPassphrase returns the cached history passphrase of the active
+// profile, if it has not expired
+func CachedPassphrase() (string, bool) {
+	name, err := passphraseCacheName()
+	if err != nil {
+		return "", false
+	}
+	return cacheLoad(name)
+}
+
+// ForgetPassphrase removes the cached history passphrase of the active
+// profile. Forgetting a passphrase that isn't cached is not an error.
+func ForgetPassphrase() error {
+	name, err := passphraseCacheName()
+	if err != nil {
+		return err
+	}
+	return cacheRemove(name)
+}
+
+// ResolveHistoryPassphrase fills in the history passphrase of a config
+// that doesn't store it. With history_passphrase set to prompt it comes
+// from the cache if it is enabled, otherwise from asking with prompt,
+// checking the answer against the existing history. With keychain it
+// comes from the OS keychain, and the first time from asking, after
+// which the keychain keeps it. With hardware it is unwrapped by the
+// enrolled hardware token, which waits to be touched. A nil prompt never
+// asks. Configs that store the passphrase are left alone.
+func ResolveHistoryPassphrase(cfg *config.Config, prompt PassphrasePrompter) error {
+	if cfg.HistoryEncryptionKey != "" {
+		return nil
+	}
+	switch cfg.HistoryPassphrase {
+	case config.PassphrasePrompt:
+		return resolvePromptedPassphrase(cfg, prompt)
+	case config.PassphraseKeychain:
+		return resolveKeychainPassphrase(cfg, prompt)
+	case config.PassphraseHardware:
+		key, err := LoadHardwareKey()
+		if err != nil {
+			return err
+		}
+		passphrase, err := key.Unwrap()
+		if err != nil {
+			return err
+		}
+		cfg.HistoryEncryptionKey = passphrase
+	}
+	return nil
+}
+
+// resolvePromptedPassphrase fills in a prompted passphrase, from the
+// cache or by asking
+func resolvePromptedPassphrase(cfg *config.Config, prompt PassphrasePrompter) error {
+	ttl := time.Duration(cfg.PassphraseCacheMinutes) * time.Minute
+	if ttl > 0 {
+		if passphrase, ok := CachedPassphrase(); ok {
+			cfg.HistoryEncryptionKey = passphrase
+			// Using it restarts the timeout, like gpg-agent
+			_ = CachePassphrase(passphrase, ttl)
+			return nil
+		}
+	}
+	if prompt == nil {
+		return fmt.Errorf("history passphrase not entered")
+	}
+
+	passphrase, err := askHistoryPassphrase(cfg, prompt)
+	if err != nil {
+		return err
+	}
+	cfg.HistoryEncryptionKey = passphrase
+	if ttl > 0 {
+		if err := CachePassphrase(passphrase, ttl); err != nil && !errors.Is(err, ErrNoPassphraseCache) {
+			return fmt.Errorf("cannot cache the passphrase: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveKeychainPassphrase fills in a passphrase from the OS keychain,
+// asking for it and adding it there if it's missing
+func resolveKeychainPassphrase(cfg *config.Config, prompt PassphrasePrompter) error {
+	passphrase, err := KeychainPassphrase()
+	if err == nil {
+		cfg.HistoryEncryptionKey = passphrase
+		return nil
+	}
+	if !errors.Is(err, ErrNotInKeychain) {
+		return fmt.Errorf("cannot read the keychain: %w", err)
+	}
+	if prompt == nil {
+		return fmt.Errorf("history passphrase not entered: %w", err)
+	}
+
+	passphrase, err = askHistoryPassphrase(cfg, prompt)
+	if err != nil {
+		return err
+	}
+	if err := StoreKeychainPassphrase(passphrase); err != nil {
+		return fmt.Errorf("cannot add the passphrase to the keychain: %w", err)
+	}
+	cfg.HistoryEncryptionKey = passphrase
+	return nil
+}
+
+// askHistoryPassphrase asks for the history passphrase with prompt,
+// twice for a history that doesn't exist yet, and checks the answer
+// against an existing one
+func askHistoryPassphrase(cfg *config.Config, prompt PassphrasePrompter) (string, error) {
+	history := NewHistoryManager(true, "", cfg.HistoryMaxEntries)
+	historyPath, err := history.getHistoryPath()
+	if err != nil {
+		return "", err
+	}
+	_, statErr := os.Stat(historyPath)
+	exists := statErr == nil
+
+	passphrase, err := prompt(!exists)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("history passphrase not entered")
+	}
+	if exists {
+		history.SetPassphrase(passphrase)
+		if _, err := history.LoadHistory(); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrWrongPassphrase, err)
+		}
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyPermissions lets only the possessor and processes of the same user
+// see, read and update the key; there is no group or other access
+const keyPermissions = 0x3f3f0000
+
+// cacheStore adds or replaces a user key in the user keyring and sets it
+// to expire after ttl
+func cacheStore(name, secret string, ttl time.Duration) error {
+	id, err := unix.AddKey("user", name, []byte(secret), unix.KEY_SPEC_USER_KEYRING)
+	if err != nil {
+		return err
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SETPERM, id, keyPermissions, 0, 0); err != nil {
+		return err
+	}
+	seconds := int(ttl.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	_, err = unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, id, seconds, 0, 0)
+	return err
+}
+
+// cacheLoad reads a key from the user keyring
+func cacheLoad(name string) (string, bool) {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", name, 0)
+	if err != nil {
+		return "", false
+	}
+
+	buf := make([]byte, 256)
+	for {
+		n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+		if err != nil {
+			return "", false
+		}
+		if n <= len(buf) {
+			return string(buf[:n]), true
+		}
+		buf = make([]byte, n)
+	}
+}
+
+// cacheRemove invalidates a key in the user keyring
+func cacheRemove(name string) error {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", name, 0)
+	if errors.Is(err, unix.ENOKEY) || errors.Is(err, unix.EKEYEXPIRED) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+	return err
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package utils
+
+import "time"
+
+// cacheStore is not supported; every run asks for the passphrase
+func cacheStore(name, secret string, ttl time.Duration) error {
+	return ErrNoPassphraseCache
+}
+
+// cacheLoad finds nothing without a cache
+func cacheLoad(name string) (string, bool) {
+	return "", false
+}
+
+// cacheRemove has nothing to remove without a cache
+func cacheRemove(name string) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxComLen is how much of a program's file name macOS keeps for a process
+const maxComLen = 16
+
+// peerCredentials asks the kernel who connected to a Unix socket, with
+// LOCAL_PEERCRED and LOCAL_PEERPID. macOS doesn't give the path of the
+// program without libproc, so Exe is the file name the kernel recorded
+// when the program started, cut to maxComLen bytes.
+func peerCredentials(conn net.Conn) (PeerCredentials, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return PeerCredentials{}, errNoPeerCredentials
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return PeerCredentials{}, err
+	}
+
+	var cred *unix.Xucred
+	var pid int
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		if cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED); credErr != nil {
+			return
+		}
+		pid, credErr = unix.GetsockoptInt(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+	}); err != nil {
+		return PeerCredentials{}, err
+	}
+	if credErr != nil {
+		return PeerCredentials{}, fmt.Errorf("LOCAL_PEERCRED: %w", credErr)
+	}
+
+	peer := PeerCredentials{UID: int(cred.Uid), PID: pid}
+	if proc, err := unix.SysctlKinfoProc("kern.proc.pid", pid); err == nil {
+		peer.Exe = unix.ByteSliceToString(proc.Proc.P_comm[:])
+	}
+	return peer, nil
+}
+
+// checkAllowedClient refuses agent_allowed_clients entries that can't be
+// told apart on macOS: paths, and names longer than the kernel keeps
+func checkAllowedClient(allowed string) error {
+	if strings.ContainsRune(allowed, '/') {
+		return fmt.Errorf("%q: macOS doesn't report the path of a client's program, list it by file name", allowed)
+	}
+	if len(allowed) > maxComLen {
+		return fmt.Errorf("%q: macOS keeps only the first %d bytes of a program's file name, list those", allowed, maxComLen)
+	}
+	return nil
+}
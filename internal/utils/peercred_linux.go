@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentials asks the kernel who connected to a Unix socket, with
+// SO_PEERCRED, and finds the program in /proc
+func peerCredentials(conn net.Conn) (PeerCredentials, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return PeerCredentials{}, errNoPeerCredentials
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return PeerCredentials{}, err
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return PeerCredentials{}, err
+	}
+	if credErr != nil {
+		return PeerCredentials{}, fmt.Errorf("SO_PEERCRED: %w", credErr)
+	}
+
+	peer := PeerCredentials{UID: int(cred.Uid), PID: int(cred.Pid)}
+	peer.Exe, _ = os.Readlink(fmt.Sprintf("/proc/%d/exe", cred.Pid))
+	return peer, nil
+}
+
+// checkAllowedClient accepts every agent_allowed_clients entry: Linux
+// reports the path of a client's program
+func checkAllowedClient(allowed string) error {
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package utils
+
+import "net"
+
+// peerCredentials is not supported; clients can't be told apart
+func peerCredentials(conn net.Conn) (PeerCredentials, error) {
+	return PeerCredentials{}, errNoPeerCredentials
+}
+
+// checkAllowedClient accepts every agent_allowed_clients entry; the agent
+// refuses every client when there is a list
+func checkAllowedClient(allowed string) error {
+	return nil
+}
@@ -0,0 +1,66 @@
+package utils
+
+import "time"
+
+// DefaultRecentPasswords is how many generated passwords a session keeps
+const DefaultRecentPasswords = 20
+
+// RecentPassword is a password generated earlier in the session
+type RecentPassword struct {
+	Password    string
+	Type        string // Generator type, e.g. "random" or "pin"
+	HistoryID   string // History entry, if the password was saved
+	GeneratedAt time.Time
+}
+
+// RecentPasswords is an in-memory ring of the passwords generated this
+// session, so one overwritten by accident can be brought back. It is never
+// written to disk and works with history disabled.
+type RecentPasswords struct {
+	entries []RecentPassword
+	size    int
+}
+
+// NewRecentPasswords creates a ring keeping the last size passwords
+func NewRecentPasswords(size int) *RecentPasswords {
+	if size <= 0 {
+		size = DefaultRecentPasswords
+	}
+	return &RecentPasswords{size: size}
+}
+
+// Add records a generated password, dropping the oldest once the ring is full
+func (r *RecentPasswords) Add(password RecentPassword) {
+	if password.Password == "" {
+		return
+	}
+	if password.GeneratedAt.IsZero() {
+		password.GeneratedAt = time.Now()
+	}
+
+	r.entries = append(r.entries, password)
+	if len(r.entries) > r.size {
+		r.entries[0] = RecentPassword{}
+		r.entries = r.entries[1:]
+	}
+}
+
+// List returns the passwords of the given generator type, oldest first.
+// An empty type returns every password.
+func (r *RecentPasswords) List(genType string) []RecentPassword {
+	var list []RecentPassword
+	for _, entry := range r.entries {
+		if genType == "" || entry.Type == genType {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// Clear forgets every password
+func (r *RecentPasswords) Clear() {
+	for i := range r.entries {
+		r.entries[i] = RecentPassword{}
+	}
+	r.entries = nil
+}
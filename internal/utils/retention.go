@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// RetentionPolicy limits what the history keeps, beyond its maximum number
+// of entries. The zero value keeps everything.
+type RetentionPolicy struct {
+	Dedupe  bool          // A repeated password replaces its older entry
+	MaxAge  time.Duration // Entries older than this are purged; 0 = keep
+	MaxSize int           // Cap on the encrypted history file in bytes; 0 = none
+}
+
+// RetentionFromConfig returns the retention policy set in cfg
+func RetentionFromConfig(cfg *config.Config) RetentionPolicy {
+	return RetentionPolicy{
+		Dedupe:  cfg.HistoryDedupe,
+		MaxAge:  time.Duration(cfg.HistoryRetentionDays) * 24 * time.Hour,
+		MaxSize: cfg.HistoryMaxSizeKB * 1024,
+	}
+}
+
+// SetRetention sets the policy applied when entries are added
+func (h *HistoryManager) SetRetention(policy RetentionPolicy) {
+	h.retention = policy
+}
+
+// applyRetention enforces the retention policy and the maximum number of
+// entries on entries, whose first entry was just added and is always
+// kept. Dropping the oldest entries to fit the size cap goes last, so it
+// only removes what the other limits left. Dropping the oldest entries
+// keeps the hash chain intact; removing others relinks it.
+func (h *HistoryManager) applyRetention(entries []HistoryEntry, now time.Time) ([]HistoryEntry, error) {
+	count := len(entries)
+	known := chainHashes(entries)
+	if h.retention.Dedupe {
+		entries = dedupeEntries(entries)
+	}
+
+	if h.retention.MaxAge > 0 {
+		cutoff := now.Add(-h.retention.MaxAge)
+		kept := entries[:1]
+		for _, entry := range entries[1:] {
+			// The share revocation checklist needs entries still shared
+			if entry.CreatedAt.After(cutoff) || entry.IsShared() {
+				kept = append(kept, entry)
+			}
+		}
+		entries = kept
+	}
+
+	// Entries removed from the middle break the hash chain
+	if len(entries) != count {
+		relinkEntries(entries, known)
+	}
+
+	if len(entries) > h.maxEntries {
+		entries = entries[:h.maxEntries]
+	}
+
+	if h.retention.MaxSize > 0 {
+		return h.trimToSize(entries)
+	}
+	return entries, nil
+}
+
+// dedupeEntries removes the older entries with the same password as the
+// new first entry, carrying their usage audit trail, shares and tags over
+// to it. Linked entries are kept: they reuse the password on purpose.
+func dedupeEntries(entries []HistoryEntry) []HistoryEntry {
+	newest := entries[0]
+	if newest.Linked() {
+		return entries
+	}
+
+	var older []HistoryEntry
+	for _, entry := range entries[1:] {
+		if entry.Password != newest.Password || entry.Linked() || len(LinkedEntries(entries, entry.ID)) > 0 {
+			older = append(older, entry)
+			continue
+		}
+
+		newest.CopyCount += entry.CopyCount
+		newest.LastCopiedAt = latest(newest.LastCopiedAt, entry.LastCopiedAt)
+		newest.RevealCount += entry.RevealCount
+		newest.LastRevealedAt = latest(newest.LastRevealedAt, entry.LastRevealedAt)
+		newest.Shares = append(newest.Shares, entry.Shares...)
+		newest.Tags = mergeTags(newest.Tags, entry.Tags)
+		if newest.Description == "" {
+			newest.Description = entry.Description
+		}
+		if newest.Site == "" {
+			newest.Site = entry.Site
+		}
+		if newest.Username == "" {
+			newest.Username = entry.Username
+		}
+	}
+	return append([]HistoryEntry{newest}, older...)
+}
+
+// latest returns the later of two optional times
+func latest(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
+
+// trimToSize drops the oldest entries until the encrypted history fits
+// the size cap. The newest entry is kept even if it alone exceeds it.
+func (h *HistoryManager) trimToSize(entries []HistoryEntry) ([]HistoryEntry, error) {
+	overhead := len(defaultKDFParams(h.kdf).header()) + 16 + 12 + 16 // Salt, nonce and GCM tag
+
+	var sizeErr error
+	fits := func(n int) bool {
+		data, err := json.MarshalIndent(entries[:n], "", "  ")
+		if err != nil {
+			sizeErr = err
+			return true
+		}
+		return overhead+len(data) <= h.retention.MaxSize
+	}
+
+	// The first count that doesn't fit; every smaller count does
+	n := sort.Search(len(entries)+1, func(n int) bool { return n > 0 && !fits(n) })
+	if sizeErr != nil {
+		return nil, fmt.Errorf("failed to marshal history data: %w", sizeErr)
+	}
+	if n <= 1 {
+		return entries[:1], nil
+	}
+	return entries[:n-1], nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import "sort"
+
+// ReuseGroup is a password shared by several history entries, newest
+// entry first
+type ReuseGroup struct {
+	Password string
+	Entries  []HistoryEntry
+}
+
+// Unacknowledged counts the entries of the group that are not linked to
+// another entry. Linking records that a password is reused on purpose;
+// a group is fully acknowledged when only its primary is unlinked.
+func (g ReuseGroup) Unacknowledged() int {
+	n := 0
+	for _, entry := range g.Entries {
+		if !entry.Linked() {
+			n++
+		}
+	}
+	return n
+}
+
+// FindReuse groups the entries that share a password. Only passwords used
+// by at least two entries are returned, the most reused first, and
+// unacknowledged reuse before reuse recorded with links.
+func FindReuse(entries []HistoryEntry) []ReuseGroup {
+	index := make(map[string]int)
+	var groups []ReuseGroup
+	for _, entry := range entries {
+		if entry.Password == "" {
+			continue
+		}
+		i, seen := index[entry.Password]
+		if !seen {
+			i = len(groups)
+			index[entry.Password] = i
+			groups = append(groups, ReuseGroup{Password: entry.Password})
+		}
+		groups[i].Entries = append(groups[i].Entries, entry)
+	}
+
+	var reused []ReuseGroup
+	for _, group := range groups {
+		if len(group.Entries) > 1 {
+			sort.SliceStable(group.Entries, func(i, j int) bool {
+				return group.Entries[i].CreatedAt.After(group.Entries[j].CreatedAt)
+			})
+			reused = append(reused, group)
+		}
+	}
+	sort.SliceStable(reused, func(i, j int) bool {
+		a, b := reused[i], reused[j]
+		if (a.Unacknowledged() > 1) != (b.Unacknowledged() > 1) {
+			return a.Unacknowledged() > 1
+		}
+		return len(a.Entries) > len(b.Entries)
+	})
+	return reused
+}
+
+// ReusedEntryCount returns how many entries use a password shared with
+// another entry
+func ReusedEntryCount(groups []ReuseGroup) int {
+	n := 0
+	for _, group := range groups {
+		n += len(group.Entries)
+	}
+	return n
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Superseded reports whether a newer entry replaced this entry's password
+func (e HistoryEntry) Superseded() bool {
+	return e.SupersededBy != ""
+}
+
+// FindPredecessor returns the newest current entry that a new password in
+// entry would replace: one for the same site and username or, when entry
+// has no site, one with the same description. Only older entries count,
+// and those already superseded are skipped, so a rotation always
+// continues the end of a chain.
+func FindPredecessor(entries []HistoryEntry, entry HistoryEntry) (HistoryEntry, bool) {
+	site := strings.TrimSpace(entry.Site)
+	description := strings.TrimSpace(entry.Description)
+	if site == "" && description == "" {
+		return HistoryEntry{}, false
+	}
+
+	for _, other := range entries {
+		if other.ID == entry.ID || other.Superseded() || other.CreatedAt.After(entry.CreatedAt) {
+			continue
+		}
+		if site != "" {
+			if strings.EqualFold(other.Site, site) && strings.EqualFold(other.Username, entry.Username) {
+				return other, true
+			}
+			continue
+		}
+		if other.Site == "" && strings.EqualFold(other.Description, description) {
+			return other, true
+		}
+	}
+	return HistoryEntry{}, false
+}
+
+// RotateEntry records that the entry newID replaces the password of the
+// entry oldID: the old entry is marked superseded and entries linked to it
+// are flagged for updating, as by MarkRotated. It returns how many linked
+// entries were flagged.
+func (h *HistoryManager) RotateEntry(newID, oldID string) (int, error) {
+	if newID == "" || oldID == "" {
+		return 0, fmt.Errorf("entry ID cannot be empty")
+	}
+	if newID == oldID {
+		return 0, fmt.Errorf("an entry cannot replace itself")
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return 0, err
+	}
+
+	newIndex, oldIndex := -1, -1
+	for i := range entries {
+		switch entries[i].ID {
+		case newID:
+			newIndex = i
+		case oldID:
+			oldIndex = i
+		}
+	}
+	if newIndex < 0 {
+		return 0, fmt.Errorf("history entry %s not found", newID)
+	}
+	if oldIndex < 0 {
+		return 0, fmt.Errorf("history entry %s not found", oldID)
+	}
+	if entries[oldIndex].Superseded() {
+		return 0, fmt.Errorf("%q was already replaced", entries[oldIndex].Label())
+	}
+
+	now := time.Now()
+	entries[oldIndex].SupersededBy = newID
+	entries[oldIndex].SupersededAt = &now
+	entries[newIndex].Replaces = oldID
+
+	flagged := 0
+	for i := range entries {
+		if entries[i].LinkedTo == oldID {
+			entries[i].PrimaryRotatedAt = &now
+			flagged++
+		}
+	}
+	return flagged, h.saveHistory(entries)
+}
+
+// RotationChain returns the entries that replaced one another's password
+// through the entry with the given ID, oldest first. An entry that was
+// never rotated is a chain of one.
+func RotationChain(entries []HistoryEntry, id string) []HistoryEntry {
+	byID := make(map[string]HistoryEntry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+	entry, ok := byID[id]
+	if !ok {
+		return nil
+	}
+
+	// Seen guards against loops written by hand or by a bad merge
+	seen := map[string]bool{id: true}
+	chain := []HistoryEntry{entry}
+	for previous, ok := byID[entry.Replaces]; ok && !seen[previous.ID]; previous, ok = byID[previous.Replaces] {
+		seen[previous.ID] = true
+		chain = append([]HistoryEntry{previous}, chain...)
+	}
+	for next, ok := byID[entry.SupersededBy]; ok && !seen[next.ID]; next, ok = byID[next.SupersededBy] {
+		seen[next.ID] = true
+		chain = append(chain, next)
+	}
+	return chain
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Scratchpad is a single encrypted text buffer for temporarily parking
+// secrets. It lives next to the history and uses the same key.
+type Scratchpad struct {
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsExpired reports whether the scratchpad is older than clearAfter.
+// A zero clearAfter never expires.
+func (s Scratchpad) IsExpired(clearAfter time.Duration) bool {
+	return clearAfter > 0 && s.Text != "" && time.Since(s.UpdatedAt) >= clearAfter
+}
+
+// LoadScratchpad loads and decrypts the scratchpad. An expired scratchpad
+// is cleared and returned empty.
+func (h *HistoryManager) LoadScratchpad(clearAfter time.Duration) (Scratchpad, error) {
+	if err := h.requirePassphrase(); err != nil {
+		return Scratchpad{}, err
+	}
+
+	scratchpadPath, err := h.getScratchpadPath()
+	if err != nil {
+		return Scratchpad{}, err
+	}
+
+	encryptedData, err := os.ReadFile(scratchpadPath)
+	if os.IsNotExist(err) {
+		return Scratchpad{}, nil
+	}
+	if err != nil {
+		return Scratchpad{}, fmt.Errorf("failed to read scratchpad: %w", err)
+	}
+
+	data, err := h.decrypt(encryptedData)
+	if err != nil {
+		return Scratchpad{}, fmt.Errorf("failed to decrypt scratchpad: %w", err)
+	}
+
+	var pad Scratchpad
+	if err := json.Unmarshal(data, &pad); err != nil {
+		return Scratchpad{}, fmt.Errorf("failed to parse scratchpad: %w", err)
+	}
+
+	if pad.IsExpired(clearAfter) {
+		return Scratchpad{}, h.ClearScratchpad()
+	}
+
+	return pad, nil
+}
+
+// SaveScratchpad encrypts and saves the scratchpad text. Saving empty text
+// removes the file.
+func (h *HistoryManager) SaveScratchpad(text string) (Scratchpad, error) {
+	if err := h.requirePassphrase(); err != nil {
+		return Scratchpad{}, err
+	}
+
+	if text == "" {
+		return Scratchpad{}, h.ClearScratchpad()
+	}
+
+	scratchpadPath, err := h.getScratchpadPath()
+	if err != nil {
+		return Scratchpad{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(scratchpadPath), 0700); err != nil {
+		return Scratchpad{}, fmt.Errorf("failed to create scratchpad directory: %w", err)
+	}
+
+	pad := Scratchpad{Text: text, UpdatedAt: time.Now()}
+	data, err := json.Marshal(pad)
+	if err != nil {
+		return Scratchpad{}, fmt.Errorf("failed to marshal scratchpad: %w", err)
+	}
+
+	encryptedData, err := h.encrypt(data)
+	if err != nil {
+		return Scratchpad{}, fmt.Errorf("failed to encrypt scratchpad: %w", err)
+	}
+
+	if err := os.WriteFile(scratchpadPath, encryptedData, 0600); err != nil {
+		return Scratchpad{}, fmt.Errorf("failed to write scratchpad: %w", err)
+	}
+
+	return pad, nil
+}
+
+// ClearScratchpad removes the scratchpad
+func (h *HistoryManager) ClearScratchpad() error {
+	scratchpadPath, err := h.getScratchpadPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(scratchpadPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove scratchpad: %w", err)
+	}
+
+	return nil
+}
+
+// getScratchpadPath returns the path to the scratchpad file
+func (h *HistoryManager) getScratchpadPath() (string, error) {
+	historyPath, err := h.getHistoryPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(historyPath), "scratchpad.enc"), nil
+}
@@ -0,0 +1,693 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// SecretServiceTag is the tag marking the history entries the Secret
+// Service provider exposes, unless told to expose them all
+const SecretServiceTag = "secret-service"
+
+// Names and object paths of the freedesktop.org Secret Service API
+const (
+	secretServiceName    = "org.freedesktop.secrets"
+	secretServicePath    = dbusObjectPath("/org/freedesktop/secrets")
+	secretCollectionPath = secretServicePath + "/collection/passman"
+	secretDefaultAlias   = secretServicePath + "/aliases/default"
+	secretSessionPrefix  = secretServicePath + "/session/"
+
+	ifaceSecretService    = "org.freedesktop.Secret.Service"
+	ifaceSecretCollection = "org.freedesktop.Secret.Collection"
+	ifaceSecretItem       = "org.freedesktop.Secret.Item"
+	ifaceSecretSession    = "org.freedesktop.Secret.Session"
+	ifaceProperties       = "org.freedesktop.DBus.Properties"
+	ifaceIntrospectable   = "org.freedesktop.DBus.Introspectable"
+	ifacePeer             = "org.freedesktop.DBus.Peer"
+)
+
+// Session algorithms: secrets in the clear, which is what gnome-keyring
+// also offers on the local bus, or encrypted with a key agreed by
+// Diffie-Hellman, which libsecret tries first
+const (
+	secretAlgorithmPlain = "plain"
+	secretAlgorithmDH    = "dh-ietf1024-sha256-aes128-cbc-pkcs7"
+)
+
+// maxSecretSessions limits the sessions open at once; those of clients
+// that leave the bus are closed for them
+const maxSecretSessions = 256
+
+// RequestName flags and results
+const (
+	dbusNameAllowReplacement = 0x1
+	dbusNameReplaceExisting  = 0x2
+	dbusNameDoNotQueue       = 0x4
+
+	dbusNamePrimaryOwner = 1
+	dbusNameAlreadyOwner = 4
+)
+
+// secretDHPrime is the 1024-bit MODP group of RFC 2409, section 6.2,
+// with generator 2
+var secretDHPrime, _ = new(big.Int).SetString(
+	"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DD"+
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE65381FFFFFFFFFFFFFFFF", 16)
+
+// secretSession is a session opened by a client; secrets it fetches are
+// encrypted with key, or sent in the clear without one
+type secretSession struct {
+	owner string // Unique bus name of the client
+	key   []byte
+}
+
+// SecretService exposes history entries on the session bus through the
+// freedesktop.org Secret Service API (org.freedesktop.secrets), so
+// applications using libsecret can look them up instead of in
+// gnome-keyring. The entries form one read-only, always unlocked
+// collection, also the default alias; each is an item with the
+// attributes id, site, username and type, and its password as the
+// secret. Only entries with the tag given are exposed, all of them with
+// an empty tag; entries replaced by a rotation never are.
+type SecretService struct {
+	agent *Agent
+	tag   string
+
+	conn        *dbusConn
+	sessions    map[dbusObjectPath]*secretSession
+	nextSession int
+	items       map[dbusObjectPath]HistoryEntry
+	itemPaths   []dbusObjectPath // Newest first
+	onConnected func(items int)
+}
+
+// NewSecretService creates a provider serving the entries of agent's
+// history tagged tag, or all of them if tag is empty
+func NewSecretService(agent *Agent, tag string) *SecretService {
+	return &SecretService{agent: agent, tag: tag, sessions: make(map[dbusObjectPath]*secretSession)}
+}
+
+// OnConnected sets a function called with the number of items exposed
+// once the provider owns its bus name
+func (s *SecretService) OnConnected(fn func(items int)) {
+	s.onConnected = fn
+}
+
+// Serve connects to the session bus, takes the name
+// org.freedesktop.secrets and answers calls until ctx is cancelled.
+// Another provider owning the name, e.g. gnome-keyring, is an error
+// unless replace is set and it allows being replaced; passman allows it
+// in turn.
+func (s *SecretService) Serve(ctx context.Context, replace bool) error {
+	conn, err := dialSessionBus()
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// Close the sessions of clients leaving the bus
+	rule := "type='signal',sender='org.freedesktop.DBus',path='/org/freedesktop/DBus',interface='org.freedesktop.DBus',member='NameOwnerChanged'"
+	if _, err := conn.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", rule); err != nil {
+		return err
+	}
+
+	flags := uint32(dbusNameAllowReplacement | dbusNameDoNotQueue)
+	if replace {
+		flags |= dbusNameReplaceExisting
+	}
+	reply, err := conn.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "RequestName", "su", secretServiceName, flags)
+	if err != nil {
+		return err
+	}
+	if result, _ := reply[0].(uint32); result != dbusNamePrimaryOwner && result != dbusNameAlreadyOwner {
+		return fmt.Errorf("another Secret Service provider, e.g. gnome-keyring or KeePassXC, owns %s; stop it first", secretServiceName)
+	}
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	if s.onConnected != nil {
+		s.onConnected(len(s.items))
+	}
+
+	for {
+		m, err := conn.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		switch m.Type {
+		case dbusSignal:
+			if m.Member == "NameOwnerChanged" && m.Signature == "sss" && m.Body[2] == "" {
+				s.closeSessionsOf(m.Body[0].(string))
+			}
+			if m.Member == "NameLost" && m.Signature == "s" && m.Body[0] == secretServiceName {
+				return fmt.Errorf("another Secret Service provider replaced passman on the bus")
+			}
+		case dbusMethodCall:
+			if err := s.reply(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reply answers a method call, unless the caller asked for no reply
+func (s *SecretService) reply(m *dbusMessage) error {
+	sig, body, err := s.handle(m)
+	if m.Flags&dbusNoReplyExpected != 0 {
+		return nil
+	}
+	resp := &dbusMessage{Type: dbusMethodReturn, Destination: m.Sender, ReplySerial: m.Serial, Signature: sig, Body: body}
+	if err != nil {
+		var callErr *dbusCallError
+		if !errors.As(err, &callErr) {
+			callErr = &dbusCallError{Name: "org.freedesktop.DBus.Error.Failed", Message: err.Error()}
+		}
+		resp = &dbusMessage{
+			Type: dbusError, Destination: m.Sender, ReplySerial: m.Serial, ErrorName: callErr.Name,
+			Signature: "s", Body: []interface{}{callErr.Message},
+		}
+	}
+	return s.conn.send(resp)
+}
+
+// Errors answered to calls
+func errUnknownMethod(m *dbusMessage) error {
+	return &dbusCallError{"org.freedesktop.DBus.Error.UnknownMethod", fmt.Sprintf("no method %s.%s(%s) on %s", m.Interface, m.Member, m.Signature, m.Path)}
+}
+
+func errNotSupported(what string) error {
+	return &dbusCallError{"org.freedesktop.DBus.Error.NotSupported", what + ": passman's Secret Service is read-only; save secrets in passman"}
+}
+
+func errNoSuchObject(path dbusObjectPath) error {
+	return &dbusCallError{"org.freedesktop.Secret.Error.NoSuchObject", fmt.Sprintf("no such object %s", path)}
+}
+
+// handle runs a method call and returns the signature and arguments of
+// the reply
+func (s *SecretService) handle(m *dbusMessage) (string, []interface{}, error) {
+	if err := s.refresh(); err != nil {
+		return "", nil, err
+	}
+	ifaces := s.interfaces(m.Path)
+	if ifaces == nil {
+		return "", nil, &dbusCallError{"org.freedesktop.DBus.Error.UnknownObject", fmt.Sprintf("no object %s", m.Path)}
+	}
+	iface := m.Interface
+	if iface == "" {
+		iface = s.findInterface(ifaces, m.Member)
+	}
+	if !containsString(ifaces, iface) {
+		return "", nil, errUnknownMethod(m)
+	}
+	call := iface + "." + m.Member + "(" + m.Signature + ")"
+
+	switch call {
+	case ifaceIntrospectable + ".Introspect()":
+		return "s", []interface{}{s.introspect(m.Path, ifaces)}, nil
+	case ifacePeer + ".Ping()":
+		return "", nil, nil
+
+	case ifaceProperties + ".Get(ss)":
+		props, err := s.properties(m.Path, m.Body[0].(string))
+		if err != nil {
+			return "", nil, err
+		}
+		value, ok := props[m.Body[1].(string)]
+		if !ok {
+			return "", nil, &dbusCallError{"org.freedesktop.DBus.Error.UnknownProperty", fmt.Sprintf("no property %s", m.Body[1])}
+		}
+		return "v", []interface{}{value}, nil
+	case ifaceProperties + ".GetAll(s)":
+		props, err := s.properties(m.Path, m.Body[0].(string))
+		if err != nil {
+			return "", nil, err
+		}
+		return "a{sv}", []interface{}{props}, nil
+	case ifaceProperties + ".Set(ssv)":
+		return "", nil, &dbusCallError{"org.freedesktop.DBus.Error.PropertyReadOnly", "passman's Secret Service is read-only"}
+
+	case ifaceSecretService + ".OpenSession(sv)":
+		return s.openSession(m.Sender, m.Body[0].(string), m.Body[1].(dbusVariant))
+	case ifaceSecretService + ".SearchItems(a{ss})", ifaceSecretCollection + ".SearchItems(a{ss})":
+		paths := s.search(stringMap(m.Body[0]))
+		if iface == ifaceSecretCollection {
+			return "ao", []interface{}{paths}, nil
+		}
+		return "aoao", []interface{}{paths, []dbusObjectPath{}}, nil
+	case ifaceSecretService + ".Unlock(ao)":
+		// Everything is unlocked already
+		var unlocked []dbusObjectPath
+		for _, path := range objectPaths(m.Body[0]) {
+			if s.interfaces(path) != nil {
+				unlocked = append(unlocked, path)
+			}
+		}
+		return "aoo", []interface{}{unlocked, dbusObjectPath("/")}, nil
+	case ifaceSecretService + ".Lock(ao)":
+		return "aoo", []interface{}{[]dbusObjectPath{}, dbusObjectPath("/")}, nil
+	case ifaceSecretService + ".GetSecrets(aoo)":
+		secrets := []interface{}{}
+		for _, path := range objectPaths(m.Body[0]) {
+			if _, ok := s.items[path]; !ok {
+				continue
+			}
+			secret, err := s.secret(m.Sender, m.Body[1].(dbusObjectPath), path)
+			if err != nil {
+				return "", nil, err
+			}
+			secrets = append(secrets, []interface{}{path, secret})
+		}
+		return "a{o(oayays)}", []interface{}{secrets}, nil
+	case ifaceSecretService + ".ReadAlias(s)":
+		if m.Body[0] == "default" {
+			return "o", []interface{}{secretCollectionPath}, nil
+		}
+		return "o", []interface{}{dbusObjectPath("/")}, nil
+	case ifaceSecretService + ".SetAlias(so)":
+		return "", nil, errNotSupported("cannot set an alias")
+	case ifaceSecretService + ".CreateCollection(a{sv}s)":
+		return "", nil, errNotSupported("cannot create a collection")
+
+	case ifaceSecretCollection + ".CreateItem(a{sv}(oayays)b)":
+		return "", nil, errNotSupported("cannot create an item")
+	case ifaceSecretCollection + ".Delete()":
+		return "", nil, errNotSupported("cannot delete the collection")
+
+	case ifaceSecretItem + ".GetSecret(o)":
+		secret, err := s.secret(m.Sender, m.Body[0].(dbusObjectPath), m.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(oayays)", []interface{}{secret}, nil
+	case ifaceSecretItem + ".SetSecret((oayays))":
+		return "", nil, errNotSupported("cannot change a secret")
+	case ifaceSecretItem + ".Delete()":
+		return "", nil, errNotSupported("cannot delete an item")
+
+	case ifaceSecretSession + ".Close()":
+		if session := s.sessions[m.Path]; session != nil && session.owner == m.Sender {
+			delete(s.sessions, m.Path)
+		}
+		return "", nil, nil
+	}
+	return "", nil, errUnknownMethod(m)
+}
+
+// refresh rereads the exposed entries if the history changed
+func (s *SecretService) refresh() error {
+	entries, err := s.agent.Entries()
+	if err != nil {
+		return fmt.Errorf("cannot read the history: %w", err)
+	}
+	s.items = make(map[dbusObjectPath]HistoryEntry)
+	s.itemPaths = s.itemPaths[:0]
+	for _, entry := range entries {
+		if entry.SupersededBy != "" || entry.Password == "" || (s.tag != "" && !entry.HasTag(s.tag)) {
+			continue
+		}
+		path := secretCollectionPath + "/" + dbusObjectPath(dbusPathElement(entry.ID))
+		if _, dup := s.items[path]; dup {
+			continue
+		}
+		s.items[path] = entry
+		s.itemPaths = append(s.itemPaths, path)
+	}
+	return nil
+}
+
+// dbusPathElement turns id into an object path element, which may only
+// hold ASCII letters, digits and underscores: an underscore is doubled
+// and other bytes become _ and two hex digits, so IDs never collide
+func dbusPathElement(id string) string {
+	var b strings.Builder
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteByte(c)
+		case c == '_':
+			b.WriteString("__")
+		default:
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// interfaces returns the interfaces of the object at path, or nil if
+// there is none
+func (s *SecretService) interfaces(path dbusObjectPath) []string {
+	common := []string{ifaceProperties, ifaceIntrospectable, ifacePeer}
+	switch {
+	case path == secretServicePath:
+		return append([]string{ifaceSecretService}, common...)
+	case path == secretCollectionPath || path == secretDefaultAlias:
+		return append([]string{ifaceSecretCollection}, common...)
+	case s.sessions[path] != nil:
+		return []string{ifaceSecretSession, ifaceIntrospectable, ifacePeer}
+	}
+	if _, ok := s.items[path]; ok {
+		return append([]string{ifaceSecretItem}, common...)
+	}
+	switch path {
+	case "/", "/org", "/org/freedesktop", secretServicePath + "/collection", secretServicePath + "/aliases":
+		return []string{ifaceIntrospectable, ifacePeer}
+	}
+	return nil
+}
+
+// findInterface returns the interface of ifaces with a method called
+// member, for calls that name no interface
+func (s *SecretService) findInterface(ifaces []string, member string) string {
+	for _, iface := range ifaces {
+		if strings.Contains(secretInterfaceXML[iface], `<method name="`+member+`">`) {
+			return iface
+		}
+	}
+	return ""
+}
+
+// properties returns the properties of iface on the object at path
+func (s *SecretService) properties(path dbusObjectPath, iface string) (map[string]dbusVariant, error) {
+	ifaces := s.interfaces(path)
+	if !containsString(ifaces, iface) {
+		return nil, &dbusCallError{"org.freedesktop.DBus.Error.UnknownInterface", fmt.Sprintf("no interface %s on %s", iface, path)}
+	}
+	switch iface {
+	case ifaceSecretService:
+		return map[string]dbusVariant{"Collections": {"ao", []dbusObjectPath{secretCollectionPath}}}, nil
+	case ifaceSecretCollection:
+		var created, modified time.Time
+		for _, entry := range s.items {
+			if created.IsZero() || entry.CreatedAt.Before(created) {
+				created = entry.CreatedAt
+			}
+			if changed := entryModified(entry); changed.After(modified) {
+				modified = changed
+			}
+		}
+		return map[string]dbusVariant{
+			"Items":    {"ao", append([]dbusObjectPath{}, s.itemPaths...)},
+			"Label":    {"s", "passman"},
+			"Locked":   {"b", false},
+			"Created":  {"t", unixSeconds(created)},
+			"Modified": {"t", unixSeconds(modified)},
+		}, nil
+	case ifaceSecretItem:
+		entry := s.items[path]
+		return map[string]dbusVariant{
+			"Attributes": {"a{ss}", secretAttributes(entry)},
+			"Label":      {"s", entryLabel(entry)},
+			"Locked":     {"b", false},
+			"Type":       {"s", "org.freedesktop.Secret.Generic"},
+			"Created":    {"t", unixSeconds(entry.CreatedAt)},
+			"Modified":   {"t", unixSeconds(entryModified(entry))},
+		}, nil
+	}
+	return map[string]dbusVariant{}, nil
+}
+
+// secretAttributes are the attributes an entry is searched by
+func secretAttributes(entry HistoryEntry) map[string]string {
+	attrs := map[string]string{"id": entry.ID, "xdg:schema": "org.freedesktop.Secret.Generic"}
+	if entry.Type != "" {
+		attrs["type"] = entry.Type
+	}
+	if entry.Site != "" {
+		attrs["site"] = entry.Site
+	}
+	if entry.Username != "" {
+		attrs["username"] = entry.Username
+	}
+	return attrs
+}
+
+// entryLabel names an entry for other applications
+func entryLabel(entry HistoryEntry) string {
+	if entry.Description != "" {
+		return entry.Description
+	}
+	if entry.Site != "" {
+		return entry.Site
+	}
+	return entry.ID
+}
+
+// entryModified returns when an entry was last edited
+func entryModified(entry HistoryEntry) time.Time {
+	modified := entry.CreatedAt
+	for _, t := range []*time.Time{entry.EditedAt, entry.TaggedAt} {
+		if t != nil && t.After(modified) {
+			modified = *t
+		}
+	}
+	return modified
+}
+
+// unixSeconds returns t in seconds since the epoch, 0 for the zero time
+func unixSeconds(t time.Time) uint64 {
+	if t.IsZero() || t.Unix() < 0 {
+		return 0
+	}
+	return uint64(t.Unix())
+}
+
+// search returns the items having every one of attrs, newest first
+func (s *SecretService) search(attrs map[string]string) []dbusObjectPath {
+	matches := []dbusObjectPath{}
+	for _, path := range s.itemPaths {
+		itemAttrs := secretAttributes(s.items[path])
+		match := true
+		for key, value := range attrs {
+			if itemAttrs[key] != value {
+				match = false
+				break
+			}
+		}
+		if match {
+			matches = append(matches, path)
+		}
+	}
+	return matches
+}
+
+// openSession starts a session for sender with the given algorithm,
+// returning the server's half of the key agreement and the session path
+func (s *SecretService) openSession(sender, algorithm string, input dbusVariant) (string, []interface{}, error) {
+	if len(s.sessions) >= maxSecretSessions {
+		return "", nil, &dbusCallError{"org.freedesktop.DBus.Error.LimitsExceeded", "too many open sessions"}
+	}
+
+	session := &secretSession{owner: sender}
+	output := dbusVariant{Sig: "s", Value: ""}
+	switch algorithm {
+	case secretAlgorithmPlain:
+	case secretAlgorithmDH:
+		peer, ok := input.Value.([]byte)
+		if !ok {
+			return "", nil, &dbusCallError{"org.freedesktop.DBus.Error.InvalidArgs", "the input of " + secretAlgorithmDH + " must be a byte array"}
+		}
+		public, key, err := secretKeyAgreement(peer)
+		if err != nil {
+			return "", nil, &dbusCallError{"org.freedesktop.DBus.Error.InvalidArgs", err.Error()}
+		}
+		session.key = key
+		output = dbusVariant{Sig: "ay", Value: public}
+	default:
+		return "", nil, &dbusCallError{"org.freedesktop.DBus.Error.NotSupported", fmt.Sprintf("unsupported algorithm %q (use %s or %s)", algorithm, secretAlgorithmPlain, secretAlgorithmDH)}
+	}
+
+	s.nextSession++
+	path := secretSessionPrefix + dbusObjectPath(fmt.Sprint(s.nextSession))
+	s.sessions[path] = session
+	return "vo", []interface{}{output, path}, nil
+}
+
+// secretKeyAgreement completes a Diffie-Hellman exchange with the
+// client's public key peer, returning the server's public key and the
+// AES-128 key derived with HKDF-SHA256, as libsecret does
+func secretKeyAgreement(peer []byte) ([]byte, []byte, error) {
+	y := new(big.Int).SetBytes(peer)
+	pMinus1 := new(big.Int).Sub(secretDHPrime, big.NewInt(1))
+	if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(pMinus1) >= 0 {
+		return nil, nil, fmt.Errorf("invalid public key")
+	}
+
+	size := (secretDHPrime.BitLen() + 7) / 8
+	privateBytes := make([]byte, size)
+	if _, err := rand.Read(privateBytes); err != nil {
+		return nil, nil, err
+	}
+	private := new(big.Int).SetBytes(privateBytes)
+	public := new(big.Int).Exp(big.NewInt(2), private, secretDHPrime)
+	shared := new(big.Int).Exp(y, private, secretDHPrime)
+
+	key := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared.FillBytes(make([]byte, size)), nil, nil), key); err != nil {
+		return nil, nil, err
+	}
+	return public.FillBytes(make([]byte, size)), key, nil
+}
+
+// secret returns the secret of the item at path for the session, as a
+// Secret struct (session, parameters, value, content type), and records
+// the reveal in the entry's audit trail
+func (s *SecretService) secret(sender string, sessionPath, path dbusObjectPath) ([]interface{}, error) {
+	session := s.sessions[sessionPath]
+	if session == nil || session.owner != sender {
+		return nil, &dbusCallError{"org.freedesktop.Secret.Error.NoSession", fmt.Sprintf("no session %s", sessionPath)}
+	}
+	entry, ok := s.items[path]
+	if !ok {
+		return nil, errNoSuchObject(path)
+	}
+
+	params, value := []byte{}, []byte(entry.Password)
+	if session.key != nil {
+		block, err := aes.NewCipher(session.key)
+		if err != nil {
+			return nil, err
+		}
+		params = make([]byte, aes.BlockSize)
+		if _, err := rand.Read(params); err != nil {
+			return nil, err
+		}
+		pad := aes.BlockSize - len(value)%aes.BlockSize
+		padded := append(append([]byte{}, value...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+		value = make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, params).CryptBlocks(value, padded)
+	}
+
+	_ = s.agent.recordReveal(entry.ID)
+	return []interface{}{sessionPath, params, value, "text/plain; charset=utf8"}, nil
+}
+
+// closeSessionsOf closes the sessions of a client that left the bus
+func (s *SecretService) closeSessionsOf(owner string) {
+	for path, session := range s.sessions {
+		if session.owner == owner {
+			delete(s.sessions, path)
+		}
+	}
+}
+
+// introspect describes the object at path and its children
+func (s *SecretService) introspect(path dbusObjectPath, ifaces []string) string {
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE node PUBLIC "-//freedesktop//DTD D-BUS Object Introspection 1.0//EN" "http://www.freedesktop.org/standards/dbus/1.0/introspect.dtd">` + "\n<node>\n")
+	for _, iface := range ifaces {
+		fmt.Fprintf(&b, " <interface name=%q>%s</interface>\n", iface, secretInterfaceXML[iface])
+	}
+	var children []string
+	switch path {
+	case "/":
+		children = []string{"org"}
+	case "/org":
+		children = []string{"freedesktop"}
+	case "/org/freedesktop":
+		children = []string{"secrets"}
+	case secretServicePath:
+		children = []string{"collection", "aliases"}
+	case secretServicePath + "/collection":
+		children = []string{"passman"}
+	case secretServicePath + "/aliases":
+		children = []string{"default"}
+	case secretCollectionPath:
+		for _, item := range s.itemPaths {
+			children = append(children, string(item[len(secretCollectionPath)+1:]))
+		}
+	}
+	for _, child := range children {
+		fmt.Fprintf(&b, " <node name=%q/>\n", child)
+	}
+	b.WriteString("</node>\n")
+	return b.String()
+}
+
+// secretInterfaceXML holds the introspection data of each interface
+var secretInterfaceXML = map[string]string{
+	ifaceSecretService: `<method name="OpenSession"><arg name="algorithm" type="s" direction="in"/><arg name="input" type="v" direction="in"/><arg name="output" type="v" direction="out"/><arg name="result" type="o" direction="out"/></method>` +
+		`<method name="CreateCollection"><arg name="properties" type="a{sv}" direction="in"/><arg name="alias" type="s" direction="in"/><arg name="collection" type="o" direction="out"/><arg name="prompt" type="o" direction="out"/></method>` +
+		`<method name="SearchItems"><arg name="attributes" type="a{ss}" direction="in"/><arg name="unlocked" type="ao" direction="out"/><arg name="locked" type="ao" direction="out"/></method>` +
+		`<method name="Unlock"><arg name="objects" type="ao" direction="in"/><arg name="unlocked" type="ao" direction="out"/><arg name="prompt" type="o" direction="out"/></method>` +
+		`<method name="Lock"><arg name="objects" type="ao" direction="in"/><arg name="locked" type="ao" direction="out"/><arg name="Prompt" type="o" direction="out"/></method>` +
+		`<method name="GetSecrets"><arg name="items" type="ao" direction="in"/><arg name="session" type="o" direction="in"/><arg name="secrets" type="a{o(oayays)}" direction="out"/></method>` +
+		`<method name="ReadAlias"><arg name="name" type="s" direction="in"/><arg name="collection" type="o" direction="out"/></method>` +
+		`<method name="SetAlias"><arg name="name" type="s" direction="in"/><arg name="collection" type="o" direction="in"/></method>` +
+		`<property name="Collections" type="ao" access="read"/>`,
+	ifaceSecretCollection: `<method name="Delete"><arg name="prompt" type="o" direction="out"/></method>` +
+		`<method name="SearchItems"><arg name="attributes" type="a{ss}" direction="in"/><arg name="results" type="ao" direction="out"/></method>` +
+		`<method name="CreateItem"><arg name="properties" type="a{sv}" direction="in"/><arg name="secret" type="(oayays)" direction="in"/><arg name="replace" type="b" direction="in"/><arg name="item" type="o" direction="out"/><arg name="prompt" type="o" direction="out"/></method>` +
+		`<property name="Items" type="ao" access="read"/><property name="Label" type="s" access="read"/><property name="Locked" type="b" access="read"/><property name="Created" type="t" access="read"/><property name="Modified" type="t" access="read"/>`,
+	ifaceSecretItem: `<method name="Delete"><arg name="Prompt" type="o" direction="out"/></method>` +
+		`<method name="GetSecret"><arg name="session" type="o" direction="in"/><arg name="secret" type="(oayays)" direction="out"/></method>` +
+		`<method name="SetSecret"><arg name="secret" type="(oayays)" direction="in"/></method>` +
+		`<property name="Locked" type="b" access="read"/><property name="Attributes" type="a{ss}" access="read"/><property name="Label" type="s" access="read"/><property name="Type" type="s" access="read"/><property name="Created" type="t" access="read"/><property name="Modified" type="t" access="read"/>`,
+	ifaceSecretSession: `<method name="Close"></method>`,
+	ifaceProperties: `<method name="Get"><arg name="interface" type="s" direction="in"/><arg name="property" type="s" direction="in"/><arg name="value" type="v" direction="out"/></method>` +
+		`<method name="GetAll"><arg name="interface" type="s" direction="in"/><arg name="properties" type="a{sv}" direction="out"/></method>` +
+		`<method name="Set"><arg name="interface" type="s" direction="in"/><arg name="property" type="s" direction="in"/><arg name="value" type="v" direction="in"/></method>`,
+	ifaceIntrospectable: `<method name="Introspect"><arg name="data" type="s" direction="out"/></method>`,
+	ifacePeer:           `<method name="Ping"></method>`,
+}
+
+// stringMap converts a decoded a{ss} into a map
+func stringMap(v interface{}) map[string]string {
+	m := make(map[string]string)
+	items, _ := v.([]interface{})
+	for _, item := range items {
+		pair := item.([]interface{})
+		m[pair[0].(string)] = pair[1].(string)
+	}
+	return m
+}
+
+// objectPaths converts a decoded ao into paths
+func objectPaths(v interface{}) []dbusObjectPath {
+	var paths []dbusObjectPath
+	items, _ := v.([]interface{})
+	for _, item := range items {
+		paths = append(paths, item.(dbusObjectPath))
+	}
+	return paths
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
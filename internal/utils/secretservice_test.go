@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// callSecretService sends a method call from sender through the wire
+// codec and returns the reply's arguments, as decoded by a client
+func callSecretService(t *testing.T, s *SecretService, sender string, path dbusObjectPath, iface, member, sig string, args ...interface{}) ([]interface{}, error) {
+	t.Helper()
+	call := &dbusMessage{Type: dbusMethodCall, Serial: 1, Path: path, Interface: iface, Member: member, Signature: sig, Body: args}
+	data, err := call.marshal()
+	if err != nil {
+		t.Fatalf("marshal(%s) failed: %v", member, err)
+	}
+	received, err := readDBusMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readDBusMessage(%s) failed: %v", member, err)
+	}
+	received.Sender = sender
+
+	replySig, body, err := s.handle(received)
+	if err != nil {
+		return nil, err
+	}
+	reply := &dbusMessage{Type: dbusMethodReturn, Serial: 2, ReplySerial: 1, Signature: replySig, Body: body}
+	if data, err = reply.marshal(); err != nil {
+		t.Fatalf("marshal(%s reply) failed: %v", member, err)
+	}
+	if reply, err = readDBusMessage(bytes.NewReader(data)); err != nil {
+		t.Fatalf("readDBusMessage(%s reply) failed: %v", member, err)
+	}
+	return reply.Body, nil
+}
+
+// secretClientKey completes the client's side of a dh-ietf1024 session
+// as libsecret does: the AES key is HKDF-SHA256 of the shared secret
+func secretClientKey(t *testing.T, private *big.Int, serverPublic []byte) []byte {
+	t.Helper()
+	size := (secretDHPrime.BitLen() + 7) / 8
+	shared := new(big.Int).Exp(new(big.Int).SetBytes(serverPublic), private, secretDHPrime)
+	key := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared.FillBytes(make([]byte, size)), nil, nil), key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestSecretServiceDHSession(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example")
+	s := NewSecretService(NewAgent(h, "test"), "")
+	const client = ":1.7"
+
+	private, err := rand.Int(rand.Reader, secretDHPrime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := new(big.Int).Exp(big.NewInt(2), private, secretDHPrime).Bytes()
+
+	reply, err := callSecretService(t, s, client, secretServicePath, ifaceSecretService, "OpenSession", "sv",
+		secretAlgorithmDH, dbusVariant{Sig: "ay", Value: public})
+	if err != nil {
+		t.Fatalf("OpenSession failed: %v", err)
+	}
+	output := reply[0].(dbusVariant)
+	serverPublic, ok := output.Value.([]byte)
+	if output.Sig != "ay" || !ok || len(serverPublic) != 128 {
+		t.Fatalf("Expected the server's 128-byte public key, got %#v", output)
+	}
+	session := reply[1].(dbusObjectPath)
+	key := secretClientKey(t, private, serverPublic)
+
+	reply, err = callSecretService(t, s, client, secretServicePath, ifaceSecretService, "SearchItems", "a{ss}",
+		map[string]string{"site": "b.example"})
+	if err != nil {
+		t.Fatalf("SearchItems failed: %v", err)
+	}
+	unlocked := objectPaths(reply[0])
+	if len(unlocked) != 1 {
+		t.Fatalf("Expected 1 item for b.example, got %v", unlocked)
+	}
+
+	reply, err = callSecretService(t, s, client, unlocked[0], ifaceSecretItem, "GetSecret", "o", session)
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	secret := reply[0].([]interface{})
+	iv, ciphertext := secret[1].([]byte), secret[2].([]byte)
+	if secret[0] != session || len(iv) != aes.BlockSize || len(ciphertext)%aes.BlockSize != 0 {
+		t.Fatalf("Expected an AES-CBC secret for %s, got %#v", session, secret)
+	}
+	if bytes.Contains(ciphertext, []byte("pw-b.example")) {
+		t.Fatal("Expected the secret to be encrypted")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	pad := int(plaintext[len(plaintext)-1])
+	if pad < 1 || pad > aes.BlockSize || !bytes.Equal(plaintext[len(plaintext)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		t.Fatalf("Expected PKCS#7 padding, got %x", plaintext)
+	}
+	if got := string(plaintext[:len(plaintext)-pad]); got != "pw-b.example" {
+		t.Errorf("Expected pw-b.example, got %q", got)
+	}
+
+	// The session is the client's alone
+	_, err = callSecretService(t, s, ":1.8", unlocked[0], ifaceSecretItem, "GetSecret", "o", session)
+	var callErr *dbusCallError
+	if !errors.As(err, &callErr) || callErr.Name != "org.freedesktop.Secret.Error.NoSession" {
+		t.Errorf("Expected another client to get NoSession, got %v", err)
+	}
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		want := 0
+		if entry.Site == "b.example" {
+			want = 1
+		}
+		if entry.RevealCount != want {
+			t.Errorf("%s: expected %d reveals, got %d", entry.Site, want, entry.RevealCount)
+		}
+	}
+}
+
+func TestSecretServiceRejectsWeakDHKeys(t *testing.T) {
+	pMinus1 := new(big.Int).Sub(secretDHPrime, big.NewInt(1))
+	for _, public := range [][]byte{{}, {1}, pMinus1.Bytes(), secretDHPrime.Bytes()} {
+		if _, _, err := secretKeyAgreement(public); err == nil {
+			t.Errorf("Expected the public key %x to be refused", public)
+		}
+	}
+}
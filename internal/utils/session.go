@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionStats counts what happened since passman started, for the
+// footer. Background tasks report to it, so it is safe for concurrent use.
+type SessionStats struct {
+	mu           sync.Mutex
+	generations  int
+	tasks        map[string]int // Running background tasks by name
+	lastActivity time.Time      // Last key press or mouse event, for the idle lock
+	lastSync     time.Time      // When the history was last synced, zero if never
+	syncErr      error          // Why the last sync failed
+}
+
+// NewSessionStats starts counting a new session
+func NewSessionStats() *SessionStats {
+	return &SessionStats{
+		tasks:        make(map[string]int),
+		lastActivity: time.Now(),
+	}
+}
+
+// RecordActivity notes that the user did something at t
+func (s *SessionStats) RecordActivity(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastActivity = t
+}
+
+// LastActivity returns when the user last did something
+func (s *SessionStats) LastActivity() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastActivity
+}
+
+// RecordGeneration counts a generated secret
+func (s *SessionStats) RecordGeneration() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generations++
+}
+
+// Generations returns how many secrets were generated this session
+func (s *SessionStats) Generations() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generations
+}
+
+// StartTask records a running background task, e.g. "export". Call the
+// returned function when the task finishes.
+func (s *SessionStats) StartTask(name string) (done func()) {
+	s.mu.Lock()
+	s.tasks[name]++
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.tasks[name]--; s.tasks[name] <= 0 {
+				delete(s.tasks, name)
+			}
+		})
+	}
+}
+
+// PendingTasks returns the names of the running background tasks, sorted,
+// with a name repeated for each task of that kind
+func (s *SessionStats) PendingTasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name, count := range s.tasks {
+		for i := 0; i < count; i++ {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RecordSync notes a sync that finished at t, failed if err is set
+func (s *SessionStats) RecordSync(t time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSync = t
+	s.syncErr = err
+}
+
+// LastSync returns when the history was last synced and whether that
+// failed; the time is zero if it wasn't synced this session
+func (s *SessionStats) LastSync() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSync, s.syncErr
+}
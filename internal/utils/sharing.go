@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ShareRecord records that a secret was shared with someone. It is stored
+// on the history entry, inside the encrypted history.
+type ShareRecord struct {
+	Recipient string     `json:"recipient"`
+	Method    string     `json:"method,omitempty"` // How it was shared, e.g. "manual" or "export"
+	SharedAt  time.Time  `json:"shared_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Nil never expires
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // When the secret was rotated or access revoked
+}
+
+// IsExpired reports whether the share has passed its expiry without being revoked
+func (s ShareRecord) IsExpired(now time.Time) bool {
+	return s.RevokedAt == nil && s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// IsActive reports whether the share is neither revoked nor expired
+func (s ShareRecord) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && !s.IsExpired(now)
+}
+
+// String formats the share for display
+func (s ShareRecord) String() string {
+	var b strings.Builder
+	b.WriteString(s.Recipient)
+	if s.Method != "" {
+		fmt.Fprintf(&b, " via %s", s.Method)
+	}
+	fmt.Fprintf(&b, " on %s", s.SharedAt.Format("Jan 2 2006"))
+
+	switch {
+	case s.RevokedAt != nil:
+		fmt.Fprintf(&b, ", revoked %s", s.RevokedAt.Format("Jan 2 2006"))
+	case s.ExpiresAt == nil:
+		b.WriteString(", no expiry")
+	case s.IsExpired(time.Now()):
+		fmt.Fprintf(&b, ", expired %s — rotate", s.ExpiresAt.Format("Jan 2 2006"))
+	default:
+		fmt.Fprintf(&b, ", expires %s", s.ExpiresAt.Format("Jan 2 2006"))
+	}
+	return b.String()
+}
+
+// IsShared reports whether the entry has any share that is not revoked
+func (e HistoryEntry) IsShared() bool {
+	for _, share := range e.Shares {
+		if share.RevokedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsRotation reports whether any share of the entry has expired and
+// the secret has not been rotated since
+func (e HistoryEntry) NeedsRotation() bool {
+	now := time.Now()
+	for _, share := range e.Shares {
+		if share.IsExpired(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordShare adds a share record to the entry with the given ID. A zero
+// validFor never expires.
+func (h *HistoryManager) RecordShare(id, recipient, method string, validFor time.Duration) error {
+	if id == "" {
+		return fmt.Errorf("entry ID cannot be empty")
+	}
+	recipient = strings.TrimSpace(recipient)
+	if recipient == "" {
+		return fmt.Errorf("recipient cannot be empty")
+	}
+
+	share := ShareRecord{
+		Recipient: recipient,
+		Method:    method,
+		SharedAt:  time.Now(),
+	}
+	if validFor > 0 {
+		expiresAt := share.SharedAt.Add(validFor)
+		share.ExpiresAt = &expiresAt
+	}
+
+	return h.updateEntry(id, func(entry *HistoryEntry) {
+		entry.Shares = append(entry.Shares, share)
+	})
+}
+
+// RevokeShares marks every unrevoked share of the entry as revoked, once
+// the secret has been rotated or the recipient's access removed
+func (h *HistoryManager) RevokeShares(id string) error {
+	if id == "" {
+		return fmt.Errorf("entry ID cannot be empty")
+	}
+
+	now := time.Now()
+	return h.updateEntry(id, func(entry *HistoryEntry) {
+		for i := range entry.Shares {
+			if entry.Shares[i].RevokedAt == nil {
+				entry.Shares[i].RevokedAt = &now
+			}
+		}
+	})
+}
+
+// updateEntry applies update to the entry with the given ID and saves the history
+func (h *HistoryManager) updateEntry(id string, update func(entry *HistoryEntry)) error {
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		if entries[i].ID == id {
+			update(&entries[i])
+			return h.saveHistory(entries)
+		}
+	}
+
+	return fmt.Errorf("history entry %s not found", id)
+}
+
+// RevocationChecklist returns the entries with expired shares, which
+// should be rotated and then marked revoked
+func RevocationChecklist(entries []HistoryEntry) []HistoryEntry {
+	var checklist []HistoryEntry
+	for _, entry := range entries {
+		if entry.NeedsRotation() {
+			checklist = append(checklist, entry)
+		}
+	}
+	return checklist
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SSH key types ssh-keygen is asked to create
+const (
+	SSHKeyEd25519 = "ed25519"
+	SSHKeyECDSA   = "ecdsa"
+	SSHKeyRSA     = "rsa"
+)
+
+// ErrNoSSHKeygen is returned when ssh-keygen is not installed
+var ErrNoSSHKeygen = errors.New("ssh-keygen not found (install OpenSSH)")
+
+// SSHKeyOptions describes the key pair to create
+type SSHKeyOptions struct {
+	Type    string // ed25519, ecdsa or rsa
+	Bits    int    // Key size for ecdsa and rsa; 0 = ssh-keygen's default
+	Comment string
+	Path    string // Private key file; the public key gets a .pub suffix
+}
+
+// SSHKeyInfo is what the history records about a key pair protected by a
+// generated passphrase. The key itself is never stored.
+type SSHKeyInfo struct {
+	Path        string `json:"path"`
+	Type        string `json:"type"`
+	Bits        int    `json:"bits,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"` // Empty if the key was not created by passman
+}
+
+// DefaultSSHKeyPath returns ~/.ssh/id_TYPE
+func DefaultSSHKeyPath(keyType string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "id_"+keyType), nil
+}
+
+// Validate checks the key type and size and that no key would be
+// overwritten
+func (o SSHKeyOptions) Validate() error {
+	switch o.Type {
+	case SSHKeyEd25519:
+		if o.Bits != 0 {
+			return fmt.Errorf("ed25519 keys have a fixed size")
+		}
+	case SSHKeyECDSA:
+		if o.Bits != 0 && o.Bits != 256 && o.Bits != 384 && o.Bits != 521 {
+			return fmt.Errorf("ecdsa keys are 256, 384 or 521 bits")
+		}
+	case SSHKeyRSA:
+		if o.Bits != 0 && o.Bits < 3072 {
+			return fmt.Errorf("rsa keys must be at least 3072 bits")
+		}
+	default:
+		return fmt.Errorf("unknown key type %q (use ed25519, ecdsa or rsa)", o.Type)
+	}
+
+	if o.Path == "" {
+		return fmt.Errorf("key path is required")
+	}
+	for _, path := range []string{o.Path, o.Path + ".pub"} {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+	}
+	return nil
+}
+
+// Args returns the ssh-keygen arguments that create the key. The
+// passphrase is not among them: ssh-keygen asks for it, so it never shows
+// up in the process list or the shell history.
+func (o SSHKeyOptions) Args() []string {
+	args := []string{"-t", o.Type}
+	if o.Bits != 0 {
+		args = append(args, "-b", strconv.Itoa(o.Bits))
+	}
+	return append(args, "-C", o.Comment, "-f", o.Path)
+}
+
+// Command returns the ssh-keygen command line, quoted for a POSIX shell,
+// for running it by hand
+func (o SSHKeyOptions) Command() string {
+	parts := []string{"ssh-keygen"}
+	for _, arg := range o.Args() {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Info returns the history record of the key, with its fingerprint if it
+// was created
+func (o SSHKeyOptions) Info(fingerprint string) *SSHKeyInfo {
+	return &SSHKeyInfo{
+		Path:        o.Path,
+		Type:        o.Type,
+		Bits:        o.Bits,
+		Comment:     o.Comment,
+		Fingerprint: fingerprint,
+	}
+}
+
+// SSHKeygenAvailable reports whether ssh-keygen is installed and passman
+// can hand it the passphrase
+func SSHKeygenAvailable() bool {
+	if !canDetachTerminal {
+		return false
+	}
+	_, err := exec.LookPath("ssh-keygen")
+	return err == nil
+}
+
+// CreateSSHKey runs ssh-keygen to create the key pair protected by
+// passphrase and returns the key's fingerprint. ssh-keygen is detached
+// from the terminal so it reads the passphrase from its stdin.
+func CreateSSHKey(ctx context.Context, opts SSHKeyOptions, passphrase string) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase is required")
+	}
+	if strings.ContainsAny(passphrase, "\r\n") {
+		return "", fmt.Errorf("passphrase cannot contain line breaks")
+	}
+	if !SSHKeygenAvailable() {
+		return "", ErrNoSSHKeygen
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "ssh-keygen", append([]string{"-q"}, opts.Args()...)...)
+	if err := detachTerminal(cmd); err != nil {
+		return "", err
+	}
+	// Once to set it, once to confirm it
+	cmd.Stdin = strings.NewReader(passphrase + "\n" + passphrase + "\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ssh-keygen failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	output, err := exec.CommandContext(ctx, "ssh-keygen", "-l", "-f", opts.Path+".pub").Output()
+	if err != nil {
+		return "", fmt.Errorf("key created, but reading its fingerprint failed: %w", err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return "", fmt.Errorf("key created, but ssh-keygen printed no fingerprint")
+	}
+	return fields[1], nil
+}
+
+// shellQuote quotes s for a POSIX shell unless it is safe as is
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./@:+=,", r))
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
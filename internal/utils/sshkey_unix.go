@@ -0,0 +1,18 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// canDetachTerminal reports whether detachTerminal works here
+const canDetachTerminal = true
+
+// detachTerminal starts cmd in a new session without a controlling
+// terminal, so it reads prompts from stdin instead of /dev/tty
+func detachTerminal(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	return nil
+}
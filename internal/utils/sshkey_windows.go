@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// canDetachTerminal is false on Windows, where ssh-keygen always reads the
+// passphrase from the console; the command is offered instead
+const canDetachTerminal = false
+
+// detachTerminal is not supported on Windows
+func detachTerminal(cmd *exec.Cmd) error {
+	return errors.New("cannot pass the passphrase to ssh-keygen on Windows; run the command by hand")
+}
@@ -8,6 +8,8 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -30,7 +32,7 @@ func main() {
 	cfg := DemoConfig{
 		AutoCopyToClipboard:      true,
 		DefaultExportFormat:      "txt",
-		DefaultExportPath:        "/tmp/passwords",
+		DefaultExportPath:        filepath.Join(os.TempDir(), "passwords"),
 		HistoryEnabled:           false, // Disabled by default for security
 		HistoryMaxEntries:        100,
 		DefaultPassphraseWords:   4,
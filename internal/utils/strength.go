@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"sync"
+
+	"github.com/mshnjffr/passman/internal/generator"
+)
+
+// strengthAnalyzer rates history passwords; it is built on first use as
+// it loads the common password lists
+var strengthAnalyzer = sync.OnceValue(generator.NewSecurityAnalyzer)
+
+// analyzeEntries stores the estimated strength of entries that have none:
+// new entries and those saved before strengths were stored
+func analyzeEntries(entries []HistoryEntry) {
+	for i := range entries {
+		if entries[i].Strength == "" && entries[i].Password != "" {
+			analysis := strengthAnalyzer().Analyze(entries[i].Password)
+			entries[i].Entropy = analysis.Entropy
+			entries[i].Strength = generator.SecurityLevelToString(analysis.Level)
+		}
+	}
+}
+
+// SecurityLevel returns the stored strength of the entry's password,
+// analysing it now if the entry has none stored
+func (e HistoryEntry) SecurityLevel() (generator.SecurityLevel, float64) {
+	if level, ok := generator.ParseSecurityLevel(e.Strength); ok {
+		return level, e.Entropy
+	}
+	analysis := strengthAnalyzer().Analyze(e.Password)
+	return analysis.Level, analysis.Entropy
+}
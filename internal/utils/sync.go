@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Errors returned by sync remotes
+var (
+	ErrRemoteNotFound = errors.New("nothing synced to the remote yet")
+	ErrSyncConflict   = errors.New("the remote changed during the sync")
+)
+
+// syncAttempts is how often a sync starts over when another device
+// pushed in the meantime
+const syncAttempts = 3
+
+// SyncRemote stores the encrypted history file somewhere other devices
+// can reach. Remotes only ever see ciphertext: the file is encrypted with
+// the history passphrase before it leaves the machine.
+type SyncRemote interface {
+	// Fetch returns the remote file and a version to pass to Store, or
+	// ErrRemoteNotFound when nothing was synced yet
+	Fetch(ctx context.Context) (data []byte, version string, err error)
+	// Store replaces the remote file if it is still at version, or creates
+	// it if version is empty and there is none, and returns
+	// ErrSyncConflict if that is no longer the case
+	Store(ctx context.Context, data []byte, version string) error
+	// String describes the remote without credentials
+	String() string
+}
+
+// ParseSyncRemote returns the remote of a sync_remote URL: WebDAV for
+// http(s)://, an S3 object for s3://bucket/key and a git repository for
+// git+ followed by anything git clone accepts
+func ParseSyncRemote(raw string) (SyncRemote, error) {
+	switch {
+	case strings.HasPrefix(raw, "https://"), strings.HasPrefix(raw, "http://"):
+		return newWebDAVRemote(raw)
+	case strings.HasPrefix(raw, "s3://"):
+		return newS3Remote(raw)
+	case strings.HasPrefix(raw, "git+"):
+		return newGitRemote(strings.TrimPrefix(raw, "git+"))
+	case raw == "":
+		return nil, fmt.Errorf("no sync remote configured; set sync_remote")
+	}
+	return nil, fmt.Errorf("unsupported sync remote %q (use an http(s)://, s3:// or git+ URL)", raw)
+}
+
+// SyncReport is the result of a sync
+type SyncReport struct {
+	Remote    string
+	Pulled    int // Entries new or newer on the remote
+	Pushed    int // Entries new or newer here
+	Conflicts int // Entries changed on both sides; the most recently used won
+	Attempts  int
+	At        time.Time
+}
+
+// String summarises the sync for display
+func (r SyncReport) String() string {
+	s := fmt.Sprintf("Synced with %s: %d pulled, %d pushed", r.Remote, r.Pulled, r.Pushed)
+	if r.Conflicts > 0 {
+		s += fmt.Sprintf(", %d conflicts resolved by most recent use", r.Conflicts)
+	}
+	return s
+}
+
+// Sync merges the history with the copy on remote and pushes the result.
+// Entries are matched by ID. An entry changed on both sides is a conflict,
+// won by the side that used it last. Deleting an entry is not synced:
+// another device still holding it brings it back.
+func (h *HistoryManager) Sync(ctx context.Context, remote SyncRemote) (*SyncReport, error) {
+	if !h.enabled {
+		return nil, fmt.Errorf("history is disabled")
+	}
+
+	report := &SyncReport{Remote: remote.String()}
+	for report.Attempts < syncAttempts {
+		report.Attempts++
+		err := h.syncOnce(ctx, remote, report)
+		if !errors.Is(err, ErrSyncConflict) {
+			if err != nil {
+				return nil, err
+			}
+			report.At = time.Now()
+			return report, nil
+		}
+	}
+	return nil, fmt.Errorf("gave up after %d attempts: %w", syncAttempts, ErrSyncConflict)
+}
+
+// syncOnce runs one fetch, merge and store round
+func (h *HistoryManager) syncOnce(ctx context.Context, remote SyncRemote, report *SyncReport) error {
+	data, version, err := remote.Fetch(ctx)
+	exists := err == nil
+	var remoteEntries []HistoryEntry
+	switch {
+	case errors.Is(err, ErrRemoteNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to fetch from %s: %w", remote, err)
+	default:
+		plaintext, err := h.decrypt(data)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt the synced history (is the history passphrase the same on every device?): %w", err)
+		}
+		if err := json.Unmarshal(plaintext, &remoteEntries); err != nil {
+			return fmt.Errorf("failed to parse the synced history: %w", err)
+		}
+	}
+
+	merged, result, err := h.mergeIntoHistory(remoteEntries)
+	if err != nil {
+		return err
+	}
+	report.Pulled, report.Pushed, report.Conflicts = result.pulled, result.pushed, result.conflicts
+
+	if result.pushed == 0 && exists {
+		return nil
+	}
+
+	plaintext, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history data: %w", err)
+	}
+	encryptedData, err := h.encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt history data: %w", err)
+	}
+	if err := remote.Store(ctx, encryptedData, version); err != nil {
+		if errors.Is(err, ErrSyncConflict) {
+			return err
+		}
+		return fmt.Errorf("failed to push to %s: %w", remote, err)
+	}
+	return nil
+}
+
+// mergeIntoHistory merges remote entries into the local history and saves
+// it if anything was pulled. The history is locked only here, never while
+// waiting on the network, and entries added meanwhile are merged too.
+func (h *HistoryManager) mergeIntoHistory(remote []HistoryEntry) ([]HistoryEntry, mergeResult, error) {
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return nil, mergeResult{}, err
+	}
+	defer unlock()
+
+	local, err := h.LoadHistory()
+	if err != nil {
+		return nil, mergeResult{}, err
+	}
+
+	merged, result := mergeSynced(local, remote)
+	if len(merged) > h.maxEntries {
+		merged = merged[:h.maxEntries]
+	}
+	if result.pulled > 0 {
+		// Both devices merge into the same order, so they build the same chain
+		relinkEntries(merged, chainHashes(local, remote))
+		if err := h.saveHistory(merged); err != nil {
+			return nil, mergeResult{}, err
+		}
+	}
+	return merged, result, nil
+}
+
+// mergeResult counts what a merge took from each side
+type mergeResult struct {
+	pulled, pushed, conflicts int
+}
+
+// mergeSynced merges the local and remote histories by entry ID, newest
+// entry first
+func mergeSynced(local, remote []HistoryEntry) ([]HistoryEntry, mergeResult) {
+	var result mergeResult
+
+	byID := make(map[string]int, len(remote))
+	for i, entry := range remote {
+		byID[entry.ID] = i
+	}
+
+	merged := make([]HistoryEntry, 0, len(local)+len(remote))
+	matched := make(map[string]bool, len(remote))
+	for _, entry := range local {
+		i, ok := byID[entry.ID]
+		if !ok {
+			result.pushed++
+			merged = append(merged, entry)
+			continue
+		}
+		matched[entry.ID] = true
+
+		theirs := remote[i]
+		if sameEntry(entry, theirs) {
+			merged = append(merged, entry)
+			continue
+		}
+		result.conflicts++
+		if lastActivity(theirs).After(lastActivity(entry)) {
+			result.pulled++
+			merged = append(merged, theirs)
+		} else {
+			result.pushed++
+			merged = append(merged, entry)
+		}
+	}
+	for _, entry := range remote {
+		if !matched[entry.ID] {
+			result.pulled++
+			merged = append(merged, entry)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+	return merged, result
+}
+
+// sameEntry reports whether two versions of an entry are identical. The
+// previous hash is left out: it depends on the device's other entries.
+func sameEntry(a, b HistoryEntry) bool {
+	a.PrevHash, b.PrevHash = "", ""
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// lastActivity returns when an entry was last created, used, shared,
+// revoked, tagged, edited, superseded or flagged by a rotation
+func lastActivity(entry HistoryEntry) time.Time {
+	at := entry.CreatedAt
+	later := func(t *time.Time) {
+		if t != nil && t.After(at) {
+			at = *t
+		}
+	}
+	later(entry.LastCopiedAt)
+	later(entry.LastRevealedAt)
+	later(entry.PrimaryRotatedAt)
+	later(entry.SupersededAt)
+	later(entry.TaggedAt)
+	later(entry.EditedAt)
+	for _, share := range entry.Shares {
+		later(&share.SharedAt)
+		later(share.RevokedAt)
+	}
+	return at
+}
+
+// syncEnv returns the first non-empty environment variable of names
+func syncEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
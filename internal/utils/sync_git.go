@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mshnjffr/passman/internal/config"
+)
+
+// gitRemote syncs the history file through a git repository, with a
+// working copy in the cache directory. A rejected push means another
+// device pushed first. Authentication is left to git: SSH keys or a
+// credential helper.
+type gitRemote struct {
+	url  string // Anything git clone accepts
+	file string // Path of the history file in the repository
+	dir  string // Working copy
+}
+
+// newGitRemote parses a git URL, optionally followed by #path/in/repo
+func newGitRemote(raw string) (*gitRemote, error) {
+	url, file := raw, "history.enc"
+	if i := strings.LastIndex(raw, "#"); i >= 0 {
+		url, file = raw[:i], raw[i+1:]
+	}
+	file = filepath.ToSlash(filepath.Clean(file))
+	if url == "" || file == "." || strings.HasPrefix(file, "../") || filepath.IsAbs(file) {
+		return nil, fmt.Errorf("git sync remote must be git+URL or git+URL#path/in/repo")
+	}
+
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return &gitRemote{
+		url:  url,
+		file: file,
+		dir:  filepath.Join(cacheDir, "sync", hex.EncodeToString(sum[:8])),
+	}, nil
+}
+
+func (r *gitRemote) String() string {
+	return "git+" + r.url + "#" + r.file
+}
+
+// git runs git in the working copy without ever prompting
+func (r *gitRemote) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// branch returns the branch the working copy tracks
+func (r *gitRemote) branch(ctx context.Context) (string, error) {
+	return r.git(ctx, "symbolic-ref", "--quiet", "--short", "HEAD")
+}
+
+func (r *gitRemote) Fetch(ctx context.Context) ([]byte, string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, "", fmt.Errorf("git sync needs git installed")
+	}
+
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(r.dir), 0700); err != nil {
+			return nil, "", err
+		}
+		cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", r.url, r.dir)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, "", fmt.Errorf("git clone: %s", strings.TrimSpace(string(out)))
+		}
+	} else if _, err := r.git(ctx, "fetch", "--quiet", "origin"); err != nil {
+		return nil, "", err
+	}
+
+	branch, err := r.branch(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	// An empty repository has no remote branch yet
+	version, err := r.git(ctx, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch)
+	if err == nil {
+		if _, err := r.git(ctx, "reset", "--quiet", "--hard", version); err != nil {
+			return nil, "", err
+		}
+	} else {
+		version = ""
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(r.file)))
+	if os.IsNotExist(err) {
+		return nil, version, ErrRemoteNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, version, nil
+}
+
+// Store commits the file and pushes; version is not needed, as git
+// rejects a push that would overwrite another device's commit
+func (r *gitRemote) Store(ctx context.Context, data []byte, version string) error {
+	path := filepath.Join(r.dir, filepath.FromSlash(r.file))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	if _, err := r.git(ctx, "add", "--", r.file); err != nil {
+		return err
+	}
+
+	commit := []string{"commit", "--quiet", "-m", "passman sync"}
+	if email, _ := r.git(ctx, "config", "user.email"); email == "" {
+		commit = append([]string{"-c", "user.name=passman", "-c", "user.email=passman@localhost"}, commit...)
+	}
+	if _, err := r.git(ctx, commit...); err != nil {
+		return err
+	}
+
+	branch, err := r.branch(ctx)
+	if err != nil {
+		return err
+	}
+	if out, err := r.git(ctx, "push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		if strings.Contains(out, "rejected") || strings.Contains(out, "fetch first") {
+			return ErrSyncConflict
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Remote syncs the history file with an S3 object, using conditional
+// writes to detect concurrent pushes. Credentials and region come from the
+// usual AWS_* environment variables; AWS_ENDPOINT_URL_S3 or
+// AWS_ENDPOINT_URL point it at an S3-compatible service.
+type s3Remote struct {
+	bucket       string
+	key          string
+	region       string
+	endpoint     *url.URL
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+}
+
+// newS3Remote parses an s3://bucket/key URL
+func newS3Remote(raw string) (*s3Remote, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync remote: %w", err)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("S3 sync remote must name an object, e.g. s3://my-bucket/passman/history.enc")
+	}
+
+	r := &s3Remote{
+		bucket:       u.Host,
+		key:          key,
+		region:       syncEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		accessKey:    syncEnv("AWS_ACCESS_KEY_ID"),
+		secretKey:    syncEnv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: syncEnv("AWS_SESSION_TOKEN"),
+		client:       &http.Client{Timeout: syncTimeout},
+	}
+	if r.region == "" {
+		r.region = "us-east-1"
+	}
+	if r.accessKey == "" || r.secretKey == "" {
+		return nil, fmt.Errorf("S3 sync needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := syncEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	if endpoint == "" {
+		endpoint = "https://s3." + r.region + ".amazonaws.com"
+	}
+	if r.endpoint, err = url.Parse(endpoint); err != nil || r.endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	return r, nil
+}
+
+func (r *s3Remote) String() string {
+	return "s3://" + r.bucket + "/" + r.key
+}
+
+// objectURL is the path-style URL of the object, which works with AWS and
+// S3-compatible services alike
+func (r *s3Remote) objectURL() *url.URL {
+	u := *r.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + r.bucket + "/" + r.key
+	u.RawPath = ""
+	return &u
+}
+
+// request sends a request for the object signed with AWS Signature V4
+func (r *s3Remote) request(ctx context.Context, method string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.objectURL().String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	r.sign(req, body, time.Now().UTC())
+	return r.client.Do(req)
+}
+
+// sign adds the AWS Signature V4 authorization to req
+func (r *s3Remote) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	stamp := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if r.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", r.sessionToken)
+	}
+
+	// Sign the host and every x-amz-* and conditional header
+	signed := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || strings.HasPrefix(lower, "if-") {
+			signed[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + r.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", stamp, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+r.secretKey), date)
+	key = hmacSHA256(key, r.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		r.accessKey, scope, signedHeaders, signature))
+}
+
+func (r *s3Remote) Fetch(ctx context.Context) ([]byte, string, error) {
+	resp, err := r.request(ctx, http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", ErrRemoteNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("GET returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+func (r *s3Remote) Store(ctx context.Context, data []byte, version string) error {
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	if version == "" {
+		header.Set("If-None-Match", "*")
+	} else {
+		header.Set("If-Match", version)
+	}
+
+	resp, err := r.request(ctx, http.MethodPut, data, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed, resp.StatusCode == http.StatusConflict:
+		return ErrSyncConflict
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("PUT returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sha256Hex returns the hex SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
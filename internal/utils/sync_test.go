@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeSyncedConflicts(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	later := created.Add(30 * time.Minute)
+	entry := func(id string, minute int) HistoryEntry {
+		return HistoryEntry{ID: id, Password: "pw-" + id, Type: "random", CreatedAt: created.Add(time.Duration(minute) * time.Minute)}
+	}
+
+	same, sameThere := entry("same", 0), entry("same", 0)
+	sameThere.PrevHash = "another device's chain"
+	copied, edited := entry("copied", 1), entry("edited", 2)
+	copiedThere, editedThere := copied, edited
+	copied.CopyCount, copied.LastCopiedAt = 1, &later
+	editedThere.Description, editedThere.EditedAt = "edited there", &later
+
+	local := []HistoryEntry{same, copied, edited, entry("local", 3)}
+	remote := []HistoryEntry{sameThere, copiedThere, editedThere, entry("remote", 4)}
+
+	merged, result := mergeSynced(local, remote)
+	if result != (mergeResult{pulled: 2, pushed: 2, conflicts: 2}) {
+		t.Errorf("Expected 2 pulled, 2 pushed and 2 conflicts, got %+v", result)
+	}
+
+	wantIDs := []string{"remote", "local", "edited", "copied", "same"}
+	if len(merged) != len(wantIDs) {
+		t.Fatalf("Expected %d entries, got %d", len(wantIDs), len(merged))
+	}
+	for i, id := range wantIDs {
+		if merged[i].ID != id {
+			t.Errorf("Entry %d: expected %s, got %s", i, id, merged[i].ID)
+		}
+	}
+	if merged[2].Description != "edited there" {
+		t.Error("Expected the entry edited on the remote to win")
+	}
+	if merged[3].CopyCount != 1 {
+		t.Error("Expected the entry copied here to win")
+	}
+	if merged[4].PrevHash != "" {
+		t.Error("Expected the local version of an unchanged entry to be kept")
+	}
+}
+
+func TestMergeIntoHistory(t *testing.T) {
+	h := newTestHistory(t)
+	addTestEntries(t, h, "a.example", "b.example")
+
+	remote, err := h.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := time.Now()
+	for i := range remote {
+		if remote[i].Site == "b.example" {
+			remote[i].Username, remote[i].EditedAt = "me", &edited
+		}
+	}
+	remote = append([]HistoryEntry{{ID: "from-another-device", Password: "pw-c.example", Site: "c.example", CreatedAt: edited}}, remote...)
+
+	_, result, err := h.mergeIntoHistory(remote)
+	if err != nil {
+		t.Fatalf("mergeIntoHistory failed: %v", err)
+	}
+	if result.pulled != 2 || result.conflicts != 1 {
+		t.Errorf("Expected 2 pulled with 1 conflict, got %+v", result)
+	}
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].ID != "from-another-device" {
+		t.Fatalf("Expected the remote entry to be saved first, got %+v", entries)
+	}
+	if entries[1].Site != "b.example" || entries[1].Username != "me" {
+		t.Errorf("Expected the remote edit of b.example to be saved, got %+v", entries[1])
+	}
+	chain, err := h.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	if !chain.OK() {
+		t.Errorf("Expected the merged history to be chained, got %+v", chain.Breaks)
+	}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// syncTimeout bounds every request to a sync remote
+const syncTimeout = 60 * time.Second
+
+// unversioned is the version of a WebDAV file served without an ETag, which
+// is then overwritten without a precondition
+const unversioned = "unversioned"
+
+// webdavRemote syncs the history file with a WebDAV server, using ETags to
+// detect concurrent pushes. Credentials come from the URL or from
+// PASSMAN_SYNC_USER and PASSMAN_SYNC_PASSWORD.
+type webdavRemote struct {
+	url      string // Without credentials
+	user     string
+	password string
+	client   *http.Client
+}
+
+// newWebDAVRemote parses an http(s):// URL of the history file
+func newWebDAVRemote(raw string) (*webdavRemote, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync remote: %w", err)
+	}
+	if u.Host == "" || u.Path == "" || u.Path[len(u.Path)-1] == '/' {
+		return nil, fmt.Errorf("WebDAV sync remote must be the URL of a file, e.g. https://dav.example.com/passman/history.enc")
+	}
+
+	r := &webdavRemote{
+		user:     syncEnv("PASSMAN_SYNC_USER"),
+		password: syncEnv("PASSMAN_SYNC_PASSWORD"),
+		client:   &http.Client{Timeout: syncTimeout},
+	}
+	if u.User != nil {
+		r.user = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			r.password = password
+		}
+		u.User = nil
+	}
+	r.url = u.String()
+	return r, nil
+}
+
+func (r *webdavRemote) String() string {
+	return r.url
+}
+
+// request sends a request to the file's URL
+func (r *webdavRemote) request(ctx context.Context, method string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if r.user != "" || r.password != "" {
+		req.SetBasicAuth(r.user, r.password)
+	}
+	return r.client.Do(req)
+}
+
+func (r *webdavRemote) Fetch(ctx context.Context) ([]byte, string, error) {
+	resp, err := r.request(ctx, http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", ErrRemoteNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("GET returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	version := resp.Header.Get("ETag")
+	if version == "" {
+		version = unversioned
+	}
+	return data, version, nil
+}
+
+func (r *webdavRemote) Store(ctx context.Context, data []byte, version string) error {
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	switch version {
+	case "":
+		header.Set("If-None-Match", "*")
+	case unversioned:
+	default:
+		header.Set("If-Match", version)
+	}
+
+	resp, err := r.request(ctx, http.MethodPut, data, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return ErrSyncConflict
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("PUT returned %s", resp.Status)
+	}
+	return nil
+}
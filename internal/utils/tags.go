@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the longest tag allowed, in characters
+const MaxTagLength = 32
+
+// ParseTags splits a comma- or space-separated list into tags: trimmed,
+// lowercased and each listed once, in the order given. A leading # is
+// dropped, so "#work" and "work" are the same tag.
+func ParseTags(input string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// HasTag reports whether the entry is tagged with tag, ignoring case
+func (e HistoryEntry) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AllTags returns every tag used in entries, sorted
+func AllTags(entries []HistoryEntry) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// mergeTags returns the tags of a followed by those of b it lacks
+func mergeTags(a, b []string) []string {
+	merged := append([]string(nil), a...)
+	for _, tag := range b {
+		found := false
+		for _, t := range merged {
+			found = found || t == tag
+		}
+		if !found {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// SetTags replaces the tags of the entry with the given ID; no tags
+// removes them all
+func (h *HistoryManager) SetTags(id string, tags []string) error {
+	if !h.enabled {
+		return fmt.Errorf("history is disabled")
+	}
+
+	now := time.Now()
+	return h.updateEntry(id, func(entry *HistoryEntry) {
+		entry.Tags = tags
+		if len(tags) == 0 {
+			entry.Tags = nil
+		}
+		entry.TaggedAt = &now
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/mshnjffr/passman/internal/config"
 	"github.com/mshnjffr/passman/internal/utils"
@@ -40,7 +41,7 @@ func main() {
 	// Test export
 	fmt.Println("\n3. Testing Export System...")
 	export := utils.NewExportManager()
-	tempFile := "/tmp/test_export.txt"
+	tempFile := filepath.Join(os.TempDir(), "test_export.txt")
 	
 	err := export.ExportSingle(
 		"test-password-123",
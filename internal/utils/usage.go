@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// UsageKind identifies how a history entry was used
+type UsageKind string
+
+const (
+	UsageCopied   UsageKind = "copied"
+	UsageRevealed UsageKind = "revealed"
+)
+
+// UsageStats summarises how history entries have been used
+type UsageStats struct {
+	Total     int
+	Copied    int // Entries copied at least once
+	Revealed  int // Entries revealed at least once
+	NeverUsed int // Entries never copied or revealed
+	Copies    int // Total number of copies
+}
+
+// RecordUsage records that the entry with the given ID was copied or
+// revealed. The audit trail is stored inside the encrypted history.
+func (h *HistoryManager) RecordUsage(id string, kind UsageKind) error {
+	if id == "" {
+		return fmt.Errorf("entry ID cannot be empty")
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range entries {
+		if entries[i].ID != id {
+			continue
+		}
+
+		switch kind {
+		case UsageCopied:
+			entries[i].CopyCount++
+			entries[i].LastCopiedAt = &now
+		case UsageRevealed:
+			entries[i].RevealCount++
+			entries[i].LastRevealedAt = &now
+		default:
+			return fmt.Errorf("unknown usage kind: %s", kind)
+		}
+		return h.saveHistory(entries)
+	}
+
+	return fmt.Errorf("history entry %s not found", id)
+}
+
+// recordReveals records that the entries with the given IDs were
+// revealed, at once, for secrets handed out in bulk. An ID listed twice
+// was revealed twice; IDs no longer in the history are skipped.
+func (h *HistoryManager) recordReveals(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	unlock, err := h.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := h.LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	revealed := make(map[string]int, len(ids))
+	for _, id := range ids {
+		revealed[id]++
+	}
+	now := time.Now()
+	for i := range entries {
+		if n := revealed[entries[i].ID]; n > 0 {
+			entries[i].RevealCount += n
+			entries[i].LastRevealedAt = &now
+		}
+	}
+	return h.saveHistory(entries)
+}
+
+// NeverUsed reports whether the entry was never copied or revealed
+func (e HistoryEntry) NeverUsed() bool {
+	return e.CopyCount == 0 && e.RevealCount == 0
+}
+
+// LastUsedAt returns the most recent copy or reveal time, or the zero time
+func (e HistoryEntry) LastUsedAt() time.Time {
+	var last time.Time
+	if e.LastCopiedAt != nil && e.LastCopiedAt.After(last) {
+		last = *e.LastCopiedAt
+	}
+	if e.LastRevealedAt != nil && e.LastRevealedAt.After(last) {
+		last = *e.LastRevealedAt
+	}
+	return last
+}
+
+// ComputeUsageStats summarises the usage audit trail of entries
+func ComputeUsageStats(entries []HistoryEntry) UsageStats {
+	stats := UsageStats{Total: len(entries)}
+	for _, entry := range entries {
+		if entry.CopyCount > 0 {
+			stats.Copied++
+		}
+		if entry.RevealCount > 0 {
+			stats.Revealed++
+		}
+		if entry.NeverUsed() {
+			stats.NeverUsed++
+		}
+		stats.Copies += entry.CopyCount
+	}
+	return stats
+}
+
+// CleanupCandidates returns entries that were never copied or revealed and
+// are older than minAge, as candidates for removal
+func CleanupCandidates(entries []HistoryEntry, minAge time.Duration) []HistoryEntry {
+	cutoff := time.Now().Add(-minAge)
+
+	var candidates []HistoryEntry
+	for _, entry := range entries {
+		if entry.NeverUsed() && entry.CreatedAt.Before(cutoff) {
+			candidates = append(candidates, entry)
+		}
+	}
+	return candidates
+}
@@ -2,8 +2,12 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"crypto/rand"
-	"embed"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -12,10 +16,10 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
-)
 
-//go:embed data/eff_large_wordlist.txt
-var embeddedWordlist embed.FS
+	"github.com/mshnjffr/passman/internal/config"
+	"github.com/mshnjffr/passman/internal/generator"
+)
 
 // WordlistManager handles EFF wordlist operations
 type WordlistManager struct {
@@ -49,27 +53,30 @@ func (w *WordlistManager) LoadWordlist() error {
 	return w.downloadAndCacheWordlist()
 }
 
-// loadEmbeddedWordlist loads the wordlist from embedded files
+// loadEmbeddedWordlist loads the EFF wordlist embedded in the generator package
 func (w *WordlistManager) loadEmbeddedWordlist() error {
-	file, err := embeddedWordlist.Open("data/eff_large_wordlist.txt")
-	if err != nil {
-		return fmt.Errorf("failed to open embedded wordlist: %w", err)
+	words := generator.GetEFFWordlist()
+	if len(words) == 0 {
+		return fmt.Errorf("embedded wordlist is empty")
 	}
-	defer file.Close()
 
-	return w.parseWordlist(file)
+	w.wordlist = words
+	return nil
 }
 
-// loadFromFile loads the wordlist from a file
+// loadFromFile loads the wordlist from a file, if it is the EFF list
+// unchanged
 func (w *WordlistManager) loadFromFile(filePath string) error {
-	file, err := os.Open(filePath)
+	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open wordlist file: %w", err)
 	}
-	defer file.Close()
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != EFFWordlistSHA256 {
+		return fmt.Errorf("cached wordlist %s does not match the pinned SHA-256; run passman wordlist update", filePath)
+	}
 
 	w.loadedFromFile = true
-	return w.parseWordlist(file)
+	return w.parseWordlist(bytes.NewReader(data))
 }
 
 // parseWordlist parses the wordlist from a reader
@@ -103,72 +110,148 @@ func (w *WordlistManager) parseWordlist(reader io.Reader) error {
 	return nil
 }
 
+// The EFF large wordlist and the SHA-256 of the published file. The list
+// has not changed since 2016; a download that doesn't match is rejected
+// rather than cached.
+const (
+	effWordlistURL    = "https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt"
+	EFFWordlistSHA256 = "addd35536511597a02fa0a9ff1e5284677b8883b83e986e43f15a3db996b903e"
+)
+
+// maxWordlistDownload limits how much of a download is read; the list is
+// about 106 KiB
+const maxWordlistDownload = 1 << 20
+
 // downloadAndCacheWordlist downloads the EFF wordlist and caches it
 func (w *WordlistManager) downloadAndCacheWordlist() error {
-	const effWordlistURL = "https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt"
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	data, err := downloadWordlist(ctx)
+	if err != nil {
+		return err
+	}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// Parse wordlist from response
+	if err := w.parseWordlist(bytes.NewReader(data)); err != nil {
+		return err
 	}
 
-	resp, err := client.Get(effWordlistURL)
+	// Don't fail if we can't cache; the list is loaded
+	_ = w.cacheWordlist(data)
+	return nil
+}
+
+// Update downloads the EFF wordlist and replaces the cached copy with
+// it. The loaded wordlist is left alone, so this can run in the
+// background while passphrases are generated.
+func (w *WordlistManager) Update(ctx context.Context) error {
+	data, err := downloadWordlist(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to download wordlist: %w", err)
+		return err
+	}
+	return w.cacheWordlist(data)
+}
+
+// downloadWordlist downloads the EFF wordlist and checks it against
+// EFFWordlistSHA256
+func downloadWordlist(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, effWordlistURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download wordlist: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download wordlist: HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to download wordlist: HTTP %d", resp.StatusCode)
 	}
-
-	// Parse wordlist from response
-	if err := w.parseWordlist(resp.Body); err != nil {
-		return err
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWordlistDownload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download wordlist: %w", err)
 	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != EFFWordlistSHA256 {
+		return nil, fmt.Errorf("downloaded wordlist has SHA-256 %x, not the pinned %s", sum, EFFWordlistSHA256)
+	}
+	return data, nil
+}
 
-	// Cache to file
-	if err := w.cacheWordlist(); err != nil {
-		// Don't fail if we can't cache, just log it
-		// In a real application, you'd use proper logging
-		return nil
+// RefreshIfStale updates the cached wordlist if there is none or it is
+// older than interval, and reports whether it did. An interval of 0
+// never updates it.
+func (w *WordlistManager) RefreshIfStale(ctx context.Context, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return false, nil
+	}
+	status, err := w.CacheStatus()
+	if err != nil {
+		return false, err
 	}
+	if status.Cached && status.Verified && time.Since(status.Updated) < interval {
+		return false, nil
+	}
+	if err := w.Update(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	return nil
+// WordlistCacheStatus describes the cached copy of the EFF wordlist
+type WordlistCacheStatus struct {
+	Path     string
+	Cached   bool      // There is a cached copy
+	Updated  time.Time // When it was last downloaded
+	Verified bool      // Its SHA-256 is the pinned one
 }
 
-// cacheWordlist saves the current wordlist to cache
-func (w *WordlistManager) cacheWordlist() error {
-	cachePath, err := w.getWordlistPath()
+// CacheStatus reports on the cached copy of the EFF wordlist
+func (w *WordlistManager) CacheStatus() (WordlistCacheStatus, error) {
+	path, err := w.getWordlistPath()
 	if err != nil {
-		return err
+		return WordlistCacheStatus{}, err
 	}
-
-	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
-		return err
+	status := WordlistCacheStatus{Path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return status, err
 	}
 
-	file, err := os.Create(cachePath)
+	sum := sha256.Sum256(data)
+	status.Cached = true
+	status.Updated = info.ModTime()
+	status.Verified = hex.EncodeToString(sum[:]) == EFFWordlistSHA256
+	return status, nil
+}
+
+// cacheWordlist saves a verified download as the cached copy
+func (w *WordlistManager) cacheWordlist(data []byte) error {
+	cachePath, err := w.getWordlistPath()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	for i, word := range w.wordlist {
-		// Write in EFF format
-		fmt.Fprintf(file, "%05d\t%s\n", i+1, word)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
 	}
-
-	return nil
+	return writeFileAtomic(cachePath, data)
 }
 
 // getWordlistPath returns the path for cached wordlist
 func (w *WordlistManager) getWordlistPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	cacheDir, err := config.GetCacheDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(homeDir, ".config", "passman", "eff_wordlist.txt"), nil
+	return filepath.Join(cacheDir, "eff_wordlist.txt"), nil
 }
 
 // GeneratePassphrase generates a memorable passphrase using EFF wordlist
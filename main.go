@@ -1,41 +1,63 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/mshnjffr/passman/internal/cli"
 	"github.com/mshnjffr/passman/internal/config"
 	"github.com/mshnjffr/passman/internal/generator"
+	"github.com/mshnjffr/passman/internal/stateless"
 	"github.com/mshnjffr/passman/internal/ui"
 	"github.com/mshnjffr/passman/internal/utils"
 )
 
 const (
 	appName    = "passman"
-	appVersion = "1.0.0"
+	appVersion = "1.1.0"
 )
 
 func main() {
-	// Handle command line arguments
+	// An insecure seed may be given before or after any command
+	args, err := applyInsecureSeed(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	os.Args = append(os.Args[:1], args...)
+
+	// So may a profile, which applies to the TUI and every command
+	args, err = applyProfile(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	os.Args = append(os.Args[:1], args...)
+
+	// Run a command, if one is given
 	if len(os.Args) > 1 {
-		switch os.Args[1] {
-		case "--help", "-h", "help":
-			showHelp()
-			return
-		case "--version", "-v", "version":
-			fmt.Printf("%s %s\n", appName, appVersion)
-			return
-		case "--test", "test":
-			runComponentTests()
-			return
-		case "--reset", "reset":
-			resetConfiguration()
-			return
-		}
+		app := newApp()
+		recordCommand(app, os.Args[1])
+		os.Exit(app.Run(os.Args[1], os.Args[2:]))
 	}
 
 	// Initialize logging
@@ -45,7 +67,13 @@ func main() {
 	cfg, err := config.Load()
 	if err != nil {
 		log.Printf("Failed to load config: %v", err)
-		fmt.Fprintf(os.Stderr, "Error: Failed to load configuration: %v\n", err)
+		printConfigError(err)
+		return
+	}
+
+	// Ask for a passphrase the config doesn't store before the TUI takes
+	// over the terminal
+	if cfg.HistoryEnabled && !resolvePassphrase(&cfg) {
 		return
 	}
 
@@ -57,16 +85,70 @@ func main() {
 		return
 	}
 
+	manager.RecordMetric(utils.MetricLaunch, "")
+
+	// Keep the cached wordlist within wordlist_update_interval_days, if set;
+	// by default passman makes no network request of its own
+	if cfg.WordlistUpdateInterval > 0 {
+		go refreshWordlist(manager.Wordlist, cfg.WordlistUpdateInterval)
+	}
+
+	// Apply the configured theme before the first render
+	if err := ui.SetTheme(cfg.Theme); err != nil {
+		log.Printf("Failed to apply theme: %v", err)
+	}
+
+	// Apply custom keybindings; conflicts fall back to the defaults
+	if err := ui.SetKeymap(cfg.Keys); err != nil {
+		log.Printf("Failed to apply keybindings: %v", err)
+	}
+
 	// Initialize the UI with manager
 	model := ui.NewModelWithManager(manager)
 
+	// Show what's new once after an upgrade
+	state, err := config.LoadState()
+	if err != nil {
+		log.Printf("Failed to load state: %v", err)
+	} else if state.LastSeenVersion != appVersion {
+		if state.IsNewVersion(appVersion) {
+			model = ui.NewWhatsNewModelWithManager(manager)
+		}
+		state.LastSeenVersion = appVersion
+		if err := state.Save(); err != nil {
+			log.Printf("Failed to save state: %v", err)
+		}
+	}
+
+	// Set up a new install instead of starting with built-in defaults
+	if config.IsFirstRun() {
+		model = ui.NewWizardModelWithManager(manager)
+	}
+
 	// Create and run the Bubble Tea program
 	program := tea.NewProgram(
-		model,
+		ui.WithConfigReload(model, manager),
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
 
+	// Reload the config when it is edited while the TUI runs
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go func() {
+		err := config.Watch(watchCtx, config.DefaultWatchInterval, func(cfg config.Config, err error) {
+			if err != nil {
+				log.Printf("Ignoring changed config: %v", err)
+				return
+			}
+			log.Println("Config file changed, reloading")
+			program.Send(ui.ConfigReloadedMsg{Config: cfg})
+		})
+		if err != nil && err != context.Canceled {
+			log.Printf("Config watch stopped: %v", err)
+		}
+	}()
+
 	// Run the program
 	if _, err := program.Run(); err != nil {
 		log.Printf("Error running program: %v", err)
@@ -77,128 +159,2428 @@ func main() {
 	log.Println("Application shutdown gracefully")
 }
 
-func showHelp() {
-	configDir := getConfigDir()
-	configFile := filepath.Join(configDir, "config.json")
+// newApp describes the command line: the global options, every command
+// and the sections of the help and the man page
+func newApp() *cli.App {
+	configFile, _ := config.GetConfigPath()
+	paths, _ := config.ResolvePaths()
 
-	fmt.Printf(`%s %s
-A beautiful, secure password generator with a stunning terminal UI
+	return &cli.App{
+		Name:    appName,
+		Version: appVersion,
+		Summary: "A beautiful, secure password generator with a stunning terminal UI",
+		Options: []cli.Option{
+			{Name: "--profile NAME", Usage: "Use a profile's config and history (or set PASSMAN_PROFILE); profiles are switched in the TUI with p on the menu"},
+			{Name: "--insecure-seed N", Usage: "INSECURE: make all output deterministic for test fixtures; requires PASSMAN_ALLOW_INSECURE_SEED=1"},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:    "version",
+				Aliases: []string{"--version", "-version", "-v"},
+				Summary: "Show version information",
+				Setup: func(flags *flag.FlagSet) cli.RunFunc {
+					return func(args []string) int {
+						fmt.Printf("%s %s\n", appName, appVersion)
+						return 0
+					}
+				},
+			},
+			{
+				Name:    "test",
+				Aliases: []string{"--test", "-test"},
+				Summary: "Check the config, random source, generator output distribution, history encryption, clipboard and wordlists; exits 1 if any check fails",
+				Setup: func(flags *flag.FlagSet) cli.RunFunc {
+					jsonOutput := flags.Bool("json", false, "print the report as JSON")
+					return func(args []string) int {
+						return runComponentTests(*jsonOutput)
+					}
+				},
+			},
+			{
+				Name:    "reset",
+				Aliases: []string{"--reset", "-reset"},
+				Summary: "Reset configuration to defaults",
+				Setup: func(flags *flag.FlagSet) cli.RunFunc {
+					return func(args []string) int {
+						resetConfiguration()
+						return 0
+					}
+				},
+			},
+			{
+				Name:    "key",
+				Summary: "Print a random N-byte key (default 32 bytes, hex)",
+				Setup:   keyCommand,
+			},
+			{
+				Name:    "generate",
+				Summary: "Generate one password with the config's defaults and print it, or copy it with -copy; -hidden never shows it; it is saved to the history unless -no-history",
+				Setup:   generateCommand,
+			},
+			{
+				Name:        "derive",
+				Args:        "SITE",
+				Summary:     "Derive a site's password from a master passphrase, the site, a login and a counter, storing nothing",
+				Description: "Stateless mode: the password is computed from the master passphrase, the site, -login, -counter, -length and -charsets with Argon2id and HKDF-SHA256, so the same inputs give the same password on any machine and nothing is saved, not even to the history. Raise -counter to rotate a password.\n\nA mistyped passphrase gives a different password without any error, so the short check code printed with each password should always be the same for your passphrase. Derived passwords are unrelated to the random generators and to the history passphrase.",
+				Setup:       deriveCommand,
+			},
+			{
+				Name:    "fsck",
+				Summary: "Check the encrypted history for damaged records; -repair quarantines them and keeps the rest",
+				Setup:   fsckCommand,
+			},
+			{
+				Name:    "verify",
+				Summary: "Check the history's hash chain, which shows entries edited, inserted or removed outside passman; exits 1 if it is broken",
+				Setup:   verifyCommand,
+			},
+			{
+				Name:    "export",
+				Args:    "[FILE]",
+				Summary: "Export the password history; -unique keeps each password once with first-seen date and merged labels; several formats are written at once",
+				Setup:   exportCommand,
+			},
+			{
+				Name:    "backup",
+				Summary: "Write the whole history to one file encrypted with a backup passphrase, to move it to another machine",
+				Setup:   backupCommand,
+			},
+			{
+				Name:    "restore",
+				Args:    "FILE",
+				Summary: "Merge a backup into the history (or replace it with -replace), after copying the current history aside",
+				Setup:   restoreCommand,
+			},
+			{
+				Name:        "history",
+				Args:        "list | search QUERY | show ID | delete ID | clear",
+				Summary:     "List, search, show and delete history entries from the shell, or clear the whole history",
+				Description: "Work on the encrypted history from the shell, asking for the history passphrase if it is not stored. Entries are given by ID or description.\n\nlist prints the newest entries and search those whose description, site, username, type or tag contains QUERY, both without passwords unless -json. show prints one entry with its password and records the reveal. delete removes one entry after asking, or straight away with -yes.\n\nclear deletes every entry once the word clear is typed, or straight away with -yes. With backup_before_clear set (or -backup) the history is first written to a backup in the export directory, encrypted with the history passphrase; restore it with 'passman restore FILE' and that passphrase.",
+				Setup:       historyCommand,
+			},
+			{
+				Name:    "sync",
+				Summary: "Merge the history with the encrypted copy on sync_remote (WebDAV, S3 or git) and push the result; only ciphertext leaves the machine",
+				Setup:   syncCommand,
+			},
+			{
+				Name:    "migrate",
+				Summary: "Re-encrypt the history files with another key derivation function or key, after backing them up and verifying the result",
+				Setup:   migrateCommand,
+			},
+			{
+				Name:    "profile",
+				Args:    "[list | create NAME]",
+				Summary: "List profiles, or create one from the default profile's settings with its own export path and history",
+				Setup:   profileCommand,
+			},
+			{
+				Name:    "forget",
+				Summary: "Forget the cached history passphrase (with history_passphrase: prompt), or with -keychain remove it from the OS keychain",
+				Setup:   forgetCommand,
+			},
+			{
+				Name:        "hardware-key",
+				Args:        "[status | enroll yubikey|fido2]",
+				Summary:     "Wrap the history passphrase with a YubiKey or FIDO2 token, so opening the history needs a touch",
+				Description: "enroll wraps the history passphrase in use with the token and sets history_passphrase to hardware; from then on passman asks the token to unwrap it on every start, which waits for a touch. A YubiKey needs an OTP slot set up for HMAC-SHA1 challenge-response (ykman otp chalresp --touch --generate 2) and ykchalresp; a FIDO2 token gets a new credential with the hmac-secret extension, through fido2-cred and fido2-assert of libfido2.\n\nThe wrapped passphrase is kept in the data directory's hardware-key.json. Without the token the history only opens with the passphrase itself, so keep a copy of it somewhere safe.",
+				Setup:       hardwareKeyCommand,
+			},
+			{
+				Name:    "agent",
+				Summary: "Serve generate, analyze and history requests to local tools on a user-only Unix socket, one JSON object per line",
+				Setup:   agentCommand,
+			},
+			{
+				Name:        "serve",
+				Summary:     "Serve generate, analyze and history requests as a REST API on 127.0.0.1:7845, authenticated with a token kept in the data directory",
+				Description: "For integrations such as launcher scripts and editor plugins that speak HTTP. Every request needs the header \"Authorization: Bearer TOKEN\", with the token created on first use in the data directory's api-token file (-token prints it, -new-token replaces it).\n\nGET /v1/ping, POST /v1/generate with the params of the agent's generate, POST /v1/analyze with {\"password\": ...} and GET /v1/history?query=...&limit=N answer {\"result\": ...}, or {\"error\": ...} with a 4xx status. The history passphrase is asked for once when the server starts.\n\nOnly loopback addresses are accepted by -listen unless -allow-remote is given; the API is plain HTTP, so the token and secrets would cross the network unencrypted.",
+				Setup:       serveCommand,
+			},
+			{
+				Name:        "secret-service",
+				Summary:     "Expose the history entries tagged secret-service (or all with -all) on the Linux session bus as a Secret Service, so libsecret applications can look them up",
+				Description: "Takes the D-Bus name org.freedesktop.secrets, as gnome-keyring and KeePassXC do, and serves the entries as one read-only, always unlocked collection, also the default one. Each entry is an item labelled with its description, with the attributes id, site, username and type to search by, e.g. secret-tool lookup site github.com; rotated entries are left out. Every secret fetched is recorded as a reveal in the entry's audit trail.\n\nAny application of your session can read the exposed secrets while it runs, as with an unlocked keyring, so only tagged entries are exposed unless -all is given. Another provider already owning the name must be stopped first, or replaced with -replace if it allows that. The history passphrase is asked for once when it starts.",
+				Setup:       secretServiceCommand,
+			},
+			{
+				Name:        "native-host",
+				Args:        "[ORIGIN]",
+				Summary:     "Serve a browser extension over Chrome and Firefox native messaging: list the presets and generate passwords with them; -install registers passman with the browser",
+				Description: "The browser starts passman native-host when its extension connects and exchanges length-prefixed JSON messages with it on standard input and output, with the requests of the agent: ping, presets and generate. generate takes a preset (or a type, using the config's defaults) and can save the password to the history with the extension's site and username; the history itself is never readable by the extension. With history_passphrase set to prompt, saving needs the passphrase cached by an earlier passman run.\n\n-install chrome, chromium or firefox with -extension ID writes a launcher for the active profile to the data directory and the browser's manifest allowing only that extension to start it. On Windows the manifest is registered in HKEY_CURRENT_USER.",
+				Setup:       nativeHostCommand,
+			},
+			{
+				Name:    "sshkey",
+				Summary: "Generate a passphrase, create an SSH key pair protected by it with ssh-keygen (or print the command) and save the passphrase and key metadata to the history",
+				Setup:   sshKeyCommand,
+			},
+			{
+				Name:    "run",
+				Args:    "JOBFILE",
+				Summary: "Run the generation tasks of a YAML or JSON job file; exits 1 if any task failed, 2 if the job file is invalid",
+				Setup:   runCommand,
+			},
+			{
+				Name:    "bench",
+				Summary: "Measure how many secrets a second each generator makes at several sizes and store the rates, which dry runs use to estimate generation times",
+				Setup:   benchCommand,
+			},
+			{
+				Name:    "stats",
+				Summary: "Show the usage counts kept on this machine while enable_telemetry is on: commands run, secrets generated and copies; nothing is ever sent",
+				Setup:   statsCommand,
+			},
+			{
+				Name:        "wordlist",
+				Args:        "[status | update]",
+				Summary:     "Show the age and checksum of the cached EFF wordlist, or download it again, checked against its pinned SHA-256",
+				Description: "With wordlist_update_interval_days set (default 0, never), the TUI checks the cached copy when it starts and downloads it again in the background once it is older than that. A download whose SHA-256 differs from the one pinned in passman is rejected and the cached copy kept. Passphrases come from the wordlist built into passman either way; the cached copy is used only if that one is missing.",
+				Setup:       wordlistCommand,
+			},
+			{
+				Name:    "pick",
+				Summary: "Fuzzy-find a history entry by description, site, username or tag and copy its password (or print it with -print); bind it to a hotkey in a terminal window",
+				Setup:   pickCommand,
+			},
+		},
+		Sections: []cli.Section{
+			{Title: "Features", Body: `🔐 Cryptographically secure password generation
+🎨 Beautiful neon-themed terminal interface
+📊 Real-time strength visualization
+🔄 Animated generation with spinners
+📋 Instant clipboard integration
+💾 Export to multiple formats
+📈 Advanced security analysis`},
+			{Title: "Keyboard Shortcuts", Body: `Tab/Shift+Tab    Navigate between components
+g                Generate password
+c                Copy to clipboard
+s                Save/Export
+q, Ctrl+C        Quit`},
+			{Title: "Configuration", Body: fmt.Sprintf(`Profile: %s
+Config directory: %s
+Config file: %s
+Data directory: %s
+Cache directory: %s
+Log directory: %s
+The config file may be config.json, config.yaml or config.toml;
+unknown keys and invalid values are reported with their line
+Set PASSMAN_CONFIG_DIR to keep all of these in one directory`,
+				config.ActiveProfile(), getConfigDir(), configFile, paths.Data, paths.Cache, paths.Logs),
+				Man: `Config: $XDG_CONFIG_HOME/passman (~/.config/passman)
+Data: $XDG_DATA_HOME/passman (~/.local/share/passman)
+Cache: $XDG_CACHE_HOME/passman (~/.cache/passman)
+Logs: $XDG_STATE_HOME/passman/logs (~/.local/state/passman/logs)
+On macOS and Windows the platform's application directories are used;
+'passman help' shows the directories of this machine.
+The config file may be config.json, config.yaml or config.toml;
+unknown keys and invalid values are reported with their line
+Set PASSMAN_CONFIG_DIR to keep all of these in one directory`},
+			{Title: "Examples", Body: `passman                  Start the beautiful TUI
+passman test             Test system components
+passman reset            Reset configuration
+passman key -bytes 64 -encoding base64
+                         Generate a 512-bit HMAC secret
+passman help export      Describe the export command
+passman man > passman.1  Install the man page
 
-USAGE:
-  ./%s [options]
+For more information, visit: https://github.com/mshnjffr/passman`},
+		},
+	}
+}
+
+// runComponentTests runs the diagnostics and prints their report, as a
+// table or as JSON for bug reports and monitoring
+func runComponentTests(jsonOutput bool) int {
+	report := utils.RunDiagnostics(context.Background())
+	if jsonOutput {
+		if code := printJSON(report); code != 0 {
+			return code
+		}
+	} else {
+		fmt.Print("Testing system components...\n\n")
+		marks := map[string]string{
+			utils.DiagnosticPass: "✓ PASS",
+			utils.DiagnosticWarn: "⚠ WARN",
+			utils.DiagnosticFail: "✗ FAIL",
+			utils.DiagnosticSkip: "- SKIP",
+		}
+		for _, check := range report.Checks {
+			line := fmt.Sprintf("%-24s %s", check.Name+":", marks[check.Status])
+			if check.Detail != "" {
+				line += ": " + check.Detail
+			}
+			fmt.Println(line)
+		}
+		if report.Failed == 0 {
+			fmt.Println("\nAll components tested successfully! 🎉")
+		} else {
+			fmt.Printf("\n%d of %d checks failed\n", report.Failed, len(report.Checks))
+		}
+	}
 
-OPTIONS:
-  -help, -h        Show this help information
-  -version, -v     Show version information
-  -test            Test system components and exit
-  -reset           Reset configuration to defaults
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
 
-FEATURES:
-  🔐 Cryptographically secure password generation
-  🎨 Beautiful neon-themed terminal interface
-  📊 Real-time strength visualization
-  🔄 Animated generation with spinners
-  📋 Instant clipboard integration
-  💾 Export to multiple formats
-  📈 Advanced security analysis
+// applyInsecureSeed removes --insecure-seed N from args and makes the
+// generators deterministic. It is refused unless the opt-in environment
+// variable is set, so it cannot be enabled by accident.
+func applyInsecureSeed(args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--insecure-seed" && name != "-insecure-seed" {
+			rest = append(rest, args[i])
+			continue
+		}
 
-KEYBOARD SHORTCUTS:
-  Tab/Shift+Tab    Navigate between components
-  g                Generate password
-  c                Copy to clipboard
-  s                Save/Export
-  q, Ctrl+C        Quit
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s needs a seed value", name)
+			}
+			i++
+			value = args[i]
+		}
 
-CONFIGURATION:
-  Config directory: %s
-  Config file: %s
+		seed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid insecure seed %q: must be a non-negative integer", value)
+		}
 
-EXAMPLES:
-  ./%s              Start the beautiful TUI
-  ./%s --test       Test system components
-  ./%s --reset      Reset configuration
+		if os.Getenv(generator.InsecureSeedEnv) != "1" {
+			return nil, fmt.Errorf("%s makes every password predictable; set %s=1 to confirm this is for test fixtures",
+				name, generator.InsecureSeedEnv)
+		}
 
-For more information, visit: https://github.com/mshnjffr/passman
-`, appName, appVersion, appName, configDir, configFile, appName, appName, appName)
+		generator.SetInsecureSeed(seed)
+		fmt.Fprintf(os.Stderr, "WARNING: insecure seed %d in use. Output is predictable and must not be used as real secrets.\n", seed)
+	}
+	return rest, nil
 }
 
-func runComponentTests() {
-	fmt.Println("Testing system components...\n")
+// applyProfile removes --profile NAME from args and selects that profile,
+// falling back to PASSMAN_PROFILE
+func applyProfile(args []string) ([]string, error) {
+	var rest []string
+	profile := os.Getenv(config.ProfileEnv)
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--profile" && name != "-profile" {
+			rest = append(rest, args[i])
+			continue
+		}
 
-	// Test config loading
-	fmt.Print("config:      ")
-	if _, err := config.Load(); err != nil {
-		fmt.Printf("✗ FAIL: %v\n", err)
-	} else {
-		fmt.Println("✓ PASS")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s needs a profile name", name)
+			}
+			i++
+			value = args[i]
+		}
+		profile = value
 	}
 
-	// Test generators
-	fmt.Print("generators:  ")
-	randomGen := generator.NewRandomGenerator(12, generator.Lowercase, generator.Uppercase)
-	memorableGen := generator.NewMemorableGenerator(3, "-", generator.GetEFFWordlist())
-	pinGen := generator.NewPINGenerator(4)
-	
-	ctx := context.Background()
-	if _, err := randomGen.Generate(ctx); err != nil {
-		fmt.Printf("✗ FAIL: random generator: %v\n", err)
-	} else if _, err := memorableGen.Generate(ctx); err != nil {
-		fmt.Printf("✗ FAIL: memorable generator: %v\n", err)
-	} else if _, err := pinGen.Generate(ctx); err != nil {
-		fmt.Printf("✗ FAIL: PIN generator: %v\n", err)
-	} else {
-		fmt.Println("✓ PASS")
+	if err := config.SetProfile(profile); err != nil {
+		return nil, err
 	}
+	return rest, nil
+}
 
-	// Test utilities
-	fmt.Print("utilities:   ")
-	cfg, _ := config.Load()
-	if _, err := utils.NewManager(&cfg); err != nil {
-		fmt.Printf("✗ FAIL: %v\n", err)
-	} else {
-		fmt.Println("✓ PASS")
+// profileCommand lists or creates profiles
+func profileCommand(flags *flag.FlagSet) cli.RunFunc {
+	return func(args []string) int {
+		if len(args) == 0 || args[0] == "list" {
+			names, err := config.ProfileNames()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			for _, name := range names {
+				marker := "  "
+				if name == config.ActiveProfile() {
+					marker = "* "
+				}
+				fmt.Println(marker + name)
+			}
+			return 0
+		}
+
+		if args[0] == "create" && len(args) == 2 {
+			if err := config.CreateProfile(args[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			fmt.Printf("Created profile %s; use it with --profile %s\n", args[1], args[1])
+			return 0
+		}
+
+		fmt.Fprintln(os.Stderr, "Usage: passman profile [list | create NAME]")
+		return 2
+	}
+}
+
+// hardwareKeyCommand shows or enrolls the hardware token the history
+// passphrase is wrapped with
+func hardwareKeyCommand(flags *flag.FlagSet) cli.RunFunc {
+	slot := flags.Int("slot", 2, "YubiKey OTP `slot` set up for challenge-response, 1 or 2")
+	device := flags.String("device", "", "FIDO2 `device` path, as fido2-token -L lists them (default the first one found)")
+
+	return func(args []string) int {
+		if len(args) == 0 || args[0] == "status" && len(args) == 1 {
+			key, err := utils.LoadHardwareKey()
+			if errors.Is(err, utils.ErrNoHardwareKey) {
+				fmt.Println("No hardware key enrolled")
+				return 0
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			fmt.Printf("History passphrase wrapped with a %s\n", key)
+			return 0
+		}
+		if args[0] != "enroll" || len(args) != 2 || args[1] != utils.HardwareYubiKey && args[1] != utils.HardwareFIDO2 {
+			fmt.Fprintln(os.Stderr, "Usage: passman hardware-key [-device DEVICE] [-slot SLOT] [status | enroll yubikey|fido2]")
+			return 2
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+		if cfg.HistoryEncryptionKey == config.Default().HistoryEncryptionKey {
+			fmt.Fprintln(os.Stderr, "Error: the history uses the built-in default key; choose a passphrase with passman migrate -new-key-env first")
+			return 1
+		}
+
+		if args[1] == utils.HardwareFIDO2 {
+			fmt.Fprintln(os.Stderr, "Touch your FIDO2 token twice: to create a credential, then to wrap the passphrase...")
+		} else {
+			fmt.Fprintln(os.Stderr, "Touch your YubiKey to wrap the passphrase...")
+		}
+		key, err := utils.EnrollHardwareKey(args[1], *slot, *device, cfg.HistoryEncryptionKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		// Check the token gives the same answer again before relying on it
+		fmt.Fprintln(os.Stderr, "Touch it once more to check the passphrase unwraps...")
+		if unwrapped, err := key.Unwrap(); err != nil || unwrapped != cfg.HistoryEncryptionKey {
+			fmt.Fprintf(os.Stderr, "Error: the passphrase does not unwrap again (%v); history_passphrase is unchanged\n", err)
+			return 1
+		}
+
+		previous := cfg.HistoryPassphrase
+		cfg.HistoryPassphrase = config.PassphraseHardware
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Wrapped the history passphrase of profile %s with a %s\n", config.ActiveProfile(), key)
+		if previous == config.PassphraseKeychain {
+			fmt.Println("It is still in the OS keychain; passman forget -keychain removes it from there.")
+		}
+		return 0
 	}
+}
 
-	fmt.Println("\nAll components tested successfully! 🎉")
+// rewrapHardwareKey wraps a new history passphrase with the enrolled
+// hardware token
+func rewrapHardwareKey(passphrase string) error {
+	key, err := utils.LoadHardwareKey()
+	if err != nil {
+		return err
+	}
+	if err := key.Wrap(passphrase); err != nil {
+		return err
+	}
+	return key.Save()
 }
 
-func resetConfiguration() {
-	configDir := getConfigDir()
-	configFile := filepath.Join(configDir, "config.json")
-	
-	if err := os.Remove(configFile); err != nil && !os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error removing config file: %v\n", err)
-		os.Exit(1)
+// agentCommand serves generate, analyze and history requests on a Unix
+// socket until interrupted
+func agentCommand(flags *flag.FlagSet) cli.RunFunc {
+	socket := flags.String("socket", "", "Unix `socket` to listen on (default $XDG_RUNTIME_DIR/passman/PROFILE.sock, or agent.sock in the data directory)")
+
+	return func(args []string) int {
+		if *socket == "" {
+			path, err := utils.AgentSocketPath()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			*socket = path
+		}
+
+		agent, ok := loadAgent()
+		if !ok {
+			return 1
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		listener, err := utils.ListenAgent(*socket)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		fmt.Fprintf(os.Stderr, "passman agent listening on %s (Ctrl+C to stop)\n", *socket)
+		if err := agent.Serve(ctx, listener); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
 	}
-	
-	fmt.Printf("Configuration reset to defaults.\nConfig file: %s\n", configFile)
 }
 
-func initLogging() {
-	configDir := getConfigDir()
-	logDir := filepath.Join(configDir, "logs")
-	
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		// Fallback to stderr if we can't create log directory
-		return
+// secretServiceCommand serves history entries on the session bus
+// through the Secret Service API until interrupted
+func secretServiceCommand(flags *flag.FlagSet) cli.RunFunc {
+	tag := flags.String("tag", utils.SecretServiceTag, "expose the entries with this `tag`")
+	all := flags.Bool("all", false, "expose every entry, not only the tagged ones")
+	replace := flags.Bool("replace", false, "take over from a Secret Service provider that allows it")
+
+	return func(args []string) int {
+		if len(args) != 0 {
+			fmt.Fprintln(os.Stderr, "Usage: passman secret-service [-tag TAG] [-all] [-replace]")
+			return 2
+		}
+		if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+			fmt.Fprintln(os.Stderr, "Error: the Secret Service is a D-Bus API of Linux and BSD desktops")
+			return 1
+		}
+
+		agent, ok := loadAgent()
+		if !ok {
+			return 1
+		}
+		if *all {
+			*tag = ""
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		service := utils.NewSecretService(agent, *tag)
+		service.OnConnected(func(items int) {
+			scope := "every entry"
+			if *tag != "" {
+				scope = "the entries tagged " + *tag
+			}
+			fmt.Fprintf(os.Stderr, "passman is the Secret Service of this session, exposing %s (%d now; Ctrl+C to stop)\n", scope, items)
+		})
+		if err := service.Serve(ctx, *replace); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
 	}
-	
-	logFile := filepath.Join(logDir, "app.log")
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+}
+
+// nativeHostCommand serves a browser extension on standard input and
+// output, or installs the manifest letting the browser start it
+func nativeHostCommand(flags *flag.FlagSet) cli.RunFunc {
+	install := flags.String("install", "", "register passman with `browser`: chrome, chromium or firefox")
+	extension := flags.String("extension", "", "`ID` of the extension allowed to connect, required with -install")
+
+	return func(args []string) int {
+		if *install == "" {
+			// The browser passes the extension's origin, or on Firefox the
+			// manifest path and extension ID; both are ignored
+			if term.IsTerminal(os.Stdin.Fd()) {
+				fmt.Fprintln(os.Stderr, "passman native-host is started by the browser; register it with:")
+				fmt.Fprintln(os.Stderr, "  passman native-host -install chrome|chromium|firefox -extension ID")
+				return 2
+			}
+			host := utils.NewNativeHost(appVersion)
+			if err := host.Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return 0
+		}
+
+		if len(args) != 0 || *extension == "" {
+			fmt.Fprintln(os.Stderr, "Usage: passman native-host -install BROWSER -extension ID")
+			return 2
+		}
+		executable, err := os.Executable()
+		if err == nil {
+			executable, err = filepath.Abs(executable)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot find the passman executable: %v\n", err)
+			return 1
+		}
+
+		path, err := utils.InstallNativeHost(*install, *extension, executable)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Installed %s for %s (profile %s)\n", path, *install, config.ActiveProfile())
+		fmt.Println("Restart the browser if the extension cannot connect yet")
+		return 0
+	}
+}
+
+// loadAgent creates the agent answering requests with the config and
+// history of the active profile, asking for the history passphrase once,
+// up front. It prints any error.
+func loadAgent() (*utils.Agent, bool) {
+	cfg, err := config.Load()
 	if err != nil {
-		// Fallback to stderr if we can't open log file
-		return
+		printConfigError(err)
+		return nil, false
 	}
-	
-	log.SetOutput(file)
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Printf("Application started - %s %s", appName, appVersion)
+	if cfg.HistoryEnabled && !resolvePassphrase(&cfg) {
+		return nil, false
+	}
+
+	history := utils.NewHistoryManager(cfg.HistoryEnabled, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+	history.SetKDF(cfg.HistoryKDF)
+	history.SetRetention(utils.RetentionFromConfig(&cfg))
+
+	agent := utils.NewAgent(history, appVersion)
+	if err := agent.SetPolicy(utils.AgentPolicyFromConfig(&cfg)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, false
+	}
+	if err := agent.SetCrackAttacker(cfg.CrackAttacker); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, false
+	}
+	return agent, true
+}
+
+// serveCommand serves the agent's requests as a REST API over HTTP,
+// authenticated with the API token
+func serveCommand(flags *flag.FlagSet) cli.RunFunc {
+	listen := flags.String("listen", utils.DefaultAPIAddress, "`address` to listen on, host:port")
+	allowRemote := flags.Bool("allow-remote", false, "allow a -listen address other machines can reach; the API is plain HTTP")
+	newToken := flags.Bool("new-token", false, "replace the API token, locking out clients using the old one")
+	showToken := flags.Bool("token", false, "print the API token and exit")
+
+	return func(args []string) int {
+		if len(args) != 0 {
+			fmt.Fprintln(os.Stderr, "Usage: passman serve [-listen ADDRESS] [-allow-remote] [-new-token] [-token]")
+			return 2
+		}
+		if !utils.IsLoopbackAddress(*listen) && !*allowRemote {
+			fmt.Fprintf(os.Stderr, "Error: %s is reachable from other machines; listen on 127.0.0.1 or pass -allow-remote\n", *listen)
+			return 2
+		}
+
+		token, created, err := utils.LoadAPIToken(*newToken)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: API token: %v\n", err)
+			return 1
+		}
+		if *showToken {
+			fmt.Println(token)
+			return 0
+		}
+
+		agent, ok := loadAgent()
+		if !ok {
+			return 1
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		listener, err := net.Listen("tcp", *listen)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		server := &http.Server{
+			Handler:           utils.NewAPIServer(agent, token),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		// Requests still answered after Serve returns may reveal entries too
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+		defer func() {
+			if err := agent.FlushReveals(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}()
+
+		tokenPath, _ := utils.APITokenPath()
+		if created {
+			fmt.Fprintf(os.Stderr, "Created the API token in %s\n", tokenPath)
+		}
+		if !utils.IsLoopbackAddress(*listen) {
+			fmt.Fprintln(os.Stderr, "Warning: the API token and secrets cross the network unencrypted")
+		}
+		fmt.Fprintf(os.Stderr, "passman serve listening on http://%s (Ctrl+C to stop)\n", listener.Addr())
+		fmt.Fprintf(os.Stderr, "Send \"Authorization: Bearer $(cat %s)\" with each request\n", tokenPath)
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		<-stopped
+		return 0
+	}
+}
+
+// sshKeyCommand generates a passphrase, creates an SSH key pair protected
+// by it with ssh-keygen and records the passphrase, with the key's
+// metadata, in the history. With -print, or without ssh-keygen, it prints
+// the command to run instead.
+func sshKeyCommand(flags *flag.FlagSet) cli.RunFunc {
+	keyType := flags.String("type", utils.SSHKeyEd25519, "key `type`: ed25519, ecdsa or rsa")
+	bits := flags.Int("bits", 0, "key size in `bits` for ecdsa and rsa (default: ssh-keygen's)")
+	path := flags.String("f", "", "private key `file` (default ~/.ssh/id_TYPE)")
+	comment := flags.String("comment", "", "key `comment` (default user@host)")
+	words := flags.Int("words", 0, "passphrase `words` (default: default_passphrase_words from the config)")
+	printOnly := flags.Bool("print", false, "print the ssh-keygen command instead of running it")
+
+	return func(args []string) int {
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		if cfg.HistoryEnabled && !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		opts := utils.SSHKeyOptions{Type: *keyType, Bits: *bits, Comment: *comment, Path: *path}
+		if opts.Path == "" {
+			if opts.Path, err = utils.DefaultSSHKeyPath(opts.Type); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+		if opts.Comment == "" {
+			user := os.Getenv("USER")
+			if user == "" {
+				user = os.Getenv("USERNAME")
+			}
+			host, _ := os.Hostname()
+			opts.Comment = user + "@" + host
+		}
+		if err := opts.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+
+		wordCount := *words
+		if wordCount == 0 {
+			wordCount = cfg.DefaultPassphraseWords
+		}
+		wordlist, err := generator.GetBundledWordlist(generator.DefaultWordlistID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		gen := generator.NewMemorableGenerator(wordCount, cfg.DefaultPassphraseSeparator, wordlist)
+		if err := gen.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		passphrase, err := gen.Generate(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		printEntropyWarning(generator.CheckEntropy(gen))
+
+		fingerprint := ""
+		if !*printOnly && utils.SSHKeygenAvailable() {
+			fingerprint, err = utils.CreateSSHKey(context.Background(), opts, passphrase)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+
+		if cfg.HistoryEnabled {
+			history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+			history.SetKDF(cfg.HistoryKDF)
+			history.SetRetention(utils.RetentionFromConfig(&cfg))
+			entry := utils.HistoryEntry{
+				Password:    passphrase,
+				Length:      len([]rune(passphrase)),
+				Type:        "memorable",
+				Settings:    fmt.Sprintf("ssh-key %s, %d words", opts.Type, wordCount),
+				Description: "SSH key " + opts.Path,
+				SSHKey:      opts.Info(fingerprint),
+			}
+			if err := history.AddEntry(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save to history: %v\n", err)
+			}
+		}
+
+		if fingerprint == "" {
+			if !*printOnly {
+				fmt.Fprintln(os.Stderr, "passman cannot run ssh-keygen here; run it yourself.")
+			}
+			fmt.Fprintln(os.Stderr, "Run this and enter the passphrase below when ssh-keygen asks for it:")
+			fmt.Fprintf(os.Stderr, "  %s\n", opts.Command())
+		} else {
+			fmt.Fprintf(os.Stderr, "Created %s and %s.pub (%s)\n", opts.Path, opts.Path, fingerprint)
+		}
+		if cfg.HistoryEnabled {
+			fmt.Fprintln(os.Stderr, "The passphrase is saved in the history.")
+		}
+		fmt.Println(passphrase)
+		return 0
+	}
+}
+
+// forgetCommand removes the cached history passphrase of the active
+// profile, or the one in the OS keychain
+func forgetCommand(flags *flag.FlagSet) cli.RunFunc {
+	keychain := flags.Bool("keychain", false, "remove the passphrase from the OS keychain; the next run asks for it again")
+	return func(args []string) int {
+		if *keychain {
+			if err := utils.DeleteKeychainPassphrase(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			fmt.Printf("Removed the history passphrase of profile %s from the keychain\n", config.ActiveProfile())
+			return 0
+		}
+		if err := utils.ForgetPassphrase(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Forgot the cached history passphrase of profile %s\n", config.ActiveProfile())
+		return 0
+	}
+}
+
+// resolvePassphrase fills in a history passphrase the config doesn't
+// store, from the cache or by asking, and prints any error
+func resolvePassphrase(cfg *config.Config) bool {
+	if cfg.HistoryPassphrase == config.PassphraseHardware && cfg.HistoryEncryptionKey == "" {
+		fmt.Fprintln(os.Stderr, "Touch your hardware key to open the history...")
+	}
+	if err := utils.ResolveHistoryPassphrase(cfg, promptPassphrase); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// promptPassphrase reads the history passphrase from the terminal without
+// echoing it
+func promptPassphrase(confirm bool) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("the history passphrase must be entered, but there is no terminal to ask for it on")
+	}
+
+	passphrase, err := readSecret(fmt.Sprintf("History passphrase (profile %s): ", config.ActiveProfile()))
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		again, err := readSecret("No history yet; repeat the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("the passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
+
+// readSecret prompts on stderr and reads a line from the terminal without
+// echoing it
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return string(secret), err
+}
+
+// backupPassphrase reads the backup passphrase from the environment
+// variable env, or asks for it on the terminal, twice when confirm is set
+func backupPassphrase(env string, confirm bool) (string, error) {
+	if env != "" {
+		// Read from the environment so the passphrase never appears in the process list
+		passphrase := os.Getenv(env)
+		if passphrase == "" {
+			return "", fmt.Errorf("%s is not set", env)
+		}
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("no terminal to ask for the backup passphrase on; use -passphrase-env")
+	}
+	passphrase, err := readSecret("Backup passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("the backup passphrase cannot be empty")
+	}
+	if confirm {
+		again, err := readSecret("Repeat the backup passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("the passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
+
+// keyCommand prints a raw random key
+func keyCommand(flags *flag.FlagSet) cli.RunFunc {
+	size := flags.Int("bytes", generator.DefaultKeyBytes, "key size in `bytes`")
+	encodingName := flags.String("encoding", generator.KeyHex.String(), "output `encoding`: hex, base64 or base64url")
+
+	return func(args []string) int {
+		if *size <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: -bytes must be positive")
+			return 2
+		}
+		encoding, err := generator.ParseKeyEncoding(*encodingName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+
+		gen := generator.NewKeyGenerator(*size, encoding)
+		key, err := gen.Generate(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		printEntropyWarning(generator.CheckEntropy(gen))
+		fmt.Println(key)
+		return 0
+	}
+}
+
+// generateCommand generates one secret with the config's defaults, prints
+// it (or copies it with -copy, or writes it to -fd) and records it in the
+// history like the TUI does, so scripts and hotkeys need no screen. With
+// -hidden the password never reaches the terminal.
+func generateCommand(flags *flag.FlagSet) cli.RunFunc {
+	genType := flags.String("type", "", "generator `type`: "+generator.NamesText()+" (default: default_generator from the config)")
+	presetName := flags.String("preset", "", "use the settings of the saved preset `name`; other flags override them")
+	length := flags.Int("length", 0, "password or PIN `length` (default: from the config)")
+	words := flags.Int("words", 0, "passphrase `words` (default: default_passphrase_words from the config)")
+	separator := flags.String("separator", "", "passphrase word or PIN group `separator`, none to join the words (default: default_passphrase_separator or default_pin_separator from the config)")
+	group := flags.Int("group", 0, "split a PIN into groups of `N` digits, e.g. 4 for 1234-5678 (default: default_pin_group_size from the config)")
+	charsets := flags.String("charsets", "", "comma-separated `charsets` of a random password: lower, upper, digits, symbols or a locale (default: from the config)")
+	description := flags.String("description", "", "history `description` (default \"<Type> password\")")
+	site := flags.String("site", "", "`site` the password is for, saved in the history")
+	username := flags.String("username", "", "account `name` at the site, saved in the history")
+	rotate := flags.Bool("rotate", false, "mark an earlier entry for the same site or description as replaced without asking")
+	copyIt := flags.Bool("copy", false, "copy the password to the clipboard instead of printing it, clearing it after clear_clipboard_after")
+	noHistory := flags.Bool("no-history", false, "do not record the password in the history")
+	fd := flags.Int("fd", -1, "write the password to file `descriptor` N instead of printing it, e.g. -fd 3 3>secret.txt")
+	hidden := flags.Bool("hidden", false, "never show the password, for shared screens: copy it, or write it to -fd if that is not a terminal")
+
+	return func(args []string) int {
+		if len(args) != 0 {
+			fmt.Fprintln(os.Stderr, "Usage: passman generate [-preset NAME] [-type TYPE] [-length N] [-words N] [-charsets LIST] [-copy] [-fd N] [-hidden] [-no-history]")
+			return 2
+		}
+
+		var out *os.File
+		if *fd >= 0 {
+			out = os.NewFile(uintptr(*fd), "fd "+strconv.Itoa(*fd))
+			if _, err := out.Stat(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: file descriptor %d is not open\n", *fd)
+				return 2
+			}
+			if *hidden && term.IsTerminal(out.Fd()) {
+				fmt.Fprintf(os.Stderr, "Error: file descriptor %d is a terminal, where -hidden would show the password\n", *fd)
+				return 2
+			}
+		} else if *hidden {
+			*copyIt = true
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		if *noHistory {
+			cfg.HistoryEnabled = false
+		}
+		if cfg.HistoryEnabled && !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		task := utils.JobTask{
+			Type:   strings.ToLower(*genType),
+			Length: *length,
+			Words:  *words,
+		}
+		if *presetName != "" {
+			preset, ok := cfg.FindPreset(*presetName)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: no preset %q%s\n", *presetName, presetList(&cfg))
+				return 2
+			}
+			if task.Type != "" && task.Type != preset.Type {
+				fmt.Fprintf(os.Stderr, "Error: preset %q generates %s, not %s\n", *presetName, preset.Type, task.Type)
+				return 2
+			}
+			task.Type, task.Charsets, task.Wordlist = preset.Type, preset.Charsets, preset.Wordlist
+			task.Separator, task.GroupSize = preset.Separator, preset.GroupSize
+			task.Capitalize, task.Digit = preset.Capitalize, preset.Digit
+			if task.Length == 0 {
+				task.Length = preset.Length
+			}
+			if task.Words == 0 {
+				task.Words = preset.Words
+			}
+		}
+		if task.Type == "" {
+			task.Type = cfg.DefaultGenerator
+		}
+
+		// The config fills in what no preset set; a preset's false is kept
+		switch task.Type {
+		case utils.TaskMemorable:
+			if task.Separator == "" {
+				task.Separator = cfg.DefaultPassphraseSeparator
+			}
+			if *presetName == "" {
+				task.Capitalize, task.Digit = cfg.DefaultPassphraseCapitalize, cfg.DefaultPassphraseDigit
+			}
+		case utils.TaskPIN:
+			if task.Separator == "" {
+				task.Separator = cfg.DefaultPinSeparator
+			}
+			if *presetName == "" {
+				task.GroupSize = cfg.DefaultPinGroupSize
+			}
+		}
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "separator":
+				task.Separator = *separator
+			case "group":
+				task.GroupSize = *group
+			}
+		})
+
+		settings := ""
+		switch task.Type {
+		case utils.TaskRandom:
+			if task.Length == 0 {
+				task.Length = cfg.DefaultLength
+			}
+			if *charsets != "" {
+				task.Charsets = strings.Split(*charsets, ",")
+			} else if len(task.Charsets) == 0 {
+				if cfg.DefaultIncludeLowercase {
+					task.Charsets = append(task.Charsets, "lower")
+				}
+				if cfg.DefaultIncludeUppercase {
+					task.Charsets = append(task.Charsets, "upper")
+				}
+				if cfg.DefaultIncludeNumbers {
+					task.Charsets = append(task.Charsets, "digits")
+				}
+				if cfg.DefaultIncludeSymbols {
+					task.Charsets = append(task.Charsets, "symbols")
+				}
+			}
+			settings = fmt.Sprintf("Length: %d, Charsets: %s", task.Length, strings.Join(task.Charsets, ","))
+		case utils.TaskMemorable:
+			if task.Words == 0 {
+				task.Words = cfg.DefaultPassphraseWords
+			}
+			settings = fmt.Sprintf("Word Count: %d, Separator: %q, Capitalize: %t, Digit: %t", task.Words, task.Separator, task.Capitalize, task.Digit)
+		case utils.TaskPIN:
+			if task.Length == 0 {
+				task.Length = cfg.DefaultPinLength
+			}
+			settings = fmt.Sprintf("PIN Length: %d", task.Length)
+			if task.GroupSize > 0 {
+				settings += fmt.Sprintf(", Groups: %d, Separator: %q", task.GroupSize, task.Separator)
+			}
+		}
+
+		gen, err := task.BuildGenerator()
+		if err == nil {
+			err = gen.Validate()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		password, err := gen.Generate(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		printEntropyWarning(generator.CheckEntropy(gen))
+
+		manager, err := utils.NewManager(&cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		manager.RecordMetric(utils.MetricGenerated, task.Type)
+
+		id := ""
+		if cfg.HistoryEnabled {
+			entry := utils.HistoryEntry{
+				ID:          manager.History.NewID(),
+				Password:    password,
+				Length:      utf8.RuneCountInString(password),
+				Type:        task.Type,
+				Settings:    settings,
+				Description: *description,
+				Site:        strings.TrimSpace(*site),
+				Username:    strings.TrimSpace(*username),
+			}
+			explicit := entry.Description != "" || entry.Site != ""
+			if entry.Description == "" {
+				entry.Description = fmt.Sprintf("%s password", strings.Title(task.Type))
+			}
+			if err := manager.History.AddEntry(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save to history: %v\n", err)
+			} else {
+				id = entry.ID
+				if explicit {
+					rotatePredecessor(manager.History, entry, *rotate)
+				}
+			}
+		}
+
+		if out != nil {
+			if _, err := fmt.Fprintln(out, password); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to write to file descriptor %d: %v\n", *fd, err)
+				return 1
+			}
+		}
+		if !*copyIt {
+			if out == nil {
+				fmt.Println(password)
+			}
+			return 0
+		}
+		if err := manager.Clipboard.Copy(password); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		manager.RecordMetric(utils.MetricCopied, "")
+		if id != "" {
+			_ = manager.History.RecordUsage(id, utils.UsageCopied)
+		}
+		waitForClipboardClear(manager)
+		return 0
+	}
+}
+
+// presetList names the saved presets for an error message, or tells how
+// to save one
+func presetList(cfg *config.Config) string {
+	names := cfg.PresetNames()
+	if len(names) == 0 {
+		return "; save one with P on a generator screen"
+	}
+	return " (saved: " + strings.Join(names, ", ") + ")"
+}
+
+// deriveCommand derives a site's password from a master passphrase with
+// the stateless package. Nothing is read from or written to the history:
+// the same inputs give the same password on any machine.
+func deriveCommand(flags *flag.FlagSet) cli.RunFunc {
+	login := flags.String("login", "", "account `name` at the site, so several accounts get different passwords")
+	counter := flags.Int("counter", 1, "password `number`; raise it to rotate the site's password")
+	length := flags.Int("length", stateless.DefaultLength, fmt.Sprintf("password `length` (%d-%d)", stateless.MinLength, stateless.MaxLength))
+	charsets := flags.String("charsets", "lower,upper,digits,symbols", "comma-separated character `classes`: lower, upper, digits and symbols")
+	passphraseEnv := flags.String("passphrase-env", "", "read the master passphrase from this environment `variable`")
+	copyIt := flags.Bool("copy", false, "copy the password to the clipboard instead of printing it, clearing it after clear_clipboard_after")
+
+	return func(args []string) int {
+		operands, err := parseInterspersed(flags, args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if len(operands) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: passman derive [-login NAME] [-counter N] [-length N] [-charsets LIST] [-copy] SITE")
+			return 2
+		}
+
+		profile := stateless.Profile{Site: operands[0], Login: *login, Counter: *counter, Length: *length}
+		for _, name := range strings.Split(*charsets, ",") {
+			switch strings.TrimSpace(name) {
+			case "lower":
+				profile.Lower = true
+			case "upper":
+				profile.Upper = true
+			case "digits":
+				profile.Digits = true
+			case "symbols":
+				profile.Symbols = true
+			default:
+				fmt.Fprintf(os.Stderr, "Error: unknown character class %q (use lower, upper, digits or symbols)\n", name)
+				return 2
+			}
+		}
+		if err := profile.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+
+		var passphrase string
+		if *passphraseEnv != "" {
+			// Read from the environment so the passphrase never appears in the process list
+			if passphrase = os.Getenv(*passphraseEnv); passphrase == "" {
+				fmt.Fprintf(os.Stderr, "Error: %s is not set\n", *passphraseEnv)
+				return 2
+			}
+		} else {
+			if !term.IsTerminal(os.Stdin.Fd()) {
+				fmt.Fprintln(os.Stderr, "Error: no terminal to ask for the master passphrase on; use -passphrase-env")
+				return 1
+			}
+			if passphrase, err = readSecret("Master passphrase: "); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+
+		password, err := stateless.Derive(passphrase, profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		// A mistyped passphrase silently gives another password; the
+		// fingerprint shows it
+		fmt.Fprintf(os.Stderr, "Master passphrase check: %s; site %s, counter %d\n",
+			stateless.Fingerprint(passphrase), stateless.NormalizeSite(profile.Site), profile.Counter)
+
+		if !*copyIt {
+			fmt.Println(password)
+			return 0
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		cfg.HistoryEnabled = false
+		manager, err := utils.NewManager(&cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if err := manager.Clipboard.Copy(password); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		manager.RecordMetric(utils.MetricCopied, "")
+		waitForClipboardClear(manager)
+		return 0
+	}
+}
+
+// rotatePredecessor offers to record that entry replaces the password of
+// the newest earlier entry for its site or description, asking on the
+// terminal unless rotate is set. The answer never stops the command: the
+// new password is saved either way.
+func rotatePredecessor(history *utils.HistoryManager, entry utils.HistoryEntry, rotate bool) {
+	entries, err := history.LoadHistory()
+	if err != nil {
+		return
+	}
+	// The saved entry, with the creation time the history gave it
+	if entry, err = utils.FindEntry(entries, entry.ID); err != nil {
+		return
+	}
+	previous, found := utils.FindPredecessor(entries, entry)
+	if !found {
+		return
+	}
+
+	if !rotate {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			fmt.Fprintf(os.Stderr, "Note: %q from %s is for the same account; use -rotate to mark it replaced\n",
+				previous.Label(), previous.CreatedAt.Format("Jan 2 2006"))
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%q from %s is for the same account. Does this password replace it? [Y/n] ",
+			previous.Label(), previous.CreatedAt.Format("Jan 2 2006"))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+			return
+		}
+	}
+
+	flagged, err := history.RotateEntry(entry.ID, previous.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record the rotation: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Marked %q as replaced\n", previous.Label())
+	if flagged > 0 {
+		fmt.Fprintf(os.Stderr, "%d linked entries use the old password and need updating\n", flagged)
+	}
+}
+
+// printEntropyWarning prints a low-entropy warning to stderr, in red when
+// stderr is a terminal and NO_COLOR is not set, so piped output stays clean
+func printEntropyWarning(w *generator.EntropyWarning) {
+	if w == nil {
+		return
+	}
+
+	start, end := "", ""
+	if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == "" {
+		start, end = "\x1b[1;31m", "\x1b[0m"
+	}
+
+	fmt.Fprintf(os.Stderr, "%sWarning: %s%s\n", start, w, end)
+	for _, suggestion := range w.Suggestions {
+		fmt.Fprintf(os.Stderr, "  - %s\n", suggestion)
+	}
+}
+
+// fsckCommand checks the history store
+func fsckCommand(flags *flag.FlagSet) cli.RunFunc {
+	repair := flags.Bool("repair", false, "quarantine damaged records and rewrite the history")
+
+	return func(args []string) int {
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+		report, err := history.Fsck(*repair)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		fmt.Printf("Checking %s\n", report.Path)
+		for _, issue := range report.Issues {
+			fmt.Println("  " + issue.String())
+		}
+		fmt.Printf("%d records, %d valid, %d issues\n", report.Records, report.ValidRecords, len(report.Issues))
+
+		if report.Repaired {
+			if report.Quarantined > 0 {
+				fmt.Printf("Quarantined %d records to %s\n", report.Quarantined, report.QuarantinePath)
+			} else {
+				fmt.Printf("Moved unreadable history to %s\n", report.QuarantinePath)
+			}
+			return 0
+		}
+
+		if report.HasErrors() {
+			fmt.Println("Run 'passman fsck -repair' to quarantine damaged records.")
+			return 1
+		}
+		return 0
+	}
+}
+
+// verifyCommand checks the history's hash chain
+func verifyCommand(flags *flag.FlagSet) cli.RunFunc {
+	return func(args []string) int {
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+		report, err := history.VerifyChain()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		if report.Head != "" {
+			fmt.Println("  " + report.Head)
+		}
+		for _, b := range report.Breaks {
+			fmt.Println("  " + b.String())
+		}
+		fmt.Printf("%d entries, %d links intact, %d broken", report.Entries, report.Linked, len(report.Breaks))
+		if report.Unlinked > 0 {
+			fmt.Printf(", %d saved before chaining (linked on the next save)", report.Unlinked)
+		}
+		fmt.Println()
+
+		if !report.OK() {
+			fmt.Println("The history was changed outside passman.")
+			return 1
+		}
+		return 0
+	}
+}
+
+// exportCommand exports the password history
+func exportCommand(flags *flag.FlagSet) cli.RunFunc {
+	format := flags.String("format", "", "export `format`: txt, json, csv or html (a printable emergency kit); a comma-separated list writes each at once (default: default_export_format from the config)")
+	output := flags.String("o", "", "output `file`, or - for standard output (default: export directory with a timestamped name); with several formats, each gets its extension")
+	unique := flags.Bool("unique", false, "export each password only once")
+	firstSeen := flags.Bool("first-seen", true, "with -unique, date passwords by their first generation")
+	mergeLabels := flags.Bool("merge-labels", true, "with -unique, merge the descriptions of duplicates")
+	encrypt := flags.String("encrypt", "", "encrypt with `tool`: age, gpg or off (default: export_encryption from the config)")
+	recipient := flags.String("recipient", "", "age recipients or gpg key IDs, comma-separated, or a file of them (default: export_recipient from the config)")
+
+	return func(args []string) int {
+		operands, err := parseInterspersed(flags, args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if len(operands) > 1 || (len(operands) == 1 && *output != "") {
+			fmt.Fprintln(os.Stderr, "Usage: passman export [-format LIST] [-unique] [-o FILE | FILE]")
+			return 2
+		}
+		if len(operands) == 1 {
+			*output = operands[0]
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		if *format == "" {
+			*format = cfg.DefaultExportFormat
+		}
+		if *encrypt == "" {
+			*encrypt = cfg.ExportEncryption
+		}
+		if *recipient == "" {
+			*recipient = cfg.ExportRecipient
+		}
+		switch *encrypt {
+		case utils.ExportEncryptOff, utils.ExportEncryptAge, utils.ExportEncryptGPG:
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown encryption %q (use age, gpg or off)\n", *encrypt)
+			return 2
+		}
+		toStdout := *output == utils.StdoutPath
+		if toStdout && strings.Contains(*format, ",") {
+			fmt.Fprintln(os.Stderr, "Error: only one format can be written to standard output")
+			return 2
+		}
+
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+		entries, err := history.LoadHistory()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		var exportEntries []utils.PasswordEntry
+		if *unique {
+			exportEntries = utils.UniquePasswords(entries, utils.DedupOptions{
+				KeepFirstSeen: *firstSeen,
+				MergeLabels:   *mergeLabels,
+			})
+		} else {
+			for _, entry := range entries {
+				exportEntries = append(exportEntries, utils.PasswordEntry{
+					Password:    entry.Password,
+					Length:      entry.Length,
+					Type:        entry.Type,
+					CreatedAt:   entry.CreatedAt,
+					Description: entry.Description,
+				})
+			}
+		}
+
+		exporter := utils.NewExportManager()
+		exporter.SetFileMode(cfg.ExportMode())
+		exporter.SetEncryption(*encrypt, *recipient)
+		var targets []utils.ExportTarget
+		for _, name := range strings.Split(*format, ",") {
+			exportFormat := utils.ExportFormat(strings.TrimSpace(name))
+			path := *output
+			if path == "" {
+				path = cfg.GetExportPath(exporter.GetSuggestedFilename(exportFormat, "passwords"))
+			} else if strings.Contains(*format, ",") {
+				tool := exporter.Encryption()
+				if tool != "" {
+					path = strings.TrimSuffix(path, "."+tool)
+				}
+				path = strings.TrimSuffix(path, filepath.Ext(path)) + "." + string(exportFormat)
+				if tool != "" {
+					path += "." + tool
+				}
+			}
+			targets = append(targets, utils.ExportTarget{Format: exportFormat, Path: path})
+		}
+
+		report := exporter.ExportAll(exportEntries, targets, func(p utils.ExportProgress) {
+			if p.Result.Err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", p.Result.Target.Path, p.Result.Err)
+				return
+			}
+			if toStdout {
+				// Standard output carries the export itself
+				fmt.Fprintf(os.Stderr, "Exported %d of %d history entries\n", len(exportEntries), len(entries))
+				return
+			}
+			fmt.Printf("Exported %d of %d history entries to %s\n", len(exportEntries), len(entries), p.Result.Target.Path)
+		})
+		if failed := report.Failed(); len(failed) > 0 {
+			if len(targets) > 1 {
+				fmt.Fprintf(os.Stderr, "Error: %d of %d exports failed\n", len(failed), len(targets))
+			}
+			return 1
+		}
+		return 0
+	}
+}
+
+// backupCommand writes the history to a passphrase-protected backup file
+func backupCommand(flags *flag.FlagSet) cli.RunFunc {
+	output := flags.String("o", "", "backup `file` (default: export directory with a timestamped name)")
+	passphraseEnv := flags.String("passphrase-env", "", "read the backup passphrase from this environment `variable` instead of asking")
+
+	return func(args []string) int {
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+
+		path := *output
+		if path == "" {
+			path = cfg.GetExportPath(utils.DefaultBackupName(time.Now()))
+		}
+
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+		passphrase, err := backupPassphrase(*passphraseEnv, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+		info, err := history.ExportBackup(path, passphrase)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		fmt.Printf("Backed up %d history entries to %s\n", info.Entries, info.Path)
+		fmt.Println("Restore it with 'passman restore' and the backup passphrase.")
+		return 0
+	}
+}
+
+// restoreCommand merges a backup file into the history
+func restoreCommand(flags *flag.FlagSet) cli.RunFunc {
+	replace := flags.Bool("replace", false, "replace the history with the backup instead of merging")
+	passphraseEnv := flags.String("passphrase-env", "", "read the backup passphrase from this environment `variable` instead of asking")
+
+	return func(args []string) int {
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: passman restore [-passphrase-env VARIABLE] [-replace] FILE")
+			return 2
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+		passphrase, err := backupPassphrase(*passphraseEnv, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+		report, err := history.ImportBackup(args[0], passphrase, *replace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		fmt.Println(report.String())
+		if report.BackupPath != "" {
+			fmt.Printf("The previous history was saved to %s\n", report.BackupPath)
+		}
+		return 0
+	}
+}
+
+// historyUsage is the usage line of the history command
+const historyUsage = "Usage: passman history list | search QUERY | show ID | delete ID | clear [flags]"
+
+// historyCommand runs a history subcommand. Flags may also follow the
+// subcommand and its arguments, e.g. passman history show ID -json.
+func historyCommand(flags *flag.FlagSet) cli.RunFunc {
+	asJSON := flags.Bool("json", false, "print entries as JSON, passwords included")
+	limit := flags.Int("limit", 20, "list or search at most `n` entries, newest first; 0 for all")
+	yes := flags.Bool("yes", false, "delete or clear without asking for confirmation")
+	backup := flags.Bool("backup", false, "back the history up before clearing, whatever backup_before_clear says")
+	noBackup := flags.Bool("no-backup", false, "clear without a backup, whatever backup_before_clear says")
+
+	return func(args []string) int {
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, historyUsage)
+			return 2
+		}
+		operands, err := parseInterspersed(flags, args[1:])
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				fmt.Println("See 'passman help history'")
+				return 0
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+
+		wantOperands := map[string]bool{"list": false, "search": true, "show": true, "delete": true, "clear": false}
+		needsOperand, known := wantOperands[args[0]]
+		if !known {
+			fmt.Fprintf(os.Stderr, "Error: unknown history command %q\n%s\n", args[0], historyUsage)
+			return 2
+		}
+		if needsOperand != (len(operands) > 0) || (args[0] != "search" && len(operands) > 1) {
+			fmt.Fprintln(os.Stderr, historyUsage)
+			return 2
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		if !cfg.HistoryEnabled {
+			fmt.Fprintln(os.Stderr, "Error: history is disabled")
+			return 1
+		}
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+
+		switch args[0] {
+		case "list":
+			entries, err := history.GetRecentEntries(*limit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			if skipped := history.SkippedRecords(); skipped > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: %d history records could not be read; run 'passman fsck' for details\n", skipped)
+			}
+			return printHistoryEntries(entries, *asJSON)
+		case "search":
+			entries, err := history.SearchEntries(strings.Join(operands, " "))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			if *limit > 0 && len(entries) > *limit {
+				entries = entries[:*limit]
+			}
+			return printHistoryEntries(entries, *asJSON)
+		case "show":
+			return showHistoryEntry(history, operands[0], *asJSON)
+		case "delete":
+			return deleteHistoryEntry(history, operands[0], *yes)
+		}
+		return clearHistory(history, &cfg, *yes, (cfg.BackupBeforeClear || *backup) && !*noBackup)
+	}
+}
+
+// parseInterspersed parses flags wherever they appear among args and
+// returns the other arguments in order
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var operands []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return operands, nil
+		}
+		operands = append(operands, args[0])
+		args = args[1:]
+	}
+}
+
+// printHistoryEntries prints entries as a table without their passwords,
+// or as JSON with them
+func printHistoryEntries(entries []utils.HistoryEntry, asJSON bool) int {
+	if asJSON {
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No matching history entries")
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tTYPE\tSTRENGTH\tDESCRIPTION\tSITE\tUSERNAME\tTAGS")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.ID, entry.CreatedAt.Format("2006-01-02 15:04"),
+			entry.Type, entry.Strength, entry.Description, entry.Site, entry.Username, strings.Join(entry.Tags, ","))
+	}
+	w.Flush()
+	return 0
+}
+
+// showHistoryEntry prints one entry, found by ID or description, with its
+// password, and records the reveal in its usage trail
+func showHistoryEntry(history *utils.HistoryManager, ref string, asJSON bool) int {
+	entries, err := history.LoadHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	entry, err := utils.FindEntry(entries, ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := history.RecordUsage(entry.ID, utils.UsageRevealed); err != nil {
+		log.Printf("Failed to record reveal: %v", err)
+	}
+
+	if asJSON {
+		return printJSON(entry)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", entry.ID)
+	fmt.Fprintf(w, "Password:\t%s\n", entry.Password)
+	fmt.Fprintf(w, "Created:\t%s\n", entry.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Type:\t%s, %d characters\n", entry.Type, entry.Length)
+	if entry.Strength != "" {
+		fmt.Fprintf(w, "Strength:\t%s (%.1f bits)\n", entry.Strength, entry.Entropy)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"Description", entry.Description},
+		{"Site", entry.Site},
+		{"Username", entry.Username},
+		{"Tags", strings.Join(entry.Tags, ", ")},
+		{"Linked to", entry.LinkedTo},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", field.name, field.value)
+		}
+	}
+	fmt.Fprintf(w, "Used:\tcopied %d times, revealed %d times\n", entry.CopyCount, entry.RevealCount)
+	w.Flush()
+	return 0
+}
+
+// deleteHistoryEntry deletes one entry, found by ID or description, after
+// asking unless yes
+func deleteHistoryEntry(history *utils.HistoryManager, ref string, yes bool) int {
+	entries, err := history.LoadHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	entry, err := utils.FindEntry(entries, ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if !yes {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			fmt.Fprintln(os.Stderr, "Error: no terminal to confirm on; use -yes")
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Delete %q (%s)? [y/N] ", entry.Label(), entry.ID)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Fprintln(os.Stderr, "Not deleted")
+			return 1
+		}
+	}
+
+	if err := history.DeleteEntry(entry.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Deleted %q\n", entry.Label())
+	return 0
+}
+
+// printJSON prints value as indented JSON
+func printJSON(value interface{}) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// clearHistory deletes every history entry once the confirmation is
+// typed, unless yes, backing the history up first if backup
+func clearHistory(history *utils.HistoryManager, cfg *config.Config, yes, backup bool) int {
+	entries, err := history.LoadHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Println("The history is already empty")
+		return 0
+	}
+
+	if !yes {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			fmt.Fprintln(os.Stderr, "Error: no terminal to confirm on; use -yes")
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "This deletes all %d history entries. Type %q to confirm: ", len(entries), utils.ClearConfirmation)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != utils.ClearConfirmation {
+			fmt.Fprintln(os.Stderr, "Not cleared")
+			return 1
+		}
+	}
+
+	if !backup {
+		if err := history.ClearHistory(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Cleared %d history entries\n", len(entries))
+		return 0
+	}
+
+	info, err := history.ClearWithBackup(cfg.GetExportPath(utils.DefaultBackupName(time.Now())))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Cleared %d history entries after backing them up to %s\n", info.Entries, info.Path)
+	fmt.Println("Restore them with 'passman restore' and the history passphrase.")
+	return 0
+}
+
+// syncCommand syncs the history with the configured remote
+func syncCommand(flags *flag.FlagSet) cli.RunFunc {
+	remoteURL := flags.String("remote", "", "sync with this `URL` instead of sync_remote from the config")
+
+	return func(args []string) int {
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		if *remoteURL != "" {
+			cfg.SyncRemote = *remoteURL
+		}
+
+		remote, err := utils.ParseSyncRemote(cfg.SyncRemote)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+		report, err := history.Sync(ctx, remote)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		fmt.Println(report.String())
+		return 0
+	}
+}
+
+// migrateCommand converts the history files to another key derivation
+// function or passphrase
+func migrateCommand(flags *flag.FlagSet) cli.RunFunc {
+	kdf := flags.String("kdf", "", "target key derivation `function`: pbkdf2 or argon2id")
+	format := flags.String("format", utils.StorageJSON, "target storage `format` (only json is supported)")
+	newKeyEnv := flags.String("new-key-env", "", "re-encrypt with the passphrase in this environment `variable`")
+	dryRun := flags.Bool("dry-run", false, "convert and verify without writing anything")
+
+	return func(args []string) int {
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+
+		opts := utils.MigrateOptions{KDF: *kdf, Format: *format, DryRun: *dryRun}
+		if *newKeyEnv != "" {
+			// Read from the environment so the key never appears in the process list
+			opts.NewPassphrase = os.Getenv(*newKeyEnv)
+			if opts.NewPassphrase == "" {
+				fmt.Fprintf(os.Stderr, "Error: %s is not set\n", *newKeyEnv)
+				return 2
+			}
+		}
+		if opts.KDF == "" && opts.NewPassphrase == "" {
+			fmt.Fprintln(os.Stderr, "Error: nothing to migrate; give -kdf or -new-key-env")
+			return 2
+		}
+
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		history := utils.NewHistoryManager(true, cfg.HistoryEncryptionKey, cfg.HistoryMaxEntries)
+		history.SetKDF(cfg.HistoryKDF)
+		report, err := history.Migrate(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		if len(report.Files) == 0 {
+			fmt.Println("No history files found")
+		}
+		for _, file := range report.Files {
+			fmt.Println("  " + file.String())
+		}
+		if report.DryRun {
+			fmt.Println("Dry run: all files converted and verified, nothing written")
+			return 0
+		}
+
+		// Files written from now on must use the new settings too
+		cfg.HistoryKDF = history.KDF()
+		if opts.NewPassphrase != "" {
+			cfg.HistoryEncryptionKey = opts.NewPassphrase
+			_ = utils.ForgetPassphrase()
+			if cfg.HistoryPassphrase == config.PassphraseKeychain {
+				if err := utils.StoreKeychainPassphrase(opts.NewPassphrase); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: history migrated but the keychain could not be updated: %v\n", err)
+					fmt.Fprintln(os.Stderr, "Restore the .bak files, or run passman forget -keychain and enter the new passphrase on the next run.")
+					return 1
+				}
+			}
+			if cfg.HistoryPassphrase == config.PassphraseHardware {
+				fmt.Fprintln(os.Stderr, "Touch your hardware key to wrap the new passphrase...")
+				if err := rewrapHardwareKey(opts.NewPassphrase); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: history migrated but the passphrase could not be wrapped: %v\n", err)
+					fmt.Fprintln(os.Stderr, "Restore the .bak files, or run passman hardware-key enroll again.")
+					return 1
+				}
+			}
+		}
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: history migrated but the configuration could not be saved: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Restore the .bak files or update history_kdf and history_encryption_key by hand.")
+			return 1
+		}
+
+		fmt.Printf("Migrated and verified %d files; backups use the old key\n", len(report.Files))
+		return 0
+	}
+}
+
+// runCommand runs the tasks of a job file
+func runCommand(flags *flag.FlagSet) cli.RunFunc {
+	dryRun := flags.Bool("dry-run", false, "validate the job file without generating anything")
+	reportPath := flags.String("report", "", "write a JSON audit report (without secrets) to this `file`")
+
+	return func(args []string) int {
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: passman run [-dry-run] [-report FILE] JOBFILE")
+			return utils.JobExitInvalid
+		}
+
+		job, err := utils.LoadJobFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return utils.JobExitInvalid
+		}
+
+		report := utils.RunJobs(context.Background(), job, *dryRun)
+		var rates map[string]int
+		if cfg, err := config.Load(); err == nil && *dryRun {
+			rates = cfg.GenerationRates
+		}
+		for _, result := range report.Results {
+			line := result.String()
+			// Dry runs estimate the time from the rates passman bench measured
+			if rate, ok := rates[result.Type]; ok && result.Error == "" {
+				line += fmt.Sprintf(", about %s", generator.EstimateGenerationTime(result.Requested, float64(rate)))
+			}
+			fmt.Println(line)
+		}
+		if *dryRun && len(rates) == 0 {
+			fmt.Fprintln(os.Stderr, "Run passman bench to estimate how long each task takes")
+		}
+
+		if *reportPath != "" {
+			if err := report.WriteReport(*reportPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return utils.JobExitFailed
+			}
+		}
+
+		if report.ExitCode != utils.JobExitOK {
+			fmt.Fprintln(os.Stderr, "Error: one or more tasks failed")
+		}
+		return report.ExitCode
+	}
+}
+
+// benchCommand measures how many secrets a second each generator makes at
+// several sizes, and stores the rates at the defaults in the config for
+// estimates
+func benchCommand(flags *flag.FlagSet) cli.RunFunc {
+	duration := flags.Duration("duration", 200*time.Millisecond, "how long to generate at each size")
+	only := flags.String("type", "", "benchmark only this generator `type` ("+generator.NamesText()+")")
+	save := flags.Bool("save", true, "store the rates in the config's generation_rates")
+	asJSON := flags.Bool("json", false, "print the results as JSON")
+
+	return func(args []string) int {
+		if len(args) != 0 {
+			fmt.Fprintln(os.Stderr, "Usage: passman bench [-duration D] [-type TYPE] [-save=false] [-json]")
+			return 2
+		}
+		if *duration <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: -duration must be positive")
+			return 2
+		}
+		registrations := generator.Registered()
+		if *only != "" {
+			reg, ok := generator.Lookup(*only)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: unknown type %q (use %s)\n", *only, generator.NamesText())
+				return 2
+			}
+			registrations = []generator.Registration{reg}
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		type benchRow struct {
+			Type      string `json:"type"`
+			Size      int    `json:"size,omitempty"`
+			Unit      string `json:"unit,omitempty"`
+			PerSecond int    `json:"per_second"`
+		}
+		var rows []benchRow
+		rates := make(map[string]int)
+		if !*asJSON {
+			fmt.Printf("%-10s %-10s %14s\n", "Type", "Size", "Per second")
+		}
+		for _, reg := range registrations {
+			sizes, _ := generator.BenchSizes(reg)
+			for _, size := range sizes {
+				result, err := generator.Benchmark(ctx, reg.Name, size, *duration)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s: %v\n", reg.Name, err)
+					return 1
+				}
+				if size == 0 {
+					rates[reg.Name] = max(int(result.PerSecond()), 1)
+				}
+				rows = append(rows, benchRow{reg.Name, result.Size, result.Unit, int(result.PerSecond())})
+				if !*asJSON {
+					label := "defaults"
+					if size > 0 {
+						label = fmt.Sprintf("%d %s", size, result.Unit)
+					}
+					fmt.Printf("%-10s %-10s %14.0f\n", reg.Name, label, result.PerSecond())
+				}
+			}
+		}
+
+		if *save {
+			if cfg.GenerationRates == nil {
+				cfg.GenerationRates = make(map[string]int)
+			}
+			for name, rate := range rates {
+				cfg.GenerationRates[name] = rate
+			}
+			if err := cfg.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to save the rates: %v\n", err)
+				return 1
+			}
+			if !*asJSON {
+				fmt.Println("Saved the rates at the defaults to generation_rates")
+			}
+		}
+		if *asJSON {
+			return printJSON(rows)
+		}
+		return 0
+	}
+}
+
+// recordCommand counts a run of the command called name in the usage
+// metrics, if enable_telemetry is on. Unknown commands are not counted:
+// their name may be a mistyped secret.
+func recordCommand(app *cli.App, name string) {
+	cmd := app.Lookup(name)
+	if cmd == nil {
+		return
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return
+	}
+	_ = utils.RecordMetric(&cfg, utils.MetricCommand, cmd.Name)
+}
+
+// wordlistCommand shows or updates the cached EFF wordlist
+func wordlistCommand(flags *flag.FlagSet) cli.RunFunc {
+	return func(args []string) int {
+		wordlist := utils.NewWordlistManager()
+		if len(args) == 1 && args[0] == "update" {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := wordlist.Update(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			status, err := wordlist.CacheStatus()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			fmt.Printf("Downloaded the EFF wordlist to %s; SHA-256 verified\n", status.Path)
+			return 0
+		}
+		if len(args) > 1 || len(args) == 1 && args[0] != "status" {
+			fmt.Fprintln(os.Stderr, "Usage: passman wordlist [status | update]")
+			return 2
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		status, err := wordlist.CacheStatus()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if !status.Cached {
+			fmt.Printf("No cached wordlist in %s; passman wordlist update downloads it\n", status.Path)
+			return 0
+		}
+		fmt.Printf("Cached wordlist: %s\n", status.Path)
+		fmt.Printf("Downloaded:      %s\n", status.Updated.Format("2006-01-02 15:04"))
+		if cfg.WordlistUpdateInterval > 0 {
+			next := status.Updated.AddDate(0, 0, cfg.WordlistUpdateInterval)
+			fmt.Printf("Next update:     %s (every %d days)\n", next.Format("2006-01-02"), cfg.WordlistUpdateInterval)
+		} else {
+			fmt.Println("Next update:     only with passman wordlist update")
+		}
+		if !status.Verified {
+			fmt.Printf("SHA-256:         does not match %s; it is not used until passman wordlist update replaces it\n", utils.EFFWordlistSHA256)
+			return 1
+		}
+		fmt.Println("SHA-256:         verified")
+		return 0
+	}
+}
+
+// refreshWordlist downloads the EFF wordlist again if the cached copy is
+// older than days. It runs in the background of the TUI, so failures,
+// e.g. being offline, only go to the log.
+func refreshWordlist(wordlist *utils.WordlistManager, days int) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	updated, err := wordlist.RefreshIfStale(ctx, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		log.Printf("Failed to update the wordlist: %v", err)
+		return
+	}
+	if updated {
+		log.Printf("Updated the cached EFF wordlist")
+	}
+}
+
+// statsCommand shows or resets the local usage counts
+func statsCommand(flags *flag.FlagSet) cli.RunFunc {
+	reset := flags.Bool("reset", false, "delete the counts and start over")
+	asJSON := flags.Bool("json", false, "print the counts as JSON")
+
+	return func(args []string) int {
+		if len(args) != 0 {
+			fmt.Fprintln(os.Stderr, "Usage: passman stats [-reset] [-json]")
+			return 2
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+
+		if *reset {
+			if err := utils.ResetMetrics(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			fmt.Println("Deleted the usage counts")
+			return 0
+		}
+
+		metrics, err := utils.LoadMetrics()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if *asJSON {
+			return printJSON(struct {
+				Enabled bool `json:"enabled"`
+				utils.Metrics
+			}{cfg.EnableTelemetry, metrics})
+		}
+
+		if !cfg.EnableTelemetry {
+			fmt.Println("Usage metrics are off. Turn on enable_telemetry (Settings, Advanced, Usage Metrics)")
+			fmt.Println("to count commands, generations and copies on this machine; nothing is ever sent.")
+			if metrics.Empty() {
+				return 0
+			}
+			fmt.Println()
+		}
+		if metrics.Empty() {
+			fmt.Println("Nothing counted yet")
+			return 0
+		}
+
+		path, _ := utils.MetricsPath()
+		fmt.Printf("Counted since %s in %s\n", metrics.Since, path)
+		fmt.Printf("\n%-20s %8d\n", "TUI sessions", metrics.Launches)
+		fmt.Printf("%-20s %8d\n", "Copies", metrics.Copies)
+		for _, group := range []struct {
+			title  string
+			counts map[string]int
+		}{
+			{"Secrets generated", metrics.Generated},
+			{"Commands run", metrics.Commands},
+		} {
+			if len(group.counts) == 0 {
+				continue
+			}
+			fmt.Printf("\n%s:\n", group.title)
+			for _, count := range utils.SortedCounts(group.counts) {
+				fmt.Printf("  %-18s %8d\n", count.Name, count.Count)
+			}
+		}
+		return 0
+	}
+}
+
+// pickCommand runs the fuzzy finder over the history and copies or prints
+// the password picked. The finder draws on stderr, so stdout carries only
+// the password and can be piped.
+func pickCommand(flags *flag.FlagSet) cli.RunFunc {
+	printIt := flags.Bool("print", false, "print the password to stdout instead of copying it")
+	query := flags.String("query", "", "start with this search `query`")
+	selectOne := flags.Bool("select-1", false, "pick without asking when the query matches exactly one entry")
+
+	return func(args []string) int {
+		cfg, err := config.Load()
+		if err != nil {
+			printConfigError(err)
+			return 1
+		}
+		if !cfg.HistoryEnabled {
+			fmt.Fprintln(os.Stderr, "Error: history is disabled; there is nothing to pick from")
+			return 1
+		}
+		if !resolvePassphrase(&cfg) {
+			return 1
+		}
+
+		manager, err := utils.NewManager(&cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		_ = ui.SetTheme(cfg.Theme)
+
+		var entry utils.HistoryEntry
+		picked := false
+		if *selectOne {
+			entries, err := manager.History.LoadHistory()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			if matches := utils.FuzzyFilter(entries, *query); len(matches) == 1 {
+				entry, picked = matches[0], true
+			}
+		}
+		if !picked {
+			model := ui.NewStandalonePickModel(manager)
+			model.SetQuery(*query)
+			program := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(os.Stderr))
+			if _, err := program.Run(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			if entry, picked = model.Picked(); !picked {
+				return 1
+			}
+		}
+
+		if *printIt {
+			fmt.Println(entry.Password)
+			_ = manager.History.RecordUsage(entry.ID, utils.UsageRevealed)
+			return 0
+		}
+
+		if err := manager.Clipboard.Copy(entry.Password); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		_ = manager.History.RecordUsage(entry.ID, utils.UsageCopied)
+		manager.RecordMetric(utils.MetricCopied, "")
+
+		waitForClipboardClear(manager)
+		return 0
+	}
+}
+
+// waitForClipboardClear reports the copy and, when the clipboard is
+// cleared automatically, stays until it is, as the clear dies with the
+// process
+func waitForClipboardClear(manager *utils.Manager) {
+	at, clears := manager.Clipboard.ClearsAt()
+	if !clears {
+		fmt.Fprintln(os.Stderr, "Password copied to clipboard")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Password copied to clipboard; clearing it in %s (Ctrl+C clears it now)\n",
+		time.Until(at).Round(time.Second))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	manager.Clipboard.WaitForClear(ctx)
+}
+
+func resetConfiguration() {
+	configFile, err := config.GetConfigPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding config file: %v\n", err)
+		os.Exit(1)
+	}
+	
+	if err := os.Remove(configFile); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error removing config file: %v\n", err)
+		os.Exit(1)
+	}
+	
+	fmt.Printf("Configuration reset to defaults.\nConfig file: %s\n", configFile)
+}
+
+func initLogging() {
+	logDir, err := config.GetLogDir()
+	if err != nil {
+		return
+	}
+	
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		// Fallback to stderr if we can't create log directory
+		return
+	}
+	
+	logFile := filepath.Join(logDir, "app.log")
+	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		// Fallback to stderr if we can't open log file
+		return
+	}
+	
+	log.SetOutput(file)
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.Printf("Application started - %s %s", appName, appVersion)
+}
+
+// printConfigError reports a config that failed to load, one problem per line
+func printConfigError(err error) {
+	var schemaErr *config.SchemaError
+	if errors.As(err, &schemaErr) {
+		fmt.Fprintln(os.Stderr, "Error: invalid configuration:")
+		for _, line := range strings.Split(schemaErr.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  "+line)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: Failed to load configuration: %v\n", err)
 }
 
 func getConfigDir() string {
-	homeDir, err := os.UserHomeDir()
+	configDir, err := config.GetConfigDir()
 	if err != nil {
 		return ".passman"
 	}
-	return filepath.Join(homeDir, ".config", appName)
+	return configDir
 }